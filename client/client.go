@@ -0,0 +1,145 @@
+// Package client is a client for gvalkey, and other servers speaking RESP, with connection pooling, pipelining,
+// transactions and pub/sub.
+//
+// every command takes a context, whose deadline and cancellation interrupt it, and commands failing on a broken
+// connection are retried on a new one.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"time"
+)
+
+var (
+	// ErrNil is the error of commands whose reply is null, such as GET on a missing key.
+	ErrNil = errors.New("nil reply")
+	// ErrClosed is the error of commands run once the client is closed.
+	ErrClosed = errors.New("client is closed")
+)
+
+// retryBackoff is the wait before retrying a command, multiplied by the number of the attempt.
+const retryBackoff = 8 * time.Millisecond
+
+// Client runs commands over a pool of connections, it is safe for concurrent use.
+type Client struct {
+	cmdable
+
+	addr        string
+	poolSize    int
+	dialTimeout time.Duration
+	db          int
+	protocol    int
+	maxRetries  int
+
+	pool *pool
+}
+
+// New creates a client of the server at addr, connections being opened when commands need them.
+func New(addr string, opts ...Option) *Client {
+	c := &Client{
+		addr:        addr,
+		poolSize:    10 * runtime.GOMAXPROCS(0),
+		dialTimeout: 5 * time.Second,
+		protocol:    2,
+		maxRetries:  1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.cmdable = c.process
+	c.pool = newPool(c.poolSize, c.dial)
+	return c
+}
+
+// Close closes the idle connections of the client, the ones in use being closed once their command completes.
+func (c *Client) Close() error {
+	return c.pool.close()
+}
+
+// dial opens a connection and prepares it with the protocol and the database of the client.
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: c.dialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := newConn(nc)
+
+	var handshake []Cmder
+	if c.protocol != 2 {
+		handshake = append(handshake, newCmd(toAny, "HELLO", c.protocol))
+	}
+	if c.db != 0 {
+		handshake = append(handshake, newCmd(toString, "SELECT", c.db))
+	}
+	if len(handshake) == 0 {
+		return cn, nil
+	}
+	if err := cn.roundTrip(ctx, prepare(handshake)); err != nil {
+		cn.close()
+		return nil, err
+	}
+	for _, cmd := range handshake {
+		if err := cmd.Err(); err != nil {
+			cn.close()
+			return nil, fmt.Errorf("prepare connection: %w", err)
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) process(ctx context.Context, cmd Cmder) error {
+	cmds := prepare([]Cmder{cmd})
+	if len(cmds) == 0 {
+		return cmd.Err()
+	}
+	if err := c.withConn(ctx, func(cn *conn) error { return cn.roundTrip(ctx, cmds) }); err != nil {
+		cmd.setReply(nil, err)
+	}
+	return cmd.Err()
+}
+
+// withConn runs fn with a connection of the pool, retrying it on a new connection when it fails on a broken one.
+func (c *Client) withConn(ctx context.Context, fn func(cn *conn) error) error {
+	for attempt := 0; ; attempt++ {
+		cn, err := c.pool.get(ctx)
+		if err == nil {
+			err = fn(cn)
+			c.pool.put(cn)
+		}
+		if err == nil || attempt >= c.maxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		backoff := time.NewTimer(time.Duration(attempt+1) * retryBackoff)
+		select {
+		case <-backoff.C:
+		case <-ctx.Done():
+			backoff.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// retryable tells whether err is a failure of the connection rather than of the caller.
+func retryable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, ErrClosed) && !errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// prepare encodes the arguments of cmds, and returns the ones that can be sent, the others failing at once.
+func prepare(cmds []Cmder) []Cmder {
+	valid := cmds[:0:0]
+	for _, cmd := range cmds {
+		if err := cmd.encode(); err != nil {
+			cmd.setReply(nil, err)
+			continue
+		}
+		valid = append(valid, cmd)
+	}
+	return valid
+}
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/gvalkeytest"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer runs a server for the duration of the test, and returns a client of it.
+func newServer(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	c := New(gvalkeytest.Run(t).Addr(), opts...)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// newScriptedServer replies to the commands of a single connection with replies, in order,
+// and sends the commands it received to the returned channel.
+func newScriptedServer(t *testing.T, replies ...string) (string, <-chan resp.Array) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan resp.Array, len(replies))
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		parser := resp.NewParser(conn)
+		for _, reply := range replies {
+			command, err := parser.Parse()
+			if err != nil {
+				return
+			}
+			received <- command.(resp.Array)
+			if _, err := conn.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestStrings(t *testing.T) {
+	ctx := context.Background()
+	c := newServer(t)
+
+	require.NoError(t, c.Set(ctx, "key", "value", 0).Err())
+	value, err := c.Get(ctx, "key").Result()
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	_, err = c.Get(ctx, "missing").Result()
+	require.ErrorIs(t, err, ErrNil)
+
+	set, err := c.SetNX(ctx, "key", "other", time.Minute).Result()
+	require.NoError(t, err)
+	require.False(t, set)
+
+	previous, err := c.SetArgs(ctx, "key", 42, SetArgs{TTL: 1500 * time.Millisecond, Get: true}).Result()
+	require.NoError(t, err)
+	require.Equal(t, "value", previous)
+	require.Equal(t, "42", c.Get(ctx, "key").Val())
+
+	require.Equal(t, int64(1), c.Del(ctx, "key", "missing").Val())
+
+	err = c.HSet(ctx, "key", "field").Err()
+	var replyErr resp.SimpleError
+	require.ErrorAs(t, err, &replyErr)
+
+	err = c.Do(ctx, "SET", "key", struct{}{}).Err()
+	require.EqualError(t, err, "cannot use struct {} as an argument of a command")
+}
+
+func TestDataTypes(t *testing.T) {
+	ctx := context.Background()
+	c := newServer(t)
+
+	require.NoError(t, c.HSet(ctx, "hash", map[string]string{"a": "1", "b": "2"}).Err())
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, c.HGetAll(ctx, "hash").Val())
+	require.Equal(t, []int64{1, -2}, c.HExpire(ctx, "hash", time.Minute, "a", "missing").Val())
+
+	require.NoError(t, c.RPush(ctx, "list", "a", "b", "c").Err())
+	require.Equal(t, []string{"a", "b", "c"}, c.LRange(ctx, "list", 0, -1).Val())
+	require.Equal(t, []string{"list", "a"}, c.BLPop(ctx, time.Second, "list").Val())
+
+	id, err := c.XAdd(ctx, &XAddArgs{Stream: "stream", ID: "1-1", Values: []string{"field", "value"}}).Result()
+	require.NoError(t, err)
+	require.Equal(t, "1-1", id)
+	require.NoError(t, c.XGroupCreate(ctx, "stream", "group", "0").Err())
+	streams, err := c.XReadGroup(ctx, &XReadGroupArgs{Group: "group", Consumer: "consumer", Streams: []string{"stream", ">"}, Block: -1}).Result()
+	require.NoError(t, err)
+	require.Equal(t, []XStream{{Stream: "stream", Messages: []XMessage{{ID: "1-1", Values: map[string]string{"field": "value"}}}}}, streams)
+	require.Equal(t, XPending{Count: 1, Lower: "1-1", Higher: "1-1", Consumers: map[string]int64{"consumer": 1}}, c.XPending(ctx, "stream", "group").Val())
+
+	require.NoError(t, c.GeoAdd(ctx, "geo", &GeoLocation{Name: "Palermo", Longitude: 13.361389, Latitude: 38.115556}).Err())
+	positions, err := c.GeoPos(ctx, "geo", "Palermo", "missing").Result()
+	require.NoError(t, err)
+	require.InDelta(t, 13.361389, positions[0].Longitude, 1e-5)
+	require.Nil(t, positions[1])
+	locations, err := c.GeoSearchLocation(ctx, "geo", &GeoSearchLocationQuery{
+		GeoSearchQuery: GeoSearchQuery{Longitude: 15, Latitude: 37, Radius: 200, Unit: "km"},
+		WithDist:       true,
+	}).Result()
+	require.NoError(t, err)
+	require.Len(t, locations, 1)
+	require.InDelta(t, 190.4424, locations[0].Dist, 1e-3)
+
+	result, err := c.Eval(ctx, "return {KEYS[1], tonumber(ARGV[1]) + 1}", []string{"key"}, 41).Result()
+	require.NoError(t, err)
+	require.Equal(t, []any{"key", int64(42)}, result)
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	c := newServer(t)
+
+	var get *Cmd[string]
+	cmds, err := c.Pipelined(ctx, func(p *Pipeline) error {
+		p.Set(ctx, "key", "value", 0)
+		get = p.Get(ctx, "key")
+		p.Get(ctx, "missing")
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, cmds, 3)
+	require.Equal(t, "value", get.Val())
+	require.ErrorIs(t, cmds[2].Err(), ErrNil)
+
+	p := c.Pipeline()
+	p.Set(ctx, "key", "value", 0)
+	p.HGet(ctx, "key", "field")
+	_, err = p.Exec(ctx)
+	require.ErrorContains(t, err, "WRONGTYPE")
+	require.Zero(t, p.Len())
+}
+
+func TestTxPipeline(t *testing.T) {
+	ctx := context.Background()
+
+	addr, received := newScriptedServer(t, "+OK\r\n", "+QUEUED\r\n", "+QUEUED\r\n", "*2\r\n+OK\r\n$5\r\nvalue\r\n")
+	c := New(addr)
+	defer c.Close()
+	var get *Cmd[string]
+	_, err := c.TxPipelined(ctx, func(p *Pipeline) error {
+		p.Set(ctx, "key", "value", 0)
+		get = p.Get(ctx, "key")
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", get.Val())
+	for _, name := range []string{"MULTI", "SET", "GET", "EXEC"} {
+		require.Equal(t, resp.BulkString(name), (<-received)[0])
+	}
+
+	// the commands do not run on their own on a server without transactions
+	c = newServer(t)
+	_, err = c.TxPipelined(ctx, func(p *Pipeline) error {
+		p.Set(ctx, "key", "value", 0)
+		return nil
+	})
+	require.ErrorIs(t, err, errTxAborted)
+	require.ErrorIs(t, c.Get(ctx, "key").Err(), ErrNil)
+}
+
+func TestRESP3(t *testing.T) {
+	ctx := context.Background()
+	addr, received := newScriptedServer(t,
+		"%1\r\n+proto\r\n:3\r\n",
+		"+OK\r\n",
+		"%1\r\n$1\r\na\r\n$1\r\n1\r\n",
+		">3\r\n$10\r\ninvalidate\r\n*1\r\n$1\r\na\r\n$-1\r\n,1.5\r\n",
+	)
+	c := New(addr, WithProtocol(3), WithDB(2))
+	defer c.Close()
+
+	require.Equal(t, map[string]string{"a": "1"}, c.HGetAll(ctx, "hash").Val())
+	require.Equal(t, resp.Array{resp.BulkString("HELLO"), resp.BulkString("3")}, <-received)
+	require.Equal(t, resp.Array{resp.BulkString("SELECT"), resp.BulkString("2")}, <-received)
+	<-received
+
+	// out of band messages are skipped
+	distance, err := c.GeoDist(ctx, "geo", "a", "b", "").Result()
+	require.NoError(t, err)
+	require.Equal(t, 1.5, distance)
+}
+
+func TestContext(t *testing.T) {
+	c := newServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.BLPop(ctx, 0, "list").Err()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, c.Get(ctx, "key").Err(), context.Canceled)
+
+	// the interrupted connection was dropped
+	require.NoError(t, c.Set(context.Background(), "key", "value", 0).Err())
+}
+
+func TestReconnect(t *testing.T) {
+	ctx := context.Background()
+	c := newServer(t, WithPoolSize(1))
+	require.NoError(t, c.Set(ctx, "key", "value", 0).Err())
+
+	// the idle connection is closed, as by the timeout of the server
+	cn := <-c.pool.idle
+	cn.close()
+	c.pool.idle <- cn
+
+	require.Equal(t, "value", c.Get(ctx, "key").Val())
+
+	require.NoError(t, c.Close())
+	require.ErrorIs(t, c.Get(ctx, "key").Err(), ErrClosed)
+}
+
+func TestPubSub(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := newServer(t)
+
+	ps, err := c.Subscribe(ctx, "news")
+	require.NoError(t, err)
+	defer ps.Close()
+	subscription, err := ps.Receive(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &Subscription{Kind: "subscribe", Channel: "news", Count: 1}, subscription)
+
+	require.NoError(t, ps.PSubscribe(ctx, "sport.*"))
+	_, err = ps.Receive(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(1), c.Publish(ctx, "sport.tennis", "match").Val())
+	require.Equal(t, &Message{Channel: "sport.tennis", Pattern: "sport.*", Payload: "match"}, <-ps.Channel())
+
+	// the subscriptions survive a broken connection, messages being published until the new one subscribed
+	ps.mu.Lock()
+	ps.cn.close()
+	ps.mu.Unlock()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for received := false; !received; {
+		require.NoError(t, c.Publish(ctx, "news", "hello").Err())
+		select {
+		case msg := <-ps.Channel():
+			require.Equal(t, &Message{Channel: "news", Payload: "hello"}, msg)
+			received = true
+		case <-ticker.C:
+		case <-ctx.Done():
+			require.FailNow(t, "message not received after reconnecting")
+		}
+	}
+
+	require.NoError(t, ps.Close())
+	for range ps.Channel() {
+	}
+}
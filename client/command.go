@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// Cmder is a command along with its reply once it ran.
+type Cmder interface {
+	// Args are the name and the arguments of the command.
+	Args() []any
+	// Err is the error the command failed with, an error reply of the server being a resp.SimpleError.
+	Err() error
+
+	encode() error
+	request() resp.Array
+	setReply(reply any, err error)
+}
+
+// Cmd is a command whose reply converts to a T.
+type Cmd[T any] struct {
+	args    []any
+	encoded resp.Array
+	convert func(reply any) (T, error)
+	val     T
+	err     error
+}
+
+func newCmd[T any](convert func(reply any) (T, error), args ...any) *Cmd[T] {
+	return &Cmd[T]{args: args, convert: convert}
+}
+
+func (c *Cmd[T]) Args() []any {
+	return c.args
+}
+
+func (c *Cmd[T]) Err() error {
+	return c.err
+}
+
+// Val is the reply of the command, the zero value if it failed.
+func (c *Cmd[T]) Val() T {
+	return c.val
+}
+
+func (c *Cmd[T]) Result() (T, error) {
+	return c.val, c.err
+}
+
+func (c *Cmd[T]) encode() error {
+	encoded := make(resp.Array, len(c.args))
+	for i, arg := range c.args {
+		bulk, err := encodeArg(arg)
+		if err != nil {
+			return err
+		}
+		encoded[i] = bulk
+	}
+	c.encoded = encoded
+	return nil
+}
+
+func (c *Cmd[T]) request() resp.Array {
+	return c.encoded
+}
+
+func (c *Cmd[T]) setReply(reply any, err error) {
+	if err == nil {
+		if replyErr, ok := reply.(resp.SimpleError); ok {
+			err = replyErr
+		}
+	}
+	if err == nil {
+		c.val, err = c.convert(reply)
+	}
+	c.err = err
+}
+
+// encodeArg converts an argument of a command to the bulk string sent for it.
+func encodeArg(arg any) (resp.BulkString, error) {
+	switch v := arg.(type) {
+	case string:
+		return resp.BulkString(v), nil
+	case []byte:
+		return resp.BulkString(v), nil
+	case resp.BulkString:
+		return v, nil
+	case int:
+		return resp.BulkString(strconv.Itoa(v)), nil
+	case int64:
+		return resp.BulkString(strconv.FormatInt(v, 10)), nil
+	case uint64:
+		return resp.BulkString(strconv.FormatUint(v, 10)), nil
+	case float64:
+		return resp.BulkString(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case fmt.Stringer:
+		return resp.BulkString(v.String()), nil
+	default:
+		return "", fmt.Errorf("cannot use %T as an argument of a command", arg)
+	}
+}
+
+// cmdable runs a command, at once for Client and when executed for Pipeline, and holds the helpers of every command.
+type cmdable func(ctx context.Context, cmd Cmder) error
+
+// run creates a command converting its reply with convert, and runs it.
+func run[T any](ctx context.Context, c cmdable, convert func(reply any) (T, error), args ...any) *Cmd[T] {
+	cmd := newCmd(convert, args...)
+	_ = c(ctx, cmd)
+	return cmd
+}
+
+// Do runs any command, whose reply is converted to Go values as toAny does.
+func (c cmdable) Do(ctx context.Context, args ...any) *Cmd[any] {
+	return run(ctx, c, toAny, args...)
+}
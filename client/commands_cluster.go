@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"net"
+)
+
+// ClusterNode is a node serving a range of slots.
+type ClusterNode struct {
+	ID   string
+	Addr string
+}
+
+// ClusterSlot is a range of slots, served by its master and then its replicas.
+type ClusterSlot struct {
+	Start, End int64
+	Nodes      []ClusterNode
+}
+
+func (c cmdable) ClusterInfo(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "CLUSTER", "INFO")
+}
+
+func (c cmdable) ClusterMyID(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "CLUSTER", "MYID")
+}
+
+// ClusterNodes returns the nodes of the cluster, a line each in the format of nodes.conf.
+func (c cmdable) ClusterNodes(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "CLUSTER", "NODES")
+}
+
+func (c cmdable) ClusterSlots(ctx context.Context) *Cmd[[]ClusterSlot] {
+	return run(ctx, c, sliceOf(toClusterSlot), "CLUSTER", "SLOTS")
+}
+
+// ClusterShards is the reply of CLUSTER SHARDS, a map describing every shard.
+func (c cmdable) ClusterShards(ctx context.Context) *Cmd[[]any] {
+	return run(ctx, c, toSlice, "CLUSTER", "SHARDS")
+}
+
+func (c cmdable) ClusterKeySlot(ctx context.Context, key string) *Cmd[int64] {
+	return run(ctx, c, toInt, "CLUSTER", "KEYSLOT", key)
+}
+
+func (c cmdable) ClusterCountKeysInSlot(ctx context.Context, slot int) *Cmd[int64] {
+	return run(ctx, c, toInt, "CLUSTER", "COUNTKEYSINSLOT", slot)
+}
+
+func (c cmdable) ClusterGetKeysInSlot(ctx context.Context, slot, count int) *Cmd[[]string] {
+	return run(ctx, c, toStrings, "CLUSTER", "GETKEYSINSLOT", slot, count)
+}
+
+func slotArgs(args []any, slots []int) []any {
+	for _, slot := range slots {
+		args = append(args, slot)
+	}
+	return args
+}
+
+func (c cmdable) ClusterAddSlots(ctx context.Context, slots ...int) *Cmd[string] {
+	return run(ctx, c, toString, slotArgs([]any{"CLUSTER", "ADDSLOTS"}, slots)...)
+}
+
+func (c cmdable) ClusterAddSlotsRange(ctx context.Context, first, last int) *Cmd[string] {
+	return run(ctx, c, toString, "CLUSTER", "ADDSLOTSRANGE", first, last)
+}
+
+func (c cmdable) ClusterDelSlots(ctx context.Context, slots ...int) *Cmd[string] {
+	return run(ctx, c, toString, slotArgs([]any{"CLUSTER", "DELSLOTS"}, slots)...)
+}
+
+func (c cmdable) ClusterDelSlotsRange(ctx context.Context, first, last int) *Cmd[string] {
+	return run(ctx, c, toString, "CLUSTER", "DELSLOTSRANGE", first, last)
+}
+
+// ClusterSetSlot changes the state of a slot, IMPORTING, MIGRATING or NODE with the ID of a node, or STABLE.
+func (c cmdable) ClusterSetSlot(ctx context.Context, slot int, state string, nodeID ...string) *Cmd[string] {
+	return run(ctx, c, toString, appendStrings([]any{"CLUSTER", "SETSLOT", slot, state}, nodeID)...)
+}
+
+func (c cmdable) ClusterMeet(ctx context.Context, host string, port int) *Cmd[string] {
+	return run(ctx, c, toString, "CLUSTER", "MEET", host, port)
+}
+
+func (c cmdable) ClusterForget(ctx context.Context, nodeID string) *Cmd[string] {
+	return run(ctx, c, toString, "CLUSTER", "FORGET", nodeID)
+}
+
+// Asking lets the next command access a slot being imported, it is only useful in a pipeline.
+func (c cmdable) Asking(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "ASKING")
+}
+
+func toClusterSlot(reply any) (ClusterSlot, error) {
+	elems, err := elements(reply)
+	if err != nil {
+		return ClusterSlot{}, err
+	}
+	if len(elems) < 2 {
+		return ClusterSlot{}, errUnexpected(reply)
+	}
+	var slot ClusterSlot
+	if slot.Start, err = toInt(elems[0]); err != nil {
+		return ClusterSlot{}, err
+	}
+	if slot.End, err = toInt(elems[1]); err != nil {
+		return ClusterSlot{}, err
+	}
+	for _, node := range elems[2:] {
+		fields, err := elements(node)
+		if err != nil {
+			return ClusterSlot{}, err
+		}
+		if len(fields) < 3 {
+			return ClusterSlot{}, errUnexpected(node)
+		}
+		host, err := toString(fields[0])
+		if err != nil {
+			return ClusterSlot{}, err
+		}
+		port, err := toString(fields[1])
+		if err != nil {
+			return ClusterSlot{}, err
+		}
+		id, err := toString(fields[2])
+		if err != nil {
+			return ClusterSlot{}, err
+		}
+		slot.Nodes = append(slot.Nodes, ClusterNode{ID: id, Addr: net.JoinHostPort(host, port)})
+	}
+	return slot, nil
+}
@@ -0,0 +1,172 @@
+package client
+
+import "context"
+
+// GeoLocation is a member of a geospatial index, Dist and GeoHash being set by GeoSearchLocation when asked for.
+type GeoLocation struct {
+	Name                string
+	Longitude, Latitude float64
+	Dist                float64
+	GeoHash             int64
+}
+
+// GeoPos is the position of a member.
+type GeoPos struct {
+	Longitude, Latitude float64
+}
+
+// GeoAdd adds members at their Longitude and Latitude, and returns the number of new members.
+func (c cmdable) GeoAdd(ctx context.Context, key string, locations ...*GeoLocation) *Cmd[int64] {
+	args := []any{"GEOADD", key}
+	for _, loc := range locations {
+		args = append(args, loc.Longitude, loc.Latitude, loc.Name)
+	}
+	return run(ctx, c, toInt, args...)
+}
+
+// GeoPos returns the positions of members, nil for the missing ones.
+func (c cmdable) GeoPos(ctx context.Context, key string, members ...string) *Cmd[[]*GeoPos] {
+	return run(ctx, c, sliceOf(toGeoPos), appendStrings([]any{"GEOPOS", key}, members)...)
+}
+
+// GeoDist is the distance between two members in unit, M, KM, FT or MI, failing with ErrNil if one is missing.
+func (c cmdable) GeoDist(ctx context.Context, key, member1, member2, unit string) *Cmd[float64] {
+	args := []any{"GEODIST", key, member1, member2}
+	if unit != "" {
+		args = append(args, unit)
+	}
+	return run(ctx, c, toFloat, args...)
+}
+
+func (c cmdable) GeoHash(ctx context.Context, key string, members ...string) *Cmd[[]string] {
+	return run(ctx, c, toStrings, appendStrings([]any{"GEOHASH", key}, members)...)
+}
+
+// GeoSearchQuery selects members around Member, or Longitude and Latitude when Member is empty,
+// within Radius, or BoxWidth and BoxHeight when Radius is 0, in Unit.
+type GeoSearchQuery struct {
+	Member              string
+	Longitude, Latitude float64
+	Radius              float64
+	BoxWidth, BoxHeight float64
+	Unit                string
+	// ASC or DESC, unsorted if empty
+	Sort string
+	// the number of members returned at most if not 0, the first ones found when CountAny is set
+	Count    int
+	CountAny bool
+}
+
+func (q *GeoSearchQuery) args(args []any) []any {
+	if q.Member != "" {
+		args = append(args, "FROMMEMBER", q.Member)
+	} else {
+		args = append(args, "FROMLONLAT", q.Longitude, q.Latitude)
+	}
+	if q.Radius > 0 {
+		args = append(args, "BYRADIUS", q.Radius, q.Unit)
+	} else {
+		args = append(args, "BYBOX", q.BoxWidth, q.BoxHeight, q.Unit)
+	}
+	if q.Sort != "" {
+		args = append(args, q.Sort)
+	}
+	if q.Count > 0 {
+		args = append(args, "COUNT", q.Count)
+		if q.CountAny {
+			args = append(args, "ANY")
+		}
+	}
+	return args
+}
+
+func (c cmdable) GeoSearch(ctx context.Context, key string, q *GeoSearchQuery) *Cmd[[]string] {
+	return run(ctx, c, toStrings, q.args([]any{"GEOSEARCH", key})...)
+}
+
+// GeoSearchLocationQuery is a GeoSearchQuery returning the positions, distances or geohashes of members.
+type GeoSearchLocationQuery struct {
+	GeoSearchQuery
+	WithCoord bool
+	WithDist  bool
+	WithHash  bool
+}
+
+func (c cmdable) GeoSearchLocation(ctx context.Context, key string, q *GeoSearchLocationQuery) *Cmd[[]GeoLocation] {
+	args := q.args([]any{"GEOSEARCH", key})
+	if q.WithCoord {
+		args = append(args, "WITHCOORD")
+	}
+	if q.WithDist {
+		args = append(args, "WITHDIST")
+	}
+	if q.WithHash {
+		args = append(args, "WITHHASH")
+	}
+	return run(ctx, c, sliceOf(q.toGeoLocation), args...)
+}
+
+// toGeoLocation converts a member found by GEOSEARCH, followed by its distance, hash and position as asked.
+func (q *GeoSearchLocationQuery) toGeoLocation(reply any) (GeoLocation, error) {
+	if name, err := toString(reply); err == nil {
+		return GeoLocation{Name: name}, nil
+	}
+	elems, err := elements(reply)
+	if err != nil {
+		return GeoLocation{}, err
+	}
+	if len(elems) == 0 {
+		return GeoLocation{}, errUnexpected(reply)
+	}
+	var loc GeoLocation
+	if loc.Name, err = toString(elems[0]); err != nil {
+		return GeoLocation{}, err
+	}
+	elems = elems[1:]
+	if q.WithDist && len(elems) > 0 {
+		if loc.Dist, err = toFloat(elems[0]); err != nil {
+			return GeoLocation{}, err
+		}
+		elems = elems[1:]
+	}
+	if q.WithHash && len(elems) > 0 {
+		if loc.GeoHash, err = toInt(elems[0]); err != nil {
+			return GeoLocation{}, err
+		}
+		elems = elems[1:]
+	}
+	if q.WithCoord && len(elems) > 0 {
+		pos, err := toGeoPos(elems[0])
+		if err != nil {
+			return GeoLocation{}, err
+		}
+		loc.Longitude, loc.Latitude = pos.Longitude, pos.Latitude
+	}
+	return loc, nil
+}
+
+// GeoSearchStoreQuery is a GeoSearchQuery storing the members found, scored by their distance with StoreDist.
+type GeoSearchStoreQuery struct {
+	GeoSearchQuery
+	StoreDist bool
+}
+
+// GeoSearchStore stores the members found in source to destination, and returns their number.
+func (c cmdable) GeoSearchStore(ctx context.Context, source, destination string, q *GeoSearchStoreQuery) *Cmd[int64] {
+	args := q.args([]any{"GEOSEARCHSTORE", destination, source})
+	if q.StoreDist {
+		args = append(args, "STOREDIST")
+	}
+	return run(ctx, c, toInt, args...)
+}
+
+func toGeoPos(reply any) (*GeoPos, error) {
+	coords, err := sliceOf(toFloat)(reply)
+	if err != nil {
+		return nil, err
+	}
+	if len(coords) != 2 {
+		return nil, errUnexpected(reply)
+	}
+	return &GeoPos{Longitude: coords[0], Latitude: coords[1]}, nil
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// HSet sets fields of a hash, given as alternating fields and values, or as a map[string]string or map[string]any.
+func (c cmdable) HSet(ctx context.Context, key string, values ...any) *Cmd[int64] {
+	args := []any{"HSET", key}
+	for _, v := range values {
+		switch m := v.(type) {
+		case map[string]string:
+			for field, value := range m {
+				args = append(args, field, value)
+			}
+		case map[string]any:
+			for field, value := range m {
+				args = append(args, field, value)
+			}
+		default:
+			args = append(args, v)
+		}
+	}
+	return run(ctx, c, toInt, args...)
+}
+
+func (c cmdable) HGet(ctx context.Context, key, field string) *Cmd[string] {
+	return run(ctx, c, toString, "HGET", key, field)
+}
+
+func (c cmdable) HDel(ctx context.Context, key string, fields ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"HDEL", key}, fields)...)
+}
+
+func (c cmdable) HLen(ctx context.Context, key string) *Cmd[int64] {
+	return run(ctx, c, toInt, "HLEN", key)
+}
+
+func (c cmdable) HExists(ctx context.Context, key, field string) *Cmd[bool] {
+	return run(ctx, c, toBool, "HEXISTS", key, field)
+}
+
+func (c cmdable) HGetAll(ctx context.Context, key string) *Cmd[map[string]string] {
+	return run(ctx, c, toStringMap, "HGETALL", key)
+}
+
+func (c cmdable) HKeys(ctx context.Context, key string) *Cmd[[]string] {
+	return run(ctx, c, toStrings, "HKEYS", key)
+}
+
+func (c cmdable) HVals(ctx context.Context, key string) *Cmd[[]string] {
+	return run(ctx, c, toStrings, "HVALS", key)
+}
+
+// HExpireArgs is the condition of the expirations of fields, NX, XX, GT or LT, none if empty.
+type HExpireArgs struct {
+	Condition string
+}
+
+func hashExpire(command, key string, at int64, a HExpireArgs, fields []string) []any {
+	args := []any{command, key, at}
+	if a.Condition != "" {
+		args = append(args, a.Condition)
+	}
+	return appendStrings(append(args, "FIELDS", len(fields)), fields)
+}
+
+// HExpire sets the time to live of fields of a hash, the reply of each field being 1 if it was set,
+// 0 if the condition prevented it, 2 if the field was deleted at once, and -2 if it does not exist.
+func (c cmdable) HExpire(ctx context.Context, key string, expiration time.Duration, fields ...string) *Cmd[[]int64] {
+	return c.HExpireWithArgs(ctx, key, expiration, HExpireArgs{}, fields...)
+}
+
+func (c cmdable) HExpireWithArgs(ctx context.Context, key string, expiration time.Duration, a HExpireArgs, fields ...string) *Cmd[[]int64] {
+	if expiration%time.Second == 0 {
+		return run(ctx, c, toInts, hashExpire("HEXPIRE", key, int64(expiration/time.Second), a, fields)...)
+	}
+	return run(ctx, c, toInts, hashExpire("HPEXPIRE", key, expiration.Milliseconds(), a, fields)...)
+}
+
+// HExpireAt sets the expiration time of fields of a hash, replying as HExpire.
+func (c cmdable) HExpireAt(ctx context.Context, key string, tm time.Time, fields ...string) *Cmd[[]int64] {
+	return c.HExpireAtWithArgs(ctx, key, tm, HExpireArgs{}, fields...)
+}
+
+func (c cmdable) HExpireAtWithArgs(ctx context.Context, key string, tm time.Time, a HExpireArgs, fields ...string) *Cmd[[]int64] {
+	return run(ctx, c, toInts, hashExpire("HPEXPIREAT", key, tm.UnixMilli(), a, fields)...)
+}
+
+// HTTL is the remaining time to live of fields in seconds, -1 for fields without expiration and -2 for missing ones.
+func (c cmdable) HTTL(ctx context.Context, key string, fields ...string) *Cmd[[]int64] {
+	return run(ctx, c, toInts, appendStrings([]any{"HTTL", key, "FIELDS", len(fields)}, fields)...)
+}
+
+// HPTTL is HTTL in milliseconds.
+func (c cmdable) HPTTL(ctx context.Context, key string, fields ...string) *Cmd[[]int64] {
+	return run(ctx, c, toInts, appendStrings([]any{"HPTTL", key, "FIELDS", len(fields)}, fields)...)
+}
+
+// HPersist removes the expiration of fields, the reply of each field being 1 if it had one, -1 if not, -2 if it does not exist.
+func (c cmdable) HPersist(ctx context.Context, key string, fields ...string) *Cmd[[]int64] {
+	return run(ctx, c, toInts, appendStrings([]any{"HPERSIST", key, "FIELDS", len(fields)}, fields)...)
+}
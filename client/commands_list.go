@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+func (c cmdable) LPush(ctx context.Context, key string, values ...any) *Cmd[int64] {
+	return run(ctx, c, toInt, append([]any{"LPUSH", key}, values...)...)
+}
+
+func (c cmdable) RPush(ctx context.Context, key string, values ...any) *Cmd[int64] {
+	return run(ctx, c, toInt, append([]any{"RPUSH", key}, values...)...)
+}
+
+func (c cmdable) LPop(ctx context.Context, key string) *Cmd[string] {
+	return run(ctx, c, toString, "LPOP", key)
+}
+
+func (c cmdable) RPop(ctx context.Context, key string) *Cmd[string] {
+	return run(ctx, c, toString, "RPOP", key)
+}
+
+// LPopCount pops up to count elements from the head of a list.
+func (c cmdable) LPopCount(ctx context.Context, key string, count int) *Cmd[[]string] {
+	return run(ctx, c, toStrings, "LPOP", key, count)
+}
+
+// RPopCount pops up to count elements from the tail of a list.
+func (c cmdable) RPopCount(ctx context.Context, key string, count int) *Cmd[[]string] {
+	return run(ctx, c, toStrings, "RPOP", key, count)
+}
+
+func (c cmdable) LLen(ctx context.Context, key string) *Cmd[int64] {
+	return run(ctx, c, toInt, "LLEN", key)
+}
+
+func (c cmdable) LRange(ctx context.Context, key string, start, stop int64) *Cmd[[]string] {
+	return run(ctx, c, toStrings, "LRANGE", key, start, stop)
+}
+
+// LMove moves an element from the srcPos end of source to the destPos end of destination, LEFT or RIGHT.
+func (c cmdable) LMove(ctx context.Context, source, destination, srcPos, destPos string) *Cmd[string] {
+	return run(ctx, c, toString, "LMOVE", source, destination, srcPos, destPos)
+}
+
+func (c cmdable) RPopLPush(ctx context.Context, source, destination string) *Cmd[string] {
+	return run(ctx, c, toString, "RPOPLPUSH", source, destination)
+}
+
+// BLPop pops from the head of the first non-empty list of keys, waiting for at most timeout, 0 waiting forever.
+// the reply is the key and the element, ErrNil when the timeout elapsed.
+func (c cmdable) BLPop(ctx context.Context, timeout time.Duration, keys ...string) *Cmd[[]string] {
+	return run(ctx, c, toStrings, append(appendStrings([]any{"BLPOP"}, keys), formatSeconds(timeout))...)
+}
+
+// BRPop is BLPop popping from the tail of lists.
+func (c cmdable) BRPop(ctx context.Context, timeout time.Duration, keys ...string) *Cmd[[]string] {
+	return run(ctx, c, toStrings, append(appendStrings([]any{"BRPOP"}, keys), formatSeconds(timeout))...)
+}
+
+// BLMove is LMove waiting for at most timeout for source to have an element.
+func (c cmdable) BLMove(ctx context.Context, source, destination, srcPos, destPos string, timeout time.Duration) *Cmd[string] {
+	return run(ctx, c, toString, "BLMOVE", source, destination, srcPos, destPos, formatSeconds(timeout))
+}
+
+func (c cmdable) BRPopLPush(ctx context.Context, source, destination string, timeout time.Duration) *Cmd[string] {
+	return run(ctx, c, toString, "BRPOPLPUSH", source, destination, formatSeconds(timeout))
+}
@@ -0,0 +1,38 @@
+package client
+
+import "context"
+
+func evalArgs(command, script string, keys []string, args []any) []any {
+	return append(appendStrings([]any{command, script, len(keys)}, keys), args...)
+}
+
+// Eval runs a Lua script, whose reply is converted as Do converts it.
+func (c cmdable) Eval(ctx context.Context, script string, keys []string, args ...any) *Cmd[any] {
+	return run(ctx, c, toAny, evalArgs("EVAL", script, keys, args)...)
+}
+
+func (c cmdable) EvalRO(ctx context.Context, script string, keys []string, args ...any) *Cmd[any] {
+	return run(ctx, c, toAny, evalArgs("EVAL_RO", script, keys, args)...)
+}
+
+// EvalSha runs a script cached by ScriptLoad, failing with a NOSCRIPT error if it is not.
+func (c cmdable) EvalSha(ctx context.Context, sha1 string, keys []string, args ...any) *Cmd[any] {
+	return run(ctx, c, toAny, evalArgs("EVALSHA", sha1, keys, args)...)
+}
+
+func (c cmdable) EvalShaRO(ctx context.Context, sha1 string, keys []string, args ...any) *Cmd[any] {
+	return run(ctx, c, toAny, evalArgs("EVALSHA_RO", sha1, keys, args)...)
+}
+
+// ScriptLoad caches a script, and returns its SHA1 digest.
+func (c cmdable) ScriptLoad(ctx context.Context, script string) *Cmd[string] {
+	return run(ctx, c, toString, "SCRIPT", "LOAD", script)
+}
+
+func (c cmdable) ScriptExists(ctx context.Context, hashes ...string) *Cmd[[]bool] {
+	return run(ctx, c, toBools, appendStrings([]any{"SCRIPT", "EXISTS"}, hashes)...)
+}
+
+func (c cmdable) ScriptFlush(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "SCRIPT", "FLUSH")
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// there is no helper for SELECT, which would change the database of a single connection of the pool: WithDB
+// selects the database of the client, and Pipeline.Do can send SELECT to run a pipeline on another database.
+
+// Command is the reply of COMMAND.
+func (c cmdable) Command(ctx context.Context) *Cmd[any] {
+	return run(ctx, c, toAny, "COMMAND")
+}
+
+// Info returns the sections of INFO, the default ones if none is given.
+func (c cmdable) Info(ctx context.Context, sections ...string) *Cmd[string] {
+	return run(ctx, c, toString, appendStrings([]any{"INFO"}, sections)...)
+}
+
+// ConfigGet returns the settings matching glob patterns.
+func (c cmdable) ConfigGet(ctx context.Context, patterns ...string) *Cmd[map[string]string] {
+	return run(ctx, c, toStringMap, appendStrings([]any{"CONFIG", "GET"}, patterns)...)
+}
+
+func (c cmdable) ConfigSet(ctx context.Context, name, value string) *Cmd[string] {
+	return run(ctx, c, toString, "CONFIG", "SET", name, value)
+}
+
+func (c cmdable) ConfigRewrite(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "CONFIG", "REWRITE")
+}
+
+func (c cmdable) ConfigResetStat(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "CONFIG", "RESETSTAT")
+}
+
+// SlowLog is an entry of the slow log.
+type SlowLog struct {
+	ID       int64
+	Time     time.Time
+	Duration time.Duration
+	Args     []string
+	// the address and the name of the client that ran the command
+	ClientAddr string
+	ClientName string
+}
+
+// SlowLogGet returns the count most recent entries of the slow log.
+func (c cmdable) SlowLogGet(ctx context.Context, count int64) *Cmd[[]SlowLog] {
+	return run(ctx, c, sliceOf(toSlowLog), "SLOWLOG", "GET", count)
+}
+
+func (c cmdable) SlowLogLen(ctx context.Context) *Cmd[int64] {
+	return run(ctx, c, toInt, "SLOWLOG", "LEN")
+}
+
+func (c cmdable) SlowLogReset(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "SLOWLOG", "RESET")
+}
+
+func (c cmdable) SwapDB(ctx context.Context, index1, index2 int) *Cmd[string] {
+	return run(ctx, c, toString, "SWAPDB", index1, index2)
+}
+
+func (c cmdable) FlushDB(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "FLUSHDB")
+}
+
+func (c cmdable) FlushDBAsync(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "FLUSHDB", "ASYNC")
+}
+
+func (c cmdable) FlushAll(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "FLUSHALL")
+}
+
+func (c cmdable) FlushAllAsync(ctx context.Context) *Cmd[string] {
+	return run(ctx, c, toString, "FLUSHALL", "ASYNC")
+}
+
+func (c cmdable) DBSize(ctx context.Context) *Cmd[int64] {
+	return run(ctx, c, toInt, "DBSIZE")
+}
+
+// ReplicaOf makes the server replicate the master at host and port, or become a master with NO and ONE.
+func (c cmdable) ReplicaOf(ctx context.Context, host, port string) *Cmd[string] {
+	return run(ctx, c, toString, "REPLICAOF", host, port)
+}
+
+// SlaveOf is the former name of ReplicaOf.
+func (c cmdable) SlaveOf(ctx context.Context, host, port string) *Cmd[string] {
+	return run(ctx, c, toString, "SLAVEOF", host, port)
+}
+
+// Role is the reply of ROLE, which starts with master or slave.
+func (c cmdable) Role(ctx context.Context) *Cmd[[]any] {
+	return run(ctx, c, toSlice, "ROLE")
+}
+
+func (c cmdable) Publish(ctx context.Context, channel string, message any) *Cmd[int64] {
+	return run(ctx, c, toInt, "PUBLISH", channel, message)
+}
+
+func toSlowLog(reply any) (SlowLog, error) {
+	elems, err := elements(reply)
+	if err != nil {
+		return SlowLog{}, err
+	}
+	if len(elems) < 4 {
+		return SlowLog{}, errUnexpected(reply)
+	}
+	var entry SlowLog
+	if entry.ID, err = toInt(elems[0]); err != nil {
+		return SlowLog{}, err
+	}
+	unix, err := toInt(elems[1])
+	if err != nil {
+		return SlowLog{}, err
+	}
+	entry.Time = time.Unix(unix, 0)
+	micros, err := toInt(elems[2])
+	if err != nil {
+		return SlowLog{}, err
+	}
+	entry.Duration = time.Duration(micros) * time.Microsecond
+	if entry.Args, err = toStrings(elems[3]); err != nil {
+		return SlowLog{}, err
+	}
+	if len(elems) >= 6 {
+		if entry.ClientAddr, err = toString(elems[4]); err != nil {
+			return SlowLog{}, err
+		}
+		if entry.ClientName, err = toString(elems[5]); err != nil {
+			return SlowLog{}, err
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,472 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// XMessage is an entry of a stream, whose values are nil when only its ID was asked for.
+type XMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+// XStream is the entries read from a stream.
+type XStream struct {
+	Stream   string
+	Messages []XMessage
+}
+
+// XAddArgs are the options of XADD, ID being * when empty and Values given as HSet takes them.
+type XAddArgs struct {
+	Stream     string
+	NoMkStream bool
+	// trim the stream by length or by ID, approximately when Approx is set and within Limit entries if not 0
+	MaxLen int64
+	MinID  string
+	Approx bool
+	Limit  int64
+	ID     string
+	Values any
+}
+
+func appendTrim(args []any, maxLen int64, minID string, approx bool, limit int64) []any {
+	switch {
+	case maxLen > 0:
+		args = append(args, "MAXLEN")
+	case minID != "":
+		args = append(args, "MINID")
+	default:
+		return args
+	}
+	if approx {
+		args = append(args, "~")
+	}
+	if maxLen > 0 {
+		args = append(args, maxLen)
+	} else {
+		args = append(args, minID)
+	}
+	if limit > 0 {
+		args = append(args, "LIMIT", limit)
+	}
+	return args
+}
+
+func appendValues(args []any, values any) []any {
+	switch v := values.(type) {
+	case map[string]string:
+		for field, value := range v {
+			args = append(args, field, value)
+		}
+	case map[string]any:
+		for field, value := range v {
+			args = append(args, field, value)
+		}
+	case []string:
+		args = appendStrings(args, v)
+	case []any:
+		args = append(args, v...)
+	}
+	return args
+}
+
+// XAdd appends an entry to a stream and returns its ID, failing with ErrNil when NoMkStream found no stream.
+func (c cmdable) XAdd(ctx context.Context, a *XAddArgs) *Cmd[string] {
+	args := []any{"XADD", a.Stream}
+	if a.NoMkStream {
+		args = append(args, "NOMKSTREAM")
+	}
+	args = appendTrim(args, a.MaxLen, a.MinID, a.Approx, a.Limit)
+	id := a.ID
+	if id == "" {
+		id = "*"
+	}
+	return run(ctx, c, toString, appendValues(append(args, id), a.Values)...)
+}
+
+func (c cmdable) XLen(ctx context.Context, stream string) *Cmd[int64] {
+	return run(ctx, c, toInt, "XLEN", stream)
+}
+
+func (c cmdable) XRange(ctx context.Context, stream, start, stop string) *Cmd[[]XMessage] {
+	return run(ctx, c, toXMessages, "XRANGE", stream, start, stop)
+}
+
+func (c cmdable) XRangeN(ctx context.Context, stream, start, stop string, count int64) *Cmd[[]XMessage] {
+	return run(ctx, c, toXMessages, "XRANGE", stream, start, stop, "COUNT", count)
+}
+
+func (c cmdable) XRevRange(ctx context.Context, stream, start, stop string) *Cmd[[]XMessage] {
+	return run(ctx, c, toXMessages, "XREVRANGE", stream, start, stop)
+}
+
+func (c cmdable) XRevRangeN(ctx context.Context, stream, start, stop string, count int64) *Cmd[[]XMessage] {
+	return run(ctx, c, toXMessages, "XREVRANGE", stream, start, stop, "COUNT", count)
+}
+
+func (c cmdable) XDel(ctx context.Context, stream string, ids ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"XDEL", stream}, ids)...)
+}
+
+// XTrimMaxLen trims a stream to maxLen entries and returns the number of deleted entries.
+func (c cmdable) XTrimMaxLen(ctx context.Context, stream string, maxLen int64) *Cmd[int64] {
+	return run(ctx, c, toInt, "XTRIM", stream, "MAXLEN", maxLen)
+}
+
+// XTrimMinID deletes the entries of a stream whose ID is lower than minID.
+func (c cmdable) XTrimMinID(ctx context.Context, stream, minID string) *Cmd[int64] {
+	return run(ctx, c, toInt, "XTRIM", stream, "MINID", minID)
+}
+
+func (c cmdable) XSetID(ctx context.Context, stream, lastID string) *Cmd[string] {
+	return run(ctx, c, toString, "XSETID", stream, lastID)
+}
+
+// XReadArgs are the options of XREAD, Streams holding the streams and then the IDs to read after.
+// Block waits for entries for at most its duration, 0 waiting forever, unless it is negative.
+type XReadArgs struct {
+	Streams []string
+	Count   int64
+	Block   time.Duration
+}
+
+// XRead reads the entries of streams, failing with ErrNil when Block elapsed without any.
+func (c cmdable) XRead(ctx context.Context, a *XReadArgs) *Cmd[[]XStream] {
+	args := []any{"XREAD"}
+	if a.Count > 0 {
+		args = append(args, "COUNT", a.Count)
+	}
+	if a.Block >= 0 {
+		args = append(args, "BLOCK", a.Block.Milliseconds())
+	}
+	return run(ctx, c, toXStreams, appendStrings(append(args, "STREAMS"), a.Streams)...)
+}
+
+// XReadGroupArgs are the options of XREADGROUP, as XReadArgs are for XREAD.
+type XReadGroupArgs struct {
+	Group    string
+	Consumer string
+	Streams  []string
+	Count    int64
+	Block    time.Duration
+	NoAck    bool
+}
+
+func (c cmdable) XReadGroup(ctx context.Context, a *XReadGroupArgs) *Cmd[[]XStream] {
+	args := []any{"XREADGROUP", "GROUP", a.Group, a.Consumer}
+	if a.Count > 0 {
+		args = append(args, "COUNT", a.Count)
+	}
+	if a.Block >= 0 {
+		args = append(args, "BLOCK", a.Block.Milliseconds())
+	}
+	if a.NoAck {
+		args = append(args, "NOACK")
+	}
+	return run(ctx, c, toXStreams, appendStrings(append(args, "STREAMS"), a.Streams)...)
+}
+
+// XGroupCreate creates a group delivering the entries after start, $ being the last entry.
+func (c cmdable) XGroupCreate(ctx context.Context, stream, group, start string) *Cmd[string] {
+	return run(ctx, c, toString, "XGROUP", "CREATE", stream, group, start)
+}
+
+// XGroupCreateMkStream is XGroupCreate creating the stream if it does not exist.
+func (c cmdable) XGroupCreateMkStream(ctx context.Context, stream, group, start string) *Cmd[string] {
+	return run(ctx, c, toString, "XGROUP", "CREATE", stream, group, start, "MKSTREAM")
+}
+
+func (c cmdable) XGroupSetID(ctx context.Context, stream, group, start string) *Cmd[string] {
+	return run(ctx, c, toString, "XGROUP", "SETID", stream, group, start)
+}
+
+func (c cmdable) XGroupDestroy(ctx context.Context, stream, group string) *Cmd[int64] {
+	return run(ctx, c, toInt, "XGROUP", "DESTROY", stream, group)
+}
+
+func (c cmdable) XGroupCreateConsumer(ctx context.Context, stream, group, consumer string) *Cmd[int64] {
+	return run(ctx, c, toInt, "XGROUP", "CREATECONSUMER", stream, group, consumer)
+}
+
+// XGroupDelConsumer deletes a consumer and returns the number of entries it had pending.
+func (c cmdable) XGroupDelConsumer(ctx context.Context, stream, group, consumer string) *Cmd[int64] {
+	return run(ctx, c, toInt, "XGROUP", "DELCONSUMER", stream, group, consumer)
+}
+
+func (c cmdable) XAck(ctx context.Context, stream, group string, ids ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"XACK", stream, group}, ids)...)
+}
+
+// XPending summarizes the pending entries of a group.
+type XPending struct {
+	Count     int64
+	Lower     string
+	Higher    string
+	Consumers map[string]int64
+}
+
+func (c cmdable) XPending(ctx context.Context, stream, group string) *Cmd[XPending] {
+	return run(ctx, c, toXPending, "XPENDING", stream, group)
+}
+
+// XPendingExtArgs select the pending entries XPendingExt returns.
+type XPendingExtArgs struct {
+	Stream   string
+	Group    string
+	Idle     time.Duration
+	Start    string
+	End      string
+	Count    int64
+	Consumer string
+}
+
+// XPendingExt is a pending entry, with the time since it was last delivered and how many times it was.
+type XPendingExt struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	RetryCount int64
+}
+
+func (c cmdable) XPendingExt(ctx context.Context, a *XPendingExtArgs) *Cmd[[]XPendingExt] {
+	args := []any{"XPENDING", a.Stream, a.Group}
+	if a.Idle > 0 {
+		args = append(args, "IDLE", a.Idle.Milliseconds())
+	}
+	args = append(args, a.Start, a.End, a.Count)
+	if a.Consumer != "" {
+		args = append(args, a.Consumer)
+	}
+	return run(ctx, c, sliceOf(toXPendingExt), args...)
+}
+
+// XClaimArgs are the entries pending for at least MinIdle that XCLAIM transfers to Consumer.
+type XClaimArgs struct {
+	Stream   string
+	Group    string
+	Consumer string
+	MinIdle  time.Duration
+	Messages []string
+}
+
+func (a *XClaimArgs) args() []any {
+	return appendStrings([]any{"XCLAIM", a.Stream, a.Group, a.Consumer, a.MinIdle.Milliseconds()}, a.Messages)
+}
+
+func (c cmdable) XClaim(ctx context.Context, a *XClaimArgs) *Cmd[[]XMessage] {
+	return run(ctx, c, toXMessages, a.args()...)
+}
+
+// XClaimJustID is XClaim returning the IDs of the claimed entries.
+func (c cmdable) XClaimJustID(ctx context.Context, a *XClaimArgs) *Cmd[[]string] {
+	return run(ctx, c, toStrings, append(a.args(), "JUSTID")...)
+}
+
+// XAutoClaimArgs are the options of XAUTOCLAIM, scanning the pending entries from Start.
+type XAutoClaimArgs struct {
+	Stream   string
+	Group    string
+	Consumer string
+	MinIdle  time.Duration
+	Start    string
+	Count    int64
+}
+
+func (a *XAutoClaimArgs) args() []any {
+	args := []any{"XAUTOCLAIM", a.Stream, a.Group, a.Consumer, a.MinIdle.Milliseconds(), a.Start}
+	if a.Count > 0 {
+		args = append(args, "COUNT", a.Count)
+	}
+	return args
+}
+
+// XAutoClaim is the reply of XAUTOCLAIM: the ID to continue the scan from, the claimed entries,
+// and the IDs of the pending entries that were deleted from the stream.
+type XAutoClaim struct {
+	Next     string
+	Messages []XMessage
+	Deleted  []string
+}
+
+func (c cmdable) XAutoClaim(ctx context.Context, a *XAutoClaimArgs) *Cmd[XAutoClaim] {
+	return run(ctx, c, toXAutoClaim, a.args()...)
+}
+
+// XAutoClaimJustID is XAutoClaim with the messages holding only their ID.
+func (c cmdable) XAutoClaimJustID(ctx context.Context, a *XAutoClaimArgs) *Cmd[XAutoClaim] {
+	return run(ctx, c, toXAutoClaim, append(a.args(), "JUSTID")...)
+}
+
+// XInfoStream describes a stream with the fields of XINFO STREAM.
+func (c cmdable) XInfoStream(ctx context.Context, stream string) *Cmd[map[string]any] {
+	return run(ctx, c, toInfoMap, "XINFO", "STREAM", stream)
+}
+
+func (c cmdable) XInfoGroups(ctx context.Context, stream string) *Cmd[[]map[string]any] {
+	return run(ctx, c, sliceOf(toInfoMap), "XINFO", "GROUPS", stream)
+}
+
+func (c cmdable) XInfoConsumers(ctx context.Context, stream, group string) *Cmd[[]map[string]any] {
+	return run(ctx, c, sliceOf(toInfoMap), "XINFO", "CONSUMERS", stream, group)
+}
+
+// toXMessage converts an entry, which JUSTID replies with as its ID alone.
+func toXMessage(reply any) (XMessage, error) {
+	if id, err := toString(reply); err == nil {
+		return XMessage{ID: id}, nil
+	}
+	elems, err := elements(reply)
+	if err != nil {
+		return XMessage{}, err
+	}
+	if len(elems) != 2 {
+		return XMessage{}, errUnexpected(reply)
+	}
+	id, err := toString(elems[0])
+	if err != nil {
+		return XMessage{}, err
+	}
+	values, err := toStringMap(elems[1])
+	if err != nil && !errors.Is(err, ErrNil) {
+		return XMessage{}, err
+	}
+	return XMessage{ID: id, Values: values}, nil
+}
+
+var toXMessages = sliceOf(toXMessage)
+
+// toXStreams converts the reply of XREAD, an array of streams and their entries, or a map in RESP3.
+func toXStreams(reply any) ([]XStream, error) {
+	var pairs [][2]any
+	if m, ok := reply.(resp.Map); ok {
+		for _, entry := range m {
+			pairs = append(pairs, [2]any{entry.Key, entry.Value})
+		}
+	} else {
+		elems, err := elements(reply)
+		if err != nil {
+			return nil, err
+		}
+		for _, elem := range elems {
+			pair, err := elements(elem)
+			if err != nil {
+				return nil, err
+			}
+			if len(pair) != 2 {
+				return nil, errUnexpected(elem)
+			}
+			pairs = append(pairs, [2]any{pair[0], pair[1]})
+		}
+	}
+
+	streams := make([]XStream, len(pairs))
+	for i, pair := range pairs {
+		name, err := toString(pair[0])
+		if err != nil {
+			return nil, err
+		}
+		messages, err := toXMessages(pair[1])
+		if err != nil {
+			return nil, err
+		}
+		streams[i] = XStream{Stream: name, Messages: messages}
+	}
+	return streams, nil
+}
+
+func toXPending(reply any) (XPending, error) {
+	elems, err := elements(reply)
+	if err != nil {
+		return XPending{}, err
+	}
+	if len(elems) != 4 {
+		return XPending{}, errUnexpected(reply)
+	}
+	count, err := toInt(elems[0])
+	if err != nil {
+		return XPending{}, err
+	}
+	pending := XPending{Count: count, Consumers: map[string]int64{}}
+	if count == 0 {
+		return pending, nil
+	}
+	if pending.Lower, err = toString(elems[1]); err != nil {
+		return XPending{}, err
+	}
+	if pending.Higher, err = toString(elems[2]); err != nil {
+		return XPending{}, err
+	}
+	consumers, err := elements(elems[3])
+	if err != nil {
+		return XPending{}, err
+	}
+	for _, consumer := range consumers {
+		fields, err := toStrings(consumer)
+		if err != nil {
+			return XPending{}, err
+		}
+		if len(fields) != 2 {
+			return XPending{}, errUnexpected(consumer)
+		}
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return XPending{}, err
+		}
+		pending.Consumers[fields[0]] = n
+	}
+	return pending, nil
+}
+
+func toXPendingExt(reply any) (XPendingExt, error) {
+	elems, err := elements(reply)
+	if err != nil {
+		return XPendingExt{}, err
+	}
+	if len(elems) != 4 {
+		return XPendingExt{}, errUnexpected(reply)
+	}
+	var ext XPendingExt
+	if ext.ID, err = toString(elems[0]); err != nil {
+		return XPendingExt{}, err
+	}
+	if ext.Consumer, err = toString(elems[1]); err != nil {
+		return XPendingExt{}, err
+	}
+	idle, err := toInt(elems[2])
+	if err != nil {
+		return XPendingExt{}, err
+	}
+	ext.Idle = time.Duration(idle) * time.Millisecond
+	if ext.RetryCount, err = toInt(elems[3]); err != nil {
+		return XPendingExt{}, err
+	}
+	return ext, nil
+}
+
+func toXAutoClaim(reply any) (XAutoClaim, error) {
+	elems, err := elements(reply)
+	if err != nil {
+		return XAutoClaim{}, err
+	}
+	if len(elems) < 2 {
+		return XAutoClaim{}, errUnexpected(reply)
+	}
+	var claim XAutoClaim
+	if claim.Next, err = toString(elems[0]); err != nil {
+		return XAutoClaim{}, err
+	}
+	if claim.Messages, err = toXMessages(elems[1]); err != nil {
+		return XAutoClaim{}, err
+	}
+	if len(elems) > 2 {
+		if claim.Deleted, err = toStrings(elems[2]); err != nil {
+			return XAutoClaim{}, err
+		}
+	}
+	return claim, nil
+}
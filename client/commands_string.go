@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// SetArgs are the options of SET.
+type SetArgs struct {
+	// NX or XX, to only set keys that do not exist or that exist
+	Mode string
+	// TTL is the time to live of the key, none if zero and ExpireAt is zero
+	TTL time.Duration
+	// ExpireAt is the time the key expires at, none if zero
+	ExpireAt time.Time
+	// Get returns the previous value of the key
+	Get bool
+}
+
+// appendExpiration appends EX or PX for ttl, PX being used when it is not in whole seconds.
+func appendExpiration(args []any, ttl time.Duration) []any {
+	if ttl%time.Second == 0 {
+		return append(args, "EX", int64(ttl/time.Second))
+	}
+	return append(args, "PX", ttl.Milliseconds())
+}
+
+// formatSeconds formats the timeout of a blocking command, 0 waiting forever.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+func (c cmdable) Get(ctx context.Context, key string) *Cmd[string] {
+	return run(ctx, c, toString, "GET", key)
+}
+
+// Set sets key to value, expiring after expiration unless it is 0.
+func (c cmdable) Set(ctx context.Context, key string, value any, expiration time.Duration) *Cmd[string] {
+	args := []any{"SET", key, value}
+	if expiration > 0 {
+		args = appendExpiration(args, expiration)
+	}
+	return run(ctx, c, toString, args...)
+}
+
+// SetNX sets key only if it does not exist, and reports whether it did.
+func (c cmdable) SetNX(ctx context.Context, key string, value any, expiration time.Duration) *Cmd[bool] {
+	args := []any{"SET", key, value}
+	if expiration > 0 {
+		args = appendExpiration(args, expiration)
+	}
+	return run(ctx, c, toBool, append(args, "NX")...)
+}
+
+// SetXX sets key only if it exists, and reports whether it did.
+func (c cmdable) SetXX(ctx context.Context, key string, value any, expiration time.Duration) *Cmd[bool] {
+	args := []any{"SET", key, value}
+	if expiration > 0 {
+		args = appendExpiration(args, expiration)
+	}
+	return run(ctx, c, toBool, append(args, "XX")...)
+}
+
+// SetArgs sets key with the options of a, the reply being OK, or the previous value with Get.
+// it fails with ErrNil when NX or XX prevented the write, or when Get found no previous value.
+func (c cmdable) SetArgs(ctx context.Context, key string, value any, a SetArgs) *Cmd[string] {
+	args := []any{"SET", key, value}
+	switch {
+	case a.TTL > 0:
+		args = appendExpiration(args, a.TTL)
+	case !a.ExpireAt.IsZero():
+		args = append(args, "PXAT", a.ExpireAt.UnixMilli())
+	}
+	if a.Mode != "" {
+		args = append(args, a.Mode)
+	}
+	if a.Get {
+		args = append(args, "GET")
+	}
+	return run(ctx, c, toString, args...)
+}
+
+func (c cmdable) Del(ctx context.Context, keys ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"DEL"}, keys)...)
+}
+
+// Dump serializes the value of key in the format of RESTORE.
+func (c cmdable) Dump(ctx context.Context, key string) *Cmd[string] {
+	return run(ctx, c, toString, "DUMP", key)
+}
+
+// Restore creates key from the payload of DUMP, expiring after ttl unless it is 0.
+func (c cmdable) Restore(ctx context.Context, key string, ttl time.Duration, payload string) *Cmd[string] {
+	return run(ctx, c, toString, "RESTORE", key, ttl.Milliseconds(), payload)
+}
+
+// RestoreReplace is Restore replacing key if it exists.
+func (c cmdable) RestoreReplace(ctx context.Context, key string, ttl time.Duration, payload string) *Cmd[string] {
+	return run(ctx, c, toString, "RESTORE", key, ttl.Milliseconds(), payload, "REPLACE")
+}
+
+// Move moves key to the database db, and reports whether it did.
+func (c cmdable) Move(ctx context.Context, key string, db int) *Cmd[bool] {
+	return run(ctx, c, toBool, "MOVE", key, db)
+}
+
+// Migrate moves key to the database db of another server, the reply being OK or NOKEY.
+func (c cmdable) Migrate(ctx context.Context, host string, port int, key string, db int, timeout time.Duration) *Cmd[string] {
+	return run(ctx, c, toString, "MIGRATE", host, port, key, db, timeout.Milliseconds())
+}
+
+func (c cmdable) SetBit(ctx context.Context, key string, offset int64, value int) *Cmd[int64] {
+	return run(ctx, c, toInt, "SETBIT", key, offset, value)
+}
+
+func (c cmdable) GetBit(ctx context.Context, key string, offset int64) *Cmd[int64] {
+	return run(ctx, c, toInt, "GETBIT", key, offset)
+}
+
+// BitCount is the range counted by BITCOUNT, in bytes unless Unit is BIT.
+type BitCount struct {
+	Start, End int64
+	Unit       string
+}
+
+// BitCount counts the set bits of key, within bitCount unless it is nil.
+func (c cmdable) BitCount(ctx context.Context, key string, bitCount *BitCount) *Cmd[int64] {
+	args := []any{"BITCOUNT", key}
+	if bitCount != nil {
+		args = append(args, bitCount.Start, bitCount.End)
+		if bitCount.Unit != "" {
+			args = append(args, bitCount.Unit)
+		}
+	}
+	return run(ctx, c, toInt, args...)
+}
+
+// BitPos finds the first bit set to bit, pos being the optional start and end bytes.
+func (c cmdable) BitPos(ctx context.Context, key string, bit int64, pos ...int64) *Cmd[int64] {
+	args := []any{"BITPOS", key, bit}
+	for _, p := range pos {
+		args = append(args, p)
+	}
+	return run(ctx, c, toInt, args...)
+}
+
+func (c cmdable) BitOpAnd(ctx context.Context, destKey string, keys ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"BITOP", "AND", destKey}, keys)...)
+}
+
+func (c cmdable) BitOpOr(ctx context.Context, destKey string, keys ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"BITOP", "OR", destKey}, keys)...)
+}
+
+func (c cmdable) BitOpXor(ctx context.Context, destKey string, keys ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"BITOP", "XOR", destKey}, keys)...)
+}
+
+func (c cmdable) BitOpNot(ctx context.Context, destKey string, key string) *Cmd[int64] {
+	return run(ctx, c, toInt, "BITOP", "NOT", destKey, key)
+}
+
+// BitField runs the GET, SET, INCRBY and OVERFLOW operations of args, a failed INCRBY being 0.
+func (c cmdable) BitField(ctx context.Context, key string, args ...any) *Cmd[[]int64] {
+	return run(ctx, c, toInts, append([]any{"BITFIELD", key}, args...)...)
+}
+
+func (c cmdable) BitFieldRO(ctx context.Context, key string, args ...any) *Cmd[[]int64] {
+	return run(ctx, c, toInts, append([]any{"BITFIELD_RO", key}, args...)...)
+}
+
+func (c cmdable) PFAdd(ctx context.Context, key string, elements ...any) *Cmd[int64] {
+	return run(ctx, c, toInt, append([]any{"PFADD", key}, elements...)...)
+}
+
+func (c cmdable) PFCount(ctx context.Context, keys ...string) *Cmd[int64] {
+	return run(ctx, c, toInt, appendStrings([]any{"PFCOUNT"}, keys)...)
+}
+
+func (c cmdable) PFMerge(ctx context.Context, destKey string, keys ...string) *Cmd[string] {
+	return run(ctx, c, toString, appendStrings([]any{"PFMERGE", destKey}, keys)...)
+}
+
+func appendStrings(args []any, strs []string) []any {
+	for _, s := range strs {
+		args = append(args, s)
+	}
+	return args
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// writeBufferSize is the size of the commands buffered by write above which they are sent, before the whole pipeline is.
+const writeBufferSize = 32 << 10
+
+// conn is a connection to the server, used by one goroutine at a time.
+type conn struct {
+	nc     net.Conn
+	enc    resp.Encoder
+	parser *resp.Parser
+	// set once an error left the connection in an unknown state, so that the pool closes it
+	broken atomic.Bool
+}
+
+func newConn(nc net.Conn) *conn {
+	return &conn{
+		nc:     nc,
+		parser: resp.NewReplyParser(nc),
+	}
+}
+
+func (cn *conn) close() {
+	_ = cn.nc.Close()
+}
+
+// roundTrip sends cmds at once and reads their replies, any error breaking the connection.
+func (cn *conn) roundTrip(ctx context.Context, cmds []Cmder) error {
+	err := cn.withContext(ctx, cn.nc.SetDeadline, func() error {
+		for _, cmd := range cmds {
+			if err := cn.write(cmd); err != nil {
+				return err
+			}
+		}
+		if err := cn.flush(); err != nil {
+			return err
+		}
+		for _, cmd := range cmds {
+			reply, err := cn.read()
+			if err != nil {
+				return err
+			}
+			cmd.setReply(reply, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		cn.broken.Store(true)
+	}
+	return err
+}
+
+// write buffers cmd, whose arguments were encoded by prepare.
+func (cn *conn) write(cmd Cmder) error {
+	if err := cn.enc.Encode(cmd.request()); err != nil {
+		return err
+	}
+	if cn.enc.Buffered() >= writeBufferSize {
+		return cn.flush()
+	}
+	return nil
+}
+
+// flush sends the buffered commands.
+func (cn *conn) flush() error {
+	_, err := cn.enc.WriteTo(cn.nc)
+	return err
+}
+
+// read reads the next reply, skipping the out of band messages of RESP3.
+func (cn *conn) read() (any, error) {
+	for {
+		reply, err := cn.parser.Parse()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := reply.(resp.Push); !ok {
+			return reply, nil
+		}
+	}
+}
+
+// withContext runs fn with the deadline of ctx, interrupting it when ctx is canceled.
+// setDeadline sets the deadline of reads, writes or both, as SetDeadline and its variants of net.Conn.
+func (cn *conn) withContext(ctx context.Context, setDeadline func(t time.Time) error, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := setDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = setDeadline(time.Unix(1, 0))
+	})
+
+	err := fn()
+	if !stop() {
+		// the deadline moved to the past, maybe after fn returned, so the connection cannot be trusted
+		cn.broken.Store(true)
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,257 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// the converters of replies accept both their RESP2 and RESP3 forms, such as a flat array or a map for HGETALL.
+
+func errUnexpected(reply any) error {
+	return fmt.Errorf("unexpected reply of type %T", reply)
+}
+
+func isNull(reply any) bool {
+	switch reply.(type) {
+	case resp.Null, resp.NullArray:
+		return true
+	default:
+		return false
+	}
+}
+
+// toAny converts a reply to Go values: strings, int64, float64, bool, *big.Int, []any and map[any]any,
+// null replies being nil, and ErrNil for the reply itself.
+func toAny(reply any) (any, error) {
+	if isNull(reply) {
+		return nil, ErrNil
+	}
+	return value(reply), nil
+}
+
+func value(reply any) any {
+	switch v := reply.(type) {
+	case resp.BulkString:
+		return string(v)
+	case resp.SimpleString:
+		return string(v)
+	case resp.VerbatimString:
+		return v.Text
+	case resp.Integer:
+		return int64(v)
+	case resp.Double:
+		return float64(v)
+	case resp.Boolean:
+		return bool(v)
+	case resp.BigNumber:
+		n, _ := new(big.Int).SetString(string(v), 10)
+		return n
+	case resp.Null, resp.NullArray:
+		return nil
+	case resp.Array:
+		return values(v)
+	case resp.Set:
+		return values(v)
+	case resp.Push:
+		return values(v)
+	case resp.Map:
+		m := make(map[any]any, len(v))
+		for _, entry := range v {
+			key := value(entry.Key)
+			if _, ok := key.([]any); ok {
+				// aggregates cannot be keys of Go maps
+				key = fmt.Sprint(key)
+			}
+			m[key] = value(entry.Value)
+		}
+		return m
+	default:
+		// errors nested in aggregates, such as the replies of EXEC
+		return v
+	}
+}
+
+func values(elements []any) []any {
+	converted := make([]any, len(elements))
+	for i, element := range elements {
+		converted[i] = value(element)
+	}
+	return converted
+}
+
+func toString(reply any) (string, error) {
+	switch v := reply.(type) {
+	case resp.BulkString:
+		return string(v), nil
+	case resp.SimpleString:
+		return string(v), nil
+	case resp.VerbatimString:
+		return v.Text, nil
+	case resp.Integer:
+		return v.String(), nil
+	case resp.Double:
+		return v.String(), nil
+	case resp.BigNumber:
+		return string(v), nil
+	case resp.Boolean:
+		return v.String(), nil
+	case resp.Null, resp.NullArray:
+		return "", ErrNil
+	default:
+		return "", errUnexpected(reply)
+	}
+}
+
+func toInt(reply any) (int64, error) {
+	switch v := reply.(type) {
+	case resp.Integer:
+		return int64(v), nil
+	case resp.BulkString:
+		return strconv.ParseInt(string(v), 10, 64)
+	case resp.SimpleString:
+		return strconv.ParseInt(string(v), 10, 64)
+	case resp.Boolean:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case resp.Null, resp.NullArray:
+		return 0, ErrNil
+	default:
+		return 0, errUnexpected(reply)
+	}
+}
+
+func toFloat(reply any) (float64, error) {
+	switch v := reply.(type) {
+	case resp.Double:
+		return float64(v), nil
+	case resp.Integer:
+		return float64(v), nil
+	case resp.BulkString:
+		return parseFloat(string(v))
+	case resp.SimpleString:
+		return parseFloat(string(v))
+	case resp.Null, resp.NullArray:
+		return 0, ErrNil
+	default:
+		return 0, errUnexpected(reply)
+	}
+}
+
+func parseFloat(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	default:
+		return strconv.ParseFloat(s, 64)
+	}
+}
+
+// toBool converts integers and booleans, OK being true and null false, as the replies of SET NX.
+func toBool(reply any) (bool, error) {
+	switch v := reply.(type) {
+	case resp.Integer:
+		return v != 0, nil
+	case resp.Boolean:
+		return bool(v), nil
+	case resp.SimpleString:
+		return v == "OK", nil
+	case resp.Null, resp.NullArray:
+		return false, nil
+	default:
+		return false, errUnexpected(reply)
+	}
+}
+
+// elements returns the elements of an aggregate, the keys and values of a map alternating.
+func elements(reply any) ([]any, error) {
+	switch v := reply.(type) {
+	case resp.Array:
+		return v, nil
+	case resp.Set:
+		return v, nil
+	case resp.Push:
+		return v, nil
+	case resp.Map:
+		flat := make([]any, 0, 2*len(v))
+		for _, entry := range v {
+			flat = append(flat, entry.Key, entry.Value)
+		}
+		return flat, nil
+	case resp.Null, resp.NullArray:
+		return nil, ErrNil
+	default:
+		return nil, errUnexpected(reply)
+	}
+}
+
+// sliceOf converts the elements of an aggregate with convert, null elements being zero values.
+func sliceOf[T any](convert func(reply any) (T, error)) func(reply any) ([]T, error) {
+	return func(reply any) ([]T, error) {
+		elems, err := elements(reply)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]T, len(elems))
+		for i, elem := range elems {
+			if isNull(elem) {
+				continue
+			}
+			if result[i], err = convert(elem); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+}
+
+var (
+	toStrings = sliceOf(toString)
+	toInts    = sliceOf(toInt)
+	toBools   = sliceOf(toBool)
+	toSlice   = sliceOf(func(reply any) (any, error) { return value(reply), nil })
+)
+
+// toStringMap converts a map, or an array of alternating keys and values.
+func toStringMap(reply any) (map[string]string, error) {
+	elems, err := toStrings(reply)
+	if err != nil {
+		return nil, err
+	}
+	if len(elems)%2 != 0 {
+		return nil, fmt.Errorf("odd number of elements in a map reply: %d", len(elems))
+	}
+	m := make(map[string]string, len(elems)/2)
+	for i := 0; i < len(elems); i += 2 {
+		m[elems[i]] = elems[i+1]
+	}
+	return m, nil
+}
+
+// toInfoMap converts the replies describing an object as alternating names and values, such as XINFO STREAM.
+func toInfoMap(reply any) (map[string]any, error) {
+	elems, err := elements(reply)
+	if err != nil {
+		return nil, err
+	}
+	if len(elems)%2 != 0 {
+		return nil, fmt.Errorf("odd number of elements in a map reply: %d", len(elems))
+	}
+	m := make(map[string]any, len(elems)/2)
+	for i := 0; i < len(elems); i += 2 {
+		key, err := toString(elems[i])
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value(elems[i+1])
+	}
+	return m, nil
+}
@@ -0,0 +1,45 @@
+package client
+
+import "context"
+
+// Monitor is a connection streaming every command the server receives.
+type Monitor struct {
+	cn *conn
+}
+
+// Monitor opens a connection running MONITOR.
+func (c *Client) Monitor(ctx context.Context) (*Monitor, error) {
+	cn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newCmd(toString, "MONITOR")
+	_ = cmd.encode()
+	if err := cn.roundTrip(ctx, []Cmder{cmd}); err != nil {
+		cn.close()
+		return nil, err
+	}
+	if err := cmd.Err(); err != nil {
+		cn.close()
+		return nil, err
+	}
+	return &Monitor{cn: cn}, nil
+}
+
+// Receive waits for the next command, formatted as timestamp [db addr] "command" "arg" ...
+func (m *Monitor) Receive(ctx context.Context) (string, error) {
+	var line string
+	err := m.cn.withContext(ctx, m.cn.nc.SetDeadline, func() error {
+		reply, err := m.cn.read()
+		if err != nil {
+			return err
+		}
+		line, err = toString(reply)
+		return err
+	})
+	return line, err
+}
+
+func (m *Monitor) Close() error {
+	return m.cn.nc.Close()
+}
@@ -0,0 +1,39 @@
+package client
+
+import "time"
+
+type Option func(*Client)
+
+// WithPoolSize sets the number of connections the client opens at most, ten per CPU by default.
+func WithPoolSize(n int) Option {
+	return func(c *Client) {
+		c.poolSize = n
+	}
+}
+
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.dialTimeout = d
+	}
+}
+
+// WithDB selects the database of every connection, including the ones opened to reconnect.
+func WithDB(db int) Option {
+	return func(c *Client) {
+		c.db = db
+	}
+}
+
+// WithProtocol sets the version of RESP spoken with the server, 2 by default, 3 being negotiated with HELLO.
+func WithProtocol(version int) Option {
+	return func(c *Client) {
+		c.protocol = version
+	}
+}
+
+// WithMaxRetries sets how many times a command failing on a broken connection is retried on a new one, once by default.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
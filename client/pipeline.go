@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+var errTxAborted = errors.New("transaction aborted")
+
+// Pipeline queues commands to send them at once with Exec, in a MULTI and EXEC transaction for TxPipeline.
+// the commands of a pipeline get their reply once it is executed, it is not safe for concurrent use.
+type Pipeline struct {
+	cmdable
+
+	client *Client
+	tx     bool
+	cmds   []Cmder
+}
+
+func (c *Client) Pipeline() *Pipeline {
+	p := &Pipeline{client: c}
+	p.cmdable = p.queue
+	return p
+}
+
+// TxPipeline creates a pipeline whose commands run atomically.
+func (c *Client) TxPipeline() *Pipeline {
+	p := c.Pipeline()
+	p.tx = true
+	return p
+}
+
+// Pipelined queues the commands of fn to a pipeline, and executes it unless fn fails.
+func (c *Client) Pipelined(ctx context.Context, fn func(p *Pipeline) error) ([]Cmder, error) {
+	return c.Pipeline().run(ctx, fn)
+}
+
+// TxPipelined is Pipelined in a transaction.
+func (c *Client) TxPipelined(ctx context.Context, fn func(p *Pipeline) error) ([]Cmder, error) {
+	return c.TxPipeline().run(ctx, fn)
+}
+
+func (p *Pipeline) run(ctx context.Context, fn func(p *Pipeline) error) ([]Cmder, error) {
+	if err := fn(p); err != nil {
+		p.Discard()
+		return nil, err
+	}
+	return p.Exec(ctx)
+}
+
+func (p *Pipeline) queue(_ context.Context, cmd Cmder) error {
+	p.cmds = append(p.cmds, cmd)
+	return nil
+}
+
+// Len is the number of queued commands.
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+// Discard drops the queued commands.
+func (p *Pipeline) Discard() {
+	p.cmds = nil
+}
+
+// Exec sends the queued commands and reads their replies, and returns the commands along with the first error
+// other than ErrNil among them. the pipeline is empty afterwards.
+func (p *Pipeline) Exec(ctx context.Context) ([]Cmder, error) {
+	cmds := p.cmds
+	p.cmds = nil
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+
+	valid := prepare(cmds)
+	if p.tx && len(valid) < len(cmds) {
+		// a transaction runs all of its commands or none
+		err := fmt.Errorf("%w: %w", errTxAborted, firstErr(cmds))
+		for _, cmd := range valid {
+			cmd.setReply(nil, err)
+		}
+		return cmds, firstErr(cmds)
+	}
+
+	var err error
+	if p.tx {
+		err = p.client.withConn(ctx, func(cn *conn) error { return execTx(ctx, cn, valid) })
+	} else {
+		err = p.client.withConn(ctx, func(cn *conn) error { return cn.roundTrip(ctx, valid) })
+	}
+	if err != nil {
+		for _, cmd := range valid {
+			cmd.setReply(nil, err)
+		}
+	}
+	return cmds, firstErr(cmds)
+}
+
+func firstErr(cmds []Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !errors.Is(err, ErrNil) {
+			return err
+		}
+	}
+	return nil
+}
+
+// queuedCmd is a command sent in a transaction, whose reply is QUEUED or the error that will abort the transaction.
+type queuedCmd struct {
+	Cmder
+	err error
+}
+
+func (q *queuedCmd) setReply(reply any, err error) {
+	if replyErr, ok := reply.(resp.SimpleError); ok {
+		err = replyErr
+	}
+	q.err = err
+}
+
+// execTx runs cmds in a transaction, MULTI being sent first so that no command runs on its own on a server
+// without transactions.
+func execTx(ctx context.Context, cn *conn, cmds []Cmder) error {
+	multi := newCmd(toString, "MULTI")
+	exec := newCmd(func(reply any) (any, error) { return reply, nil }, "EXEC")
+	_ = multi.encode()
+	_ = exec.encode()
+
+	if err := cn.roundTrip(ctx, []Cmder{multi}); err != nil {
+		return err
+	}
+	if err := multi.Err(); err != nil {
+		for _, cmd := range cmds {
+			cmd.setReply(nil, fmt.Errorf("%w: %w", errTxAborted, err))
+		}
+		return nil
+	}
+
+	queued := make([]*queuedCmd, len(cmds))
+	batch := make([]Cmder, 0, len(cmds)+1)
+	for i, cmd := range cmds {
+		queued[i] = &queuedCmd{Cmder: cmd}
+		batch = append(batch, queued[i])
+	}
+	if err := cn.roundTrip(ctx, append(batch, exec)); err != nil {
+		return err
+	}
+
+	replies, err := elements(exec.Val())
+	if err == nil && len(replies) != len(cmds) {
+		err = errUnexpected(exec.Val())
+	}
+	switch {
+	case exec.Err() != nil:
+		err = exec.Err()
+	case errors.Is(err, ErrNil):
+		err = errTxAborted
+	}
+	for i, cmd := range cmds {
+		switch {
+		case queued[i].err != nil:
+			cmd.setReply(nil, queued[i].err)
+		case err != nil:
+			cmd.setReply(nil, err)
+		default:
+			cmd.setReply(replies[i], nil)
+		}
+	}
+	return nil
+}
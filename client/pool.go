@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// pool keeps the idle connections of a client, and bounds the number of connections in use.
+type pool struct {
+	dial func(ctx context.Context) (*conn, error)
+	// holds a token for each connection in use
+	tokens chan struct{}
+	idle   chan *conn
+	closed atomic.Bool
+}
+
+func newPool(size int, dial func(ctx context.Context) (*conn, error)) *pool {
+	return &pool{
+		dial:   dial,
+		tokens: make(chan struct{}, size),
+		idle:   make(chan *conn, size),
+	}
+}
+
+// get takes an idle connection or opens one, waiting while every connection is in use.
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	if p.closed.Load() {
+		return nil, ErrClosed
+	}
+	select {
+	case p.tokens <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case cn := <-p.idle:
+		return cn, nil
+	default:
+	}
+	cn, err := p.dial(ctx)
+	if err != nil {
+		<-p.tokens
+		return nil, err
+	}
+	return cn, nil
+}
+
+// put gives back a connection taken with get, which is closed if it is broken.
+func (p *pool) put(cn *conn) {
+	defer func() { <-p.tokens }()
+
+	if cn.broken.Load() || p.closed.Load() {
+		cn.close()
+		return
+	}
+	select {
+	case p.idle <- cn:
+	default:
+		cn.close()
+	}
+	// the pool may have been closed meanwhile, without seeing this connection
+	if p.closed.Load() {
+		p.drain()
+	}
+}
+
+func (p *pool) close() error {
+	if p.closed.Swap(true) {
+		return ErrClosed
+	}
+	p.drain()
+	return nil
+}
+
+func (p *pool) drain() {
+	for {
+		select {
+		case cn := <-p.idle:
+			cn.close()
+		default:
+			return
+		}
+	}
+}
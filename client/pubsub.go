@@ -0,0 +1,309 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// messageBuffer is the number of messages Channel holds for a slow receiver.
+const messageBuffer = 100
+
+// Message is a message published to Channel, Pattern being the pattern it matched when it was received through one.
+type Message struct {
+	Channel string
+	Pattern string
+	Payload string
+}
+
+// Subscription confirms a change of subscriptions, Count being the number of subscriptions left.
+type Subscription struct {
+	// subscribe, unsubscribe, psubscribe or punsubscribe
+	Kind    string
+	Channel string
+	Count   int64
+}
+
+// PubSub is a connection subscribed to channels and patterns, which is opened again with the same subscriptions
+// if it breaks while receiving messages.
+// a single goroutine may receive messages at a time, while other ones change the subscriptions.
+type PubSub struct {
+	client *Client
+
+	// cancels Channel once closed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	cn       *conn
+	channels map[string]struct{}
+	patterns map[string]struct{}
+	closed   bool
+
+	chOnce sync.Once
+	ch     chan *Message
+}
+
+func (c *Client) newPubSub() *PubSub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PubSub{
+		client:   c,
+		ctx:      ctx,
+		cancel:   cancel,
+		channels: map[string]struct{}{},
+		patterns: map[string]struct{}{},
+	}
+}
+
+// Subscribe opens a connection subscribed to channels.
+func (c *Client) Subscribe(ctx context.Context, channels ...string) (*PubSub, error) {
+	ps := c.newPubSub()
+	if err := ps.Subscribe(ctx, channels...); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	return ps, nil
+}
+
+// PSubscribe opens a connection subscribed to glob patterns.
+func (c *Client) PSubscribe(ctx context.Context, patterns ...string) (*PubSub, error) {
+	ps := c.newPubSub()
+	if err := ps.PSubscribe(ctx, patterns...); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (ps *PubSub) Subscribe(ctx context.Context, channels ...string) error {
+	return ps.send(ctx, "SUBSCRIBE", channels, ps.channels, true)
+}
+
+// Unsubscribe stops listening to channels, or to every channel if none is given.
+func (ps *PubSub) Unsubscribe(ctx context.Context, channels ...string) error {
+	return ps.send(ctx, "UNSUBSCRIBE", channels, ps.channels, false)
+}
+
+func (ps *PubSub) PSubscribe(ctx context.Context, patterns ...string) error {
+	return ps.send(ctx, "PSUBSCRIBE", patterns, ps.patterns, true)
+}
+
+// PUnsubscribe stops listening to patterns, or to every pattern if none is given.
+func (ps *PubSub) PUnsubscribe(ctx context.Context, patterns ...string) error {
+	return ps.send(ctx, "PUNSUBSCRIBE", patterns, ps.patterns, false)
+}
+
+// send sends a change of subscriptions and records it in subscriptions, whose confirmation comes through Receive.
+func (ps *PubSub) send(ctx context.Context, command string, names []string, subscriptions map[string]struct{}, add bool) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+	for _, name := range names {
+		if add {
+			subscriptions[name] = struct{}{}
+		} else {
+			delete(subscriptions, name)
+		}
+	}
+	if !add && len(names) == 0 {
+		clear(subscriptions)
+	}
+
+	if ps.cn == nil {
+		// connecting subscribes to everything recorded
+		return ps.connect(ctx)
+	}
+	return ps.write(ctx, ps.cn, appendStrings([]any{command}, names))
+}
+
+// connect opens the connection of ps and subscribes it to the recorded channels and patterns, ps.mu being held.
+func (ps *PubSub) connect(ctx context.Context) error {
+	cn, err := ps.client.dial(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ps.channels) > 0 {
+		err = ps.write(ctx, cn, appendKeys([]any{"SUBSCRIBE"}, ps.channels))
+	}
+	if err == nil && len(ps.patterns) > 0 {
+		err = ps.write(ctx, cn, appendKeys([]any{"PSUBSCRIBE"}, ps.patterns))
+	}
+	if err != nil {
+		cn.close()
+		return err
+	}
+	ps.cn = cn
+	return nil
+}
+
+func appendKeys(args []any, set map[string]struct{}) []any {
+	for key := range set {
+		args = append(args, key)
+	}
+	return args
+}
+
+// write sends a command without waiting for its reply, only setting the write deadline
+// so that it does not interrupt Receive.
+func (ps *PubSub) write(ctx context.Context, cn *conn, args []any) error {
+	cmd := newCmd(toAny, args...)
+	if err := cmd.encode(); err != nil {
+		return err
+	}
+	err := cn.withContext(ctx, cn.nc.SetWriteDeadline, func() error {
+		if err := cn.write(cmd); err != nil {
+			return err
+		}
+		return cn.flush()
+	})
+	if err != nil {
+		cn.broken.Store(true)
+	}
+	return err
+}
+
+// Receive waits for the next message or confirmation of a subscription, a *Message or a *Subscription.
+func (ps *PubSub) Receive(ctx context.Context) (any, error) {
+	ps.mu.Lock()
+	cn, closed := ps.cn, ps.closed
+	ps.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	if cn == nil {
+		return nil, errNotConnected
+	}
+
+	var reply any
+	err := cn.withContext(ctx, cn.nc.SetReadDeadline, func() error {
+		var err error
+		reply, err = cn.parser.Parse()
+		return err
+	})
+	if err != nil {
+		cn.broken.Store(true)
+		return nil, err
+	}
+	return toPubSubMessage(reply)
+}
+
+var errNotConnected = errors.New("connection was lost")
+
+// ReceiveMessage waits for the next message, skipping the confirmations of subscriptions,
+// and subscribing again on a new connection when the connection breaks.
+func (ps *PubSub) ReceiveMessage(ctx context.Context) (*Message, error) {
+	for attempt := 0; ; {
+		msg, err := ps.Receive(ctx)
+		switch {
+		case err == nil:
+			attempt = 0
+			if m, ok := msg.(*Message); ok {
+				return m, nil
+			}
+			continue
+		case !retryable(ctx, err):
+			return nil, err
+		}
+
+		attempt++
+		if err := ps.reconnect(ctx); err == nil {
+			continue
+		}
+		backoff := time.NewTimer(time.Duration(min(attempt, 100)) * retryBackoff)
+		select {
+		case <-backoff.C:
+		case <-ctx.Done():
+			backoff.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// reconnect replaces a broken connection.
+func (ps *PubSub) reconnect(ctx context.Context) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+	if ps.cn != nil {
+		if !ps.cn.broken.Load() {
+			return nil
+		}
+		ps.cn.close()
+		ps.cn = nil
+	}
+	return ps.connect(ctx)
+}
+
+// Channel returns a channel receiving the messages until ps is closed, as ReceiveMessage does.
+// messages are dropped by the server when the channel stays full.
+func (ps *PubSub) Channel() <-chan *Message {
+	ps.chOnce.Do(func() {
+		ps.ch = make(chan *Message, messageBuffer)
+		go func() {
+			defer close(ps.ch)
+			for {
+				msg, err := ps.ReceiveMessage(ps.ctx)
+				if err != nil {
+					return
+				}
+				select {
+				case ps.ch <- msg:
+				case <-ps.ctx.Done():
+					return
+				}
+			}
+		}()
+	})
+	return ps.ch
+}
+
+// Close closes the connection, which ends Channel.
+func (ps *PubSub) Close() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+	ps.closed = true
+	ps.cancel()
+	if ps.cn != nil {
+		ps.cn.close()
+	}
+	return nil
+}
+
+func toPubSubMessage(reply any) (any, error) {
+	elems, err := toStrings(reply)
+	if err != nil {
+		return nil, err
+	}
+	if len(elems) < 3 {
+		return nil, errUnexpected(reply)
+	}
+	switch elems[0] {
+	case "message":
+		return &Message{Channel: elems[1], Payload: elems[2]}, nil
+	case "pmessage":
+		if len(elems) < 4 {
+			return nil, errUnexpected(reply)
+		}
+		return &Message{Pattern: elems[1], Channel: elems[2], Payload: elems[3]}, nil
+	case "subscribe", "unsubscribe", "psubscribe", "punsubscribe":
+		count, err := strconv.ParseInt(elems[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &Subscription{Kind: elems[0], Channel: elems[1], Count: count}, nil
+	default:
+		return nil, errUnexpected(reply)
+	}
+}
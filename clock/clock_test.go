@@ -0,0 +1,24 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	f := NewFake(start)
+	require.Equal(t, start, f.Now())
+
+	f.Advance(time.Minute)
+	require.Equal(t, start.Add(time.Minute), f.Now())
+
+	f.Set(start.Add(-time.Hour))
+	require.Equal(t, start.Add(-time.Hour), f.Now())
+}
+
+func TestReal(t *testing.T) {
+	require.WithinDuration(t, time.Now(), Real.Now(), time.Second)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/PlayerNeo42/gvalkey/internal/benchmark"
+)
+
+func main() {
+	commandLine, err := benchmark.ParseCommandLine(filepath.Base(os.Args[0]), os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	if err := run(commandLine); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(commandLine *benchmark.CommandLine) error {
+	// Ctrl-C ends the benchmark early, and the figures so far are still reported
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var results []*benchmark.Result
+	if commandLine.InProcess {
+		var err error
+		if results, err = benchmark.RunStores(ctx, commandLine); err != nil {
+			return err
+		}
+	} else {
+		result, err := benchmark.RunServer(ctx, commandLine)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	fmt.Printf("%d clients, pipeline %d, %d keys, %s bytes values, mix %s\n\n",
+		commandLine.Clients, commandLine.Pipeline, commandLine.KeySpace, &commandLine.ValueSize, &commandLine.Mix)
+	for _, result := range results {
+		if err := result.Report(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/PlayerNeo42/gvalkey/internal/cli"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/mattn/go-isatty"
+)
+
+func main() {
+	commandLine, err := cli.ParseCommandLine(filepath.Base(os.Args[0]), os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	// like redis-cli, replies are formatted for humans on a terminal, and printed as they are for scripts
+	if !commandLine.NoRaw && !isatty.IsTerminal(os.Stdout.Fd()) {
+		commandLine.Raw = true
+	}
+
+	session := cli.NewSession(commandLine, os.Stdout, os.Stderr)
+	err = run(commandLine, session)
+	_ = session.Close()
+
+	// the error reply of a command has been printed as its reply
+	var replyErr resp.SimpleError
+	if err != nil && (len(commandLine.Args) == 0 || !errors.As(err, &replyErr)) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(commandLine *cli.CommandLine, session *cli.Session) error {
+	switch {
+	case commandLine.Pipe:
+		return session.Pipe(os.Stdin)
+	case commandLine.Scan:
+		return session.Scan(commandLine.Pattern, commandLine.Count)
+	case commandLine.BigKeys:
+		return session.BigKeys()
+	case commandLine.Latency:
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return session.Latency(ctx)
+	case len(commandLine.Args) > 0:
+		return session.Run(commandLine.Args)
+	default:
+		return session.Repl(cli.NewLineReader(os.Stdin, os.Stdout, cli.HistoryFile(), session.Hint))
+	}
+}
@@ -1,24 +1,82 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 
 	"github.com/PlayerNeo42/gvalkey/internal/config"
 	"github.com/PlayerNeo42/gvalkey/internal/log"
+	"github.com/PlayerNeo42/gvalkey/internal/version"
 	"github.com/PlayerNeo42/gvalkey/server"
 )
 
 func main() {
-	conf, err := config.Load()
+	commandLine, err := config.ParseCommandLine(filepath.Base(os.Args[0]), os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	if commandLine.Version {
+		fmt.Printf("gvalkey v=%s redis=%s go=%s bits=%d\n", version.Version, version.RedisVersion, runtime.Version(), strconv.IntSize)
+		return
+	}
+
+	// like redis-server, the configuration file is the first argument
+	var configFile string
+	if commandLine.ConfigFile != "" {
+		configFile, err = filepath.Abs(commandLine.ConfigFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to resolve config file path:", err)
+			os.Exit(1)
+		}
+	}
+
+	var unknownDirectives []config.Directive
+	conf, err := config.Load(
+		config.WithFile(configFile),
+		config.WithOverrides(commandLine.Overrides...),
+		config.WithUnknownDirectiveHandler(func(d config.Directive) {
+			unknownDirectives = append(unknownDirectives, d)
+		}),
+	)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "failed to load config:", err)
 		os.Exit(1)
 	}
 
-	logger := log.New(conf.LogLevel)
+	if commandLine.TestConfig {
+		for _, d := range unknownDirectives {
+			fmt.Println("ignoring unsupported config directive", d.String())
+		}
+		fmt.Println("configuration is valid")
+		return
+	}
+
+	registry := config.NewRegistry(conf, configFile)
+
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(log.ParseLevel(conf.LogLevel))
+	registry.OnChange("loglevel", func(c *config.Config) {
+		logLevel.Set(log.ParseLevel(c.LogLevel))
+	})
+
+	logger := log.New(logLevel)
+
+	for _, d := range unknownDirectives {
+		logger.Warn("ignoring unsupported config directive", "directive", d.String())
+	}
 
-	tcpServer := server.NewServer(fmt.Sprintf("%s:%d", conf.Host, conf.Port), server.WithLogger(logger))
+	tcpServer := server.NewServer(net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port)), server.WithLogger(logger), server.WithConfig(registry))
 	if err := tcpServer.ListenAndServe(); err != nil {
 		logger.Error("failed to start server", "error", err)
 		os.Exit(1)
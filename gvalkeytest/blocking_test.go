@@ -0,0 +1,160 @@
+package gvalkeytest
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/client"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+// waitBlocked waits for n clients of s to be blocked, as told by INFO.
+func waitBlocked(t *testing.T, s *Server, n int) {
+	t.Helper()
+	c := newClient(t, s)
+	want := "blocked_clients:" + strconv.Itoa(n) + "\r\n"
+	require.Eventually(t, func() bool {
+		info, err := c.Do(t.Context(), "INFO", "clients").Result()
+		return err == nil && strings.Contains(info.(string), want)
+	}, 5*time.Second, time.Millisecond)
+}
+
+type popResult struct {
+	popped []string
+	err    error
+}
+
+func TestBLPopFIFO(t *testing.T) {
+	s := Run(t)
+
+	// the clients blocked first are served first, each by a single element
+	results := make([]chan popResult, 3)
+	for i := range results {
+		results[i] = make(chan popResult, 1)
+		c := newClient(t, s)
+		go func() {
+			popped, err := c.BLPop(t.Context(), 0, "queue").Result()
+			results[i] <- popResult{popped, err}
+		}()
+		waitBlocked(t, s, i+1)
+	}
+
+	require.NoError(t, newClient(t, s).RPush(t.Context(), "queue", "a", "b", "c").Err())
+	for i, want := range []string{"a", "b", "c"} {
+		res := <-results[i]
+		require.NoError(t, res.err)
+		require.Equal(t, []string{"queue", want}, res.popped)
+	}
+	require.Empty(t, s.List("queue"))
+}
+
+func TestBLPopTimeout(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+
+	start := time.Now()
+	err := c.BLPop(t.Context(), 100*time.Millisecond, "queue").Err()
+	require.ErrorIs(t, err, client.ErrNil)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	waitBlocked(t, s, 0)
+
+	// an element pushed after the timeout stays in the list
+	s.Push("queue", "a")
+	require.Equal(t, []string{"a"}, s.List("queue"))
+
+	require.ErrorContains(t, c.BLPop(t.Context(), -time.Second, "queue").Err(), "timeout is negative")
+}
+
+func TestBLPopDisconnect(t *testing.T) {
+	s := Run(t)
+
+	conn, err := net.Dial("tcp", s.Addr())
+	require.NoError(t, err)
+	cmd, err := resp.Encode(resp.Array{resp.BulkString("BLPOP"), resp.BulkString("queue"), resp.BulkString("0")})
+	require.NoError(t, err)
+	_, err = conn.Write(cmd)
+	require.NoError(t, err)
+	waitBlocked(t, s, 1)
+
+	// the client going away is no longer blocked, so the element is left for others
+	require.NoError(t, conn.Close())
+	waitBlocked(t, s, 0)
+	require.NoError(t, newClient(t, s).RPush(t.Context(), "queue", "a").Err())
+	require.Equal(t, []string{"a"}, s.List("queue"))
+}
+
+func TestBLMove(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+
+	moved := make(chan string, 1)
+	go func() {
+		element, err := c.BLMove(t.Context(), "source", "destination", "LEFT", "RIGHT", 0).Result()
+		if err != nil {
+			t.Error(err)
+		}
+		moved <- element
+	}()
+	waitBlocked(t, s, 1)
+
+	s.Push("destination", "first")
+	require.NoError(t, newClient(t, s).RPush(t.Context(), "source", "a", "b").Err())
+	require.Equal(t, "a", <-moved)
+	require.Equal(t, []string{"b"}, s.List("source"))
+	require.Equal(t, []string{"first", "a"}, s.List("destination"))
+}
+
+func TestBLPopSwapDB(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+
+	results := make(chan popResult, 1)
+	go func() {
+		popped, err := c.BLPop(t.Context(), 0, "queue").Result()
+		results <- popResult{popped, err}
+	}()
+	waitBlocked(t, s, 1)
+
+	// the list pushed to another database serves the client once SWAPDB brings it to its database
+	other := newClient(t, s, client.WithDB(1))
+	require.NoError(t, other.RPush(t.Context(), "queue", "a").Err())
+	require.NoError(t, other.SwapDB(t.Context(), 0, 1).Err())
+
+	res := <-results
+	require.NoError(t, res.err)
+	require.Equal(t, []string{"queue", "a"}, res.popped)
+}
+
+func TestXReadBlock(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+
+	type readResult struct {
+		streams []client.XStream
+		err     error
+	}
+	results := make(chan readResult, 1)
+	go func() {
+		streams, err := c.XRead(t.Context(), &client.XReadArgs{Streams: []string{"events", "$"}, Block: 0}).Result()
+		results <- readResult{streams, err}
+	}()
+	waitBlocked(t, s, 1)
+
+	id, err := newClient(t, s).XAdd(t.Context(), &client.XAddArgs{Stream: "events", Values: []string{"type", "login"}}).Result()
+	require.NoError(t, err)
+
+	res := <-results
+	require.NoError(t, res.err)
+	require.Equal(t, []client.XStream{{
+		Stream:   "events",
+		Messages: []client.XMessage{{ID: id, Values: map[string]string{"type": "login"}}},
+	}}, res.streams)
+
+	// a read timing out replies nil
+	err = c.XRead(t.Context(), &client.XReadArgs{Streams: []string{"events", "$"}, Block: 100 * time.Millisecond}).Err()
+	require.ErrorIs(t, err, client.ErrNil)
+}
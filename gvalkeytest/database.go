@@ -0,0 +1,240 @@
+package gvalkeytest
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// Database seeds and inspects the keys of a database of the server directly, bypassing the protocol.
+// keys holding a value of another type than the one a method expects fail the test.
+type Database struct {
+	s     *Server
+	index int
+}
+
+// store returns the store of the database, which SWAPDB may have changed.
+func (db *Database) store() store.Store {
+	return db.s.srv.DB(db.index)
+}
+
+// compute runs fn within the store, so that the mutable values of lists and hashes are not raced with the server,
+// and fails the test with the error fn returns, the key being left untouched.
+func (db *Database) compute(key string, fn func(entry store.Entry, exists bool) (store.Entry, store.Op, error)) {
+	var err error
+	db.store().Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var op store.Op
+		if entry, op, err = fn(entry, exists); err != nil {
+			return entry, store.OpKeep
+		}
+		return entry, op
+	})
+	if err != nil {
+		db.s.t.Fatalf("gvalkeytest: %v", err)
+	}
+}
+
+// valueOf returns the value of key and whether it is set, or an error when it is not a T.
+func valueOf[T any](key string, entry store.Entry, exists bool) (T, bool, error) {
+	var zero T
+	if !exists {
+		return zero, false, nil
+	}
+	v, ok := entry.Value.(T)
+	if !ok {
+		return zero, false, fmt.Errorf("key %q holds a %s, not a %s", key, typeOf(entry.Value), typeOf(zero))
+	}
+	return v, true, nil
+}
+
+// typeOf returns the name of the type of a value, as TYPE replies it.
+// the zero values of pointers have the type of the values they point to.
+func typeOf(value any) string {
+	switch value.(type) {
+	case resp.BulkString:
+		return "string"
+	case *object.List:
+		return "list"
+	case *object.Hash:
+		return "hash"
+	case *object.SortedSet:
+		return "zset"
+	case *object.Stream:
+		return "stream"
+	default:
+		return "unknown"
+	}
+}
+
+// Set sets a string key, removing its time to live.
+func (db *Database) Set(key, value string) {
+	db.store().Set(resp.SetArgs{Key: resp.BulkString(key), Value: resp.BulkString(value)})
+}
+
+// Get returns the value of a string key.
+func (db *Database) Get(key string) (value string, ok bool) {
+	db.compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op, error) {
+		v, isString, err := valueOf[resp.BulkString](key, entry, exists)
+		value, ok = string(v), isString
+		return entry, store.OpKeep, err
+	})
+	return value, ok
+}
+
+// Exists tells whether key is set and not expired.
+func (db *Database) Exists(key string) bool {
+	_, ok := db.store().Get(key)
+	return ok
+}
+
+// Del removes key, and tells whether it was set.
+func (db *Database) Del(key string) bool {
+	return db.store().Del(key)
+}
+
+// Type returns the type of key as TYPE replies it, none when it is not set.
+func (db *Database) Type(key string) string {
+	value, ok := db.store().Get(key)
+	if !ok {
+		return "none"
+	}
+	return typeOf(value)
+}
+
+// Keys returns the keys of the database, sorted.
+func (db *Database) Keys() []string {
+	var keys []string
+	db.store().Range(func(key string, _ store.Entry) bool {
+		keys = append(keys, key)
+		return true
+	})
+	slices.Sort(keys)
+	return keys
+}
+
+// Flush removes the keys of the database.
+func (db *Database) Flush() {
+	db.store().Flush()
+}
+
+// SetTTL sets the time to live of key relatively to the clock of the server, and tells whether the key is set.
+func (db *Database) SetTTL(key string, ttl time.Duration) bool {
+	return db.SetExpireAt(key, db.s.Now().Add(ttl))
+}
+
+// SetExpireAt sets the time key expires at, and tells whether the key is set.
+func (db *Database) SetExpireAt(key string, at time.Time) bool {
+	var found bool
+	db.store().Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		if !exists {
+			return entry, store.OpKeep
+		}
+		found = true
+		entry.ExpireAt = at
+		return entry, store.OpSet
+	})
+	return found
+}
+
+// TTL returns the time to live of key relatively to the clock of the server, 0 when it does not expire,
+// and whether the key is set.
+func (db *Database) TTL(key string) (time.Duration, bool) {
+	var (
+		ttl   time.Duration
+		found bool
+	)
+	db.store().Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		found = exists
+		if exists && !entry.ExpireAt.IsZero() {
+			ttl = entry.ExpireAt.Sub(db.s.Now())
+		}
+		return entry, store.OpKeep
+	})
+	return ttl, found
+}
+
+// Push appends values to the list at key, creating it when needed, and returns the length of the list.
+func (db *Database) Push(key string, values ...string) int {
+	var n int
+	db.compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op, error) {
+		l, ok, err := valueOf[*object.List](key, entry, exists)
+		if err != nil || len(values) == 0 {
+			if ok {
+				n = l.Len()
+			}
+			return entry, store.OpKeep, err
+		}
+		if !ok {
+			l = object.NewList()
+			entry = store.Entry{Value: l}
+		}
+		l.PushRight(values...)
+		n = l.Len()
+		return entry, store.OpSet, nil
+	})
+	return n
+}
+
+// List returns the elements of the list at key.
+func (db *Database) List(key string) []string {
+	var elements []string
+	db.compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op, error) {
+		l, ok, err := valueOf[*object.List](key, entry, exists)
+		if ok {
+			elements = l.Range(0, -1)
+		}
+		return entry, store.OpKeep, err
+	})
+	return elements
+}
+
+// HSet sets the fields of the hash at key, creating it when needed.
+func (db *Database) HSet(key string, fieldValues map[string]string) {
+	db.compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op, error) {
+		hash, ok, err := valueOf[*object.Hash](key, entry, exists)
+		if err != nil || len(fieldValues) == 0 {
+			return entry, store.OpKeep, err
+		}
+		if !ok {
+			hash = object.NewHash()
+			entry = store.Entry{Value: hash}
+		}
+		for field, value := range fieldValues {
+			hash.Set(field, value)
+		}
+		return entry, store.OpSet, nil
+	})
+}
+
+// HGet returns a field of the hash at key.
+func (db *Database) HGet(key, field string) (value string, ok bool) {
+	db.compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op, error) {
+		hash, isHash, err := valueOf[*object.Hash](key, entry, exists)
+		if isHash {
+			value, ok = hash.Get(field)
+		}
+		return entry, store.OpKeep, err
+	})
+	return value, ok
+}
+
+// Hash returns the fields of the hash at key.
+func (db *Database) Hash(key string) map[string]string {
+	var fields map[string]string
+	db.compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op, error) {
+		hash, ok, err := valueOf[*object.Hash](key, entry, exists)
+		if ok {
+			fields = make(map[string]string, hash.Len())
+			hash.Range(func(field, value string) bool {
+				fields[field] = value
+				return true
+			})
+		}
+		return entry, store.OpKeep, err
+	})
+	return fields
+}
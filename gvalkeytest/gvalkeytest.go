@@ -0,0 +1,174 @@
+// Package gvalkeytest runs a gvalkey server in-process for integration tests, without Docker or an external binary.
+//
+// the server listens on an ephemeral loopback port, its keys can be seeded and inspected directly, and its clock
+// only moves when told to, so that expirations are tested without waiting for them:
+//
+//	s := gvalkeytest.Run(t)
+//	s.Set("greeting", "hello")
+//	s.SetTTL("greeting", time.Minute)
+//	// ... run the code under test against s.Addr() ...
+//	s.FastForward(time.Minute)
+//	require.False(t, s.Exists("greeting"))
+package gvalkeytest
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+	"github.com/PlayerNeo42/gvalkey/server"
+)
+
+// Server is a server running for the duration of a test, it is closed by the cleanup of the test.
+// the methods of the embedded Database act on the database 0, see DB for the other ones.
+type Server struct {
+	*Database
+
+	t         testing.TB
+	srv       *server.Server
+	addr      string
+	host      string
+	port      int
+	databases int
+	clock     *clock.Fake
+
+	served    chan error
+	closeOnce sync.Once
+}
+
+type options struct {
+	start     time.Time
+	overrides []string
+	logger    *slog.Logger
+}
+
+type Option func(*options)
+
+// WithStartTime sets the time the clock of the server starts at, the current time by default.
+func WithStartTime(start time.Time) Option {
+	return func(o *options) {
+		o.start = start
+	}
+}
+
+// WithConfig sets configuration directives as name/value pairs, e.g. WithConfig("databases", "4").
+// the other settings keep their default, GVK_ environment variables being ignored so that tests do not depend on them.
+func WithConfig(nameValues ...string) Option {
+	return func(o *options) {
+		o.overrides = append(o.overrides, nameValues...)
+	}
+}
+
+// WithLogger sets the logger of the server, which logs nothing by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Run starts a server on an ephemeral loopback port, failing the test when it cannot.
+func Run(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	o := options{
+		start:  time.Now(),
+		logger: slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	conf, err := config.Load(config.WithoutEnv(), config.WithOverrides(o.overrides...))
+	if err != nil {
+		t.Fatalf("gvalkeytest: invalid configuration: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("gvalkeytest: listen failed: %v", err)
+	}
+
+	s := &Server{
+		t:         t,
+		addr:      listener.Addr().String(),
+		databases: conf.Databases,
+		clock:     clock.NewFake(o.start),
+		served:    make(chan error, 1),
+	}
+	host, port, _ := net.SplitHostPort(s.addr)
+	s.host = host
+	s.port, _ = strconv.Atoi(port)
+	s.srv = server.NewServer(s.addr,
+		server.WithConfig(config.NewRegistry(conf, "")),
+		server.WithClock(s.clock),
+		server.WithLogger(o.logger),
+	)
+	s.Database = s.DB(0)
+
+	go func() {
+		s.served <- s.srv.Serve(listener)
+	}()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Addr returns the address of the server, such as 127.0.0.1:40123.
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Host returns the IP address the server listens on.
+func (s *Server) Host() string {
+	return s.host
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.port
+}
+
+// Close closes the connections and stops the server, it is called by the cleanup of the test and may be called earlier.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		if err := s.srv.Close(); err != nil {
+			s.t.Errorf("gvalkeytest: close failed: %v", err)
+		}
+		if err := <-s.served; !errors.Is(err, server.ErrServerClosed) {
+			s.t.Errorf("gvalkeytest: serve failed: %v", err)
+		}
+	})
+}
+
+// DB returns the database numbered index, which must be below the databases setting, 16 by default.
+func (s *Server) DB(index int) *Database {
+	if index < 0 || index >= s.databases {
+		s.t.Fatalf("gvalkeytest: database %d out of range, the server has %d", index, s.databases)
+	}
+	return &Database{s: s, index: index}
+}
+
+// Now returns the time of the clock of the server.
+func (s *Server) Now() time.Time {
+	return s.clock.Now()
+}
+
+// FastForward moves the clock of the server forward by d, expiring the keys whose time to live is shorter.
+func (s *Server) FastForward(d time.Duration) {
+	s.clock.Advance(d)
+}
+
+// SetTime moves the clock of the server to now, which may be in the past.
+func (s *Server) SetTime(now time.Time) {
+	s.clock.Set(now)
+}
+
+// FlushAll removes the keys of every database.
+func (s *Server) FlushAll() {
+	for i := range s.databases {
+		s.srv.DB(i).Flush()
+	}
+}
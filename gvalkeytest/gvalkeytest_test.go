@@ -0,0 +1,393 @@
+package gvalkeytest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/client"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/rdb"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, s *Server, opts ...client.Option) *client.Client {
+	t.Helper()
+	c := client.New(s.Addr(), opts...)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestRun(t *testing.T) {
+	s := Run(t)
+	host, port, err := net.SplitHostPort(s.Addr())
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", s.Host())
+	require.Equal(t, host, s.Host())
+	require.Equal(t, port, strconv.Itoa(s.Port()))
+
+	c := newClient(t, s)
+	require.NoError(t, c.Set(t.Context(), "greeting", "hello", 0).Err())
+	value, ok := s.Get("greeting")
+	require.True(t, ok)
+	require.Equal(t, "hello", value)
+
+	s.Set("answer", "42")
+	got, err := c.Get(t.Context(), "answer").Result()
+	require.NoError(t, err)
+	require.Equal(t, "42", got)
+	require.Equal(t, []string{"answer", "greeting"}, s.Keys())
+}
+
+func TestRunIgnoresEnv(t *testing.T) {
+	t.Setenv("GVK_DATABASES", "2")
+	t.Setenv("GVK_MAXMEMORY", "1")
+
+	s := Run(t, WithConfig("timeout", "30"))
+	c := newClient(t, s)
+	got, err := c.ConfigGet(t.Context(), "databases").Result()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"databases": "16"}, got)
+	got, err = c.ConfigGet(t.Context(), "timeout").Result()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"timeout": "30"}, got)
+	require.NoError(t, c.Set(t.Context(), "k", "v", 0).Err(), "maxmemory should keep its default")
+}
+
+func TestClose(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s, client.WithMaxRetries(0))
+	require.NoError(t, c.Set(t.Context(), "k", "v", 0).Err())
+
+	s.Close()
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	require.Error(t, c.Get(ctx, "k").Err())
+
+	_, err := net.DialTimeout("tcp", s.Addr(), time.Second)
+	require.Error(t, err)
+
+	// closing again, as the cleanup does, is harmless
+	s.Close()
+}
+
+func TestDatabases(t *testing.T) {
+	s := Run(t, WithConfig("databases", "4"))
+	s.DB(2).Set("k", "two")
+	require.False(t, s.Exists("k"))
+
+	c := newClient(t, s, client.WithDB(2))
+	got, err := c.Get(t.Context(), "k").Result()
+	require.NoError(t, err)
+	require.Equal(t, "two", got)
+
+	// SWAPDB is followed
+	require.NoError(t, c.SwapDB(t.Context(), 0, 2).Err())
+	value, ok := s.Get("k")
+	require.True(t, ok)
+	require.Equal(t, "two", value)
+	require.Empty(t, s.DB(2).Keys())
+
+	s.FlushAll()
+	require.Empty(t, s.Keys())
+}
+
+func TestMove(t *testing.T) {
+	s := Run(t)
+	s.Set("k", "v")
+	clients := []*client.Client{newClient(t, s), newClient(t, s, client.WithDB(1))}
+
+	// MOVEs in opposite directions lock the key in the two databases in the same order, so they never deadlock
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				if err := c.Move(t.Context(), "k", 1-i).Err(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the key is never lost nor copied
+	require.Equal(t, 1, len(s.Keys())+len(s.DB(1).Keys()))
+}
+
+// sendNeverReading sends a command on a connection of its own, which never reads what the server sends.
+func sendNeverReading(t *testing.T, s *Server, args ...string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", s.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	cmd := make(resp.Array, len(args))
+	for i, arg := range args {
+		cmd[i] = resp.BulkString(arg)
+	}
+	encoded, err := resp.Encode(cmd)
+	require.NoError(t, err)
+	_, err = conn.Write(encoded)
+	require.NoError(t, err)
+}
+
+func TestSubscriberNeverReading(t *testing.T) {
+	s := Run(t, WithConfig("notify-keyspace-events", "KEA"))
+	c := newClient(t, s)
+	sendNeverReading(t, s, "PSUBSCRIBE", "*")
+	require.Eventually(t, func() bool {
+		n, err := c.Publish(t.Context(), "channel", "hello").Result()
+		return err == nil && n == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the keyspace events of the writes pile up for the subscriber rather than holding the writers back
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	prefix := strings.Repeat("k", 1024)
+	for i := range 5000 {
+		require.NoError(t, c.Set(ctx, prefix+strconv.Itoa(i), "v", 0).Err())
+	}
+	require.NoError(t, newClient(t, s).Get(ctx, prefix+"0").Err())
+
+	// until the subscriber goes over the output buffer limit and is disconnected
+	message := strings.Repeat("m", 1<<20)
+	require.Eventually(t, func() bool {
+		n, err := c.Publish(ctx, "channel", message).Result()
+		return err == nil && n == 0
+	}, 10*time.Second, time.Millisecond)
+}
+
+func TestMonitorNeverReading(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+	sendNeverReading(t, s, "MONITOR")
+
+	// the commands pile up for the monitor rather than holding the clients back, until it is disconnected
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	value := strings.Repeat("v", 1<<20)
+	for i := range 100 {
+		require.NoError(t, c.Set(ctx, "k"+strconv.Itoa(i), value, 0).Err())
+	}
+	require.NoError(t, newClient(t, s).Get(ctx, "k0").Err())
+}
+
+func TestFastForward(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := Run(t, WithStartTime(start))
+	require.Equal(t, start, s.Now())
+	c := newClient(t, s)
+
+	require.NoError(t, c.Set(t.Context(), "session", "token", time.Minute).Err())
+	ttl, ok := s.TTL("session")
+	require.True(t, ok)
+	require.Equal(t, time.Minute, ttl)
+
+	// like with Redis, keys live until their expiration time has passed
+	s.FastForward(time.Minute)
+	require.True(t, s.Exists("session"))
+	s.FastForward(time.Millisecond)
+	require.False(t, s.Exists("session"))
+	_, err := c.Get(t.Context(), "session").Result()
+	require.ErrorIs(t, err, client.ErrNil)
+
+	s.Set("seeded", "v")
+	require.True(t, s.SetTTL("seeded", time.Hour))
+	require.False(t, s.SetTTL("missing", time.Hour))
+	s.SetTime(start.Add(2 * time.Hour))
+	require.False(t, s.Exists("seeded"))
+
+	s.Set("persistent", "v")
+	ttl, ok = s.TTL("persistent")
+	require.True(t, ok)
+	require.Zero(t, ttl)
+	_, ok = s.TTL("missing")
+	require.False(t, ok)
+}
+
+func TestFastForwardStreams(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+	id, err := c.XAdd(t.Context(), &client.XAddArgs{Stream: "jobs", ID: "*", Values: []any{"task", "1"}}).Result()
+	require.NoError(t, err)
+	require.NoError(t, c.XGroupCreate(t.Context(), "jobs", "workers", "0").Err())
+	require.NoError(t, c.XReadGroup(t.Context(), &client.XReadGroupArgs{Group: "workers", Consumer: "alice", Streams: []string{"jobs", ">"}}).Err())
+
+	// pending entries become idle with the clock of the server
+	claim := &client.XClaimArgs{Stream: "jobs", Group: "workers", Consumer: "bob", MinIdle: time.Minute, Messages: []string{id}}
+	claimed, err := c.XClaimJustID(t.Context(), claim).Result()
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+
+	s.FastForward(time.Minute)
+	pending, err := c.XPendingExt(t.Context(), &client.XPendingExtArgs{Stream: "jobs", Group: "workers", Start: "-", End: "+", Count: 10}).Result()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, time.Minute, pending[0].Idle)
+	claimed, err = c.XClaimJustID(t.Context(), claim).Result()
+	require.NoError(t, err)
+	require.Equal(t, []string{id}, claimed)
+}
+
+func TestListsAndHashes(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+
+	require.Equal(t, 2, s.Push("queue", "a", "b"))
+	require.NoError(t, c.RPush(t.Context(), "queue", "c").Err())
+	require.Equal(t, []string{"a", "b", "c"}, s.List("queue"))
+	require.Equal(t, "list", s.Type("queue"))
+	require.Nil(t, s.List("missing"))
+
+	s.HSet("user", map[string]string{"name": "ada"})
+	require.NoError(t, c.HSet(t.Context(), "user", "lang", "go").Err())
+	require.Equal(t, map[string]string{"name": "ada", "lang": "go"}, s.Hash("user"))
+	field, ok := s.HGet("user", "lang")
+	require.True(t, ok)
+	require.Equal(t, "go", field)
+	require.Equal(t, "hash", s.Type("user"))
+
+	require.True(t, s.Del("user"))
+	require.Equal(t, "none", s.Type("user"))
+	require.False(t, s.Del("user"))
+}
+
+func TestWrongType(t *testing.T) {
+	s := Run(t)
+	s.Push("queue", "a")
+
+	// the test fails rather than the key being read as another type
+	ft := &fakeT{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		(&Database{s: &Server{t: ft, srv: s.srv}, index: 0}).Get("queue")
+	}()
+	<-done
+	require.Equal(t, `gvalkeytest: key "queue" holds a list, not a string`, ft.fatal)
+	require.Equal(t, []string{"a"}, s.List("queue"))
+}
+
+// fakeT records the failure of a helper instead of failing the test.
+type fakeT struct {
+	testing.TB
+	fatal string
+}
+
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestScan(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+	want := make([]string, 100)
+	for i := range want {
+		want[i] = "key:" + strconv.Itoa(i)
+		s.Set(want[i], strconv.Itoa(i))
+	}
+	s.Push("queue", "a")
+
+	// every key is returned once, whatever the number of calls
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := c.Do(t.Context(), "SCAN", cursor, "MATCH", "key:*", "COUNT", "7").Result()
+		require.NoError(t, err)
+		page, ok := reply.([]any)
+		require.True(t, ok)
+		require.Len(t, page, 2)
+		for _, key := range page[1].([]any) {
+			keys = append(keys, key.(string))
+		}
+		cursor = page[0].(string)
+		if cursor == "0" {
+			break
+		}
+	}
+	slices.Sort(keys)
+	slices.Sort(want)
+	require.Equal(t, want, keys)
+
+	reply, err := c.Do(t.Context(), "SCAN", "0", "TYPE", "list", "COUNT", "1000").Result()
+	require.NoError(t, err)
+	require.Equal(t, []any{"0", []any{"queue"}}, reply)
+
+	typ, err := c.Do(t.Context(), "TYPE", "queue").Result()
+	require.NoError(t, err)
+	require.Equal(t, "list", typ)
+	length, err := c.Do(t.Context(), "STRLEN", "key:42").Result()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), length)
+	require.ErrorContains(t, c.Do(t.Context(), "STRLEN", "queue").Err(), "WRONGTYPE")
+}
+
+func TestCommandDocs(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+
+	// every command is documented, the undocumented ones being left out of COMMAND DOCS
+	count, err := c.Do(t.Context(), "COMMAND", "COUNT").Result()
+	require.NoError(t, err)
+	docs, err := c.Do(t.Context(), "COMMAND", "DOCS").Result()
+	require.NoError(t, err)
+	require.Equal(t, count, int64(len(docs.([]any))/2))
+
+	docs, err = c.Do(t.Context(), "COMMAND", "DOCS", "GET", "nosuchcommand").Result()
+	require.NoError(t, err)
+	require.Equal(t, []any{"get", []any{"arguments", []any{[]any{"name", "key", "type", "key"}}}}, docs)
+
+	docs, err = c.Do(t.Context(), "COMMAND", "DOCS", "client").Result()
+	require.NoError(t, err)
+	require.Equal(t, []any{"client", []any{"subcommands", []any{
+		"client|getname", []any{},
+		"client|setname", []any{"arguments", []any{[]any{"name", "connection-name", "type", "string"}}},
+	}}}, docs)
+}
+
+func TestSaveRules(t *testing.T) {
+	s := Run(t)
+	c := newClient(t, s)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "dump.rdb")
+
+	// the rules set live apply to the writes that follow
+	require.NoError(t, c.ConfigSet(t.Context(), "dir", dir).Err())
+	require.NoError(t, c.ConfigSet(t.Context(), "save", "60 2").Err())
+	require.NoError(t, c.Set(t.Context(), "a", "1", 0).Err())
+	s.FastForward(time.Minute)
+	require.Never(t, func() bool {
+		_, err := os.Stat(file)
+		return err == nil
+	}, 300*time.Millisecond, 10*time.Millisecond)
+
+	require.NoError(t, c.Set(t.Context(), "b", "2", 0).Err())
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(file)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	snapshot, err := os.Open(file)
+	require.NoError(t, err)
+	defer snapshot.Close()
+	keys := map[string]any{}
+	require.NoError(t, rdb.Load(snapshot, func(db int, key string, entry store.Entry) error {
+		keys[key] = entry.Value
+		return nil
+	}))
+	require.Equal(t, map[string]any{"a": resp.BulkString("1"), "b": resp.BulkString("2")}, keys)
+}
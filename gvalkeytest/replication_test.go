@@ -0,0 +1,130 @@
+package gvalkeytest
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// linkProxy forwards the connections it accepts to a server, so that a test can cut the link of a replica.
+type linkProxy struct {
+	listener net.Listener
+	target   string
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newLinkProxy(t *testing.T, target string) *linkProxy {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	p := &linkProxy{listener: listener, target: target}
+	t.Cleanup(func() {
+		_ = listener.Close()
+		p.cut()
+	})
+	go p.serve()
+	return p
+}
+
+func (p *linkProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		upstream, err := net.Dial("tcp", p.target)
+		if err != nil {
+			_ = conn.Close()
+			continue
+		}
+		p.mu.Lock()
+		p.conns = append(p.conns, conn, upstream)
+		p.mu.Unlock()
+		go pipe(conn, upstream)
+		go pipe(upstream, conn)
+	}
+}
+
+func pipe(dst, src net.Conn) {
+	_, _ = io.Copy(dst, src)
+	_ = dst.Close()
+	_ = src.Close()
+}
+
+func (p *linkProxy) hostPort() (string, string) {
+	host, port, _ := net.SplitHostPort(p.listener.Addr().String())
+	return host, port
+}
+
+// cut closes the connections forwarded so far, as a network failure would.
+func (p *linkProxy) cut() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.conns {
+		_ = conn.Close()
+	}
+	p.conns = nil
+}
+
+func TestReplication(t *testing.T) {
+	master := Run(t)
+	replica := Run(t)
+	mc := newClient(t, master)
+	rc := newClient(t, replica)
+	proxy := newLinkProxy(t, master.Addr())
+
+	// keys written before REPLICAOF reach the replica with the snapshot of the full synchronization
+	master.Set("seeded", "before")
+	require.NoError(t, mc.HSet(t.Context(), "hash", "field", "value").Err())
+	replica.Set("stale", "dropped")
+	host, port := proxy.hostPort()
+	require.NoError(t, rc.ReplicaOf(t.Context(), host, port).Err())
+	require.Eventually(t, func() bool {
+		value, _ := replica.Get("seeded")
+		return value == "before"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, map[string]string{"field": "value"}, replica.Hash("hash"))
+	require.False(t, replica.Exists("stale"))
+
+	// writes on the master are propagated
+	require.NoError(t, mc.RPush(t.Context(), "list", "a", "b").Err())
+	require.NoError(t, mc.Del(t.Context(), "seeded").Err())
+	require.NoError(t, mc.Set(t.Context(), "after", "1", 0).Err())
+	require.Eventually(t, func() bool {
+		value, _ := replica.Get("after")
+		return value == "1"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"a", "b"}, replica.List("list"))
+	require.False(t, replica.Exists("seeded"))
+
+	// the replica serves reads but rejects writes from clients
+	got, err := rc.Get(t.Context(), "after").Result()
+	require.NoError(t, err)
+	require.Equal(t, "1", got)
+	require.ErrorContains(t, rc.Set(t.Context(), "after", "2", 0).Err(), "READONLY")
+	value, _ := master.Get("after")
+	require.Equal(t, "1", value)
+
+	// once the link drops, the replica reconnects and resumes from the backlog without a new snapshot
+	proxy.cut()
+	for i := range 10 {
+		require.NoError(t, mc.Set(t.Context(), "while-down", strconv.Itoa(i), 0).Err())
+	}
+	require.Eventually(t, func() bool {
+		value, _ := replica.Get("while-down")
+		return value == "9"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	info, err := mc.Info(t.Context(), "stats").Result()
+	require.NoError(t, err)
+	require.Contains(t, info, "sync_full:1\r\n")
+	require.Contains(t, info, "sync_partial_ok:1\r\n")
+	require.Contains(t, info, "sync_partial_err:0\r\n")
+}
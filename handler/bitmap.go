@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"github.com/PlayerNeo42/gvalkey/internal/bitmap"
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+// stringOf returns the raw bytes of the string value, a missing key is reported as an empty string.
+func stringOf(value any, exists bool) (string, error) {
+	if !exists || value == nil {
+		return "", nil
+	}
+	s, ok := value.(resp.BulkString)
+	if !ok {
+		return "", errWrongType
+	}
+	return string(s), nil
+}
+
+// getString returns the string stored at key, strings are immutable so they are read without locking the key.
+func (h *Handler) getString(c *Client, key string) (string, bool, error) {
+	value, exists := h.db(c).Get(key)
+	s, err := stringOf(value, exists)
+	return s, exists, err
+}
+
+func (h *Handler) handleSetBit(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := resp.ParseBitOffset(args[2])
+	if err != nil {
+		return nil, err
+	}
+	bit, err := resp.ParseBit(args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	var prev int
+	h.db(c).Compute(key.String(), func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var s string
+		s, err = stringOf(entry.Value, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		// stored strings are shared with readers, so the bits are changed on a copy
+		var b []byte
+		b, prev = bitmap.SetBit([]byte(s), offset, bit)
+		entry.Value = resp.BulkString(b)
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.notifyKeyspaceEvent(pubsub.ClassString, "setbit", key.String(), c.db)
+	return resp.Integer(prev), nil
+}
+
+func (h *Handler) handleGetBit(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := resp.ParseBitOffset(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	s, _, err := h.getString(c, key.String())
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(bitmap.GetBit(s, offset)), nil
+}
+
+func (h *Handler) handleBitCount(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	bitRange, err := resp.ParseBitRangeArgs(args[2:], true)
+	if err != nil {
+		return nil, err
+	}
+
+	s, _, err := h.getString(c, key.String())
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(bitmap.Count(s, bitRange.Start, bitRange.End, bitRange.BitUnit)), nil
+}
+
+func (h *Handler) handleBitPos(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	bit, err := resp.ParseBit(args[2])
+	if err != nil {
+		return nil, resp.NewSimpleError("The bit argument must be 1 or 0.")
+	}
+	bitRange, err := resp.ParseBitRangeArgs(args[3:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	s, exists, err := h.getString(c, key.String())
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		// a missing key is an endless run of clear bits
+		if bit == 1 {
+			return resp.Integer(-1), nil
+		}
+		return resp.Integer(0), nil
+	}
+	return resp.Integer(bitmap.Pos(s, bit, bitRange.Start, bitRange.End, bitRange.EndGiven, bitRange.BitUnit)), nil
+}
+
+func (h *Handler) handleBitOp(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	op, dest, keys := resp.BulkString(strs[0]).Upper(), strs[1], strs[2:]
+
+	srcs := make([]string, len(keys))
+	for i, key := range keys {
+		if srcs[i], _, err = h.getString(c, key); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []byte
+	switch op {
+	case resp.AND:
+		result = bitmap.And(srcs)
+	case resp.OR:
+		result = bitmap.Or(srcs)
+	case resp.XOR:
+		result = bitmap.Xor(srcs)
+	case resp.NOT:
+		if len(srcs) != 1 {
+			return nil, resp.NewSimpleError("BITOP NOT must be called with a single source key.")
+		}
+		result = bitmap.Not(srcs[0])
+	default:
+		return nil, errSyntax
+	}
+
+	// like Redis, an empty result removes the destination instead of storing an empty string
+	if len(result) == 0 {
+		if h.db(c).Del(dest) {
+			h.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", dest, c.db)
+		}
+		return resp.Integer(0), nil
+	}
+
+	h.db(c).Set(resp.SetArgs{Key: resp.BulkString(dest), Value: resp.BulkString(result)})
+	h.notifyKeyspaceEvent(pubsub.ClassString, "set", dest, c.db)
+	return resp.Integer(len(result)), nil
+}
+
+func (h *Handler) handleBitField(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.bitField(c, args, false)
+}
+
+func (h *Handler) handleBitFieldRO(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.bitField(c, args, true)
+}
+
+func (h *Handler) bitField(c *Client, args resp.Array, readOnly bool) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := resp.ParseBitFieldArgs(args, readOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	// the string is only padded to the end of the fields that are written
+	var end uint64
+	for _, op := range ops {
+		if op.Kind != resp.GET {
+			end = max(end, bitFieldOf(op).End())
+		}
+	}
+	if end == 0 {
+		s, _, err := h.getString(c, key.String())
+		if err != nil {
+			return nil, err
+		}
+		result, _ := applyBitField([]byte(s), ops)
+		return result, nil
+	}
+
+	var result resp.Array
+	written := false
+	h.db(c).Compute(key.String(), func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var s string
+		s, err = stringOf(entry.Value, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		b := bitmap.Grow([]byte(s), end)
+		result, written = applyBitField(b, ops)
+		entry.Value = resp.BulkString(b)
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if written {
+		h.notifyKeyspaceEvent(pubsub.ClassString, "setbit", key.String(), c.db)
+	}
+	return result, nil
+}
+
+func bitFieldOf(op resp.BitFieldOp) bitmap.Field {
+	return bitmap.Field{Signed: op.Signed, Bits: op.Bits, Offset: op.Offset}
+}
+
+// applyBitField runs ops against b, which is already large enough for the writes, and reports whether any field was written.
+func applyBitField(b []byte, ops []resp.BitFieldOp) (resp.Array, bool) {
+	result := make(resp.Array, 0, len(ops))
+	written := false
+	for _, op := range ops {
+		field := bitFieldOf(op)
+		current := field.Get(b)
+
+		var value int64
+		switch op.Kind {
+		case resp.GET:
+			result = append(result, resp.Integer(current))
+			continue
+		case resp.SET:
+			value = op.Value
+		default:
+			value = current
+		}
+		incr := int64(0)
+		if op.Kind == resp.INCRBY {
+			incr = op.Value
+		}
+
+		next, ok := field.Add(value, incr, overflowOf(op.Overflow))
+		if !ok {
+			result = append(result, resp.NULL)
+			continue
+		}
+		b = field.Set(b, next)
+		written = true
+
+		// SET replies the previous value, INCRBY the new one
+		if op.Kind == resp.SET {
+			result = append(result, resp.Integer(current))
+		} else {
+			result = append(result, resp.Integer(next))
+		}
+	}
+	return result, written
+}
+
+func overflowOf(overflow resp.BulkString) bitmap.Overflow {
+	switch overflow {
+	case resp.SAT:
+		return bitmap.Sat
+	case resp.FAIL:
+		return bitmap.Fail
+	default:
+		return bitmap.Wrap
+	}
+}
@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// blockedClient is a client of a blocking command waiting for one of its keys to be able to serve it.
+type blockedClient struct {
+	db   int
+	keys []string
+
+	// try attempts to serve the client, it reports false when none of the keys can serve it yet.
+	// it is always called with the registry lock held, so it must not signal keys itself.
+	try func() (resp.Payload, bool, error)
+
+	// receives the reply once the client is served, buffered so that serving never blocks
+	result chan blockResult
+
+	// whether the client is still in the queues of its keys, guarded by the registry lock
+	queued bool
+
+	// whether try must run exclusively of every other command, as the command did when it blocked
+	exclusive bool
+}
+
+type blockResult struct {
+	payload resp.Payload
+	err     error
+}
+
+type blockKey struct {
+	db  int
+	key string
+}
+
+// blockingRegistry queues the clients blocked on each key, they are served in the order they blocked.
+type blockingRegistry struct {
+	mu      sync.Mutex
+	waiting map[blockKey][]*blockedClient
+}
+
+func newBlockingRegistry() *blockingRegistry {
+	return &blockingRegistry{waiting: make(map[blockKey][]*blockedClient)}
+}
+
+// tryOrQueue serves b right away when possible, otherwise it queues b on its keys and reports false.
+// both happen under the lock, so that a push between the attempt and the queuing cannot be missed.
+func (r *blockingRegistry) tryOrQueue(b *blockedClient) (blockResult, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payload, ok, err := b.try(); ok || err != nil {
+		return blockResult{payload, err}, true
+	}
+
+	for _, key := range b.keys {
+		k := blockKey{b.db, key}
+		r.waiting[k] = append(r.waiting[k], b)
+	}
+	b.queued = true
+	return blockResult{}, false
+}
+
+// signal serves the clients blocked on key in FIFO order.
+// clients the key cannot serve keep waiting without holding back the others,
+// as a stream entry may serve a reader of the whole stream and not one waiting for later IDs.
+func (r *blockingRegistry) signal(db int, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range slices.Clone(r.waiting[blockKey{db, key}]) {
+		payload, ok, err := b.try()
+		if !ok && err == nil {
+			continue
+		}
+		r.dequeue(b)
+		b.result <- blockResult{payload, err}
+	}
+}
+
+// try attempts to serve b once, without queuing it when it cannot be served yet.
+func (r *blockingRegistry) try(b *blockedClient) (resp.Payload, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return b.try()
+}
+
+// cancel removes a client that timed out or went away, it reports false when the client was served in the meantime.
+func (r *blockingRegistry) cancel(b *blockedClient) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !b.queued {
+		return false
+	}
+	r.dequeue(b)
+	return true
+}
+
+// keysOf returns the keys of database db that clients are blocked on.
+func (r *blockingRegistry) keysOf(db int) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []string
+	for k := range r.waiting {
+		if k.db == db {
+			keys = append(keys, k.key)
+		}
+	}
+	return keys
+}
+
+// needsExclusive reports whether some client blocked on keys must be served under the exclusive execution lock.
+func (r *blockingRegistry) needsExclusive(keys []blockKey) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range keys {
+		for _, b := range r.waiting[k] {
+			if b.exclusive {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// len returns the number of blocked clients.
+func (r *blockingRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clients := make(map[*blockedClient]struct{})
+	for _, queue := range r.waiting {
+		for _, b := range queue {
+			clients[b] = struct{}{}
+		}
+	}
+	return len(clients)
+}
+
+// dequeue removes b from the queues of its keys, the lock must be held.
+func (r *blockingRegistry) dequeue(b *blockedClient) {
+	for _, key := range b.keys {
+		k := blockKey{b.db, key}
+		queue := r.waiting[k]
+		for i, other := range queue {
+			if other == b {
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+		if len(queue) == 0 {
+			delete(r.waiting, k)
+		} else {
+			r.waiting[k] = queue
+		}
+	}
+	b.queued = false
+}
+
+// block runs try and, until it succeeds, parks the client on keys for at most timeout, 0 meaning forever.
+// it returns a null array when the timeout expires or the client disconnects.
+// only the goroutine of the client is parked, other clients keep being served.
+// scripts and masters cannot wait, their blocking commands time out right away like in Redis.
+//
+// a write command is propagated to replicas when it is served rather than when it returns,
+// so that replicas see it right after the command that served it.
+func (h *Handler) block(c *Client, keys []string, timeout time.Duration, try func() (resp.Payload, bool, error)) (resp.Payload, error) {
+	b := &blockedClient{
+		db:        c.db,
+		keys:      keys,
+		try:       try,
+		result:    make(chan blockResult, 1),
+		exclusive: c.exclusive,
+	}
+	if c.script || c.master {
+		payload, ok, err := h.blocking.try(b)
+		if !ok && err == nil {
+			return resp.NullArray{}, nil
+		}
+		return payload, err
+	}
+
+	if c.writes {
+		db, args := c.db, c.args
+		b.try = func() (resp.Payload, bool, error) {
+			payload, ok, err := try()
+			if ok && err == nil {
+				h.propagate(db, args)
+			}
+			return payload, ok, err
+		}
+		c.propagated = true
+	}
+
+	if res, done := h.blocking.tryOrQueue(b); done {
+		return res.payload, res.err
+	}
+
+	// the time parked, up to holding the lock again, is left out of the execution time of the command
+	parkedAt := time.Now()
+	defer func() { c.parked += time.Since(parkedAt) }()
+
+	// exclusive commands must not wait for parked clients, which run again once served
+	if c.exclusive {
+		h.execLock.Unlock()
+		defer h.execLock.Lock()
+	} else {
+		h.execLock.RUnlock()
+		defer h.execLock.RLock()
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	disconnected, stopWatching := c.watchDisconnect()
+	defer stopWatching()
+
+	select {
+	case res := <-b.result:
+		return res.payload, res.err
+	case <-expired:
+	case <-disconnected:
+	}
+
+	if h.blocking.cancel(b) {
+		return resp.NullArray{}, nil
+	}
+	// the client was served while timing out
+	res := <-b.result
+	return res.payload, res.err
+}
+
+// signalKeyReady records that a key may have received elements, the clients blocked on it are served
+// once the command of c is done and propagated, so that replicas see the command before the ones it serves.
+func (h *Handler) signalKeyReady(c *Client, db int, key string) {
+	c.readyKeys = append(c.readyKeys, blockKey{db, key})
+}
+
+// serveReadyKeys serves the clients blocked on the keys signaled by the command of c.
+// clients of exclusive commands, such as BLMOVE moving elements between lists, are served under the exclusive lock,
+// which a command holding the shared lock takes for the time of serving them.
+func (h *Handler) serveReadyKeys(c *Client) {
+	if len(c.readyKeys) == 0 {
+		return
+	}
+	if !c.exclusive && !c.script && h.blocking.needsExclusive(c.readyKeys) {
+		h.execLock.RUnlock()
+		h.execLock.Lock()
+		defer func() {
+			h.execLock.Unlock()
+			h.execLock.RLock()
+		}()
+	}
+	for _, k := range c.readyKeys {
+		h.blocking.signal(k.db, k.key)
+	}
+	c.readyKeys = nil
+}
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// Client holds the state of one connection.
+type Client struct {
+	conn   net.Conn
+	parser *resp.Parser
+
+	// serializes writes, messages published by other clients are written from their goroutines
+	mu sync.Mutex
+	// encodes the replies, its buffer being reused from one reply to the next
+	enc resp.Encoder
+	// set once the client subscribes or monitors, its writes are then queued, see queueWrites
+	out *outbound
+
+	// index of the database selected with SELECT
+	db int
+	// name set with CLIENT SETNAME
+	name string
+
+	// channels and patterns the client is subscribed to, only accessed from the connection goroutine
+	channels map[string]struct{}
+	patterns map[string]struct{}
+
+	// set on the clients scripts run their commands with, readOnly forbidding writes as EVAL_RO does
+	script   bool
+	readOnly bool
+
+	// set on the client applying the replication stream of the master, it is never parked and may write on read-only replicas
+	master bool
+	// set once the client is a replica synchronized with PSYNC
+	replica *replicaConn
+	// port the replica listens on, as told by REPLCONF listening-port
+	listeningPort int
+
+	// the command running and whether it writes, recorded by dispatch so that it can be propagated to replicas.
+	// handlers may rewrite args so that replicas get the same result, and blocking commands propagate themselves once served.
+	args       resp.Array
+	writes     bool
+	propagated bool
+	// whether the command holds the execution lock exclusively
+	exclusive bool
+	// time the command spent parked by block, which is not part of its execution time
+	parked time.Duration
+	// keys signaled by the command, see signalKeyReady
+	readyKeys []blockKey
+
+	// set by ASKING, the next command may access the keys of a slot the cluster node imports
+	asking bool
+}
+
+func newClient(conn net.Conn) *Client {
+	return &Client{
+		conn:     conn,
+		parser:   resp.NewParser(conn),
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+	}
+}
+
+// newMasterClient returns the client applying the stream of a master, read from br which may hold the first commands already.
+func newMasterClient(conn net.Conn, br *bufio.Reader, db int) *Client {
+	// bufio.NewReader returns br itself, so nothing it buffered is lost
+	return &Client{
+		conn:     conn,
+		parser:   resp.NewParser(br),
+		db:       db,
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+		master:   true,
+	}
+}
+
+// scriptClient returns the client the script of c runs its commands with.
+// it starts on the database of c, a SELECT by the script does not affect c.
+func (c *Client) scriptClient(readOnly bool) *Client {
+	return &Client{
+		conn:     c.conn,
+		db:       c.db,
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+		script:   true,
+		readOnly: readOnly,
+	}
+}
+
+// RemoteAddr returns the address of the client as a string.
+func (c *Client) RemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
+
+// outboundLimit is how many bytes may wait to be written to a client whose writes are queued before it is disconnected,
+// as the hard limit of Redis for pub/sub clients.
+const outboundLimit = 32 << 20
+
+var errOutboundOverflow = errors.New("client output buffer limit reached")
+
+// outbound is the state of the queued writes of a client, the queue being the buffer of its encoder.
+type outbound struct {
+	// signaled when something was queued
+	ready chan struct{}
+	// set once the queue went over outboundLimit, guarded by the client lock
+	overflowed bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Deliver queues a published message to the client, it never blocks.
+func (c *Client) Deliver(msg resp.Payload) {
+	// a client over the limit is disconnected, nothing else to do here
+	_ = c.write(msg)
+}
+
+// write sends p, nothing being sent when it cannot be encoded.
+// once the writes are queued, it only queues p, and disconnects the client when the queue grows over outboundLimit.
+func (c *Client) write(p resp.Payload) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.out != nil && c.out.overflowed {
+		return errOutboundOverflow
+	}
+	if err := c.enc.Encode(p); err != nil {
+		return err
+	}
+	if c.out == nil {
+		_, err := c.enc.WriteTo(c.conn)
+		return err
+	}
+
+	if c.enc.Buffered() > outboundLimit {
+		c.out.overflowed = true
+		c.enc.Reset()
+		// reading fails from now on, which ends the client
+		_ = c.conn.Close()
+		return errOutboundOverflow
+	}
+	select {
+	case c.out.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// queueWrites makes the writes to c go through a queue sent by a goroutine of its own, so that the clients
+// publishing to a subscriber or a monitor never wait for it to read. replies are queued too, which keeps them in order.
+func (c *Client) queueWrites() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.out != nil {
+		return
+	}
+	c.out = &outbound{
+		ready: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go c.flushQueue(c.out)
+}
+
+// flushQueue writes what is queued to c until stopQueue is called.
+func (c *Client) flushQueue(out *outbound) {
+	defer close(out.done)
+
+	var flushing resp.Encoder
+	for {
+		select {
+		case <-out.stop:
+			return
+		case <-out.ready:
+		}
+
+		// the queue is swapped with the empty buffer of the last write, so that writes go on queuing meanwhile
+		c.mu.Lock()
+		c.enc, flushing = flushing, c.enc
+		c.mu.Unlock()
+
+		if _, err := flushing.WriteTo(c.conn); err != nil {
+			// reading fails too once the connection is closed, which ends the client
+			_ = c.conn.Close()
+			return
+		}
+	}
+}
+
+// stopQueue stops the goroutine sending the queued writes of a client that is going away, the rest being dropped.
+func (c *Client) stopQueue() {
+	c.mu.Lock()
+	out := c.out
+	c.mu.Unlock()
+
+	if out == nil {
+		return
+	}
+	// the connection is closed first, so that a write the client does not read fails
+	_ = c.conn.Close()
+	close(out.stop)
+	<-out.done
+}
+
+// overflowed reports whether the client was disconnected for going over outboundLimit.
+func (c *Client) overflowed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.out != nil && c.out.overflowed
+}
+
+// subscriptions returns the number of channels and patterns the client is subscribed to.
+func (c *Client) subscriptions() int {
+	return len(c.channels) + len(c.patterns)
+}
+
+// watchDisconnect watches the connection while the client is parked by a blocking command and not reading commands.
+// the returned channel is closed if the client goes away, and stop must be called before reading commands again.
+func (c *Client) watchDisconnect() (<-chan struct{}, func()) {
+	disconnected := make(chan struct{})
+	exited := make(chan struct{})
+
+	// blocked clients are not subject to the idle timeout
+	_ = c.conn.SetReadDeadline(time.Time{})
+
+	go func() {
+		defer close(exited)
+		// a pipelined command does not end the wait, it stays buffered until the client is unblocked
+		err := c.parser.WaitInput()
+		var netErr net.Error
+		if err != nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+			close(disconnected)
+		}
+	}()
+
+	stop := func() {
+		// interrupt the pending read, the deadline is reset before the next command is parsed
+		_ = c.conn.SetReadDeadline(time.Now())
+		<-exited
+	}
+	return disconnected, stop
+}
+
+// handlePing replies PONG, or the message it is given. subscribed clients get both as an array, like with Redis.
+func (h *Handler) handlePing(c *Client, args resp.Array) (resp.Payload, error) {
+	if len(args) > 2 {
+		return nil, errors.New("wrong number of arguments for 'ping' command")
+	}
+	var message resp.BulkString
+	if len(args) == 2 {
+		var ok bool
+		if message, ok = args[1].(resp.BulkString); !ok {
+			return nil, errors.New("message is not a bulk string")
+		}
+	}
+
+	switch {
+	case c.subscriptions() > 0:
+		return resp.Array{resp.BulkString("pong"), message}, nil
+	case len(args) == 2:
+		return message, nil
+	default:
+		return resp.PONG, nil
+	}
+}
+
+func (h *Handler) handleClient(c *Client, args resp.Array) (resp.Payload, error) {
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch subcommand {
+	case resp.SETNAME:
+		if len(args) != 3 {
+			return nil, fmt.Errorf("wrong number of arguments for 'client|%s' command", subcommand)
+		}
+		name, err := resp.ParseClientName(args[2])
+		if err != nil {
+			return nil, err
+		}
+		c.name = name
+		return resp.OK, nil
+	case resp.GETNAME:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("wrong number of arguments for 'client|%s' command", subcommand)
+		}
+		if c.name == "" {
+			return resp.NULL, nil
+		}
+		return resp.BulkString(c.name), nil
+	default:
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+}
@@ -0,0 +1,427 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/cluster"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+var (
+	errClusterDisabled = resp.NewSimpleError("This instance has cluster support disabled")
+	errCrossSlot       = resp.NewPrefixedError("CROSSSLOT", "Keys in request don't hash to the same slot")
+	errTryAgain        = resp.NewPrefixedError("TRYAGAIN", "Multiple keys request during rehashing of slot")
+	errSlotNotServed   = resp.NewPrefixedError("CLUSTERDOWN", "Hash slot not served")
+	errSelectInCluster = resp.NewSimpleError("SELECT is not allowed in cluster mode")
+)
+
+// clusterArity is the number of arguments of the CLUSTER subcommands, CLUSTER included, negative values being minimums.
+var clusterArity = map[resp.BulkString]int{
+	resp.INFO:            2,
+	resp.MYID:            2,
+	resp.NODES:           2,
+	resp.SLOTS:           2,
+	resp.SHARDS:          2,
+	resp.KEYSLOT:         3,
+	resp.COUNTKEYSINSLOT: 3,
+	resp.GETKEYSINSLOT:   4,
+	resp.ADDSLOTS:        -3,
+	resp.ADDSLOTSRANGE:   -4,
+	resp.DELSLOTS:        -3,
+	resp.DELSLOTSRANGE:   -4,
+	resp.SETSLOT:         -4,
+	resp.MEET:            -4,
+	resp.FORGET:          3,
+}
+
+// route checks that the node serves the keys of cmd, and returns the redirection of the client otherwise.
+// during the migration of a slot, the keys already moved are served by the target node
+// to the clients that sent ASKING right before.
+func (h *Handler) route(c *Client, cmd *Command, args resp.Array, asking bool) error {
+	keysOf, ok := commandKeys[cmd.Name]
+	if !ok {
+		return nil
+	}
+	keys := keysOf(args)
+	if len(keys) == 0 {
+		return nil
+	}
+	slot := cluster.KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if cluster.KeySlot(key) != slot {
+			return errCrossSlot
+		}
+	}
+
+	owner, migrating, importing := h.cluster.Route(slot)
+	switch {
+	case owner == h.cluster.Myself() && migrating == nil:
+		return nil
+	case owner == h.cluster.Myself():
+		switch h.missingKeys(c, keys) {
+		case 0:
+			return nil
+		case len(keys):
+			return h.redirection(c, "ASK", slot, migrating)
+		default:
+			return errTryAgain
+		}
+	case importing != nil && (asking || cmd.Name == resp.RESTOREASKING):
+		if len(keys) > 1 && h.missingKeys(c, keys) > 0 {
+			return errTryAgain
+		}
+		return nil
+	case owner == nil:
+		return errSlotNotServed
+	default:
+		return h.redirection(c, "MOVED", slot, owner)
+	}
+}
+
+func (h *Handler) missingKeys(c *Client, keys []string) int {
+	missing := 0
+	for _, key := range keys {
+		if _, ok := h.db(c).Get(key); !ok {
+			missing++
+		}
+	}
+	return missing
+}
+
+// redirection returns the MOVED or ASK error sending the client to node for slot.
+func (h *Handler) redirection(c *Client, kind string, slot int, node *cluster.Node) error {
+	return resp.NewPrefixedError(kind, fmt.Sprintf("%d %s", slot, net.JoinHostPort(h.nodeHost(c, node), strconv.Itoa(node.Port))))
+}
+
+// nodeHost returns the IP address c reaches node at.
+// the local node is announced with cluster-announce-ip, or else with the address c connected to.
+func (h *Handler) nodeHost(c *Client, node *cluster.Node) string {
+	if node != h.cluster.Myself() {
+		return node.Host
+	}
+	if ip := h.config.Config().ClusterAnnounceIP; ip != "" {
+		return ip
+	}
+	if addr, ok := c.conn.LocalAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return "127.0.0.1"
+}
+
+func (h *Handler) handleAsking(c *Client, args resp.Array) (resp.Payload, error) {
+	if h.cluster == nil {
+		return nil, errClusterDisabled
+	}
+	c.asking = true
+	return resp.OK, nil
+}
+
+func (h *Handler) handleCluster(c *Client, args resp.Array) (resp.Payload, error) {
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+	if h.cluster == nil {
+		return nil, errClusterDisabled
+	}
+	arity, ok := clusterArity[subcommand]
+	if !ok {
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+	if (arity > 0 && len(args) != arity) || (arity < 0 && len(args) < -arity) {
+		return nil, fmt.Errorf("wrong number of arguments for 'cluster|%s' command", strings.ToLower(string(subcommand)))
+	}
+
+	switch subcommand {
+	case resp.INFO:
+		return h.clusterInfo(), nil
+	case resp.MYID:
+		return resp.BulkString(h.cluster.Myself().ID), nil
+	case resp.NODES:
+		return h.clusterNodes(c), nil
+	case resp.SLOTS:
+		return h.clusterSlots(c), nil
+	case resp.SHARDS:
+		return h.clusterShards(c), nil
+	case resp.KEYSLOT:
+		key, err := resp.ParseStrings(args[2:])
+		if err != nil {
+			return nil, err
+		}
+		return resp.Integer(cluster.KeySlot(key[0])), nil
+	case resp.COUNTKEYSINSLOT, resp.GETKEYSINSLOT:
+		return h.keysInSlot(c, subcommand, args)
+	case resp.ADDSLOTS, resp.ADDSLOTSRANGE, resp.DELSLOTS, resp.DELSLOTSRANGE:
+		return h.assignSlots(subcommand, args)
+	case resp.SETSLOT:
+		return h.setSlot(c, args)
+	case resp.MEET:
+		return h.meet(args)
+	default:
+		ids, err := resp.ParseStrings(args[2:])
+		if err != nil {
+			return nil, err
+		}
+		if err := h.cluster.Forget(ids[0]); err != nil {
+			return nil, err
+		}
+		return resp.OK, nil
+	}
+}
+
+func (h *Handler) infoCluster(w *infoWriter) {
+	w.field("cluster_enabled", boolToInt(h.cluster != nil))
+}
+
+func (h *Handler) clusterInfo() resp.Payload {
+	assigned, size := 0, make(map[*cluster.Node]bool)
+	for _, r := range h.cluster.Ranges() {
+		assigned += r.End - r.Start + 1
+		size[r.Node] = true
+	}
+	state := "fail"
+	if assigned == cluster.SlotCount {
+		state = "ok"
+	}
+
+	w := &infoWriter{}
+	w.field("cluster_enabled", 1)
+	w.field("cluster_state", state)
+	w.field("cluster_slots_assigned", assigned)
+	w.field("cluster_slots_ok", assigned)
+	w.field("cluster_slots_pfail", 0)
+	w.field("cluster_slots_fail", 0)
+	w.field("cluster_known_nodes", len(h.cluster.Nodes()))
+	w.field("cluster_size", len(size))
+	w.field("cluster_current_epoch", 0)
+	w.field("cluster_my_epoch", 0)
+	return resp.BulkString(w.String())
+}
+
+// clusterNodes describes the nodes in the format of nodes.conf, the local node showing the slots it migrates or imports.
+func (h *Handler) clusterNodes(c *Client) resp.Payload {
+	ranges := h.cluster.Ranges()
+	migrating, importing := h.cluster.Migrations()
+
+	var b strings.Builder
+	for _, node := range h.cluster.Nodes() {
+		flags := "master"
+		if node == h.cluster.Myself() {
+			flags = "myself,master"
+		}
+		host := h.nodeHost(c, node)
+		fmt.Fprintf(&b, "%s %s:%d@%d %s - 0 0 0 connected", node.ID, host, node.Port, node.Port+10000, flags)
+		for _, r := range ranges {
+			switch {
+			case r.Node != node:
+			case r.Start == r.End:
+				fmt.Fprintf(&b, " %d", r.Start)
+			default:
+				fmt.Fprintf(&b, " %d-%d", r.Start, r.End)
+			}
+		}
+		if node == h.cluster.Myself() {
+			for slot, target := range migrating {
+				fmt.Fprintf(&b, " [%d->-%s]", slot, target.ID)
+			}
+			for slot, source := range importing {
+				fmt.Fprintf(&b, " [%d-<-%s]", slot, source.ID)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return resp.BulkString(b.String())
+}
+
+func (h *Handler) clusterSlots(c *Client) resp.Payload {
+	ranges := h.cluster.Ranges()
+	result := make(resp.Array, len(ranges))
+	for i, r := range ranges {
+		node := resp.Array{resp.BulkString(h.nodeHost(c, r.Node)), resp.Integer(r.Node.Port), resp.BulkString(r.Node.ID), resp.Array{}}
+		result[i] = resp.Array{resp.Integer(r.Start), resp.Integer(r.End), node}
+	}
+	return result
+}
+
+// clusterShards describes every node as a shard of its own, as nodes have no replicas.
+func (h *Handler) clusterShards(c *Client) resp.Payload {
+	ranges := h.cluster.Ranges()
+	nodes := h.cluster.Nodes()
+	result := make(resp.Array, len(nodes))
+	for i, node := range nodes {
+		slots := resp.Array{}
+		for _, r := range ranges {
+			if r.Node == node {
+				slots = append(slots, resp.Integer(r.Start), resp.Integer(r.End))
+			}
+		}
+		host := resp.BulkString(h.nodeHost(c, node))
+		description := resp.Array{
+			resp.BulkString("id"), resp.BulkString(node.ID),
+			resp.BulkString("port"), resp.Integer(node.Port),
+			resp.BulkString("ip"), host,
+			resp.BulkString("endpoint"), host,
+			resp.BulkString("role"), resp.BulkString("master"),
+			resp.BulkString("replication-offset"), resp.Integer(0),
+			resp.BulkString("health"), resp.BulkString("online"),
+		}
+		result[i] = resp.Array{resp.BulkString("slots"), slots, resp.BulkString("nodes"), resp.Array{description}}
+	}
+	return result
+}
+
+// keysInSlot serves COUNTKEYSINSLOT and GETKEYSINSLOT, walking the whole keyspace as keys are not indexed by slot.
+func (h *Handler) keysInSlot(c *Client, subcommand resp.BulkString, args resp.Array) (resp.Payload, error) {
+	slot, err := resp.ParseSlot(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if subcommand == resp.COUNTKEYSINSLOT {
+		return resp.Integer(len(slotKeys(h.db(c), slot, -1))), nil
+	}
+
+	count, err := resp.ParseInteger(args[3])
+	if err != nil || count < 0 {
+		return nil, resp.NewSimpleError("Invalid number of keys")
+	}
+	keys := slotKeys(h.db(c), slot, int(count))
+	result := make(resp.Array, len(keys))
+	for i, key := range keys {
+		result[i] = resp.BulkString(key)
+	}
+	return result, nil
+}
+
+// slotKeys returns up to count keys of db in slot, all of them when count is negative.
+func slotKeys(db store.Store, slot, count int) []string {
+	var keys []string
+	db.Range(func(key string, _ store.Entry) bool {
+		if count >= 0 && len(keys) >= count {
+			return false
+		}
+		if cluster.KeySlot(key) == slot {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys
+}
+
+func (h *Handler) assignSlots(subcommand resp.BulkString, args resp.Array) (resp.Payload, error) {
+	var slots []int
+	var err error
+	if subcommand == resp.ADDSLOTSRANGE || subcommand == resp.DELSLOTSRANGE {
+		if len(args)%2 != 0 {
+			return nil, fmt.Errorf("wrong number of arguments for 'cluster|%s' command", strings.ToLower(string(subcommand)))
+		}
+		slots, err = resp.ParseSlotRanges(args[2:])
+	} else {
+		slots, err = resp.ParseSlots(args[2:])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subcommand == resp.ADDSLOTS || subcommand == resp.ADDSLOTSRANGE {
+		err = h.cluster.AddSlots(slots)
+	} else {
+		err = h.cluster.DelSlots(slots)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.OK, nil
+}
+
+func (h *Handler) setSlot(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseSetSlotArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch parsed.State {
+	case resp.MIGRATING:
+		err = h.cluster.SetMigrating(parsed.Slot, parsed.NodeID)
+	case resp.IMPORTING:
+		err = h.cluster.SetImporting(parsed.Slot, parsed.NodeID)
+	case resp.STABLE:
+		h.cluster.SetStable(parsed.Slot)
+	default:
+		owner, _, _ := h.cluster.Route(parsed.Slot)
+		if owner == h.cluster.Myself() && parsed.NodeID != owner.ID && len(slotKeys(h.db(c), parsed.Slot, 1)) > 0 {
+			return nil, resp.NewSimpleError(fmt.Sprintf("Can't assign hashslot %d to a different node while I still hold keys for this hash slot.", parsed.Slot))
+		}
+		err = h.cluster.SetNode(parsed.Slot, parsed.NodeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.OK, nil
+}
+
+// meet adds the node listening at ip:port, which is asked for its ID right away and then polled with the other nodes.
+func (h *Handler) meet(args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[2:])
+	if err != nil {
+		return nil, err
+	}
+	port, err := resp.ParseInteger(args[3])
+	if err != nil || port < 1 || port > 65535 || net.ParseIP(strs[0]) == nil {
+		return nil, resp.NewSimpleError(fmt.Sprintf("Invalid node address specified: %s:%s", strs[0], strs[1]))
+	}
+
+	id, err := nodeID(net.JoinHostPort(strs[0], strconv.FormatInt(port, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach node %s:%d: %w", strs[0], port, err)
+	}
+	if err := h.cluster.Meet(id, strs[0], int(port)); err != nil {
+		return nil, err
+	}
+	return resp.OK, nil
+}
+
+// nodeID asks the node at addr for its ID with CLUSTER MYID.
+func nodeID(addr string) (string, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(dialTimeout)); err != nil {
+		return "", err
+	}
+
+	id, err := peerBulk(conn, bufio.NewReader(conn), "CLUSTER", "MYID")
+	if err != nil {
+		return "", err
+	}
+	if len(id) != 40 {
+		return "", errors.New("invalid node ID")
+	}
+	return id, nil
+}
+
+// peerBulk sends a command whose reply is a bulk string to the node at the other end of conn.
+func peerBulk(conn net.Conn, br *bufio.Reader, args ...string) (string, error) {
+	header, err := peerCommand(conn, br, args...)
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(header)
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("%s failed: unexpected reply", args[0])
+	}
+	bulk := make([]byte, n+2)
+	if _, err := io.ReadFull(br, bulk); err != nil {
+		return "", err
+	}
+	return string(bulk[:n]), nil
+}
@@ -2,11 +2,32 @@ package handler
 
 import (
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/PlayerNeo42/gvalkey/resp"
 )
 
+// CommandFlag describes how a command interacts with the dataset.
+type CommandFlag uint
+
+const (
+	// FlagWrite marks commands that may modify the dataset.
+	FlagWrite CommandFlag = 1 << iota
+	// FlagReadOnly marks commands that only read the dataset.
+	FlagReadOnly
+	// FlagDenyOOM marks commands that may grow memory usage, they are rejected when maxmemory is reached.
+	FlagDenyOOM
+	// FlagAdmin marks server administration commands.
+	FlagAdmin
+	// FlagSubscriber marks the commands allowed once a client has subscribed to channels or patterns.
+	FlagSubscriber
+	// FlagExclusive marks the commands that run exclusively of every other command, such as the ones running scripts.
+	FlagExclusive
+	// FlagNoScript marks the commands that scripts are not allowed to call.
+	FlagNoScript
+)
+
 type Command struct {
 	// name of the command
 	Name resp.BulkString
@@ -16,8 +37,11 @@ type Command struct {
 	// negative value means at least that number of arguments
 	Args int
 
+	// flags of the command
+	Flags CommandFlag
+
 	// handler of the command
-	Handler func(command resp.Array) (resp.Payload, error)
+	Handler func(c *Client, args resp.Array) (resp.Payload, error)
 }
 
 type CommandTable struct {
@@ -46,3 +70,21 @@ func (c *CommandTable) Get(name resp.BulkString) (*Command, bool) {
 	}
 	return cmd, true
 }
+
+// Names returns the names of the registered commands, sorted.
+func (c *CommandTable) Names() []resp.BulkString {
+	var names []resp.BulkString
+	c.m.Range(func(key, _ any) bool {
+		if name, ok := key.(resp.BulkString); ok {
+			names = append(names, name)
+		}
+		return true
+	})
+	slices.Sort(names)
+	return names
+}
+
+// Has reports whether the command has all the given flags.
+func (c *Command) Has(flags CommandFlag) bool {
+	return c.Flags&flags == flags
+}
@@ -1,7 +1,261 @@
 package handler
 
-import "github.com/PlayerNeo42/gvalkey/resp"
+import (
+	"fmt"
+	"slices"
+	"strings"
 
-func (h *Handler) handleCommand(command resp.Array) (resp.Payload, error) {
-	return resp.OK, nil
+	"github.com/PlayerNeo42/gvalkey/internal/commanddoc"
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// commandSyntax holds the syntax of the arguments of every command, keyed by its lower case name and by
+// "name|subcommand" for the subcommands, in the notation of commanddoc.
+var commandSyntax = map[string]string{
+	"get":                     "key",
+	"strlen":                  "key",
+	"set":                     "key value [EX seconds|PX milliseconds|EXAT unix-time-seconds|PXAT unix-time-milliseconds] [NX|XX] [GET]",
+	"del":                     "key [key ...]",
+	"command":                 "",
+	"command|count":           "",
+	"command|docs":            "[command-name [command-name ...]]",
+	"info":                    "[section [section ...]]",
+	"config":                  "",
+	"config|get":              "parameter [parameter ...]",
+	"config|set":              "parameter value [parameter value ...]",
+	"config|rewrite":          "",
+	"config|resetstat":        "",
+	"slowlog":                 "",
+	"slowlog|get":             "[count]",
+	"slowlog|len":             "",
+	"slowlog|reset":           "",
+	"monitor":                 "",
+	"client":                  "",
+	"client|setname":          "connection-name",
+	"client|getname":          "",
+	"ping":                    "[message]",
+	"select":                  "index",
+	"swapdb":                  "index1 index2",
+	"move":                    "key db",
+	"flushdb":                 "[ASYNC|SYNC]",
+	"flushall":                "[ASYNC|SYNC]",
+	"dbsize":                  "",
+	"type":                    "key",
+	"scan":                    "cursor [MATCH pattern] [COUNT count] [TYPE type]",
+	"lpush":                   "key element [element ...]",
+	"rpush":                   "key element [element ...]",
+	"lpop":                    "key [count]",
+	"rpop":                    "key [count]",
+	"llen":                    "key",
+	"lrange":                  "key start stop",
+	"lmove":                   "source destination <LEFT|RIGHT> <LEFT|RIGHT>",
+	"rpoplpush":               "source destination",
+	"blpop":                   "key [key ...] timeout",
+	"brpop":                   "key [key ...] timeout",
+	"blmove":                  "source destination <LEFT|RIGHT> <LEFT|RIGHT> timeout",
+	"brpoplpush":              "source destination timeout",
+	"setbit":                  "key offset value",
+	"getbit":                  "key offset",
+	"bitcount":                "key [start end [BYTE|BIT]]",
+	"bitpos":                  "key bit [start [end [BYTE|BIT]]]",
+	"bitop":                   "<AND|OR|XOR|NOT> destkey key [key ...]",
+	"bitfield":                "key [GET encoding offset ...] [SET encoding offset value ...] [INCRBY encoding offset increment ...] [OVERFLOW <WRAP|SAT|FAIL> ...]",
+	"bitfield_ro":             "key [GET encoding offset ...]",
+	"pfadd":                   "key [element [element ...]]",
+	"pfcount":                 "key [key ...]",
+	"pfmerge":                 "destkey [sourcekey [sourcekey ...]]",
+	"geoadd":                  "key [NX|XX] [CH] longitude latitude member [longitude latitude member ...]",
+	"geopos":                  "key [member [member ...]]",
+	"geodist":                 "key member1 member2 [M|KM|FT|MI]",
+	"geohash":                 "key [member [member ...]]",
+	"geosearch":               "key " + geoSearchSyntax + " [WITHCOORD] [WITHDIST] [WITHHASH]",
+	"geosearchstore":          "destination source " + geoSearchSyntax + " [STOREDIST]",
+	"hset":                    "key field value [field value ...]",
+	"hget":                    "key field",
+	"hdel":                    "key field [field ...]",
+	"hlen":                    "key",
+	"hexists":                 "key field",
+	"hgetall":                 "key",
+	"hkeys":                   "key",
+	"hvals":                   "key",
+	"hexpire":                 "key seconds [NX|XX|GT|LT] FIELDS numfields field [field ...]",
+	"hpexpire":                "key milliseconds [NX|XX|GT|LT] FIELDS numfields field [field ...]",
+	"hexpireat":               "key unix-time-seconds [NX|XX|GT|LT] FIELDS numfields field [field ...]",
+	"hpexpireat":              "key unix-time-milliseconds [NX|XX|GT|LT] FIELDS numfields field [field ...]",
+	"httl":                    "key FIELDS numfields field [field ...]",
+	"hpttl":                   "key FIELDS numfields field [field ...]",
+	"hpersist":                "key FIELDS numfields field [field ...]",
+	"xadd":                    "key [NOMKSTREAM] [<MAXLEN|MINID> [=|~] threshold [LIMIT count]] <*|id> field value [field value ...]",
+	"xlen":                    "key",
+	"xrange":                  "key start end [COUNT count]",
+	"xrevrange":               "key end start [COUNT count]",
+	"xdel":                    "key id [id ...]",
+	"xtrim":                   "key <MAXLEN|MINID> [=|~] threshold [LIMIT count]",
+	"xsetid":                  "key last-id",
+	"xread":                   "[COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]",
+	"xreadgroup":              "GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...]",
+	"xgroup":                  "",
+	"xgroup|create":           "key group <id|$> [MKSTREAM] [ENTRIESREAD entries-read]",
+	"xgroup|setid":            "key group <id|$> [ENTRIESREAD entries-read]",
+	"xgroup|destroy":          "key group",
+	"xgroup|createconsumer":   "key group consumer",
+	"xgroup|delconsumer":      "key group consumer",
+	"xack":                    "key group id [id ...]",
+	"xpending":                "key group [[IDLE min-idle-time] start end count [consumer]]",
+	"xclaim":                  "key group consumer min-idle-time id [id ...] [IDLE ms] [TIME unix-time-milliseconds] [RETRYCOUNT count] [FORCE] [JUSTID] [LASTID lastid]",
+	"xautoclaim":              "key group consumer min-idle-time start [COUNT count] [JUSTID]",
+	"xinfo":                   "",
+	"xinfo|stream":            "key",
+	"xinfo|groups":            "key",
+	"xinfo|consumers":         "key group",
+	"eval":                    "script numkeys [key [key ...]] [arg [arg ...]]",
+	"eval_ro":                 "script numkeys [key [key ...]] [arg [arg ...]]",
+	"evalsha":                 "sha1 numkeys [key [key ...]] [arg [arg ...]]",
+	"evalsha_ro":              "sha1 numkeys [key [key ...]] [arg [arg ...]]",
+	"script":                  "",
+	"script|load":             "script",
+	"script|exists":           "sha1 [sha1 ...]",
+	"script|flush":            "[ASYNC|SYNC]",
+	"script|kill":             "",
+	"subscribe":               "channel [channel ...]",
+	"unsubscribe":             "[channel [channel ...]]",
+	"psubscribe":              "pattern [pattern ...]",
+	"punsubscribe":            "[pattern [pattern ...]]",
+	"publish":                 "channel message",
+	"replicaof":               "<host port|NO ONE>",
+	"slaveof":                 "<host port|NO ONE>",
+	"replconf":                "[option value [option value ...]]",
+	"psync":                   "replicationid offset",
+	"role":                    "",
+	"cluster":                 "",
+	"cluster|info":            "",
+	"cluster|myid":            "",
+	"cluster|nodes":           "",
+	"cluster|slots":           "",
+	"cluster|shards":          "",
+	"cluster|keyslot":         "string",
+	"cluster|countkeysinslot": "slot",
+	"cluster|getkeysinslot":   "slot count",
+	"cluster|addslots":        "slot [slot ...]",
+	"cluster|addslotsrange":   "start-slot end-slot [start-slot end-slot ...]",
+	"cluster|delslots":        "slot [slot ...]",
+	"cluster|delslotsrange":   "start-slot end-slot [start-slot end-slot ...]",
+	"cluster|setslot":         "slot <IMPORTING node-id|MIGRATING node-id|NODE node-id|STABLE>",
+	"cluster|meet":            "ip port",
+	"cluster|forget":          "node-id",
+	"asking":                  "",
+	"migrate":                 `host port <key|""> destination-db timeout [COPY] [REPLACE] [KEYS key [key ...]]`,
+	"dump":                    "key",
+	"restore":                 "key ttl serialized-value [REPLACE] [ABSTTL]",
+	"restore-asking":          "key ttl serialized-value [REPLACE] [ABSTTL]",
+}
+
+// geoSearchSyntax is the syntax of the arguments shared by GEOSEARCH and GEOSEARCHSTORE.
+const geoSearchSyntax = "<FROMMEMBER member|FROMLONLAT longitude latitude> " +
+	"<BYRADIUS radius <M|KM|FT|MI>|BYBOX width height <M|KM|FT|MI>> [ASC|DESC] [COUNT count [ANY]]"
+
+// commandDocs holds the parsed commandSyntax.
+var commandDocs = parseCommandDocs()
+
+func parseCommandDocs() map[string][]commanddoc.Arg {
+	docs := make(map[string][]commanddoc.Arg, len(commandSyntax))
+	for name, syntax := range commandSyntax {
+		docs[name] = commanddoc.MustParse(syntax)
+	}
+	return docs
+}
+
+func (h *Handler) handleCommand(c *Client, args resp.Array) (resp.Payload, error) {
+	if len(args) == 1 {
+		return resp.OK, nil
+	}
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch subcommand {
+	case resp.COUNT:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("wrong number of arguments for 'command|%s' command", subcommand)
+		}
+		return resp.Integer(len(h.commandTable.Names())), nil
+	case resp.DOCS:
+		names, err := resp.ParseStrings(args[2:])
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			for _, name := range h.commandTable.Names() {
+				names = append(names, name.String())
+			}
+		}
+		// unknown commands are left out, like Redis does, and so are the ones missing from commandSyntax
+		result := resp.Array{}
+		for _, name := range names {
+			name = strings.ToLower(name)
+			if _, ok := h.commandTable.Get(resp.BulkString(name)); !ok {
+				continue
+			}
+			if _, ok := commandDocs[name]; !ok {
+				continue
+			}
+			result = append(result, resp.BulkString(name), commandDoc(name))
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+}
+
+// commandDoc returns the documentation of the command named name, as an array alternating fields and values.
+func commandDoc(name string) resp.Array {
+	doc := resp.Array{}
+	if args := commandDocs[name]; len(args) > 0 {
+		doc = append(doc, resp.BulkString("arguments"), argDocs(args))
+	}
+
+	var subcommands []string
+	for sub := range commandDocs {
+		if strings.HasPrefix(sub, name+"|") {
+			subcommands = append(subcommands, sub)
+		}
+	}
+	if len(subcommands) > 0 {
+		slices.Sort(subcommands)
+		docs := make(resp.Array, 0, 2*len(subcommands))
+		for _, sub := range subcommands {
+			docs = append(docs, resp.BulkString(sub), commandDoc(sub))
+		}
+		doc = append(doc, resp.BulkString("subcommands"), docs)
+	}
+	return doc
+}
+
+func argDocs(args []commanddoc.Arg) resp.Array {
+	docs := make(resp.Array, len(args))
+	for i, arg := range args {
+		doc := resp.Array{resp.BulkString("name"), resp.BulkString(arg.Name), resp.BulkString("type"), resp.BulkString(arg.Type)}
+		if arg.Token != "" {
+			doc = append(doc, resp.BulkString("token"), resp.BulkString(arg.Token))
+		}
+		var flags resp.Array
+		if arg.Optional {
+			flags = append(flags, resp.SimpleString("optional"))
+		}
+		if arg.Multiple {
+			flags = append(flags, resp.SimpleString("multiple"))
+		}
+		if arg.MultipleToken {
+			flags = append(flags, resp.SimpleString("multiple_token"))
+		}
+		if len(flags) > 0 {
+			doc = append(doc, resp.BulkString("flags"), flags)
+		}
+		if len(arg.Args) > 0 {
+			doc = append(doc, resp.BulkString("arguments"), argDocs(arg.Args))
+		}
+		docs[i] = doc
+	}
+	return docs
 }
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+func (h *Handler) handleConfig(c *Client, args resp.Array) (resp.Payload, error) {
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch subcommand {
+	case resp.GET:
+		return h.handleConfigGet(args)
+	case resp.SET:
+		return h.handleConfigSet(args)
+	case resp.REWRITE:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("wrong number of arguments for 'config|%s' command", subcommand)
+		}
+		if err := h.config.Rewrite(); err != nil {
+			return nil, fmt.Errorf("rewriting config file: %w", err)
+		}
+		h.logger.Info("config rewritten", "file", h.config.File())
+		return resp.OK, nil
+	case resp.RESETSTAT:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("wrong number of arguments for 'config|%s' command", subcommand)
+		}
+		h.stats.Reset()
+		return resp.OK, nil
+	default:
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+}
+
+func (h *Handler) handleConfigGet(args resp.Array) (resp.Payload, error) {
+	patterns, err := resp.ParseStrings(args[2:])
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("wrong number of arguments for 'config|%s' command", resp.GET)
+	}
+
+	seen := make(map[string]bool)
+	result := resp.Array{}
+	for _, pattern := range patterns {
+		pairs := h.config.Get(pattern)
+		for i := 0; i < len(pairs); i += 2 {
+			if seen[pairs[i]] {
+				continue
+			}
+			seen[pairs[i]] = true
+			result = append(result, resp.BulkString(pairs[i]), resp.BulkString(pairs[i+1]))
+		}
+	}
+	return result, nil
+}
+
+func (h *Handler) handleConfigSet(args resp.Array) (resp.Payload, error) {
+	nameValues, err := resp.ParseStrings(args[2:])
+	if err != nil {
+		return nil, err
+	}
+	if len(nameValues) == 0 || len(nameValues)%2 != 0 {
+		return nil, fmt.Errorf("wrong number of arguments for 'config|%s' command", resp.SET)
+	}
+
+	if err := h.config.Set(nameValues...); err != nil {
+		return nil, err
+	}
+	h.logger.Info("config updated", "settings", nameValues)
+	return resp.OK, nil
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+// databases holds the logical databases clients switch between with SELECT.
+//
+// clients keep the index of their database rather than the store itself, so that SWAPDB is visible to them immediately.
+type databases struct {
+	mu     sync.RWMutex
+	stores []store.Store
+
+	// the number each database had at startup, which unlike its index SWAPDB does not change
+	ranks map[store.Store]int
+}
+
+func newDatabases(stores []store.Store) *databases {
+	ranks := make(map[store.Store]int, len(stores))
+	for i, st := range stores {
+		ranks[st] = i
+	}
+	return &databases{stores: stores, ranks: ranks}
+}
+
+func (d *databases) get(index int) store.Store {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.stores[index]
+}
+
+func (d *databases) len() int {
+	// the number of databases never changes, so no lock is needed
+	return len(d.stores)
+}
+
+func (d *databases) swap(i, j int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stores[i], d.stores[j] = d.stores[j], d.stores[i]
+}
+
+// all returns the databases indexed by their number.
+func (d *databases) all() []store.Store {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return append([]store.Store(nil), d.stores...)
+}
+
+// indexOf returns the current number of the database st.
+func (d *databases) indexOf(st store.Store) (int, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for i, s := range d.stores {
+		if s == st {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// lockOrder reports whether a must be locked before b when a command locks a key in both, which keeps
+// commands locking the same two databases in opposite directions from deadlocking.
+func (d *databases) lockOrder(a, b store.Store) bool {
+	// ranks is never written after startup, so no lock is needed
+	return d.ranks[a] < d.ranks[b]
+}
+
+// DB returns the database numbered index, following SWAPDB.
+func (h *Handler) DB(index int) store.Store {
+	return h.dbs.get(index)
+}
+
+// Databases returns the databases indexed by their number.
+func (h *Handler) Databases() []store.Store {
+	return h.dbs.all()
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+var (
+	errDBIndexOutOfRange = errors.New("DB index is out of range")
+	errSameObject        = errors.New("source and destination objects are the same")
+)
+
+// parseDBIndex parses a database index and checks that it exists.
+func (h *Handler) parseDBIndex(arg any) (int, error) {
+	index, err := resp.ParseInteger(arg)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 || index >= int64(h.dbs.len()) {
+		return 0, errDBIndexOutOfRange
+	}
+	return int(index), nil
+}
+
+func (h *Handler) handleSelect(c *Client, args resp.Array) (resp.Payload, error) {
+	index, err := h.parseDBIndex(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if index != 0 && h.cluster != nil {
+		return nil, errSelectInCluster
+	}
+
+	c.db = index
+	return resp.OK, nil
+}
+
+func (h *Handler) handleSwapDB(c *Client, args resp.Array) (resp.Payload, error) {
+	first, err := h.parseDBIndex(args[1])
+	if err != nil {
+		return nil, err
+	}
+	second, err := h.parseDBIndex(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	h.dbs.swap(first, second)
+
+	// clients blocked on either database may be served by the keys of the other one
+	h.signalBlockedKeys(c, first)
+	h.signalBlockedKeys(c, second)
+	return resp.OK, nil
+}
+
+// signalBlockedKeys signals every key of database db that clients are blocked on, after a command replaced its whole content.
+func (h *Handler) signalBlockedKeys(c *Client, db int) {
+	for _, key := range h.blocking.keysOf(db) {
+		h.signalKeyReady(c, db, key)
+	}
+}
+
+func (h *Handler) handleMove(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	index, err := h.parseDBIndex(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if index == c.db {
+		return nil, errSameObject
+	}
+
+	src, dst := h.db(c), h.dbs.get(index)
+	if !moveKey(src, dst, key.String(), h.dbs.lockOrder(src, dst)) {
+		return resp.Integer(0), nil
+	}
+
+	h.notifyKeyspaceEvent(pubsub.ClassGeneric, "move_from", key.String(), c.db)
+	h.notifyKeyspaceEvent(pubsub.ClassGeneric, "move_to", key.String(), index)
+	h.signalKeyReady(c, index, key.String())
+	return resp.Integer(1), nil
+}
+
+// moveKey moves key from src to dst unless dst already has it, and reports whether it did.
+// the key is locked in both databases for the whole move, so that no write to it falls in between,
+// the one of srcFirst being locked first.
+func moveKey(src, dst store.Store, key string, srcFirst bool) bool {
+	moved := false
+	if srcFirst {
+		src.Compute(key, func(e store.Entry, ok bool) (store.Entry, store.Op) {
+			if !ok {
+				return e, store.OpKeep
+			}
+			dst.Compute(key, func(existing store.Entry, exists bool) (store.Entry, store.Op) {
+				if exists {
+					return existing, store.OpKeep
+				}
+				moved = true
+				return e, store.OpSet
+			})
+			if !moved {
+				return e, store.OpKeep
+			}
+			return e, store.OpDelete
+		})
+		return moved
+	}
+
+	dst.Compute(key, func(existing store.Entry, exists bool) (store.Entry, store.Op) {
+		if exists {
+			return existing, store.OpKeep
+		}
+		var entry store.Entry
+		src.Compute(key, func(e store.Entry, ok bool) (store.Entry, store.Op) {
+			if !ok {
+				return e, store.OpKeep
+			}
+			entry, moved = e, true
+			return e, store.OpDelete
+		})
+		if !moved {
+			return existing, store.OpKeep
+		}
+		return entry, store.OpSet
+	})
+	return moved
+}
+
+func (h *Handler) handleFlushDB(c *Client, args resp.Array) (resp.Payload, error) {
+	// ASYNC is accepted for compatibility, freed values are reclaimed by the garbage collector in the background anyway
+	if _, err := resp.ParseFlushArgs(args); err != nil {
+		return nil, err
+	}
+
+	h.db(c).Flush()
+	h.signalBlockedKeys(c, c.db)
+	return resp.OK, nil
+}
+
+func (h *Handler) handleFlushAll(c *Client, args resp.Array) (resp.Payload, error) {
+	if _, err := resp.ParseFlushArgs(args); err != nil {
+		return nil, err
+	}
+
+	for i, db := range h.dbs.all() {
+		db.Flush()
+		h.signalBlockedKeys(c, i)
+	}
+	return resp.OK, nil
+}
+
+func (h *Handler) handleDBSize(c *Client, _ resp.Array) (resp.Payload, error) {
+	return resp.Integer(h.db(c).Stats().Keys), nil
+}
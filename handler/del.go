@@ -1,10 +1,11 @@
 package handler
 
 import (
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
 	"github.com/PlayerNeo42/gvalkey/resp"
 )
 
-func (h *Handler) handleDel(args resp.Array) (resp.Payload, error) {
+func (h *Handler) handleDel(c *Client, args resp.Array) (resp.Payload, error) {
 	keys, err := resp.ParseDelArgs(args)
 	if err != nil {
 		return nil, err
@@ -12,7 +13,8 @@ func (h *Handler) handleDel(args resp.Array) (resp.Payload, error) {
 
 	count := 0
 	for _, key := range keys {
-		if h.store.Del(key.String()) {
+		if h.db(c).Del(key.String()) {
+			h.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key.String(), c.db)
 			count++
 		}
 	}
@@ -3,11 +3,13 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/PlayerNeo42/gvalkey/resp"
 )
 
-func (h *Handler) dispatch(args resp.Array) (resp.Payload, error) {
+func (h *Handler) dispatch(c *Client, args resp.Array) (resp.Payload, error) {
 	val, ok := args[0].(resp.BulkString)
 	if !ok {
 		return resp.NULL, errors.New("command must be a bulk string")
@@ -20,6 +22,10 @@ func (h *Handler) dispatch(args resp.Array) (resp.Payload, error) {
 		return nil, errors.New("unsupported command")
 	}
 
+	// ASKING only applies to the command following it
+	asking := c.asking
+	c.asking = false
+
 	// positive value means fixed number of arguments
 	// negative value means at least that number of arguments
 	if (cmd.Args > 0 && len(args) != cmd.Args) ||
@@ -27,5 +33,126 @@ func (h *Handler) dispatch(args resp.Array) (resp.Payload, error) {
 		return nil, fmt.Errorf("wrong number of arguments for '%s' command", cmd.Name)
 	}
 
-	return cmd.Handler(args)
+	if c.subscriptions() > 0 && !cmd.Has(FlagSubscriber) {
+		return nil, resp.NewSimpleError(fmt.Sprintf("Can't execute '%s': only (P)SUBSCRIBE / (P)UNSUBSCRIBE are allowed in this context", strings.ToLower(string(val))))
+	}
+
+	if c.script {
+		if cmd.Has(FlagNoScript) {
+			return nil, resp.NewSimpleError("This Redis command is not allowed from script")
+		}
+		if c.readOnly && cmd.Has(FlagWrite) {
+			return nil, resp.NewSimpleError("Write commands are not allowed from read-only scripts.")
+		}
+	}
+
+	// masters only propagate the keys they serve, and scripts were routed by their keys
+	if h.cluster != nil && !c.master && !c.script {
+		if err := h.route(c, cmd, args, asking); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.Has(FlagWrite) && !c.master && h.isReadOnlyReplica() {
+		return nil, errReadOnlyReplica
+	}
+
+	// SCRIPT KILL stops the script holding the execution lock, it cannot wait for it
+	if !isScriptKill(cmd, args) {
+		unlock, err := h.lock(c, cmd)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	// replicas leave eviction to their master, which propagates the keys it evicts
+	if cmd.Has(FlagDenyOOM) && !c.master {
+		if err := h.freeMemoryIfNeeded(c); err != nil {
+			return nil, err
+		}
+	}
+
+	h.stats.TotalCommands.Add(1)
+	h.countLookups(c, cmd, args)
+
+	db := c.db
+	c.args, c.writes, c.propagated, c.parked = args, cmd.Has(FlagWrite), false, 0
+	start := time.Now()
+	payload, err := cmd.Handler(c, args)
+	// blocking commands are measured without the time they were parked
+	elapsed := time.Since(start) - c.parked
+	h.metrics.ObserveCommand(strings.ToLower(string(cmd.Name)), elapsed)
+	h.logSlow(c, args, start, elapsed)
+	// the commands of the master are propagated as they are received, see masterLink
+	if err == nil && c.writes && !c.propagated && !c.master {
+		h.propagate(db, c.args)
+	}
+	if err == nil && c.writes {
+		h.snapshots.dirty.Add(1)
+	}
+	h.serveReadyKeys(c)
+
+	return payload, err
+}
+
+// lock takes the execution lock for cmd and returns the function releasing it.
+// writes run exclusively while they are propagated, a replica may start synchronizing while the lock is awaited.
+func (h *Handler) lock(c *Client, cmd *Command) (func(), error) {
+	// the commands of scripts already run under the exclusive lock of their script
+	if c.script {
+		return func() {}, nil
+	}
+
+	if !cmd.Has(FlagExclusive) {
+		if err := h.acquire(c, h.execLock.TryRLock, h.execLock.RLock, h.execLock.RUnlock); err != nil {
+			return nil, err
+		}
+		if !cmd.Has(FlagWrite) || !h.repl.propagating.Load() {
+			c.exclusive = false
+			return h.execLock.RUnlock, nil
+		}
+		h.execLock.RUnlock()
+	}
+
+	if err := h.acquire(c, h.execLock.TryLock, h.execLock.Lock, h.execLock.Unlock); err != nil {
+		return nil, err
+	}
+	c.exclusive = true
+	return h.execLock.Unlock, nil
+}
+
+// acquire takes the execution lock with lock, unless the script holding it is busy, see lua-time-limit:
+// the client then gets a BUSY error rather than waiting for the script. the master link always waits.
+func (h *Handler) acquire(c *Client, tryLock func() bool, lock, unlock func()) error {
+	if tryLock() {
+		return nil
+	}
+	if c.master {
+		lock()
+		return nil
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		lock()
+		close(acquired)
+	}()
+	for {
+		busy, changed := h.running.busyState()
+		if busy {
+			break
+		}
+		select {
+		case <-acquired:
+			return nil
+		case <-changed:
+		}
+	}
+	// the lock is released as soon as it is acquired
+	go func() {
+		<-acquired
+		unlock()
+	}()
+	return errBusyScript
 }
@@ -0,0 +1,12 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+var (
+	errWrongType = resp.NewPrefixedError("WRONGTYPE", "Operation against a key holding the wrong kind of value")
+	errSyntax    = errors.New("syntax error")
+)
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// maxEvictionsPerCommand bounds the work a single command spends getting back under maxmemory.
+// used memory only drops once the garbage collector has run, so evicting until the limit is met
+// would empty the keyspace before the freed memory is observed.
+const maxEvictionsPerCommand = 16
+
+var errOOM = resp.NewPrefixedError("OOM", "command not allowed when used memory > 'maxmemory'.")
+
+// freeMemoryIfNeeded evicts keys according to maxmemory-policy while used memory is over maxmemory.
+// keys are evicted from the database selected by the client first, then from the other databases.
+// it returns errOOM when memory cannot be freed and the command must be rejected.
+func (h *Handler) freeMemoryIfNeeded(c *Client) error {
+	conf := h.config.Config()
+	if conf.MaxMemory == 0 {
+		return nil
+	}
+
+	limit := uint64(conf.MaxMemory)
+	for range maxEvictionsPerCommand {
+		if h.stats.UsedMemory() <= limit {
+			return nil
+		}
+
+		db, key, ok := h.evictionCandidate(c, conf.MaxMemoryPolicy)
+		if !ok {
+			return errOOM
+		}
+
+		if h.dbs.get(db).Del(key) {
+			h.stats.EvictedKeys.Add(1)
+			h.logger.Debug("evicted key", "key", key, "policy", conf.MaxMemoryPolicy)
+			h.notifyKeyspaceEvent(pubsub.ClassEvicted, "evicted", key, db)
+			h.propagate(db, resp.Array{resp.DEL, resp.BulkString(key)})
+		}
+	}
+	return nil
+}
+
+// evictionCandidate picks a key to evict according to policy, it returns the number of its database and the key.
+func (h *Handler) evictionCandidate(c *Client, policy string) (int, string, bool) {
+	var volatile bool
+	switch policy {
+	case "allkeys-random":
+		volatile = false
+	case "volatile-random":
+		volatile = true
+	default:
+		return 0, "", false
+	}
+
+	for i := range h.dbs.len() {
+		db := (c.db + i) % h.dbs.len()
+		if key, ok := h.dbs.get(db).RandomKey(volatile); ok {
+			return db, key, true
+		}
+	}
+	return 0, "", false
+}
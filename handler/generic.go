@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+	"strconv"
+
+	"github.com/PlayerNeo42/gvalkey/internal/glob"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// typeName returns the name of the type of value, as TYPE replies it.
+func typeName(value any) string {
+	switch value.(type) {
+	case resp.BulkString:
+		return "string"
+	case *object.List:
+		return "list"
+	case *object.Hash:
+		return "hash"
+	case *object.SortedSet:
+		return "zset"
+	case *object.Stream:
+		return "stream"
+	default:
+		return "none"
+	}
+}
+
+func (h *Handler) handleType(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := h.db(c).Get(key.String())
+	if !ok {
+		return resp.SimpleString("none"), nil
+	}
+	return resp.SimpleString(typeName(value)), nil
+}
+
+// scanHash orders the keys for SCAN, whose cursor is the hash of the next key to return.
+// keys present for the whole iteration are returned once, whatever is added or removed meanwhile.
+func scanHash(key string) uint64 {
+	f := fnv.New64a()
+	_, _ = f.Write([]byte(key))
+	return f.Sum64()
+}
+
+// handleScan returns up to COUNT keys from the cursor on, before they are filtered by MATCH and TYPE like with Redis.
+// the keys are collected on every call, which is linear in the number of keys of the database.
+func (h *Handler) handleScan(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseScanArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	type scanned struct {
+		key  string
+		hash uint64
+		typ  string
+	}
+	var keys []scanned
+	h.db(c).Range(func(key string, entry store.Entry) bool {
+		if hash := scanHash(key); hash >= parsed.Cursor {
+			keys = append(keys, scanned{key: key, hash: hash, typ: typeName(entry.Value)})
+		}
+		return true
+	})
+	slices.SortFunc(keys, func(a, b scanned) int {
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.key, b.key))
+	})
+
+	// the keys sharing the hash of the last one returned are returned with it, the next cursor being past them
+	n := min(parsed.Count, len(keys))
+	for n > 0 && n < len(keys) && keys[n].hash == keys[n-1].hash {
+		n++
+	}
+	var next uint64
+	if n < len(keys) {
+		next = keys[n].hash
+	}
+
+	found := resp.Array{}
+	for _, k := range keys[:n] {
+		if (parsed.Match == "" || glob.Match(parsed.Match, k.key)) && (parsed.Type == "" || parsed.Type == k.typ) {
+			found = append(found, resp.BulkString(k.key))
+		}
+	}
+	return resp.Array{resp.BulkString(strconv.FormatUint(next, 10)), found}, nil
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PlayerNeo42/gvalkey/internal/geo"
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+var errNoSuchMember = errors.New("could not decode requested zset member")
+
+func checkPosition(lon, lat float64) error {
+	if !geo.Valid(lon, lat) {
+		return fmt.Errorf("invalid longitude,latitude pair %f,%f", lon, lat)
+	}
+	return nil
+}
+
+// formatCoord formats a coordinate with the 17 decimals Redis replies with, without trailing zeros.
+func formatCoord(v float64) string {
+	s := strings.TrimRight(strconv.FormatFloat(v, 'f', 17, 64), "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func formatDist(dist float64) resp.BulkString {
+	return resp.BulkString(strconv.FormatFloat(dist, 'f', 4, 64))
+}
+
+func positionPayload(score float64) resp.Array {
+	lon, lat := geo.Decode(uint64(score))
+	return resp.Array{resp.BulkString(formatCoord(lon)), resp.BulkString(formatCoord(lat))}
+}
+
+func (h *Handler) handleGeoAdd(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseGeoAddArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range parsed.Members {
+		if err := checkPosition(m.Lon, m.Lat); err != nil {
+			return nil, err
+		}
+	}
+
+	added, updated := 0, 0
+	h.db(c).Compute(parsed.Key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var z *object.SortedSet
+		z, err = sortedSetOf(entry, exists)
+		if err != nil || (z == nil && parsed.XX) {
+			return entry, store.OpKeep
+		}
+		if z == nil {
+			z = object.NewSortedSet()
+			entry = store.Entry{Value: z}
+		}
+
+		for _, m := range parsed.Members {
+			score := float64(geo.Encode(m.Lon, m.Lat))
+			prev, present := z.Score(m.Member)
+			switch {
+			case present && (parsed.NX || prev == score):
+			case present:
+				z.Add(m.Member, score)
+				updated++
+			case !parsed.XX:
+				z.Add(m.Member, score)
+				added++
+			}
+		}
+		if added+updated == 0 {
+			return entry, store.OpKeep
+		}
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// GEOADD is a ZADD with geohashes as scores, and notifies as such
+	if added+updated > 0 {
+		h.notifyKeyspaceEvent(pubsub.ClassZSet, "zadd", parsed.Key, c.db)
+	}
+	if parsed.CH {
+		return resp.Integer(added + updated), nil
+	}
+	return resp.Integer(added), nil
+}
+
+// scoresOf returns the scores of members, nil for the missing ones.
+func (h *Handler) scoresOf(c *Client, key string, members []string) ([]*float64, error) {
+	scores := make([]*float64, len(members))
+	_, err := h.readSortedSet(c.db, key, func(z *object.SortedSet) error {
+		for i, member := range members {
+			if score, ok := z.Score(member); ok {
+				scores[i] = &score
+			}
+		}
+		return nil
+	})
+	return scores, err
+}
+
+func (h *Handler) handleGeoPos(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	scores, err := h.scoresOf(c, strs[0], strs[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(resp.Array, len(scores))
+	for i, score := range scores {
+		if score == nil {
+			result[i] = resp.NullArray{}
+			continue
+		}
+		result[i] = positionPayload(*score)
+	}
+	return result, nil
+}
+
+func (h *Handler) handleGeoHash(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	scores, err := h.scoresOf(c, strs[0], strs[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(resp.Array, len(scores))
+	for i, score := range scores {
+		if score == nil {
+			result[i] = resp.NULL
+			continue
+		}
+		result[i] = resp.BulkString(geo.String(uint64(*score)))
+	}
+	return result, nil
+}
+
+func (h *Handler) handleGeoDist(c *Client, args resp.Array) (resp.Payload, error) {
+	if len(args) > 5 {
+		return nil, errSyntax
+	}
+	strs, err := resp.ParseStrings(args[1:4])
+	if err != nil {
+		return nil, err
+	}
+	unit := 1.0
+	if len(args) == 5 {
+		if unit, err = resp.ParseGeoUnit(args[4]); err != nil {
+			return nil, err
+		}
+	}
+
+	scores, err := h.scoresOf(c, strs[0], strs[1:])
+	if err != nil {
+		return nil, err
+	}
+	if scores[0] == nil || scores[1] == nil {
+		return resp.NULL, nil
+	}
+	lon1, lat1 := geo.Decode(uint64(*scores[0]))
+	lon2, lat2 := geo.Decode(uint64(*scores[1]))
+	return formatDist(geo.Distance(lon1, lat1, lon2, lat2) / unit), nil
+}
+
+// geoResult is a member found by GEOSEARCH.
+type geoResult struct {
+	object.ScoredMember
+	// distance to the center of the search, in meters
+	dist float64
+}
+
+// geoSearch returns the members of the sorted set at parsed.Key within the searched area, ordered and limited as asked.
+func (h *Handler) geoSearch(c *Client, parsed *resp.GeoSearchArgs) ([]geoResult, error) {
+	shape := geo.Shape{
+		Lon:    parsed.Lon,
+		Lat:    parsed.Lat,
+		Radius: parsed.Radius * parsed.Unit,
+		Box:    parsed.By == resp.BYBOX,
+		Width:  parsed.Width * parsed.Unit,
+		Height: parsed.Height * parsed.Unit,
+	}
+	if parsed.From == resp.FROMLONLAT {
+		if err := checkPosition(shape.Lon, shape.Lat); err != nil {
+			return nil, err
+		}
+	}
+	// with ANY, the search stops as soon as enough members are found
+	limit := 0
+	if parsed.Any {
+		limit = int(parsed.Count)
+	}
+
+	var results []geoResult
+	_, err := h.readSortedSet(c.db, parsed.Key, func(z *object.SortedSet) error {
+		if parsed.From == resp.FROMMEMBER {
+			score, ok := z.Score(parsed.Member)
+			if !ok {
+				return errNoSuchMember
+			}
+			shape.Lon, shape.Lat = geo.Decode(uint64(score))
+		}
+
+		for _, r := range shape.Ranges() {
+			z.RangeByScore(r.Min, r.Max, func(m object.ScoredMember) bool {
+				lon, lat := geo.Decode(uint64(m.Score))
+				if dist, ok := shape.Contains(lon, lat); ok {
+					results = append(results, geoResult{m, dist})
+				}
+				return limit == 0 || len(results) < limit
+			})
+			if limit > 0 && len(results) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// COUNT without ANY returns the closest members
+	order := parsed.Order
+	if parsed.Count > 0 && order == "" && !parsed.Any {
+		order = resp.ASC
+	}
+	switch order {
+	case resp.ASC:
+		slices.SortStableFunc(results, func(a, b geoResult) int { return cmp.Compare(a.dist, b.dist) })
+	case resp.DESC:
+		slices.SortStableFunc(results, func(a, b geoResult) int { return cmp.Compare(b.dist, a.dist) })
+	}
+	if parsed.Count > 0 && len(results) > int(parsed.Count) {
+		results = results[:parsed.Count]
+	}
+	return results, nil
+}
+
+func (h *Handler) handleGeoSearch(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseGeoSearchArgs(args, false)
+	if err != nil {
+		return nil, err
+	}
+	results, err := h.geoSearch(c, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	withOptions := parsed.WithDist || parsed.WithHash || parsed.WithCoord
+	reply := make(resp.Array, len(results))
+	for i, r := range results {
+		if !withOptions {
+			reply[i] = resp.BulkString(r.Member)
+			continue
+		}
+		item := resp.Array{resp.BulkString(r.Member)}
+		if parsed.WithDist {
+			item = append(item, formatDist(r.dist/parsed.Unit))
+		}
+		if parsed.WithHash {
+			item = append(item, resp.Integer(int64(r.Score)))
+		}
+		if parsed.WithCoord {
+			item = append(item, positionPayload(r.Score))
+		}
+		reply[i] = item
+	}
+	return reply, nil
+}
+
+func (h *Handler) handleGeoSearchStore(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseGeoSearchArgs(args, true)
+	if err != nil {
+		return nil, err
+	}
+	results, err := h.geoSearch(c, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	// like Redis, an empty result removes the destination
+	if len(results) == 0 {
+		if h.db(c).Del(parsed.Dest) {
+			h.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", parsed.Dest, c.db)
+		}
+		return resp.Integer(0), nil
+	}
+
+	z := object.NewSortedSet()
+	for _, r := range results {
+		score := r.Score
+		if parsed.StoreDist {
+			score = r.dist / parsed.Unit
+		}
+		z.Add(r.Member, score)
+	}
+	h.db(c).Set(resp.SetArgs{Key: resp.BulkString(parsed.Dest), Value: z})
+	h.notifyKeyspaceEvent(pubsub.ClassZSet, "geosearchstore", parsed.Dest, c.db)
+	return resp.Integer(len(results)), nil
+}
@@ -1,23 +1,15 @@
 package handler
 
-import (
-	"fmt"
+import "github.com/PlayerNeo42/gvalkey/resp"
 
-	"github.com/PlayerNeo42/gvalkey/resp"
-)
-
-func (h *Handler) handleGet(args resp.Array) (resp.Payload, error) {
+func (h *Handler) handleGet(c *Client, args resp.Array) (resp.Payload, error) {
 	key, err := resp.ParseGetArgs(args)
 	if err != nil {
 		return nil, err
 	}
 
-	value, ok := h.store.Get(key.String())
-	if !ok {
-		return resp.NULL, nil
-	}
-
-	if value == nil {
+	value, ok := h.db(c).Get(key.String())
+	if !ok || value == nil {
 		return resp.NULL, nil
 	}
 
@@ -25,5 +17,17 @@ func (h *Handler) handleGet(args resp.Array) (resp.Payload, error) {
 		return val, nil
 	}
 
-	return nil, fmt.Errorf("value is not a valid type: %T", value)
+	return nil, errWrongType
+}
+
+func (h *Handler) handleStrLen(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	s, _, err := h.getString(c, key.String())
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(len(s)), nil
 }
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/cluster"
+)
+
+// gossipInterval is how often every other node of the cluster is polled.
+const gossipInterval = time.Second
+
+// gossip polls the CLUSTER NODES of the other nodes for as long as the server runs,
+// learning the slots they serve and the nodes they know.
+func (h *Handler) gossip() {
+	ticker := time.NewTicker(gossipInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for _, node := range h.cluster.Nodes()[1:] {
+			if err := h.pollNode(node); err != nil {
+				h.logger.Debug("cluster node unreachable", "node", node.ID, "error", err)
+			}
+		}
+	}
+}
+
+// pollNode merges the view of node, and introduces the local node to it if it does not know it yet,
+// as MEET is only sent to one side of a pair of nodes.
+func (h *Handler) pollNode(node *cluster.Node) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(node.Host, strconv.Itoa(node.Port)), dialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(dialTimeout)); err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(conn)
+	reply, err := peerBulk(conn, br, "CLUSTER", "NODES")
+	if err != nil {
+		return err
+	}
+	view, err := cluster.ParseNodes(reply)
+	if err != nil {
+		return err
+	}
+	if h.cluster.Gossip(node.ID, view) {
+		return nil
+	}
+
+	cfg := h.config.Config()
+	host := cfg.ClusterAnnounceIP
+	if addr, ok := conn.LocalAddr().(*net.TCPAddr); ok && host == "" {
+		host = addr.IP.String()
+	}
+	_, err = peerCommand(conn, br, "CLUSTER", "MEET", host, strconv.Itoa(cfg.Port))
+	return err
+}
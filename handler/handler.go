@@ -2,48 +2,247 @@ package handler
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/PlayerNeo42/gvalkey/internal/cluster"
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+	"github.com/PlayerNeo42/gvalkey/internal/metrics"
+	"github.com/PlayerNeo42/gvalkey/internal/monitor"
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/internal/script"
+	"github.com/PlayerNeo42/gvalkey/internal/slowlog"
+	"github.com/PlayerNeo42/gvalkey/internal/stats"
 	"github.com/PlayerNeo42/gvalkey/resp"
 	"github.com/PlayerNeo42/gvalkey/store"
 )
 
 type Handler struct {
 	logger       *slog.Logger
-	store        store.Store
+	dbs          *databases
+	clock        clock.Clock
+	stats        *stats.Stats
+	metrics      *metrics.Metrics
+	slowlog      *slowlog.Log
+	config       *config.Registry
+	pubsub       *pubsub.Hub
+	monitors     *monitor.Hub
+	blocking     *blockingRegistry
 	commandTable *CommandTable
+	scripts      *script.Engine
+	running      *scriptState
+	repl         *replicationState
+	snapshots    *snapshotState
+	storeEvents  *storeEvents
+	// nil unless cluster-enabled is set
+	cluster *cluster.State
+
+	// held exclusively by the commands flagged FlagExclusive, and by writes while they are propagated to replicas,
+	// shared by all other commands, so that scripts are atomic and replicas apply writes in the order they ran
+	execLock sync.RWMutex
+
+	// classes of keyspace events to publish, cached from the notify-keyspace-events setting
+	keyspaceEvents atomic.Uint32
 }
 
-func New(logger *slog.Logger, s store.Store) *Handler {
+// New creates a handler serving the given databases, indexed by their number.
+func New(logger *slog.Logger, dbs []store.Store, opts ...Option) *Handler {
 	commandTable := NewCommandTable()
-	h := &Handler{logger: logger, store: s, commandTable: commandTable}
+	h := &Handler{
+		logger:       logger,
+		dbs:          newDatabases(dbs),
+		clock:        clock.Real,
+		stats:        stats.New(),
+		config:       config.NewRegistry(config.Default(), ""),
+		pubsub:       pubsub.New(),
+		monitors:     monitor.New(),
+		blocking:     newBlockingRegistry(),
+		commandTable: commandTable,
+		scripts:      script.New(logger),
+		running:      newScriptState(),
+		repl:         newReplicationState(),
+		storeEvents:  newStoreEvents(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.metrics = metrics.New(h.stats, h.dbs.all)
+	h.slowlog = slowlog.New(h.config.Config().SlowlogMaxLen)
+	h.config.OnChange("slowlog-max-len", h.resizeSlowlog)
+
+	if cfg := h.config.Config(); cfg.ClusterEnabled {
+		h.cluster = cluster.New(cluster.NewNodeID(), cfg.Port)
+		go h.gossip()
+	}
+
+	h.loadKeyspaceEvents(h.config.Config())
+	h.config.OnChange("notify-keyspace-events", h.loadKeyspaceEvents)
+	go h.publishStoreEvents()
+
+	h.snapshots = newSnapshotState(h.clock.Now())
+	h.loadSaveRules(h.config.Config())
+	h.config.OnChange("save", h.loadSaveRules)
+	go h.saveLoop()
 
-	commandTable.MustRegister(&Command{resp.GET, 2, h.handleGet})
-	commandTable.MustRegister(&Command{resp.SET, -3, h.handleSet})
-	commandTable.MustRegister(&Command{resp.DEL, -2, h.handleDel})
-	commandTable.MustRegister(&Command{resp.COMMAND, -1, h.handleCommand})
+	commandTable.MustRegister(&Command{resp.GET, 2, FlagReadOnly, h.handleGet})
+	commandTable.MustRegister(&Command{resp.STRLEN, 2, FlagReadOnly, h.handleStrLen})
+	commandTable.MustRegister(&Command{resp.SET, -3, FlagWrite | FlagDenyOOM, h.handleSet})
+	commandTable.MustRegister(&Command{resp.DEL, -2, FlagWrite, h.handleDel})
+	commandTable.MustRegister(&Command{resp.COMMAND, -1, 0, h.handleCommand})
+	commandTable.MustRegister(&Command{resp.INFO, -1, 0, h.handleInfo})
+	commandTable.MustRegister(&Command{resp.CONFIG, -2, FlagAdmin, h.handleConfig})
+	commandTable.MustRegister(&Command{resp.SLOWLOG, -2, FlagAdmin, h.handleSlowlog})
+	commandTable.MustRegister(&Command{resp.MONITOR, 1, FlagAdmin | FlagNoScript, h.handleMonitor})
+	commandTable.MustRegister(&Command{resp.CLIENT, -2, FlagNoScript, h.handleClient})
+	commandTable.MustRegister(&Command{resp.PING, -1, FlagSubscriber, h.handlePing})
+	commandTable.MustRegister(&Command{resp.SELECT, 2, 0, h.handleSelect})
+	commandTable.MustRegister(&Command{resp.SWAPDB, 3, FlagWrite, h.handleSwapDB})
+	commandTable.MustRegister(&Command{resp.MOVE, 3, FlagWrite, h.handleMove})
+	commandTable.MustRegister(&Command{resp.FLUSHDB, -1, FlagWrite, h.handleFlushDB})
+	commandTable.MustRegister(&Command{resp.FLUSHALL, -1, FlagWrite, h.handleFlushAll})
+	commandTable.MustRegister(&Command{resp.DBSIZE, 1, FlagReadOnly, h.handleDBSize})
+	commandTable.MustRegister(&Command{resp.TYPE, 2, FlagReadOnly, h.handleType})
+	commandTable.MustRegister(&Command{resp.SCAN, -2, FlagReadOnly, h.handleScan})
+	commandTable.MustRegister(&Command{resp.LPUSH, -3, FlagWrite | FlagDenyOOM, h.handleLPush})
+	commandTable.MustRegister(&Command{resp.RPUSH, -3, FlagWrite | FlagDenyOOM, h.handleRPush})
+	commandTable.MustRegister(&Command{resp.LPOP, -2, FlagWrite, h.handleLPop})
+	commandTable.MustRegister(&Command{resp.RPOP, -2, FlagWrite, h.handleRPop})
+	commandTable.MustRegister(&Command{resp.LLEN, 2, FlagReadOnly, h.handleLLen})
+	commandTable.MustRegister(&Command{resp.LRANGE, 4, FlagReadOnly, h.handleLRange})
+	// moves pop and push in two keys, they run exclusively so that the element is always in one of the lists
+	commandTable.MustRegister(&Command{resp.LMOVE, 5, FlagWrite | FlagDenyOOM | FlagExclusive, h.handleLMove})
+	commandTable.MustRegister(&Command{resp.RPOPLPUSH, 3, FlagWrite | FlagDenyOOM | FlagExclusive, h.handleRPopLPush})
+	commandTable.MustRegister(&Command{resp.BLPOP, -3, FlagWrite, h.handleBLPop})
+	commandTable.MustRegister(&Command{resp.BRPOP, -3, FlagWrite, h.handleBRPop})
+	commandTable.MustRegister(&Command{resp.BLMOVE, 6, FlagWrite | FlagDenyOOM | FlagExclusive, h.handleBLMove})
+	commandTable.MustRegister(&Command{resp.BRPOPLPUSH, 4, FlagWrite | FlagDenyOOM | FlagExclusive, h.handleBRPopLPush})
+	commandTable.MustRegister(&Command{resp.SETBIT, 4, FlagWrite | FlagDenyOOM, h.handleSetBit})
+	commandTable.MustRegister(&Command{resp.GETBIT, 3, FlagReadOnly, h.handleGetBit})
+	commandTable.MustRegister(&Command{resp.BITCOUNT, -2, FlagReadOnly, h.handleBitCount})
+	commandTable.MustRegister(&Command{resp.BITPOS, -3, FlagReadOnly, h.handleBitPos})
+	commandTable.MustRegister(&Command{resp.BITOP, -4, FlagWrite | FlagDenyOOM, h.handleBitOp})
+	commandTable.MustRegister(&Command{resp.BITFIELD, -2, FlagWrite | FlagDenyOOM, h.handleBitField})
+	commandTable.MustRegister(&Command{resp.BITFIELDRO, -2, FlagReadOnly, h.handleBitFieldRO})
+	commandTable.MustRegister(&Command{resp.PFADD, -2, FlagWrite | FlagDenyOOM, h.handlePFAdd})
+	// PFCOUNT writes back the cached cardinality, like Redis
+	commandTable.MustRegister(&Command{resp.PFCOUNT, -2, FlagWrite, h.handlePFCount})
+	commandTable.MustRegister(&Command{resp.PFMERGE, -2, FlagWrite | FlagDenyOOM, h.handlePFMerge})
+	commandTable.MustRegister(&Command{resp.GEOADD, -5, FlagWrite | FlagDenyOOM, h.handleGeoAdd})
+	commandTable.MustRegister(&Command{resp.GEOPOS, -2, FlagReadOnly, h.handleGeoPos})
+	commandTable.MustRegister(&Command{resp.GEODIST, -4, FlagReadOnly, h.handleGeoDist})
+	commandTable.MustRegister(&Command{resp.GEOHASH, -2, FlagReadOnly, h.handleGeoHash})
+	commandTable.MustRegister(&Command{resp.GEOSEARCH, -7, FlagReadOnly, h.handleGeoSearch})
+	commandTable.MustRegister(&Command{resp.GEOSEARCHSTORE, -8, FlagWrite | FlagDenyOOM, h.handleGeoSearchStore})
+	commandTable.MustRegister(&Command{resp.HSET, -4, FlagWrite | FlagDenyOOM, h.handleHSet})
+	commandTable.MustRegister(&Command{resp.HGET, 3, FlagReadOnly, h.handleHGet})
+	commandTable.MustRegister(&Command{resp.HDEL, -3, FlagWrite, h.handleHDel})
+	commandTable.MustRegister(&Command{resp.HLEN, 2, FlagReadOnly, h.handleHLen})
+	commandTable.MustRegister(&Command{resp.HEXISTS, 3, FlagReadOnly, h.handleHExists})
+	commandTable.MustRegister(&Command{resp.HGETALL, 2, FlagReadOnly, h.handleHGetAll})
+	commandTable.MustRegister(&Command{resp.HKEYS, 2, FlagReadOnly, h.handleHKeys})
+	commandTable.MustRegister(&Command{resp.HVALS, 2, FlagReadOnly, h.handleHVals})
+	commandTable.MustRegister(&Command{resp.HEXPIRE, -6, FlagWrite, h.handleHExpire})
+	commandTable.MustRegister(&Command{resp.HPEXPIRE, -6, FlagWrite, h.handleHPExpire})
+	commandTable.MustRegister(&Command{resp.HEXPIREAT, -6, FlagWrite, h.handleHExpireAt})
+	commandTable.MustRegister(&Command{resp.HPEXPIREAT, -6, FlagWrite, h.handleHPExpireAt})
+	commandTable.MustRegister(&Command{resp.HTTL, -5, FlagReadOnly, h.handleHTTL})
+	commandTable.MustRegister(&Command{resp.HPTTL, -5, FlagReadOnly, h.handleHPTTL})
+	commandTable.MustRegister(&Command{resp.HPERSIST, -5, FlagWrite, h.handleHPersist})
+	commandTable.MustRegister(&Command{resp.XADD, -5, FlagWrite | FlagDenyOOM, h.handleXAdd})
+	commandTable.MustRegister(&Command{resp.XLEN, 2, FlagReadOnly, h.handleXLen})
+	commandTable.MustRegister(&Command{resp.XRANGE, -4, FlagReadOnly, h.handleXRange})
+	commandTable.MustRegister(&Command{resp.XREVRANGE, -4, FlagReadOnly, h.handleXRevRange})
+	commandTable.MustRegister(&Command{resp.XDEL, -3, FlagWrite, h.handleXDel})
+	commandTable.MustRegister(&Command{resp.XTRIM, -4, FlagWrite, h.handleXTrim})
+	commandTable.MustRegister(&Command{resp.XSETID, -3, FlagWrite | FlagDenyOOM, h.handleXSetID})
+	commandTable.MustRegister(&Command{resp.XREAD, -4, FlagReadOnly, h.handleXRead})
+	commandTable.MustRegister(&Command{resp.XREADGROUP, -7, FlagWrite, h.handleXReadGroup})
+	commandTable.MustRegister(&Command{resp.XGROUP, -2, FlagWrite | FlagDenyOOM, h.handleXGroup})
+	commandTable.MustRegister(&Command{resp.XACK, -4, FlagWrite, h.handleXAck})
+	commandTable.MustRegister(&Command{resp.XPENDING, -3, FlagReadOnly, h.handleXPending})
+	commandTable.MustRegister(&Command{resp.XCLAIM, -6, FlagWrite, h.handleXClaim})
+	commandTable.MustRegister(&Command{resp.XAUTOCLAIM, -6, FlagWrite, h.handleXAutoClaim})
+	commandTable.MustRegister(&Command{resp.XINFO, -2, FlagReadOnly, h.handleXInfo})
+	commandTable.MustRegister(&Command{resp.EVAL, -3, FlagExclusive | FlagNoScript, h.handleEval})
+	commandTable.MustRegister(&Command{resp.EVALRO, -3, FlagReadOnly | FlagExclusive | FlagNoScript, h.handleEvalRO})
+	commandTable.MustRegister(&Command{resp.EVALSHA, -3, FlagExclusive | FlagNoScript, h.handleEvalSHA})
+	commandTable.MustRegister(&Command{resp.EVALSHARO, -3, FlagReadOnly | FlagExclusive | FlagNoScript, h.handleEvalSHARO})
+	commandTable.MustRegister(&Command{resp.SCRIPT, -2, FlagExclusive | FlagNoScript, h.handleScript})
+	commandTable.MustRegister(&Command{resp.SUBSCRIBE, -2, FlagSubscriber | FlagNoScript, h.handleSubscribe})
+	commandTable.MustRegister(&Command{resp.UNSUBSCRIBE, -1, FlagSubscriber | FlagNoScript, h.handleUnsubscribe})
+	commandTable.MustRegister(&Command{resp.PSUBSCRIBE, -2, FlagSubscriber | FlagNoScript, h.handlePSubscribe})
+	commandTable.MustRegister(&Command{resp.PUNSUBSCRIBE, -1, FlagSubscriber | FlagNoScript, h.handlePUnsubscribe})
+	commandTable.MustRegister(&Command{resp.PUBLISH, 3, 0, h.handlePublish})
+	commandTable.MustRegister(&Command{resp.REPLICAOF, 3, FlagAdmin | FlagExclusive | FlagNoScript, h.handleReplicaOf})
+	commandTable.MustRegister(&Command{resp.SLAVEOF, 3, FlagAdmin | FlagExclusive | FlagNoScript, h.handleReplicaOf})
+	commandTable.MustRegister(&Command{resp.REPLCONF, -1, FlagAdmin | FlagNoScript, h.handleReplConf})
+	commandTable.MustRegister(&Command{resp.PSYNC, 3, FlagAdmin | FlagExclusive | FlagNoScript, h.handlePSync})
+	commandTable.MustRegister(&Command{resp.ROLE, 1, FlagNoScript, h.handleRole})
+	commandTable.MustRegister(&Command{resp.CLUSTER, -2, FlagNoScript, h.handleCluster})
+	commandTable.MustRegister(&Command{resp.ASKING, 1, FlagNoScript, h.handleAsking})
+	commandTable.MustRegister(&Command{resp.MIGRATE, -6, FlagWrite | FlagNoScript, h.handleMigrate})
+	commandTable.MustRegister(&Command{resp.DUMP, 2, FlagReadOnly, h.handleDump})
+	commandTable.MustRegister(&Command{resp.RESTORE, -4, FlagWrite | FlagDenyOOM, h.handleRestore})
+	commandTable.MustRegister(&Command{resp.RESTOREASKING, -4, FlagWrite | FlagDenyOOM, h.handleRestore})
+
+	if replicaOf := h.config.Config().ReplicaOf; replicaOf != "" {
+		// the setting was validated when loaded
+		if host, port, err := config.ParseReplicaOf(replicaOf); err == nil {
+			h.replicaOf(host, port)
+		}
+	}
 
 	return h
 }
 
+// Close stops the background work of the handler: the checks of the save rules, the replication of a master
+// and the publishing of the keyspace events of the stores.
+func (h *Handler) Close() {
+	h.stopSaving()
+	h.stopReplication()
+	h.stopStoreEvents()
+}
+
 func (h *Handler) Serve(conn net.Conn) {
 	defer conn.Close()
+	conn = &countingConn{Conn: conn, stats: h.stats}
+
+	h.stats.ConnectedClients.Add(1)
+	h.stats.TotalConnections.Add(1)
+	defer h.stats.ConnectedClients.Add(-1)
 
-	parser := resp.NewParser(conn)
+	client := newClient(conn)
+	defer client.stopQueue()
+	defer h.unsubscribeAll(client)
+	defer h.monitors.Detach(client)
+	defer h.dropReplica(client)
 
 	for {
-		value, err := parser.Parse()
+		h.setIdleDeadline(conn)
+
+		value, err := client.parser.Parse()
 		if err != nil {
+			if client.overflowed() {
+				h.logger.Warn("closing client over the output buffer limit", "remote_addr", conn.RemoteAddr().String())
+				return
+			}
 			if errors.Is(err, io.EOF) {
 				h.logger.Info("client closed connection", "remote_addr", conn.RemoteAddr().String())
 				return
 			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				h.logger.Info("closing idle client", "remote_addr", conn.RemoteAddr().String())
+				return
+			}
 			h.logger.Error("parse command failed", "error", err)
-			errPayload := resp.NewSimpleError(err.Error())
-			if _, err = io.Copy(conn, errPayload.RESPReader()); err != nil {
+			h.stats.ParseErrors.Add(1)
+			if err = client.write(resp.NewSimpleError(err.Error())); err != nil {
 				h.logger.Error("write error message to client failed", "error", err)
 			}
 			return
@@ -55,20 +254,54 @@ func (h *Handler) Serve(conn net.Conn) {
 		switch v := value.(type) {
 		case resp.Array:
 			h.logger.Debug("received array command", "remote_addr", conn.RemoteAddr().String(), "command", v)
-			response, commandErr = h.dispatch(v)
+			if h.monitors.Active() {
+				h.monitors.Feed(time.Now(), client.db, conn.RemoteAddr().String(), v)
+			}
+			response, commandErr = h.dispatch(client, v)
 		default:
 			h.logger.Error("unsupported command type", "remote_addr", conn.RemoteAddr().String(), "command", v)
 			commandErr = errors.New("command must be an array")
 		}
 
 		if commandErr != nil {
-			response = resp.NewSimpleError(commandErr.Error())
+			response = errorReply(commandErr)
 		}
 
-		h.logger.Debug("writing response", "remote_addr", conn.RemoteAddr().String(), "response", response, "payload", fmt.Sprintf("%q", response.RESPReader()))
+		h.logger.Debug("writing response", "remote_addr", conn.RemoteAddr().String(), "response", response)
 
-		if _, err = io.Copy(conn, response.RESPReader()); err != nil {
+		err = client.write(response)
+		if errors.Is(err, resp.ErrUnencodable) {
+			// a reply holding a value of a type that has no encoding, the client gets an error rather than no reply
+			h.logger.Error("encode response failed", "remote_addr", conn.RemoteAddr().String(), "error", err)
+			err = client.write(errorReply(err))
+		}
+		if err != nil {
 			h.logger.Error("write ok message to client failed", "error", err)
 		}
 	}
 }
+
+// errorReply returns the reply of a failed command, errors without a prefix being sent with ERR.
+func errorReply(err error) resp.SimpleError {
+	var respErr resp.SimpleError
+	if errors.As(err, &respErr) {
+		return respErr
+	}
+	return resp.NewSimpleError(err.Error())
+}
+
+// setIdleDeadline closes the connection once it has been idle for longer than the timeout setting.
+func (h *Handler) setIdleDeadline(conn net.Conn) {
+	var deadline time.Time
+	if timeout := h.config.Config().Timeout; timeout > 0 {
+		deadline = time.Now().Add(time.Duration(timeout) * time.Second)
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		h.logger.Warn("set read deadline failed", "remote_addr", conn.RemoteAddr().String(), "error", err)
+	}
+}
+
+// db returns the database selected by the client.
+func (h *Handler) db(c *Client) store.Store {
+	return h.dbs.get(c.db)
+}
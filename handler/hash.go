@@ -0,0 +1,418 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// the background expiration of the stores reclaims the expired fields of hashes
+var _ store.FieldExpirer = (*object.Hash)(nil)
+
+// replies of the hash field expiration commands for each field
+const (
+	fieldMissing   = -2
+	fieldNoExpire  = -1
+	fieldNotMet    = 0
+	fieldUpdated   = 1
+	fieldExpiredAt = 2
+)
+
+// hashOf returns the hash held by entry, a missing key is reported as a nil hash.
+func hashOf(entry store.Entry, exists bool) (*object.Hash, error) {
+	if !exists {
+		return nil, nil
+	}
+	hash, ok := entry.Value.(*object.Hash)
+	if !ok {
+		return nil, errWrongType
+	}
+	return hash, nil
+}
+
+// withHash runs fn on the hash at key once its expired fields are reclaimed, and reports whether the key exists.
+// a missing key is created for fn when create is true, fn is not called otherwise.
+// fn returns the hash events to publish, none meaning it did not modify the hash, which is deleted if left empty.
+func (h *Handler) withHash(db int, key string, create bool, fn func(hash *object.Hash) ([]string, error)) (bool, error) {
+	var err error
+	var events []string
+	found, expired, deleted := false, false, false
+	h.dbs.get(db).Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var hash *object.Hash
+		hash, err = hashOf(entry, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+
+		if hash != nil {
+			n, empty := hash.ExpireFields(h.clock.Now())
+			expired = n > 0
+			if empty {
+				hash, deleted = nil, true
+			}
+		}
+		if hash == nil {
+			if !create {
+				return entry, store.OpDelete
+			}
+			hash = object.NewHash()
+			entry = store.Entry{Value: hash}
+		}
+		found = true
+
+		events, err = fn(hash)
+		switch {
+		case hash.Len() == 0:
+			deleted = true
+			return entry, store.OpDelete
+		case len(events) > 0 || expired:
+			deleted = false
+			return entry, store.OpSet
+		default:
+			return entry, store.OpKeep
+		}
+	})
+
+	if expired {
+		h.notifyKeyspaceEvent(pubsub.ClassHash, "hexpired", key, db)
+	}
+	for _, event := range events {
+		h.notifyKeyspaceEvent(pubsub.ClassHash, event, key, db)
+	}
+	if deleted {
+		h.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key, db)
+	}
+	return found, err
+}
+
+// FieldsExpired is called by the stores when their background expiration removes fields of a hash,
+// deleted telling whether the hash was left empty and removed.
+func (h *Handler) FieldsExpired(db store.Store, key string, deleted bool) {
+	h.notifyStoreEvent(pubsub.ClassHash, "hexpired", key, db)
+	if deleted {
+		h.notifyStoreEvent(pubsub.ClassGeneric, "del", key, db)
+	}
+}
+
+func (h *Handler) handleHSet(c *Client, args resp.Array) (resp.Payload, error) {
+	key, pairs, err := resp.ParseHSetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	added := 0
+	_, err = h.withHash(c.db, key, true, func(hash *object.Hash) ([]string, error) {
+		for i := 0; i < len(pairs); i += 2 {
+			if hash.Set(pairs[i], pairs[i+1]) {
+				added++
+			}
+		}
+		return []string{"hset"}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(added), nil
+}
+
+func (h *Handler) handleHGet(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	var result resp.Payload = resp.NULL
+	_, err = h.withHash(c.db, strs[0], false, func(hash *object.Hash) ([]string, error) {
+		if value, ok := hash.Get(strs[1]); ok {
+			result = resp.BulkString(value)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (h *Handler) handleHDel(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := 0
+	_, err = h.withHash(c.db, strs[0], false, func(hash *object.Hash) ([]string, error) {
+		for _, field := range strs[1:] {
+			if hash.Delete(field) {
+				deleted++
+			}
+		}
+		if deleted == 0 {
+			return nil, nil
+		}
+		return []string{"hdel"}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(deleted), nil
+}
+
+func (h *Handler) handleHLen(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	length := 0
+	_, err = h.withHash(c.db, key.String(), false, func(hash *object.Hash) ([]string, error) {
+		length = hash.Len()
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(length), nil
+}
+
+func (h *Handler) handleHExists(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	exists := false
+	_, err = h.withHash(c.db, strs[0], false, func(hash *object.Hash) ([]string, error) {
+		_, exists = hash.Get(strs[1])
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return resp.Integer(1), nil
+	}
+	return resp.Integer(0), nil
+}
+
+func (h *Handler) handleHGetAll(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashContent(c, args, true, true)
+}
+
+func (h *Handler) handleHKeys(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashContent(c, args, true, false)
+}
+
+func (h *Handler) handleHVals(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashContent(c, args, false, true)
+}
+
+// hashContent replies the fields and/or the values of a hash, as HGETALL, HKEYS and HVALS do.
+func (h *Handler) hashContent(c *Client, args resp.Array, withFields, withValues bool) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	result := resp.Array{}
+	_, err = h.withHash(c.db, key.String(), false, func(hash *object.Hash) ([]string, error) {
+		hash.Range(func(field, value string) bool {
+			if withFields {
+				result = append(result, resp.BulkString(field))
+			}
+			if withValues {
+				result = append(result, resp.BulkString(value))
+			}
+			return true
+		})
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (h *Handler) handleHExpire(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashExpire(c, args, time.Second, false)
+}
+
+func (h *Handler) handleHPExpire(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashExpire(c, args, time.Millisecond, false)
+}
+
+func (h *Handler) handleHExpireAt(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashExpire(c, args, time.Second, true)
+}
+
+func (h *Handler) handleHPExpireAt(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashExpire(c, args, time.Millisecond, true)
+}
+
+// fieldReplies returns the reply of a field expiration command on a missing key.
+func fieldReplies(fields []string, reply int) resp.Array {
+	result := make(resp.Array, len(fields))
+	for i := range result {
+		result[i] = resp.Integer(reply)
+	}
+	return result
+}
+
+func (h *Handler) hashExpire(c *Client, args resp.Array, unit time.Duration, absolute bool) (resp.Payload, error) {
+	parsed, err := resp.ParseHExpireArgs(args, unit, absolute, h.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	c.args = hashExpireAt(parsed)
+
+	result := fieldReplies(parsed.Fields, fieldMissing)
+	_, err = h.withHash(c.db, parsed.Key, false, func(hash *object.Hash) ([]string, error) {
+		now := h.clock.Now()
+		updated, expired := false, false
+		for i, field := range parsed.Fields {
+			reply := expireField(hash, field, parsed.ExpireAt, parsed.Condition, now)
+			switch reply {
+			case fieldUpdated:
+				updated = true
+			case fieldExpiredAt:
+				expired = true
+			}
+			result[i] = resp.Integer(reply)
+		}
+
+		var events []string
+		if updated {
+			events = append(events, "hexpire")
+		}
+		// fields whose expiration is already elapsed are deleted right away
+		if expired {
+			events = append(events, "hexpired")
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// hashExpireAt rewrites the expiration of fields for replicas with its deadline in milliseconds.
+func hashExpireAt(parsed *resp.HExpireArgs) resp.Array {
+	args := resp.Array{resp.HPEXPIREAT, resp.BulkString(parsed.Key), resp.BulkString(strconv.FormatInt(parsed.ExpireAt.UnixMilli(), 10))}
+	if parsed.Condition != "" {
+		args = append(args, parsed.Condition)
+	}
+	args = append(args, resp.FIELDS, resp.BulkString(strconv.Itoa(len(parsed.Fields))))
+	for _, field := range parsed.Fields {
+		args = append(args, resp.BulkString(field))
+	}
+	return args
+}
+
+// expireField sets the expiration of field to at when condition allows it, and returns the reply for the field.
+func expireField(hash *object.Hash, field string, at time.Time, condition resp.BulkString, now time.Time) int {
+	current, ok := hash.ExpireAt(field)
+	if !ok {
+		return fieldMissing
+	}
+
+	// fields without expiration behave as if they expired at infinity
+	persistent := current.IsZero()
+	switch condition {
+	case resp.NX:
+		if !persistent {
+			return fieldNotMet
+		}
+	case resp.XX:
+		if persistent {
+			return fieldNotMet
+		}
+	case resp.GT:
+		if persistent || !at.After(current) {
+			return fieldNotMet
+		}
+	case resp.LT:
+		if !persistent && !at.Before(current) {
+			return fieldNotMet
+		}
+	}
+
+	if !at.After(now) {
+		hash.Delete(field)
+		return fieldExpiredAt
+	}
+	hash.SetExpireAt(field, at)
+	return fieldUpdated
+}
+
+func (h *Handler) handleHTTL(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashTTL(c, args, time.Second)
+}
+
+func (h *Handler) handleHPTTL(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.hashTTL(c, args, time.Millisecond)
+}
+
+func (h *Handler) hashTTL(c *Client, args resp.Array, unit time.Duration) (resp.Payload, error) {
+	key, fields, err := resp.ParseHFieldsArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	result := fieldReplies(fields, fieldMissing)
+	_, err = h.withHash(c.db, key, false, func(hash *object.Hash) ([]string, error) {
+		now := h.clock.Now()
+		for i, field := range fields {
+			at, ok := hash.ExpireAt(field)
+			switch {
+			case !ok:
+			case at.IsZero():
+				result[i] = resp.Integer(fieldNoExpire)
+			default:
+				// remaining seconds are rounded up, like Redis does
+				result[i] = resp.Integer((at.Sub(now) + unit - time.Millisecond) / unit)
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (h *Handler) handleHPersist(c *Client, args resp.Array) (resp.Payload, error) {
+	key, fields, err := resp.ParseHFieldsArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	result := fieldReplies(fields, fieldMissing)
+	_, err = h.withHash(c.db, key, false, func(hash *object.Hash) ([]string, error) {
+		persisted := false
+		for i, field := range fields {
+			switch {
+			case hash.Persist(field):
+				result[i] = resp.Integer(fieldUpdated)
+				persisted = true
+			default:
+				if _, ok := hash.Get(field); ok {
+					result[i] = resp.Integer(fieldNoExpire)
+				}
+			}
+		}
+		if !persisted {
+			return nil, nil
+		}
+		return []string{"hpersist"}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PlayerNeo42/gvalkey/internal/hll"
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+var (
+	errNotHLL       = resp.NewPrefixedError("WRONGTYPE", "Key is not a valid HyperLogLog string value.")
+	errCorruptedHLL = resp.NewPrefixedError("INVALIDOBJ", "Corrupted HLL object detected")
+)
+
+// sketchOf decodes the HyperLogLog held by value, a missing key is reported as a nil sketch.
+func sketchOf(value any, exists bool) (*hll.Sketch, error) {
+	if !exists {
+		return nil, nil
+	}
+	s, err := stringOf(value, exists)
+	if err != nil {
+		return nil, err
+	}
+
+	sk, err := hll.Parse(s)
+	switch {
+	case errors.Is(err, hll.ErrInvalid):
+		return nil, errNotHLL
+	case errors.Is(err, hll.ErrCorrupted):
+		return nil, errCorruptedHLL
+	}
+	return sk, err
+}
+
+func (h *Handler) handlePFAdd(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	key, elements := strs[0], strs[1:]
+
+	updated := false
+	h.db(c).Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var sk *hll.Sketch
+		sk, err = sketchOf(entry.Value, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		if sk == nil {
+			sk = hll.New()
+			updated = true
+		}
+
+		for _, element := range elements {
+			if sk.Add(element) {
+				updated = true
+			}
+		}
+		if !updated {
+			return entry, store.OpKeep
+		}
+		entry.Value = resp.BulkString(sk.Bytes(hll.SparseMaxBytes))
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !updated {
+		return resp.Integer(0), nil
+	}
+	h.notifyKeyspaceEvent(pubsub.ClassString, "pfadd", key, c.db)
+	return resp.Integer(1), nil
+}
+
+func (h *Handler) handlePFCount(c *Client, args resp.Array) (resp.Payload, error) {
+	keys, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 1 {
+		merged, err := h.mergeSketches(c, keys)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Integer(merged.Count()), nil
+	}
+
+	var count uint64
+	h.db(c).Compute(keys[0], func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var sk *hll.Sketch
+		sk, err = sketchOf(entry.Value, exists)
+		if err != nil || sk == nil {
+			return entry, store.OpKeep
+		}
+
+		// like Redis, the cardinality is cached in the header until the next change
+		stale := sk.Stale()
+		count = sk.Count()
+		if !stale {
+			return entry, store.OpKeep
+		}
+		entry.Value = resp.BulkString(sk.Bytes(hll.SparseMaxBytes))
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(count), nil
+}
+
+// mergeSketches returns the union of the HyperLogLogs stored at keys, missing keys being empty.
+func (h *Handler) mergeSketches(c *Client, keys []string) (*hll.Sketch, error) {
+	merged := hll.New()
+	for _, key := range keys {
+		value, exists := h.db(c).Get(key)
+		sk, err := sketchOf(value, exists)
+		if err != nil {
+			return nil, err
+		}
+		if sk != nil {
+			merged.Merge(sk)
+		}
+	}
+	return merged, nil
+}
+
+func (h *Handler) handlePFMerge(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	dest := strs[0]
+	merged, err := h.mergeSketches(c, strs[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	h.db(c).Compute(dest, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		// the destination is part of the union
+		var sk *hll.Sketch
+		sk, err = sketchOf(entry.Value, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		if sk == nil {
+			sk = hll.New()
+		}
+		sk.Merge(merged)
+		entry.Value = resp.BulkString(sk.Bytes(hll.SparseMaxBytes))
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.notifyKeyspaceEvent(pubsub.ClassString, "pfadd", dest, c.db)
+	return resp.OK, nil
+}
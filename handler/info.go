@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/version"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+// infoSection is one "# Name" block of the INFO reply.
+type infoSection struct {
+	name string
+	// whether the section is part of the reply when no section is requested
+	isDefault bool
+	render    func(h *Handler, w *infoWriter)
+}
+
+// infoSections lists the sections in the order Redis prints them.
+var infoSections = []infoSection{
+	{"server", true, (*Handler).infoServer},
+	{"clients", true, (*Handler).infoClients},
+	{"memory", true, (*Handler).infoMemory},
+	{"stats", true, (*Handler).infoStats},
+	{"replication", true, (*Handler).infoReplication},
+	{"cluster", true, (*Handler).infoCluster},
+	{"keyspace", true, (*Handler).infoKeyspace},
+}
+
+func (h *Handler) handleInfo(c *Client, args resp.Array) (resp.Payload, error) {
+	requested, err := resp.ParseInfoArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &infoWriter{}
+	for _, section := range infoSections {
+		if !isInfoSectionRequested(section, requested) {
+			continue
+		}
+		w.section(section.name)
+		section.render(h, w)
+	}
+
+	return resp.BulkString(w.String()), nil
+}
+
+func isInfoSectionRequested(section infoSection, requested []string) bool {
+	if len(requested) == 0 {
+		return section.isDefault
+	}
+	for _, name := range requested {
+		switch name {
+		case "all", "everything":
+			return true
+		case "default":
+			if section.isDefault {
+				return true
+			}
+		}
+	}
+	return slices.Contains(requested, section.name)
+}
+
+func (h *Handler) infoServer(w *infoWriter) {
+	uptime := h.stats.Uptime()
+
+	w.field("redis_version", version.RedisVersion)
+	w.field("gvalkey_version", version.Version)
+	w.field("redis_mode", "standalone")
+	w.field("os", runtime.GOOS+" "+runtime.GOARCH)
+	w.field("arch_bits", strconv.IntSize)
+	w.field("go_version", runtime.Version())
+	w.field("process_id", os.Getpid())
+	w.field("run_id", h.stats.RunID())
+	w.field("tcp_port", h.config.Config().Port)
+	w.field("server_time_usec", time.Now().UnixMicro())
+	w.field("uptime_in_seconds", int64(uptime/time.Second))
+	w.field("uptime_in_days", int64(uptime/(24*time.Hour)))
+	w.field("config_file", h.config.File())
+}
+
+func (h *Handler) infoClients(w *infoWriter) {
+	w.field("connected_clients", h.stats.ConnectedClients.Load())
+	w.field("blocked_clients", h.blocking.len())
+}
+
+func (h *Handler) infoMemory(w *infoWriter) {
+	mem := h.stats.Memory()
+	conf := h.config.Config()
+
+	w.field("used_memory", mem.Used)
+	w.field("used_memory_human", bytesToHuman(mem.Used))
+	w.field("used_memory_rss", mem.System)
+	w.field("used_memory_rss_human", bytesToHuman(mem.System))
+	w.field("used_memory_peak", mem.Peak)
+	w.field("used_memory_peak_human", bytesToHuman(mem.Peak))
+	w.field("maxmemory", int64(conf.MaxMemory))
+	w.field("maxmemory_human", bytesToHuman(uint64(conf.MaxMemory)))
+	w.field("maxmemory_policy", conf.MaxMemoryPolicy)
+	w.field("mem_allocator", "go")
+}
+
+func (h *Handler) infoStats(w *infoWriter) {
+	w.field("total_connections_received", h.stats.TotalConnections.Load())
+	w.field("total_commands_processed", h.stats.TotalCommands.Load())
+	w.field("total_net_input_bytes", h.stats.NetInputBytes.Load())
+	w.field("total_net_output_bytes", h.stats.NetOutputBytes.Load())
+	w.field("expired_keys", h.stats.ExpiredKeys.Load())
+	var expire store.ExpireStats
+	for _, db := range h.dbs.all() {
+		st := db.ExpireStats()
+		expire.TimeCapReached += st.TimeCapReached
+		expire.CycleTime += st.CycleTime
+	}
+	w.field("expired_time_cap_reached_count", expire.TimeCapReached)
+	w.field("expire_cycle_cpu_milliseconds", expire.CycleTime.Milliseconds())
+	w.field("evicted_keys", h.stats.EvictedKeys.Load())
+	w.field("keyspace_hits", h.stats.KeyspaceHits.Load())
+	w.field("keyspace_misses", h.stats.KeyspaceMisses.Load())
+	w.field("sync_full", h.stats.SyncFull.Load())
+	w.field("sync_partial_ok", h.stats.SyncPartialOK.Load())
+	w.field("sync_partial_err", h.stats.SyncPartialErr.Load())
+}
+
+func (h *Handler) infoKeyspace(w *infoWriter) {
+	for i, db := range h.dbs.all() {
+		st := db.Stats()
+		if st.Keys == 0 {
+			continue
+		}
+		w.field("db"+strconv.Itoa(i), fmt.Sprintf("keys=%d,expires=%d,avg_ttl=0", st.Keys, st.Expires))
+	}
+}
+
+// infoWriter builds the INFO reply in the "key:value\r\n" text format.
+type infoWriter struct {
+	strings.Builder
+}
+
+func (w *infoWriter) section(name string) {
+	if w.Len() > 0 {
+		w.WriteString("\r\n")
+	}
+	w.WriteString("# ")
+	w.WriteString(strings.ToUpper(name[:1]))
+	w.WriteString(name[1:])
+	w.WriteString("\r\n")
+}
+
+func (w *infoWriter) field(key string, value any) {
+	fmt.Fprintf(w, "%s:%v\r\n", key, value)
+}
+
+// bytesToHuman formats a byte count the way Redis does, e.g. 1.50M.
+func bytesToHuman(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"K", "M", "G", "T", "P"} {
+		value /= unit
+		if value < unit {
+			return fmt.Sprintf("%.2f%s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%.2fE", value/unit)
+}
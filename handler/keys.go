@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// keysFunc returns the keys a command accesses, nil when its arguments cannot be parsed as the command will fail anyway.
+type keysFunc func(args resp.Array) []string
+
+// keyRange returns the keys from the argument first to last, every step arguments.
+// a negative last counts from the end, -1 being the last argument, like in the command table of Redis.
+func keyRange(first, last, step int) keysFunc {
+	return func(args resp.Array) []string {
+		end := last
+		if end < 0 {
+			end += len(args)
+		}
+		var keys []string
+		for i := first; i <= end && i < len(args); i += step {
+			key, ok := args[i].(resp.BulkString)
+			if !ok {
+				return nil
+			}
+			keys = append(keys, string(key))
+		}
+		return keys
+	}
+}
+
+var (
+	firstKey = keyRange(1, 1, 1)
+	allKeys  = keyRange(1, -1, 1)
+)
+
+// commandKeys locates the keys of the commands accessing keys, so that cluster nodes redirect the commands of the slots they do not serve
+// and the lookups of read-only commands are counted, see countLookups.
+var commandKeys = map[resp.BulkString]keysFunc{
+	resp.GET:            firstKey,
+	resp.SET:            firstKey,
+	resp.DEL:            allKeys,
+	resp.STRLEN:         firstKey,
+	resp.TYPE:           firstKey,
+	resp.MOVE:           firstKey,
+	resp.LPUSH:          firstKey,
+	resp.RPUSH:          firstKey,
+	resp.LPOP:           firstKey,
+	resp.RPOP:           firstKey,
+	resp.LLEN:           firstKey,
+	resp.LRANGE:         firstKey,
+	resp.LMOVE:          keyRange(1, 2, 1),
+	resp.RPOPLPUSH:      keyRange(1, 2, 1),
+	resp.BLPOP:          keyRange(1, -2, 1),
+	resp.BRPOP:          keyRange(1, -2, 1),
+	resp.BLMOVE:         keyRange(1, 2, 1),
+	resp.BRPOPLPUSH:     keyRange(1, 2, 1),
+	resp.SETBIT:         firstKey,
+	resp.GETBIT:         firstKey,
+	resp.BITCOUNT:       firstKey,
+	resp.BITPOS:         firstKey,
+	resp.BITOP:          keyRange(2, -1, 1),
+	resp.BITFIELD:       firstKey,
+	resp.BITFIELDRO:     firstKey,
+	resp.PFADD:          firstKey,
+	resp.PFCOUNT:        allKeys,
+	resp.PFMERGE:        allKeys,
+	resp.GEOADD:         firstKey,
+	resp.GEOPOS:         firstKey,
+	resp.GEODIST:        firstKey,
+	resp.GEOHASH:        firstKey,
+	resp.GEOSEARCH:      firstKey,
+	resp.GEOSEARCHSTORE: keyRange(1, 2, 1),
+	resp.HSET:           firstKey,
+	resp.HGET:           firstKey,
+	resp.HDEL:           firstKey,
+	resp.HLEN:           firstKey,
+	resp.HEXISTS:        firstKey,
+	resp.HGETALL:        firstKey,
+	resp.HKEYS:          firstKey,
+	resp.HVALS:          firstKey,
+	resp.HEXPIRE:        firstKey,
+	resp.HPEXPIRE:       firstKey,
+	resp.HEXPIREAT:      firstKey,
+	resp.HPEXPIREAT:     firstKey,
+	resp.HTTL:           firstKey,
+	resp.HPTTL:          firstKey,
+	resp.HPERSIST:       firstKey,
+	resp.XADD:           firstKey,
+	resp.XLEN:           firstKey,
+	resp.XRANGE:         firstKey,
+	resp.XREVRANGE:      firstKey,
+	resp.XDEL:           firstKey,
+	resp.XTRIM:          firstKey,
+	resp.XSETID:         firstKey,
+	resp.XREAD:          streamKeys(false),
+	resp.XREADGROUP:     streamKeys(true),
+	resp.XGROUP:         keyRange(2, 2, 1),
+	resp.XACK:           firstKey,
+	resp.XPENDING:       firstKey,
+	resp.XCLAIM:         firstKey,
+	resp.XAUTOCLAIM:     firstKey,
+	resp.XINFO:          keyRange(2, 2, 1),
+	resp.EVAL:           scriptKeys,
+	resp.EVALRO:         scriptKeys,
+	resp.EVALSHA:        scriptKeys,
+	resp.EVALSHARO:      scriptKeys,
+	resp.MIGRATE:        migrateKeys,
+	resp.DUMP:           firstKey,
+	resp.RESTORE:        firstKey,
+	resp.RESTOREASKING:  firstKey,
+}
+
+// streamKeys returns the keys following the STREAMS option of XREAD, or of XREADGROUP when group is true.
+func streamKeys(group bool) keysFunc {
+	return func(args resp.Array) []string {
+		parsed, err := resp.ParseXReadArgs(args, group)
+		if err != nil {
+			return nil
+		}
+		return parsed.Keys
+	}
+}
+
+func scriptKeys(args resp.Array) []string {
+	parsed, err := resp.ParseEvalArgs(args)
+	if err != nil {
+		return nil
+	}
+	return parsed.Keys
+}
+
+func migrateKeys(args resp.Array) []string {
+	parsed, err := resp.ParseMigrateArgs(args)
+	if err != nil {
+		return nil
+	}
+	return parsed.Keys
+}
+
+// readingWrites are the write commands reading their keys like read-only commands, PFCOUNT only writing back its cache.
+var readingWrites = map[resp.BulkString]bool{
+	resp.PFCOUNT: true,
+}
+
+// countLookups counts a keyspace hit or miss for every key read by a read-only command, publishing keymiss events for the misses.
+// scripts are left out, the commands they run being counted instead.
+func (h *Handler) countLookups(c *Client, cmd *Command, args resp.Array) {
+	if (!cmd.Has(FlagReadOnly) && !readingWrites[cmd.Name]) || cmd.Has(FlagNoScript) {
+		return
+	}
+	keysOf, ok := commandKeys[cmd.Name]
+	if !ok {
+		return
+	}
+	for _, key := range keysOf(args) {
+		if _, ok := h.db(c).Get(key); ok {
+			h.stats.KeyspaceHits.Add(1)
+			continue
+		}
+		h.stats.KeyspaceMisses.Add(1)
+		h.notifyKeyspaceEvent(pubsub.ClassKeyMiss, "keymiss", key, c.db)
+	}
+}
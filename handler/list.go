@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// listOf returns the list held by entry, a missing key is reported as a nil list.
+func listOf(entry store.Entry, exists bool) (*object.List, error) {
+	if !exists {
+		return nil, nil
+	}
+	l, ok := entry.Value.(*object.List)
+	if !ok {
+		return nil, errWrongType
+	}
+	return l, nil
+}
+
+func sideName(left bool, event string) string {
+	if left {
+		return "l" + event
+	}
+	return "r" + event
+}
+
+// push adds values to the list at key, creating it if needed, and returns its new length.
+// callers signal the key to blocked clients once they are done with it.
+func (h *Handler) push(db int, key string, values []string, left bool) (int, error) {
+	var length int
+	var err error
+	h.dbs.get(db).Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var l *object.List
+		l, err = listOf(entry, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		if l == nil {
+			l = object.NewList()
+			entry = store.Entry{Value: l}
+		}
+
+		if left {
+			l.PushLeft(values...)
+		} else {
+			l.PushRight(values...)
+		}
+		length = l.Len()
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	h.notifyKeyspaceEvent(pubsub.ClassList, sideName(left, "push"), key, db)
+	return length, nil
+}
+
+// pop removes up to count elements from the list at key, deleting the key once the list is empty.
+// it returns nil when the key does not exist.
+func (h *Handler) pop(db int, key string, left bool, count int) ([]string, error) {
+	var values []string
+	var err error
+	deleted := false
+	h.dbs.get(db).Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var l *object.List
+		l, err = listOf(entry, exists)
+		if err != nil || l == nil {
+			return entry, store.OpKeep
+		}
+
+		values = make([]string, 0, min(count, l.Len()))
+		for range count {
+			var v string
+			var ok bool
+			if left {
+				v, ok = l.PopLeft()
+			} else {
+				v, ok = l.PopRight()
+			}
+			if !ok {
+				break
+			}
+			values = append(values, v)
+		}
+
+		if l.Len() == 0 {
+			deleted = true
+			return entry, store.OpDelete
+		}
+		return entry, store.OpSet
+	})
+	if err != nil || values == nil {
+		return nil, err
+	}
+
+	if len(values) > 0 {
+		h.notifyKeyspaceEvent(pubsub.ClassList, sideName(left, "pop"), key, db)
+	}
+	if deleted {
+		h.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key, db)
+	}
+	return values, nil
+}
+
+// move pops an element from src and pushes it to dst, as LMOVE does.
+// it must run under the exclusive execution lock, so that no command sees the element in neither list
+// nor changes dst in between. callers signal dst to blocked clients once they are done with it.
+func (h *Handler) move(db int, src, dst string, fromLeft, toLeft bool) (string, bool, error) {
+	// like Redis, the destination type is checked first so that nothing is popped for nothing,
+	// the push cannot fail once the element is popped
+	var err error
+	h.dbs.get(db).Compute(dst, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		_, err = listOf(entry, exists)
+		return entry, store.OpKeep
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	values, err := h.pop(db, src, fromLeft, 1)
+	if err != nil || len(values) == 0 {
+		return "", false, err
+	}
+
+	if _, err := h.push(db, dst, values, toLeft); err != nil {
+		return "", false, err
+	}
+	return values[0], true, nil
+}
+
+func (h *Handler) handleLPush(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handlePush(c, args, true)
+}
+
+func (h *Handler) handleRPush(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handlePush(c, args, false)
+}
+
+func (h *Handler) handlePush(c *Client, args resp.Array, left bool) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := h.push(c.db, strs[0], strs[1:], left)
+	if err != nil {
+		return nil, err
+	}
+	h.signalKeyReady(c, c.db, strs[0])
+	return resp.Integer(length), nil
+}
+
+func (h *Handler) handleLPop(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handlePop(c, args, true)
+}
+
+func (h *Handler) handleRPop(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handlePop(c, args, false)
+}
+
+func (h *Handler) handlePop(c *Client, args resp.Array, left bool) (resp.Payload, error) {
+	if len(args) > 3 {
+		return nil, errSyntax
+	}
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// without count, a single element is returned instead of an array
+	count := int64(1)
+	if len(args) == 3 {
+		count, err = resp.ParseInteger(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, errors.New("value is out of range, must be positive")
+		}
+	}
+
+	values, err := h.pop(c.db, key.String(), left, int(count))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 2 {
+		if len(values) == 0 {
+			return resp.NULL, nil
+		}
+		return resp.BulkString(values[0]), nil
+	}
+	if values == nil {
+		return resp.NullArray{}, nil
+	}
+	return bulkStrings(values), nil
+}
+
+func (h *Handler) handleLLen(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var length int
+	h.db(c).Compute(key.String(), func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var l *object.List
+		l, err = listOf(entry, exists)
+		if l != nil {
+			length = l.Len()
+		}
+		return entry, store.OpKeep
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(length), nil
+}
+
+func (h *Handler) handleLRange(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	start, err := resp.ParseInteger(args[2])
+	if err != nil {
+		return nil, err
+	}
+	stop, err := resp.ParseInteger(args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	h.db(c).Compute(key.String(), func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var l *object.List
+		l, err = listOf(entry, exists)
+		if l != nil {
+			values = l.Range(int(start), int(stop))
+		}
+		return entry, store.OpKeep
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bulkStrings(values), nil
+}
+
+func (h *Handler) handleLMove(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:3])
+	if err != nil {
+		return nil, err
+	}
+	fromLeft, err := resp.ParseListSide(args[3])
+	if err != nil {
+		return nil, err
+	}
+	toLeft, err := resp.ParseListSide(args[4])
+	if err != nil {
+		return nil, err
+	}
+
+	return h.lmove(c, strs[0], strs[1], fromLeft, toLeft)
+}
+
+func (h *Handler) handleRPopLPush(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return h.lmove(c, strs[0], strs[1], false, true)
+}
+
+func (h *Handler) lmove(c *Client, src, dst string, fromLeft, toLeft bool) (resp.Payload, error) {
+	value, ok, err := h.move(c.db, src, dst, fromLeft, toLeft)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return resp.NULL, nil
+	}
+	h.signalKeyReady(c, c.db, dst)
+	return resp.BulkString(value), nil
+}
+
+func bulkStrings(values []string) resp.Array {
+	result := make(resp.Array, len(values))
+	for i, v := range values {
+		result[i] = resp.BulkString(v)
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+func (h *Handler) handleBLPop(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handleBlockingPop(c, args, true)
+}
+
+func (h *Handler) handleBRPop(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handleBlockingPop(c, args, false)
+}
+
+func (h *Handler) handleBlockingPop(c *Client, args resp.Array, left bool) (resp.Payload, error) {
+	keys, err := resp.ParseStrings(args[1 : len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := resp.ParseTimeout(args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	db := c.db
+	return h.block(c, keys, timeout, func() (resp.Payload, bool, error) {
+		// keys are tried in the order they were given, like Redis does
+		for _, key := range keys {
+			values, err := h.pop(db, key, left, 1)
+			if err != nil {
+				return nil, false, err
+			}
+			if len(values) > 0 {
+				return resp.Array{resp.BulkString(key), resp.BulkString(values[0])}, true, nil
+			}
+		}
+		return nil, false, nil
+	})
+}
+
+func (h *Handler) handleBLMove(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:3])
+	if err != nil {
+		return nil, err
+	}
+	fromLeft, err := resp.ParseListSide(args[3])
+	if err != nil {
+		return nil, err
+	}
+	toLeft, err := resp.ParseListSide(args[4])
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := resp.ParseTimeout(args[5])
+	if err != nil {
+		return nil, err
+	}
+
+	return h.blockingMove(c, strs[0], strs[1], fromLeft, toLeft, timeout)
+}
+
+func (h *Handler) handleBRPopLPush(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:3])
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := resp.ParseTimeout(args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	return h.blockingMove(c, strs[0], strs[1], false, true, timeout)
+}
+
+func (h *Handler) blockingMove(c *Client, src, dst string, fromLeft, toLeft bool, timeout time.Duration) (resp.Payload, error) {
+	db := c.db
+	payload, err := h.block(c, []string{src}, timeout, func() (resp.Payload, bool, error) {
+		value, ok, err := h.move(db, src, dst, fromLeft, toLeft)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		return resp.BulkString(value), true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, timedOut := payload.(resp.NullArray); timedOut {
+		// unlike the pops, the moves reply with a null bulk string on timeout
+		return resp.NULL, nil
+	}
+	// the moved element may serve clients blocked on the destination
+	h.signalKeyReady(c, db, dst)
+	return payload, nil
+}
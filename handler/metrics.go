@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/PlayerNeo42/gvalkey/internal/stats"
+)
+
+// MetricsHandler serves the metrics of the server in the Prometheus text format.
+func (h *Handler) MetricsHandler() http.Handler {
+	return h.metrics.Handler()
+}
+
+// countingConn counts the bytes exchanged with a client.
+type countingConn struct {
+	net.Conn
+	stats *stats.Stats
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.stats.NetInputBytes.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.stats.NetOutputBytes.Add(int64(n))
+	return n, err
+}
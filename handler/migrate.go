@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+	"github.com/PlayerNeo42/gvalkey/store/rdb"
+)
+
+var (
+	errBusyKey    = resp.NewPrefixedError("BUSYKEY", "Target key name already exists.")
+	errBadPayload = resp.NewSimpleError("DUMP payload version or checksum are wrong")
+	errBadFormat  = resp.NewSimpleError("Bad data format")
+)
+
+// dumpedKey is a key serialized by DUMP, along with its expiration.
+type dumpedKey struct {
+	key      string
+	payload  []byte
+	expireAt time.Time
+}
+
+// dumpKey serializes the value of key, read while its writers are held back as values such as lists are mutable.
+func (h *Handler) dumpKey(db store.Store, key string) (*dumpedKey, error) {
+	var dumped *dumpedKey
+	var err error
+	db.Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		if !exists {
+			return entry, store.OpKeep
+		}
+		var payload []byte
+		if payload, err = rdb.Dump(entry.Value, h.clock); err == nil {
+			dumped = &dumpedKey{key: key, payload: payload, expireAt: entry.ExpireAt}
+		}
+		return entry, store.OpKeep
+	})
+	return dumped, err
+}
+
+func (h *Handler) handleDump(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	dumped, err := h.dumpKey(h.db(c), key.String())
+	if err != nil {
+		return nil, err
+	}
+	if dumped == nil {
+		return resp.NULL, nil
+	}
+	return resp.BulkString(dumped.payload), nil
+}
+
+// handleRestore creates a key from a DUMP payload, RESTORE-ASKING being the same command for the migration of cluster slots.
+func (h *Handler) handleRestore(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseRestoreArgs(args, h.clock)
+	if err != nil {
+		return nil, err
+	}
+	value, err := rdb.Restore(parsed.Payload)
+	switch {
+	case errors.Is(err, rdb.ErrBadPayload):
+		return nil, errBadPayload
+	case err != nil:
+		return nil, errBadFormat
+	}
+
+	if _, exists := h.db(c).Get(parsed.Key); exists && !parsed.Replace {
+		return nil, errBusyKey
+	}
+
+	// replicas get the deadline of the expiration rather than the TTL, which they would apply later
+	c.args = resp.Array{resp.RESTORE, resp.BulkString(parsed.Key), resp.BulkString("0"), resp.BulkString(parsed.Payload), resp.REPLACE}
+	if !parsed.ExpireAt.IsZero() {
+		if !parsed.ExpireAt.After(h.clock.Now()) {
+			// the key is expired already, it is rather deleted
+			if h.db(c).Del(parsed.Key) {
+				h.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", parsed.Key, c.db)
+			}
+			c.args = resp.Array{resp.DEL, resp.BulkString(parsed.Key)}
+			return resp.OK, nil
+		}
+		c.args[2] = resp.BulkString(strconv.FormatInt(parsed.ExpireAt.UnixMilli(), 10))
+		c.args = append(c.args, resp.ABSTTL)
+	}
+
+	h.db(c).Set(resp.SetArgs{Key: resp.BulkString(parsed.Key), Value: value, ExpireAt: parsed.ExpireAt})
+	h.notifyKeyspaceEvent(pubsub.ClassGeneric, "restore", parsed.Key, c.db)
+	switch value.(type) {
+	case *object.List, *object.Stream:
+		h.signalKeyReady(c, c.db, parsed.Key)
+	}
+	return resp.OK, nil
+}
+
+// handleMigrate moves keys to another server with RESTORE, deleting them locally once the target stored them unless COPY is given.
+// in cluster mode the target is sent RESTORE-ASKING, so that it accepts keys of the slots it imports.
+func (h *Handler) handleMigrate(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseMigrateArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var dumped []*dumpedKey
+	for _, key := range parsed.Keys {
+		d, err := h.dumpKey(h.db(c), key)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			dumped = append(dumped, d)
+		}
+	}
+	// the local dataset changes only once keys are deleted, see below
+	c.writes = false
+	if len(dumped) == 0 {
+		return resp.SimpleString("NOKEY"), nil
+	}
+
+	restored, err := h.restoreOn(parsed, dumped)
+	if !parsed.Copy && len(restored) > 0 {
+		c.writes = true
+		c.args = resp.Array{resp.DEL}
+		for _, key := range restored {
+			if h.db(c).Del(key) {
+				h.notifyKeyspaceEvent(pubsub.ClassGeneric, "del", key, c.db)
+			}
+			c.args = append(c.args, resp.BulkString(key))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.OK, nil
+}
+
+// restoreOn sends the dumped keys to the target of MIGRATE in a single pipeline,
+// it returns the keys the target stored, along with the first error met.
+func (h *Handler) restoreOn(parsed *resp.MigrateArgs, dumped []*dumpedKey) ([]string, error) {
+	addr := net.JoinHostPort(parsed.Host, strconv.Itoa(parsed.Port))
+	conn, err := net.DialTimeout("tcp", addr, parsed.Timeout)
+	if err != nil {
+		return nil, resp.NewPrefixedError("IOERR", "error or timeout connecting to the client")
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(parsed.Timeout)); err != nil {
+		return nil, err
+	}
+
+	restore := resp.RESTORE
+	if h.cluster != nil {
+		restore = resp.RESTOREASKING
+	}
+	var pipeline []byte
+	pipeline = append(pipeline, encode(resp.Array{resp.SELECT, resp.BulkString(strconv.Itoa(parsed.DB))})...)
+	for _, d := range dumped {
+		command := resp.Array{restore, resp.BulkString(d.key), resp.BulkString("0"), resp.BulkString(d.payload)}
+		if !d.expireAt.IsZero() {
+			command[2] = resp.BulkString(strconv.FormatInt(d.expireAt.UnixMilli(), 10))
+			command = append(command, resp.ABSTTL)
+		}
+		if parsed.Replace {
+			command = append(command, resp.REPLACE)
+		}
+		pipeline = append(pipeline, encode(command)...)
+	}
+	if _, err := conn.Write(pipeline); err != nil {
+		return nil, resp.NewPrefixedError("IOERR", "error or timeout writing to target instance")
+	}
+
+	// the replies of SELECT and of every RESTORE
+	br := bufio.NewReader(conn)
+	var restored []string
+	var firstErr error
+	// keys restored in another database than the requested one are kept
+	selected := true
+	for i := -1; i < len(dumped); i++ {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return restored, resp.NewPrefixedError("IOERR", "error or timeout reading from target node")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "-"):
+			selected = selected && i >= 0
+			if firstErr == nil {
+				firstErr = resp.NewSimpleError("Target instance replied with error: " + line[1:])
+			}
+		case i >= 0 && selected:
+			restored = append(restored, dumped[i].key)
+		}
+	}
+	return restored, firstErr
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// handleMonitor streams the commands received from every client to c, which is told OK before the first of them.
+// the commands are queued, so that a monitor that does not read holds back no client.
+func (h *Handler) handleMonitor(c *Client, args resp.Array) (resp.Payload, error) {
+	c.queueWrites()
+	if err := c.write(resp.OK); err != nil {
+		return nil, err
+	}
+	h.monitors.Attach(c)
+	return noReply, nil
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+// loadKeyspaceEvents caches the classes of the notify-keyspace-events setting.
+func (h *Handler) loadKeyspaceEvents(c *config.Config) {
+	// the setting is validated by the configuration, so parsing cannot fail
+	classes, _ := pubsub.ParseEventClasses(c.NotifyKeyspaceEvents)
+	h.keyspaceEvents.Store(uint32(classes))
+}
+
+// notifyKeyspaceEvent publishes an event about key in database db, when its class is enabled.
+func (h *Handler) notifyKeyspaceEvent(class pubsub.EventClass, event, key string, db int) {
+	classes := pubsub.EventClass(h.keyspaceEvents.Load())
+	if !classes.Enabled(class) {
+		return
+	}
+
+	dbIndex := strconv.Itoa(db)
+	if classes&pubsub.ClassKeyspace != 0 {
+		h.pubsub.Publish("__keyspace@"+dbIndex+"__:"+key, event)
+	}
+	if classes&pubsub.ClassKeyevent != 0 {
+		h.pubsub.Publish("__keyevent@"+dbIndex+"__:"+event, key)
+	}
+}
+
+// storeEvent is a keyspace event reported by a store.
+type storeEvent struct {
+	class pubsub.EventClass
+	event string
+	key   string
+	db    int
+}
+
+// storeEvents holds the events reported by the stores until publishStoreEvents publishes them.
+// the stores report them with their locks held, or from their own goroutine, where publishing must not happen.
+type storeEvents struct {
+	mu      sync.Mutex
+	pending []storeEvent
+	// signaled when pending goes from empty to not empty
+	ready chan struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newStoreEvents() *storeEvents {
+	return &storeEvents{
+		ready: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// notifyStoreEvent queues an event reported by the store db, it never blocks.
+func (h *Handler) notifyStoreEvent(class pubsub.EventClass, event, key string, db store.Store) {
+	if !pubsub.EventClass(h.keyspaceEvents.Load()).Enabled(class) {
+		return
+	}
+	// the store may have been moved by SWAPDB, so its index is looked up at the time of the event
+	index, ok := h.dbs.indexOf(db)
+	if !ok {
+		return
+	}
+
+	e := h.storeEvents
+	e.mu.Lock()
+	e.pending = append(e.pending, storeEvent{class, event, key, index})
+	e.mu.Unlock()
+	select {
+	case e.ready <- struct{}{}:
+	default:
+	}
+}
+
+// publishStoreEvents publishes the events reported by the stores, in their order, until the handler is closed.
+func (h *Handler) publishStoreEvents() {
+	e := h.storeEvents
+	defer close(e.done)
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-e.ready:
+		}
+
+		e.mu.Lock()
+		pending := e.pending
+		e.pending = nil
+		e.mu.Unlock()
+
+		for _, ev := range pending {
+			h.notifyKeyspaceEvent(ev.class, ev.event, ev.key, ev.db)
+		}
+	}
+}
+
+// stopStoreEvents stops publishing the events reported by the stores.
+func (h *Handler) stopStoreEvents() {
+	close(h.storeEvents.stop)
+	<-h.storeEvents.done
+}
+
+// KeyExpired is called by the stores when a key expires, db being the store that held it.
+func (h *Handler) KeyExpired(db store.Store, key string) {
+	h.stats.ExpiredKeys.Add(1)
+	h.notifyStoreEvent(pubsub.ClassExpired, "expired", key, db)
+}
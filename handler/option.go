@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+	"github.com/PlayerNeo42/gvalkey/internal/stats"
+)
+
+type Option func(*Handler)
+
+func WithStats(st *stats.Stats) Option {
+	return func(h *Handler) {
+		h.stats = st
+	}
+}
+
+func WithConfig(registry *config.Registry) Option {
+	return func(h *Handler) {
+		h.config = registry
+	}
+}
+
+// WithClock sets the clock deciding the expirations, which must be the clock of the stores.
+func WithClock(c clock.Clock) Option {
+	return func(h *Handler) {
+		h.clock = c
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"slices"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+var (
+	subscribeKind    = resp.BulkString("subscribe")
+	unsubscribeKind  = resp.BulkString("unsubscribe")
+	psubscribeKind   = resp.BulkString("psubscribe")
+	punsubscribeKind = resp.BulkString("punsubscribe")
+)
+
+// multiReply sends several replies to a single command, as (un)subscribing does once per channel.
+type multiReply []resp.Payload
+
+func (m multiReply) AppendRESP(dst []byte) ([]byte, error) {
+	for _, p := range m {
+		var err error
+		if dst, err = p.AppendRESP(dst); err != nil {
+			return dst, err
+		}
+	}
+	return dst, nil
+}
+
+func (h *Handler) handleSubscribe(c *Client, args resp.Array) (resp.Payload, error) {
+	channels, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	c.queueWrites()
+	replies := make(multiReply, 0, len(channels))
+	for _, channel := range channels {
+		if h.pubsub.Subscribe(c, channel) {
+			c.channels[channel] = struct{}{}
+		}
+		replies = append(replies, resp.Array{subscribeKind, resp.BulkString(channel), resp.Integer(c.subscriptions())})
+	}
+	return replies, nil
+}
+
+func (h *Handler) handlePSubscribe(c *Client, args resp.Array) (resp.Payload, error) {
+	patterns, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	c.queueWrites()
+	replies := make(multiReply, 0, len(patterns))
+	for _, pattern := range patterns {
+		if h.pubsub.PSubscribe(c, pattern) {
+			c.patterns[pattern] = struct{}{}
+		}
+		replies = append(replies, resp.Array{psubscribeKind, resp.BulkString(pattern), resp.Integer(c.subscriptions())})
+	}
+	return replies, nil
+}
+
+func (h *Handler) handleUnsubscribe(c *Client, args resp.Array) (resp.Payload, error) {
+	channels, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	// without arguments, the client is unsubscribed from every channel
+	if len(channels) == 0 {
+		channels = sortedKeys(c.channels)
+	}
+	if len(channels) == 0 {
+		return resp.Array{unsubscribeKind, resp.NULL, resp.Integer(c.subscriptions())}, nil
+	}
+
+	replies := make(multiReply, 0, len(channels))
+	for _, channel := range channels {
+		h.pubsub.Unsubscribe(c, channel)
+		delete(c.channels, channel)
+		replies = append(replies, resp.Array{unsubscribeKind, resp.BulkString(channel), resp.Integer(c.subscriptions())})
+	}
+	return replies, nil
+}
+
+func (h *Handler) handlePUnsubscribe(c *Client, args resp.Array) (resp.Payload, error) {
+	patterns, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	// without arguments, the client is unsubscribed from every pattern
+	if len(patterns) == 0 {
+		patterns = sortedKeys(c.patterns)
+	}
+	if len(patterns) == 0 {
+		return resp.Array{punsubscribeKind, resp.NULL, resp.Integer(c.subscriptions())}, nil
+	}
+
+	replies := make(multiReply, 0, len(patterns))
+	for _, pattern := range patterns {
+		h.pubsub.PUnsubscribe(c, pattern)
+		delete(c.patterns, pattern)
+		replies = append(replies, resp.Array{punsubscribeKind, resp.BulkString(pattern), resp.Integer(c.subscriptions())})
+	}
+	return replies, nil
+}
+
+func (h *Handler) handlePublish(_ *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Integer(h.pubsub.Publish(strs[0], strs[1])), nil
+}
+
+// unsubscribeAll removes the subscriptions of a disconnecting client.
+func (h *Handler) unsubscribeAll(c *Client) {
+	for channel := range c.channels {
+		h.pubsub.Unsubscribe(c, channel)
+	}
+	for pattern := range c.patterns {
+		h.pubsub.PUnsubscribe(c, pattern)
+	}
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/replication"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/rdb"
+)
+
+// states of the link with the master
+const (
+	linkConnect int32 = iota
+	linkConnecting
+	linkSync
+	linkConnected
+)
+
+// linkStates names the states of the link as ROLE reports them.
+var linkStates = [...]string{
+	linkConnect:    "connect",
+	linkConnecting: "connecting",
+	linkSync:       "sync",
+	linkConnected:  "connected",
+}
+
+const (
+	replicaAckInterval = time.Second
+	reconnectDelay     = time.Second
+	dialTimeout        = 5 * time.Second
+)
+
+// masterLink replicates a master from its own goroutine, reconnecting until it is stopped.
+type masterLink struct {
+	host string
+	port int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// closed once the goroutine of the link has returned
+	done chan struct{}
+
+	state atomic.Int32
+	// time of the last read from the master, in nanoseconds since the epoch
+	lastIO atomic.Int64
+
+	// database selected by the stream of the master, kept across reconnections since partial synchronizations resume the stream.
+	// only accessed from the goroutine of the link
+	db int
+}
+
+func newMasterLink(host string, port int) *masterLink {
+	ctx, cancel := context.WithCancel(context.Background())
+	link := &masterLink{
+		host:   host,
+		port:   port,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	link.state.Store(linkConnect)
+	return link
+}
+
+func (l *masterLink) addr() string {
+	return net.JoinHostPort(l.host, strconv.Itoa(l.port))
+}
+
+// stop ends the link, closing its connection.
+func (l *masterLink) stop() {
+	l.cancel()
+}
+
+// replicate synchronizes with the master of link and applies its stream, reconnecting after failures.
+func (h *Handler) replicate(link *masterLink) {
+	defer close(link.done)
+	for {
+		err := h.syncWithMaster(link)
+		if link.ctx.Err() != nil {
+			return
+		}
+		h.logger.Warn("replication link failed", "master", link.addr(), "error", err)
+		link.state.Store(linkConnect)
+
+		timer := time.NewTimer(reconnectDelay)
+		select {
+		case <-timer.C:
+		case <-link.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// syncWithMaster performs the handshake of Redis replicas, PING, REPLCONF and PSYNC, then applies the stream until the connection ends.
+func (h *Handler) syncWithMaster(link *masterLink) error {
+	link.state.Store(linkConnecting)
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(link.ctx, "tcp", link.addr())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stopClosing := context.AfterFunc(link.ctx, func() { _ = conn.Close() })
+	defer stopClosing()
+
+	br := bufio.NewReader(conn)
+	// PING checks that the master answers before anything else is sent
+	pong, err := peerCommand(conn, br, "PING")
+	if err != nil {
+		return err
+	}
+	if pong != string(resp.PONG) {
+		return fmt.Errorf("unexpected PING reply '%s'", pong)
+	}
+	if _, err := peerCommand(conn, br, "REPLCONF", "listening-port", strconv.Itoa(h.config.Config().Port)); err != nil {
+		return err
+	}
+	if _, err := peerCommand(conn, br, "REPLCONF", "capa", "psync2"); err != nil {
+		return err
+	}
+
+	h.repl.mu.Lock()
+	replID, offset := "?", int64(-1)
+	if h.repl.backlog != nil {
+		replID, offset = h.repl.backlog.ReplID(), h.repl.backlog.Offset()+1
+	}
+	h.repl.mu.Unlock()
+
+	link.state.Store(linkSync)
+	reply, err := peerCommand(conn, br, "PSYNC", replID, strconv.FormatInt(offset, 10))
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(reply)
+	switch {
+	case len(fields) == 3 && fields[0] == "FULLRESYNC":
+		offset, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid FULLRESYNC reply '%s'", reply)
+		}
+		if err := h.fullSync(link, br, fields[1], offset); err != nil {
+			return err
+		}
+		link.db = 0
+	case len(fields) >= 1 && fields[0] == "CONTINUE":
+		// the master may have been promoted since, it then continues its history under a new ID
+		if len(fields) == 2 && fields[1] != replID {
+			if err := h.resetBacklog(link, fields[1], offset-1); err != nil {
+				return err
+			}
+		}
+		h.logger.Info("partial synchronization with master", "master", link.addr(), "offset", offset-1)
+	default:
+		return fmt.Errorf("unexpected PSYNC reply '%s'", reply)
+	}
+
+	link.lastIO.Store(time.Now().UnixNano())
+	link.state.Store(linkConnected)
+	return h.applyStream(link, conn, br)
+}
+
+// peerCommand sends a command to another server and returns the first line of its reply, without the type prefix.
+func peerCommand(conn net.Conn, br *bufio.Reader, args ...string) (string, error) {
+	command := make(resp.Array, len(args))
+	for i, arg := range args {
+		command[i] = resp.BulkString(arg)
+	}
+	if _, err := conn.Write(encode(command)); err != nil {
+		return "", err
+	}
+
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty reply")
+	}
+	if line[0] == '-' {
+		return "", fmt.Errorf("%s failed: %s", args[0], line[1:])
+	}
+	return line[1:], nil
+}
+
+// fullSync reads the snapshot following FULLRESYNC, replaces the dataset with it and starts the history replID at offset.
+func (h *Handler) fullSync(link *masterLink, br *bufio.Reader, replID string, offset int64) error {
+	var line string
+	// masters may send newlines to keep the connection alive while they produce the snapshot
+	for line == "" {
+		var err error
+		if line, err = br.ReadString('\n'); err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+	}
+	if line[0] != '$' {
+		return fmt.Errorf("unexpected snapshot header '%s'", line)
+	}
+	size, err := strconv.Atoi(line[1:])
+	if err != nil || size < 0 {
+		return fmt.Errorf("invalid snapshot size '%s'", line[1:])
+	}
+
+	snapshot := make([]byte, size)
+	if _, err := io.ReadFull(br, snapshot); err != nil {
+		return err
+	}
+	link.lastIO.Store(time.Now().UnixNano())
+
+	// no command runs while the dataset is replaced, nor can the link be stopped
+	h.execLock.Lock()
+	defer h.execLock.Unlock()
+
+	if !h.isCurrentLink(link) {
+		return link.ctx.Err()
+	}
+	if err := h.loadSnapshot(snapshot); err != nil {
+		return err
+	}
+	h.logger.Info("full synchronization with master done", "master", link.addr(), "bytes", size)
+	return h.resetBacklog(link, replID, offset)
+}
+
+// loadSnapshot replaces the dataset with the one of a snapshot.
+func (h *Handler) loadSnapshot(snapshot []byte) error {
+	for _, db := range h.dbs.all() {
+		db.Flush()
+	}
+	return rdb.Load(bytes.NewReader(snapshot), func(db int, key string, entry store.Entry) error {
+		if db >= h.dbs.len() {
+			return fmt.Errorf("snapshot holds database %d, only %d are configured", db, h.dbs.len())
+		}
+		h.dbs.get(db).Set(resp.SetArgs{Key: resp.BulkString(key), Value: entry.Value, ExpireAt: entry.ExpireAt})
+		return nil
+	})
+}
+
+func (h *Handler) isCurrentLink(link *masterLink) bool {
+	h.repl.mu.Lock()
+	defer h.repl.mu.Unlock()
+
+	return h.repl.link == link
+}
+
+// resetBacklog starts the history replID at offset, the replicas fed so far having to synchronize again.
+func (h *Handler) resetBacklog(link *masterLink, replID string, offset int64) error {
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.link != link {
+		return link.ctx.Err()
+	}
+	if r.backlog == nil {
+		r.backlog = replication.NewBacklog(int(h.config.Config().ReplBacklogSize), replID, offset)
+		return nil
+	}
+	r.backlog.Reset(replID, offset)
+	return nil
+}
+
+// applyStream runs the commands the master propagates, acknowledging the offset reached every second.
+func (h *Handler) applyStream(link *masterLink, conn net.Conn, br *bufio.Reader) error {
+	mc := newMasterClient(conn, br, link.db)
+
+	acks := time.NewTicker(replicaAckInterval)
+	defer acks.Stop()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-acks.C:
+				h.repl.mu.Lock()
+				_, offset := h.repl.position()
+				h.repl.mu.Unlock()
+				_ = mc.write(resp.Array{resp.REPLCONF, resp.ACK, resp.BulkString(strconv.FormatInt(offset, 10))})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		value, err := mc.parser.Parse()
+		if err != nil {
+			return err
+		}
+		link.lastIO.Store(time.Now().UnixNano())
+
+		args, ok := value.(resp.Array)
+		if !ok || len(args) == 0 {
+			return fmt.Errorf("unexpected %T in the stream of the master", value)
+		}
+		if _, err := h.dispatch(mc, args); err != nil {
+			h.logger.Warn("command from master failed", "command", args, "error", err)
+		}
+		link.db = mc.db
+
+		if !h.appendFromMaster(link, encode(args)) {
+			return link.ctx.Err()
+		}
+	}
+}
+
+// appendFromMaster feeds the stream of the master to the backlog, it reports false once link is not the current one.
+func (h *Handler) appendFromMaster(link *masterLink, p []byte) bool {
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.link != link {
+		return false
+	}
+	r.backlog.Append(p)
+	return true
+}
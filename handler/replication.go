@@ -0,0 +1,429 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/replication"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store/rdb"
+)
+
+var (
+	errReadOnlyReplica = resp.NewPrefixedError("READONLY", "You can't write against a read only replica.")
+	errNoMasterLink    = resp.NewPrefixedError("NOMASTERLINK", "Can't SYNC while not connected with my master")
+)
+
+// noReply is returned by the commands that do not reply, such as REPLCONF ACK.
+var noReply = multiReply{}
+
+// rawReply is written as is, as the snapshot of a full synchronization which is a bulk string without the final CRLF.
+type rawReply []byte
+
+func (r rawReply) AppendRESP(dst []byte) ([]byte, error) {
+	return append(dst, r...), nil
+}
+
+// replicationState tracks the role of the server, the replicas it feeds and the master it replicates.
+//
+// a master propagates the writes of its clients to the backlog once a replica synchronized,
+// replicas read the backlog from their offset in the goroutine of their connection.
+// a replica appends the stream of its master to its own backlog, so that it can feed replicas in turn.
+// expirations are not propagated, replicas expire keys on their own from the same deadlines.
+type replicationState struct {
+	mu sync.Mutex
+	// identifies the history of the dataset until a backlog exists
+	replID string
+	// created when the first replica synchronizes, or when synchronizing with a master
+	backlog *replication.Backlog
+	// database of the last propagated command, -1 forcing a SELECT before the next one
+	selected int
+	// replicas in the order they synchronized
+	replicas []*replicaConn
+	// the master replicated, nil while the server is a master
+	link *masterLink
+
+	// whether writes are propagated, which is the case of a master with a backlog
+	propagating atomic.Bool
+	// whether the server replicates a master
+	replica atomic.Bool
+}
+
+// replicaConn is a replica fed by this server.
+type replicaConn struct {
+	client *Client
+	// port the replica listens on, as told by REPLCONF listening-port
+	port int
+	// offset the replica acknowledged with REPLCONF ACK
+	ackOffset atomic.Int64
+	// time of the last acknowledgment, in nanoseconds since the epoch
+	lastAck atomic.Int64
+	// closed when the connection of the replica ends
+	done chan struct{}
+}
+
+func newReplicationState() *replicationState {
+	return &replicationState{
+		replID:   replication.NewReplID(),
+		selected: -1,
+	}
+}
+
+// position returns the replication ID and the offset of the dataset.
+func (r *replicationState) position() (string, int64) {
+	if r.backlog == nil {
+		return r.replID, 0
+	}
+	return r.backlog.ReplID(), r.backlog.Offset()
+}
+
+// encode returns the RESP encoding of p, which is made of payloads only.
+func encode(p resp.Payload) []byte {
+	b, err := resp.Encode(p)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// isReadOnlyReplica reports whether the writes of clients are rejected.
+func (h *Handler) isReadOnlyReplica() bool {
+	return h.repl.replica.Load() && h.config.Config().ReplicaReadOnly
+}
+
+// propagate appends a write run on database db to the replication stream, it must run under the exclusive execution lock.
+func (h *Handler) propagate(db int, args resp.Array) {
+	if !h.repl.propagating.Load() {
+		return
+	}
+
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.backlog == nil {
+		return
+	}
+	if db != r.selected {
+		r.backlog.Append(encode(resp.Array{resp.SELECT, resp.BulkString(strconv.Itoa(db))}))
+		r.selected = db
+	}
+	r.backlog.Append(encode(args))
+}
+
+// handlePSync synchronizes a replica, it runs exclusively so that the snapshot matches the offset of the backlog.
+// the replica resumes from its offset when the backlog still holds what it missed, it is sent a snapshot otherwise.
+func (h *Handler) handlePSync(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParsePSyncArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.link != nil && r.link.state.Load() != linkConnected {
+		return nil, errNoMasterLink
+	}
+	if r.backlog == nil {
+		r.backlog = replication.NewBacklog(int(h.config.Config().ReplBacklogSize), r.replID, 0)
+		r.selected = -1
+		r.propagating.Store(r.link == nil)
+	}
+	replID := r.backlog.ReplID()
+
+	// replicas send the offset of the next byte they need, counting from 1 like Redis
+	offset := parsed.Offset - 1
+	var header []byte
+	if _, _, err := r.backlog.ReadFrom(parsed.ReplID, offset); err == nil {
+		h.stats.SyncPartialOK.Add(1)
+		header = fmt.Appendf(nil, "+CONTINUE %s\r\n", replID)
+	} else {
+		h.stats.SyncFull.Add(1)
+		// "?" asks for a full synchronization, any other history is a partial one that could not be served
+		if parsed.ReplID != "?" {
+			h.stats.SyncPartialErr.Add(1)
+		}
+		var snapshot bytes.Buffer
+		if err := rdb.Save(&snapshot, h.dbs.all(), h.clock); err != nil {
+			return nil, err
+		}
+		offset = r.backlog.Offset()
+		// the replica starts on database 0
+		r.selected = -1
+		header = fmt.Appendf(nil, "+FULLRESYNC %s %d\r\n$%d\r\n", replID, offset, snapshot.Len())
+		header = append(header, snapshot.Bytes()...)
+	}
+
+	rc := &replicaConn{
+		client: c,
+		port:   c.listeningPort,
+		done:   make(chan struct{}),
+	}
+	rc.ackOffset.Store(offset)
+	rc.lastAck.Store(time.Now().UnixNano())
+	c.replica = rc
+	r.replicas = append(r.replicas, rc)
+	h.logger.Info("replica synchronizing", "remote_addr", c.RemoteAddr(), "partial", header[1] == 'C', "offset", offset)
+
+	go h.feedReplica(rc, r.backlog, replID, offset, header)
+	return noReply, nil
+}
+
+// feedReplica writes header then the stream from offset to a replica, until it disconnects.
+// a replica that falls behind what the backlog holds, or whose history was replaced, is disconnected to synchronize again.
+func (h *Handler) feedReplica(rc *replicaConn, backlog *replication.Backlog, replID string, offset int64, header []byte) {
+	if err := rc.client.write(rawReply(header)); err != nil {
+		return
+	}
+	for {
+		p, changed, err := backlog.ReadFrom(replID, offset)
+		if err != nil {
+			h.logger.Warn("disconnecting replica", "remote_addr", rc.client.RemoteAddr(), "error", err)
+			_ = rc.client.conn.Close()
+			return
+		}
+		if len(p) > 0 {
+			if err := rc.client.write(rawReply(p)); err != nil {
+				return
+			}
+			offset += int64(len(p))
+		}
+
+		select {
+		case <-changed:
+		case <-rc.done:
+			return
+		}
+	}
+}
+
+// dropReplica forgets the replica of a connection that ended.
+func (h *Handler) dropReplica(c *Client) {
+	if c.replica == nil {
+		return
+	}
+	close(c.replica.done)
+
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replicas = slices.DeleteFunc(r.replicas, func(rc *replicaConn) bool { return rc == c.replica })
+}
+
+func (h *Handler) handleReplConf(c *Client, args resp.Array) (resp.Payload, error) {
+	if len(args)%2 == 0 {
+		return nil, errSyntax
+	}
+
+	for i := 1; i < len(args); i += 2 {
+		option, ok := args[i].(resp.BulkString)
+		if !ok {
+			return nil, errSyntax
+		}
+		switch option.Upper() {
+		case resp.LISTENINGPORT:
+			port, err := resp.ParseInteger(args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			c.listeningPort = int(port)
+		case resp.CAPA:
+			// the capabilities of replicas are not needed, streams are always sent with psync2 semantics
+		case resp.ACK:
+			offset, err := resp.ParseInteger(args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if c.replica != nil {
+				c.replica.ackOffset.Store(offset)
+				c.replica.lastAck.Store(time.Now().UnixNano())
+			}
+			return noReply, nil
+		case resp.GETACK:
+			if !c.master {
+				return resp.OK, nil
+			}
+			h.repl.mu.Lock()
+			_, offset := h.repl.position()
+			h.repl.mu.Unlock()
+			// the master link does not write replies, acknowledgements are the only ones a master expects
+			_ = c.write(resp.Array{resp.REPLCONF, resp.ACK, resp.BulkString(strconv.FormatInt(offset, 10))})
+			return noReply, nil
+		default:
+			return nil, resp.NewSimpleError(fmt.Sprintf("Unrecognized REPLCONF option: %s", args[i]))
+		}
+	}
+	return resp.OK, nil
+}
+
+func (h *Handler) handleReplicaOf(_ *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseReplicaOfArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.NoOne {
+		h.promote()
+		return resp.OK, nil
+	}
+	if !h.replicaOf(parsed.Host, parsed.Port) {
+		return resp.SimpleString("OK Already connected to specified master"), nil
+	}
+	return resp.OK, nil
+}
+
+// replicaOf starts replicating the master at host:port, it reports false when it is the master replicated already.
+func (h *Handler) replicaOf(host string, port int) bool {
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.link != nil {
+		if r.link.host == host && r.link.port == port {
+			return false
+		}
+		r.link.stop()
+	}
+
+	r.link = newMasterLink(host, port)
+	r.propagating.Store(false)
+	r.replica.Store(true)
+	h.logger.Info("replicating master", "master", r.link.addr())
+	go h.replicate(r.link)
+	return true
+}
+
+// stopReplication stops replicating the master, waiting for the link to stop applying its stream.
+func (h *Handler) stopReplication() {
+	r := h.repl
+	r.mu.Lock()
+	link := r.link
+	r.mu.Unlock()
+
+	if link == nil {
+		return
+	}
+	link.stop()
+	<-link.done
+}
+
+// promote turns a replica into a master, with a new history as its dataset now diverges from the one of its master.
+func (h *Handler) promote() {
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.link == nil {
+		return
+	}
+	r.link.stop()
+	r.link = nil
+	r.replica.Store(false)
+
+	if r.backlog == nil {
+		r.replID = replication.NewReplID()
+		return
+	}
+	r.backlog.Reset(replication.NewReplID(), r.backlog.Offset())
+	r.selected = -1
+	r.propagating.Store(true)
+	h.logger.Info("promoted to master")
+}
+
+func (h *Handler) handleRole(_ *Client, _ resp.Array) (resp.Payload, error) {
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, offset := r.position()
+	if r.link != nil {
+		if r.backlog == nil {
+			offset = -1
+		}
+		return resp.Array{
+			resp.BulkString("slave"),
+			resp.BulkString(r.link.host),
+			resp.Integer(r.link.port),
+			resp.BulkString(linkStates[r.link.state.Load()]),
+			resp.Integer(offset),
+		}, nil
+	}
+
+	replicas := make(resp.Array, len(r.replicas))
+	for i, rc := range r.replicas {
+		replicas[i] = resp.Array{
+			resp.BulkString(rc.ip()),
+			resp.BulkString(strconv.Itoa(rc.port)),
+			resp.BulkString(strconv.FormatInt(rc.ackOffset.Load(), 10)),
+		}
+	}
+	return resp.Array{resp.BulkString("master"), resp.Integer(offset), replicas}, nil
+}
+
+// ip returns the address of the replica without its port.
+func (rc *replicaConn) ip() string {
+	host, _, err := net.SplitHostPort(rc.client.RemoteAddr())
+	if err != nil {
+		return rc.client.RemoteAddr()
+	}
+	return host
+}
+
+func (h *Handler) infoReplication(w *infoWriter) {
+	r := h.repl
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replID, offset := r.position()
+	if r.link == nil {
+		w.field("role", "master")
+	} else {
+		state := r.link.state.Load()
+		w.field("role", "slave")
+		w.field("master_host", r.link.host)
+		w.field("master_port", r.link.port)
+		linkStatus, lastIO := "down", int64(-1)
+		if state == linkConnected {
+			linkStatus = "up"
+			lastIO = int64(time.Since(time.Unix(0, r.link.lastIO.Load())) / time.Second)
+		}
+		w.field("master_link_status", linkStatus)
+		w.field("master_last_io_seconds_ago", lastIO)
+		w.field("master_sync_in_progress", boolToInt(state == linkSync))
+		w.field("slave_repl_offset", offset)
+		w.field("slave_read_only", boolToInt(h.config.Config().ReplicaReadOnly))
+	}
+
+	w.field("connected_slaves", len(r.replicas))
+	for i, rc := range r.replicas {
+		lag := int64(time.Since(time.Unix(0, rc.lastAck.Load())) / time.Second)
+		w.field("slave"+strconv.Itoa(i), fmt.Sprintf("ip=%s,port=%d,state=online,offset=%d,lag=%d", rc.ip(), rc.port, rc.ackOffset.Load(), lag))
+	}
+	w.field("master_replid", replID)
+	w.field("master_repl_offset", offset)
+
+	var first, histLen int64
+	if r.backlog != nil {
+		first, histLen = r.backlog.History()
+	}
+	w.field("repl_backlog_active", boolToInt(r.backlog != nil))
+	w.field("repl_backlog_size", int64(h.config.Config().ReplBacklogSize))
+	w.field("repl_backlog_first_byte_offset", first+1)
+	w.field("repl_backlog_histlen", histLen)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+	"github.com/PlayerNeo42/gvalkey/store/rdb"
+)
+
+const (
+	// saveCheckInterval is how often the save rules are checked, as often as the serverCron of Redis runs by default
+	saveCheckInterval = 100 * time.Millisecond
+	// saveRetryDelay is how long a failed snapshot waits before the next attempt, like Redis
+	saveRetryDelay = 5 * time.Second
+)
+
+// snapshotState saves the dataset to the snapshot file following the save rules.
+type snapshotState struct {
+	rules atomic.Pointer[[]config.SaveRule]
+	// write commands since the last snapshot
+	dirty atomic.Int64
+	// time of the last snapshot, or of the start of the server, and of the last failed attempt, in nanoseconds since the epoch
+	lastSave    atomic.Int64
+	lastFailure atomic.Int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newSnapshotState(now time.Time) *snapshotState {
+	s := &snapshotState{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	s.lastSave.Store(now.UnixNano())
+	return s
+}
+
+// loadSaveRules caches the rules of the save setting, the next check applying them.
+func (h *Handler) loadSaveRules(c *config.Config) {
+	// the setting is validated by the configuration, so parsing cannot fail
+	rules, _ := config.ParseSaveRules(c.Save)
+	h.snapshots.rules.Store(&rules)
+}
+
+// saveLoop checks the save rules until the handler is closed, saving the dataset when one of them is met.
+func (h *Handler) saveLoop() {
+	defer close(h.snapshots.done)
+	ticker := time.NewTicker(saveCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.snapshots.stop:
+			return
+		case <-ticker.C:
+		}
+		if !h.saveDue(h.clock.Now()) {
+			continue
+		}
+		if err := h.save(); err != nil {
+			h.snapshots.lastFailure.Store(h.clock.Now().UnixNano())
+			h.logger.Error("saving the snapshot failed", "error", err)
+		}
+	}
+}
+
+// saveDue reports whether a save rule is met at now, and a failed attempt is not too recent to try again.
+func (h *Handler) saveDue(now time.Time) bool {
+	s := h.snapshots
+	dirty := s.dirty.Load()
+	if dirty == 0 || now.Sub(time.Unix(0, s.lastFailure.Load())) < saveRetryDelay {
+		return false
+	}
+	elapsed := now.Sub(time.Unix(0, s.lastSave.Load()))
+	for _, rule := range *s.rules.Load() {
+		if dirty >= int64(rule.Changes) && elapsed >= time.Duration(rule.Seconds)*time.Second {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the dataset to the snapshot file, through a temporary file renamed over it so that it is never left half written.
+func (h *Handler) save() error {
+	var snapshot bytes.Buffer
+	// the dataset is encoded exclusively of every command so that the snapshot is consistent
+	h.execLock.Lock()
+	dirty := h.snapshots.dirty.Load()
+	err := rdb.Save(&snapshot, h.dbs.all(), h.clock)
+	h.execLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	cfg := h.config.Config()
+	tmp, err := os.CreateTemp(cfg.Dir, "temp-*.rdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(snapshot.Bytes()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(cfg.Dir, cfg.DBFilename)); err != nil {
+		return err
+	}
+
+	h.snapshots.dirty.Add(-dirty)
+	h.snapshots.lastSave.Store(h.clock.Now().UnixNano())
+	h.logger.Info("snapshot saved", "changes", dirty)
+	return nil
+}
+
+// stopSaving stops the checks of the save rules, a snapshot being written is completed.
+func (h *Handler) stopSaving() {
+	close(h.snapshots.stop)
+	<-h.snapshots.done
+}
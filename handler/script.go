@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/script"
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+var (
+	errBusyScript   = resp.NewPrefixedError("BUSY", "Redis is busy running a script. You can only call SCRIPT KILL.")
+	errNotBusy      = resp.NewPrefixedError("NOTBUSY", "No scripts in execution right now.")
+	errUnkillable   = resp.NewPrefixedError("UNKILLABLE", "Sorry the script already executed write commands against the dataset. You can either wait the script termination or kill the server in a hard way.")
+	errScriptKilled = resp.NewSimpleError("Script killed by user with SCRIPT KILL...")
+	errScriptClient = errors.New("script client disconnected")
+)
+
+// runningScript is a script being executed.
+type runningScript struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	client *Client
+	timer  *time.Timer
+	done   chan struct{}
+
+	// the fields below are guarded by the lock of scriptState.
+	// once the script has written, stopping it would break its atomicity, it can no longer be killed
+	wrote bool
+	// stops watching the connection of the client, set once the script is busy
+	stopWatching func()
+}
+
+// scriptState tracks the script being executed, which turns busy once it has run for longer than lua-time-limit:
+// other clients then get BUSY errors rather than waiting for it, and it can be killed until it writes.
+type scriptState struct {
+	mu      sync.Mutex
+	running *runningScript
+	busy    bool
+	// closed and replaced each time a script turns busy, waking up the clients waiting for the execution lock
+	busyChanged chan struct{}
+}
+
+func newScriptState() *scriptState {
+	return &scriptState{busyChanged: make(chan struct{})}
+}
+
+// busyState reports whether a busy script is running, and returns a channel closed once a script turns busy.
+func (s *scriptState) busyState() (bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.busy, s.busyChanged
+}
+
+// kill stops run, or the running script when run is nil, with cause unless it has written.
+func (s *scriptState) kill(run *runningScript, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running == nil || (run != nil && s.running != run) {
+		return errNotBusy
+	}
+	if s.running.wrote {
+		return errUnkillable
+	}
+	s.running.cancel(cause)
+	return nil
+}
+
+// markWrite records that run is about to write, failing when it was killed in the meantime.
+func (s *scriptState) markWrite(run *runningScript) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if run.ctx.Err() != nil {
+		return context.Cause(run.ctx)
+	}
+	run.wrote = true
+	return nil
+}
+
+// startScript records that c runs a script, endScript must be called once it returns.
+func (h *Handler) startScript(c *Client) *runningScript {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	run := &runningScript{ctx: ctx, cancel: cancel, client: c, done: make(chan struct{})}
+
+	h.running.mu.Lock()
+	h.running.running = run
+	h.running.mu.Unlock()
+
+	if limit := h.config.Config().LuaTimeLimit; limit > 0 {
+		run.timer = time.AfterFunc(time.Duration(limit)*time.Millisecond, func() { h.scriptBusy(run) })
+	}
+	return run
+}
+
+// scriptBusy is called once run has been running for lua-time-limit.
+func (h *Handler) scriptBusy(run *runningScript) {
+	s := h.running
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running != run {
+		return
+	}
+	h.logger.Warn("script running for longer than lua-time-limit, other clients get BUSY errors", "remote_addr", run.client.RemoteAddr())
+	s.busy = true
+	close(s.busyChanged)
+	s.busyChanged = make(chan struct{})
+
+	// the script of a client going away is stopped, unless it has written. the master link is never watched
+	if run.client.master {
+		return
+	}
+	disconnected, stop := run.client.watchDisconnect()
+	run.stopWatching = stop
+	go func() {
+		select {
+		case <-disconnected:
+			if s.kill(run, errScriptClient) == nil {
+				h.logger.Warn("stopped the script of a disconnected client", "remote_addr", run.client.RemoteAddr())
+			}
+		case <-run.done:
+		}
+	}()
+}
+
+// endScript records that run returned.
+func (h *Handler) endScript(run *runningScript) {
+	if run.timer != nil {
+		run.timer.Stop()
+	}
+
+	s := h.running
+	s.mu.Lock()
+	s.running, s.busy = nil, false
+	stop := run.stopWatching
+	s.mu.Unlock()
+
+	close(run.done)
+	if stop != nil {
+		stop()
+	}
+	run.cancel(nil)
+}
+
+// isScriptKill reports whether the command is SCRIPT KILL, which runs without the execution lock.
+func isScriptKill(cmd *Command, args resp.Array) bool {
+	if cmd.Name != resp.SCRIPT {
+		return false
+	}
+	subcommand, err := resp.ParseSubcommand(args)
+	return err == nil && subcommand == resp.KILL
+}
+
+func (h *Handler) handleEval(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.eval(c, args, false, false)
+}
+
+func (h *Handler) handleEvalRO(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.eval(c, args, false, true)
+}
+
+func (h *Handler) handleEvalSHA(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.eval(c, args, true, false)
+}
+
+func (h *Handler) handleEvalSHARO(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.eval(c, args, true, true)
+}
+
+// eval runs a script given by its body or, when bySHA is true, by its digest.
+// the script holds the exclusive lock taken by dispatch, no other command runs until it returns or is killed.
+func (h *Handler) eval(c *Client, args resp.Array, bySHA, readOnly bool) (resp.Payload, error) {
+	parsed, err := resp.ParseEvalArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	run := h.startScript(c)
+	defer h.endScript(run)
+
+	caller := h.scriptCaller(run, c.scriptClient(readOnly))
+	if bySHA {
+		return h.scripts.EvalSHA(run.ctx, parsed.Script, parsed.Keys, parsed.Args, caller)
+	}
+	return h.scripts.Eval(run.ctx, parsed.Script, parsed.Keys, parsed.Args, caller)
+}
+
+// scriptCaller runs the redis.call commands of the script run as client sc.
+func (h *Handler) scriptCaller(run *runningScript, sc *Client) script.Caller {
+	return func(args []string) resp.Payload {
+		// read-only scripts are not allowed to write, dispatch rejects their writes
+		if cmd, ok := h.commandTable.Get(resp.BulkString(args[0])); ok && cmd.Has(FlagWrite) && !sc.readOnly {
+			if err := h.running.markWrite(run); err != nil {
+				return errorReply(err)
+			}
+		}
+
+		command := make(resp.Array, len(args))
+		for i, arg := range args {
+			command[i] = resp.BulkString(arg)
+		}
+		payload, err := h.dispatch(sc, command)
+		if err != nil {
+			return errorReply(err)
+		}
+		return payload
+	}
+}
+
+func (h *Handler) handleScript(c *Client, args resp.Array) (resp.Payload, error) {
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch subcommand {
+	case resp.LOAD:
+		if len(args) != 3 {
+			return nil, fmt.Errorf("wrong number of arguments for 'script|%s' command", subcommand)
+		}
+		body, err := resp.ParseStrings(args[2:])
+		if err != nil {
+			return nil, err
+		}
+		sha, err := h.scripts.Load(body[0])
+		if err != nil {
+			return nil, err
+		}
+		return resp.BulkString(sha), nil
+	case resp.EXISTS:
+		if len(args) < 3 {
+			return nil, fmt.Errorf("wrong number of arguments for 'script|%s' command", subcommand)
+		}
+		shas, err := resp.ParseStrings(args[2:])
+		if err != nil {
+			return nil, err
+		}
+		result := make(resp.Array, len(shas))
+		for i, sha := range shas {
+			result[i] = resp.Integer(0)
+			if h.scripts.Exists(sha) {
+				result[i] = resp.Integer(1)
+			}
+		}
+		return result, nil
+	case resp.FLUSH:
+		// ASYNC is accepted but the cache is always dropped right away, along with the Lua state
+		if _, err := resp.ParseFlushArgs(args[1:]); err != nil {
+			return nil, err
+		}
+		h.scripts.Flush()
+		return resp.OK, nil
+	case resp.KILL:
+		// run by dispatch without the execution lock, which the script holds
+		if len(args) != 2 {
+			return nil, fmt.Errorf("wrong number of arguments for 'script|%s' command", subcommand)
+		}
+		if err := h.running.kill(nil, errScriptKilled); err != nil {
+			return nil, err
+		}
+		h.logger.Warn("script killed with SCRIPT KILL", "remote_addr", c.RemoteAddr())
+		return resp.OK, nil
+	default:
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+}
@@ -1,16 +1,26 @@
 package handler
 
 import (
+	"strconv"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
 	"github.com/PlayerNeo42/gvalkey/resp"
 )
 
-func (h *Handler) handleSet(args resp.Array) (resp.Payload, error) {
-	parsedArgs, err := resp.ParseSetArgs(args)
+func (h *Handler) handleSet(c *Client, args resp.Array) (resp.Payload, error) {
+	parsedArgs, err := resp.ParseSetArgs(args, h.clock)
 	if err != nil {
 		return nil, err
 	}
 
-	oldValue, success := h.store.Set(*parsedArgs)
+	if !parsedArgs.ExpireAt.IsZero() {
+		c.args = setAt(parsedArgs)
+	}
+
+	oldValue, success := h.db(c).Set(*parsedArgs)
+	if success {
+		h.notifyKeyspaceEvent(pubsub.ClassString, "set", parsedArgs.Key.String(), c.db)
+	}
 
 	// handle the GET option: return the old value or NULL.
 	if parsedArgs.Get {
@@ -36,3 +46,15 @@ func (h *Handler) handleSet(args resp.Array) (resp.Payload, error) {
 	// otherwise, the SET was successful.
 	return resp.OK, nil
 }
+
+// setAt rewrites a SET for replicas with the deadline of its expiration, which a relative one would push back.
+func setAt(parsed *resp.SetArgs) resp.Array {
+	args := resp.Array{resp.SET, parsed.Key, parsed.Value, resp.PXAT, resp.BulkString(strconv.FormatInt(parsed.ExpireAt.UnixMilli(), 10))}
+	if parsed.NX {
+		args = append(args, resp.NX)
+	}
+	if parsed.XX {
+		args = append(args, resp.XX)
+	}
+	return args
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+	"github.com/PlayerNeo42/gvalkey/internal/slowlog"
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// logSlow records the command of c in the slow log when it ran for at least slowlog-log-slower-than microseconds.
+// the commands of scripts are left out, the script being recorded as a whole.
+func (h *Handler) logSlow(c *Client, args resp.Array, start time.Time, elapsed time.Duration) {
+	threshold := h.config.Config().SlowlogLogSlowerThan
+	if threshold < 0 || c.script || elapsed < time.Duration(threshold)*time.Microsecond {
+		return
+	}
+
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		if str, ok := arg.(resp.BulkString); ok {
+			strs[i] = string(str)
+		}
+	}
+	h.slowlog.Add(slowlog.Entry{Time: start, Duration: elapsed, Args: strs, ClientAddr: c.conn.RemoteAddr().String(), ClientName: c.name})
+}
+
+func (h *Handler) resizeSlowlog(cfg *config.Config) {
+	h.slowlog.SetMaxLen(cfg.SlowlogMaxLen)
+}
+
+func (h *Handler) handleSlowlog(c *Client, args resp.Array) (resp.Payload, error) {
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch subcommand {
+	case resp.GET:
+		if len(args) > 3 {
+			return nil, fmt.Errorf("wrong number of arguments for 'slowlog|%s' command", subcommand)
+		}
+		count, err := resp.ParseSlowlogGetArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		entries := h.slowlog.Entries(count)
+		result := make(resp.Array, len(entries))
+		for i, e := range entries {
+			cmd := make(resp.Array, len(e.Args))
+			for j, arg := range e.Args {
+				cmd[j] = resp.BulkString(arg)
+			}
+			result[i] = resp.Array{
+				resp.Integer(e.ID),
+				resp.Integer(e.Time.Unix()),
+				resp.Integer(e.Duration.Microseconds()),
+				cmd,
+				resp.BulkString(e.ClientAddr),
+				resp.BulkString(e.ClientName),
+			}
+		}
+		return result, nil
+	case resp.LEN, resp.RESET:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("wrong number of arguments for 'slowlog|%s' command", subcommand)
+		}
+		if subcommand == resp.LEN {
+			return resp.Integer(h.slowlog.Len()), nil
+		}
+		h.slowlog.Reset()
+		return resp.OK, nil
+	default:
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+}
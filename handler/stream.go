@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// defaultTrimLimit bounds the entries removed by approximate trimming without LIMIT, like Redis does.
+const defaultTrimLimit = 10000
+
+var (
+	errStreamExhausted = resp.NewSimpleError("The stream has exhausted the last possible ID, unable to add more items")
+	errNoSuchKey       = errors.New("no such key")
+)
+
+// streamOf returns the stream held by entry, a missing key is reported as a nil stream.
+func streamOf(entry store.Entry, exists bool) (*object.Stream, error) {
+	if !exists {
+		return nil, nil
+	}
+	s, ok := entry.Value.(*object.Stream)
+	if !ok {
+		return nil, errWrongType
+	}
+	return s, nil
+}
+
+// withStream runs fn on the stream at key and reports whether the key exists, fn is not called otherwise.
+// fn reports whether it modified the stream.
+func (h *Handler) withStream(db int, key string, fn func(s *object.Stream) (bool, error)) (bool, error) {
+	var err error
+	found := false
+	h.dbs.get(db).Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var s *object.Stream
+		s, err = streamOf(entry, exists)
+		if err != nil || s == nil {
+			return entry, store.OpKeep
+		}
+		found = true
+
+		var modified bool
+		modified, err = fn(s)
+		if modified {
+			return entry, store.OpSet
+		}
+		return entry, store.OpKeep
+	})
+	return found, err
+}
+
+// addID is the ID argument of XADD, whose parts may be left for the stream to generate.
+type addID struct {
+	id object.StreamID
+	// "*", the whole ID is generated
+	auto bool
+	// "<ms>-*", only the sequence is generated
+	autoSeq bool
+}
+
+func parseAddID(s string) (addID, error) {
+	if s == "*" {
+		return addID{auto: true}, nil
+	}
+	if ms, ok := strings.CutSuffix(s, "-*"); ok {
+		id, err := object.ParseStreamID(ms, 0)
+		if err != nil || strings.Contains(ms, "-") {
+			return addID{}, object.ErrInvalidStreamID
+		}
+		return addID{id: id, autoSeq: true}, nil
+	}
+	id, err := object.ParseStreamID(s, 0)
+	return addID{id: id}, err
+}
+
+// resolve returns the ID to add to s at time now.
+func (a addID) resolve(s *object.Stream, now time.Time) (object.StreamID, error) {
+	switch {
+	case a.auto:
+		id, ok := s.NextID(now)
+		if !ok {
+			return object.StreamID{}, errStreamExhausted
+		}
+		return id, nil
+	case a.autoSeq:
+		last := s.LastID()
+		if a.id.Ms > last.Ms {
+			return object.StreamID{Ms: a.id.Ms}, nil
+		}
+		if a.id.Ms < last.Ms {
+			return object.StreamID{}, object.ErrStreamIDTooSmall
+		}
+		id, ok := last.Next()
+		if !ok || id.Ms != last.Ms {
+			return object.StreamID{}, object.ErrStreamIDTooSmall
+		}
+		return id, nil
+	default:
+		return a.id, nil
+	}
+}
+
+// trimmer returns the function applying the trimming options to a stream, nil when there are none.
+func trimmer(trim resp.TrimArgs) (func(s *object.Stream) int, error) {
+	limit := int(trim.Limit)
+	if trim.Approx && limit == 0 {
+		limit = defaultTrimLimit
+	}
+
+	switch trim.Strategy {
+	case resp.MAXLEN:
+		// the parser checked the threshold is a non-negative integer
+		maxLen, err := resp.ParseInteger(resp.BulkString(trim.Threshold))
+		if err != nil {
+			return nil, err
+		}
+		return func(s *object.Stream) int { return s.TrimMaxLen(int(maxLen), trim.Approx, limit) }, nil
+	case resp.MINID:
+		minID, err := object.ParseStreamID(trim.Threshold, 0)
+		if err != nil {
+			return nil, err
+		}
+		return func(s *object.Stream) int { return s.TrimMinID(minID, trim.Approx, limit) }, nil
+	default:
+		return nil, nil
+	}
+}
+
+// parseRangeID parses a bound of XRANGE: "-", "+", an ID, or an exclusive ID prefixed with "(".
+// incomplete IDs get the lowest sequence as start and the highest as end.
+func parseRangeID(s string, end bool) (object.StreamID, error) {
+	switch s {
+	case "-":
+		return object.MinStreamID, nil
+	case "+":
+		return object.MaxStreamID, nil
+	}
+
+	missingSeq := uint64(0)
+	if end {
+		missingSeq = object.MaxStreamID.Seq
+	}
+	exclusive, ok := strings.CutPrefix(s, "(")
+	if !ok {
+		return object.ParseStreamID(s, missingSeq)
+	}
+
+	id, err := object.ParseStreamID(exclusive, missingSeq)
+	if err != nil {
+		return id, err
+	}
+	if end {
+		id, ok = id.Prev()
+		if !ok {
+			return id, errors.New("invalid end ID for the interval")
+		}
+		return id, nil
+	}
+	id, ok = id.Next()
+	if !ok {
+		return id, errors.New("invalid start ID for the interval")
+	}
+	return id, nil
+}
+
+// entryPayload replies an entry as its ID followed by its field/value pairs, which are null for deleted entries.
+func entryPayload(entry object.StreamEntry) resp.Array {
+	if entry.Fields == nil {
+		return resp.Array{resp.BulkString(entry.ID.String()), resp.NullArray{}}
+	}
+	return resp.Array{resp.BulkString(entry.ID.String()), bulkStrings(entry.Fields)}
+}
+
+func entriesPayload(entries []object.StreamEntry) resp.Array {
+	result := make(resp.Array, len(entries))
+	for i, entry := range entries {
+		result[i] = entryPayload(entry)
+	}
+	return result
+}
+
+func (h *Handler) handleXAdd(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseXAddArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := parseAddID(parsed.ID)
+	if err != nil {
+		return nil, err
+	}
+	trim, err := trimmer(parsed.Trim)
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.clock.Now()
+	var id object.StreamID
+	added := false
+	trimmed := 0
+	h.db(c).Compute(parsed.Key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var s *object.Stream
+		s, err = streamOf(entry, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		if s == nil {
+			if parsed.NoMkStream {
+				return entry, store.OpKeep
+			}
+			s = object.NewStream()
+			entry = store.Entry{Value: s}
+		}
+
+		id, err = spec.resolve(s, now)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		if err = s.Add(id, parsed.Fields); err != nil {
+			return entry, store.OpKeep
+		}
+		added = true
+		if trim != nil {
+			trimmed = trim(s)
+		}
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return resp.NULL, nil
+	}
+
+	// replicas get the ID that was generated, the ID precedes the fields
+	c.args = slices.Clone(args)
+	c.args[len(args)-len(parsed.Fields)-1] = resp.BulkString(id.String())
+
+	h.notifyKeyspaceEvent(pubsub.ClassStream, "xadd", parsed.Key, c.db)
+	if trimmed > 0 {
+		h.notifyKeyspaceEvent(pubsub.ClassStream, "xtrim", parsed.Key, c.db)
+	}
+	h.signalKeyReady(c, c.db, parsed.Key)
+	return resp.BulkString(id.String()), nil
+}
+
+func (h *Handler) handleXLen(c *Client, args resp.Array) (resp.Payload, error) {
+	key, err := resp.ParseGetArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	length := 0
+	_, err = h.withStream(c.db, key.String(), func(s *object.Stream) (bool, error) {
+		length = s.Len()
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(length), nil
+}
+
+func (h *Handler) handleXRange(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handleRange(c, args, false)
+}
+
+func (h *Handler) handleXRevRange(c *Client, args resp.Array) (resp.Payload, error) {
+	return h.handleRange(c, args, true)
+}
+
+func (h *Handler) handleRange(c *Client, args resp.Array, rev bool) (resp.Payload, error) {
+	key, first, second, count, err := resp.ParseXRangeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	// XREVRANGE takes the end first
+	startArg, endArg := first, second
+	if rev {
+		startArg, endArg = second, first
+	}
+	start, err := parseRangeID(startArg, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseRangeID(endArg, true)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []object.StreamEntry{}
+	if count == 0 {
+		return entriesPayload(entries), nil
+	}
+	_, err = h.withStream(c.db, key, func(s *object.Stream) (bool, error) {
+		entries = s.Range(start, end, int(max(count, 0)), rev)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entriesPayload(entries), nil
+}
+
+func (h *Handler) handleXDel(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]object.StreamID, len(strs)-1)
+	for i, s := range strs[1:] {
+		if ids[i], err = object.ParseStreamID(s, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	deleted := 0
+	_, err = h.withStream(c.db, strs[0], func(s *object.Stream) (bool, error) {
+		for _, id := range ids {
+			if s.Delete(id) {
+				deleted++
+			}
+		}
+		return deleted > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deleted > 0 {
+		h.notifyKeyspaceEvent(pubsub.ClassStream, "xdel", strs[0], c.db)
+	}
+	return resp.Integer(deleted), nil
+}
+
+func (h *Handler) handleXTrim(c *Client, args resp.Array) (resp.Payload, error) {
+	key, trimArgs, err := resp.ParseXTrimArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	trim, err := trimmer(trimArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := 0
+	_, err = h.withStream(c.db, key, func(s *object.Stream) (bool, error) {
+		trimmed = trim(s)
+		return trimmed > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed > 0 {
+		h.notifyKeyspaceEvent(pubsub.ClassStream, "xtrim", key, c.db)
+	}
+	return resp.Integer(trimmed), nil
+}
+
+func (h *Handler) handleXSetID(c *Client, args resp.Array) (resp.Payload, error) {
+	if len(args) != 3 {
+		return nil, errSyntax
+	}
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	id, err := object.ParseStreamID(strs[1], 0)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := h.withStream(c.db, strs[0], func(s *object.Stream) (bool, error) {
+		return true, s.SetLastID(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errNoSuchKey
+	}
+
+	h.notifyKeyspaceEvent(pubsub.ClassStream, "xsetid", strs[0], c.db)
+	return resp.OK, nil
+}
@@ -0,0 +1,462 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+var (
+	errBusyGroup       = resp.NewPrefixedError("BUSYGROUP", "Consumer Group name already exists")
+	errGroupKeyMissing = resp.NewSimpleError("The XGROUP subcommand requires the key to exist. " +
+		"Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+)
+
+func errNoGroup(key, group string) error {
+	return resp.NewPrefixedError("NOGROUP", fmt.Sprintf("No such consumer group '%s' for key name '%s'", group, key))
+}
+
+func errNoGroupKey(key, group string) error {
+	return resp.NewPrefixedError("NOGROUP", fmt.Sprintf("No such key '%s' or consumer group '%s'", key, group))
+}
+
+// parseGroupID parses the last delivered ID of XGROUP CREATE and SETID, "$" standing for the last ID of s.
+func parseGroupID(id string, s *object.Stream) (object.StreamID, error) {
+	if id == "$" {
+		return s.LastID(), nil
+	}
+	return object.ParseStreamID(id, 0)
+}
+
+// withGroup runs fn on a consumer group of the stream at key, with missing keys and groups reported by notFound.
+func (h *Handler) withGroup(db int, key, group string, notFound func(key, group string) error,
+	fn func(s *object.Stream, g *object.ConsumerGroup) (bool, error),
+) error {
+	found, err := h.withStream(db, key, func(s *object.Stream) (bool, error) {
+		g, ok := s.Group(group)
+		if !ok {
+			return false, notFound(key, group)
+		}
+		return fn(s, g)
+	})
+	if err == nil && !found {
+		return notFound(key, group)
+	}
+	return err
+}
+
+func (h *Handler) handleXGroup(c *Client, args resp.Array) (resp.Payload, error) {
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+	strs, err := resp.ParseStrings(args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	arity := map[resp.BulkString]int{
+		resp.CREATE:         3,
+		resp.SETID:          3,
+		resp.DESTROY:        2,
+		resp.CREATECONSUMER: 3,
+		resp.DELCONSUMER:    3,
+	}
+	want, ok := arity[subcommand]
+	if !ok {
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+	if len(strs) < want {
+		return nil, fmt.Errorf("wrong number of arguments for 'xgroup|%s' command", subcommand)
+	}
+
+	switch subcommand {
+	case resp.CREATE:
+		return h.handleXGroupCreate(c, strs)
+	case resp.SETID:
+		return h.handleXGroupSetID(c, strs)
+	default:
+		if len(strs) != want {
+			return nil, errSyntax
+		}
+		return h.handleXGroupConsumers(c, subcommand, strs)
+	}
+}
+
+// parseGroupOptions parses the options of XGROUP CREATE and SETID, it reports whether MKSTREAM was given.
+func parseGroupOptions(options []string, allowMkStream bool) (bool, error) {
+	mkStream := false
+	for i := 0; i < len(options); i++ {
+		switch resp.BulkString(options[i]).Upper() {
+		case resp.MKSTREAM:
+			if !allowMkStream {
+				return false, errSyntax
+			}
+			mkStream = true
+		case resp.ENTRIESREAD:
+			// the lag of the groups is computed from the stream, so the counter is not needed
+			if i+1 >= len(options) {
+				return false, errSyntax
+			}
+			if _, err := resp.ParseInteger(resp.BulkString(options[i+1])); err != nil {
+				return false, err
+			}
+			i++
+		default:
+			return false, errSyntax
+		}
+	}
+	return mkStream, nil
+}
+
+func (h *Handler) handleXGroupCreate(c *Client, strs []string) (resp.Payload, error) {
+	key, group, id := strs[0], strs[1], strs[2]
+	mkStream, err := parseGroupOptions(strs[3:], true)
+	if err != nil {
+		return nil, err
+	}
+
+	h.db(c).Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var s *object.Stream
+		s, err = streamOf(entry, exists)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		if s == nil {
+			if !mkStream {
+				err = errGroupKeyMissing
+				return entry, store.OpKeep
+			}
+			s = object.NewStream()
+			entry = store.Entry{Value: s}
+		}
+
+		var lastID object.StreamID
+		lastID, err = parseGroupID(id, s)
+		if err != nil {
+			return entry, store.OpKeep
+		}
+		if !s.CreateGroup(group, lastID) {
+			err = errBusyGroup
+			return entry, store.OpKeep
+		}
+		return entry, store.OpSet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.notifyKeyspaceEvent(pubsub.ClassStream, "xgroup-create", key, c.db)
+	return resp.OK, nil
+}
+
+func (h *Handler) handleXGroupSetID(c *Client, strs []string) (resp.Payload, error) {
+	key, group, id := strs[0], strs[1], strs[2]
+	if _, err := parseGroupOptions(strs[3:], false); err != nil {
+		return nil, err
+	}
+
+	found, err := h.withStream(c.db, key, func(s *object.Stream) (bool, error) {
+		g, ok := s.Group(group)
+		if !ok {
+			return false, errNoGroup(key, group)
+		}
+		lastID, err := parseGroupID(id, s)
+		if err != nil {
+			return false, err
+		}
+		g.SetID(lastID)
+		return true, nil
+	})
+	if err == nil && !found {
+		err = errGroupKeyMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.notifyKeyspaceEvent(pubsub.ClassStream, "xgroup-setid", key, c.db)
+	return resp.OK, nil
+}
+
+// handleXGroupConsumers handles the subcommands of XGROUP that remove groups or manage consumers.
+func (h *Handler) handleXGroupConsumers(c *Client, subcommand resp.BulkString, strs []string) (resp.Payload, error) {
+	key, group := strs[0], strs[1]
+
+	var reply int
+	event := ""
+	found, err := h.withStream(c.db, key, func(s *object.Stream) (bool, error) {
+		if subcommand == resp.DESTROY {
+			if s.DestroyGroup(group) {
+				reply, event = 1, "xgroup-destroy"
+			}
+			return reply > 0, nil
+		}
+
+		g, ok := s.Group(group)
+		if !ok {
+			return false, errNoGroup(key, group)
+		}
+		consumer := strs[2]
+		if subcommand == resp.CREATECONSUMER {
+			if _, created := g.CreateConsumer(consumer, h.clock.Now()); created {
+				reply, event = 1, "xgroup-createconsumer"
+			}
+			return reply > 0, nil
+		}
+		pending, deleted := g.DeleteConsumer(consumer)
+		if deleted {
+			reply, event = pending, "xgroup-delconsumer"
+		}
+		return deleted, nil
+	})
+	if err == nil && !found {
+		err = errGroupKeyMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if event != "" {
+		h.notifyKeyspaceEvent(pubsub.ClassStream, event, key, c.db)
+	}
+	if event == "xgroup-destroy" {
+		// clients blocked reading from the group are answered with an error
+		h.signalKeyReady(c, c.db, key)
+	}
+	return resp.Integer(reply), nil
+}
+
+func (h *Handler) handleXAck(c *Client, args resp.Array) (resp.Payload, error) {
+	strs, err := resp.ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]object.StreamID, len(strs)-2)
+	for i, s := range strs[2:] {
+		if ids[i], err = object.ParseStreamID(s, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	acked := 0
+	_, err = h.withStream(c.db, strs[0], func(s *object.Stream) (bool, error) {
+		g, ok := s.Group(strs[1])
+		if !ok {
+			return false, nil
+		}
+		for _, id := range ids {
+			if g.Ack(id) {
+				acked++
+			}
+		}
+		return acked > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Integer(acked), nil
+}
+
+func (h *Handler) handleXPending(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseXPendingArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Start == "" {
+		return h.pendingSummary(c, parsed.Key, parsed.Group)
+	}
+
+	start, err := parseRangeID(parsed.Start, false)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseRangeID(parsed.End, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := resp.Array{}
+	now := h.clock.Now()
+	err = h.withGroup(c.db, parsed.Key, parsed.Group, errNoGroupKey, func(_ *object.Stream, g *object.ConsumerGroup) (bool, error) {
+		var consumer *object.Consumer
+		if parsed.Consumer != "" {
+			var ok bool
+			if consumer, ok = g.Consumer(parsed.Consumer); !ok {
+				return false, nil
+			}
+		}
+		if parsed.Count == 0 {
+			return false, nil
+		}
+		for _, pe := range g.PendingRange(start, end, int(parsed.Count), consumer, parsed.MinIdle, now) {
+			result = append(result, resp.Array{
+				resp.BulkString(pe.ID.String()),
+				resp.BulkString(pe.Consumer.Name),
+				resp.Integer(now.Sub(pe.DeliveryTime).Milliseconds()),
+				resp.Integer(pe.DeliveryCount),
+			})
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pendingSummary replies the number of pending entries of a group, their ID range and their count per consumer.
+func (h *Handler) pendingSummary(c *Client, key, group string) (resp.Payload, error) {
+	result := resp.Array{resp.Integer(0), resp.NULL, resp.NULL, resp.NullArray{}}
+	err := h.withGroup(c.db, key, group, errNoGroupKey, func(_ *object.Stream, g *object.ConsumerGroup) (bool, error) {
+		pending := g.PendingRange(object.MinStreamID, object.MaxStreamID, 0, nil, 0, h.clock.Now())
+		if len(pending) == 0 {
+			return false, nil
+		}
+
+		consumers := resp.Array{}
+		for _, consumer := range g.Consumers() {
+			if n := consumer.Pending(); n > 0 {
+				consumers = append(consumers, resp.Array{resp.BulkString(consumer.Name), resp.BulkString(strconv.Itoa(n))})
+			}
+		}
+		result = resp.Array{
+			resp.Integer(len(pending)),
+			resp.BulkString(pending[0].ID.String()),
+			resp.BulkString(pending[len(pending)-1].ID.String()),
+			consumers,
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (h *Handler) handleXClaim(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseXClaimArgs(args, h.clock)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]object.StreamID, len(parsed.IDs))
+	for i, s := range parsed.IDs {
+		if ids[i], err = object.ParseStreamID(s, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	now := h.clock.Now()
+	deliveryTime := now
+	if parsed.DeliveryTime != nil && parsed.DeliveryTime.Before(now) {
+		deliveryTime = *parsed.DeliveryTime
+	}
+
+	var claimed []object.StreamEntry
+	err = h.withGroup(c.db, parsed.Key, parsed.Group, errNoGroupKey, func(s *object.Stream, g *object.ConsumerGroup) (bool, error) {
+		consumer, _ := g.CreateConsumer(parsed.Consumer, now)
+		consumer.SeenTime = now
+
+		for _, id := range ids {
+			entry, exists := s.Get(id)
+			pe, pending := g.Pending(id)
+			if !pending && (!parsed.Force || !exists) {
+				continue
+			}
+			if !exists {
+				// the entry was deleted from the stream, so it cannot be delivered anymore
+				g.Ack(id)
+				continue
+			}
+			if pending && now.Sub(pe.DeliveryTime) < parsed.MinIdle {
+				continue
+			}
+
+			pe = g.Claim(id, consumer)
+			pe.DeliveryTime = deliveryTime
+			if parsed.RetryCount != nil {
+				pe.DeliveryCount = *parsed.RetryCount
+			} else if !parsed.JustID {
+				pe.DeliveryCount++
+			}
+			consumer.ActiveTime = now
+			claimed = append(claimed, entry)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.JustID {
+		return streamIDs(claimed), nil
+	}
+	return entriesPayload(claimed), nil
+}
+
+func (h *Handler) handleXAutoClaim(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseXAutoClaimArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	start, err := parseRangeID(parsed.Start, false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.clock.Now()
+	cursor := object.MinStreamID
+	var claimed []object.StreamEntry
+	deleted := resp.Array{}
+	err = h.withGroup(c.db, parsed.Key, parsed.Group, errNoGroupKey, func(s *object.Stream, g *object.ConsumerGroup) (bool, error) {
+		consumer, _ := g.CreateConsumer(parsed.Consumer, now)
+		consumer.SeenTime = now
+
+		pending := g.PendingRange(start, object.MaxStreamID, 0, nil, parsed.MinIdle, now)
+		for i, pe := range pending {
+			// deleted entries count toward COUNT, like Redis does
+			if i == int(parsed.Count) {
+				cursor = pe.ID
+				break
+			}
+
+			entry, exists := s.Get(pe.ID)
+			if !exists {
+				g.Ack(pe.ID)
+				deleted = append(deleted, resp.BulkString(pe.ID.String()))
+				continue
+			}
+			pe = g.Claim(pe.ID, consumer)
+			pe.DeliveryTime = now
+			if !parsed.JustID {
+				pe.DeliveryCount++
+			}
+			consumer.ActiveTime = now
+			claimed = append(claimed, entry)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries resp.Array
+	if parsed.JustID {
+		entries = streamIDs(claimed)
+	} else {
+		entries = entriesPayload(claimed)
+	}
+	return resp.Array{resp.BulkString(cursor.String()), entries, deleted}, nil
+}
+
+func streamIDs(entries []object.StreamEntry) resp.Array {
+	result := make(resp.Array, len(entries))
+	for i, entry := range entries {
+		result[i] = resp.BulkString(entry.ID.String())
+	}
+	return result
+}
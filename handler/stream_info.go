@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+func (h *Handler) handleXInfo(c *Client, args resp.Array) (resp.Payload, error) {
+	subcommand, err := resp.ParseSubcommand(args)
+	if err != nil {
+		return nil, err
+	}
+	strs, err := resp.ParseStrings(args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	want := map[resp.BulkString]int{resp.STREAM: 1, resp.GROUPS: 1, resp.CONSUMERS: 2}[subcommand]
+	if want == 0 {
+		return nil, fmt.Errorf("unknown subcommand '%s'", subcommand)
+	}
+	if len(strs) != want {
+		return nil, fmt.Errorf("wrong number of arguments for 'xinfo|%s' command", subcommand)
+	}
+
+	var result resp.Array
+	found, err := h.withStream(c.db, strs[0], func(s *object.Stream) (bool, error) {
+		switch subcommand {
+		case resp.STREAM:
+			result = streamInfo(s)
+		case resp.GROUPS:
+			result = groupsInfo(s)
+		default:
+			g, ok := s.Group(strs[1])
+			if !ok {
+				return false, errNoGroup(strs[0], strs[1])
+			}
+			result = consumersInfo(g, h.clock.Now())
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errNoSuchKey
+	}
+	return result, nil
+}
+
+func streamInfo(s *object.Stream) resp.Array {
+	firstID := object.MinStreamID
+	var first, last resp.Payload = resp.NULL, resp.NULL
+	if entry, ok := s.First(); ok {
+		firstID = entry.ID
+		first = entryPayload(entry)
+	}
+	if entry, ok := s.Last(); ok {
+		last = entryPayload(entry)
+	}
+
+	return resp.Array{
+		resp.BulkString("length"), resp.Integer(s.Len()),
+		// the blocks play the role of the radix tree nodes of Redis
+		resp.BulkString("radix-tree-keys"), resp.Integer(s.Blocks()),
+		resp.BulkString("radix-tree-nodes"), resp.Integer(s.Blocks()),
+		resp.BulkString("last-generated-id"), resp.BulkString(s.LastID().String()),
+		resp.BulkString("max-deleted-entry-id"), resp.BulkString(s.MaxDeletedID().String()),
+		resp.BulkString("entries-added"), resp.Integer(s.EntriesAdded()),
+		resp.BulkString("recorded-first-entry-id"), resp.BulkString(firstID.String()),
+		resp.BulkString("groups"), resp.Integer(len(s.Groups())),
+		resp.BulkString("first-entry"), first,
+		resp.BulkString("last-entry"), last,
+	}
+}
+
+func groupsInfo(s *object.Stream) resp.Array {
+	groups := s.Groups()
+	result := make(resp.Array, len(groups))
+	for i, g := range groups {
+		lag := g.Lag(s)
+		result[i] = resp.Array{
+			resp.BulkString("name"), resp.BulkString(g.Name),
+			resp.BulkString("consumers"), resp.Integer(len(g.Consumers())),
+			resp.BulkString("pending"), resp.Integer(g.PendingCount()),
+			resp.BulkString("last-delivered-id"), resp.BulkString(g.LastID.String()),
+			resp.BulkString("entries-read"), resp.Integer(int64(s.EntriesAdded()) - int64(lag)),
+			resp.BulkString("lag"), resp.Integer(lag),
+		}
+	}
+	return result
+}
+
+func consumersInfo(g *object.ConsumerGroup, now time.Time) resp.Array {
+	consumers := g.Consumers()
+	result := make(resp.Array, len(consumers))
+	for i, consumer := range consumers {
+		inactive := int64(-1)
+		if !consumer.ActiveTime.IsZero() {
+			inactive = now.Sub(consumer.ActiveTime).Milliseconds()
+		}
+		result[i] = resp.Array{
+			resp.BulkString("name"), resp.BulkString(consumer.Name),
+			resp.BulkString("pending"), resp.Integer(consumer.Pending()),
+			resp.BulkString("idle"), resp.Integer(now.Sub(consumer.SeenTime).Milliseconds()),
+			resp.BulkString("inactive"), resp.Integer(inactive),
+		}
+	}
+	return result
+}
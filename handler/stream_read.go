@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// newEntriesID is the ID XREADGROUP takes to read the entries never delivered to the group.
+const newEntriesID = ">"
+
+func errNoGroupRead(key, group string) error {
+	return resp.NewPrefixedError("NOGROUP", fmt.Sprintf("No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option", key, group))
+}
+
+// resolveReadIDs returns the IDs after which XREAD reads each stream.
+// "$" stands for the last ID of the stream and "+" for the ID before its last entry, both at the time of the call.
+func (h *Handler) resolveReadIDs(db int, keys, ids []string) ([]object.StreamID, error) {
+	after := make([]object.StreamID, len(ids))
+	for i, id := range ids {
+		switch id {
+		case "$", "+":
+			_, err := h.withStream(db, keys[i], func(s *object.Stream) (bool, error) {
+				after[i] = s.LastID()
+				if last, ok := s.Last(); ok && id == "+" {
+					after[i], _ = last.ID.Prev()
+				}
+				return false, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		default:
+			var err error
+			if after[i], err = object.ParseStreamID(id, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return after, nil
+}
+
+func (h *Handler) handleXRead(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseXReadArgs(args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	db := c.db
+	after, err := h.resolveReadIDs(db, parsed.Keys, parsed.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	read := func() (resp.Payload, bool, error) {
+		var result resp.Array
+		for i, key := range parsed.Keys {
+			var entries []object.StreamEntry
+			_, err := h.withStream(db, key, func(s *object.Stream) (bool, error) {
+				entries = s.After(after[i], int(parsed.Count))
+				return false, nil
+			})
+			if err != nil {
+				return nil, false, err
+			}
+			if len(entries) > 0 {
+				result = append(result, resp.Array{resp.BulkString(key), entriesPayload(entries)})
+			}
+		}
+		return result, len(result) > 0, nil
+	}
+
+	if !parsed.Block {
+		return readNow(read)
+	}
+	return h.block(c, parsed.Keys, parsed.Timeout, read)
+}
+
+func (h *Handler) handleXReadGroup(c *Client, args resp.Array) (resp.Payload, error) {
+	parsed, err := resp.ParseXReadArgs(args, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// the history of the consumer is read after the given IDs, new entries are read with ">"
+	after := make([]object.StreamID, len(parsed.IDs))
+	history := false
+	for i, id := range parsed.IDs {
+		if id == newEntriesID {
+			continue
+		}
+		if after[i], err = object.ParseStreamID(id, 0); err != nil {
+			return nil, err
+		}
+		history = true
+	}
+
+	db := c.db
+	read := func() (resp.Payload, bool, error) {
+		var result resp.Array
+		now := h.clock.Now()
+		for i, key := range parsed.Keys {
+			var entries []object.StreamEntry
+			consumerCreated := false
+			found, err := h.withStream(db, key, func(s *object.Stream) (bool, error) {
+				g, ok := s.Group(parsed.Group)
+				if !ok {
+					return false, errNoGroupRead(key, parsed.Group)
+				}
+				var consumer *object.Consumer
+				consumer, consumerCreated = g.CreateConsumer(parsed.Consumer, now)
+				if parsed.IDs[i] == newEntriesID {
+					entries = g.ReadNew(s, consumer, int(parsed.Count), parsed.NoAck, now)
+				} else {
+					entries = g.ReadPending(s, consumer, after[i], int(parsed.Count), now)
+				}
+				return true, nil
+			})
+			if err == nil && !found {
+				err = errNoGroupRead(key, parsed.Group)
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			if consumerCreated {
+				h.notifyKeyspaceEvent(pubsub.ClassStream, "xgroup-createconsumer", key, db)
+			}
+			// the history is always replied, even when empty
+			if len(entries) > 0 || parsed.IDs[i] != newEntriesID {
+				result = append(result, resp.Array{resp.BulkString(key), entriesPayload(entries)})
+			}
+		}
+		return result, len(result) > 0, nil
+	}
+
+	if !parsed.Block || history {
+		return readNow(read)
+	}
+	return h.block(c, parsed.Keys, parsed.Timeout, read)
+}
+
+// readNow runs a read of streams without blocking, it replies a null array when nothing was read.
+func readNow(read func() (resp.Payload, bool, error)) (resp.Payload, error) {
+	payload, ok, err := read()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return resp.NullArray{}, nil
+	}
+	return payload, nil
+}
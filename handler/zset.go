@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// sortedSetOf returns the sorted set held by entry, a missing key is reported as a nil set.
+func sortedSetOf(entry store.Entry, exists bool) (*object.SortedSet, error) {
+	if !exists {
+		return nil, nil
+	}
+	z, ok := entry.Value.(*object.SortedSet)
+	if !ok {
+		return nil, errWrongType
+	}
+	return z, nil
+}
+
+// readSortedSet runs fn on the sorted set at key without modifying it and reports whether the key exists,
+// fn is not called otherwise.
+func (h *Handler) readSortedSet(db int, key string, fn func(z *object.SortedSet) error) (bool, error) {
+	var err error
+	found := false
+	h.dbs.get(db).Compute(key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		var z *object.SortedSet
+		z, err = sortedSetOf(entry, exists)
+		if err != nil || z == nil {
+			return entry, store.OpKeep
+		}
+		found = true
+		err = fn(z)
+		return entry, store.OpKeep
+	})
+	return found, err
+}
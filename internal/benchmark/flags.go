@@ -0,0 +1,200 @@
+package benchmark
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommandLine holds the parsed arguments of the benchmark.
+type CommandLine struct {
+	Host string
+	Port int
+	// number of concurrent clients, each with its own connection
+	Clients int
+	// number of commands sent by a client before reading their replies
+	Pipeline int
+	// number of requests after which the benchmark ends, 0 to run for Duration
+	Requests int64
+	// time after which the benchmark ends
+	Duration time.Duration
+	// number of distinct keys, picked at random
+	KeySpace int
+	// size of the values, picked at random between the bounds
+	ValueSize SizeRange
+	// commands sent, in proportion of their weights
+	Mix Mix
+
+	// benchmark the stores in-process rather than a server, the store being naive, eventloop or all
+	InProcess bool
+	Store     string
+}
+
+// Addr returns the address of the server.
+func (cl *CommandLine) Addr() string {
+	return net.JoinHostPort(cl.Host, strconv.Itoa(cl.Port))
+}
+
+// ParseCommandLine parses the options of the benchmark, e.g. -c 100 -P 16 --mix get=9,set=1.
+// usage and errors are written to output, and flag.ErrHelp is returned when --help is requested.
+func ParseCommandLine(program string, args []string, output io.Writer) (*CommandLine, error) {
+	cl := &CommandLine{
+		ValueSize: SizeRange{Min: 64, Max: 64},
+		Mix:       Mix{{Command: "get", Weight: 80}, {Command: "set", Weight: 20}},
+	}
+
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&cl.Host, "h", "127.0.0.1", "Server `hostname`")
+	fs.IntVar(&cl.Port, "p", 6379, "Server `port`")
+	fs.IntVar(&cl.Clients, "c", 50, "Number of parallel `clients`")
+	fs.IntVar(&cl.Pipeline, "P", 1, "Pipeline `requests`, sent before reading their replies")
+	fs.Int64Var(&cl.Requests, "n", 0, "Total number of `requests`, 0 to run for the duration")
+	fs.DurationVar(&cl.Duration, "duration", 10*time.Second, "How long the benchmark runs")
+	fs.IntVar(&cl.KeySpace, "r", 100000, "Number of distinct `keys`")
+	fs.Var(&cl.ValueSize, "d", "Value `size` in bytes, or range of sizes such as 16-1024")
+	fs.Var(&cl.Mix, "mix", "Weighted `commands`, among "+strings.Join(commandNames(), ", "))
+	fs.BoolVar(&cl.InProcess, "in-process", false, "Benchmark the stores in-process, without a server and the network")
+	fs.StringVar(&cl.Store, "store", "all", "Store benchmarked in-process: naive, eventloop or all")
+
+	fs.Usage = func() {
+		printUsage(fs, program)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := cl.validate(fs.Args()); err != nil {
+		fmt.Fprintln(output, err)
+		return nil, err
+	}
+	return cl, nil
+}
+
+func (cl *CommandLine) validate(args []string) error {
+	switch {
+	case len(args) > 0:
+		return fmt.Errorf("unexpected argument %q", args[0])
+	case cl.Clients < 1:
+		return errors.New("-c must be at least 1")
+	case cl.Pipeline < 1:
+		return errors.New("-P must be at least 1")
+	case cl.Requests < 0:
+		return errors.New("-n must not be negative")
+	case cl.Duration <= 0:
+		return errors.New("--duration must be positive")
+	case cl.KeySpace < 1:
+		return errors.New("-r must be at least 1")
+	case !slices.Contains([]string{"naive", "eventloop", "all"}, cl.Store):
+		return fmt.Errorf("unknown store %q, expected naive, eventloop or all", cl.Store)
+	default:
+		return nil
+	}
+}
+
+func printUsage(fs *flag.FlagSet, program string) {
+	out := fs.Output()
+
+	fmt.Fprintf(out, "Usage: %s [options]\n\n", program)
+	fmt.Fprintf(out, "Clients send a mix of commands on random keys, until the number of requests is reached or the duration elapsed.\n\n")
+
+	fmt.Fprintln(out, "Options:")
+	for _, name := range []string{"h", "p", "c", "P", "n", "duration", "r", "d", "mix", "in-process", "store"} {
+		f := fs.Lookup(name)
+		placeholder, usage := flag.UnquoteUsage(f)
+
+		dashes := "--"
+		if len(name) == 1 {
+			dashes = "-"
+		}
+		if placeholder != "" {
+			placeholder = " <" + placeholder + ">"
+		}
+		fmt.Fprintf(out, "  %s%s%s\n        %s", dashes, name, placeholder, usage)
+		if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" {
+			fmt.Fprintf(out, " (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "  --help\n        Print this help and exit\n")
+}
+
+// SizeRange is a range of sizes, given as a single size or as min-max.
+type SizeRange struct {
+	Min int
+	Max int
+}
+
+func (r *SizeRange) String() string {
+	if r.Min == r.Max {
+		return strconv.Itoa(r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+func (r *SizeRange) Set(value string) error {
+	low, high, isRange := strings.Cut(value, "-")
+	lowest, err := strconv.Atoi(low)
+	if err != nil {
+		return fmt.Errorf("invalid size %q", value)
+	}
+	highest := lowest
+	if isRange {
+		if highest, err = strconv.Atoi(high); err != nil {
+			return fmt.Errorf("invalid size %q", value)
+		}
+	}
+	if lowest < 0 || highest < lowest {
+		return fmt.Errorf("invalid size range %q", value)
+	}
+	r.Min, r.Max = lowest, highest
+	return nil
+}
+
+// Mix is the list of commands sent, each with its weight.
+type Mix []WeightedCommand
+
+// WeightedCommand is a command of a Mix, sent in proportion of its weight over the total weight.
+type WeightedCommand struct {
+	Command string
+	Weight  int
+}
+
+func (m *Mix) String() string {
+	parts := make([]string, len(*m))
+	for i, c := range *m {
+		parts[i] = c.Command + "=" + strconv.Itoa(c.Weight)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set parses a list of command=weight, such as get=9,set=1, a command without weight weighing 1.
+func (m *Mix) Set(value string) error {
+	var mix Mix
+	for part := range strings.SplitSeq(value, ",") {
+		name, weight, hasWeight := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.ToLower(name)
+		if _, ok := commands[name]; !ok {
+			return fmt.Errorf("unknown command %q, expected one of %s", name, strings.Join(commandNames(), ", "))
+		}
+		w := 1
+		if hasWeight {
+			var err error
+			if w, err = strconv.Atoi(weight); err != nil || w < 1 {
+				return fmt.Errorf("invalid weight %q of %s", weight, name)
+			}
+		}
+		if slices.ContainsFunc(mix, func(c WeightedCommand) bool { return c.Command == name }) {
+			return fmt.Errorf("duplicate command %q", name)
+		}
+		mix = append(mix, WeightedCommand{Command: name, Weight: w})
+	}
+	*m = mix
+	return nil
+}
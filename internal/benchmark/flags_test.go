@@ -0,0 +1,73 @@
+package benchmark
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cl, err := ParseCommandLine("gvalkey-benchmark", nil, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:6379", cl.Addr())
+		require.Equal(t, 50, cl.Clients)
+		require.Equal(t, 1, cl.Pipeline)
+		require.Equal(t, 10*time.Second, cl.Duration)
+		require.Equal(t, SizeRange{Min: 64, Max: 64}, cl.ValueSize)
+		require.Equal(t, Mix{{Command: "get", Weight: 80}, {Command: "set", Weight: 20}}, cl.Mix)
+		require.False(t, cl.InProcess)
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		cl, err := ParseCommandLine("gvalkey-benchmark", []string{
+			"-p", "7000", "-c", "8", "-P", "16", "-n", "1000", "-r", "10", "-d", "16-1024",
+			"--mix", "GET=9, set=1,lpush", "--in-process", "--store", "naive",
+		}, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:7000", cl.Addr())
+		require.Equal(t, 8, cl.Clients)
+		require.Equal(t, 16, cl.Pipeline)
+		require.Equal(t, int64(1000), cl.Requests)
+		require.Equal(t, 10, cl.KeySpace)
+		require.Equal(t, SizeRange{Min: 16, Max: 1024}, cl.ValueSize)
+		require.Equal(t, "16-1024", cl.ValueSize.String())
+		require.Equal(t, Mix{{Command: "get", Weight: 9}, {Command: "set", Weight: 1}, {Command: "lpush", Weight: 1}}, cl.Mix)
+		require.Equal(t, "get=9,set=1,lpush=1", cl.Mix.String())
+		require.True(t, cl.InProcess)
+		require.Equal(t, "naive", cl.Store)
+	})
+
+	t.Run("Help lists every option", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := ParseCommandLine("gvalkey-benchmark", []string{"--help"}, &out)
+		require.ErrorIs(t, err, flag.ErrHelp)
+		for _, option := range []string{"-c <clients>", "-P <requests>", "--duration", "--mix <commands>", "--in-process", "--store"} {
+			require.Contains(t, out.String(), option)
+		}
+	})
+
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-c", "0"}, "-c must be at least 1"},
+		{[]string{"-P", "0"}, "-P must be at least 1"},
+		{[]string{"--duration", "0s"}, "--duration must be positive"},
+		{[]string{"-d", "10-5"}, "invalid size range"},
+		{[]string{"-d", "big"}, "invalid size"},
+		{[]string{"--mix", "get=9,flushall=1"}, `unknown command "flushall"`},
+		{[]string{"--mix", "get=0"}, "invalid weight"},
+		{[]string{"--mix", "get,get"}, `duplicate command "get"`},
+		{[]string{"--store", "disk"}, `unknown store "disk"`},
+		{[]string{"extra"}, `unexpected argument "extra"`},
+	} {
+		t.Run(tc.err, func(t *testing.T) {
+			_, err := ParseCommandLine("gvalkey-benchmark", tc.args, &bytes.Buffer{})
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package benchmark implements gvalkey-benchmark, which measures the throughput and the latencies of a server,
+// or of store.Store implementations in-process.
+package benchmark
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Histogram records durations in buckets whose width grows with the duration, like HdrHistogram,
+// so that percentiles keep a number of significant digits from nanoseconds to the highest trackable duration.
+// it is not safe for concurrent use, concurrent recorders keep a histogram each and merge them.
+type Histogram struct {
+	// log2 of the number of buckets for each power of two, the values below being recorded exactly
+	subBits uint
+	highest int64
+	counts  []int64
+
+	total int64
+	sum   int64
+	min   int64
+	max   int64
+}
+
+// NewHistogram creates a histogram of durations up to highest, keeping digits significant digits, from 1 to 5.
+// longer durations are recorded as highest.
+func NewHistogram(highest time.Duration, digits int) *Histogram {
+	digits = min(max(digits, 1), 5)
+	// the buckets of a power of two are at most 1/2^(subBits-1) of their lowest value wide
+	subBits := uint(bits.Len64(uint64(2*math.Pow10(digits)) - 1))
+	h := &Histogram{subBits: subBits, highest: max(int64(highest), 1)}
+	h.counts = make([]int64, h.index(h.highest)+1)
+	h.Reset()
+	return h
+}
+
+// index returns the bucket of v: values below 2^subBits have their own bucket, and each following power of two
+// is split in 2^(subBits-1) buckets.
+func (h *Histogram) index(v int64) int {
+	subCount := int64(1) << h.subBits
+	if v < subCount {
+		return int(v)
+	}
+	shift := uint(bits.Len64(uint64(v))) - h.subBits
+	top := v >> shift
+	return int(subCount + int64(shift-1)*(subCount/2) + top - subCount/2)
+}
+
+// highestEquivalent returns the highest value recorded in the bucket at index.
+func (h *Histogram) highestEquivalent(index int) int64 {
+	subCount := int64(1) << h.subBits
+	if int64(index) < subCount {
+		return int64(index)
+	}
+	shift := uint((int64(index)-subCount)/(subCount/2)) + 1
+	top := (int64(index)-subCount)%(subCount/2) + subCount/2
+	return (top+1)<<shift - 1
+}
+
+// Record adds a duration, negative durations being recorded as zero.
+func (h *Histogram) Record(d time.Duration) {
+	v := min(max(int64(d), 0), h.highest)
+	h.counts[h.index(v)]++
+	h.total++
+	h.sum += v
+	h.min = min(h.min, v)
+	h.max = max(h.max, v)
+}
+
+// Merge adds the durations recorded by other, which must have been created with the same arguments.
+func (h *Histogram) Merge(other *Histogram) {
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.total += other.total
+	h.sum += other.sum
+	h.min = min(h.min, other.min)
+	h.max = max(h.max, other.max)
+}
+
+// Reset removes the recorded durations.
+func (h *Histogram) Reset() {
+	clear(h.counts)
+	h.total, h.sum = 0, 0
+	h.min, h.max = math.MaxInt64, 0
+}
+
+// Count returns the number of recorded durations.
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// Min returns the shortest recorded duration.
+func (h *Histogram) Min() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.min)
+}
+
+// Max returns the longest recorded duration.
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+// Mean returns the average of the recorded durations.
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / h.total)
+}
+
+// Percentile returns the duration below which p percent of the recorded durations fall, with the precision of the histogram.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(min(max(p, 0), 100) / 100 * float64(h.total)))
+	rank = max(rank, 1)
+
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			return time.Duration(min(max(h.highestEquivalent(i), h.min), h.max))
+		}
+	}
+	return time.Duration(h.max)
+}
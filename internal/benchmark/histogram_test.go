@@ -0,0 +1,82 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogram(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		h := NewHistogram(time.Second, 3)
+		require.Zero(t, h.Count())
+		require.Zero(t, h.Min())
+		require.Zero(t, h.Max())
+		require.Zero(t, h.Mean())
+		require.Zero(t, h.Percentile(99))
+	})
+
+	t.Run("Percentiles", func(t *testing.T) {
+		h := NewHistogram(time.Minute, 3)
+		for i := 1; i <= 10000; i++ {
+			h.Record(time.Duration(i) * time.Microsecond)
+		}
+		require.Equal(t, int64(10000), h.Count())
+		require.Equal(t, time.Microsecond, h.Min())
+		require.Equal(t, 10*time.Millisecond, h.Max())
+		require.InDelta(t, 5000*time.Microsecond, h.Mean(), float64(time.Microsecond))
+
+		for _, tc := range []struct {
+			p    float64
+			want time.Duration
+		}{
+			{0, time.Microsecond},
+			{50, 5 * time.Millisecond},
+			{90, 9 * time.Millisecond},
+			{99, 9900 * time.Microsecond},
+			{99.9, 9990 * time.Microsecond},
+			{100, 10 * time.Millisecond},
+		} {
+			got := h.Percentile(tc.p)
+			// 3 significant digits
+			require.InEpsilon(t, tc.want, got, 0.001, "p%v", tc.p)
+			require.GreaterOrEqual(t, got, tc.want, "p%v", tc.p)
+		}
+	})
+
+	t.Run("Small values are exact", func(t *testing.T) {
+		h := NewHistogram(time.Second, 2)
+		for _, d := range []time.Duration{3, 1, 2, 150} {
+			h.Record(d)
+		}
+		require.Equal(t, time.Duration(1), h.Percentile(25))
+		require.Equal(t, time.Duration(2), h.Percentile(50))
+		require.Equal(t, time.Duration(3), h.Percentile(75))
+		require.Equal(t, time.Duration(150), h.Percentile(100))
+	})
+
+	t.Run("Out of range", func(t *testing.T) {
+		h := NewHistogram(time.Second, 3)
+		h.Record(-time.Second)
+		h.Record(time.Hour)
+		require.Zero(t, h.Min())
+		require.Equal(t, time.Second, h.Max())
+		require.Equal(t, time.Second, h.Percentile(100))
+	})
+
+	t.Run("Merge and reset", func(t *testing.T) {
+		a, b := NewHistogram(time.Second, 3), NewHistogram(time.Second, 3)
+		a.Record(time.Millisecond)
+		b.Record(3 * time.Millisecond)
+		a.Merge(b)
+		require.Equal(t, int64(2), a.Count())
+		require.Equal(t, time.Millisecond, a.Min())
+		require.Equal(t, 3*time.Millisecond, a.Max())
+		require.Equal(t, 2*time.Millisecond, a.Mean())
+
+		a.Reset()
+		require.Zero(t, a.Count())
+		require.Zero(t, a.Max())
+	})
+}
@@ -0,0 +1,102 @@
+package benchmark
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// reportedPercentiles are the percentiles of the latency columns of the report.
+var reportedPercentiles = []float64{50, 90, 99, 99.9, 99.99}
+
+// Result is the outcome of a benchmark.
+type Result struct {
+	// server address or store name
+	Name    string
+	Clients int
+	Mix     Mix
+	Elapsed time.Duration
+	// number of error replies
+	Errors int64
+	// latencies of the commands, in the order of the mix
+	Latencies []*Histogram
+	// unit of the reported latencies, time.Millisecond or time.Microsecond
+	Unit time.Duration
+
+	mu sync.Mutex
+}
+
+func newResult(name string, cl *CommandLine, unit time.Duration) *Result {
+	r := &Result{Name: name, Clients: cl.Clients, Mix: cl.Mix, Unit: unit}
+	for range cl.Mix {
+		r.Latencies = append(r.Latencies, NewHistogram(histogramHighest, histogramDigits))
+	}
+	return r
+}
+
+// Requests returns the number of requests that got a reply.
+func (r *Result) Requests() int64 {
+	var n int64
+	for _, h := range r.Latencies {
+		n += h.Count()
+	}
+	return n
+}
+
+// Throughput returns the number of requests per second.
+func (r *Result) Throughput() float64 {
+	return perSecond(r.Requests(), r.Elapsed)
+}
+
+// Report writes the throughput and the latency percentiles, per command and for all of them.
+func (r *Result) Report(w io.Writer) error {
+	fmt.Fprintf(w, "====== %s ======\n", r.Name)
+	fmt.Fprintf(w, "%d requests completed in %.2f seconds, %d clients, %d errors\n",
+		r.Requests(), r.Elapsed.Seconds(), r.Clients, r.Errors)
+	fmt.Fprintf(w, "throughput: %.2f requests per second\n\n", r.Throughput())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "command\trequests\trps\tmin\t")
+	for _, p := range reportedPercentiles {
+		fmt.Fprintf(tw, "p%s\t", strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	fmt.Fprint(tw, "max\tavg\t\n")
+
+	all := NewHistogram(histogramHighest, histogramDigits)
+	for i, h := range r.Latencies {
+		r.writeRow(tw, r.Mix[i].Command, h)
+		all.Merge(h)
+	}
+	if len(r.Latencies) > 1 {
+		r.writeRow(tw, "all", all)
+	}
+	if r.Unit == time.Microsecond {
+		fmt.Fprintln(tw, "\nlatencies in microseconds")
+	} else {
+		fmt.Fprintln(tw, "\nlatencies in milliseconds")
+	}
+	return tw.Flush()
+}
+
+func (r *Result) writeRow(w io.Writer, name string, h *Histogram) {
+	fmt.Fprintf(w, "%s\t%d\t%.2f\t%.3f\t", name, h.Count(), perSecond(h.Count(), r.Elapsed), r.in(h.Min()))
+	for _, p := range reportedPercentiles {
+		fmt.Fprintf(w, "%.3f\t", r.in(h.Percentile(p)))
+	}
+	fmt.Fprintf(w, "%.3f\t%.3f\t\n", r.in(h.Max()), r.in(h.Mean()))
+}
+
+func perSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// in converts d to the unit of the result.
+func (r *Result) in(d time.Duration) float64 {
+	return float64(d) / float64(max(r.Unit, 1))
+}
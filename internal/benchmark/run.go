@@ -0,0 +1,232 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/eventloop"
+	"github.com/PlayerNeo42/gvalkey/store/naive"
+)
+
+const (
+	dialTimeout = 5 * time.Second
+
+	// histograms track latencies up to a minute with 3 significant digits
+	histogramHighest = time.Minute
+	histogramDigits  = 3
+
+	// number of latencies a client buffers before recording them into the shared histograms
+	sampleBuffer = 1024
+	// number of requests a client applies to a store in-process between two checks of the end of the benchmark
+	storeBatch = 64
+)
+
+// budget hands the requests out to the clients, without limit when total is 0.
+type budget struct {
+	total int64
+	taken atomic.Int64
+}
+
+// take returns how many of n requests may still be sent, 0 once every request was handed out.
+func (b *budget) take(n int) int {
+	if b.total == 0 {
+		return n
+	}
+	taken := b.taken.Add(int64(n))
+	return int(max(min(int64(n), b.total-taken+int64(n)), 0))
+}
+
+// sample is the latency of a request, along with the index of its command in the mix.
+type sample struct {
+	command int
+	latency time.Duration
+}
+
+// recorder buffers the latencies measured by a client, and records them into the histograms of the result every so often.
+type recorder struct {
+	result  *Result
+	samples []sample
+	errors  int64
+}
+
+func (r *recorder) record(command int, latency time.Duration) {
+	r.samples = append(r.samples, sample{command: command, latency: latency})
+	if len(r.samples) == cap(r.samples) {
+		r.flush()
+	}
+}
+
+func (r *recorder) flush() {
+	r.result.mu.Lock()
+	defer r.result.mu.Unlock()
+	for _, s := range r.samples {
+		r.result.Latencies[s.command].Record(s.latency)
+	}
+	r.result.Errors += r.errors
+	r.samples, r.errors = r.samples[:0], 0
+}
+
+// clientFunc sends the requests of a client until the budget is exhausted or ctx is done.
+type clientFunc func(ctx context.Context, client int, w *workload, b *budget, r *recorder) error
+
+// run runs a benchmark with cl.Clients goroutines running fn, and collects their latencies.
+// errors of the clients once ctx is done are not reported, as they are those of the interrupted requests.
+func run(ctx context.Context, cl *CommandLine, result *Result, fn clientFunc) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, cl.Duration)
+	defer cancel()
+
+	b := &budget{total: cl.Requests}
+	values := randomValues(cl)
+	errs := make([]error, cl.Clients)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range cl.Clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &recorder{result: result, samples: make([]sample, 0, sampleBuffer)}
+			errs[i] = fn(ctx, i, newWorkload(cl, uint64(i), values), b, r)
+			r.flush()
+		}()
+	}
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+
+	if ctx.Err() == nil {
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// RunServer benchmarks the server at cl.Addr(), each client with its own connection.
+func RunServer(ctx context.Context, cl *CommandLine) (*Result, error) {
+	conns := make([]net.Conn, 0, cl.Clients)
+	defer func() {
+		for _, nc := range conns {
+			_ = nc.Close()
+		}
+	}()
+	for range cl.Clients {
+		nc, err := net.DialTimeout("tcp", cl.Addr(), dialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to %s: %w", cl.Addr(), err)
+		}
+		conns = append(conns, nc)
+	}
+
+	return run(ctx, cl, newResult(cl.Addr(), cl, time.Millisecond), func(ctx context.Context, client int, w *workload, b *budget, r *recorder) error {
+		nc := conns[client]
+		// interrupts the client waiting for replies once the benchmark is over
+		stop := context.AfterFunc(ctx, func() {
+			_ = nc.SetDeadline(time.Unix(1, 0))
+		})
+		defer stop()
+		return runConn(ctx, nc, cl.Pipeline, w, b, r)
+	})
+}
+
+// runConn sends batches of pipeline requests on nc, and measures the latency of each reply since its batch was sent.
+func runConn(ctx context.Context, nc net.Conn, pipeline int, w *workload, b *budget, r *recorder) error {
+	var enc resp.Encoder
+	parser := resp.NewReplyParser(nc)
+	batch := make([]int, 0, pipeline)
+	var o op
+
+	for ctx.Err() == nil {
+		n := b.take(pipeline)
+		if n == 0 {
+			return nil
+		}
+		batch = batch[:0]
+		for range n {
+			i := w.next(&o)
+			batch = append(batch, i)
+			if err := enc.Encode(w.commands[i].request(&o)); err != nil {
+				return err
+			}
+		}
+
+		start := time.Now()
+		if _, err := enc.WriteTo(nc); err != nil {
+			return err
+		}
+		for _, i := range batch {
+			reply, err := parser.Parse()
+			if err != nil {
+				return err
+			}
+			if _, ok := reply.(resp.SimpleError); ok {
+				r.errors++
+			}
+			r.record(i, time.Since(start))
+		}
+	}
+	return nil
+}
+
+// RunStore benchmarks a store in-process, each client being a goroutine applying its requests to the store.
+// the latencies include the time of measuring them, which is small but not negligible next to the fastest operations.
+func RunStore(ctx context.Context, cl *CommandLine, name string, s store.Store) (*Result, error) {
+	return run(ctx, cl, newResult(name, cl, time.Microsecond), func(ctx context.Context, _ int, w *workload, b *budget, r *recorder) error {
+		var o op
+		for ctx.Err() == nil {
+			n := b.take(storeBatch)
+			if n == 0 {
+				return nil
+			}
+			for range n {
+				i := w.next(&o)
+				start := time.Now()
+				w.commands[i].apply(s, &o)
+				r.record(i, time.Since(start))
+			}
+		}
+		return nil
+	})
+}
+
+// storeFactories create the stores benchmarked in-process, along with the function closing them.
+var storeFactories = []struct {
+	name string
+	open func() (store.Store, func())
+}{
+	{"naive", func() (store.Store, func()) {
+		s := naive.NewNaiveStore()
+		return s, s.Close
+	}},
+	{"eventloop", func() (store.Store, func()) {
+		s := eventloop.NewEventloopStore()
+		return s, s.Close
+	}},
+}
+
+// RunStores benchmarks the stores selected by cl.Store in turn, each starting empty.
+func RunStores(ctx context.Context, cl *CommandLine) ([]*Result, error) {
+	var results []*Result
+	for _, f := range storeFactories {
+		if cl.Store != "all" && cl.Store != f.name {
+			continue
+		}
+		s, closeStore := f.open()
+		result, err := RunStore(ctx, cl, f.name, s)
+		closeStore()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,129 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/gvalkeytest"
+	"github.com/stretchr/testify/require"
+)
+
+// newCommandLine returns the options of a short benchmark of every command.
+func newCommandLine(t *testing.T, args ...string) *CommandLine {
+	t.Helper()
+	args = append([]string{"-c", "4", "-P", "8", "-r", "50", "-d", "1-32", "--mix", "get,set,del,lpush,rpush,lpop,rpop,hset,hget"}, args...)
+	cl, err := ParseCommandLine("gvalkey-benchmark", args, &bytes.Buffer{})
+	require.NoError(t, err)
+	return cl
+}
+
+// serve runs a server for the duration of the test, and points cl to it.
+func serve(t *testing.T, cl *CommandLine) {
+	t.Helper()
+	s := gvalkeytest.Run(t)
+	cl.Host, cl.Port = s.Host(), s.Port()
+}
+
+func TestRunServer(t *testing.T) {
+	t.Run("Requests", func(t *testing.T) {
+		cl := newCommandLine(t, "-n", "1000")
+		serve(t, cl)
+
+		result, err := RunServer(t.Context(), cl)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), result.Requests())
+		require.Zero(t, result.Errors)
+		for i, h := range result.Latencies {
+			require.Positive(t, h.Count(), cl.Mix[i].Command)
+		}
+
+		var out bytes.Buffer
+		require.NoError(t, result.Report(&out))
+		require.Contains(t, out.String(), "1000 requests completed")
+		require.Contains(t, out.String(), "p99.9")
+		require.Contains(t, out.String(), "latencies in milliseconds")
+		for _, name := range append(commandNames(), "all") {
+			require.Contains(t, out.String(), name)
+		}
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		cl := newCommandLine(t, "--duration", "100ms")
+		serve(t, cl)
+
+		start := time.Now()
+		result, err := RunServer(t.Context(), cl)
+		require.NoError(t, err)
+		require.Less(t, time.Since(start), 5*time.Second)
+		require.Positive(t, result.Requests())
+		require.Positive(t, result.Throughput())
+	})
+
+	t.Run("Interrupted", func(t *testing.T) {
+		cl := newCommandLine(t)
+		serve(t, cl)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		result, err := RunServer(ctx, cl)
+		require.NoError(t, err)
+		require.Less(t, result.Elapsed, 5*time.Second)
+	})
+
+	t.Run("No server", func(t *testing.T) {
+		cl := newCommandLine(t, "-p", "1")
+		_, err := RunServer(t.Context(), cl)
+		require.ErrorContains(t, err, "could not connect to 127.0.0.1:1")
+	})
+}
+
+func TestRunStores(t *testing.T) {
+	cl := newCommandLine(t, "-n", "2000", "--in-process")
+	results, err := RunStores(t.Context(), cl)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "naive", results[0].Name)
+	require.Equal(t, "eventloop", results[1].Name)
+	for _, result := range results {
+		require.Equal(t, int64(2000), result.Requests())
+		require.Equal(t, time.Microsecond, result.Unit)
+	}
+
+	cl.Store = "eventloop"
+	results, err = RunStores(t.Context(), cl)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "eventloop", results[0].Name)
+}
+
+func TestWorkload(t *testing.T) {
+	cl := newCommandLine(t, "--mix", "get=3,hset=1", "-d", "4-8")
+	values := randomValues(cl)
+	require.Len(t, values, 16)
+
+	w := newWorkload(cl, 1, values)
+	counts := make([]int, len(cl.Mix))
+	var o op
+	for range 4000 {
+		i := w.next(&o)
+		counts[i]++
+		require.Contains(t, []string{"string", "hash"}, o.key[:len(w.commands[i].keyType)])
+		require.GreaterOrEqual(t, len(o.value), 4)
+		require.LessOrEqual(t, len(o.value), 8)
+	}
+	require.InDelta(t, 3000, counts[0], 200)
+	require.InDelta(t, 1000, counts[1], 200)
+}
+
+func TestBudget(t *testing.T) {
+	b := &budget{total: 10}
+	require.Equal(t, 4, b.take(4))
+	require.Equal(t, 4, b.take(4))
+	require.Equal(t, 2, b.take(4))
+	require.Zero(t, b.take(4))
+
+	unlimited := &budget{}
+	require.Equal(t, 4, unlimited.take(4))
+}
@@ -0,0 +1,199 @@
+package benchmark
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// op is a request of the workload.
+type op struct {
+	key   string
+	field string
+	value string
+}
+
+// command is a command of the workload, which is either sent to a server or applied to a store.
+type command struct {
+	// type of the keys of the command, prefixing them so that commands on different types do not clash
+	keyType string
+	request func(o *op) resp.Array
+	apply   func(s store.Store, o *op)
+}
+
+var commands = map[string]command{
+	"get": {
+		keyType: "string",
+		request: func(o *op) resp.Array { return resp.Array{resp.GET, resp.BulkString(o.key)} },
+		apply:   func(s store.Store, o *op) { s.Get(o.key) },
+	},
+	"set": {
+		keyType: "string",
+		request: func(o *op) resp.Array { return resp.Array{resp.SET, resp.BulkString(o.key), resp.BulkString(o.value)} },
+		apply: func(s store.Store, o *op) {
+			s.Set(resp.SetArgs{Key: resp.BulkString(o.key), Value: resp.BulkString(o.value)})
+		},
+	},
+	"del": {
+		keyType: "string",
+		request: func(o *op) resp.Array { return resp.Array{resp.DEL, resp.BulkString(o.key)} },
+		apply:   func(s store.Store, o *op) { s.Del(o.key) },
+	},
+	"lpush": {
+		keyType: "list",
+		request: func(o *op) resp.Array {
+			return resp.Array{resp.LPUSH, resp.BulkString(o.key), resp.BulkString(o.value)}
+		},
+		apply: func(s store.Store, o *op) { push(s, o, (*object.List).PushLeft) },
+	},
+	"rpush": {
+		keyType: "list",
+		request: func(o *op) resp.Array {
+			return resp.Array{resp.RPUSH, resp.BulkString(o.key), resp.BulkString(o.value)}
+		},
+		apply: func(s store.Store, o *op) { push(s, o, (*object.List).PushRight) },
+	},
+	"lpop": {
+		keyType: "list",
+		request: func(o *op) resp.Array { return resp.Array{resp.LPOP, resp.BulkString(o.key)} },
+		apply:   func(s store.Store, o *op) { pop(s, o, (*object.List).PopLeft) },
+	},
+	"rpop": {
+		keyType: "list",
+		request: func(o *op) resp.Array { return resp.Array{resp.RPOP, resp.BulkString(o.key)} },
+		apply:   func(s store.Store, o *op) { pop(s, o, (*object.List).PopRight) },
+	},
+	"hset": {
+		keyType: "hash",
+		request: func(o *op) resp.Array {
+			return resp.Array{resp.HSET, resp.BulkString(o.key), resp.BulkString(o.field), resp.BulkString(o.value)}
+		},
+		apply: hset,
+	},
+	"hget": {
+		keyType: "hash",
+		request: func(o *op) resp.Array { return resp.Array{resp.HGET, resp.BulkString(o.key), resp.BulkString(o.field)} },
+		apply:   hget,
+	},
+}
+
+// commandNames returns the names of the commands, sorted.
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// push applies LPUSH or RPUSH to a store, like the handler does.
+func push(s store.Store, o *op, pushFn func(l *object.List, values ...string)) {
+	s.Compute(o.key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		if !exists {
+			entry = store.Entry{Value: object.NewList()}
+		}
+		l, ok := entry.Value.(*object.List)
+		if !ok {
+			return entry, store.OpKeep
+		}
+		pushFn(l, o.value)
+		return entry, store.OpSet
+	})
+}
+
+// pop applies LPOP or RPOP to a store, removing the lists left empty.
+func pop(s store.Store, o *op, popFn func(l *object.List) (string, bool)) {
+	s.Compute(o.key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		l, ok := entry.Value.(*object.List)
+		if !exists || !ok {
+			return entry, store.OpKeep
+		}
+		popFn(l)
+		if l.Len() == 0 {
+			return entry, store.OpDelete
+		}
+		return entry, store.OpSet
+	})
+}
+
+// hget reads a field within Compute, as hashes are modified in place.
+func hget(s store.Store, o *op) {
+	s.Compute(o.key, func(entry store.Entry, _ bool) (store.Entry, store.Op) {
+		if hash, ok := entry.Value.(*object.Hash); ok {
+			hash.Get(o.field)
+		}
+		return entry, store.OpKeep
+	})
+}
+
+func hset(s store.Store, o *op) {
+	s.Compute(o.key, func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		if !exists {
+			entry = store.Entry{Value: object.NewHash()}
+		}
+		hash, ok := entry.Value.(*object.Hash)
+		if !ok {
+			return entry, store.OpKeep
+		}
+		hash.Set(o.field, o.value)
+		return entry, store.OpSet
+	})
+}
+
+// workload picks the requests of a client at random.
+type workload struct {
+	rng      *rand.Rand
+	keySpace int
+	sizes    SizeRange
+	// the commands of the mix, and the running sum of their weights
+	commands []command
+	weights  []int
+	// random text that values are cut from
+	values string
+}
+
+// newWorkload creates the workload of a client, values being cut from values, which must be twice as long as the largest value.
+func newWorkload(cl *CommandLine, seed uint64, values string) *workload {
+	w := &workload{
+		rng:      rand.New(rand.NewPCG(seed, seed)),
+		keySpace: cl.KeySpace,
+		sizes:    cl.ValueSize,
+		values:   values,
+	}
+	total := 0
+	for _, c := range cl.Mix {
+		total += c.Weight
+		w.commands = append(w.commands, commands[c.Command])
+		w.weights = append(w.weights, total)
+	}
+	return w
+}
+
+// randomValues returns random text, to cut the values of the workloads of cl from.
+func randomValues(cl *CommandLine) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	var b strings.Builder
+	for range 2 * cl.ValueSize.Max {
+		b.WriteByte(alphabet[rand.IntN(len(alphabet))])
+	}
+	return b.String()
+}
+
+// next picks the next request into o, and returns the index of its command in the mix.
+func (w *workload) next(o *op) int {
+	i, _ := slices.BinarySearch(w.weights, w.rng.IntN(w.weights[len(w.weights)-1])+1)
+	c := w.commands[i]
+
+	o.key = c.keyType + ":" + strconv.Itoa(w.rng.IntN(w.keySpace))
+	o.field = "field:" + strconv.Itoa(w.rng.IntN(w.keySpace))
+	size := w.sizes.Min + w.rng.IntN(w.sizes.Max-w.sizes.Min+1)
+	start := w.rng.IntN(len(w.values) - size + 1)
+	o.value = w.values[start : start+size]
+	return i
+}
@@ -0,0 +1,186 @@
+// Package bitmap implements the bit-level operations Redis performs on the raw bytes of strings.
+//
+// bits are numbered from the most significant bit of the first byte, so bit 0 is the highest bit of byte 0.
+// reads take strings so that stored values are not copied, writes take and return byte slices.
+// BITFIELD, which mixes both, works on byte slices.
+package bitmap
+
+import (
+	"math/bits"
+)
+
+// GetBit returns the bit at offset, bits past the end of b are 0.
+func GetBit(b string, offset uint64) int {
+	return bitAt(b, offset)
+}
+
+func bitAt[T ~string | ~[]byte](b T, offset uint64) int {
+	i := offset / 8
+	if i >= uint64(len(b)) {
+		return 0
+	}
+	return int(b[i]>>(7-offset%8)) & 1
+}
+
+// SetBit sets the bit at offset to bit and returns the previous value, b is grown with zero bytes if needed.
+func SetBit(b []byte, offset uint64, bit int) ([]byte, int) {
+	b = Grow(b, offset/8+1)
+	i, mask := offset/8, byte(1)<<(7-offset%8)
+	prev := 0
+	if b[i]&mask != 0 {
+		prev = 1
+	}
+	if bit == 1 {
+		b[i] |= mask
+	} else {
+		b[i] &^= mask
+	}
+	return b, prev
+}
+
+// Grow pads b with zero bytes up to n bytes.
+func Grow(b []byte, n uint64) []byte {
+	if uint64(len(b)) >= n {
+		return b
+	}
+	return append(b, make([]byte, n-uint64(len(b)))...)
+}
+
+// normalizeRange resolves the negative indexes of a range over length units the way BITCOUNT and BITPOS do.
+func normalizeRange(start, end, length int64) (first, last int64, ok bool) {
+	if start < 0 {
+		start += length
+	}
+	if end < 0 {
+		end += length
+	}
+	start, end = max(start, 0), max(end, 0)
+	end = min(end, length-1)
+	if start > end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// Count returns the number of set bits between start and end inclusive.
+// the range is in bytes, or in bits when bitUnit is true, and negative indexes count from the end.
+func Count(b string, start, end int64, bitUnit bool) int64 {
+	length := int64(len(b))
+	if bitUnit {
+		length *= 8
+	}
+	start, end, ok := normalizeRange(start, end, length)
+	if !ok {
+		return 0
+	}
+	if !bitUnit {
+		return countBytes(b[start : end+1])
+	}
+
+	first, last := start/8, end/8
+	if first == last {
+		return int64(bits.OnesCount8(b[first] & edgeMask(start, end)))
+	}
+	count := int64(bits.OnesCount8(b[first] & edgeMask(start, 7)))
+	count += countBytes(b[first+1 : last])
+	count += int64(bits.OnesCount8(b[last] & edgeMask(0, end)))
+	return count
+}
+
+func countBytes(b string) int64 {
+	var count int64
+	for i := range len(b) {
+		count += int64(bits.OnesCount8(b[i]))
+	}
+	return count
+}
+
+// edgeMask selects the bits from position start%8 to end%8 of a byte.
+func edgeMask(start, end int64) byte {
+	return byte(0xff>>(start%8)) & byte(0xff<<(7-end%8))
+}
+
+// Pos returns the position of the first bit set to bit between start and end inclusive, -1 when there is none.
+// the range is interpreted like Count's. looking for a clear bit without an explicit end,
+// the string is considered padded with zeros, so the first bit after the range is returned.
+func Pos(b string, bit int, start, end int64, endGiven, bitUnit bool) int64 {
+	length := int64(len(b))
+	if bitUnit {
+		length *= 8
+	}
+	start, end, ok := normalizeRange(start, end, length)
+	if !ok {
+		return -1
+	}
+
+	firstBit, lastBit := start, end
+	if !bitUnit {
+		firstBit, lastBit = start*8, end*8+7
+	}
+	for i := firstBit; i <= lastBit; i++ {
+		// skip whole bytes that cannot contain the bit
+		if i%8 == 0 && i+7 <= lastBit {
+			if c := b[i/8]; (bit == 1 && c == 0) || (bit == 0 && c == 0xff) {
+				i += 7
+				continue
+			}
+		}
+		if GetBit(b, uint64(i)) == bit {
+			return i
+		}
+	}
+
+	if bit == 0 && !endGiven {
+		return lastBit + 1
+	}
+	return -1
+}
+
+// And returns the bitwise AND of srcs, shorter strings being padded with zeros.
+func And(srcs []string) []byte {
+	return combine(srcs, func(a, b byte) byte { return a & b })
+}
+
+// Or returns the bitwise OR of srcs.
+func Or(srcs []string) []byte {
+	return combine(srcs, func(a, b byte) byte { return a | b })
+}
+
+// Xor returns the bitwise XOR of srcs.
+func Xor(srcs []string) []byte {
+	return combine(srcs, func(a, b byte) byte { return a ^ b })
+}
+
+// Not returns the bitwise negation of src.
+func Not(src string) []byte {
+	result := make([]byte, len(src))
+	for i := range len(src) {
+		result[i] = ^src[i]
+	}
+	return result
+}
+
+func combine(srcs []string, op func(a, b byte) byte) []byte {
+	length := 0
+	for _, src := range srcs {
+		length = max(length, len(src))
+	}
+
+	result := make([]byte, length)
+	for i := range length {
+		var acc byte
+		for j, src := range srcs {
+			var c byte
+			if i < len(src) {
+				c = src[i]
+			}
+			if j == 0 {
+				acc = c
+			} else {
+				acc = op(acc, c)
+			}
+		}
+		result[i] = acc
+	}
+	return result
+}
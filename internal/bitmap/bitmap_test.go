@@ -0,0 +1,105 @@
+package bitmap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBit(t *testing.T) {
+	b, prev := SetBit(nil, 7, 1)
+	require.Equal(t, []byte{0x01}, b)
+	require.Zero(t, prev)
+
+	b, prev = SetBit(b, 7, 0)
+	require.Equal(t, []byte{0x00}, b)
+	require.Equal(t, 1, prev)
+
+	b, _ = SetBit(b, 17, 1)
+	require.Equal(t, []byte{0x00, 0x00, 0x40}, b, "Setting a bit past the end should pad with zero bytes")
+	require.Equal(t, 1, GetBit(string(b), 17))
+	require.Zero(t, GetBit(string(b), 1000))
+}
+
+func TestCount(t *testing.T) {
+	// "foobar" is the example of the Redis documentation
+	require.Equal(t, int64(26), Count("foobar", 0, -1, false))
+	require.Equal(t, int64(4), Count("foobar", 0, 0, false))
+	require.Equal(t, int64(6), Count("foobar", 1, 1, false))
+	require.Equal(t, int64(17), Count("foobar", 5, 30, true))
+	require.Equal(t, int64(3), Count("foobar", 1, 5, true))
+	require.Zero(t, Count("foobar", 3, 2, false))
+	require.Zero(t, Count("", 0, -1, false))
+}
+
+func TestPos(t *testing.T) {
+	require.Equal(t, int64(12), Pos("\xff\xf0\x00", 0, 0, -1, false, false))
+	require.Equal(t, int64(8), Pos("\x00\xff\xf0", 1, 0, -1, false, false))
+	require.Equal(t, int64(16), Pos("\x00\xff\xf0", 1, 2, -1, false, false))
+	require.Equal(t, int64(10), Pos("\x00\xff\xf0", 1, 10, 20, true, true), "BIT ranges search between bit offsets")
+	require.Equal(t, int64(-1), Pos("\x00\xff\xf0", 1, 0, 7, true, true))
+	require.Equal(t, int64(24), Pos("\xff\xff\xff", 0, 0, -1, false, false), "Clear bits are found after the end of the string")
+	require.Equal(t, int64(-1), Pos("\xff\xff\xff", 0, 0, -1, true, false))
+	require.Equal(t, int64(-1), Pos("\x00\x00", 1, 0, -1, false, false))
+}
+
+func TestOperations(t *testing.T) {
+	srcs := []string{"\xf0\x0f", "\xff"}
+	require.Equal(t, []byte{0xf0, 0x00}, And(srcs))
+	require.Equal(t, []byte{0xff, 0x0f}, Or(srcs))
+	require.Equal(t, []byte{0x0f, 0x0f}, Xor(srcs))
+	require.Equal(t, []byte{0x0f, 0xf0}, Not(srcs[0]))
+	require.Empty(t, Or(nil))
+}
+
+func TestField(t *testing.T) {
+	t.Run("Get and Set", func(t *testing.T) {
+		f := Field{Signed: true, Bits: 8, Offset: 4}
+		b := f.Set(nil, -2)
+		require.Equal(t, []byte{0x0f, 0xe0}, b)
+		require.Equal(t, int64(-2), f.Get(b))
+
+		u := Field{Bits: 4, Offset: 4}
+		require.Equal(t, int64(15), u.Get(b))
+		require.Equal(t, uint64(2), f.End())
+	})
+
+	t.Run("Signed overflow", func(t *testing.T) {
+		f := Field{Signed: true, Bits: 8}
+		v, ok := f.Add(120, 10, Wrap)
+		require.True(t, ok)
+		require.Equal(t, int64(-126), v)
+
+		v, _ = f.Add(120, 10, Sat)
+		require.Equal(t, int64(127), v)
+		v, _ = f.Add(-120, -10, Sat)
+		require.Equal(t, int64(-128), v)
+
+		_, ok = f.Add(120, 10, Fail)
+		require.False(t, ok)
+
+		v, _ = f.Add(300, 0, Sat)
+		require.Equal(t, int64(127), v, "Setting a value that does not fit should overflow too")
+
+		i64 := Field{Signed: true, Bits: 64}
+		v, _ = i64.Add(math.MaxInt64, 1, Wrap)
+		require.Equal(t, int64(math.MinInt64), v)
+		v, _ = i64.Add(math.MaxInt64, 1, Sat)
+		require.Equal(t, int64(math.MaxInt64), v)
+	})
+
+	t.Run("Unsigned overflow", func(t *testing.T) {
+		f := Field{Bits: 4}
+		v, _ := f.Add(14, 3, Wrap)
+		require.Equal(t, int64(1), v)
+		v, _ = f.Add(1, -3, Wrap)
+		require.Equal(t, int64(14), v)
+		v, _ = f.Add(1, -3, Sat)
+		require.Zero(t, v)
+		v, _ = f.Add(-1, 0, Sat)
+		require.Equal(t, int64(15), v)
+		_, ok := f.Add(1, math.MinInt64, Fail)
+		require.False(t, ok)
+	})
+}
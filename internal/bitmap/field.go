@@ -0,0 +1,118 @@
+package bitmap
+
+import (
+	"math"
+)
+
+// Overflow is the behavior of BITFIELD when a value does not fit its field.
+type Overflow int
+
+const (
+	// Wrap keeps the low bits of the value, like integer arithmetic in most languages.
+	Wrap Overflow = iota
+	// Sat saturates to the minimum or maximum value of the field.
+	Sat
+	// Fail leaves the field unchanged.
+	Fail
+)
+
+// Field is an integer of up to 64 bits, or 63 when unsigned, stored at a bit offset of a string.
+type Field struct {
+	Signed bool
+	Bits   uint
+	Offset uint64
+}
+
+// End returns the number of bytes a string needs to hold the field.
+func (f Field) End() uint64 {
+	return (f.Offset + uint64(f.Bits) + 7) / 8
+}
+
+// Get returns the value of the field, bits past the end of b are 0.
+func (f Field) Get(b []byte) int64 {
+	var v uint64
+	for i := range uint64(f.Bits) {
+		v = v<<1 | uint64(bitAt(b, f.Offset+i))
+	}
+	if f.Signed {
+		return signExtend(v, f.Bits)
+	}
+	return int64(v)
+}
+
+// Set stores the low bits of v in the field, b is grown with zero bytes if needed.
+func (f Field) Set(b []byte, v int64) []byte {
+	b = Grow(b, f.End())
+	u := uint64(v)
+	for i := range uint64(f.Bits) {
+		bit := int(u>>(uint64(f.Bits)-1-i)) & 1
+		b, _ = SetBit(b, f.Offset+i, bit)
+	}
+	return b
+}
+
+// Add returns value+incr as stored in the field, applying overflow when the result does not fit.
+// ok is false when the result overflows with Fail.
+// value is interpreted as unsigned for unsigned fields, so that SET with a negative value overflows.
+func (f Field) Add(value, incr int64, overflow Overflow) (int64, bool) {
+	if f.Signed {
+		return f.addSigned(value, incr, overflow)
+	}
+	return f.addUnsigned(uint64(value), incr, overflow)
+}
+
+func (f Field) addSigned(value, incr int64, overflow Overflow) (int64, bool) {
+	maxValue := int64(math.MaxInt64 >> (64 - f.Bits))
+	minValue := -maxValue - 1
+
+	up := incr >= 0 && value > maxValue-incr
+	down := incr <= 0 && value < minValue-incr
+	if !up && !down {
+		return value + incr, true
+	}
+
+	switch overflow {
+	case Sat:
+		if up {
+			return maxValue, true
+		}
+		return minValue, true
+	case Fail:
+		return 0, false
+	default:
+		return signExtend(uint64(value)+uint64(incr), f.Bits), true
+	}
+}
+
+func (f Field) addUnsigned(value uint64, incr int64, overflow Overflow) (int64, bool) {
+	maxValue := uint64(math.MaxUint64) >> (64 - f.Bits)
+
+	var up, down bool
+	if incr >= 0 {
+		up = value > maxValue || uint64(incr) > maxValue-value
+	} else {
+		// -incr is computed on unsigned integers, so that it holds for math.MinInt64
+		down = -uint64(incr) > value
+	}
+	if !up && !down {
+		return int64(value + uint64(incr)), true
+	}
+
+	switch overflow {
+	case Sat:
+		if up {
+			return int64(maxValue), true
+		}
+		return 0, true
+	case Fail:
+		return 0, false
+	default:
+		return int64((value + uint64(incr)) & maxValue), true
+	}
+}
+
+// signExtend interprets the low n bits of v as a two's complement integer.
+func signExtend(v uint64, n uint) int64 {
+	shift := 64 - n
+	return int64(v<<shift) >> shift
+}
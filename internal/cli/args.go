@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errInvalidArgs = errors.New("invalid argument(s)")
+
+// SplitArgs splits a typed line into arguments the way redis-cli does.
+// arguments are separated by spaces and may be quoted, double quotes supporting escapes such as \n and \x41,
+// and single quotes only \'. a closing quote must be followed by a space or end the line.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		arg := []byte{}
+		for i < len(line) && !isSpace(line[i]) {
+			var err error
+			switch line[i] {
+			case '"':
+				arg, i, err = readQuoted(line, i+1, arg)
+			case '\'':
+				arg, i, err = readSingleQuoted(line, i+1, arg)
+			default:
+				arg = append(arg, line[i])
+				i++
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, string(arg))
+	}
+}
+
+// readQuoted appends the double quoted text starting at start to arg, and returns the index following the closing quote.
+func readQuoted(line string, start int, arg []byte) ([]byte, int, error) {
+	for i := start; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			return arg, i + 1, checkClosed(line, i+1)
+		case c != '\\' || i+1 == len(line):
+			arg = append(arg, c)
+		case line[i+1] == 'x' && i+3 < len(line) && isHex(line[i+2]) && isHex(line[i+3]):
+			b, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+			arg = append(arg, byte(b))
+			i += 3
+		default:
+			i++
+			arg = append(arg, unescape(line[i]))
+		}
+	}
+	return nil, 0, errInvalidArgs
+}
+
+// readSingleQuoted appends the single quoted text starting at start to arg, and returns the index following the closing quote.
+func readSingleQuoted(line string, start int, arg []byte) ([]byte, int, error) {
+	for i := start; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\'':
+			return arg, i + 1, checkClosed(line, i+1)
+		case c == '\\' && i+1 < len(line) && line[i+1] == '\'':
+			arg = append(arg, '\'')
+			i++
+		default:
+			arg = append(arg, c)
+		}
+	}
+	return nil, 0, errInvalidArgs
+}
+
+func checkClosed(line string, next int) error {
+	if next < len(line) && !isSpace(line[next]) {
+		return errInvalidArgs
+	}
+	return nil
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return c
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\v' || c == '\f' || c == 0
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
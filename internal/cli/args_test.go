@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"GET key", []string{"GET", "key"}},
+		{"  SET   key\tvalue  ", []string{"SET", "key", "value"}},
+		{`SET key "hello world"`, []string{"SET", "key", "hello world"}},
+		{`SET key "a\nb\t\"c\"\\"`, []string{"SET", "key", "a\nb\t\"c\"\\"}},
+		{`SET key "\x41\x7a\xff"`, []string{"SET", "key", "Az\xff"}},
+		{`SET key "\xZZ"`, []string{"SET", "key", "xZZ"}},
+		{`SET key 'it\'s "raw" \n'`, []string{"SET", "key", `it's "raw" \n`}},
+		{`SET key ""`, []string{"SET", "key", ""}},
+		{`SET pre"fix"`, []string{"SET", "prefix"}},
+	}
+	for _, test := range tests {
+		args, err := SplitArgs(test.line)
+		require.NoError(t, err, test.line)
+		require.Equal(t, test.args, args, test.line)
+	}
+
+	for _, line := range []string{`SET key "unbalanced`, `SET key 'unbalanced`, `SET key "a"b`, `SET key 'a'b`} {
+		_, err := SplitArgs(line)
+		require.ErrorIs(t, err, errInvalidArgs, line)
+	}
+}
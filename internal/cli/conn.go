@@ -0,0 +1,76 @@
+// Package cli implements gvalkey-cli, a command-line client for gvalkey and other Redis-compatible servers.
+package cli
+
+import (
+	"net"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// writeBufferSize is the size of the commands buffered by Write above which they are sent, without waiting for Flush.
+const writeBufferSize = 32 << 10
+
+// Conn is a connection to a server, on which commands are sent and their replies read in order.
+type Conn struct {
+	nc     net.Conn
+	enc    resp.Encoder
+	parser *resp.Parser
+}
+
+// Dial connects to the server at addr.
+func Dial(addr string, timeout time.Duration) (*Conn, error) {
+	nc, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{
+		nc:     nc,
+		parser: resp.NewReplyParser(nc),
+	}, nil
+}
+
+// Write buffers a command, which is sent by the next Flush, or once enough commands are buffered.
+func (c *Conn) Write(command resp.Array) error {
+	if err := c.enc.Encode(command); err != nil {
+		return err
+	}
+	if c.enc.Buffered() >= writeBufferSize {
+		return c.Flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered commands.
+func (c *Conn) Flush() error {
+	_, err := c.enc.WriteTo(c.nc)
+	return err
+}
+
+// Receive reads the next reply, error replies are returned as resp.SimpleError values rather than errors.
+func (c *Conn) Receive() (any, error) {
+	return c.parser.Parse()
+}
+
+// Do sends a command and reads its reply.
+func (c *Conn) Do(args ...string) (any, error) {
+	if err := c.Write(newCommand(args)); err != nil {
+		return nil, err
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	return c.Receive()
+}
+
+func (c *Conn) Close() error {
+	return c.nc.Close()
+}
+
+func newCommand(args []string) resp.Array {
+	cmd := make(resp.Array, len(args))
+	for i, arg := range args {
+		cmd[i] = resp.BulkString(arg)
+	}
+	return cmd
+}
@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl-C is pressed.
+var ErrInterrupted = errors.New("interrupted")
+
+// maxHistory is the number of lines kept in the history.
+const maxHistory = 100
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// Editor reads the lines typed on a terminal in raw mode, with line editing, a history and hints
+// shown after the cursor, using the key bindings of readline such as Ctrl-A and the arrow keys.
+type Editor struct {
+	in  *bufio.Reader
+	out io.Writer
+	// the number of columns of the terminal
+	width func() int
+	// returns the text hinted after the line, may be nil
+	hint    func(line string) string
+	history []string
+}
+
+func NewEditor(in io.Reader, out io.Writer, width func() int, hint func(line string) string) *Editor {
+	return &Editor{
+		in:    bufio.NewReader(in),
+		out:   out,
+		width: width,
+		hint:  hint,
+	}
+}
+
+// lineState is the line being edited.
+type lineState struct {
+	prompt string
+	buf    []rune
+	pos    int
+	// the history with the edited line last, browsing it keeps the changes made to its lines until the line is entered
+	history []string
+	index   int
+}
+
+// ReadLine reads a line, it returns io.EOF when Ctrl-D is pressed on an empty line and ErrInterrupted on Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	l := &lineState{prompt: prompt, history: append(slices.Clip(e.history), ""), index: len(e.history)}
+	if err := e.refresh(l, true); err != nil {
+		return "", err
+	}
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, '\n':
+			// the hint is cleared from the entered line
+			if err := e.refresh(l, false); err != nil {
+				return "", err
+			}
+			_, err := io.WriteString(e.out, "\r\n")
+			return string(l.buf), err
+		case keyCtrlC:
+			return "", e.end("^C\r\n", ErrInterrupted)
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				return "", e.end("\r\n", io.EOF)
+			}
+			l.deleteChar()
+		case keyCtrlL:
+			if _, err := io.WriteString(e.out, "\x1b[H\x1b[2J"); err != nil {
+				return "", err
+			}
+		case keyEscape:
+			if err := e.escape(l); err != nil {
+				return "", err
+			}
+		default:
+			e.edit(l, r)
+		}
+
+		if err := e.refresh(l, true); err != nil {
+			return "", err
+		}
+	}
+}
+
+// end writes the end of a line that is not entered, and returns reason unless the write fails.
+func (e *Editor) end(s string, reason error) error {
+	if _, err := io.WriteString(e.out, s); err != nil {
+		return err
+	}
+	return reason
+}
+
+// edit applies a control key, or inserts a printable character.
+func (e *Editor) edit(l *lineState, r rune) {
+	switch r {
+	case keyBackspace, keyCtrlH:
+		l.backspace()
+	case keyCtrlA:
+		l.pos = 0
+	case keyCtrlE:
+		l.pos = len(l.buf)
+	case keyCtrlB:
+		l.pos = max(l.pos-1, 0)
+	case keyCtrlF:
+		l.pos = min(l.pos+1, len(l.buf))
+	case keyCtrlP:
+		l.browse(-1)
+	case keyCtrlN:
+		l.browse(1)
+	case keyCtrlK:
+		l.buf = l.buf[:l.pos]
+	case keyCtrlU:
+		l.buf, l.pos = l.buf[:0], 0
+	case keyCtrlW:
+		l.deleteWord()
+	default:
+		if unicode.IsPrint(r) {
+			l.insert(r)
+		}
+	}
+}
+
+// escape reads the rest of an escape sequence, such as the arrow keys.
+func (e *Editor) escape(l *lineState) error {
+	kind, err := e.in.ReadByte()
+	if err != nil {
+		return err
+	}
+	if kind != '[' && kind != 'O' {
+		return nil
+	}
+	key, err := e.in.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if key >= '0' && key <= '9' {
+		// extended keys end with a tilde, e.g. ESC [ 3 ~ for delete
+		if tilde, err := e.in.ReadByte(); err != nil || tilde != '~' {
+			return err
+		}
+		switch key {
+		case '3':
+			l.deleteChar()
+		case '1', '7':
+			l.pos = 0
+		case '4', '8':
+			l.pos = len(l.buf)
+		}
+		return nil
+	}
+
+	switch key {
+	case 'A':
+		l.browse(-1)
+	case 'B':
+		l.browse(1)
+	case 'C':
+		l.pos = min(l.pos+1, len(l.buf))
+	case 'D':
+		l.pos = max(l.pos-1, 0)
+	case 'H':
+		l.pos = 0
+	case 'F':
+		l.pos = len(l.buf)
+	}
+	return nil
+}
+
+// refresh redraws the line, scrolled horizontally so that the cursor fits in the terminal.
+func (e *Editor) refresh(l *lineState, withHint bool) error {
+	cols := e.width()
+	plen := len([]rune(l.prompt))
+	buf, pos := l.buf, l.pos
+	for plen+pos >= cols && pos > 0 {
+		buf, pos = buf[1:], pos-1
+	}
+	if len(buf) > cols-plen {
+		buf = buf[:max(cols-plen, pos)]
+	}
+
+	var b strings.Builder
+	b.WriteString("\r" + l.prompt + string(buf))
+	if withHint && e.hint != nil {
+		hint := []rune(e.hint(string(l.buf)))
+		if room := cols - plen - len(buf); room > 0 && len(hint) > 0 {
+			b.WriteString("\x1b[90m" + string(hint[:min(len(hint), room)]) + "\x1b[0m")
+		}
+	}
+	// erase the rest of the previous line, and move the cursor back
+	b.WriteString("\x1b[0K\r")
+	if plen+pos > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", plen+pos)
+	}
+	_, err := io.WriteString(e.out, b.String())
+	return err
+}
+
+// AddHistory adds a line to the history, unless it repeats the last one.
+func (e *Editor) AddHistory(line string) {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// LoadHistory reads the history saved in path, a missing file being an empty history.
+func (e *Editor) LoadHistory(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for line := range strings.Lines(string(data)) {
+		e.AddHistory(strings.TrimRight(line, "\r\n"))
+	}
+	return nil
+}
+
+// SaveHistory writes the history to path, one line per entry.
+func (e *Editor) SaveHistory(path string) error {
+	var b strings.Builder
+	for _, line := range e.history {
+		b.WriteString(line + "\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
+
+func (l *lineState) insert(r rune) {
+	l.buf = append(l.buf[:l.pos], append([]rune{r}, l.buf[l.pos:]...)...)
+	l.pos++
+}
+
+func (l *lineState) backspace() {
+	if l.pos > 0 {
+		l.buf = append(l.buf[:l.pos-1], l.buf[l.pos:]...)
+		l.pos--
+	}
+}
+
+func (l *lineState) deleteChar() {
+	if l.pos < len(l.buf) {
+		l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+	}
+}
+
+// deleteWord deletes the word before the cursor, along with the spaces following it.
+func (l *lineState) deleteWord() {
+	start := l.pos
+	for start > 0 && l.buf[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && l.buf[start-1] != ' ' {
+		start--
+	}
+	l.buf = append(l.buf[:start], l.buf[l.pos:]...)
+	l.pos = start
+}
+
+// browse replaces the line with the previous or the next line of the history.
+func (l *lineState) browse(delta int) {
+	index := l.index + delta
+	if index < 0 || index >= len(l.history) {
+		return
+	}
+	l.history[l.index] = string(l.buf)
+	l.index = index
+	l.buf = []rune(l.history[index])
+	l.pos = len(l.buf)
+}
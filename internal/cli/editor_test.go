@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	arrowUp    = "\x1b[A"
+	arrowDown  = "\x1b[B"
+	arrowRight = "\x1b[C"
+	arrowLeft  = "\x1b[D"
+	deleteKey  = "\x1b[3~"
+)
+
+func newTestEditor(input string, hint func(string) string) (*Editor, *bytes.Buffer) {
+	var out bytes.Buffer
+	return NewEditor(strings.NewReader(input), &out, func() int { return 80 }, hint), &out
+}
+
+func TestEditorEditing(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  string
+	}{
+		{"typing", "GET key\r", "GET key"},
+		{"backspace", "GET keyy\x7f\r", "GET key"},
+		{"insert", "GT key" + arrowLeft + arrowLeft + arrowLeft + arrowLeft + arrowLeft + "E\r", "GET key"},
+		{"home and end", "ET ke\x01G\x05y\r", "GET key"},
+		{"delete", "GET kkey\x01" + arrowRight + arrowRight + arrowRight + arrowRight + deleteKey + "\r", "GET key"},
+		{"ctrl-d deletes", "GETT\x02\x04\r", "GET"},
+		{"kill to the end", "GET key extra\x02\x02\x02\x02\x02\x02\x0b\r", "GET key"},
+		{"kill the line", "SET key\x15GET key\r", "GET key"},
+		{"delete word", "GET other  \x17key\r", "GET key"},
+		{"unicode", "SET key héllo\x7fe\r", "SET key hélle"},
+		{"control characters are ignored", "GET\x00\x07 key\r", "GET key"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, _ := newTestEditor(test.input, nil)
+			line, err := e.ReadLine("> ")
+			require.NoError(t, err)
+			require.Equal(t, test.line, line)
+		})
+	}
+}
+
+func TestEditorEnd(t *testing.T) {
+	e, _ := newTestEditor("\x04", nil)
+	_, err := e.ReadLine("> ")
+	require.ErrorIs(t, err, io.EOF)
+
+	e, out := newTestEditor("GET\x03", nil)
+	_, err = e.ReadLine("> ")
+	require.ErrorIs(t, err, ErrInterrupted)
+	require.True(t, strings.HasSuffix(out.String(), "^C\r\n"))
+
+	e, _ = newTestEditor("GET", nil)
+	_, err = e.ReadLine("> ")
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestEditorHistory(t *testing.T) {
+	e, _ := newTestEditor(
+		arrowUp+"\r"+
+			// the edited line is kept while browsing
+			"GET"+arrowUp+arrowUp+arrowUp+arrowDown+arrowDown+"\r"+
+			// changes to the lines of the history only last until the line is entered
+			arrowUp+"\x7f\x7f\x7f1"+arrowDown+arrowUp+"\r"+
+			arrowUp+"\r",
+		nil,
+	)
+	e.AddHistory("SET a 1")
+	e.AddHistory("SET b 2")
+	e.AddHistory("SET b 2")
+	e.AddHistory("")
+
+	for _, expected := range []string{"SET b 2", "GET", "SET 1", "SET b 2"} {
+		line, err := e.ReadLine("> ")
+		require.NoError(t, err)
+		require.Equal(t, expected, line)
+	}
+	require.Equal(t, []string{"SET a 1", "SET b 2"}, e.history)
+
+	for i := range 2 * maxHistory {
+		e.AddHistory(strings.Repeat("x", i+1))
+	}
+	require.Len(t, e.history, maxHistory)
+	require.Equal(t, strings.Repeat("x", 2*maxHistory), e.history[maxHistory-1])
+
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, e.LoadHistory(path), "a missing history is empty")
+	require.NoError(t, e.SaveHistory(path))
+	loaded, _ := newTestEditor("", nil)
+	require.NoError(t, loaded.LoadHistory(path))
+	require.Equal(t, e.history, loaded.history)
+
+	require.NoError(t, os.WriteFile(path, []byte("GET a\r\nGET b"), 0o600))
+	loaded, _ = newTestEditor("", nil)
+	require.NoError(t, loaded.LoadHistory(path))
+	require.Equal(t, []string{"GET a", "GET b"}, loaded.history)
+}
+
+func TestEditorRefresh(t *testing.T) {
+	hint := func(line string) string {
+		if line == "GET" {
+			return " key"
+		}
+		return ""
+	}
+	e, out := newTestEditor("GET\r", hint)
+	_, err := e.ReadLine("> ")
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "\r> GET\x1b[90m key\x1b[0m\x1b[0K\r\x1b[5C")
+	// the hint is cleared from the entered line
+	require.True(t, strings.HasSuffix(out.String(), "\r> GET\x1b[0K\r\x1b[5C\r\n"))
+
+	// long lines scroll to keep the cursor visible
+	e, out = newTestEditor(strings.Repeat("a", 100)+"\r", nil)
+	e.width = func() int { return 20 }
+	_, err = e.ReadLine("> ")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(out.String(), "\r> "+strings.Repeat("a", 17)+"\x1b[0K\r\x1b[19C\r\n"))
+}
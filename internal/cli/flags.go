@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// CommandLine holds the parsed arguments of the client.
+type CommandLine struct {
+	Host string
+	Port int
+	// database selected once connected
+	DB int
+	// switch to RESP3 with HELLO 3 once connected
+	RESP3 bool
+	// force the raw output, or the human readable one, which is otherwise picked depending on whether the output is a terminal
+	Raw   bool
+	NoRaw bool
+
+	// send the commands read from the standard input in the RESP format, for mass insertion
+	Pipe bool
+	// list the keys matching Pattern with SCAN, Count being its hint of the number of keys per call
+	Scan    bool
+	Pattern string
+	Count   int
+	// find the biggest key of every type
+	BigKeys bool
+	// measure the latency of the server until interrupted
+	Latency bool
+
+	// command to run instead of starting the REPL
+	Args []string
+}
+
+// Addr returns the address of the server.
+func (cl *CommandLine) Addr() string {
+	return net.JoinHostPort(cl.Host, strconv.Itoa(cl.Port))
+}
+
+// ParseCommandLine parses "[options] [command [arg ...]]" arguments, options following redis-cli, e.g. -p 6380 or --scan.
+// usage and errors are written to output, and flag.ErrHelp is returned when --help is requested.
+func ParseCommandLine(program string, args []string, output io.Writer) (*CommandLine, error) {
+	cl := &CommandLine{}
+
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&cl.Host, "h", "127.0.0.1", "Server `hostname`")
+	fs.IntVar(&cl.Port, "p", 6379, "Server `port`")
+	fs.IntVar(&cl.DB, "n", 0, "Database `number`")
+	fs.BoolVar(&cl.RESP3, "3", false, "Start the session in RESP3 protocol mode")
+	fs.BoolVar(&cl.Raw, "raw", false, "Use raw formatting for replies (default when the output is not a terminal)")
+	fs.BoolVar(&cl.NoRaw, "no-raw", false, "Force formatted output even when the output is not a terminal")
+	fs.BoolVar(&cl.Pipe, "pipe", false, "Transfer raw RESP protocol from stdin to the server")
+	fs.BoolVar(&cl.Scan, "scan", false, "List all keys using the SCAN command")
+	fs.StringVar(&cl.Pattern, "pattern", "", "Keys `pattern` when using --scan")
+	fs.IntVar(&cl.Count, "count", 0, "Count `hint` when using --scan")
+	fs.BoolVar(&cl.BigKeys, "bigkeys", false, "Sample keys looking for keys with many elements")
+	fs.BoolVar(&cl.Latency, "latency", false, "Enter a special mode continuously sampling latency")
+
+	fs.Usage = func() {
+		printUsage(fs, program)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cl.Args = fs.Args()
+
+	if err := cl.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		return nil, err
+	}
+	return cl, nil
+}
+
+func (cl *CommandLine) validate() error {
+	modes := 0
+	for _, enabled := range []bool{cl.Pipe, cl.Scan, cl.BigKeys, cl.Latency} {
+		if enabled {
+			modes++
+		}
+	}
+	switch {
+	case modes > 1:
+		return errors.New("--pipe, --scan, --bigkeys and --latency cannot be combined")
+	case modes == 1 && len(cl.Args) > 0:
+		return fmt.Errorf("unexpected command %q, --pipe, --scan, --bigkeys and --latency do not take one", cl.Args[0])
+	case cl.Raw && cl.NoRaw:
+		return errors.New("--raw and --no-raw cannot be combined")
+	default:
+		return nil
+	}
+}
+
+func printUsage(fs *flag.FlagSet, program string) {
+	out := fs.Output()
+
+	fmt.Fprintf(out, "Usage: %s [options] [command [arg ...]]\n\n", program)
+	fmt.Fprintf(out, "Without a command, commands are read interactively.\n\n")
+
+	fmt.Fprintln(out, "Options:")
+	for _, name := range []string{"h", "p", "n", "3", "raw", "no-raw", "pipe", "scan", "pattern", "count", "bigkeys", "latency"} {
+		f := fs.Lookup(name)
+		placeholder, usage := flag.UnquoteUsage(f)
+
+		dashes := "--"
+		if len(name) == 1 {
+			dashes = "-"
+		}
+		if placeholder != "" {
+			placeholder = " <" + placeholder + ">"
+		}
+		fmt.Fprintf(out, "  %s%s%s\n        %s", dashes, name, placeholder, usage)
+		if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" {
+			fmt.Fprintf(out, " (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "  --help\n        Print this help and exit\n")
+}
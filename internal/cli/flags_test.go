@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cl, err := ParseCommandLine("gvalkey-cli", nil, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:6379", cl.Addr())
+		require.Zero(t, cl.DB)
+		require.Empty(t, cl.Args)
+	})
+
+	t.Run("Options and command", func(t *testing.T) {
+		cl, err := ParseCommandLine("gvalkey-cli", []string{"-h", "::1", "-p", "7000", "-n", "2", "-3", "--raw", "SET", "key", "-1"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Equal(t, "[::1]:7000", cl.Addr())
+		require.Equal(t, 2, cl.DB)
+		require.True(t, cl.RESP3)
+		require.True(t, cl.Raw)
+		require.Equal(t, []string{"SET", "key", "-1"}, cl.Args)
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		cl, err := ParseCommandLine("gvalkey-cli", []string{"--scan", "--pattern", "user:*", "--count", "100"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.True(t, cl.Scan)
+		require.Equal(t, "user:*", cl.Pattern)
+		require.Equal(t, 100, cl.Count)
+	})
+
+	t.Run("Help lists every option", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := ParseCommandLine("gvalkey-cli", []string{"--help"}, &out)
+		require.ErrorIs(t, err, flag.ErrHelp)
+		for _, option := range []string{"-h <hostname>", "-p <port>", "(default 6379)", "-n <number>", "-3", "--raw", "--no-raw", "--pipe", "--scan", "--pattern <pattern>", "--count <hint>", "--bigkeys", "--latency", "--help"} {
+			require.Contains(t, out.String(), option)
+		}
+	})
+
+	t.Run("Invalid combinations", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"--pipe", "--latency"},
+			{"--scan", "GET", "key"},
+			{"--raw", "--no-raw"},
+		} {
+			var out bytes.Buffer
+			_, err := ParseCommandLine("gvalkey-cli", args, &out)
+			require.Error(t, err, args)
+			require.Contains(t, out.String(), err.Error())
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// FormatReply renders a reply the way redis-cli does on a terminal, e.g. (integer) 1 or 1) "a" for arrays.
+func FormatReply(reply any) string {
+	return formatReply(reply, "")
+}
+
+// formatReply renders a reply whose lines, but the first one, start with prefix.
+func formatReply(reply any, prefix string) string {
+	switch r := reply.(type) {
+	case resp.SimpleError:
+		return "(error) " + r.Error() + "\n"
+	case resp.SimpleString:
+		return string(r) + "\n"
+	case resp.BulkString:
+		return Quote(string(r)) + "\n"
+	case resp.VerbatimString:
+		return r.Text + "\n"
+	case resp.Integer:
+		return "(integer) " + r.String() + "\n"
+	case resp.Double:
+		return "(double) " + r.String() + "\n"
+	case resp.BigNumber:
+		return "(big number) " + string(r) + "\n"
+	case resp.Boolean:
+		return "(" + r.String() + ")\n"
+	case resp.Null, resp.NullArray:
+		return "(nil)\n"
+	case resp.Array:
+		return formatAggregate(r, false, ')', "(empty array)", prefix)
+	case resp.Set:
+		return formatAggregate(r, false, '~', "(empty set)", prefix)
+	case resp.Push:
+		return formatAggregate(r, false, ')', "(empty push)", prefix)
+	case resp.Map:
+		elements := make([]any, 0, 2*len(r))
+		for _, entry := range r {
+			elements = append(elements, entry.Key, entry.Value)
+		}
+		return formatAggregate(elements, true, '#', "(empty hash)", prefix)
+	default:
+		return fmt.Sprintf("%v\n", r)
+	}
+}
+
+// formatAggregate numbers the elements, or the key and value pairs of a map, aligning nested aggregates under their index.
+func formatAggregate(elements []any, pairs bool, sep byte, empty, prefix string) string {
+	if len(elements) == 0 {
+		return empty + "\n"
+	}
+
+	step := 1
+	if pairs {
+		step = 2
+	}
+	n := len(elements) / step
+	width := len(strconv.Itoa(n))
+	nested := prefix + strings.Repeat(" ", width+2)
+
+	var b strings.Builder
+	for i := range n {
+		// the first index follows the index of the parent, or starts the reply
+		if i > 0 {
+			b.WriteString(prefix)
+		}
+		fmt.Fprintf(&b, "%*d%c ", width, i+1, sep)
+		element := formatReply(elements[i*step], nested)
+		if pairs {
+			element = strings.TrimSuffix(element, "\n") + " => " + formatReply(elements[i*step+1], nested)
+		}
+		b.WriteString(element)
+	}
+	return b.String()
+}
+
+// FormatRawReply renders a reply the way redis-cli does when its output is not a terminal: strings as they are,
+// and the elements of aggregates on their own lines.
+func FormatRawReply(reply any) string {
+	return formatRaw(reply) + "\n"
+}
+
+func formatRaw(reply any) string {
+	switch r := reply.(type) {
+	case resp.SimpleError:
+		return r.Error()
+	case resp.Null, resp.NullArray:
+		return ""
+	case resp.Boolean:
+		return "(" + r.String() + ")"
+	case resp.Array:
+		return joinRaw(r)
+	case resp.Set:
+		return joinRaw(r)
+	case resp.Push:
+		return joinRaw(r)
+	case resp.Map:
+		elements := make([]any, 0, 2*len(r))
+		for _, entry := range r {
+			elements = append(elements, entry.Key, entry.Value)
+		}
+		return joinRaw(elements)
+	case fmt.Stringer:
+		return r.String()
+	default:
+		return fmt.Sprint(r)
+	}
+}
+
+func joinRaw(elements []any) string {
+	lines := make([]string, len(elements))
+	for i, element := range elements {
+		lines[i] = formatRaw(element)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Quote quotes s like redis-cli, escaping quotes, backslashes and the bytes that are not printable ASCII characters.
+func Quote(s string) string {
+	b := make([]byte, 0, len(s)+2)
+	b = append(b, '"')
+	for i := range len(s) {
+		switch c := s[i]; c {
+		case '\\', '"':
+			b = append(b, '\\', c)
+		case '\n':
+			b = append(b, `\n`...)
+		case '\r':
+			b = append(b, `\r`...)
+		case '\t':
+			b = append(b, `\t`...)
+		case '\a':
+			b = append(b, `\a`...)
+		case '\b':
+			b = append(b, `\b`...)
+		default:
+			if c < ' ' || c > '~' {
+				b = fmt.Appendf(b, `\x%02x`, c)
+			} else {
+				b = append(b, c)
+			}
+		}
+	}
+	return string(append(b, '"'))
+}
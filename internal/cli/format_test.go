@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"math"
+	"testing"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply any
+		tty   string
+		raw   string
+	}{
+		{"simple string", resp.OK, "OK\n", "OK\n"},
+		{"bulk string", resp.BulkString("a \"b\"\n\x00é"), "\"a \\\"b\\\"\\n\\x00\\xc3\\xa9\"\n", "a \"b\"\n\x00é\n"},
+		{"error", resp.NewPrefixedError("WRONGTYPE", "wrong kind"), "(error) WRONGTYPE wrong kind\n", "WRONGTYPE wrong kind\n"},
+		{"integer", resp.Integer(-3), "(integer) -3\n", "-3\n"},
+		{"null", resp.Null{}, "(nil)\n", "\n"},
+		{"null array", resp.NullArray{}, "(nil)\n", "\n"},
+		{"double", resp.Double(math.Inf(1)), "(double) inf\n", "inf\n"},
+		{"boolean", resp.Boolean(true), "(true)\n", "(true)\n"},
+		{"big number", resp.BigNumber("1234567890123456789012"), "(big number) 1234567890123456789012\n", "1234567890123456789012\n"},
+		{"verbatim string", resp.VerbatimString{Format: "txt", Text: "line 1\nline 2"}, "line 1\nline 2\n", "line 1\nline 2\n"},
+		{"empty array", resp.Array{}, "(empty array)\n", "\n"},
+		{"empty map", resp.Map{}, "(empty hash)\n", "\n"},
+		{"empty set", resp.Set{}, "(empty set)\n", "\n"},
+		{
+			"array",
+			resp.Array{resp.BulkString("a"), resp.Integer(1), resp.Null{}},
+			"1) \"a\"\n2) (integer) 1\n3) (nil)\n",
+			"a\n1\n\n",
+		},
+		{
+			"nested arrays",
+			resp.Array{resp.Array{resp.BulkString("1-1"), resp.Array{resp.BulkString("f"), resp.BulkString("v")}}, resp.Array{}},
+			"1) 1) \"1-1\"\n   2) 1) \"f\"\n      2) \"v\"\n2) (empty array)\n",
+			"1-1\nf\nv\n\n",
+		},
+		{
+			"index alignment",
+			resp.Array{
+				resp.Integer(1), resp.Integer(2), resp.Integer(3), resp.Integer(4), resp.Integer(5),
+				resp.Integer(6), resp.Integer(7), resp.Integer(8), resp.Integer(9), resp.Array{resp.Integer(10), resp.Integer(11)},
+			},
+			" 1) (integer) 1\n 2) (integer) 2\n 3) (integer) 3\n 4) (integer) 4\n 5) (integer) 5\n" +
+				" 6) (integer) 6\n 7) (integer) 7\n 8) (integer) 8\n 9) (integer) 9\n10) 1) (integer) 10\n    2) (integer) 11\n",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+		},
+		{
+			"map",
+			resp.Map{
+				{Key: resp.BulkString("name"), Value: resp.BulkString("gvalkey")},
+				{Key: resp.BulkString("modules"), Value: resp.Array{resp.BulkString("lua")}},
+			},
+			"1# \"name\" => \"gvalkey\"\n2# \"modules\" => 1) \"lua\"\n",
+			"name\ngvalkey\nmodules\nlua\n",
+		},
+		{"set", resp.Set{resp.BulkString("a"), resp.BulkString("b")}, "1~ \"a\"\n2~ \"b\"\n", "a\nb\n"},
+		{
+			"push",
+			resp.Push{resp.BulkString("message"), resp.BulkString("news"), resp.BulkString("hello")},
+			"1) \"message\"\n2) \"news\"\n3) \"hello\"\n",
+			"message\nnews\nhello\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.tty, FormatReply(test.reply))
+			require.Equal(t, test.raw, FormatRawReply(test.reply))
+		})
+	}
+}
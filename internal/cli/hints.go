@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// Hints holds the arguments of the commands documented by COMMAND DOCS, keyed by their upper case name,
+// such as GET or CONFIG GET for subcommands, to hint at them while a command is typed.
+type Hints map[string][]argDoc
+
+// argDoc is the documentation of an argument, which may group other arguments.
+type argDoc struct {
+	name  string
+	typ   string
+	token string
+	// the arguments of a oneof or a block
+	args []argDoc
+
+	optional bool
+	multiple bool
+	// the token is repeated along with each value
+	multipleToken bool
+}
+
+// NewHints reads the reply of COMMAND DOCS, an array of command names each followed by its documentation,
+// or a map in RESP3. replies of another shape, such as from servers without COMMAND DOCS, give no hints.
+func NewHints(reply any) Hints {
+	h := Hints{}
+	h.add(reply)
+	return h
+}
+
+func (h Hints) add(docs any) {
+	for _, command := range entries(docs) {
+		name := strings.ToUpper(strings.ReplaceAll(stringOf(command.Key), "|", " "))
+		doc := fields(command.Value)
+		h[name] = argDocs(doc["arguments"])
+		if subcommands, ok := doc["subcommands"]; ok {
+			h.add(subcommands)
+		}
+	}
+}
+
+func argDocs(reply any) []argDoc {
+	elements := elementsOf(reply)
+	docs := make([]argDoc, 0, len(elements))
+	for _, element := range elements {
+		doc := fields(element)
+		arg := argDoc{
+			name:  stringOf(doc["name"]),
+			typ:   stringOf(doc["type"]),
+			token: stringOf(doc["token"]),
+			args:  argDocs(doc["arguments"]),
+		}
+		if text := stringOf(doc["display_text"]); text != "" {
+			arg.name = text
+		}
+		for _, flag := range elementsOf(doc["flags"]) {
+			switch stringOf(flag) {
+			case "optional":
+				arg.optional = true
+			case "multiple":
+				arg.multiple = true
+			case "multiple_token":
+				arg.multipleToken = true
+			}
+		}
+		docs = append(docs, arg)
+	}
+	return docs
+}
+
+// Hint returns the arguments left to type after line, or an empty string for unknown commands.
+// the required arguments are dropped as they are typed, the hint is otherwise kept as the optional ones can come in any order.
+func (h Hints) Hint(line string) string {
+	args, err := SplitArgs(line)
+	if err != nil || len(args) == 0 {
+		return ""
+	}
+
+	docs, typed := h.lookup(args)
+	if len(docs) == 0 {
+		return ""
+	}
+
+	docs = slices.Clone(docs)
+	for len(docs) > 0 && !docs[0].optional && typed >= docs[0].words() {
+		typed -= docs[0].words()
+		if docs[0].multiple {
+			// more values are optional once one is given
+			docs[0].optional = true
+			break
+		}
+		docs = docs[1:]
+	}
+
+	parts := make([]string, len(docs))
+	for i, doc := range docs {
+		parts[i] = doc.String()
+	}
+	hint := strings.Join(parts, " ")
+	if hint != "" && !strings.HasSuffix(line, " ") {
+		hint = " " + hint
+	}
+	return hint
+}
+
+// lookup returns the arguments of the command, or of its subcommand, and the number of arguments typed for them.
+func (h Hints) lookup(args []string) ([]argDoc, int) {
+	if len(args) > 1 {
+		if docs, ok := h[strings.ToUpper(args[0]+" "+args[1])]; ok {
+			return docs, len(args) - 2
+		}
+	}
+	return h[strings.ToUpper(args[0])], len(args) - 1
+}
+
+// words is the number of words typed for a single occurrence of the argument.
+func (a argDoc) words() int {
+	if a.token != "" && a.typ != "pure-token" {
+		return 2
+	}
+	return 1
+}
+
+// String renders the argument like the syntax of the documentation, e.g. [EX seconds] or key [key ...].
+func (a argDoc) String() string {
+	var value string
+	switch a.typ {
+	case "pure-token":
+		value = a.token
+	case "oneof", "block":
+		parts := make([]string, len(a.args))
+		for i, arg := range a.args {
+			parts[i] = arg.String()
+		}
+		sep := " "
+		if a.typ == "oneof" {
+			sep = "|"
+		}
+		value = strings.Join(parts, sep)
+	default:
+		value = a.name
+	}
+
+	s := value
+	if a.token != "" && a.typ != "pure-token" {
+		s = a.token + " " + value
+	}
+	if a.multiple {
+		repeated := value
+		if a.multipleToken {
+			repeated = s
+		}
+		s += " [" + repeated + " ...]"
+	}
+	if a.optional {
+		s = "[" + s + "]"
+	}
+	return s
+}
+
+// entries returns the key and value pairs of a map, or of an array alternating keys and values.
+func entries(reply any) resp.Map {
+	switch r := reply.(type) {
+	case resp.Map:
+		return r
+	case resp.Array:
+		m := make(resp.Map, 0, len(r)/2)
+		for i := 0; i+1 < len(r); i += 2 {
+			m = append(m, resp.MapEntry{Key: r[i], Value: r[i+1]})
+		}
+		return m
+	default:
+		return nil
+	}
+}
+
+// elementsOf returns the elements of an array, or of a set as RESP3 sends flags.
+func elementsOf(reply any) []any {
+	switch r := reply.(type) {
+	case resp.Array:
+		return r
+	case resp.Set:
+		return r
+	default:
+		return nil
+	}
+}
+
+// fields indexes the entries of a map, or of an array alternating keys and values, by their key.
+func fields(reply any) map[string]any {
+	m := make(map[string]any)
+	for _, entry := range entries(reply) {
+		m[stringOf(entry.Key)] = entry.Value
+	}
+	return m
+}
+
+// stringOf returns the text of a string reply, or an empty string for other replies.
+func stringOf(reply any) string {
+	switch r := reply.(type) {
+	case resp.BulkString:
+		return string(r)
+	case resp.SimpleString:
+		return string(r)
+	case resp.VerbatimString:
+		return r.Text
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+// doc builds the documentation of a command or an argument, as the array of names and values sent in RESP2.
+func doc(pairs ...any) resp.Array {
+	for i, v := range pairs {
+		if s, ok := v.(string); ok {
+			pairs[i] = resp.BulkString(s)
+		}
+	}
+	return resp.Array(pairs)
+}
+
+func flags(names ...string) resp.Array {
+	a := make(resp.Array, len(names))
+	for i, name := range names {
+		a[i] = resp.SimpleString(name)
+	}
+	return a
+}
+
+// commandDocs is the reply of COMMAND DOCS for SET, DEL and CONFIG, which has the GET subcommand.
+var commandDocs = doc(
+	"set", doc("summary", "Sets the string value of a key.", "arguments", resp.Array{
+		doc("name", "key", "type", "key", "display_text", "key"),
+		doc("name", "value", "type", "string", "display_text", "value"),
+		doc("name", "condition", "type", "oneof", "flags", flags("optional"), "arguments", resp.Array{
+			doc("name", "nx", "type", "pure-token", "token", "NX"),
+			doc("name", "xx", "type", "pure-token", "token", "XX"),
+		}),
+		doc("name", "expiration", "type", "oneof", "flags", flags("optional"), "arguments", resp.Array{
+			doc("name", "seconds", "type", "integer", "token", "EX"),
+			doc("name", "keepttl", "type", "pure-token", "token", "KEEPTTL"),
+		}),
+	}),
+	"del", doc("arguments", resp.Array{
+		doc("name", "key", "type", "key", "flags", flags("multiple")),
+	}),
+	"config", doc("subcommands", doc(
+		"config|get", doc("arguments", resp.Array{
+			doc("name", "parameter", "type", "string", "flags", flags("multiple")),
+		}),
+	)),
+	"sort", doc("arguments", resp.Array{
+		doc("name", "key", "type", "key"),
+		doc("name", "pattern", "type", "pattern", "token", "GET", "flags", flags("optional", "multiple", "multiple_token")),
+	}),
+)
+
+func TestHints(t *testing.T) {
+	h := NewHints(commandDocs)
+
+	tests := []struct {
+		line string
+		hint string
+	}{
+		{"set", " key value [NX|XX] [EX seconds|KEEPTTL]"},
+		{"SET ", "key value [NX|XX] [EX seconds|KEEPTTL]"},
+		{"set k", " value [NX|XX] [EX seconds|KEEPTTL]"},
+		{"set k v ", "[NX|XX] [EX seconds|KEEPTTL]"},
+		{"set k v nx ", "[NX|XX] [EX seconds|KEEPTTL]"},
+		{"del ", "key [key ...]"},
+		{"del a ", "[key [key ...]]"},
+		{"config get ", "parameter [parameter ...]"},
+		{"sort ", "key [GET pattern [GET pattern ...]]"},
+		{"unknown ", ""},
+		{"", ""},
+		{`set "unbalanced`, ""},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.hint, h.Hint(test.line), test.line)
+	}
+
+	// RESP3 sends maps, and flags as sets
+	h = NewHints(resp.Map{{
+		Key: resp.BulkString("get"),
+		Value: resp.Map{{Key: resp.BulkString("arguments"), Value: resp.Array{resp.Map{
+			{Key: resp.BulkString("name"), Value: resp.BulkString("key")},
+			{Key: resp.BulkString("type"), Value: resp.BulkString("key")},
+			{Key: resp.BulkString("flags"), Value: resp.Set{resp.SimpleString("optional")}},
+		}}}},
+	}})
+	require.Equal(t, " [key]", h.Hint("get"))
+
+	// servers without COMMAND DOCS
+	require.Empty(t, NewHints(resp.OK).Hint("get "))
+}
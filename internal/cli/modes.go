@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// latencyInterval is the pause between two PINGs of the latency mode.
+const latencyInterval = 10 * time.Millisecond
+
+// Pipe sends the commands read from in, in the RESP format, while the replies are read, and returns once every command got its reply.
+// the replies are counted rather than printed, except for error replies, which make Pipe fail.
+func (s *Session) Pipe(in io.Reader) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	conn := s.conn
+	defer s.Close()
+
+	var replies, failures atomic.Int64
+	// signaled after replies grew, without blocking the reads
+	progress := make(chan struct{}, 1)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			reply, err := conn.Receive()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			if replyErr, ok := reply.(resp.SimpleError); ok {
+				failures.Add(1)
+				fmt.Fprintln(s.errOut, replyErr.Error())
+			}
+			replies.Add(1)
+			select {
+			case progress <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	sent, err := sendAll(conn, in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(s.out, "All data transferred. Waiting for the last reply...")
+	for replies.Load() < sent {
+		select {
+		case <-progress:
+		case err := <-readErr:
+			return err
+		}
+	}
+
+	fmt.Fprintln(s.out, "Last reply received from server.")
+	fmt.Fprintf(s.out, "errors: %d, replies: %d\n", failures.Load(), replies.Load())
+	if n := failures.Load(); n > 0 {
+		return fmt.Errorf("%d commands failed", n)
+	}
+	return nil
+}
+
+// sendAll writes the commands read from in, and returns how many were sent.
+func sendAll(conn *Conn, in io.Reader) (int64, error) {
+	parser := resp.NewParser(in)
+	var sent int64
+	for {
+		command, err := parser.Parse()
+		if errors.Is(err, io.EOF) {
+			return sent, conn.Flush()
+		}
+		if err != nil {
+			return sent, fmt.Errorf("invalid input after %d commands: %w", sent, err)
+		}
+		array, ok := command.(resp.Array)
+		if !ok {
+			return sent, fmt.Errorf("invalid input after %d commands: %q is not an array of arguments", sent, command)
+		}
+		if err := conn.Write(array); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+}
+
+// Scan prints the keys matching pattern, every key when it is empty, iterating over the keyspace with SCAN.
+func (s *Session) Scan(pattern string, count int) error {
+	return s.scan(pattern, count, func(key string) error {
+		_, err := fmt.Fprintln(s.out, key)
+		return err
+	})
+}
+
+// scan calls fn on the keys returned by SCAN until the iteration ends.
+func (s *Session) scan(pattern string, count int, fn func(key string) error) error {
+	cursor := "0"
+	for {
+		args := []string{"SCAN", cursor}
+		if pattern != "" {
+			args = append(args, "MATCH", pattern)
+		}
+		if count > 0 {
+			args = append(args, "COUNT", strconv.Itoa(count))
+		}
+		reply, err := s.call(args...)
+		if err != nil {
+			return err
+		}
+
+		page, ok := reply.(resp.Array)
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("unexpected SCAN reply %q", FormatRawReply(reply))
+		}
+		for _, key := range elementsOf(page[1]) {
+			if err := fn(stringOf(key)); err != nil {
+				return err
+			}
+		}
+
+		cursor = stringOf(page[0])
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// bigKeyTypes are the types of keys reported by BigKeys, along with the command returning their size and its unit.
+var bigKeyTypes = []struct {
+	name    string
+	command string
+	unit    string
+}{
+	{"string", "STRLEN", "bytes"},
+	{"list", "LLEN", "items"},
+	{"set", "SCARD", "members"},
+	{"zset", "ZCARD", "members"},
+	{"hash", "HLEN", "fields"},
+	{"stream", "XLEN", "entries"},
+}
+
+// typeStats are the sizes found for a type of keys.
+type typeStats struct {
+	keys        int64
+	size        int64
+	biggest     string
+	biggestSize int64
+}
+
+// BigKeys scans the keyspace for the biggest key of each type, and prints a summary of the sizes of the keys per type.
+func (s *Session) BigKeys() error {
+	reply, err := s.call("DBSIZE")
+	if err != nil {
+		return err
+	}
+	total, _ := reply.(resp.Integer)
+
+	fmt.Fprint(s.out, "\n# Scanning the entire keyspace to find biggest keys as well as\n# average sizes per key type.\n\n")
+
+	stats := make([]typeStats, len(bigKeyTypes))
+	var sampled, keyLength int64
+	err = s.scan("", 0, func(key string) error {
+		sampled++
+		keyLength += int64(len(key))
+
+		reply, err := s.call("TYPE", key)
+		if err != nil {
+			return err
+		}
+		i := bigKeyType(stringOf(reply))
+		if i < 0 {
+			// gone since it was scanned, or of a type without size
+			return nil
+		}
+		reply, err = s.call(bigKeyTypes[i].command, key)
+		if err != nil {
+			return err
+		}
+		size, _ := reply.(resp.Integer)
+
+		st := &stats[i]
+		st.keys++
+		st.size += int64(size)
+		if int64(size) > st.biggestSize {
+			st.biggest, st.biggestSize = key, int64(size)
+			fmt.Fprintf(s.out, "[%05.2f%%] Biggest %-6s found so far '%s' with %d %s\n",
+				percent(sampled, int64(total)), bigKeyTypes[i].name, Quote(key), size, bigKeyTypes[i].unit)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(s.out, "\n-------- summary -------\n\n")
+	fmt.Fprintf(s.out, "Sampled %d keys in the keyspace!\n", sampled)
+	fmt.Fprintf(s.out, "Total key length in bytes is %d (avg len %.2f)\n\n", keyLength, average(keyLength, sampled))
+	for i, st := range stats {
+		if st.biggest != "" {
+			fmt.Fprintf(s.out, "Biggest %6s found '%s' has %d %s\n", bigKeyTypes[i].name, Quote(st.biggest), st.biggestSize, bigKeyTypes[i].unit)
+		}
+	}
+	fmt.Fprintln(s.out)
+	for i, st := range stats {
+		fmt.Fprintf(s.out, "%d %ss with %d %s (%05.2f%% of keys, avg size %.2f)\n",
+			st.keys, bigKeyTypes[i].name, st.size, bigKeyTypes[i].unit, percent(st.keys, sampled), average(st.size, st.keys))
+	}
+	return nil
+}
+
+// bigKeyType returns the index of a type in bigKeyTypes, or -1.
+func bigKeyType(name string) int {
+	for i, t := range bigKeyTypes {
+		if t.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func percent(n, total int64) float64 {
+	return 100 * average(n, total)
+}
+
+func average(sum, n int64) float64 {
+	if n == 0 {
+		return 0
+	}
+	return float64(sum) / float64(n)
+}
+
+// latencyStats are the round trip times measured by Latency.
+type latencyStats struct {
+	lowest  time.Duration
+	highest time.Duration
+	total   time.Duration
+	samples int64
+}
+
+func (l *latencyStats) add(d time.Duration) {
+	if l.samples == 0 || d < l.lowest {
+		l.lowest = d
+	}
+	l.highest = max(l.highest, d)
+	l.total += d
+	l.samples++
+}
+
+func (l *latencyStats) average() time.Duration {
+	return l.total / time.Duration(max(l.samples, 1))
+}
+
+// Latency measures the round trip time of PING in milliseconds until ctx is done, refreshing the figures on a line of the terminal.
+// with the raw output, it samples for a second and prints "min max avg samples".
+// it fails on error replies, such as from servers without PING.
+func (s *Session) Latency(ctx context.Context) error {
+	if s.cl.Raw {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Second)
+		defer cancel()
+	}
+	ticker := time.NewTicker(latencyInterval)
+	defer ticker.Stop()
+
+	var stats latencyStats
+	for {
+		start := time.Now()
+		if _, err := s.call("PING"); err != nil {
+			return err
+		}
+		stats.add(time.Since(start))
+
+		if !s.cl.Raw {
+			fmt.Fprintf(s.out, "\x1b[0G\x1b[2Kmin: %.2f, max: %.2f, avg: %.2f (%d samples)",
+				milliseconds(stats.lowest), milliseconds(stats.highest), milliseconds(stats.average()), stats.samples)
+		}
+
+		select {
+		case <-ctx.Done():
+			if s.cl.Raw {
+				fmt.Fprintf(s.out, "%.2f %.2f %.2f %d\n",
+					milliseconds(stats.lowest), milliseconds(stats.highest), milliseconds(stats.average()), stats.samples)
+			} else {
+				fmt.Fprintln(s.out)
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+const dialTimeout = 5 * time.Second
+
+// Session runs commands on a server and prints their replies like redis-cli.
+// it connects on the first command, and again on the next command once the connection is lost.
+type Session struct {
+	cl   *CommandLine
+	conn *Conn
+	// database selected with SELECT, selected again when reconnecting
+	db     int
+	hints  Hints
+	out    io.Writer
+	errOut io.Writer
+}
+
+func NewSession(cl *CommandLine, out, errOut io.Writer) *Session {
+	return &Session{
+		cl:     cl,
+		db:     cl.DB,
+		out:    out,
+		errOut: errOut,
+	}
+}
+
+// connect connects to the server unless connected, switching to RESP3 and selecting the database as requested.
+func (s *Session) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+	conn, err := Dial(s.cl.Addr(), dialTimeout)
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %w", s.cl.Addr(), err)
+	}
+
+	var setup [][]string
+	if s.cl.RESP3 {
+		setup = append(setup, []string{"HELLO", "3"})
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	for _, args := range setup {
+		reply, err := conn.Do(args...)
+		if err == nil {
+			if replyErr, ok := reply.(resp.SimpleError); ok {
+				// not wrapped, as error replies are those of the commands that were run
+				err = errors.New(replyErr.Error())
+			}
+		}
+		if err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("%s failed: %w", args[0], err)
+		}
+	}
+
+	s.conn = conn
+	return nil
+}
+
+// Close closes the connection, if any.
+func (s *Session) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// do runs a command, dropping the connection when it fails.
+func (s *Session) do(args ...string) (any, error) {
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	reply, err := s.conn.Do(args...)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return reply, nil
+}
+
+// call is do, which also fails with error replies.
+func (s *Session) call(args ...string) (any, error) {
+	reply, err := s.do(args...)
+	if replyErr, ok := reply.(resp.SimpleError); ok {
+		return nil, replyErr
+	}
+	return reply, err
+}
+
+// Run runs a command and prints its reply, error replies being printed and then returned as resp.SimpleError.
+// after SUBSCRIBE, PSUBSCRIBE, SSUBSCRIBE and MONITOR, it prints what the server sends until the connection is lost.
+func (s *Session) Run(args []string) error {
+	name := strings.ToUpper(args[0])
+	follow := name == "SUBSCRIBE" || name == "PSUBSCRIBE" || name == "SSUBSCRIBE" || name == "MONITOR"
+	if follow && !s.cl.Raw {
+		fmt.Fprintln(s.out, "Reading messages... (press Ctrl-C to quit)")
+	}
+
+	reply, err := s.do(args...)
+	if err != nil {
+		return err
+	}
+	if err := s.print(reply); err != nil {
+		return err
+	}
+	if replyErr, ok := reply.(resp.SimpleError); ok {
+		return replyErr
+	}
+
+	switch {
+	case name == "SELECT" && len(args) == 2:
+		s.db, _ = strconv.Atoi(args[1])
+	case follow:
+		return s.follow()
+	}
+	return nil
+}
+
+// follow prints the replies sent by the server until the connection fails.
+func (s *Session) follow() error {
+	for {
+		reply, err := s.conn.Receive()
+		if err != nil {
+			_ = s.Close()
+			return err
+		}
+		if err := s.print(reply); err != nil {
+			return err
+		}
+	}
+}
+
+// print writes a reply in the output format, raw error replies going to the error output.
+func (s *Session) print(reply any) error {
+	if !s.cl.Raw {
+		_, err := io.WriteString(s.out, FormatReply(reply))
+		return err
+	}
+	w := s.out
+	if _, ok := reply.(resp.SimpleError); ok {
+		w = s.errOut
+	}
+	_, err := io.WriteString(w, FormatRawReply(reply))
+	return err
+}
+
+// Hint returns the hint of the commands documented by the server for a line being typed.
+func (s *Session) Hint(line string) string {
+	return s.hints.Hint(line)
+}
+
+// loadHints loads the hints once connected, servers without COMMAND DOCS giving none.
+func (s *Session) loadHints() {
+	if s.hints != nil || s.conn == nil {
+		return
+	}
+	if reply, err := s.do("COMMAND", "DOCS"); err == nil {
+		s.hints = NewHints(reply)
+	}
+}
+
+// Repl runs the commands read from lr until the input ends, Ctrl-C is pressed, or quit or exit is typed.
+func (s *Session) Repl(lr LineReader) error {
+	if err := s.connect(); err != nil {
+		fmt.Fprintln(s.errOut, err)
+	}
+	s.loadHints()
+
+	for {
+		line, err := lr.ReadLine(s.prompt())
+		if errors.Is(err, io.EOF) || errors.Is(err, ErrInterrupted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		args, err := SplitArgs(line)
+		if err != nil {
+			fmt.Fprintln(s.errOut, "Invalid argument(s)")
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		lr.AddHistory(line)
+
+		switch strings.ToLower(args[0]) {
+		case "quit", "exit":
+			return nil
+		case "clear":
+			fmt.Fprint(s.out, "\x1b[H\x1b[2J")
+			continue
+		}
+
+		var replyErr resp.SimpleError
+		if err := s.Run(args); err != nil && !errors.As(err, &replyErr) {
+			fmt.Fprintln(s.errOut, "Error:", err)
+		}
+		s.loadHints()
+	}
+}
+
+// prompt is the address of the server followed by the selected database, unless it is the first one.
+func (s *Session) prompt() string {
+	switch {
+	case s.conn == nil:
+		return "not connected> "
+	case s.db != 0:
+		return fmt.Sprintf("%s[%d]> ", s.cl.Addr(), s.db)
+	default:
+		return s.cl.Addr() + "> "
+	}
+}
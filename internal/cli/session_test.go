@@ -0,0 +1,303 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PlayerNeo42/gvalkey/gvalkeytest"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer runs a server, and returns a session connecting to it along with its outputs.
+func newServer(t *testing.T) (*Session, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	s := gvalkeytest.Run(t)
+	return newSession(t, &CommandLine{Host: s.Host(), Port: s.Port()})
+}
+
+// newScriptedServer runs a fake server replying to the commands of each connection with replies, raw RESP sent in order,
+// and returns a session connecting to it along with its output and the commands the server received.
+func newScriptedServer(t *testing.T, replies ...string) (*Session, *bytes.Buffer, <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		_ = listener.Close()
+	})
+
+	received := make(chan []string, len(replies))
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go replyScripted(conn, replies, received, done)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	s, out, _ := newSession(t, &CommandLine{Host: addr.IP.String(), Port: addr.Port})
+	return s, out, received
+}
+
+// replyScripted replies to the commands of conn with replies, and closes it once they are sent.
+func replyScripted(conn net.Conn, replies []string, received chan<- []string, done <-chan struct{}) {
+	defer conn.Close()
+	parser := resp.NewParser(conn)
+	for _, reply := range replies {
+		command, err := parser.Parse()
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range command.(resp.Array) {
+			args = append(args, string(arg.(resp.BulkString)))
+		}
+		select {
+		case received <- args:
+		case <-done:
+			return
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func newSession(t *testing.T, cl *CommandLine) (*Session, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	s := NewSession(cl, &out, &errOut)
+	t.Cleanup(func() { _ = s.Close() })
+	return s, &out, &errOut
+}
+
+func TestRun(t *testing.T) {
+	s, out, errOut := newServer(t)
+
+	require.NoError(t, s.Run([]string{"RPUSH", "list", "a", "b"}))
+	require.NoError(t, s.Run([]string{"LRANGE", "list", "0", "-1"}))
+	require.NoError(t, s.Run([]string{"GET", "missing"}))
+	require.Equal(t, "(integer) 2\n1) \"a\"\n2) \"b\"\n(nil)\n", out.String())
+
+	out.Reset()
+	err := s.Run([]string{"GET", "list"})
+	require.ErrorAs(t, err, new(resp.SimpleError))
+	require.Equal(t, "(error) WRONGTYPE Operation against a key holding the wrong kind of value\n", out.String())
+
+	// raw error replies go to the error output
+	out.Reset()
+	s.cl.Raw = true
+	require.Error(t, s.Run([]string{"GET", "list"}))
+	require.NoError(t, s.Run([]string{"LRANGE", "list", "0", "-1"}))
+	require.Equal(t, "a\nb\n", out.String())
+	require.Equal(t, "WRONGTYPE Operation against a key holding the wrong kind of value\n", errOut.String())
+
+	// the selected database survives reconnecting
+	require.Equal(t, s.cl.Addr()+"> ", s.prompt())
+	require.NoError(t, s.Run([]string{"SELECT", "2"}))
+	require.Equal(t, s.cl.Addr()+"[2]> ", s.prompt())
+	require.NoError(t, s.Run([]string{"SET", "key", "value"}))
+	require.NoError(t, s.Close())
+	require.Equal(t, "not connected> ", s.prompt())
+	out.Reset()
+	require.NoError(t, s.Run([]string{"GET", "key"}))
+	require.Equal(t, "value\n", out.String())
+
+	s, _, _ = newSession(t, &CommandLine{Host: "127.0.0.1", Port: 1})
+	require.ErrorContains(t, s.Run([]string{"GET", "key"}), "could not connect to 127.0.0.1:1")
+}
+
+func TestRunSetup(t *testing.T) {
+	s, out, received := newScriptedServer(t, "%1\r\n+proto\r\n:3\r\n", "+OK\r\n", "%1\r\n$1\r\na\r\n$1\r\n1\r\n")
+	s.cl.RESP3 = true
+	s.db = 3
+	require.NoError(t, s.Run([]string{"HGETALL", "hash"}))
+	require.Equal(t, []string{"HELLO", "3"}, <-received)
+	require.Equal(t, []string{"SELECT", "3"}, <-received)
+	require.Equal(t, "1# \"a\" => \"1\"\n", out.String())
+
+	s, _, _ = newScriptedServer(t, "-ERR unknown command 'HELLO'\r\n")
+	s.cl.RESP3 = true
+	err := s.Run([]string{"GET", "key"})
+	require.EqualError(t, err, "HELLO failed: ERR unknown command 'HELLO'")
+	require.NotErrorAs(t, err, new(resp.SimpleError), "the error is not the reply of the command")
+}
+
+func TestRunFollow(t *testing.T) {
+	s, out, _ := newScriptedServer(t,
+		"*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n"+
+			"*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n",
+	)
+	// the messages are printed until the connection is lost
+	require.Error(t, s.Run([]string{"SUBSCRIBE", "news"}))
+	require.Equal(t,
+		"Reading messages... (press Ctrl-C to quit)\n"+
+			"1) \"subscribe\"\n2) \"news\"\n3) (integer) 1\n"+
+			"1) \"message\"\n2) \"news\"\n3) \"hello\"\n",
+		out.String(),
+	)
+}
+
+func TestRepl(t *testing.T) {
+	s, out, errOut := newServer(t)
+	input := strings.Join([]string{
+		`SET key "hello world"`,
+		"",
+		"select 1",
+		"GET key",
+		`GET "unbalanced`,
+		"SELECT 0",
+		"get key",
+		"quit",
+		"GET never",
+	}, "\n")
+	lr := &plainReader{in: bufio.NewReader(strings.NewReader(input)), out: out, prompt: true}
+	require.NoError(t, s.Repl(lr))
+
+	addr := s.cl.Addr()
+	require.Equal(t,
+		addr+"> OK\n"+
+			addr+"> "+
+			addr+"> OK\n"+
+			addr+"[1]> (nil)\n"+
+			addr+"[1]> "+
+			addr+"[1]> OK\n"+
+			addr+"> \"hello world\"\n"+
+			addr+"> ",
+		out.String(),
+	)
+	require.Equal(t, "Invalid argument(s)\n", errOut.String())
+
+	// the input ends without quit, and without a server
+	s, out, errOut = newSession(t, &CommandLine{Host: "127.0.0.1", Port: 1})
+	require.NoError(t, s.Repl(&plainReader{in: bufio.NewReader(strings.NewReader("GET key")), out: out, prompt: true}))
+	require.Equal(t, "not connected> not connected> ", out.String())
+	require.Contains(t, errOut.String(), "could not connect to 127.0.0.1:1")
+	require.Contains(t, errOut.String(), "Error: could not connect to 127.0.0.1:1")
+}
+
+func TestReplHints(t *testing.T) {
+	reply, err := resp.Encode(commandDocs)
+	require.NoError(t, err)
+	s, _, received := newScriptedServer(t, string(reply))
+	require.NoError(t, s.Repl(&plainReader{in: bufio.NewReader(strings.NewReader("")), out: &bytes.Buffer{}}))
+	require.Equal(t, []string{"COMMAND", "DOCS"}, <-received)
+	require.Equal(t, " key [key ...]", s.Hint("del"))
+
+	s, _, _ = newServer(t)
+	require.NoError(t, s.Repl(&plainReader{in: bufio.NewReader(strings.NewReader("")), out: &bytes.Buffer{}}))
+	require.Equal(t, " source destination LEFT|RIGHT LEFT|RIGHT", s.Hint("lmove"))
+	require.Equal(t, " parameter [parameter ...]", s.Hint("config get"))
+}
+
+func TestPipe(t *testing.T) {
+	s, out, errOut := newServer(t)
+	input := "*3\r\n$3\r\nSET\r\n$1\r\na\r\n$1\r\n1\r\n" +
+		"*2\r\n$4\r\nINCR\r\n$1\r\na\r\n" +
+		"*3\r\n$5\r\nRPUSH\r\n$1\r\nl\r\n$1\r\nx\r\n"
+	require.EqualError(t, s.Pipe(strings.NewReader(input)), "1 commands failed")
+	require.Equal(t, "All data transferred. Waiting for the last reply...\nLast reply received from server.\nerrors: 1, replies: 3\n", out.String())
+	require.Equal(t, "ERR unsupported command\n", errOut.String())
+
+	out.Reset()
+	require.NoError(t, s.Pipe(strings.NewReader(strings.Repeat("*2\r\n$3\r\nGET\r\n$1\r\na\r\n", 10000))))
+	require.Contains(t, out.String(), "errors: 0, replies: 10000\n")
+
+	require.ErrorContains(t, s.Pipe(strings.NewReader("*1\r\n$3\r\nGET\r\nGET a\r\n")), "invalid input after 1 commands")
+}
+
+func TestScan(t *testing.T) {
+	s, out, received := newScriptedServer(t,
+		"*2\r\n$2\r\n17\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n",
+		"*2\r\n$1\r\n0\r\n*1\r\n$1\r\nc\r\n",
+	)
+	require.NoError(t, s.Scan("*", 10))
+	require.Equal(t, "a\nb\nc\n", out.String())
+	require.Equal(t, []string{"SCAN", "0", "MATCH", "*", "COUNT", "10"}, <-received)
+	require.Equal(t, []string{"SCAN", "17", "MATCH", "*", "COUNT", "10"}, <-received)
+
+	s, out, _ = newServer(t)
+	for _, key := range []string{"a", "b", "c"} {
+		_, err := s.do("SET", key, "1")
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Scan("", 0))
+	keys := strings.Fields(out.String())
+	slices.Sort(keys)
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+}
+
+func TestBigKeys(t *testing.T) {
+	s, out, _ := newScriptedServer(t,
+		":4\r\n",
+		"*2\r\n$1\r\n0\r\n*4\r\n$5\r\nshort\r\n$4\r\nlong\r\n$4\r\nlist\r\n$4\r\ngone\r\n",
+		"+string\r\n", ":2\r\n",
+		"+string\r\n", ":10\r\n",
+		"+list\r\n", ":3\r\n",
+		"+none\r\n",
+	)
+	require.NoError(t, s.BigKeys())
+	require.Equal(t, `
+# Scanning the entire keyspace to find biggest keys as well as
+# average sizes per key type.
+
+[25.00%] Biggest string found so far '"short"' with 2 bytes
+[50.00%] Biggest string found so far '"long"' with 10 bytes
+[75.00%] Biggest list   found so far '"list"' with 3 items
+
+-------- summary -------
+
+Sampled 4 keys in the keyspace!
+Total key length in bytes is 17 (avg len 4.25)
+
+Biggest string found '"long"' has 10 bytes
+Biggest   list found '"list"' has 3 items
+
+2 strings with 12 bytes (50.00% of keys, avg size 6.00)
+1 lists with 3 items (25.00% of keys, avg size 3.00)
+0 sets with 0 members (00.00% of keys, avg size 0.00)
+0 zsets with 0 members (00.00% of keys, avg size 0.00)
+0 hashs with 0 fields (00.00% of keys, avg size 0.00)
+0 streams with 0 entries (00.00% of keys, avg size 0.00)
+`, out.String())
+
+	s, out, _ = newServer(t)
+	for _, args := range [][]string{{"SET", "short", "ab"}, {"SET", "long", "0123456789"}, {"RPUSH", "list", "a", "b", "c"}} {
+		_, err := s.do(args...)
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.BigKeys())
+	require.Contains(t, out.String(), "Sampled 3 keys in the keyspace!\n")
+	require.Contains(t, out.String(), "Biggest string found '\"long\"' has 10 bytes\n")
+	require.Contains(t, out.String(), "Biggest   list found '\"list\"' has 3 items\n")
+}
+
+func TestLatency(t *testing.T) {
+	s, out, _ := newServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.cl.Raw = true
+	require.NoError(t, s.Latency(ctx))
+	fields := strings.Fields(out.String())
+	require.Len(t, fields, 4)
+	require.Equal(t, "1", fields[3])
+
+	out.Reset()
+	s.cl.Raw = false
+	require.NoError(t, s.Latency(ctx))
+	require.Regexp(t, `^\x1b\[0G\x1b\[2Kmin: \d+\.\d\d, max: \d+\.\d\d, avg: \d+\.\d\d \(1 samples\)\n$`, out.String())
+
+	s, _, _ = newScriptedServer(t, "-ERR unknown command 'PING'\r\n")
+	require.ErrorContains(t, s.Latency(ctx), "unknown command 'PING'")
+}
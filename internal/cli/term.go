@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// LineReader reads the lines typed in the REPL.
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+	AddHistory(line string)
+}
+
+// NewLineReader returns an Editor when in is a terminal, whose history is kept in historyFile unless it is empty,
+// and otherwise reads plain lines, prompting only when out is a terminal.
+func NewLineReader(in, out *os.File, historyFile string, hint func(line string) string) LineReader {
+	if rawSupported && isatty.IsTerminal(in.Fd()) {
+		fd := int(in.Fd())
+		t := &terminal{
+			Editor:      NewEditor(in, out, func() int { return terminalWidth(fd) }, hint),
+			fd:          fd,
+			historyFile: historyFile,
+		}
+		if historyFile != "" {
+			// the history is a convenience, a broken file does not prevent typing commands
+			_ = t.LoadHistory(historyFile)
+		}
+		return t
+	}
+	return &plainReader{in: bufio.NewReader(in), out: out, prompt: isatty.IsTerminal(out.Fd())}
+}
+
+// HistoryFile returns the path of the history file, set with GVALKEYCLI_HISTFILE or ~/.gvalkeycli_history by default.
+// it is empty when the history is disabled with /dev/null, or the home directory is unknown.
+func HistoryFile() string {
+	if path, ok := os.LookupEnv("GVALKEYCLI_HISTFILE"); ok {
+		if path == os.DevNull {
+			return ""
+		}
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gvalkeycli_history")
+}
+
+// terminal is an Editor put in raw mode while a line is read.
+type terminal struct {
+	*Editor
+	fd          int
+	historyFile string
+}
+
+func (t *terminal) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(t.fd)
+	if err != nil {
+		return "", err
+	}
+	line, err := t.Editor.ReadLine(prompt)
+	if restoreErr := restore(); err == nil {
+		err = restoreErr
+	}
+	return line, err
+}
+
+// AddHistory adds the line to the history, which is saved right away like redis-cli does.
+func (t *terminal) AddHistory(line string) {
+	t.Editor.AddHistory(line)
+	if t.historyFile != "" {
+		_ = t.SaveHistory(t.historyFile)
+	}
+}
+
+// plainReader reads lines from a pipe or a file, or from terminals that cannot be put in raw mode.
+type plainReader struct {
+	in     *bufio.Reader
+	out    io.Writer
+	prompt bool
+}
+
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	if r.prompt {
+		if _, err := io.WriteString(r.out, prompt); err != nil {
+			return "", err
+		}
+	}
+	line, err := r.in.ReadString('\n')
+	// the last line may not end with a newline
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (r *plainReader) AddHistory(string) {}
@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package cli
+
+import "errors"
+
+const rawSupported = false
+
+func makeRaw(fd int) (func() error, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func terminalWidth(fd int) int {
+	return 80
+}
@@ -0,0 +1,359 @@
+// Package cluster maps keys to the hash slots of Redis Cluster and tracks the node serving each slot.
+//
+// there is no cluster bus: nodes gossip by polling the CLUSTER NODES of each other, see Gossip.
+// a node is authoritative for the slots it serves, the others learn them from its own line.
+package cluster
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// forgetBan is how long a forgotten node is not learned again from the other nodes, so that it can be forgotten by all of them.
+const forgetBan = time.Minute
+
+var (
+	errUnknownNode   = errors.New("unknown node")
+	errForgetMyself  = errors.New("can't forget myself")
+	errNotOwner      = errors.New("not the owner of hash slot")
+	errAlreadyOwner  = errors.New("already the owner of hash slot")
+	errInvalidTarget = errors.New("can't migrate or import a hash slot to or from myself")
+	errForgotten     = errors.New("forgotten recently")
+)
+
+// Node is a member of the cluster, it is never modified once known so that it can be read without locking.
+type Node struct {
+	ID string
+	// address of the node, empty for the local node which is reached at the address clients connected to
+	Host string
+	Port int
+}
+
+// SlotRange is a range of consecutive slots served by the same node, End included.
+type SlotRange struct {
+	Start int
+	End   int
+	Node  *Node
+}
+
+// State is the view of the cluster of the local node, it is safe for concurrent use.
+type State struct {
+	mu     sync.RWMutex
+	myself *Node
+	nodes  map[string]*Node
+	owners [SlotCount]*Node
+	// slots being moved to another node, and slots being moved from another node to the local one
+	migrating map[int]*Node
+	importing map[int]*Node
+	// end of the ban of the nodes forgotten recently
+	forgotten map[string]time.Time
+}
+
+// New creates the state of a cluster made of the local node alone, which serves no slot yet.
+func New(id string, port int) *State {
+	myself := &Node{ID: id, Port: port}
+	return &State{
+		myself:    myself,
+		nodes:     map[string]*Node{id: myself},
+		migrating: make(map[int]*Node),
+		importing: make(map[int]*Node),
+		forgotten: make(map[string]time.Time),
+	}
+}
+
+// NewNodeID returns a random node ID, 40 hexadecimal characters like the ones of Redis.
+func NewNodeID() string {
+	b := make([]byte, 20)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Myself returns the local node.
+func (s *State) Myself() *Node {
+	return s.myself
+}
+
+// Meet adds the node id at host:port, or updates its address when it is already known.
+// nodes forgotten less than a minute ago are refused, as they would otherwise introduce themselves again.
+func (s *State) Meet(id, host string, port int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.banned(id) {
+		return fmt.Errorf("node %s was %w", id, errForgotten)
+	}
+	s.meet(id, host, port)
+	return nil
+}
+
+// banned reports whether the node id was forgotten less than a minute ago, the lock must be held.
+func (s *State) banned(id string) bool {
+	end, ok := s.forgotten[id]
+	if ok && time.Now().After(end) {
+		delete(s.forgotten, id)
+		return false
+	}
+	return ok
+}
+
+// meet adds or updates the node id, the lock must be held.
+func (s *State) meet(id, host string, port int) {
+	old, ok := s.nodes[id]
+	if old == s.myself {
+		return
+	}
+	node := &Node{ID: id, Host: host, Port: port}
+	s.nodes[id] = node
+	if !ok {
+		return
+	}
+	// the slots of the node follow it to its new address
+	for slot, owner := range s.owners {
+		if owner == old {
+			s.owners[slot] = node
+		}
+	}
+	for _, states := range []map[int]*Node{s.migrating, s.importing} {
+		for slot, other := range states {
+			if other == old {
+				states[slot] = node
+			}
+		}
+	}
+}
+
+// Forget removes a node, the slots it served become unassigned.
+func (s *State) Forget(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[id]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownNode, id)
+	}
+	if node == s.myself {
+		return errForgetMyself
+	}
+	delete(s.nodes, id)
+	s.forgotten[id] = time.Now().Add(forgetBan)
+	for slot, owner := range s.owners {
+		if owner == node {
+			s.owners[slot] = nil
+		}
+	}
+	for _, states := range []map[int]*Node{s.migrating, s.importing} {
+		for slot, other := range states {
+			if other == node {
+				delete(states, slot)
+			}
+		}
+	}
+	return nil
+}
+
+// Node returns the node id.
+func (s *State) Node(id string) (*Node, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node, ok := s.nodes[id]
+	return node, ok
+}
+
+// Nodes returns the known nodes, the local one first and the others by ID.
+func (s *State) Nodes() []*Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	nodes := make([]*Node, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		if node != s.myself {
+			nodes = append(nodes, node)
+		}
+	}
+	slices.SortFunc(nodes, func(a, b *Node) int { return strings.Compare(a.ID, b.ID) })
+	return append([]*Node{s.myself}, nodes...)
+}
+
+// AddSlots assigns slots to the local node, none is assigned when one of them is already.
+func (s *State) AddSlots(slots []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, slot := range slots {
+		if s.owners[slot] != nil {
+			return fmt.Errorf("slot %d is already busy", slot)
+		}
+	}
+	for _, slot := range slots {
+		s.owners[slot] = s.myself
+		delete(s.importing, slot)
+	}
+	return nil
+}
+
+// DelSlots unassigns slots, none is unassigned when one of them is not assigned.
+func (s *State) DelSlots(slots []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, slot := range slots {
+		if s.owners[slot] == nil {
+			return fmt.Errorf("slot %d is already unassigned", slot)
+		}
+	}
+	for _, slot := range slots {
+		s.owners[slot] = nil
+		delete(s.migrating, slot)
+		delete(s.importing, slot)
+	}
+	return nil
+}
+
+// SetNode assigns slot to the node id, ending its migration or its import.
+func (s *State) SetNode(slot int, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[id]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownNode, id)
+	}
+	s.owners[slot] = node
+	delete(s.migrating, slot)
+	delete(s.importing, slot)
+	return nil
+}
+
+// SetMigrating marks slot, served by the local node, as being moved to the node id.
+func (s *State) SetMigrating(slot int, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.owners[slot] != s.myself {
+		return fmt.Errorf("%w %d", errNotOwner, slot)
+	}
+	node, ok := s.nodes[id]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownNode, id)
+	}
+	if node == s.myself {
+		return errInvalidTarget
+	}
+	s.migrating[slot] = node
+	return nil
+}
+
+// SetImporting marks slot as being moved from the node id to the local node.
+func (s *State) SetImporting(slot int, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.owners[slot] == s.myself {
+		return fmt.Errorf("%w %d", errAlreadyOwner, slot)
+	}
+	node, ok := s.nodes[id]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownNode, id)
+	}
+	if node == s.myself {
+		return errInvalidTarget
+	}
+	s.importing[slot] = node
+	return nil
+}
+
+// SetStable ends the migration or the import of slot.
+func (s *State) SetStable(slot int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.migrating, slot)
+	delete(s.importing, slot)
+}
+
+// Route returns the node serving slot, nil when unassigned,
+// along with the node it is migrating to and the one it is imported from, if any.
+func (s *State) Route(slot int) (owner, migrating, importing *Node) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.owners[slot], s.migrating[slot], s.importing[slot]
+}
+
+// Ranges returns the assigned slots as ranges, in increasing order.
+func (s *State) Ranges() []SlotRange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ranges []SlotRange
+	for slot, owner := range s.owners {
+		switch {
+		case owner == nil:
+		case len(ranges) > 0 && ranges[len(ranges)-1].Node == owner && ranges[len(ranges)-1].End == slot-1:
+			ranges[len(ranges)-1].End = slot
+		default:
+			ranges = append(ranges, SlotRange{Start: slot, End: slot, Node: owner})
+		}
+	}
+	return ranges
+}
+
+// Migrations returns the slots migrating to other nodes and the ones imported from other nodes.
+func (s *State) Migrations() (migrating, importing map[int]*Node) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.migrating), maps.Clone(s.importing)
+}
+
+// Gossip merges the view of the node from, as described by its CLUSTER NODES, and reports whether from knows the local node.
+// the nodes unknown so far are added, and the slots from serves are the ones on its own line,
+// except the slots served by the local node, which only changes their owner when told so.
+func (s *State) Gossip(from string, view []NodeInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[from]
+	if !ok {
+		return true
+	}
+	knowsMe := false
+	for _, info := range view {
+		switch {
+		case info.ID == s.myself.ID:
+			knowsMe = true
+		case info.Myself:
+			if info.ID == from {
+				s.claim(node, info.Slots)
+			}
+		case s.nodes[info.ID] == nil && !s.banned(info.ID):
+			s.meet(info.ID, info.Host, info.Port)
+		}
+	}
+	return knowsMe
+}
+
+// claim records the slots node serves, the lock must be held.
+func (s *State) claim(node *Node, slots []int) {
+	claimed := make(map[int]bool, len(slots))
+	for _, slot := range slots {
+		claimed[slot] = true
+		if s.owners[slot] != s.myself {
+			s.owners[slot] = node
+		}
+	}
+	for slot, owner := range s.owners {
+		if owner == node && !claimed[slot] {
+			s.owners[slot] = nil
+		}
+	}
+}
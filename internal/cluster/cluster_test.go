@@ -0,0 +1,122 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySlot(t *testing.T) {
+	// check value of CRC-16/XMODEM
+	require.Equal(t, uint16(0x31C3), crc16("123456789"))
+
+	require.Equal(t, 12182, KeySlot("foo"))
+	require.Equal(t, 5061, KeySlot("bar"))
+	require.Equal(t, KeySlot("user1000"), KeySlot("{user1000}.following"))
+	require.Equal(t, KeySlot("{user1000}.following"), KeySlot("{user1000}.followers"))
+	// an empty hash tag hashes the whole key
+	require.Equal(t, int(crc16("foo{}{bar}")&(SlotCount-1)), KeySlot("foo{}{bar}"))
+	require.Equal(t, KeySlot("bar"), KeySlot("foo{bar}{zap}"))
+}
+
+func TestSlots(t *testing.T) {
+	s := New("a", 7000)
+	require.NoError(t, s.Meet("b", "127.0.0.1", 7001))
+
+	require.NoError(t, s.AddSlots([]int{0, 1, 2, 5}))
+	require.EqualError(t, s.AddSlots([]int{3, 2}), "slot 2 is already busy")
+	owner, _, _ := s.Route(3)
+	require.Nil(t, owner, "no slot is assigned when one is busy")
+
+	require.NoError(t, s.SetNode(3, "b"))
+	require.Error(t, s.SetNode(4, "c"))
+
+	b, ok := s.Node("b")
+	require.True(t, ok)
+	require.Equal(t, []SlotRange{
+		{Start: 0, End: 2, Node: s.Myself()},
+		{Start: 3, End: 3, Node: b},
+		{Start: 5, End: 5, Node: s.Myself()},
+	}, s.Ranges())
+
+	require.EqualError(t, s.DelSlots([]int{4}), "slot 4 is already unassigned")
+	require.NoError(t, s.DelSlots([]int{5}))
+	require.Len(t, s.Ranges(), 2)
+}
+
+func TestMigrations(t *testing.T) {
+	s := New("a", 7000)
+	require.NoError(t, s.Meet("b", "127.0.0.1", 7001))
+	require.NoError(t, s.AddSlots([]int{1}))
+	require.NoError(t, s.SetNode(2, "b"))
+
+	require.Error(t, s.SetMigrating(2, "b"))
+	require.Error(t, s.SetMigrating(1, "a"))
+	require.NoError(t, s.SetMigrating(1, "b"))
+	require.Error(t, s.SetImporting(1, "b"))
+	require.NoError(t, s.SetImporting(2, "b"))
+
+	owner, migrating, _ := s.Route(1)
+	require.Equal(t, s.Myself(), owner)
+	require.Equal(t, "b", migrating.ID)
+	_, _, importing := s.Route(2)
+	require.Equal(t, "b", importing.ID)
+
+	// moving b keeps its slots and migrations
+	require.NoError(t, s.Meet("b", "127.0.0.1", 7002))
+	owner, _, importing = s.Route(2)
+	require.Equal(t, 7002, owner.Port)
+	require.Equal(t, 7002, importing.Port)
+
+	require.NoError(t, s.SetNode(1, "b"))
+	_, migrating, _ = s.Route(1)
+	require.Nil(t, migrating)
+
+	s.SetStable(2)
+	_, _, importing = s.Route(2)
+	require.Nil(t, importing)
+
+	require.Error(t, s.Forget("a"))
+	require.NoError(t, s.Forget("b"))
+	require.Empty(t, s.Ranges())
+	require.Len(t, s.Nodes(), 1)
+}
+
+func TestGossip(t *testing.T) {
+	view, err := ParseNodes("b 10.0.0.2:7001@17001 myself,master - 0 0 0 connected 0-2 5 [6->-a]\n" +
+		"c 10.0.0.3:7002@17002 master - 0 0 0 connected\n")
+	require.NoError(t, err)
+	require.Equal(t, []NodeInfo{
+		{Node: Node{ID: "b", Host: "10.0.0.2", Port: 7001}, Myself: true, Slots: []int{0, 1, 2, 5}},
+		{Node: Node{ID: "c", Host: "10.0.0.3", Port: 7002}},
+	}, view)
+	_, err = ParseNodes("b 10.0.0.2:7001@17001 myself,master - 0 0 0 connected 3-1")
+	require.Error(t, err)
+
+	s := New("a", 7000)
+	require.NoError(t, s.Meet("b", "10.0.0.2", 7001))
+	require.NoError(t, s.AddSlots([]int{2, 4}))
+	require.False(t, s.Gossip("b", view), "b does not know a")
+
+	b, _ := s.Node("b")
+	owner, _, _ := s.Route(1)
+	require.Equal(t, b, owner)
+	owner, _, _ = s.Route(2)
+	require.Equal(t, s.Myself(), owner, "the slots of the local node are kept")
+	_, ok := s.Node("c")
+	require.True(t, ok)
+
+	// b gave up slot 5 and knows a now
+	view[0].Slots = []int{0, 1}
+	view = append(view, NodeInfo{Node: Node{ID: "a", Host: "10.0.0.1", Port: 7000}})
+	require.True(t, s.Gossip("b", view))
+	owner, _, _ = s.Route(5)
+	require.Nil(t, owner)
+
+	// a forgotten node is not learned again right away
+	require.NoError(t, s.Forget("c"))
+	s.Gossip("b", view)
+	_, ok = s.Node("c")
+	require.False(t, ok)
+	require.EqualError(t, s.Meet("c", "10.0.0.3", 7002), "node c was forgotten recently")
+}
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NodeInfo is a line of CLUSTER NODES: a node, whether it is the one that described itself, and the slots it serves.
+type NodeInfo struct {
+	Node
+	Myself bool
+	Slots  []int
+}
+
+// ParseNodes parses the reply of CLUSTER NODES, ignoring the slots being migrated or imported.
+func ParseNodes(text string) ([]NodeInfo, error) {
+	var infos []NodeInfo
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("invalid node line '%s'", line)
+		}
+
+		// ip:port@cport[,hostname]
+		addr, _, _ := strings.Cut(fields[1], "@")
+		colon := strings.LastIndexByte(addr, ':')
+		if colon < 0 {
+			return nil, fmt.Errorf("invalid node address '%s'", fields[1])
+		}
+		port, err := strconv.Atoi(addr[colon+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid node address '%s'", fields[1])
+		}
+
+		info := NodeInfo{
+			Node:   Node{ID: fields[0], Host: strings.Trim(addr[:colon], "[]"), Port: port},
+			Myself: strings.Contains(fields[2], "myself"),
+		}
+		for _, field := range fields[8:] {
+			if strings.HasPrefix(field, "[") {
+				continue
+			}
+			start, end, found := strings.Cut(field, "-")
+			if !found {
+				end = start
+			}
+			first, err1 := strconv.Atoi(start)
+			last, err2 := strconv.Atoi(end)
+			if err1 != nil || err2 != nil || first < 0 || last >= SlotCount || first > last {
+				return nil, fmt.Errorf("invalid slot range '%s'", field)
+			}
+			for slot := first; slot <= last; slot++ {
+				info.Slots = append(info.Slots, slot)
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
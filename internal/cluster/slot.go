@@ -0,0 +1,43 @@
+package cluster
+
+import "strings"
+
+// SlotCount is the number of hash slots the keyspace of a cluster is split into.
+const SlotCount = 16384
+
+// crcTable holds the CRC-16/XMODEM of every byte, the checksum Redis Cluster hashes keys with.
+var crcTable = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := range len(s) {
+		crc = crc<<8 ^ crcTable[byte(crc>>8)^s[i]]
+	}
+	return crc
+}
+
+// KeySlot returns the hash slot of key.
+// only the part between the first { and the next } is hashed when it is not empty,
+// so that keys sharing such a hash tag, like {user1}.name and {user1}.email, belong to the same slot.
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) & (SlotCount - 1))
+}
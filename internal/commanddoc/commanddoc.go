@@ -0,0 +1,233 @@
+// Package commanddoc describes the arguments of commands from their syntax, as COMMAND DOCS replies them.
+//
+// the syntax is the one of the documentation of Redis: words without lower case letters are tokens, optionally followed by their
+// value, the other words are values, [...] groups optional arguments, <...> required ones, | separates alternatives in a group
+// and ... repeats what comes before it in its group, e.g. "key [NX|XX] [EX seconds] member [member ...]".
+package commanddoc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// types of the arguments
+const (
+	TypeKey       = "key"
+	TypeString    = "string"
+	TypePureToken = "pure-token"
+	TypeOneOf     = "oneof"
+	TypeBlock     = "block"
+)
+
+// Arg is an argument of a command.
+type Arg struct {
+	Name  string
+	Type  string
+	Token string
+	// the arguments of a oneof or a block
+	Args []Arg
+
+	Optional bool
+	Multiple bool
+	// the token is repeated along with each value
+	MultipleToken bool
+}
+
+// Parse returns the arguments described by syntax.
+func Parse(syntax string) ([]Arg, error) {
+	p := &parser{tokens: tokenize(syntax)}
+	args, repeated, err := p.sequence()
+	if err != nil {
+		return nil, err
+	}
+	if repeated {
+		return nil, errors.New("... outside of a group")
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return args, nil
+}
+
+// MustParse is like Parse but panics when syntax is invalid, for syntaxes known at build time.
+func MustParse(syntax string) []Arg {
+	args, err := Parse(syntax)
+	if err != nil {
+		panic(fmt.Sprintf("commanddoc: invalid syntax %q: %v", syntax, err))
+	}
+	return args
+}
+
+// tokenize splits syntax into words and the punctuation of groups.
+func tokenize(syntax string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(syntax) {
+		for field != "" {
+			switch {
+			case strings.HasPrefix(field, "..."):
+				tokens = append(tokens, "...")
+				field = field[3:]
+			case strings.ContainsAny(field[:1], "[]<>|"):
+				tokens = append(tokens, field[:1])
+				field = field[1:]
+			default:
+				end := strings.IndexAny(field, "[]<>|")
+				if dots := strings.Index(field, "..."); dots >= 0 && (end < 0 || dots < end) {
+					end = dots
+				}
+				if end < 0 {
+					end = len(field)
+				}
+				tokens = append(tokens, field[:end])
+				field = field[end:]
+			}
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// sequence parses arguments up to the end of the group or alternative, and reports whether they end with ...
+func (p *parser) sequence() ([]Arg, bool, error) {
+	var args []Arg
+	repeated := false
+	for {
+		tok := p.peek()
+		switch tok {
+		case "", "]", ">", "|":
+			return args, repeated, nil
+		case "...":
+			p.pos++
+			repeated = true
+		case "[", "<":
+			p.pos++
+			var err error
+			if args, err = p.group(args, tok == "["); err != nil {
+				return nil, false, err
+			}
+		default:
+			p.pos++
+			args = append(args, p.word(tok))
+		}
+	}
+}
+
+// word parses a value, a token or a token followed by its value.
+func (p *parser) word(word string) Arg {
+	if !isToken(word) {
+		return Arg{Name: word, Type: typeOf(word)}
+	}
+	if value := p.peek(); value != "" && !strings.ContainsAny(value[:1], "[]<>|.") && !isToken(value) {
+		p.pos++
+		return Arg{Name: value, Type: typeOf(value), Token: word}
+	}
+	return Arg{Name: strings.ToLower(word), Type: TypePureToken, Token: word}
+}
+
+// group parses the content of a group up to its closing bracket and appends it to args.
+// an optional group repeating the arguments before it, as in key [key ...], makes them multiple instead.
+func (p *parser) group(args []Arg, optional bool) ([]Arg, error) {
+	closing := ">"
+	if optional {
+		closing = "]"
+	}
+
+	var alternatives [][]Arg
+	repeated := false
+	for {
+		alternative, rep, err := p.sequence()
+		if err != nil {
+			return nil, err
+		}
+		if len(alternative) == 0 {
+			return nil, errors.New("empty group")
+		}
+		alternatives = append(alternatives, alternative)
+		repeated = repeated || rep
+		if p.peek() != "|" {
+			break
+		}
+		p.pos++
+	}
+	if p.peek() != closing {
+		return nil, fmt.Errorf("missing %q", closing)
+	}
+	p.pos++
+
+	if len(alternatives) > 1 {
+		if repeated {
+			return nil, errors.New("... in alternatives")
+		}
+		oneof := Arg{Name: TypeOneOf, Type: TypeOneOf, Optional: optional}
+		for _, alternative := range alternatives {
+			oneof.Args = append(oneof.Args, block(alternative))
+		}
+		return append(args, oneof), nil
+	}
+
+	content := alternatives[0]
+	if repeated && optional && len(args) >= len(content) {
+		tail := args[len(args)-len(content):]
+		if reflect.DeepEqual(tail, content) {
+			arg := block(content)
+			arg.Multiple = true
+			arg.MultipleToken = arg.Token != "" && arg.Type != TypePureToken
+			return append(args[:len(args)-len(content)], arg), nil
+		}
+		// the value of a token repeated without it, as in KEYS key [key ...]
+		if last := &args[len(args)-1]; len(content) == 1 && last.Token != "" && last.Type != TypePureToken &&
+			reflect.DeepEqual(Arg{Name: last.Name, Type: last.Type}, content[0]) {
+			last.Multiple = true
+			return args, nil
+		}
+	}
+	arg := block(content)
+	arg.Optional = arg.Optional || optional
+	if repeated {
+		arg.Multiple = true
+		arg.MultipleToken = arg.Token != "" && arg.Type != TypePureToken
+	}
+	return append(args, arg), nil
+}
+
+// block returns the single argument of args, or a block grouping them.
+func block(args []Arg) Arg {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return Arg{Name: TypeBlock, Type: TypeBlock, Args: args}
+}
+
+// isToken reports whether word is a keyword, written without lower case letters, such as NX, ~ or "".
+func isToken(word string) bool {
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// typeOf returns the type of a value named name, keys being named after them.
+func typeOf(name string) string {
+	switch {
+	case name == "destination" || name == "source" || strings.HasSuffix(name, "key"):
+		return TypeKey
+	default:
+		return TypeString
+	}
+}
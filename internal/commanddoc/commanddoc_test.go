@@ -0,0 +1,123 @@
+package commanddoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		syntax string
+		want   []Arg
+	}{
+		{"", nil},
+		{"key", []Arg{{Name: "key", Type: TypeKey}}},
+		{"key [key ...]", []Arg{{Name: "key", Type: TypeKey, Multiple: true}}},
+		{"[channel ...]", []Arg{{Name: "channel", Type: TypeString, Optional: true, Multiple: true}}},
+		{
+			"key field value [field value ...]",
+			[]Arg{
+				{Name: "key", Type: TypeKey},
+				{Name: TypeBlock, Type: TypeBlock, Multiple: true, Args: []Arg{
+					{Name: "field", Type: TypeString},
+					{Name: "value", Type: TypeString},
+				}},
+			},
+		},
+		{
+			"key value [NX|XX] [GET] [EX seconds|KEEPTTL]",
+			[]Arg{
+				{Name: "key", Type: TypeKey},
+				{Name: "value", Type: TypeString},
+				{Name: TypeOneOf, Type: TypeOneOf, Optional: true, Args: []Arg{
+					{Name: "nx", Type: TypePureToken, Token: "NX"},
+					{Name: "xx", Type: TypePureToken, Token: "XX"},
+				}},
+				{Name: "get", Type: TypePureToken, Token: "GET", Optional: true},
+				{Name: TypeOneOf, Type: TypeOneOf, Optional: true, Args: []Arg{
+					{Name: "seconds", Type: TypeString, Token: "EX"},
+					{Name: "keepttl", Type: TypePureToken, Token: "KEEPTTL"},
+				}},
+			},
+		},
+		{
+			"source destination <LEFT|RIGHT>",
+			[]Arg{
+				{Name: "source", Type: TypeKey},
+				{Name: "destination", Type: TypeKey},
+				{Name: TypeOneOf, Type: TypeOneOf, Args: []Arg{
+					{Name: "left", Type: TypePureToken, Token: "LEFT"},
+					{Name: "right", Type: TypePureToken, Token: "RIGHT"},
+				}},
+			},
+		},
+		{
+			"key <FROMMEMBER member|FROMLONLAT longitude latitude> [start end [BYTE]]",
+			[]Arg{
+				{Name: "key", Type: TypeKey},
+				{Name: TypeOneOf, Type: TypeOneOf, Args: []Arg{
+					{Name: "member", Type: TypeString, Token: "FROMMEMBER"},
+					{Name: TypeBlock, Type: TypeBlock, Args: []Arg{
+						{Name: "longitude", Type: TypeString, Token: "FROMLONLAT"},
+						{Name: "latitude", Type: TypeString},
+					}},
+				}},
+				{Name: TypeBlock, Type: TypeBlock, Optional: true, Args: []Arg{
+					{Name: "start", Type: TypeString},
+					{Name: "end", Type: TypeString},
+					{Name: "byte", Type: TypePureToken, Token: "BYTE", Optional: true},
+				}},
+			},
+		},
+		{
+			`host port <key|""> [KEYS key [key ...]]`,
+			[]Arg{
+				{Name: "host", Type: TypeString},
+				{Name: "port", Type: TypeString},
+				{Name: TypeOneOf, Type: TypeOneOf, Args: []Arg{
+					{Name: "key", Type: TypeKey},
+					{Name: `""`, Type: TypePureToken, Token: `""`},
+				}},
+				{Name: "key", Type: TypeKey, Token: "KEYS", Optional: true, Multiple: true},
+			},
+		},
+		{
+			"key [= | ~] threshold",
+			[]Arg{
+				{Name: "key", Type: TypeKey},
+				{Name: TypeOneOf, Type: TypeOneOf, Optional: true, Args: []Arg{
+					{Name: "=", Type: TypePureToken, Token: "="},
+					{Name: "~", Type: TypePureToken, Token: "~"},
+				}},
+				{Name: "threshold", Type: TypeString},
+			},
+		},
+		{
+			"key [GET encoding offset ...] [KEYS key [key ...]]",
+			[]Arg{
+				{Name: "key", Type: TypeKey},
+				{Name: TypeBlock, Type: TypeBlock, Optional: true, Multiple: true, Args: []Arg{
+					{Name: "encoding", Type: TypeString, Token: "GET"},
+					{Name: "offset", Type: TypeString},
+				}},
+				{Name: "key", Type: TypeKey, Token: "KEYS", Optional: true, Multiple: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.syntax, func(t *testing.T) {
+			args, err := Parse(tt.syntax)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, args)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, syntax := range []string{"key ...", "[key", "<key]", "[]", "key]", "a|b", "[a ...|b]"} {
+		_, err := Parse(syntax)
+		require.Error(t, err, syntax)
+	}
+	require.Panics(t, func() { MustParse("[key") })
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Bytes is a memory size that accepts the units Redis uses in its configuration, e.g. 100mb or 1gb.
+type Bytes int64
+
+// memoryUnits maps a unit suffix to its multiplier, the "b" suffixes are powers of 1024.
+var memoryUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"kb", 1 << 10},
+	{"mb", 1 << 20},
+	{"gb", 1 << 30},
+	{"k", 1000},
+	{"m", 1000 * 1000},
+	{"g", 1000 * 1000 * 1000},
+	{"b", 1},
+}
+
+// ParseBytes parses a memory size such as 1024, 1k or 1gb.
+func ParseBytes(s string) (Bytes, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	multiplier := int64(1)
+	for _, unit := range memoryUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("argument must be a memory value")
+	}
+	if n < 0 {
+		return 0, errors.New("argument must be a positive memory value")
+	}
+	return Bytes(n * multiplier), nil
+}
+
+func (b *Bytes) UnmarshalText(text []byte) error {
+	n, err := ParseBytes(string(text))
+	if err != nil {
+		return err
+	}
+	*b = n
+	return nil
+}
+
+func (b Bytes) String() string {
+	return strconv.FormatInt(int64(b), 10)
+}
@@ -1,32 +1,287 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"reflect"
+	"strconv"
 	"strings"
 
+	"github.com/PlayerNeo42/gvalkey/internal/pubsub"
 	"github.com/caarlos0/env/v11"
 	"github.com/go-playground/validator/v10"
 )
 
+// Config holds the server settings.
+//
+// the conf tag names the directive used by CONFIG GET/SET, configuration files and command-line flags, followed by options:
+// mutable marks settings that can be changed at runtime,
+// append marks settings whose repeated directives in a configuration file add up instead of overriding each other.
+// the usage tag documents the setting in --help.
 type Config struct {
-	Host     string `env:"GVK_HOST" envDefault:"0.0.0.0" validate:"required,hostname|ip"`
-	Port     int    `env:"GVK_PORT" envDefault:"6379" validate:"required,min=1,max=65535"`
-	LogLevel string `env:"GVK_LOG_LEVEL" envDefault:"INFO" validate:"required,oneof=DEBUG INFO WARN ERROR"`
+	Host     string `conf:"bind" env:"GVK_HOST" envDefault:"0.0.0.0" validate:"required,hostname|ip" usage:"Server bind address"`
+	Port     int    `conf:"port" env:"GVK_PORT" envDefault:"6379" validate:"required,min=1,max=65535" usage:"Server listen port"`
+	LogLevel string `conf:"loglevel,mutable" env:"GVK_LOG_LEVEL" envDefault:"INFO" validate:"required,oneof=DEBUG INFO WARN ERROR" usage:"Logging level: DEBUG, INFO, WARN or ERROR"`
+
+	// number of logical databases selectable with SELECT
+	Databases int `conf:"databases" env:"GVK_DATABASES" envDefault:"16" validate:"omitempty,min=1,max=1024" usage:"Number of databases, clients select one of them with SELECT"`
+
+	// idle client timeout in seconds, 0 disables it
+	Timeout int `conf:"timeout,mutable" env:"GVK_TIMEOUT" envDefault:"0" validate:"gte=0" usage:"Close clients idle for this many seconds, 0 disables it"`
+
+	// memory limit for the dataset, 0 means no limit
+	MaxMemory       Bytes  `conf:"maxmemory,mutable" env:"GVK_MAXMEMORY" envDefault:"0" validate:"gte=0" usage:"Memory limit such as 100mb or 1gb, 0 means no limit"`
+	MaxMemoryPolicy string `conf:"maxmemory-policy,mutable" env:"GVK_MAXMEMORY_POLICY" envDefault:"noeviction" validate:"omitempty,oneof=noeviction allkeys-random volatile-random" usage:"Eviction policy when maxmemory is reached: noeviction, allkeys-random or volatile-random"`
+
+	// classes of keyspace events published over pub/sub, empty disables notifications
+	NotifyKeyspaceEvents string `conf:"notify-keyspace-events,mutable" env:"GVK_NOTIFY_KEYSPACE_EVENTS" envDefault:"" validate:"keyspaceevents" usage:"Keyspace events to publish, as Redis flags such as \"KEA\" or \"Ex\", empty disables them"`
+
+	// snapshotting rules as "<seconds> <changes>" pairs: the dataset is saved once it has changed that many times in that many seconds
+	Save string `conf:"save,mutable,append" env:"GVK_SAVE" envDefault:"" validate:"saverules" usage:"Snapshotting rules as \"<seconds> <changes>\" pairs, empty disables snapshotting"`
+	// directory and name of the snapshot file
+	Dir        string `conf:"dir,mutable" env:"GVK_DIR" envDefault:"." usage:"Directory the snapshot is written to"`
+	DBFilename string `conf:"dbfilename,mutable" env:"GVK_DBFILENAME" envDefault:"dump.rdb" validate:"excludes=/" usage:"Name of the snapshot file"`
+
+	// milliseconds a script runs before other clients get BUSY errors and it can be stopped with SCRIPT KILL, 0 disables it
+	LuaTimeLimit int `conf:"lua-time-limit,mutable" env:"GVK_LUA_TIME_LIMIT" envDefault:"5000" validate:"gte=0" usage:"Milliseconds a script runs before other clients get BUSY errors and SCRIPT KILL can stop it, 0 disables it"`
+
+	// master to replicate at startup as "<host> <port>", empty starts as a master
+	ReplicaOf string `conf:"replicaof" env:"GVK_REPLICAOF" envDefault:"" validate:"replicaof" usage:"Master to replicate at startup as \"<host> <port>\", empty starts as a master"`
+	// whether replicas reject the writes of their clients
+	ReplicaReadOnly bool `conf:"replica-read-only,mutable" env:"GVK_REPLICA_READ_ONLY" envDefault:"true" usage:"Reject the writes of clients while replicating a master"`
+	// size of the buffer of recent writes that lets disconnected replicas resume without a full synchronization
+	ReplBacklogSize Bytes `conf:"repl-backlog-size" env:"GVK_REPL_BACKLOG_SIZE" envDefault:"1mb" validate:"omitempty,min=16384" usage:"Size of the replication backlog, such as 1mb"`
+
+	// whether the server is a node of a cluster, serving only the hash slots assigned to it
+	ClusterEnabled bool `conf:"cluster-enabled" env:"GVK_CLUSTER_ENABLED" envDefault:"false" usage:"Run as a cluster node, serving only the hash slots assigned to it"`
+	// address given to clients in redirections, empty uses the address they connected to
+	ClusterAnnounceIP string `conf:"cluster-announce-ip,mutable" env:"GVK_CLUSTER_ANNOUNCE_IP" envDefault:"" validate:"omitempty,ip" usage:"IP address of the node given to clients, empty uses the one they connected to"`
+
+	// commands running for at least this many microseconds are recorded in the slow log, -1 disables it
+	SlowlogLogSlowerThan int `conf:"slowlog-log-slower-than,mutable" env:"GVK_SLOWLOG_LOG_SLOWER_THAN" envDefault:"10000" validate:"gte=-1" usage:"Record the commands running for at least this many microseconds in the slow log, -1 disables it"`
+	// number of entries kept in the slow log
+	SlowlogMaxLen int `conf:"slowlog-max-len,mutable" env:"GVK_SLOWLOG_MAX_LEN" envDefault:"128" validate:"gte=0" usage:"Number of entries kept in the slow log"`
+
+	// address of the HTTP listener serving Prometheus metrics on /metrics, empty disables it
+	MetricsAddr string `conf:"metrics-addr" env:"GVK_METRICS_ADDR" envDefault:"" validate:"omitempty,hostname_port" usage:"Address serving Prometheus metrics on /metrics, such as :9121, empty disables it"`
 }
 
-func Load() (*Config, error) {
-	var c Config
-	if err := env.Parse(&c); err != nil {
+type LoadOption func(*loader)
+
+type loader struct {
+	file      string
+	overrides []string
+	onUnknown func(Directive)
+	ignoreEnv bool
+}
+
+// WithFile loads the given redis.conf-style configuration file.
+func WithFile(path string) LoadOption {
+	return func(l *loader) {
+		l.file = path
+	}
+}
+
+// WithOverrides applies name/value pairs on top of every other source, typically parsed from command-line flags.
+func WithOverrides(nameValues ...string) LoadOption {
+	return func(l *loader) {
+		l.overrides = append(l.overrides, nameValues...)
+	}
+}
+
+// WithoutEnv ignores the GVK_* environment variables, for servers that must not depend on the environment such as in tests.
+func WithoutEnv() LoadOption {
+	return func(l *loader) {
+		l.ignoreEnv = true
+	}
+}
+
+// WithUnknownDirectiveHandler sets the function called for directives of the configuration file that gvalkey does not support.
+// they are ignored by default, so that configuration files written for Redis can be reused.
+func WithUnknownDirectiveHandler(fn func(Directive)) LoadOption {
+	return func(l *loader) {
+		l.onUnknown = fn
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence:
+// the default values, the configuration file, the GVK_* environment variables unless WithoutEnv is given, and the overrides.
+func Load(opts ...LoadOption) (*Config, error) {
+	l := loader{
+		onUnknown: func(Directive) {},
+	}
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	c := Default()
+
+	if l.file != "" {
+		if err := c.applyFile(l.file, l.onUnknown); err != nil {
+			return nil, err
+		}
+	}
+
+	if !l.ignoreEnv {
+		if err := c.applyEnv(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.applyOverrides(l.overrides); err != nil {
 		return nil, err
 	}
 
-	c.LogLevel = strings.ToUpper(c.LogLevel)
+	c.normalize()
 
-	if err := validateConfig(&c); err != nil {
+	if err := validateConfig(c); err != nil {
 		return nil, err
 	}
 
-	return &c, nil
+	return c, nil
+}
+
+func (c *Config) applyFile(path string, onUnknown func(Directive)) error {
+	directives, err := ParseFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range directives {
+		s, ok := LookupSetting(d.Name)
+		if !ok {
+			onUnknown(d)
+			continue
+		}
+		if len(d.Args) == 0 {
+			return fmt.Errorf("%s: wrong number of arguments", d)
+		}
+
+		value := strings.Join(d.Args, " ")
+		if s.Append && seen[s.Name] && value != "" {
+			value = c.Value(s) + " " + value
+		}
+		seen[s.Name] = true
+
+		if err := c.SetValue(s, value); err != nil {
+			return fmt.Errorf("%s: %w", d, err)
+		}
+		if err := c.validateSetting(s); err != nil {
+			return fmt.Errorf("%s: %w", d, err)
+		}
+	}
+	return nil
+}
+
+// validateSetting checks the value of the setting s, so that a bad directive is reported at its line of the file.
+func (c *Config) validateSetting(s Setting) error {
+	next := *c
+	next.normalize()
+	err := validateConfig(&next)
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+	for _, fe := range invalid {
+		if fe.Field() == s.Env {
+			return errors.New(describeError(fe))
+		}
+	}
+	return nil
+}
+
+// applyEnv overrides settings with the environment variables that are set to a non-empty value.
+func (c *Config) applyEnv() error {
+	for _, s := range settings {
+		value := os.Getenv(s.Env)
+		if value == "" {
+			continue
+		}
+		if err := c.SetValue(s, value); err != nil {
+			return fmt.Errorf("%s: %w", s.Env, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyOverrides(nameValues []string) error {
+	if len(nameValues)%2 != 0 {
+		return errors.New("overrides must be name/value pairs")
+	}
+	for i := 0; i < len(nameValues); i += 2 {
+		s, ok := LookupSetting(nameValues[i])
+		if !ok {
+			return fmt.Errorf("unknown setting '%s'", nameValues[i])
+		}
+		if err := c.SetValue(s, nameValues[i+1]); err != nil {
+			return fmt.Errorf("--%s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Default returns the configuration made of the default value of every setting.
+func Default() *Config {
+	var c Config
+	// parsing against an empty environment only applies the envDefault tags, which are known to be valid.
+	if err := env.ParseWithOptions(&c, env.Options{Environment: map[string]string{}}); err != nil {
+		panic(err)
+	}
+	return &c
+}
+
+// logLevelAliases maps the Redis log levels to their slog equivalent.
+var logLevelAliases = map[string]string{
+	"VERBOSE": "DEBUG",
+	"NOTICE":  "INFO",
+	"WARNING": "WARN",
+}
+
+const (
+	defaultDatabases  = 16
+	defaultDir        = "."
+	defaultDBFilename = "dump.rdb"
+)
+
+// normalize canonicalizes case-insensitive settings.
+func (c *Config) normalize() {
+	c.Host = bindAddress(c.Host)
+
+	c.LogLevel = strings.ToUpper(c.LogLevel)
+	if alias, ok := logLevelAliases[c.LogLevel]; ok {
+		c.LogLevel = alias
+	}
+
+	c.MaxMemoryPolicy = strings.ToLower(c.MaxMemoryPolicy)
+	if c.MaxMemoryPolicy == "" {
+		c.MaxMemoryPolicy = "noeviction"
+	}
+
+	if classes, err := pubsub.ParseEventClasses(c.NotifyKeyspaceEvents); err == nil {
+		c.NotifyKeyspaceEvents = classes.String()
+	}
+
+	if c.Databases == 0 {
+		c.Databases = defaultDatabases
+	}
+	if c.Dir == "" {
+		c.Dir = defaultDir
+	}
+	if c.DBFilename == "" {
+		c.DBFilename = defaultDBFilename
+	}
+}
+
+// bindAddress returns the address the server listens on out of a bind setting, which like Redis may list several
+// addresses, each optionally prefixed with "-". Only the first one is used.
+func bindAddress(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return value
+	}
+	return strings.TrimPrefix(fields[0], "-")
 }
 
 func validateConfig(c *Config) error {
@@ -40,5 +295,77 @@ func validateConfig(c *Config) error {
 		return name
 	})
 
+	if err := v.RegisterValidation("saverules", validateSaveRules); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("keyspaceevents", validateKeyspaceEvents); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("replicaof", validateReplicaOf); err != nil {
+		return err
+	}
+
 	return v.Struct(c)
 }
+
+// validateSaveRules checks that the field is empty or made of "<seconds> <changes>" pairs of positive integers.
+func validateSaveRules(fl validator.FieldLevel) bool {
+	_, err := ParseSaveRules(fl.Field().String())
+	return err == nil
+}
+
+// validateKeyspaceEvents checks that the field is a valid notify-keyspace-events flag string.
+func validateKeyspaceEvents(fl validator.FieldLevel) bool {
+	_, err := pubsub.ParseEventClasses(fl.Field().String())
+	return err == nil
+}
+
+// validateReplicaOf checks that the field is empty or a "<host> <port>" pair.
+func validateReplicaOf(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if value == "" {
+		return true
+	}
+	_, _, err := ParseReplicaOf(value)
+	return err == nil
+}
+
+// SaveRule asks for a snapshot once the dataset has changed Changes times within Seconds of the previous one.
+type SaveRule struct {
+	Seconds int
+	Changes int
+}
+
+// ParseSaveRules parses a save setting, made of "<seconds> <changes>" pairs of positive integers.
+func ParseSaveRules(value string) ([]SaveRule, error) {
+	fields := strings.Fields(value)
+	if len(fields)%2 != 0 {
+		return nil, errors.New("save rules must be \"<seconds> <changes>\" pairs")
+	}
+	rules := make([]SaveRule, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		seconds, err := strconv.Atoi(fields[i])
+		if err != nil || seconds < 1 {
+			return nil, errors.New("invalid save seconds")
+		}
+		changes, err := strconv.Atoi(fields[i+1])
+		if err != nil || changes < 1 {
+			return nil, errors.New("invalid save changes")
+		}
+		rules = append(rules, SaveRule{Seconds: seconds, Changes: changes})
+	}
+	return rules, nil
+}
+
+// ParseReplicaOf splits a replicaof setting into the host and the port of the master.
+func ParseReplicaOf(value string) (string, int, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return "", 0, errors.New("replicaof must be \"<host> <port>\"")
+	}
+	port, err := strconv.Atoi(fields[1])
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, errors.New("invalid master port")
+	}
+	return fields[0], port, nil
+}
@@ -249,6 +249,15 @@ func (s *ConfigTestSuite) TestValidateConfigWithInvalidInput() {
 				LogLevel: "INFO",
 			},
 		},
+		{
+			name: "invalid metrics address",
+			config: &Config{
+				Host:        "localhost",
+				Port:        8080,
+				LogLevel:    "INFO",
+				MetricsAddr: "9121",
+			},
+		},
 		{
 			name: "invalid log level",
 			config: &Config{
@@ -257,6 +266,15 @@ func (s *ConfigTestSuite) TestValidateConfigWithInvalidInput() {
 				LogLevel: "INVALID",
 			},
 		},
+		{
+			name: "invalid replicaof",
+			config: &Config{
+				Host:      "localhost",
+				Port:      8080,
+				LogLevel:  "INFO",
+				ReplicaOf: "localhost",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -325,3 +343,18 @@ func TestValidateConfig_Standalone(t *testing.T) {
 	err = validateConfig(invalidConfig)
 	require.Error(t, err)
 }
+
+func TestParseSaveRules(t *testing.T) {
+	rules, err := ParseSaveRules("3600 1 300 100")
+	require.NoError(t, err)
+	require.Equal(t, []SaveRule{{Seconds: 3600, Changes: 1}, {Seconds: 300, Changes: 100}}, rules)
+
+	rules, err = ParseSaveRules("")
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	for _, value := range []string{"3600", "0 1", "60 -1", "sixty 1"} {
+		_, err := ParseSaveRules(value)
+		require.Error(t, err, value)
+	}
+}
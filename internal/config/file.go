@@ -0,0 +1,201 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth guards against include cycles.
+const maxIncludeDepth = 16
+
+// Directive is one "name arg [arg ...]" line of a configuration file.
+type Directive struct {
+	Name string
+	Args []string
+
+	// location of the directive, for error messages
+	File string
+	Line int
+}
+
+func (d Directive) String() string {
+	return fmt.Sprintf("%s:%d: '%s'", d.File, d.Line, strings.Join(append([]string{d.Name}, d.Args...), " "))
+}
+
+// ParseFile reads the directives of a redis.conf-style configuration file.
+//
+// blank lines and lines starting with # are ignored, arguments can be quoted like in redis.conf,
+// and "include path" directives are replaced by the directives of the included file.
+// relative include paths are resolved against the directory of the including file.
+func ParseFile(path string) ([]Directive, error) {
+	return parseFile(path, 0)
+}
+
+func parseFile(path string, depth int) ([]Directive, error) {
+	if depth > maxIncludeDepth {
+		return nil, fmt.Errorf("%s: too many nested includes", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var directives []Directive
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := SplitArgs(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		d := Directive{
+			Name: strings.ToLower(args[0]),
+			Args: args[1:],
+			File: path,
+			Line: lineNumber,
+		}
+
+		if d.Name != "include" {
+			directives = append(directives, d)
+			continue
+		}
+
+		if len(d.Args) != 1 {
+			return nil, fmt.Errorf("%s: include expects exactly one file", d)
+		}
+		included := d.Args[0]
+		if !filepath.IsAbs(included) {
+			included = filepath.Join(filepath.Dir(path), included)
+		}
+		nested, err := parseFile(included, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d, err)
+		}
+		directives = append(directives, nested...)
+	}
+
+	return directives, scanner.Err()
+}
+
+// SplitArgs splits a line into arguments the way redis.conf does:
+// arguments are separated by spaces, "double quotes" support escapes such as \n and \x41,
+// and 'single quotes' only support \'.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var arg strings.Builder
+		switch line[i] {
+		case '"':
+			end, err := readDoubleQuoted(line, i+1, &arg)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case '\'':
+			end, err := readSingleQuoted(line, i+1, &arg)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		default:
+			for i < len(line) && !isSpace(line[i]) {
+				arg.WriteByte(line[i])
+				i++
+			}
+		}
+		args = append(args, arg.String())
+	}
+}
+
+// readDoubleQuoted reads a double-quoted argument starting after the opening quote,
+// and returns the position following the closing quote.
+func readDoubleQuoted(line string, i int, arg *strings.Builder) (int, error) {
+	for ; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHexDigit(line[i+2]) && isHexDigit(line[i+3]):
+			b, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+			arg.WriteByte(byte(b))
+			i += 3
+		case c == '\\' && i+1 < len(line):
+			i++
+			switch line[i] {
+			case 'n':
+				arg.WriteByte('\n')
+			case 'r':
+				arg.WriteByte('\r')
+			case 't':
+				arg.WriteByte('\t')
+			case 'b':
+				arg.WriteByte('\b')
+			case 'a':
+				arg.WriteByte('\a')
+			default:
+				arg.WriteByte(line[i])
+			}
+		case c == '"':
+			return closeQuote(line, i)
+		default:
+			arg.WriteByte(c)
+		}
+	}
+	return 0, errors.New("unbalanced quotes")
+}
+
+// readSingleQuoted reads a single-quoted argument starting after the opening quote,
+// and returns the position following the closing quote.
+func readSingleQuoted(line string, i int, arg *strings.Builder) (int, error) {
+	for ; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '\'':
+			arg.WriteByte('\'')
+			i++
+		case c == '\'':
+			return closeQuote(line, i)
+		default:
+			arg.WriteByte(c)
+		}
+	}
+	return 0, errors.New("unbalanced quotes")
+}
+
+// closeQuote checks that the closing quote at i ends the argument.
+func closeQuote(line string, i int) (int, error) {
+	if i+1 < len(line) && !isSpace(line[i+1]) {
+		return 0, errors.New("closing quote must be followed by a space")
+	}
+	return i + 1, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
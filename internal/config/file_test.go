@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSplitArgs(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected []string
+	}{
+		{"port 6379", []string{"port", "6379"}},
+		{"  save   3600 1\t300 100  ", []string{"save", "3600", "1", "300", "100"}},
+		{`save ""`, []string{"save", ""}},
+		{`logfile "/var/log/my redis.log"`, []string{"logfile", "/var/log/my redis.log"}},
+		{`name "a\"b\\c\n\x41"`, []string{"name", "a\"b\\c\nA"}},
+		{`name 'it\'s \n raw'`, []string{"name", `it's \n raw`}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			args, err := SplitArgs(tc.line)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, args)
+		})
+	}
+
+	for _, invalid := range []string{`name "unterminated`, `name 'unterminated`, `name "a"b`} {
+		t.Run(invalid, func(t *testing.T) {
+			_, err := SplitArgs(invalid)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	for _, value := range []string{"", "plain", "with space", `quote"and\backslash`, "new\nline", "\x01binary"} {
+		args, err := SplitArgs("name " + quote(value))
+		require.NoError(t, err)
+		require.Equal(t, []string{"name", value}, args)
+	}
+}
+
+func TestParseFileInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "common.conf"), "timeout 30\n")
+	writeFile(t, filepath.Join(dir, "gvalkey.conf"), "# comment\n\nport 7000\ninclude common.conf\n")
+
+	directives, err := ParseFile(filepath.Join(dir, "gvalkey.conf"))
+	require.NoError(t, err)
+	require.Len(t, directives, 2)
+	require.Equal(t, "port", directives[0].Name)
+	require.Equal(t, []string{"7000"}, directives[0].Args)
+	require.Equal(t, 3, directives[0].Line)
+	require.Equal(t, "timeout", directives[1].Name)
+	require.Equal(t, filepath.Join(dir, "common.conf"), directives[1].File)
+}
+
+func TestParseFileIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.conf"), "include b.conf\n")
+	writeFile(t, filepath.Join(dir, "b.conf"), "include a.conf\n")
+
+	_, err := ParseFile(filepath.Join(dir, "a.conf"))
+	require.ErrorContains(t, err, "too many nested includes")
+}
+
+// LoadFileTestSuite tests loading configuration files layered with environment variables
+type LoadFileTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (s *LoadFileTestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	for _, setting := range settings {
+		os.Unsetenv(setting.Env)
+	}
+}
+
+func (s *LoadFileTestSuite) TearDownTest() {
+	for _, setting := range settings {
+		os.Unsetenv(setting.Env)
+	}
+}
+
+// TestFileOverridesDefaults tests that file directives replace default values
+func (s *LoadFileTestSuite) TestFileOverridesDefaults() {
+	path := s.write("port 7000\nloglevel notice\nmaxmemory 100mb\nsave 3600 1\nsave 300 100\n")
+
+	config, err := Load(WithFile(path))
+
+	s.Require().NoError(err)
+	s.Equal(7000, config.Port)
+	s.Equal("INFO", config.LogLevel, "Redis log levels should be mapped")
+	s.Equal(Bytes(100<<20), config.MaxMemory)
+	s.Equal("3600 1 300 100", config.Save, "Save directives should add up")
+	s.Equal("0.0.0.0", config.Host, "Settings missing from the file keep their default")
+}
+
+// TestEnvOverridesFile tests that environment variables take precedence over the file
+func (s *LoadFileTestSuite) TestEnvOverridesFile() {
+	path := s.write("port 7000\ntimeout 10\n")
+	os.Setenv("GVK_PORT", "8000")
+
+	config, err := Load(WithFile(path))
+
+	s.Require().NoError(err)
+	s.Equal(8000, config.Port)
+	s.Equal(10, config.Timeout)
+}
+
+// TestBindList tests that the bind directive of a stock redis.conf listing several addresses is accepted
+func (s *LoadFileTestSuite) TestBindList() {
+	config, err := Load(WithFile(s.write("bind 127.0.0.1 -::1\n")))
+
+	s.Require().NoError(err)
+	s.Equal("127.0.0.1", config.Host, "The first address should be used")
+
+	config, err = Load(WithFile(s.write("bind -::1 127.0.0.1\n")))
+
+	s.Require().NoError(err)
+	s.Equal("::1", config.Host, "The optional '-' prefix should be stripped")
+}
+
+// TestUnknownDirectives tests that unsupported Redis directives are reported but do not fail loading
+func (s *LoadFileTestSuite) TestUnknownDirectives() {
+	path := s.write("appendonly no\nport 7000\n")
+
+	var unknown []string
+	config, err := Load(WithFile(path), WithUnknownDirectiveHandler(func(d Directive) {
+		unknown = append(unknown, d.Name)
+	}))
+
+	s.Require().NoError(err)
+	s.Equal(7000, config.Port)
+	s.Equal([]string{"appendonly"}, unknown)
+}
+
+// TestInvalidFile tests that invalid values are rejected with their location
+func (s *LoadFileTestSuite) TestInvalidFile() {
+	_, err := Load(WithFile(s.write("port abc\n")))
+	s.Require().ErrorContains(err, "gvalkey.conf:1")
+
+	_, err = Load(WithFile(s.write("port 70000\n")))
+	s.Require().ErrorContains(err, "gvalkey.conf:1: 'port 70000'", "Values should be validated at their line")
+
+	_, err = Load(WithFile(s.write("port 7000\nbind not_a_host!\n")))
+	s.Require().ErrorContains(err, "gvalkey.conf:2: 'bind not_a_host!'")
+	s.NotContains(err.Error(), "GVK_HOST")
+
+	_, err = Load(WithFile(s.write("port\n")))
+	s.Require().ErrorContains(err, "wrong number of arguments")
+
+	_, err = Load(WithFile(filepath.Join(s.dir, "missing.conf")))
+	s.Require().Error(err)
+}
+
+func (s *LoadFileTestSuite) write(content string) string {
+	path := filepath.Join(s.dir, "gvalkey.conf")
+	writeFile(s.T(), path, content)
+	return path
+}
+
+func TestLoadFileSuite(t *testing.T) {
+	suite.Run(t, new(LoadFileTestSuite))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// CommandLine holds the parsed arguments of the server.
+type CommandLine struct {
+	// path of the configuration file, given as the first argument like redis-server
+	ConfigFile string
+	// name/value pairs of the settings given as flags, to be passed to WithOverrides
+	Overrides []string
+
+	// print the version and exit
+	Version bool
+	// validate the configuration and exit
+	TestConfig bool
+}
+
+// ParseCommandLine parses "[configfile] [--setting value ...]" arguments.
+//
+// every setting is available as a flag named after its directive, e.g. --port 6380.
+// usage and errors are written to output, and flag.ErrHelp is returned when --help is requested.
+func ParseCommandLine(program string, args []string, output io.Writer) (*CommandLine, error) {
+	cl := &CommandLine{}
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cl.ConfigFile = args[0]
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.BoolVar(&cl.Version, "version", false, "Print the version and exit")
+	fs.BoolVar(&cl.TestConfig, "test-config", false, "Check the configuration and exit")
+
+	for _, s := range settings {
+		fs.Func(s.Name, s.Usage, func(value string) error {
+			cl.Overrides = append(cl.Overrides, s.Name, value)
+			return nil
+		})
+	}
+
+	fs.Usage = func() {
+		printUsage(fs, program)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected argument %q, the configuration file must come first", fs.Arg(0))
+		fmt.Fprintln(output, err)
+		return nil, err
+	}
+
+	return cl, nil
+}
+
+func printUsage(fs *flag.FlagSet, program string) {
+	out := fs.Output()
+
+	fmt.Fprintf(out, "Usage: %s [/path/to/gvalkey.conf] [options]\n\n", program)
+	fmt.Fprintf(out, "Settings are applied in increasing order of precedence: defaults, configuration file, environment variables, options.\n\n")
+
+	fmt.Fprintln(out, "Settings:")
+	for _, s := range settings {
+		fmt.Fprintf(out, "  --%s <%s>\n", s.Name, valuePlaceholder(s))
+		fmt.Fprintf(out, "        %s\n", s.Usage)
+
+		details := []string{"env " + s.Env}
+		if s.Default != "" {
+			details = append(details, "default "+s.Default)
+		}
+		if s.Mutable {
+			details = append(details, "changeable with CONFIG SET")
+		}
+		fmt.Fprintf(out, "        (%s)\n", strings.Join(details, ", "))
+	}
+
+	fmt.Fprintln(out, "\nOptions:")
+	for _, name := range []string{"version", "test-config"} {
+		fmt.Fprintf(out, "  --%s\n        %s\n", name, fs.Lookup(name).Usage)
+	}
+	fmt.Fprintf(out, "  --help\n        Print this help and exit\n")
+}
+
+// valuePlaceholder describes the kind of value a setting expects.
+func valuePlaceholder(s Setting) string {
+	switch {
+	case s.typ == reflect.TypeFor[Bytes]():
+		return "bytes"
+	case s.typ.Kind() == reflect.Int:
+		return "number"
+	case s.typ.Kind() == reflect.Bool:
+		return "yes|no"
+	default:
+		return "value"
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	t.Run("Config file and settings", func(t *testing.T) {
+		cl, err := ParseCommandLine("gvalkey", []string{"/etc/gvalkey.conf", "--port", "7000", "--maxmemory-policy=allkeys-random"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Equal(t, "/etc/gvalkey.conf", cl.ConfigFile)
+		require.Equal(t, []string{"port", "7000", "maxmemory-policy", "allkeys-random"}, cl.Overrides)
+		require.False(t, cl.Version)
+		require.False(t, cl.TestConfig)
+	})
+
+	t.Run("Without config file", func(t *testing.T) {
+		cl, err := ParseCommandLine("gvalkey", []string{"--version", "--test-config"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Empty(t, cl.ConfigFile)
+		require.True(t, cl.Version)
+		require.True(t, cl.TestConfig)
+	})
+
+	t.Run("Help lists every setting", func(t *testing.T) {
+		var out bytes.Buffer
+		_, err := ParseCommandLine("gvalkey", []string{"--help"}, &out)
+		require.ErrorIs(t, err, flag.ErrHelp)
+		for _, s := range Settings() {
+			require.Contains(t, out.String(), "--"+s.Name)
+			require.Contains(t, out.String(), s.Usage)
+		}
+	})
+
+	t.Run("Unknown flag", func(t *testing.T) {
+		_, err := ParseCommandLine("gvalkey", []string{"--unknown", "1"}, &bytes.Buffer{})
+		require.Error(t, err)
+	})
+
+	t.Run("Config file after flags", func(t *testing.T) {
+		_, err := ParseCommandLine("gvalkey", []string{"--port", "7000", "/etc/gvalkey.conf"}, &bytes.Buffer{})
+		require.ErrorContains(t, err, "configuration file must come first")
+	})
+}
+
+func TestOverridesTakePrecedence(t *testing.T) {
+	t.Setenv("GVK_PORT", "8000")
+	t.Setenv("GVK_TIMEOUT", "5")
+
+	config, err := Load(WithOverrides("port", "9000"))
+	require.NoError(t, err)
+	require.Equal(t, 9000, config.Port, "Overrides should win over environment variables")
+	require.Equal(t, 5, config.Timeout)
+
+	_, err = Load(WithOverrides("port", "0"))
+	require.Error(t, err, "Overrides should be validated")
+}
+
+func TestWithoutEnv(t *testing.T) {
+	t.Setenv("GVK_PORT", "8000")
+	t.Setenv("GVK_DATABASES", "4")
+
+	config, err := Load(WithoutEnv(), WithOverrides("port", "9000"))
+	require.NoError(t, err)
+	require.Equal(t, 9000, config.Port)
+	require.Equal(t, 16, config.Databases, "Environment variables should be ignored")
+}
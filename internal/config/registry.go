@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/PlayerNeo42/gvalkey/internal/glob"
+	"github.com/go-playground/validator/v10"
+)
+
+// Setting describes a configuration directive declared by a Config field.
+type Setting struct {
+	// directive name, as used by CONFIG GET/SET and configuration files
+	Name string
+	// environment variable the setting is loaded from
+	Env string
+	// default value, as found in the envDefault tag
+	Default string
+	// description shown by --help
+	Usage string
+	// whether CONFIG SET can change the setting at runtime
+	Mutable bool
+	// whether repeated directives in a configuration file add up
+	Append bool
+
+	field int
+	typ   reflect.Type
+}
+
+// settings lists the directives in the order the Config fields are declared.
+var settings = parseSettings()
+
+func parseSettings() []Setting {
+	t := reflect.TypeFor[Config]()
+
+	result := make([]Setting, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("conf")
+		if !ok {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		optionList := strings.Split(options, ",")
+
+		result = append(result, Setting{
+			Name:    name,
+			Env:     field.Tag.Get("env"),
+			Default: field.Tag.Get("envDefault"),
+			Usage:   field.Tag.Get("usage"),
+			Mutable: slices.Contains(optionList, "mutable"),
+			Append:  slices.Contains(optionList, "append"),
+			field:   i,
+			typ:     field.Type,
+		})
+	}
+	return result
+}
+
+// Settings returns every known directive.
+func Settings() []Setting {
+	return settings
+}
+
+// LookupSetting finds a directive by its case-insensitive name.
+func LookupSetting(name string) (Setting, bool) {
+	for _, s := range settings {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Value formats the setting of c the way CONFIG GET reports it.
+func (c *Config) Value(s Setting) string {
+	v := reflect.ValueOf(c).Elem().Field(s.field)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "yes"
+		}
+		return "no"
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return v.String()
+	}
+}
+
+// SetValue parses value into the setting of c, without validating it.
+func (c *Config) SetValue(s Setting, value string) error {
+	v := reflect.ValueOf(c).Elem().Field(s.field)
+
+	if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "yes", "true":
+			v.SetBool(true)
+		case "no", "false":
+			v.SetBool(false)
+		default:
+			return errors.New("argument must be 'yes' or 'no'")
+		}
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("argument couldn't be parsed into an integer")
+		}
+		v.SetInt(n)
+	case reflect.String:
+		v.SetString(value)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// Registry holds the live configuration of a running server.
+//
+// readers get an immutable snapshot without locking, CONFIG SET replaces the snapshot as a whole.
+type Registry struct {
+	mu      sync.Mutex // serializes writers
+	current atomic.Pointer[Config]
+	file    string
+	hooks   map[string][]func(*Config)
+}
+
+// NewRegistry creates a registry serving c. file is the configuration file used by Rewrite, it may be empty.
+func NewRegistry(c *Config, file string) *Registry {
+	r := &Registry{
+		file:  file,
+		hooks: make(map[string][]func(*Config)),
+	}
+	snapshot := *c
+	r.current.Store(&snapshot)
+	return r
+}
+
+// Config returns the current configuration, callers must not modify it.
+func (r *Registry) Config() *Config {
+	return r.current.Load()
+}
+
+// File returns the path of the configuration file, or an empty string when there is none.
+func (r *Registry) File() string {
+	return r.file
+}
+
+// OnChange registers fn to be called with the new configuration each time the named setting changes.
+func (r *Registry) OnChange(name string, fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hooks[name] = append(r.hooks[name], fn)
+}
+
+// Get returns the name and value of every setting matching the case-insensitive glob pattern, flattened into one slice.
+func (r *Registry) Get(pattern string) []string {
+	c := r.Config()
+
+	var result []string
+	for _, s := range settings {
+		if glob.MatchFold(pattern, s.Name) {
+			result = append(result, s.Name, c.Value(s))
+		}
+	}
+	return result
+}
+
+// Set applies name/value pairs atomically: either all of them are applied or none is.
+func (r *Registry) Set(nameValues ...string) error {
+	if len(nameValues) == 0 || len(nameValues)%2 != 0 {
+		return errors.New("wrong number of arguments")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := *r.Config()
+	changed := make([]Setting, 0, len(nameValues)/2)
+	for i := 0; i < len(nameValues); i += 2 {
+		name, value := nameValues[i], nameValues[i+1]
+
+		s, ok := LookupSetting(name)
+		if !ok {
+			return fmt.Errorf("unknown option or number of args for CONFIG SET - '%s'", name)
+		}
+		if !s.Mutable {
+			return fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - can't set immutable config", s.Name)
+		}
+		if err := next.SetValue(s, value); err != nil {
+			return fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - %w", s.Name, err)
+		}
+		if !slices.Contains(changed, s) {
+			changed = append(changed, s)
+		}
+	}
+
+	next.normalize()
+
+	if err := validateConfig(&next); err != nil {
+		var invalid validator.ValidationErrors
+		if errors.As(err, &invalid) {
+			return fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - %s", settingOfError(invalid[0]), describeError(invalid[0]))
+		}
+		return fmt.Errorf("CONFIG SET failed - %w", err)
+	}
+
+	r.current.Store(&next)
+
+	for _, s := range changed {
+		for _, hook := range r.hooks[s.Name] {
+			hook(&next)
+		}
+	}
+	return nil
+}
+
+// settingOfError returns the directive name of the field that failed validation.
+func settingOfError(fe validator.FieldError) string {
+	for _, s := range settings {
+		if s.Env == fe.Field() {
+			return s.Name
+		}
+	}
+	return fe.Field()
+}
+
+// describeError turns a validation failure into a short human-readable reason.
+func describeError(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "argument must not be empty"
+	case "oneof":
+		return "argument must be one of " + fe.Param()
+	case "min", "gte":
+		return "argument must be at least " + fe.Param()
+	case "max", "lte":
+		return "argument must be at most " + fe.Param()
+	case "hostname|ip":
+		return "argument must be a hostname or an IP address"
+	case "keyspaceevents":
+		return "invalid event class character"
+	default:
+		return fmt.Sprintf("argument failed the '%s' validation", fe.Tag())
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T, file string) *Registry {
+	t.Helper()
+	c := Default()
+	c.normalize()
+	return NewRegistry(c, file)
+}
+
+func TestRegistryGet(t *testing.T) {
+	r := newTestRegistry(t, "")
+
+	require.Equal(t, []string{"port", "6379"}, r.Get("port"))
+	require.Equal(t, []string{"maxmemory", "0", "maxmemory-policy", "noeviction"}, r.Get("MAXMEMORY*"))
+	require.Empty(t, r.Get("nonexistent"))
+}
+
+func TestRegistrySet(t *testing.T) {
+	t.Run("Mutable settings", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		require.NoError(t, r.Set("maxmemory", "100mb", "loglevel", "debug", "timeout", "30"))
+		require.Equal(t, Bytes(100<<20), r.Config().MaxMemory)
+		require.Equal(t, "DEBUG", r.Config().LogLevel)
+		require.Equal(t, 30, r.Config().Timeout)
+	})
+
+	t.Run("Redis log level aliases", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		require.NoError(t, r.Set("loglevel", "warning"))
+		require.Equal(t, "WARN", r.Config().LogLevel)
+	})
+
+	t.Run("Immutable setting", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		err := r.Set("port", "6380")
+		require.ErrorContains(t, err, "can't set immutable config")
+		require.Equal(t, 6379, r.Config().Port)
+	})
+
+	t.Run("Unknown setting", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		require.ErrorContains(t, r.Set("nonexistent", "1"), "unknown option")
+	})
+
+	t.Run("Invalid value fails validation", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		err := r.Set("maxmemory-policy", "allkeys-lru")
+		require.ErrorContains(t, err, "maxmemory-policy")
+		require.Equal(t, "noeviction", r.Config().MaxMemoryPolicy)
+
+		require.ErrorContains(t, r.Set("timeout", "-1"), "timeout")
+		require.ErrorContains(t, r.Set("save", "3600"), "save")
+		require.ErrorContains(t, r.Set("maxmemory", "lots"), "memory value")
+	})
+
+	t.Run("Keyspace event flags are canonicalized", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		require.NoError(t, r.Set("notify-keyspace-events", "EKg$lshzxetd"))
+		require.Equal(t, "AKE", r.Config().NotifyKeyspaceEvents)
+		require.ErrorContains(t, r.Set("notify-keyspace-events", "KQ"), "invalid event class")
+	})
+
+	t.Run("Settings are applied atomically", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		require.Error(t, r.Set("timeout", "10", "maxmemory-policy", "invalid"))
+		require.Equal(t, 0, r.Config().Timeout, "No setting should change when one of them is invalid")
+	})
+
+	t.Run("Change hooks", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		var levels []string
+		r.OnChange("loglevel", func(c *Config) {
+			levels = append(levels, c.LogLevel)
+		})
+
+		require.NoError(t, r.Set("timeout", "10"))
+		require.NoError(t, r.Set("loglevel", "error"))
+		require.Equal(t, []string{"ERROR"}, levels)
+	})
+}
+
+func TestRegistryRewrite(t *testing.T) {
+	t.Run("Without config file", func(t *testing.T) {
+		r := newTestRegistry(t, "")
+
+		require.ErrorIs(t, r.Rewrite(), ErrNoConfigFile)
+	})
+
+	t.Run("Updates known directives and keeps the rest", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "gvalkey.conf")
+		original := "# my settings\nport 6379\nunknown-directive value\ntimeout 5\ntimeout 6\n"
+		require.NoError(t, os.WriteFile(file, []byte(original), 0o600))
+
+		r := newTestRegistry(t, file)
+		require.NoError(t, r.Set("timeout", "60", "save", "3600 1 300 100"))
+		require.NoError(t, r.Rewrite())
+
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		expected := "# my settings\nport 6379\nunknown-directive value\ntimeout 60\nsave \"3600 1 300 100\"\n"
+		require.Equal(t, expected, string(content))
+	})
+}
+
+func TestParseBytes(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Bytes
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"1k", 1000},
+		{"1kb", 1024},
+		{"100MB", 100 << 20},
+		{"2g", 2 * 1000 * 1000 * 1000},
+		{"2gb", 2 << 30},
+		{"5b", 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			n, err := ParseBytes(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, n)
+		})
+	}
+
+	for _, invalid := range []string{"", "abc", "-1", "1tb"} {
+		t.Run(invalid, func(t *testing.T) {
+			_, err := ParseBytes(invalid)
+			require.Error(t, err)
+		})
+	}
+}
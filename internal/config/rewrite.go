@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoConfigFile is returned by Rewrite when the server was started without a configuration file.
+var ErrNoConfigFile = errors.New("the server is running without a config file")
+
+// Rewrite persists the current configuration to the configuration file.
+//
+// like Redis, the existing file is kept as is except for the lines of known directives, which are updated in place.
+// settings missing from the file are appended only when they differ from their default value.
+func (r *Registry) Rewrite() error {
+	if r.file == "" {
+		return ErrNoConfigFile
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	original, err := os.ReadFile(r.file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	content := rewriteConfig(original, r.Config())
+
+	// write to a temporary file first so that a failure never leaves a truncated configuration behind
+	tmp, err := os.CreateTemp(filepath.Dir(r.file), ".gvalkey-rewrite-*.conf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.file)
+}
+
+func rewriteConfig(original []byte, c *Config) []byte {
+	var out bytes.Buffer
+	written := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(original))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			out.WriteString(line)
+			out.WriteByte('\n')
+			continue
+		}
+
+		s, ok := LookupSetting(fields[0])
+		if !ok {
+			// unknown directives are preserved
+			out.WriteString(line)
+			out.WriteByte('\n')
+			continue
+		}
+
+		// only the first occurrence of a directive is kept
+		if written[s.Name] {
+			continue
+		}
+		written[s.Name] = true
+		writeDirective(&out, s, c.Value(s))
+	}
+
+	defaults := Default()
+	defaults.normalize()
+	for _, s := range settings {
+		if written[s.Name] || c.Value(s) == defaults.Value(s) {
+			continue
+		}
+		writeDirective(&out, s, c.Value(s))
+	}
+
+	return out.Bytes()
+}
+
+func writeDirective(out *bytes.Buffer, s Setting, value string) {
+	fmt.Fprintf(out, "%s %s\n", s.Name, quote(value))
+}
+
+// quote wraps value in double quotes when it could not be read back as a single argument.
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"'\\") && isPrintable(value) {
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := range len(value) {
+		c := value[i]
+		switch c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < ' ' || c > '~' {
+				fmt.Fprintf(&b, `\x%02x`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func isPrintable(value string) bool {
+	for i := range len(value) {
+		if value[i] < ' ' || value[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package geo
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// positions and values from the GEO examples of the Redis documentation
+const (
+	palermoLon, palermoLat = 13.361389, 38.115556
+	cataniaLon, cataniaLat = 15.087269, 37.502669
+)
+
+func TestEncodeDecode(t *testing.T) {
+	bits := Encode(palermoLon, palermoLat)
+	require.Equal(t, uint64(3479099956230698), bits)
+
+	lon, lat := Decode(bits)
+	require.InDelta(t, 13.36138933897018433, lon, 1e-12)
+	require.InDelta(t, 38.11555639549629859, lat, 1e-12)
+
+	require.Equal(t, "sqc8b49rny0", String(bits))
+	require.Equal(t, "sqdtr74hyu0", String(Encode(cataniaLon, cataniaLat)))
+}
+
+func TestInterleave(t *testing.T) {
+	for range 1000 {
+		x, y := rand.Uint32(), rand.Uint32()
+		gotX, gotY := deinterleave(interleave(x, y))
+		require.Equal(t, x, gotX)
+		require.Equal(t, y, gotY)
+	}
+}
+
+func TestValid(t *testing.T) {
+	require.True(t, Valid(180, 85.05112878))
+	require.False(t, Valid(180.1, 0))
+	require.False(t, Valid(0, 86))
+}
+
+func TestDistance(t *testing.T) {
+	// GEODIST measures between the stored positions, which are the centers of their geohash areas
+	lon1, lat1 := Decode(Encode(palermoLon, palermoLat))
+	lon2, lat2 := Decode(Encode(cataniaLon, cataniaLat))
+	require.InDelta(t, 166274.1516, Distance(lon1, lat1, lon2, lat2), 1e-4)
+	require.InDelta(t, 0, Distance(palermoLon, palermoLat, palermoLon, palermoLat), 1e-9)
+	// same meridian
+	require.InDelta(t, EarthRadius*degToRad(1), Distance(10, 1, 10, 2), 1e-6)
+}
+
+func TestShapeContains(t *testing.T) {
+	circle := Shape{Lon: 15, Lat: 37, Radius: 200000}
+	dist, ok := circle.Contains(cataniaLon, cataniaLat)
+	require.True(t, ok)
+	require.InDelta(t, 56441.3, dist, 1)
+	_, ok = (Shape{Lon: 15, Lat: 37, Radius: 100000}).Contains(palermoLon, palermoLat)
+	require.False(t, ok)
+
+	box := Shape{Lon: 15, Lat: 37, Box: true, Width: 400000, Height: 400000}
+	_, ok = box.Contains(palermoLon, palermoLat)
+	require.True(t, ok)
+	// Palermo is about 120km north and 150km west of the center
+	_, ok = (Shape{Lon: 15, Lat: 37, Box: true, Width: 400000, Height: 200000}).Contains(palermoLon, palermoLat)
+	require.False(t, ok)
+	_, ok = (Shape{Lon: 15, Lat: 37, Box: true, Width: 200000, Height: 400000}).Contains(palermoLon, palermoLat)
+	require.False(t, ok)
+}
+
+// TestRangesCoverShape checks that every position within a shape falls in one of its ranges.
+func TestRangesCoverShape(t *testing.T) {
+	shapes := []Shape{
+		{Lon: 15, Lat: 37, Radius: 200000},
+		{Lon: -122.4, Lat: 37.7, Radius: 50},
+		{Lon: 179.9, Lat: 0, Radius: 100000},
+		{Lon: 0, Lat: 80, Radius: 500000},
+		{Lon: 2.35, Lat: 48.85, Box: true, Width: 10000, Height: 3000},
+		{Lon: 0, Lat: 0, Radius: 6000000},
+	}
+	for _, shape := range shapes {
+		ranges := shape.Ranges()
+		require.NotEmpty(t, ranges)
+		for range 2000 {
+			lon := shape.Lon + (rand.Float64()*2-1)*20
+			lat := shape.Lat + (rand.Float64()*2-1)*20
+			if !Valid(lon, lat) {
+				continue
+			}
+			score := float64(Encode(lon, lat))
+			if _, ok := shape.Contains(Decode(Encode(lon, lat))); !ok {
+				continue
+			}
+			covered := false
+			for _, r := range ranges {
+				covered = covered || (score >= r.Min && score < r.Max)
+			}
+			require.True(t, covered, "%+v does not cover %f,%f", shape, lon, lat)
+		}
+	}
+}
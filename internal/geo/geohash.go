@@ -0,0 +1,162 @@
+// Package geo implements the 52 bits geohashes Redis stores as sorted set scores,
+// and the search of the members of a sorted set within a radius or a box.
+//
+// a geohash interleaves the bits of the latitude, on even positions, and of the longitude, on odd positions.
+// each step halves the longitude and latitude ranges, so a hash of step 26 fits in 52 bits, the mantissa of a float64 score.
+package geo
+
+const (
+	// Step is the precision of the stored hashes.
+	Step = 26
+
+	MinLongitude = -180
+	MaxLongitude = 180
+	// latitudes are bounded by the Web Mercator projection, like EPSG:900913
+	MinLatitude = -85.05112878
+	MaxLatitude = 85.05112878
+)
+
+type coordRange struct {
+	min, max float64
+}
+
+var (
+	longitudeRange = coordRange{MinLongitude, MaxLongitude}
+	latitudeRange  = coordRange{MinLatitude, MaxLatitude}
+	// the standard geohash covers every latitude
+	standardLatitudeRange = coordRange{-90, 90}
+)
+
+// hash is a geohash of step bits of longitude and step bits of latitude.
+type hash struct {
+	bits uint64
+	step uint
+}
+
+// Valid reports whether a position can be encoded.
+func Valid(lon, lat float64) bool {
+	return lon >= MinLongitude && lon <= MaxLongitude && lat >= MinLatitude && lat <= MaxLatitude
+}
+
+// Encode returns the 52 bits geohash of a valid position.
+func Encode(lon, lat float64) uint64 {
+	return encode(longitudeRange, latitudeRange, lon, lat, Step).bits
+}
+
+// Decode returns the center of the area of a 52 bits geohash.
+func Decode(bits uint64) (lon, lat float64) {
+	minLon, minLat, maxLon, maxLat := decode(longitudeRange, latitudeRange, hash{bits, Step})
+	lon = min(max((minLon+maxLon)/2, MinLongitude), MaxLongitude)
+	lat = min(max((minLat+maxLat)/2, MinLatitude), MaxLatitude)
+	return lon, lat
+}
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// String returns the 11 characters standard geohash of a 52 bits geohash, as GEOHASH replies.
+// the position is encoded again over the whole latitude range, and the last character, for which 52 bits are not enough, is 0.
+func String(bits uint64) string {
+	lon, lat := Decode(bits)
+	h := encode(longitudeRange, standardLatitudeRange, lon, lat, Step)
+
+	b := make([]byte, 11)
+	for i := range 10 {
+		b[i] = base32[h.bits>>(52-(i+1)*5)&0x1f]
+	}
+	b[10] = base32[0]
+	return string(b)
+}
+
+func encode(lonRange, latRange coordRange, lon, lat float64, step uint) hash {
+	latOffset := (lat - latRange.min) / (latRange.max - latRange.min)
+	lonOffset := (lon - lonRange.min) / (lonRange.max - lonRange.min)
+	latOffset *= float64(uint64(1) << step)
+	lonOffset *= float64(uint64(1) << step)
+	return hash{interleave(uint32(latOffset), uint32(lonOffset)), step}
+}
+
+// decode returns the bounds of the area of h.
+func decode(lonRange, latRange coordRange, h hash) (minLon, minLat, maxLon, maxLat float64) {
+	latBits, lonBits := deinterleave(h.bits)
+	cells := float64(uint64(1) << h.step)
+	latScale := latRange.max - latRange.min
+	lonScale := lonRange.max - lonRange.min
+
+	minLat = latRange.min + float64(latBits)/cells*latScale
+	maxLat = latRange.min + (float64(latBits)+1)/cells*latScale
+	minLon = lonRange.min + float64(lonBits)/cells*lonScale
+	maxLon = lonRange.min + (float64(lonBits)+1)/cells*lonScale
+	return minLon, minLat, maxLon, maxLat
+}
+
+// interleave places the bits of x on the even positions and the bits of y on the odd ones.
+func interleave(x, y uint32) uint64 {
+	return spread(x) | spread(y)<<1
+}
+
+func deinterleave(bits uint64) (x, y uint32) {
+	return squash(bits), squash(bits >> 1)
+}
+
+// spread moves bit i of v to bit 2i.
+func spread(v uint32) uint64 {
+	x := uint64(v)
+	x = (x | x<<16) & 0x0000ffff0000ffff
+	x = (x | x<<8) & 0x00ff00ff00ff00ff
+	x = (x | x<<4) & 0x0f0f0f0f0f0f0f0f
+	x = (x | x<<2) & 0x3333333333333333
+	x = (x | x<<1) & 0x5555555555555555
+	return x
+}
+
+// squash is the inverse of spread, ignoring the odd bits.
+func squash(x uint64) uint32 {
+	x &= 0x5555555555555555
+	x = (x | x>>1) & 0x3333333333333333
+	x = (x | x>>2) & 0x0f0f0f0f0f0f0f0f
+	x = (x | x>>4) & 0x00ff00ff00ff00ff
+	x = (x | x>>8) & 0x0000ffff0000ffff
+	x = (x | x>>16) & 0x00000000ffffffff
+	return uint32(x)
+}
+
+// moveX returns the hash of the area d cells east, or west when d is negative, wrapping around the range.
+func (h hash) moveX(d int) hash {
+	x := h.bits & 0xaaaaaaaaaaaaaaaa
+	y := h.bits & 0x5555555555555555
+	zz := uint64(0x5555555555555555) >> (64 - h.step*2)
+	if d > 0 {
+		x += zz + 1
+	} else {
+		x |= zz
+		x -= zz + 1
+	}
+	x &= 0xaaaaaaaaaaaaaaaa >> (64 - h.step*2)
+	return hash{x | y, h.step}
+}
+
+// moveY returns the hash of the area d cells north, or south when d is negative.
+func (h hash) moveY(d int) hash {
+	x := h.bits & 0xaaaaaaaaaaaaaaaa
+	y := h.bits & 0x5555555555555555
+	zz := uint64(0xaaaaaaaaaaaaaaaa) >> (64 - h.step*2)
+	if d > 0 {
+		y += zz + 1
+	} else {
+		y |= zz
+		y -= zz + 1
+	}
+	y &= 0x5555555555555555 >> (64 - h.step*2)
+	return hash{x | y, h.step}
+}
+
+// scoreRange returns the scores of the 52 bits hashes within the area of h, as [lower, upper).
+func (h hash) scoreRange() (lower, upper uint64) {
+	shift := 52 - h.step*2
+	return h.bits << shift, (h.bits + 1) << shift
+}
+
+// isZero reports whether h was excluded from a search.
+func (h hash) isZero() bool {
+	return h.bits == 0 && h.step == 0
+}
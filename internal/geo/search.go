@@ -0,0 +1,191 @@
+package geo
+
+import (
+	"math"
+)
+
+const (
+	// EarthRadius is the radius of the earth in meters Redis computes distances with.
+	EarthRadius = 6372797.560856
+	// mercatorMax is half the width of the Web Mercator projection in meters.
+	mercatorMax = 20037726.37
+)
+
+func degToRad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func radToDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Distance returns the distance in meters between two positions with the haversine formula.
+func Distance(lon1, lat1, lon2, lat2 float64) float64 {
+	v := math.Sin((degToRad(lon2) - degToRad(lon1)) / 2)
+	// positions on the same meridian only differ by their latitude
+	if v == 0 {
+		return latitudeDistance(lat1, lat2)
+	}
+	lat1r, lat2r := degToRad(lat1), degToRad(lat2)
+	u := math.Sin((lat2r - lat1r) / 2)
+	a := u*u + math.Cos(lat1r)*math.Cos(lat2r)*v*v
+	return 2 * EarthRadius * math.Asin(math.Sqrt(a))
+}
+
+func latitudeDistance(lat1, lat2 float64) float64 {
+	return EarthRadius * math.Abs(degToRad(lat2)-degToRad(lat1))
+}
+
+// Shape is the area of a search, a circle when Box is false, sizes being in meters.
+type Shape struct {
+	Lon, Lat float64
+
+	Radius float64
+
+	Box           bool
+	Width, Height float64
+}
+
+// Contains returns the distance from the center of the shape to a position, ok is false when the position is outside.
+func (s Shape) Contains(lon, lat float64) (dist float64, ok bool) {
+	if !s.Box {
+		dist = Distance(s.Lon, s.Lat, lon, lat)
+		return dist, dist <= s.Radius
+	}
+
+	// the latitude distance is cheaper to compute, so it is checked first
+	if latitudeDistance(s.Lat, lat) > s.Height/2 {
+		return 0, false
+	}
+	if Distance(lon, lat, s.Lon, lat) > s.Width/2 {
+		return 0, false
+	}
+	return Distance(s.Lon, s.Lat, lon, lat), true
+}
+
+// boundingBox returns the bounds of a box containing the shape.
+func (s Shape) boundingBox() (minLon, minLat, maxLon, maxLat float64) {
+	width, height := s.Radius, s.Radius
+	if s.Box {
+		width, height = s.Width/2, s.Height/2
+	}
+
+	latDelta := radToDeg(height / EarthRadius)
+	lonDeltaTop := radToDeg(width / EarthRadius / math.Cos(degToRad(s.Lat+latDelta)))
+	lonDeltaBottom := radToDeg(width / EarthRadius / math.Cos(degToRad(s.Lat-latDelta)))
+	// the box is widest on the side closest to the equator
+	lonDelta := lonDeltaTop
+	if s.Lat < 0 {
+		lonDelta = lonDeltaBottom
+	}
+	return s.Lon - lonDelta, s.Lat - latDelta, s.Lon + lonDelta, s.Lat + latDelta
+}
+
+// estimateStep returns the step of the geohash areas whose size is close to radius at a latitude.
+func estimateStep(radius, lat float64) uint {
+	if radius == 0 {
+		return Step
+	}
+	step := 1
+	for radius < mercatorMax {
+		radius *= 2
+		step++
+	}
+	// makes sure the radius is included in most cases
+	step -= 2
+
+	// areas are narrower near the poles
+	if lat > 66 || lat < -66 {
+		step--
+		if lat > 80 || lat < -80 {
+			step--
+		}
+	}
+	return uint(min(max(step, 1), Step))
+}
+
+// ScoreRange is a range of scores [Min, Max) of a sorted set of geohashes.
+type ScoreRange struct {
+	Min, Max float64
+}
+
+// Ranges returns the score ranges covering the shape, which are the geohash area of its center and the neighboring ones.
+// members within the ranges still have to be checked with Contains.
+func (s Shape) Ranges() []ScoreRange {
+	radius := s.Radius
+	if s.Box {
+		radius = math.Sqrt(s.Width/2*s.Width/2 + s.Height/2*s.Height/2)
+	}
+	minLon, minLat, maxLon, maxLat := s.boundingBox()
+
+	step := estimateStep(radius, s.Lat)
+	center := encode(longitudeRange, latitudeRange, s.Lon, s.Lat, step)
+	n := neighborsOf(center)
+
+	// the step is decreased once when the neighbors do not cover the whole bounding box
+	_, _, _, northMax := decode(longitudeRange, latitudeRange, n.north)
+	_, southMin, _, _ := decode(longitudeRange, latitudeRange, n.south)
+	_, _, eastMax, _ := decode(longitudeRange, latitudeRange, n.east)
+	westMin, _, _, _ := decode(longitudeRange, latitudeRange, n.west)
+	if step > 1 && (northMax < maxLat || southMin > minLat || eastMax < maxLon || westMin > minLon) {
+		step--
+		center = encode(longitudeRange, latitudeRange, s.Lon, s.Lat, step)
+		n = neighborsOf(center)
+	}
+
+	// neighbors entirely outside of the bounding box are excluded
+	if step >= 2 {
+		areaMinLon, areaMinLat, areaMaxLon, areaMaxLat := decode(longitudeRange, latitudeRange, center)
+		if areaMinLat < minLat {
+			n.south, n.southWest, n.southEast = hash{}, hash{}, hash{}
+		}
+		if areaMaxLat > maxLat {
+			n.north, n.northEast, n.northWest = hash{}, hash{}, hash{}
+		}
+		if areaMinLon < minLon {
+			n.west, n.southWest, n.northWest = hash{}, hash{}, hash{}
+		}
+		if areaMaxLon > maxLon {
+			n.east, n.southEast, n.northEast = hash{}, hash{}, hash{}
+		}
+	}
+
+	areas := []hash{center, n.north, n.south, n.east, n.west, n.northEast, n.northWest, n.southEast, n.southWest}
+	ranges := make([]ScoreRange, 0, len(areas))
+	for i, area := range areas {
+		// large radiuses may make neighbors identical
+		if area.isZero() || containsHash(areas[:i], area) {
+			continue
+		}
+		lower, upper := area.scoreRange()
+		ranges = append(ranges, ScoreRange{float64(lower), float64(upper)})
+	}
+	return ranges
+}
+
+func containsHash(hashes []hash, h hash) bool {
+	for _, other := range hashes {
+		if other == h {
+			return true
+		}
+	}
+	return false
+}
+
+type neighbors struct {
+	north, south, east, west                   hash
+	northEast, northWest, southEast, southWest hash
+}
+
+func neighborsOf(h hash) neighbors {
+	return neighbors{
+		north:     h.moveY(1),
+		south:     h.moveY(-1),
+		east:      h.moveX(1),
+		west:      h.moveX(-1),
+		northEast: h.moveX(1).moveY(1),
+		northWest: h.moveX(-1).moveY(1),
+		southEast: h.moveX(1).moveY(-1),
+		southWest: h.moveX(-1).moveY(-1),
+	}
+}
@@ -0,0 +1,125 @@
+// Package glob implements the glob-style pattern matching used by Redis commands such as KEYS and CONFIG GET.
+package glob
+
+// Match reports whether str matches pattern.
+//
+// supported syntax:
+//   - ? matches any single character
+//   - * matches any sequence of characters, including the empty one
+//   - [abc], [^abc] and [a-z] match character classes
+//   - \x matches x literally
+func Match(pattern, str string) bool {
+	return match(pattern, str, false)
+}
+
+// MatchFold is like Match but ignores case.
+func MatchFold(pattern, str string) bool {
+	return match(pattern, str, true)
+}
+
+func match(pattern, str string, nocase bool) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// collapse consecutive stars
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if match(pattern[1:], str[i:], nocase) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+			str = str[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+			var matched bool
+			pattern, matched = matchClass(pattern[1:], str[0], nocase)
+			if !matched {
+				return false
+			}
+			str = str[1:]
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || !equal(pattern[0], str[0], nocase) {
+				return false
+			}
+			str = str[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(str) == 0
+}
+
+// matchClass matches c against the character class at the start of pattern (after the opening bracket),
+// and returns the pattern following the closing bracket.
+func matchClass(pattern string, c byte, nocase bool) (string, bool) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			if equal(pattern[1], c, nocase) {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) >= 3 && pattern[1] == '-' && pattern[2] != ']':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			lc := c
+			if nocase {
+				start, end, lc = lower(start), lower(end), lower(c)
+			}
+			if lc >= start && lc <= end {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if equal(pattern[0], c, nocase) {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		// skip the closing bracket
+		pattern = pattern[1:]
+	}
+
+	return pattern, matched != negate
+}
+
+func equal(a, b byte, nocase bool) bool {
+	if nocase {
+		return lower(a) == lower(b)
+	}
+	return a == b
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
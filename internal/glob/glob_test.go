@@ -0,0 +1,47 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		str     string
+		matched bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "heeeello", true},
+		{"h*llo", "hello world", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{"h\\*llo", "h*llo", true},
+		{"h\\*llo", "hello", false},
+		{"user:*:name", "user:42:name", true},
+		{"user:*:name", "user:42:email", false},
+		{"**a", "bba", true},
+		{"", "", true},
+		{"", "a", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.str, func(t *testing.T) {
+			require.Equal(t, tc.matched, Match(tc.pattern, tc.str))
+		})
+	}
+}
+
+func TestMatchFold(t *testing.T) {
+	require.True(t, MatchFold("MAX*", "maxmemory"))
+	require.True(t, MatchFold("[A-Z]ort", "port"))
+	require.False(t, Match("MAX*", "maxmemory"))
+}
@@ -0,0 +1,339 @@
+// Package hll implements HyperLogLog sketches with the string representation of Redis,
+// so that the values of PFADD can be exchanged with Redis through GET, SET and DUMP.
+//
+// a sketch is a 16 bytes header followed by 16384 registers, in one of two encodings:
+//   - sparse, a run-length encoding of the registers used while most of them are zero
+//   - dense, the registers packed as 6 bits integers
+package hll
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+const (
+	// precision, the number of hash bits selecting a register
+	p         = 14
+	registers = 1 << p
+	// remaining hash bits, in which the run of zeros is counted
+	q = 64 - p
+
+	registerBits = 6
+	registerMax  = 1<<registerBits - 1
+
+	headerSize = 16
+	denseSize  = headerSize + (registers*registerBits+7)/8
+
+	encodingDense  = 0
+	encodingSparse = 1
+
+	// flag of the last byte of the cached cardinality telling the cache is stale
+	cardStale = 1 << 7
+
+	// SparseMaxBytes is the size above which sparse sketches are converted to dense ones, like hll-sparse-max-bytes.
+	SparseMaxBytes = 3000
+
+	hashSeed = 0xadc83b19
+	// alpha of the estimator for an infinite number of registers
+	alphaInf = 0.721347520444481703680
+)
+
+var (
+	// ErrInvalid is returned for strings that are not HyperLogLog sketches.
+	ErrInvalid = errors.New("key is not a valid HyperLogLog string value")
+	// ErrCorrupted is returned for sketches with a valid header but an inconsistent content.
+	ErrCorrupted = errors.New("corrupted HLL object detected")
+)
+
+// Sketch is a decoded HyperLogLog sketch.
+type Sketch struct {
+	registers [registers]uint8
+	dense     bool
+
+	card      uint64
+	cardValid bool
+}
+
+// New returns an empty sketch, which is sparse like the ones PFADD creates.
+func New() *Sketch {
+	return &Sketch{cardValid: true}
+}
+
+// Parse decodes the string representation of a sketch.
+func Parse(s string) (*Sketch, error) {
+	if len(s) < headerSize || s[:4] != "HYLL" || s[4] > encodingSparse {
+		return nil, ErrInvalid
+	}
+	if s[4] == encodingDense && len(s) != denseSize {
+		return nil, ErrInvalid
+	}
+
+	sk := &Sketch{dense: s[4] == encodingDense}
+	card := []byte(s[8:headerSize])
+	sk.cardValid = card[7]&cardStale == 0
+	sk.card = binary.LittleEndian.Uint64(card)
+
+	if sk.dense {
+		for i := range registers {
+			sk.registers[i] = denseRegister(s[headerSize:], i)
+		}
+		return sk, nil
+	}
+	if err := sk.decodeSparse(s[headerSize:]); err != nil {
+		return nil, err
+	}
+	return sk, nil
+}
+
+// sparse opcodes:
+//   - 00xxxxxx: xxxxxx+1 zero registers
+//   - 01xxxxxx yyyyyyyy: xxxxxxyyyyyyyy+1 zero registers
+//   - 1vvvvvxx: xx+1 registers of value vvvvv+1
+const (
+	sparseValMax    = 32
+	sparseValRunMax = 4
+	sparseZeroMax   = 64
+	sparseXZeroMax  = 16384
+)
+
+func (sk *Sketch) decodeSparse(ops string) error {
+	i := 0
+	for pos := 0; pos < len(ops); pos++ {
+		op := ops[pos]
+		var run, value int
+		switch {
+		case op&0xc0 == 0x00:
+			run = int(op&0x3f) + 1
+		case op&0xc0 == 0x40:
+			if pos+1 >= len(ops) {
+				return ErrCorrupted
+			}
+			run = (int(op&0x3f)<<8 | int(ops[pos+1])) + 1
+			pos++
+		default:
+			value = int(op>>2&0x1f) + 1
+			run = int(op&0x03) + 1
+		}
+		if i+run > registers {
+			return ErrCorrupted
+		}
+		for j := i; j < i+run; j++ {
+			sk.registers[j] = uint8(value)
+		}
+		i += run
+	}
+	if i != registers {
+		return ErrCorrupted
+	}
+	return nil
+}
+
+func denseRegister(b string, i int) uint8 {
+	byteIndex, shift := i*registerBits/8, uint(i*registerBits&7)
+	v := b[byteIndex] >> shift
+	if byteIndex+1 < len(b) {
+		v |= b[byteIndex+1] << (8 - shift)
+	}
+	return v & registerMax
+}
+
+// Add hashes element into the sketch and reports whether a register changed.
+func (sk *Sketch) Add(element string) bool {
+	index, count := patLen(element)
+	if sk.registers[index] >= count {
+		return false
+	}
+	sk.registers[index] = count
+	sk.cardValid = false
+	return true
+}
+
+// patLen returns the register selected by the hash of element and the length of the run of zeros in the rest of the hash, plus one.
+func patLen(element string) (int, uint8) {
+	hash := murmurHash64A(element, hashSeed)
+	index := int(hash & (registers - 1))
+	hash >>= p
+	// bounds the count to q+1 when the remaining bits are all zeros
+	hash |= 1 << q
+	return index, uint8(bits.TrailingZeros64(hash) + 1)
+}
+
+// Merge sets every register to the maximum of its value in sk and in other.
+// the result is dense when either sketch is.
+func (sk *Sketch) Merge(other *Sketch) {
+	for i, v := range other.registers {
+		if v > sk.registers[i] {
+			sk.registers[i] = v
+			sk.cardValid = false
+		}
+	}
+	sk.dense = sk.dense || other.dense
+}
+
+// Stale reports whether the cached cardinality is out of date, so that Count has to estimate it.
+func (sk *Sketch) Stale() bool {
+	return !sk.cardValid
+}
+
+// Count returns the estimated cardinality, using the cached value when it is up to date.
+func (sk *Sketch) Count() uint64 {
+	if sk.cardValid {
+		return sk.card
+	}
+	sk.card = estimate(&sk.registers)
+	sk.cardValid = true
+	return sk.card
+}
+
+// estimate implements the estimator of Otmar Ertl's "New cardinality estimation algorithms for HyperLogLog sketches",
+// like Redis does since version 5.
+func estimate(regs *[registers]uint8) uint64 {
+	// sized for any 6 bits register, so that corrupted dense sketches cannot overflow it
+	var histogram [registerMax + 1]int
+	for _, v := range regs {
+		histogram[v]++
+	}
+
+	m := float64(registers)
+	z := m * tau((m-float64(histogram[q+1]))/m)
+	for j := q; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+	z += m * sigma(float64(histogram[0])/m)
+	return uint64(math.Round(alphaInf * m * m / z))
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if prev == z {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if prev == z {
+			return z / 3
+		}
+	}
+}
+
+// Bytes returns the string representation of the sketch.
+// sparse sketches are converted to dense ones once a register exceeds what the sparse encoding holds or once they exceed maxSparse bytes.
+func (sk *Sketch) Bytes(maxSparse int) []byte {
+	if !sk.dense {
+		if b, ok := sk.encodeSparse(maxSparse); ok {
+			return b
+		}
+		sk.dense = true
+	}
+
+	b := sk.header(encodingDense, denseSize)
+	regs := b[headerSize:]
+	for i, v := range sk.registers {
+		byteIndex, shift := i*registerBits/8, uint(i*registerBits&7)
+		regs[byteIndex] |= v << shift
+		if byteIndex+1 < len(regs) {
+			regs[byteIndex+1] |= v >> (8 - shift)
+		}
+	}
+	return b
+}
+
+func (sk *Sketch) header(encoding byte, size int) []byte {
+	b := make([]byte, headerSize, size)
+	copy(b, "HYLL")
+	b[4] = encoding
+	binary.LittleEndian.PutUint64(b[8:], sk.card)
+	if !sk.cardValid {
+		b[15] |= cardStale
+	}
+	return b[:size]
+}
+
+func (sk *Sketch) encodeSparse(maxSparse int) ([]byte, bool) {
+	b := sk.header(encodingSparse, headerSize)
+	for i := 0; i < registers; {
+		value := sk.registers[i]
+		run := 1
+		for i+run < registers && sk.registers[i+run] == value {
+			run++
+		}
+		i += run
+
+		switch {
+		case value > sparseValMax:
+			return nil, false
+		case value == 0:
+			for ; run > 0; run -= min(run, sparseXZeroMax) {
+				n := min(run, sparseXZeroMax)
+				if n <= sparseZeroMax {
+					b = append(b, byte(n-1))
+				} else {
+					b = append(b, 0x40|byte((n-1)>>8), byte(n-1))
+				}
+			}
+		default:
+			for ; run > 0; run -= min(run, sparseValRunMax) {
+				n := min(run, sparseValRunMax)
+				b = append(b, 0x80|(value-1)<<2|byte(n-1))
+			}
+		}
+		if len(b)-headerSize > maxSparse {
+			return nil, false
+		}
+	}
+	return b, true
+}
+
+// murmurHash64A is the 64 bits MurmurHash2 variant Redis hashes elements with, reading blocks as little endian.
+func murmurHash64A(key string, seed uint64) uint64 {
+	const (
+		m = 0xc6a4a7935bd1e995
+		r = 47
+	)
+
+	h := seed ^ (uint64(len(key)) * m)
+	blocks := len(key) / 8
+	for i := range blocks {
+		k := binary.LittleEndian.Uint64([]byte(key[i*8 : i*8+8]))
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+	}
+
+	tail := key[blocks*8:]
+	if len(tail) > 0 {
+		for i := len(tail) - 1; i >= 0; i-- {
+			h ^= uint64(tail[i]) << (8 * i)
+		}
+		h *= m
+	}
+
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
@@ -0,0 +1,117 @@
+package hll
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMurmurHash64A(t *testing.T) {
+	// tails of every length go through a different branch of the final mix
+	seen := make(map[uint64]struct{})
+	for _, key := range []string{"", "a", "ab", "abc", "abcdefg", "abcdefgh", "abcdefghi"} {
+		seen[murmurHash64A(key, hashSeed)] = struct{}{}
+	}
+	require.Len(t, seen, 7)
+	require.Equal(t, murmurHash64A("abcdefghi", hashSeed), murmurHash64A("abcdefghi", hashSeed))
+}
+
+func TestNewSketchBytes(t *testing.T) {
+	// the representation of an empty sketch created by PFADD in Redis
+	expected := []byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff")
+	require.Equal(t, expected, New().Bytes(SparseMaxBytes))
+}
+
+func TestRoundTrip(t *testing.T) {
+	sk := New()
+	for i := range 1000 {
+		sk.Add(strconv.Itoa(i))
+	}
+
+	sparse := sk.Bytes(SparseMaxBytes)
+	require.Equal(t, byte(encodingSparse), sparse[4])
+	require.Equal(t, byte(cardStale), sparse[15]&cardStale, "Adding elements should invalidate the cached cardinality")
+
+	parsed, err := Parse(string(sparse))
+	require.NoError(t, err)
+	require.Equal(t, sk.registers, parsed.registers)
+
+	dense := sk.Bytes(0)
+	require.Len(t, dense, denseSize)
+	require.Equal(t, byte(encodingDense), dense[4])
+	parsed, err = Parse(string(dense))
+	require.NoError(t, err)
+	require.Equal(t, sk.registers, parsed.registers)
+	require.True(t, parsed.dense)
+	require.Len(t, parsed.Bytes(SparseMaxBytes), denseSize, "Dense sketches should never go back to sparse")
+}
+
+func TestCachedCount(t *testing.T) {
+	sk := New()
+	sk.Add("a")
+	sk.Add("b")
+	require.Equal(t, uint64(2), sk.Count())
+
+	parsed, err := Parse(string(sk.Bytes(SparseMaxBytes)))
+	require.NoError(t, err)
+	require.True(t, parsed.cardValid)
+	require.Equal(t, uint64(2), parsed.card)
+}
+
+func TestSparseToDense(t *testing.T) {
+	sk := New()
+	// a register above 32 cannot be represented by the sparse encoding
+	sk.registers[5] = sparseValMax + 1
+	require.Equal(t, byte(encodingDense), sk.Bytes(SparseMaxBytes)[4])
+
+	sk = New()
+	for i := range 5000 {
+		sk.Add(strconv.Itoa(i))
+	}
+	require.Equal(t, byte(encodingDense), sk.Bytes(SparseMaxBytes)[4], "Sketches larger than the sparse limit should become dense")
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, invalid := range []string{"", "HYLL", "HYLX\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xff", "HYLL\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"} {
+		_, err := Parse(invalid)
+		require.ErrorIs(t, err, ErrInvalid, "%q", invalid)
+	}
+
+	_, err := Parse("HYLL\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	require.ErrorIs(t, err, ErrInvalid, "Dense sketches must have every register")
+
+	// the runs cover one register less than needed
+	_, err = Parse("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f\xfe")
+	require.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestMerge(t *testing.T) {
+	a, b := New(), New()
+	for i := range 3000 {
+		a.Add("a" + strconv.Itoa(i))
+		b.Add("b" + strconv.Itoa(i))
+	}
+	b.Bytes(0)
+
+	a.Merge(b)
+	require.True(t, a.dense, "Merging a dense sketch should make the result dense")
+	require.InEpsilon(t, 6000, float64(a.Count()), 0.03)
+}
+
+func TestStandardError(t *testing.T) {
+	// the standard error is 1.04/sqrt(registers), about 0.81%, every estimate should be within 3 standard errors
+	bound := 3 * 1.04 / math.Sqrt(registers)
+
+	sk := New()
+	added := 0
+	for _, n := range []int{10, 100, 1000, 10000, 100000, 1000000} {
+		for ; added < n; added++ {
+			sk.Add("element:" + strconv.Itoa(added))
+		}
+		estimate := float64(sk.Count())
+		relErr := math.Abs(estimate-float64(n)) / float64(n)
+		require.LessOrEqual(t, relErr, bound, "cardinality %d estimated as %.0f", n, estimate)
+	}
+}
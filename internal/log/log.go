@@ -11,31 +11,35 @@ import (
 	"github.com/mattn/go-isatty"
 )
 
-func New(level string) *slog.Logger {
-	var slogLevel slog.Level
+// ParseLevel converts a configured log level to its slog equivalent, unknown levels fall back to INFO.
+func ParseLevel(level string) slog.Level {
 	switch strings.ToUpper(level) {
 	case "DEBUG":
-		slogLevel = slog.LevelDebug
+		return slog.LevelDebug
 	case "INFO":
-		slogLevel = slog.LevelInfo
+		return slog.LevelInfo
 	case "WARN":
-		slogLevel = slog.LevelWarn
+		return slog.LevelWarn
 	case "ERROR":
-		slogLevel = slog.LevelError
+		return slog.LevelError
 	default:
-		slogLevel = slog.LevelInfo
+		return slog.LevelInfo
 	}
+}
 
+// New creates a logger writing to stdout.
+// level may be a *slog.LevelVar so that the level can be changed while the server is running.
+func New(level slog.Leveler) *slog.Logger {
 	out := os.Stdout
 	var handler slog.Handler
 	if isatty.IsTerminal(out.Fd()) {
 		handler = tint.NewHandler(colorable.NewColorable(out), &tint.Options{
-			Level:      slogLevel,
+			Level:      level,
 			TimeFormat: time.DateTime,
 		})
 	} else {
 		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{
-			Level: slogLevel,
+			Level: level,
 		})
 	}
 
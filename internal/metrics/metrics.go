@@ -0,0 +1,122 @@
+// Package metrics exposes the statistics of the server to Prometheus.
+// the counters shared with INFO are read from stats.Stats when scraped, so CONFIG RESETSTAT resets them as well.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/stats"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gvalkey"
+
+// Metrics is safe for concurrent use.
+type Metrics struct {
+	registry *prometheus.Registry
+	calls    *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// New registers the metrics of st, and of the databases returned by keyspace in the order of their index.
+func New(st *stats.Stats, keyspace func() []store.Store) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "commands_total",
+			Help:      "Number of commands processed, including the failed ones.",
+		}, []string{"command"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "command_duration_seconds",
+			Help:      "Time spent executing commands, excluding the wait for the execution lock.",
+			// from 10µs to about 1.3s
+			Buckets: prometheus.ExponentialBuckets(0.00001, 4, 9),
+		}, []string{"command"}),
+	}
+
+	m.registry.MustRegister(
+		m.calls,
+		m.latency,
+		&keyspaceCollector{keyspace: keyspace},
+		gauge("connected_clients", "Number of connected clients.", &st.ConnectedClients),
+		counter("connections_received_total", "Number of connections accepted.", &st.TotalConnections),
+		counter("keyspace_hits_total", "Number of successful lookups of keys.", &st.KeyspaceHits),
+		counter("keyspace_misses_total", "Number of failed lookups of keys.", &st.KeyspaceMisses),
+		counter("expired_keys_total", "Number of keys removed because their TTL elapsed.", &st.ExpiredKeys),
+		counter("evicted_keys_total", "Number of keys evicted because of maxmemory.", &st.EvictedKeys),
+		counter("net_input_bytes_total", "Number of bytes read from clients.", &st.NetInputBytes),
+		counter("net_output_bytes_total", "Number of bytes written to clients.", &st.NetOutputBytes),
+		counter("parse_errors_total", "Number of requests that are not valid RESP.", &st.ParseErrors),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// ObserveCommand records a call of the command named name which ran for elapsed.
+func (m *Metrics) ObserveCommand(name string, elapsed time.Duration) {
+	m.calls.WithLabelValues(name).Inc()
+	m.latency.WithLabelValues(name).Observe(elapsed.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// loader is implemented by the atomic counters of stats.Stats.
+type loader interface {
+	Load() int64
+}
+
+func counter(name, help string, value loader) prometheus.Collector {
+	return prometheus.NewCounterFunc(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help}, func() float64 {
+		return float64(value.Load())
+	})
+}
+
+func gauge(name, help string, value loader) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Namespace: namespace, Name: name, Help: help}, func() float64 {
+		return float64(value.Load())
+	})
+}
+
+var (
+	keysDesc            = prometheus.NewDesc(namespace+"_keys", "Number of keys per database, including expired keys not reclaimed yet.", []string{"db"}, nil)
+	expiresDesc         = prometheus.NewDesc(namespace+"_expiring_keys", "Number of keys with an expiration per database.", []string{"db"}, nil)
+	activeExpiredDesc   = prometheus.NewDesc(namespace+"_active_expired_keys_total", "Number of keys reclaimed by the background expiration per database.", []string{"db"}, nil)
+	expireTimeCapDesc   = prometheus.NewDesc(namespace+"_expire_time_cap_reached_total", "Number of background expiration cycles stopped by their time budget per database.", []string{"db"}, nil)
+	expireCycleTimeDesc = prometheus.NewDesc(namespace+"_expire_cycle_seconds_total", "Time spent in background expiration cycles per database.", []string{"db"}, nil)
+)
+
+// keyspaceCollector reports the size and the expiration of every database when scraped, as they may be swapped.
+type keyspaceCollector struct {
+	keyspace func() []store.Store
+}
+
+func (c *keyspaceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- keysDesc
+	ch <- expiresDesc
+	ch <- activeExpiredDesc
+	ch <- expireTimeCapDesc
+	ch <- expireCycleTimeDesc
+}
+
+func (c *keyspaceCollector) Collect(ch chan<- prometheus.Metric) {
+	for i, s := range c.keyspace() {
+		db := strconv.Itoa(i)
+		st, expire := s.Stats(), s.ExpireStats()
+		ch <- prometheus.MustNewConstMetric(keysDesc, prometheus.GaugeValue, float64(st.Keys), db)
+		ch <- prometheus.MustNewConstMetric(expiresDesc, prometheus.GaugeValue, float64(st.Expires), db)
+		ch <- prometheus.MustNewConstMetric(activeExpiredDesc, prometheus.CounterValue, float64(expire.Expired), db)
+		ch <- prometheus.MustNewConstMetric(expireTimeCapDesc, prometheus.CounterValue, float64(expire.TimeCapReached), db)
+		ch <- prometheus.MustNewConstMetric(expireCycleTimeDesc, prometheus.CounterValue, expire.CycleTime.Seconds(), db)
+	}
+}
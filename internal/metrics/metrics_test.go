@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/internal/stats"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/naive"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	st := stats.New()
+	st.ConnectedClients.Add(2)
+	st.KeyspaceHits.Add(3)
+	st.NetInputBytes.Add(42)
+	db0, db1 := naive.NewNaiveStore(), naive.NewNaiveStore()
+	defer db0.Close()
+	defer db1.Close()
+	for i := range 5 {
+		args := resp.SetArgs{Key: resp.BulkString(strconv.Itoa(i)), Value: "value"}
+		if i == 0 {
+			args.ExpireAt = time.Now().Add(time.Hour)
+		}
+		db0.Set(args)
+	}
+	m := New(st, func() []store.Store {
+		return []store.Store{db0, db1}
+	})
+	m.ObserveCommand("get", 20*time.Microsecond)
+	m.ObserveCommand("get", 2*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		`gvalkey_commands_total{command="get"} 2`,
+		`gvalkey_command_duration_seconds_count{command="get"} 2`,
+		`gvalkey_command_duration_seconds_bucket{command="get",le="4e-05"} 1`,
+		`gvalkey_connected_clients 2`,
+		`gvalkey_keyspace_hits_total 3`,
+		`gvalkey_net_input_bytes_total 42`,
+		`gvalkey_keys{db="0"} 5`,
+		`gvalkey_keys{db="1"} 0`,
+		`gvalkey_expiring_keys{db="0"} 1`,
+		`gvalkey_active_expired_keys_total{db="1"} 0`,
+	} {
+		require.Contains(t, body, line+"\n")
+	}
+}
@@ -0,0 +1,162 @@
+// Package monitor streams the commands received by the server to the clients that ran MONITOR.
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+// Monitor receives the commands fed to the hub.
+type Monitor interface {
+	// Deliver sends a command to the monitor, it is called from the goroutine of the client that sent the command
+	// and must not block.
+	Deliver(msg resp.Payload)
+}
+
+// Hub keeps track of the monitors, it is safe for concurrent use.
+type Hub struct {
+	mu       sync.RWMutex
+	monitors map[Monitor]struct{}
+	// read without the lock, so that nothing else is done for the commands received while no monitor is attached
+	count atomic.Int32
+}
+
+func New() *Hub {
+	return &Hub{monitors: make(map[Monitor]struct{})}
+}
+
+// Attach adds m to the monitors.
+func (h *Hub) Attach(m Monitor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.monitors[m] = struct{}{}
+	h.count.Store(int32(len(h.monitors)))
+}
+
+// Detach removes m from the monitors, if it is one.
+func (h *Hub) Detach(m Monitor) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.monitors, m)
+	h.count.Store(int32(len(h.monitors)))
+}
+
+// Active reports whether a monitor is attached.
+func (h *Hub) Active() bool {
+	return h.count.Load() > 0
+}
+
+// Feed sends a command received at now from the client at addr, which selected the database db, to every monitor.
+func (h *Hub) Feed(now time.Time, db int, addr string, args resp.Array) {
+	if !h.Active() {
+		return
+	}
+	line := resp.SimpleString(Format(now, db, addr, args))
+
+	h.mu.RLock()
+	monitors := make([]Monitor, 0, len(h.monitors))
+	for m := range h.monitors {
+		monitors = append(monitors, m)
+	}
+	h.mu.RUnlock()
+
+	// delivered without the lock, so that a monitor may detach meanwhile
+	for _, m := range monitors {
+		m.Deliver(line)
+	}
+}
+
+// Format describes a command like Redis does, as in 1339518083.107412 [0 127.0.0.1:60866] "SET" "key" "value",
+// the secrets of authentication commands being redacted.
+func Format(now time.Time, db int, addr string, args resp.Array) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d.%06d [%d %s]", now.Unix(), now.Nanosecond()/1000, db, addr)
+	redacted := redactedArgs(args)
+	for i, arg := range args {
+		b.WriteByte(' ')
+		if redacted[i] {
+			b.WriteString(`"(redacted)"`)
+			continue
+		}
+		str, _ := arg.(resp.BulkString)
+		quote(&b, string(str))
+	}
+	return b.String()
+}
+
+// redactedArgs returns which arguments hold secrets: every argument of AUTH,
+// and the ones following the AUTH option of HELLO and the AUTH or AUTH2 option of MIGRATE.
+func redactedArgs(args resp.Array) []bool {
+	redacted := make([]bool, len(args))
+	if len(args) == 0 {
+		return redacted
+	}
+	name, _ := args[0].(resp.BulkString)
+	name = name.Upper()
+	if name == resp.AUTH {
+		for i := 1; i < len(args); i++ {
+			redacted[i] = true
+		}
+		return redacted
+	}
+	if name != resp.HELLO && name != resp.MIGRATE {
+		return redacted
+	}
+
+	for i := 1; i < len(args); i++ {
+		option, _ := args[i].(resp.BulkString)
+		n := 0
+		switch option.Upper() {
+		case resp.AUTH:
+			// HELLO AUTH username password, MIGRATE AUTH password
+			n = 1
+			if name == resp.HELLO {
+				n = 2
+			}
+		case resp.AUTH2:
+			// MIGRATE AUTH2 username password
+			n = 2
+		}
+		for j := i + 1; j <= i+n && j < len(args); j++ {
+			redacted[j] = true
+		}
+		i += n
+	}
+	return redacted
+}
+
+// quote writes s between double quotes, escaping the characters that are not printable like Redis does.
+func quote(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		default:
+			if c < ' ' || c > '~' {
+				fmt.Fprintf(b, `\x%02x`, c)
+				continue
+			}
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	lines []resp.Payload
+}
+
+func (r *recorder) Deliver(msg resp.Payload) {
+	r.lines = append(r.lines, msg)
+}
+
+func bulkStrings(strs ...string) resp.Array {
+	args := make(resp.Array, len(strs))
+	for i, s := range strs {
+		args[i] = resp.BulkString(s)
+	}
+	return args
+}
+
+func TestFormat(t *testing.T) {
+	now := time.Unix(1339518083, 107412000)
+	require.Equal(t, `1339518083.107412 [0 127.0.0.1:60866] "keys" "*"`, Format(now, 0, "127.0.0.1:60866", bulkStrings("keys", "*")))
+	require.Equal(t, `1339518083.000042 [3 addr] "SET" "a\"b\\" "\r\n\x00\xff"`,
+		Format(time.Unix(1339518083, 42000), 3, "addr", bulkStrings("SET", `a"b\`, "\r\n\x00\xff")))
+}
+
+func TestRedaction(t *testing.T) {
+	now := time.Unix(0, 0)
+	require.Equal(t, `0.000000 [0 a] "auth" "(redacted)" "(redacted)"`, Format(now, 0, "a", bulkStrings("auth", "user", "pass")))
+	require.Equal(t, `0.000000 [0 a] "HELLO" "3" "AUTH" "(redacted)" "(redacted)" "SETNAME" "x"`,
+		Format(now, 0, "a", bulkStrings("HELLO", "3", "AUTH", "user", "pass", "SETNAME", "x")))
+	require.Equal(t, `0.000000 [0 a] "MIGRATE" "h" "1" "" "0" "10" "auth" "(redacted)" "KEYS" "k"`,
+		Format(now, 0, "a", bulkStrings("MIGRATE", "h", "1", "", "0", "10", "auth", "pass", "KEYS", "k")))
+	require.Equal(t, `0.000000 [0 a] "MIGRATE" "AUTH2" "(redacted)" "(redacted)"`,
+		Format(now, 0, "a", bulkStrings("MIGRATE", "AUTH2", "user", "pass")))
+}
+
+func TestHub(t *testing.T) {
+	h := New()
+	require.False(t, h.Active())
+
+	m := &recorder{}
+	h.Attach(m)
+	require.True(t, h.Active())
+	h.Feed(time.Unix(0, 0), 0, "a", bulkStrings("GET", "k"))
+	require.Equal(t, []resp.Payload{resp.SimpleString(`0.000000 [0 a] "GET" "k"`)}, m.lines)
+
+	h.Detach(m)
+	require.False(t, h.Active())
+	h.Feed(time.Unix(0, 0), 0, "a", bulkStrings("GET", "k"))
+	require.Len(t, m.lines, 1)
+}
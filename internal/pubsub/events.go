@@ -0,0 +1,98 @@
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EventClass is a set of keyspace event classes, selected with the notify-keyspace-events setting.
+type EventClass uint
+
+const (
+	// ClassKeyspace publishes events to __keyspace@<db>__:<key> channels.
+	ClassKeyspace EventClass = 1 << iota
+	// ClassKeyevent publishes events to __keyevent@<db>__:<event> channels.
+	ClassKeyevent
+	// ClassGeneric events are type-independent commands such as DEL or MOVE.
+	ClassGeneric
+	ClassString
+	ClassList
+	ClassSet
+	ClassHash
+	ClassZSet
+	ClassExpired
+	ClassEvicted
+	ClassStream
+	ClassKeyMiss
+	ClassModule
+	ClassNew
+
+	// ClassAll is the "A" alias for every class of commands and key events, not including ClassKeyMiss and ClassNew.
+	ClassAll = ClassGeneric | ClassString | ClassList | ClassSet | ClassHash | ClassZSet | ClassExpired | ClassEvicted | ClassStream | ClassModule
+)
+
+// flags maps the characters of notify-keyspace-events to event classes, in the order Redis prints them.
+var flags = []struct {
+	char  byte
+	class EventClass
+}{
+	{'g', ClassGeneric},
+	{'$', ClassString},
+	{'l', ClassList},
+	{'s', ClassSet},
+	{'h', ClassHash},
+	{'z', ClassZSet},
+	{'x', ClassExpired},
+	{'e', ClassEvicted},
+	{'t', ClassStream},
+	{'d', ClassModule},
+	{'K', ClassKeyspace},
+	{'E', ClassKeyevent},
+	{'m', ClassKeyMiss},
+	{'n', ClassNew},
+}
+
+// ParseEventClasses parses a notify-keyspace-events flag string such as "KEA" or "Ex".
+func ParseEventClasses(s string) (EventClass, error) {
+	var classes EventClass
+	for i := range len(s) {
+		if s[i] == 'A' {
+			classes |= ClassAll
+			continue
+		}
+		found := false
+		for _, f := range flags {
+			if f.char == s[i] {
+				classes |= f.class
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid event class character '%c'", s[i])
+		}
+	}
+	return classes, nil
+}
+
+// String returns the canonical flag string of the classes, as printed by CONFIG GET.
+func (c EventClass) String() string {
+	var sb strings.Builder
+	for _, f := range flags {
+		if c&ClassAll == ClassAll && ClassAll&f.class != 0 {
+			if f.class == ClassGeneric {
+				sb.WriteByte('A')
+			}
+			continue
+		}
+		if c&f.class != 0 {
+			sb.WriteByte(f.char)
+		}
+	}
+	return sb.String()
+}
+
+// Enabled reports whether events of class are published to at least one kind of channel.
+func (c EventClass) Enabled(class EventClass) bool {
+	return c&(ClassKeyspace|ClassKeyevent) != 0 && c&class != 0
+}
@@ -0,0 +1,44 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventClasses(t *testing.T) {
+	testCases := []struct {
+		flags     string
+		classes   EventClass
+		canonical string
+	}{
+		{"", 0, ""},
+		{"Ex", ClassKeyevent | ClassExpired, "xE"},
+		{"KEA", ClassKeyspace | ClassKeyevent | ClassAll, "AKE"},
+		{"g$lshzxetdKE", ClassKeyspace | ClassKeyevent | ClassAll, "AKE"},
+		{"Kmn", ClassKeyspace | ClassKeyMiss | ClassNew, "Kmn"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.flags, func(t *testing.T) {
+			classes, err := ParseEventClasses(tc.flags)
+			require.NoError(t, err)
+			require.Equal(t, tc.classes, classes)
+			require.Equal(t, tc.canonical, classes.String())
+		})
+	}
+
+	_, err := ParseEventClasses("KEQ")
+	require.Error(t, err)
+}
+
+func TestEnabled(t *testing.T) {
+	classes, err := ParseEventClasses("A")
+	require.NoError(t, err)
+	require.False(t, classes.Enabled(ClassGeneric), "Events need K or E to be published")
+
+	classes, err = ParseEventClasses("Kg")
+	require.NoError(t, err)
+	require.True(t, classes.Enabled(ClassGeneric))
+	require.False(t, classes.Enabled(ClassExpired))
+}
@@ -0,0 +1,138 @@
+// Package pubsub routes published messages to the clients subscribed to channels and patterns.
+package pubsub
+
+import (
+	"sync"
+
+	"github.com/PlayerNeo42/gvalkey/internal/glob"
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
+
+var (
+	messageKind  = resp.BulkString("message")
+	pmessageKind = resp.BulkString("pmessage")
+)
+
+// Subscriber receives the messages published to its channels and patterns.
+type Subscriber interface {
+	// Deliver sends a message to the subscriber, it is called from the publishing goroutine and must not block.
+	Deliver(msg resp.Payload)
+}
+
+// Hub keeps track of subscriptions, it is safe for concurrent use.
+type Hub struct {
+	mu       sync.RWMutex
+	channels map[string]map[Subscriber]struct{}
+	patterns map[string]map[Subscriber]struct{}
+}
+
+func New() *Hub {
+	return &Hub{
+		channels: make(map[string]map[Subscriber]struct{}),
+		patterns: make(map[string]map[Subscriber]struct{}),
+	}
+}
+
+// Subscribe adds s to the subscribers of channel, it reports false when s was already subscribed.
+func (h *Hub) Subscribe(s Subscriber, channel string) bool {
+	return h.add(h.channels, s, channel)
+}
+
+// Unsubscribe removes s from the subscribers of channel, it reports false when s was not subscribed.
+func (h *Hub) Unsubscribe(s Subscriber, channel string) bool {
+	return h.remove(h.channels, s, channel)
+}
+
+// PSubscribe adds s to the subscribers of the glob-style pattern.
+func (h *Hub) PSubscribe(s Subscriber, pattern string) bool {
+	return h.add(h.patterns, s, pattern)
+}
+
+// PUnsubscribe removes s from the subscribers of the glob-style pattern.
+func (h *Hub) PUnsubscribe(s Subscriber, pattern string) bool {
+	return h.remove(h.patterns, s, pattern)
+}
+
+// Publish delivers message to the subscribers of channel and of the patterns matching it.
+// it returns the number of deliveries, a subscriber matching several times receives the message several times.
+func (h *Hub) Publish(channel, message string) int {
+	type delivery struct {
+		to  Subscriber
+		msg resp.Payload
+	}
+
+	h.mu.RLock()
+	var deliveries []delivery
+	if subscribers, ok := h.channels[channel]; ok {
+		msg := resp.Array{messageKind, resp.BulkString(channel), resp.BulkString(message)}
+		for s := range subscribers {
+			deliveries = append(deliveries, delivery{s, msg})
+		}
+	}
+	for pattern, subscribers := range h.patterns {
+		if !glob.Match(pattern, channel) {
+			continue
+		}
+		msg := resp.Array{pmessageKind, resp.BulkString(pattern), resp.BulkString(channel), resp.BulkString(message)}
+		for s := range subscribers {
+			deliveries = append(deliveries, delivery{s, msg})
+		}
+	}
+	h.mu.RUnlock()
+
+	// deliver outside of the lock, so that a slow subscriber does not hold up subscriptions
+	for _, d := range deliveries {
+		d.to.Deliver(d.msg)
+	}
+	return len(deliveries)
+}
+
+// NumSub returns the number of subscribers of channel.
+func (h *Hub) NumSub(channel string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.channels[channel])
+}
+
+// NumPat returns the number of patterns with at least one subscriber.
+func (h *Hub) NumPat() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.patterns)
+}
+
+func (h *Hub) add(index map[string]map[Subscriber]struct{}, s Subscriber, name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribers, ok := index[name]
+	if !ok {
+		subscribers = make(map[Subscriber]struct{})
+		index[name] = subscribers
+	}
+	if _, ok := subscribers[s]; ok {
+		return false
+	}
+	subscribers[s] = struct{}{}
+	return true
+}
+
+func (h *Hub) remove(index map[string]map[Subscriber]struct{}, s Subscriber, name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribers, ok := index[name]
+	if !ok {
+		return false
+	}
+	if _, ok := subscribers[s]; !ok {
+		return false
+	}
+	delete(subscribers, s)
+	if len(subscribers) == 0 {
+		delete(index, name)
+	}
+	return true
+}
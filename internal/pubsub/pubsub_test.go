@@ -0,0 +1,57 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	messages []resp.Payload
+}
+
+func (r *recorder) Deliver(msg resp.Payload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+}
+
+func TestPublishToChannel(t *testing.T) {
+	h := New()
+	a, b := &recorder{}, &recorder{}
+
+	require.True(t, h.Subscribe(a, "news"))
+	require.False(t, h.Subscribe(a, "news"), "Subscribing twice should be a no-op")
+	require.True(t, h.Subscribe(b, "sport"))
+
+	require.Equal(t, 1, h.Publish("news", "hello"))
+	require.Equal(t, []resp.Payload{resp.Array{messageKind, resp.BulkString("news"), resp.BulkString("hello")}}, a.messages)
+	require.Empty(t, b.messages)
+
+	require.True(t, h.Unsubscribe(a, "news"))
+	require.False(t, h.Unsubscribe(a, "news"))
+	require.Zero(t, h.Publish("news", "hello"))
+	require.Zero(t, h.NumSub("news"))
+}
+
+func TestPublishToPattern(t *testing.T) {
+	h := New()
+	r := &recorder{}
+
+	require.True(t, h.PSubscribe(r, "__keyspace@0__:*"))
+	require.True(t, h.Subscribe(r, "__keyspace@0__:foo"))
+	require.Equal(t, 1, h.NumPat())
+
+	require.Equal(t, 2, h.Publish("__keyspace@0__:foo", "set"), "Channel and pattern subscriptions should both receive the message")
+	require.Contains(t, r.messages, resp.Payload(resp.Array{
+		pmessageKind, resp.BulkString("__keyspace@0__:*"), resp.BulkString("__keyspace@0__:foo"), resp.BulkString("set"),
+	}))
+
+	require.Zero(t, h.Publish("__keyspace@1__:foo", "set"))
+
+	require.True(t, h.PUnsubscribe(r, "__keyspace@0__:*"))
+	require.Zero(t, h.NumPat())
+}
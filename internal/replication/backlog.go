@@ -0,0 +1,133 @@
+// Package replication keeps the recent history of the replication stream a master sends to its replicas.
+package replication
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+// ErrUnavailable is returned when the requested part of the stream is not in the backlog anymore, or belongs to another history.
+var ErrUnavailable = errors.New("offset not available in the backlog")
+
+// Backlog is a ring buffer holding the last bytes of the replication stream, it is safe for concurrent use.
+//
+// offsets count the bytes of the stream since its history started, as master_repl_offset does in Redis.
+// a replica that disconnects resumes from its offset as long as the bytes after it are still held.
+type Backlog struct {
+	mu     sync.Mutex
+	replID string
+	buf    []byte
+	// offset of the end of the stream
+	offset int64
+	// number of bytes held, the last ones of the stream
+	histLen int64
+	// closed and replaced whenever the stream grows or its history is reset
+	changed chan struct{}
+}
+
+// NewBacklog creates a backlog holding up to size bytes of the history replID, which starts at offset.
+func NewBacklog(size int, replID string, offset int64) *Backlog {
+	return &Backlog{
+		replID:  replID,
+		buf:     make([]byte, size),
+		offset:  offset,
+		changed: make(chan struct{}),
+	}
+}
+
+// NewReplID returns a random replication ID, 40 hexadecimal characters like the ones of Redis.
+func NewReplID() string {
+	b := make([]byte, 20)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Append adds p at the end of the stream, dropping the oldest bytes once the backlog is full.
+func (b *Backlog) Append(p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size, n := int64(len(b.buf)), int64(len(p))
+	b.offset += n
+	b.histLen = min(b.histLen+n, size)
+	if n > size {
+		p = p[n-size:]
+	}
+	for start := b.offset - int64(len(p)); len(p) > 0; {
+		copied := copy(b.buf[start%size:], p)
+		p = p[copied:]
+		start += int64(copied)
+	}
+	b.notify()
+}
+
+// Reset starts the history replID at offset, dropping the bytes held.
+func (b *Backlog) Reset(replID string, offset int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.replID = replID
+	b.offset = offset
+	b.histLen = 0
+	b.notify()
+}
+
+// ReadFrom returns the bytes of the history replID after offset, along with a channel closed once there is more to read.
+// it returns ErrUnavailable when the history changed or the bytes after offset were dropped.
+func (b *Backlog) ReadFrom(replID string, offset int64) ([]byte, <-chan struct{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if replID != b.replID || offset < b.offset-b.histLen || offset > b.offset {
+		return nil, nil, ErrUnavailable
+	}
+
+	size := int64(len(b.buf))
+	p := make([]byte, 0, b.offset-offset)
+	for start := offset; start < b.offset; {
+		// up to the end of the stream or of the buffer, whichever comes first
+		i := start % size
+		n := min(b.offset-start, size-i)
+		p = append(p, b.buf[i:i+n]...)
+		start += n
+	}
+	return p, b.changed, nil
+}
+
+// ReplID returns the ID of the history.
+func (b *Backlog) ReplID() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.replID
+}
+
+// Offset returns the offset of the end of the stream.
+func (b *Backlog) Offset() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.offset
+}
+
+// History returns the offset of the first byte held and the number of bytes held.
+func (b *Backlog) History() (first, length int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.offset - b.histLen, b.histLen
+}
+
+// Size returns the capacity of the backlog.
+func (b *Backlog) Size() int {
+	return len(b.buf)
+}
+
+// notify wakes up the readers waiting for the stream to change, the lock must be held.
+func (b *Backlog) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
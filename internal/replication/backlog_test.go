@@ -0,0 +1,62 @@
+package replication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBacklog(t *testing.T) {
+	b := NewBacklog(8, "id", 100)
+
+	p, changed, err := b.ReadFrom("id", 100)
+	require.NoError(t, err)
+	require.Empty(t, p)
+
+	b.Append([]byte("abcde"))
+	select {
+	case <-changed:
+	default:
+		t.Fatal("appending must notify the readers")
+	}
+	require.Equal(t, int64(105), b.Offset())
+
+	p, _, err = b.ReadFrom("id", 102)
+	require.NoError(t, err)
+	require.Equal(t, "cde", string(p))
+
+	// wraps around the end of the buffer, dropping "abc"
+	b.Append([]byte("fghijk"))
+	first, length := b.History()
+	require.Equal(t, int64(103), first)
+	require.Equal(t, int64(8), length)
+
+	p, _, err = b.ReadFrom("id", 103)
+	require.NoError(t, err)
+	require.Equal(t, "defghijk", string(p))
+
+	_, _, err = b.ReadFrom("id", 102)
+	require.ErrorIs(t, err, ErrUnavailable)
+	_, _, err = b.ReadFrom("id", 112)
+	require.ErrorIs(t, err, ErrUnavailable)
+	_, _, err = b.ReadFrom("other", 105)
+	require.ErrorIs(t, err, ErrUnavailable)
+
+	// longer than the buffer, only its end is kept
+	b.Append([]byte("0123456789"))
+	p, _, err = b.ReadFrom("id", 113)
+	require.NoError(t, err)
+	require.Equal(t, "23456789", string(p))
+
+	b.Reset("new", 500)
+	_, _, err = b.ReadFrom("id", 121)
+	require.ErrorIs(t, err, ErrUnavailable)
+	p, _, err = b.ReadFrom("new", 500)
+	require.NoError(t, err)
+	require.Empty(t, p)
+}
+
+func TestNewReplID(t *testing.T) {
+	require.Len(t, NewReplID(), 40)
+	require.NotEqual(t, NewReplID(), NewReplID())
+}
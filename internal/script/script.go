@@ -0,0 +1,376 @@
+// Package script runs the Lua scripts of EVAL, converting values between RESP and Lua like Redis does.
+package script
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// chunkName is the name scripts are compiled with, it prefixes the positions of their errors.
+const chunkName = "user_script"
+
+var errNoScript = resp.NewPrefixedError("NOSCRIPT", "No matching script. Please use EVAL.")
+
+// errReadOnly is raised by scripts assigning globals or changing the libraries, which all scripts share.
+const errReadOnly = "Attempt to modify a readonly table"
+
+// Caller runs a command on behalf of a script, errors are replied as resp.SimpleError.
+type Caller func(args []string) resp.Payload
+
+// Engine compiles and runs scripts in a single Lua state.
+// it is not safe for concurrent use, callers serialize scripts as they must run atomically anyway.
+//
+// like in Redis, the globals and the libraries are read-only, so that a script cannot change what the next ones run with.
+type Engine struct {
+	logger  *slog.Logger
+	state   *lua.LState
+	scripts map[string]*lua.LFunction
+
+	// the actual globals, scripts see them through a read-only table
+	globals *lua.LTable
+	// the read-only tables, which rawset cannot change either
+	readOnly map[*lua.LTable]struct{}
+
+	// runs the commands of the script being executed
+	caller Caller
+}
+
+func New(logger *slog.Logger) *Engine {
+	e := &Engine{logger: logger}
+	e.reset()
+	return e
+}
+
+// reset starts over with a new Lua state and an empty script cache.
+func (e *Engine) reset() {
+	e.state = lua.NewState(lua.Options{SkipOpenLibs: true})
+	e.scripts = make(map[string]*lua.LFunction)
+	e.readOnly = make(map[*lua.LTable]struct{})
+	e.openLibs()
+}
+
+// openLibs loads the Lua libraries available to scripts and the redis table, then makes them read-only.
+func (e *Engine) openLibs() {
+	ls := e.state
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		ls.Push(ls.NewFunction(lib.open))
+		ls.Push(lua.LString(lib.name))
+		ls.Call(1, 0)
+	}
+	// scripts have no access to the file system, nor to the environments of functions which would bypass the read-only globals
+	for _, name := range []string{"dofile", "loadfile", "getfenv", "setfenv", "module", "require"} {
+		ls.SetGlobal(name, lua.LNil)
+	}
+	ls.SetGlobal("rawset", ls.NewFunction(e.rawSet))
+
+	redis := ls.NewTable()
+	ls.SetFuncs(redis, map[string]lua.LGFunction{
+		"call":         func(ls *lua.LState) int { return e.call(ls, true) },
+		"pcall":        func(ls *lua.LState) int { return e.call(ls, false) },
+		"error_reply":  replyTable("err"),
+		"status_reply": replyTable("ok"),
+		"sha1hex":      sha1Hex,
+		"log":          e.log,
+	})
+	for name, level := range map[string]int{"LOG_DEBUG": 0, "LOG_VERBOSE": 1, "LOG_NOTICE": 2, "LOG_WARNING": 3} {
+		ls.SetField(redis, name, lua.LNumber(level))
+	}
+	ls.SetGlobal("redis", redis)
+
+	e.globals = ls.G.Global
+	for _, name := range []string{"redis", lua.TabLibName, lua.StringLibName, lua.MathLibName} {
+		if lib, ok := e.globals.RawGetString(name).(*lua.LTable); ok {
+			e.globals.RawSetString(name, e.readOnlyTable(lib))
+		}
+	}
+	env := e.readOnlyTable(e.globals)
+	e.globals.RawSetString("_G", env)
+	// the string library is also reachable from the metatable of strings
+	if mt, ok := ls.GetMetatable(lua.LString("")).(*lua.LTable); ok {
+		mt.RawSetString("__metatable", lua.LFalse)
+	}
+	// scripts, and the chunks they load, run with the read-only globals
+	ls.Env = env
+}
+
+// readOnlyTable returns a table reading the fields of tbl, raising an error when a field is assigned.
+func (e *Engine) readOnlyTable(tbl *lua.LTable) *lua.LTable {
+	ls := e.state
+	mt := ls.NewTable()
+	mt.RawSetString("__index", tbl)
+	mt.RawSetString("__newindex", ls.NewFunction(func(ls *lua.LState) int {
+		ls.RaiseError(errReadOnly)
+		return 0
+	}))
+	// getmetatable does not give access to tbl, nor can setmetatable remove the protection
+	mt.RawSetString("__metatable", lua.LFalse)
+
+	proxy := ls.NewTable()
+	ls.SetMetatable(proxy, mt)
+	e.readOnly[proxy] = struct{}{}
+	return proxy
+}
+
+// rawSet implements rawset, which cannot change the read-only tables either.
+func (e *Engine) rawSet(ls *lua.LState) int {
+	tbl := ls.CheckTable(1)
+	if _, ok := e.readOnly[tbl]; ok {
+		ls.RaiseError(errReadOnly)
+		return 0
+	}
+	tbl.RawSet(ls.CheckAny(2), ls.CheckAny(3))
+	ls.SetTop(1)
+	return 1
+}
+
+// SHA1 returns the digest identifying a script.
+func SHA1(body string) string {
+	sum := sha1.Sum([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load compiles a script and caches it, it returns the digest running it with EvalSHA.
+func (e *Engine) Load(body string) (string, error) {
+	sha := SHA1(body)
+	if _, ok := e.scripts[sha]; ok {
+		return sha, nil
+	}
+
+	fn, err := e.state.Load(strings.NewReader(body), chunkName)
+	if err != nil {
+		return "", resp.NewSimpleError("Error compiling script (new function): " + errorMessage(err))
+	}
+	e.scripts[sha] = fn
+	return sha, nil
+}
+
+// Exists reports whether the script with the given digest is cached.
+func (e *Engine) Exists(sha string) bool {
+	_, ok := e.scripts[strings.ToLower(sha)]
+	return ok
+}
+
+// Flush removes the cached scripts and replaces the Lua state with a new one.
+func (e *Engine) Flush() {
+	e.state.Close()
+	e.reset()
+}
+
+// Eval runs a script, caching it like Load.
+func (e *Engine) Eval(ctx context.Context, body string, keys, argv []string, caller Caller) (resp.Payload, error) {
+	sha, err := e.Load(body)
+	if err != nil {
+		return nil, err
+	}
+	return e.EvalSHA(ctx, sha, keys, argv, caller)
+}
+
+// EvalSHA runs a cached script, KEYS and ARGV being set to keys and argv, caller running its commands.
+// canceling ctx stops the script, which then fails with the cause of the cancellation.
+func (e *Engine) EvalSHA(ctx context.Context, sha string, keys, argv []string, caller Caller) (resp.Payload, error) {
+	sha = strings.ToLower(sha)
+	fn, ok := e.scripts[sha]
+	if !ok {
+		return nil, errNoScript
+	}
+
+	ls := e.state
+	e.globals.RawSetString("KEYS", stringsTable(ls, keys))
+	e.globals.RawSetString("ARGV", stringsTable(ls, argv))
+	e.caller = caller
+	defer func() { e.caller = nil }()
+	ls.SetContext(ctx)
+	defer ls.RemoveContext()
+
+	if err := ls.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}); err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) {
+			if tbl, ok := apiErr.Object.(*lua.LTable); ok {
+				if reply, ok := errorReply(tbl); ok {
+					return nil, reply
+				}
+			}
+		}
+		return nil, resp.NewSimpleError(fmt.Sprintf("%s script: %s", errorMessage(err), sha))
+	}
+
+	result := ls.Get(-1)
+	ls.Pop(1)
+	return toRESP(ls, result), nil
+}
+
+// call implements redis.call and redis.pcall, the former raising the errors of the command.
+func (e *Engine) call(ls *lua.LState, raise bool) int {
+	n := ls.GetTop()
+	if n == 0 {
+		ls.RaiseError("Please specify at least one argument for this redis lib call")
+		return 0
+	}
+	args := make([]string, n)
+	for i := range args {
+		arg := ls.Get(i + 1)
+		if arg.Type() != lua.LTString && arg.Type() != lua.LTNumber {
+			ls.RaiseError("Lua redis lib command arguments must be strings or integers")
+			return 0
+		}
+		args[i] = lua.LVAsString(arg)
+	}
+	if e.caller == nil {
+		ls.RaiseError("redis.call is not available outside of a script")
+		return 0
+	}
+
+	result := toLua(ls, e.caller(args))
+	if tbl, ok := result.(*lua.LTable); ok && raise && tbl.RawGetString("err") != lua.LNil {
+		ls.Error(tbl, 1)
+		return 0
+	}
+	ls.Push(result)
+	return 1
+}
+
+// log implements redis.log, writing to the server log.
+func (e *Engine) log(ls *lua.LState) int {
+	level := ls.CheckInt(1)
+	parts := make([]string, 0, ls.GetTop()-1)
+	for i := 2; i <= ls.GetTop(); i++ {
+		parts = append(parts, lua.LVAsString(ls.Get(i)))
+	}
+	msg := strings.Join(parts, " ")
+
+	switch level {
+	case 0:
+		e.logger.Debug(msg)
+	case 1, 2:
+		e.logger.Info(msg)
+	default:
+		e.logger.Warn(msg)
+	}
+	return 0
+}
+
+// replyTable returns redis.error_reply or redis.status_reply, building a table with the given field.
+func replyTable(field string) lua.LGFunction {
+	return func(ls *lua.LState) int {
+		tbl := ls.NewTable()
+		tbl.RawSetString(field, lua.LString(ls.CheckString(1)))
+		ls.Push(tbl)
+		return 1
+	}
+}
+
+func sha1Hex(ls *lua.LState) int {
+	ls.Push(lua.LString(SHA1(ls.CheckString(1))))
+	return 1
+}
+
+func stringsTable(ls *lua.LState, strs []string) *lua.LTable {
+	tbl := ls.CreateTable(len(strs), 0)
+	for _, s := range strs {
+		tbl.Append(lua.LString(s))
+	}
+	return tbl
+}
+
+// errorMessage returns the message of an error raised by Lua, without its stack trace.
+// it is put on a single line, as error replies cannot span several.
+func errorMessage(err error) string {
+	msg := err.Error()
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) && apiErr.Object != nil {
+		msg = apiErr.Object.String()
+	}
+	return strings.Join(strings.Fields(msg), " ")
+}
+
+// errorReply converts a table with an err field to the error it stands for.
+func errorReply(tbl *lua.LTable) (resp.SimpleError, bool) {
+	msg, ok := tbl.RawGetString("err").(lua.LString)
+	if !ok {
+		return resp.SimpleError{}, false
+	}
+	// the first word of the message is the error prefix, such as ERR or WRONGTYPE
+	if prefix, rest, found := strings.Cut(string(msg), " "); found {
+		return resp.NewPrefixedError(prefix, rest), true
+	}
+	return resp.NewSimpleError(string(msg)), true
+}
+
+// toLua converts a command reply to a Lua value.
+func toLua(ls *lua.LState, p any) lua.LValue {
+	switch v := p.(type) {
+	case resp.BulkString:
+		return lua.LString(v)
+	case resp.Integer:
+		return lua.LNumber(v)
+	case resp.SimpleString:
+		tbl := ls.NewTable()
+		tbl.RawSetString("ok", lua.LString(v))
+		return tbl
+	case resp.SimpleError:
+		tbl := ls.NewTable()
+		tbl.RawSetString("err", lua.LString(v.Error()))
+		return tbl
+	case resp.Array:
+		tbl := ls.CreateTable(len(v), 0)
+		for _, elem := range v {
+			tbl.Append(toLua(ls, elem))
+		}
+		return tbl
+	default:
+		// null replies
+		return lua.LFalse
+	}
+}
+
+// toRESP converts the value returned by a script to its reply.
+// numbers are truncated to integers, arrays stop at their first nil and false is a null reply.
+func toRESP(ls *lua.LState, v lua.LValue) resp.Payload {
+	switch v := v.(type) {
+	case lua.LString:
+		return resp.BulkString(v)
+	case lua.LNumber:
+		return resp.Integer(int64(v))
+	case lua.LBool:
+		if v {
+			return resp.Integer(1)
+		}
+		return resp.NULL
+	case *lua.LTable:
+		if reply, ok := errorReply(v); ok {
+			return reply
+		}
+		if ok, isString := v.RawGetString("ok").(lua.LString); isString {
+			return resp.SimpleString(ok)
+		}
+		result := resp.Array{}
+		for i := 1; ; i++ {
+			elem := ls.RawGetInt(v, i)
+			if elem == lua.LNil {
+				return result
+			}
+			result = append(result, toRESP(ls, elem))
+		}
+	default:
+		return resp.NULL
+	}
+}
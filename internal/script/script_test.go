@@ -0,0 +1,180 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCaller replies to GET with the stored value and records the commands it runs.
+type fakeCaller struct {
+	values map[string]string
+	calls  [][]string
+}
+
+func (f *fakeCaller) call(args []string) resp.Payload {
+	f.calls = append(f.calls, args)
+	switch args[0] {
+	case "GET":
+		if value, ok := f.values[args[1]]; ok {
+			return resp.BulkString(value)
+		}
+		return resp.NULL
+	case "PING":
+		return resp.SimpleString("PONG")
+	case "LIST":
+		return resp.Array{resp.Integer(1), resp.BulkString("two"), resp.NULL}
+	default:
+		return resp.NewPrefixedError("WRONGTYPE", "Operation against a key holding the wrong kind of value")
+	}
+}
+
+func newEngine() *Engine {
+	return New(slog.New(slog.DiscardHandler))
+}
+
+func TestEval(t *testing.T) {
+	e := newEngine()
+	caller := &fakeCaller{values: map[string]string{"k": "v"}}
+
+	reply, err := e.Eval(t.Context(), "return {KEYS[1], ARGV[1], ARGV[2]}", []string{"k"}, []string{"a", "b"}, caller.call)
+	require.NoError(t, err)
+	require.Equal(t, resp.Array{resp.BulkString("k"), resp.BulkString("a"), resp.BulkString("b")}, reply)
+
+	reply, err = e.Eval(t.Context(), "return redis.call('GET', KEYS[1])", []string{"k"}, nil, caller.call)
+	require.NoError(t, err)
+	require.Equal(t, resp.BulkString("v"), reply)
+
+	reply, err = e.Eval(t.Context(), "return redis.call('GET', 'missing') == false", nil, nil, caller.call)
+	require.NoError(t, err)
+	require.Equal(t, resp.Integer(1), reply)
+
+	_, err = e.Eval(t.Context(), "redis.call('SET', 1.5, 'x')", nil, nil, caller.call)
+	require.EqualError(t, err, "WRONGTYPE Operation against a key holding the wrong kind of value")
+	require.Equal(t, []string{"SET", "1.5", "x"}, caller.calls[len(caller.calls)-1])
+}
+
+func TestConversions(t *testing.T) {
+	e := newEngine()
+	caller := &fakeCaller{}
+
+	cases := []struct {
+		script string
+		reply  resp.Payload
+	}{
+		{"return 3.99", resp.Integer(3)},
+		{"return 'str'", resp.BulkString("str")},
+		{"return true", resp.Integer(1)},
+		{"return false", resp.NULL},
+		{"return nil", resp.NULL},
+		{"return {1, 2, nil, 4}", resp.Array{resp.Integer(1), resp.Integer(2)}},
+		{"return redis.call('PING')", resp.SimpleString("PONG")},
+		{"return redis.status_reply('FINE')", resp.SimpleString("FINE")},
+		{"return redis.error_reply('MY failure')", resp.NewPrefixedError("MY", "failure")},
+		{"return redis.pcall('SET', 'k')", resp.NewPrefixedError("WRONGTYPE", "Operation against a key holding the wrong kind of value")},
+		{"return redis.call('LIST')", resp.Array{resp.Integer(1), resp.BulkString("two"), resp.NULL}},
+		{"return type(redis.call('LIST')[3])", resp.BulkString("boolean")},
+		{"return redis.sha1hex('')", resp.BulkString("da39a3ee5e6b4b0d3255bfef95601890afd80709")},
+	}
+	for _, c := range cases {
+		reply, err := e.Eval(t.Context(), c.script, nil, nil, caller.call)
+		require.NoError(t, err, c.script)
+		require.Equal(t, c.reply, reply, c.script)
+	}
+}
+
+func TestLoadAndEvalSHA(t *testing.T) {
+	e := newEngine()
+	caller := &fakeCaller{}
+
+	sha, err := e.Load("return 1")
+	require.NoError(t, err)
+	require.Equal(t, "e0e1f9fabfc9d4800c877a703b823ac0578ff8db", sha)
+	require.True(t, e.Exists(sha))
+
+	reply, err := e.EvalSHA(t.Context(), "E0E1F9FABFC9D4800C877A703B823AC0578FF8DB", nil, nil, caller.call)
+	require.NoError(t, err)
+	require.Equal(t, resp.Integer(1), reply)
+
+	e.Flush()
+	require.False(t, e.Exists(sha))
+	_, err = e.EvalSHA(t.Context(), sha, nil, nil, caller.call)
+	require.EqualError(t, err, "NOSCRIPT No matching script. Please use EVAL.")
+
+	_, err = e.Load("return (")
+	require.ErrorContains(t, err, "Error compiling script (new function): ")
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	e := newEngine()
+	caller := &fakeCaller{}
+
+	_, err := e.Eval(t.Context(), "return redis.call()", nil, nil, caller.call)
+	require.ErrorContains(t, err, "Please specify at least one argument for this redis lib call")
+
+	_, err = e.Eval(t.Context(), "return redis.call('GET', {})", nil, nil, caller.call)
+	require.ErrorContains(t, err, "Lua redis lib command arguments must be strings or integers")
+
+	_, err = e.Eval(t.Context(), "error('boom')", nil, nil, caller.call)
+	require.ErrorContains(t, err, "boom script: ")
+
+	_, err = e.Eval(t.Context(), "return dofile('/etc/passwd')", nil, nil, caller.call)
+	require.Error(t, err)
+}
+
+func TestReadOnlyGlobals(t *testing.T) {
+	e := newEngine()
+	caller := &fakeCaller{values: map[string]string{"k": "v"}}
+
+	for _, script := range []string{
+		"x = 5",
+		"redis = nil",
+		"redis.call = nil",
+		"_G.redis = nil",
+		"string.rep = nil",
+		"KEYS = {}",
+		"function f() end",
+		"rawset(_G, 'redis', {})",
+		"rawset(redis, 'call', nil)",
+		"setmetatable(_G, nil)",
+		"loadstring('redis = nil')()",
+		"getmetatable('').__index.rep = nil",
+	} {
+		_, err := e.Eval(t.Context(), script, nil, nil, caller.call)
+		require.Error(t, err, script)
+	}
+
+	_, err := e.Eval(t.Context(), "x = 5", nil, nil, caller.call)
+	require.ErrorContains(t, err, "Attempt to modify a readonly table")
+
+	// the scripts above changed nothing the next ones run with
+	reply, err := e.Eval(t.Context(), "return {x == nil, redis.call('GET', KEYS[1]), string.rep('a', 2), ('b'):upper()}", []string{"k"}, nil, caller.call)
+	require.NoError(t, err)
+	require.Equal(t, resp.Array{resp.Integer(1), resp.BulkString("v"), resp.BulkString("aa"), resp.BulkString("B")}, reply)
+
+	// tables created by the script, KEYS and ARGV included, are still writable
+	reply, err = e.Eval(t.Context(), "local t = {} t.a = 1 rawset(t, 'b', 2) KEYS[1] = 'changed' return {t.a, t.b, KEYS[1]}", []string{"k"}, nil, caller.call)
+	require.NoError(t, err)
+	require.Equal(t, resp.Array{resp.Integer(1), resp.Integer(2), resp.BulkString("changed")}, reply)
+}
+
+func TestCanceledScript(t *testing.T) {
+	e := newEngine()
+	caller := &fakeCaller{}
+	killed := errors.New("killed")
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	time.AfterFunc(10*time.Millisecond, func() { cancel(killed) })
+	_, err := e.Eval(ctx, "while true do pcall(function() while true do end end) end", nil, nil, caller.call)
+	require.ErrorIs(t, err, killed)
+
+	// the state is still usable
+	reply, err := e.Eval(t.Context(), "return 1", nil, nil, caller.call)
+	require.NoError(t, err)
+	require.Equal(t, resp.Integer(1), reply)
+}
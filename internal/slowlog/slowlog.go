@@ -0,0 +1,127 @@
+// Package slowlog keeps the last commands that ran for longer than a threshold, as reported by SLOWLOG GET.
+package slowlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// arguments kept per entry, the last one summarizing the ones left out
+	maxArgs = 32
+	// bytes kept per argument
+	maxArgLen = 128
+)
+
+// Entry is a slow command.
+type Entry struct {
+	ID int64
+	// when the command started
+	Time     time.Time
+	Duration time.Duration
+	// the command and its arguments, truncated
+	Args []string
+	// address and name of the client that sent the command, the name being empty unless set with CLIENT SETNAME
+	ClientAddr string
+	ClientName string
+}
+
+// Log is a ring buffer of the most recent entries, it is safe for concurrent use.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+	maxLen  int
+	// index of the oldest entry once the log is full
+	head   int
+	nextID int64
+}
+
+// New creates a log holding up to maxLen entries.
+func New(maxLen int) *Log {
+	return &Log{maxLen: maxLen}
+}
+
+// Add records an entry, dropping the oldest one once the log is full.
+// the ID of the entry is assigned, and its arguments are truncated like Redis does.
+func (l *Log) Add(entry Entry) {
+	entry.Args = truncate(entry.Args)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.ID = l.nextID
+	l.nextID++
+	switch {
+	case l.maxLen == 0:
+	case len(l.entries) < l.maxLen:
+		l.entries = append(l.entries, entry)
+	default:
+		l.entries[l.head] = entry
+		l.head = (l.head + 1) % len(l.entries)
+	}
+}
+
+// Entries returns up to count entries, the most recent first, or every entry when count is negative.
+func (l *Log) Entries(count int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if count < 0 || count > len(l.entries) {
+		count = len(l.entries)
+	}
+	entries := make([]Entry, count)
+	for i := range entries {
+		entries[i] = l.entries[(l.head+len(l.entries)-1-i)%len(l.entries)]
+	}
+	return entries
+}
+
+// Len returns the number of entries held.
+func (l *Log) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.entries)
+}
+
+// Reset removes every entry, the IDs keep increasing.
+func (l *Log) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = nil
+	l.head = 0
+}
+
+// SetMaxLen changes the number of entries held, dropping the oldest ones that do not fit anymore.
+func (l *Log) SetMaxLen(maxLen int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// oldest first, from the head
+	entries := append(l.entries[l.head:len(l.entries):len(l.entries)], l.entries[:l.head]...)
+	if len(entries) > maxLen {
+		entries = entries[len(entries)-maxLen:]
+	}
+	l.entries = entries
+	l.head = 0
+	l.maxLen = maxLen
+}
+
+// truncate keeps the first arguments and the first bytes of each of them, telling how much was left out.
+func truncate(args []string) []string {
+	n := min(len(args), maxArgs)
+	truncated := make([]string, n)
+	for i := range truncated {
+		if i == maxArgs-1 && len(args) > maxArgs {
+			truncated[i] = fmt.Sprintf("... (%d more arguments)", len(args)-i)
+			break
+		}
+		truncated[i] = args[i]
+		if len(args[i]) > maxArgLen {
+			truncated[i] = fmt.Sprintf("%s... (%d more bytes)", args[i][:maxArgLen], len(args[i])-maxArgLen)
+		}
+	}
+	return truncated
+}
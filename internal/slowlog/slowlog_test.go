@@ -0,0 +1,60 @@
+package slowlog
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ids(entries []Entry) []int64 {
+	ids := make([]int64, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func TestLog(t *testing.T) {
+	l := New(3)
+	for range 5 {
+		l.Add(Entry{Args: []string{"GET", "key"}})
+	}
+	require.Equal(t, 3, l.Len())
+	require.Equal(t, []int64{4, 3, 2}, ids(l.Entries(-1)))
+	require.Equal(t, []int64{4, 3}, ids(l.Entries(2)))
+
+	l.SetMaxLen(2)
+	require.Equal(t, []int64{4, 3}, ids(l.Entries(10)))
+	l.SetMaxLen(4)
+	l.Add(Entry{})
+	l.Add(Entry{})
+	l.Add(Entry{})
+	require.Equal(t, []int64{7, 6, 5, 4}, ids(l.Entries(-1)))
+
+	l.Reset()
+	require.Zero(t, l.Len())
+	l.Add(Entry{})
+	require.Equal(t, []int64{8}, ids(l.Entries(-1)), "IDs are not reused after a reset")
+
+	l.SetMaxLen(0)
+	l.Add(Entry{})
+	require.Empty(t, l.Entries(-1))
+}
+
+func TestTruncate(t *testing.T) {
+	args := make([]string, 40)
+	for i := range args {
+		args[i] = strconv.Itoa(i)
+	}
+	args[1] = strings.Repeat("x", 130)
+
+	truncated := truncate(args)
+	require.Len(t, truncated, maxArgs)
+	require.Equal(t, strings.Repeat("x", 128)+"... (2 more bytes)", truncated[1])
+	require.Equal(t, "30", truncated[30])
+	require.Equal(t, "... (9 more arguments)", truncated[31])
+
+	require.Equal(t, args[2:34], truncate(args[2:34]), "32 arguments are kept")
+}
@@ -0,0 +1,130 @@
+// Package stats collects the server-wide counters reported by the INFO command.
+package stats
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is safe for concurrent use, every counter is updated atomically.
+type Stats struct {
+	startTime time.Time
+	runID     string
+
+	ConnectedClients atomic.Int64
+	TotalConnections atomic.Int64
+	TotalCommands    atomic.Int64
+	KeyspaceHits     atomic.Int64
+	KeyspaceMisses   atomic.Int64
+	ExpiredKeys      atomic.Int64
+	EvictedKeys      atomic.Int64
+	NetInputBytes    atomic.Int64
+	NetOutputBytes   atomic.Int64
+	// requests that are not valid RESP, the connection being closed after each of them
+	ParseErrors atomic.Int64
+	// synchronizations of replicas: full ones, partial ones served from the backlog and partial ones that were refused
+	SyncFull       atomic.Int64
+	SyncPartialOK  atomic.Int64
+	SyncPartialErr atomic.Int64
+
+	peakMemory atomic.Uint64
+}
+
+func New() *Stats {
+	return &Stats{
+		startTime: time.Now(),
+		runID:     newRunID(),
+	}
+}
+
+// RunID identifies this server process, it changes on every restart.
+func (s *Stats) RunID() string {
+	return s.runID
+}
+
+func (s *Stats) StartTime() time.Time {
+	return s.startTime
+}
+
+func (s *Stats) Uptime() time.Duration {
+	return time.Since(s.startTime)
+}
+
+// Reset clears the counters, as done by CONFIG RESETSTAT.
+// gauges such as the number of connected clients are left untouched.
+func (s *Stats) Reset() {
+	s.TotalConnections.Store(0)
+	s.TotalCommands.Store(0)
+	s.KeyspaceHits.Store(0)
+	s.KeyspaceMisses.Store(0)
+	s.ExpiredKeys.Store(0)
+	s.EvictedKeys.Store(0)
+	s.NetInputBytes.Store(0)
+	s.NetOutputBytes.Store(0)
+	s.ParseErrors.Store(0)
+	s.SyncFull.Store(0)
+	s.SyncPartialOK.Store(0)
+	s.SyncPartialErr.Store(0)
+	s.peakMemory.Store(0)
+}
+
+// Memory describes the memory usage of the process.
+type Memory struct {
+	// bytes of allocated heap objects
+	Used uint64
+	// bytes obtained from the OS
+	System uint64
+	// highest Used observed since start or the last Reset
+	Peak uint64
+}
+
+const (
+	metricHeapObjects = "/memory/classes/heap/objects:bytes"
+	metricTotalMemory = "/memory/classes/total:bytes"
+)
+
+// Memory reads the current memory usage.
+func (s *Stats) Memory() Memory {
+	samples := []metrics.Sample{{Name: metricHeapObjects}, {Name: metricTotalMemory}}
+	metrics.Read(samples)
+
+	used := samples[0].Value.Uint64()
+	s.updatePeak(used)
+
+	return Memory{
+		Used:   used,
+		System: samples[1].Value.Uint64(),
+		Peak:   s.peakMemory.Load(),
+	}
+}
+
+// UsedMemory returns the bytes of allocated heap objects, including garbage that has not been collected yet.
+// unlike Memory it only reads one metric, so it is cheap enough to be called for every write command.
+func (s *Stats) UsedMemory() uint64 {
+	samples := []metrics.Sample{{Name: metricHeapObjects}}
+	metrics.Read(samples)
+
+	used := samples[0].Value.Uint64()
+	s.updatePeak(used)
+	return used
+}
+
+func (s *Stats) updatePeak(used uint64) {
+	peak := s.peakMemory.Load()
+	for used > peak {
+		if s.peakMemory.CompareAndSwap(peak, used) {
+			return
+		}
+		peak = s.peakMemory.Load()
+	}
+}
+
+func newRunID() string {
+	b := make([]byte, 20)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
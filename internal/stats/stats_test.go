@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReset(t *testing.T) {
+	s := New()
+	s.ConnectedClients.Add(1)
+	s.TotalConnections.Add(1)
+	s.TotalCommands.Add(10)
+	s.KeyspaceHits.Add(1)
+	s.KeyspaceMisses.Add(1)
+	s.ExpiredKeys.Add(1)
+	s.EvictedKeys.Add(1)
+	s.NetInputBytes.Add(10)
+	s.NetOutputBytes.Add(10)
+	s.ParseErrors.Add(1)
+
+	s.Reset()
+
+	require.Equal(t, int64(1), s.ConnectedClients.Load(), "Connected clients is a gauge and survives a reset")
+	require.Zero(t, s.TotalConnections.Load())
+	require.Zero(t, s.TotalCommands.Load())
+	require.Zero(t, s.KeyspaceHits.Load())
+	require.Zero(t, s.KeyspaceMisses.Load())
+	require.Zero(t, s.ExpiredKeys.Load())
+	require.Zero(t, s.EvictedKeys.Load())
+	require.Zero(t, s.NetInputBytes.Load())
+	require.Zero(t, s.NetOutputBytes.Load())
+	require.Zero(t, s.ParseErrors.Load())
+}
+
+func TestMemoryPeak(t *testing.T) {
+	s := New()
+	first := s.Memory()
+	require.NotZero(t, first.Used)
+	require.GreaterOrEqual(t, first.Peak, first.Used)
+
+	second := s.Memory()
+	require.GreaterOrEqual(t, second.Peak, first.Peak, "Peak should never decrease")
+}
+
+func TestRunID(t *testing.T) {
+	require.Len(t, New().RunID(), 40)
+	require.NotEqual(t, New().RunID(), New().RunID())
+}
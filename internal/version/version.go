@@ -0,0 +1,9 @@
+// Package version holds build information injected at link time.
+package version
+
+// Version is the gvalkey release, overridden with -ldflags "-X github.com/PlayerNeo42/gvalkey/internal/version.Version=...".
+var Version = "dev"
+
+// RedisVersion is the Redis release whose behavior gvalkey reports compatibility with.
+// clients and monitoring agents gate features on it, so it is kept separate from Version.
+const RedisVersion = "7.2.0"
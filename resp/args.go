@@ -12,3 +12,211 @@ type SetArgs struct {
 	XX       bool
 	Get      bool
 }
+
+// TrimArgs holds the MAXLEN|MINID [=|~] threshold [LIMIT count] options of XADD and XTRIM.
+type TrimArgs struct {
+	// MAXLEN or MINID, empty when the stream is not trimmed
+	Strategy  BulkString
+	Approx    bool
+	Threshold string
+	// maximum number of entries to remove, 0 meaning the default
+	Limit int64
+}
+
+type XAddArgs struct {
+	Key        string
+	NoMkStream bool
+	Trim       TrimArgs
+	// "*", "<ms>-*" or an explicit ID
+	ID     string
+	Fields []string
+}
+
+// XReadArgs holds the arguments of XREAD and XREADGROUP.
+type XReadArgs struct {
+	// consumer group and consumer, only set for XREADGROUP
+	Group    string
+	Consumer string
+
+	// maximum number of entries per stream, 0 meaning no limit
+	Count int64
+	// whether to block, for at most Timeout with 0 meaning forever
+	Block   bool
+	Timeout time.Duration
+	NoAck   bool
+
+	Keys []string
+	IDs  []string
+}
+
+// XPendingArgs holds the arguments of XPENDING, Start is empty for the summary form.
+type XPendingArgs struct {
+	Key   string
+	Group string
+
+	MinIdle  time.Duration
+	Start    string
+	End      string
+	Count    int64
+	Consumer string
+}
+
+// XClaimArgs holds the arguments of XCLAIM.
+type XClaimArgs struct {
+	Key      string
+	Group    string
+	Consumer string
+	MinIdle  time.Duration
+	IDs      []string
+
+	// delivery time of the claimed entries, set by either IDLE or TIME
+	DeliveryTime *time.Time
+	RetryCount   *int64
+	Force        bool
+	JustID       bool
+}
+
+// XAutoClaimArgs holds the arguments of XAUTOCLAIM.
+type XAutoClaimArgs struct {
+	Key      string
+	Group    string
+	Consumer string
+	MinIdle  time.Duration
+	Start    string
+	Count    int64
+	JustID   bool
+}
+
+// BitRangeArgs holds the optional range of BITCOUNT and BITPOS.
+type BitRangeArgs struct {
+	Start int64
+	// -1 when the end is not given
+	End      int64
+	EndGiven bool
+	// whether the range is in bits rather than bytes
+	BitUnit bool
+}
+
+// BitFieldOp is a GET, SET or INCRBY operation of BITFIELD.
+type BitFieldOp struct {
+	Kind   BulkString
+	Signed bool
+	Bits   uint
+	Offset uint64
+	// value of SET or increment of INCRBY
+	Value int64
+	// WRAP, SAT or FAIL, in effect for SET and INCRBY
+	Overflow BulkString
+}
+
+// GeoMember is a position of GEOADD.
+type GeoMember struct {
+	Lon, Lat float64
+	Member   string
+}
+
+type GeoAddArgs struct {
+	Key     string
+	NX      bool
+	XX      bool
+	CH      bool
+	Members []GeoMember
+}
+
+// GeoSearchArgs holds the arguments of GEOSEARCH and GEOSEARCHSTORE.
+type GeoSearchArgs struct {
+	Key string
+	// destination of GEOSEARCHSTORE
+	Dest string
+
+	// FROMMEMBER or FROMLONLAT
+	From     BulkString
+	Member   string
+	Lon, Lat float64
+
+	// BYRADIUS or BYBOX, the sizes being in Unit
+	By            BulkString
+	Radius        float64
+	Width, Height float64
+	// meters per unit
+	Unit float64
+
+	// ASC, DESC or empty when the results are not sorted
+	Order BulkString
+	// 0 meaning no limit
+	Count     int64
+	Any       bool
+	WithCoord bool
+	WithDist  bool
+	WithHash  bool
+	StoreDist bool
+}
+
+// HExpireArgs holds the arguments of HEXPIRE, HPEXPIRE, HEXPIREAT and HPEXPIREAT.
+type HExpireArgs struct {
+	Key      string
+	ExpireAt time.Time
+	// NX, XX, GT, LT or empty
+	Condition BulkString
+	Fields    []string
+}
+
+// EvalArgs holds the arguments of EVAL, EVALSHA and their read-only variants.
+type EvalArgs struct {
+	// body of the script, or its SHA1 digest for EVALSHA
+	Script string
+	Keys   []string
+	Args   []string
+}
+
+// ReplicaOfArgs holds the arguments of REPLICAOF, NoOne turning the replica back into a master.
+type ReplicaOfArgs struct {
+	Host  string
+	Port  int
+	NoOne bool
+}
+
+// PSyncArgs holds the arguments of PSYNC, "?" and -1 asking for a full synchronization.
+type PSyncArgs struct {
+	ReplID string
+	Offset int64
+}
+
+// SetSlotArgs holds the arguments of CLUSTER SETSLOT.
+type SetSlotArgs struct {
+	Slot int
+	// IMPORTING, MIGRATING, NODE or STABLE
+	State BulkString
+	// empty for STABLE
+	NodeID string
+}
+
+// MigrateArgs holds the arguments of MIGRATE.
+type MigrateArgs struct {
+	Host    string
+	Port    int
+	DB      int
+	Timeout time.Duration
+	Copy    bool
+	Replace bool
+	Keys    []string
+}
+
+// RestoreArgs holds the arguments of RESTORE.
+type RestoreArgs struct {
+	Key string
+	// zero when the key does not expire
+	ExpireAt time.Time
+	Payload  []byte
+	Replace  bool
+}
+
+// ScanArgs holds the arguments of SCAN.
+type ScanArgs struct {
+	Cursor uint64
+	// glob-style pattern of the keys to return, empty for all of them
+	Match string
+	Count int
+	// type of the keys to return, as TYPE names it, empty for all of them
+	Type string
+}
@@ -3,6 +3,7 @@ package resp
 // response constants
 var (
 	OK   = SimpleString("OK")
+	PONG = SimpleString("PONG")
 	NULL = Null{}
 )
 
@@ -25,6 +26,8 @@ var (
 	TYPE   = BulkString("TYPE")
 	KEYS   = BulkString("KEYS")
 	SCAN   = BulkString("SCAN")
+	MATCH  = BulkString("MATCH")
+	STRLEN = BulkString("STRLEN")
 
 	INCR   = BulkString("INCR")
 	DECR   = BulkString("DECR")
@@ -51,6 +54,15 @@ var (
 	RPOP   = BulkString("RPOP")
 	LRANGE = BulkString("LRANGE")
 	LLEN   = BulkString("LLEN")
+	LMOVE  = BulkString("LMOVE")
+	LEFT   = BulkString("LEFT")
+	RIGHT  = BulkString("RIGHT")
+
+	RPOPLPUSH  = BulkString("RPOPLPUSH")
+	BLPOP      = BulkString("BLPOP")
+	BRPOP      = BulkString("BRPOP")
+	BLMOVE     = BulkString("BLMOVE")
+	BRPOPLPUSH = BulkString("BRPOPLPUSH")
 
 	// set commands
 	SADD      = BulkString("SADD")
@@ -69,4 +81,197 @@ var (
 	ZCARD     = BulkString("ZCARD")
 
 	COMMAND = BulkString("COMMAND")
+
+	// stream commands
+	XADD       = BulkString("XADD")
+	XLEN       = BulkString("XLEN")
+	XRANGE     = BulkString("XRANGE")
+	XREVRANGE  = BulkString("XREVRANGE")
+	XDEL       = BulkString("XDEL")
+	XTRIM      = BulkString("XTRIM")
+	XSETID     = BulkString("XSETID")
+	XREAD      = BulkString("XREAD")
+	XREADGROUP = BulkString("XREADGROUP")
+	XGROUP     = BulkString("XGROUP")
+	XACK       = BulkString("XACK")
+	XPENDING   = BulkString("XPENDING")
+	XCLAIM     = BulkString("XCLAIM")
+	XAUTOCLAIM = BulkString("XAUTOCLAIM")
+	XINFO      = BulkString("XINFO")
+
+	MAXLEN         = BulkString("MAXLEN")
+	MINID          = BulkString("MINID")
+	LIMIT          = BulkString("LIMIT")
+	NOMKSTREAM     = BulkString("NOMKSTREAM")
+	COUNT          = BulkString("COUNT")
+	BLOCK          = BulkString("BLOCK")
+	STREAMS        = BulkString("STREAMS")
+	GROUP          = BulkString("GROUP")
+	NOACK          = BulkString("NOACK")
+	CREATE         = BulkString("CREATE")
+	DESTROY        = BulkString("DESTROY")
+	CREATECONSUMER = BulkString("CREATECONSUMER")
+	DELCONSUMER    = BulkString("DELCONSUMER")
+	SETID          = BulkString("SETID")
+	MKSTREAM       = BulkString("MKSTREAM")
+	IDLE           = BulkString("IDLE")
+	TIME           = BulkString("TIME")
+	RETRYCOUNT     = BulkString("RETRYCOUNT")
+	FORCE          = BulkString("FORCE")
+	JUSTID         = BulkString("JUSTID")
+	STREAM         = BulkString("STREAM")
+	GROUPS         = BulkString("GROUPS")
+	CONSUMERS      = BulkString("CONSUMERS")
+	ENTRIESREAD    = BulkString("ENTRIESREAD")
+	LASTID         = BulkString("LASTID")
+
+	// bitmap commands
+	SETBIT     = BulkString("SETBIT")
+	GETBIT     = BulkString("GETBIT")
+	BITCOUNT   = BulkString("BITCOUNT")
+	BITPOS     = BulkString("BITPOS")
+	BITOP      = BulkString("BITOP")
+	BITFIELD   = BulkString("BITFIELD")
+	BITFIELDRO = BulkString("BITFIELD_RO")
+
+	BYTE     = BulkString("BYTE")
+	BIT      = BulkString("BIT")
+	AND      = BulkString("AND")
+	OR       = BulkString("OR")
+	XOR      = BulkString("XOR")
+	NOT      = BulkString("NOT")
+	OVERFLOW = BulkString("OVERFLOW")
+	WRAP     = BulkString("WRAP")
+	SAT      = BulkString("SAT")
+	FAIL     = BulkString("FAIL")
+
+	// hash field expiration commands
+	HEXPIRE    = BulkString("HEXPIRE")
+	HPEXPIRE   = BulkString("HPEXPIRE")
+	HEXPIREAT  = BulkString("HEXPIREAT")
+	HPEXPIREAT = BulkString("HPEXPIREAT")
+	HTTL       = BulkString("HTTL")
+	HPTTL      = BulkString("HPTTL")
+	HPERSIST   = BulkString("HPERSIST")
+
+	FIELDS = BulkString("FIELDS")
+	GT     = BulkString("GT")
+	LT     = BulkString("LT")
+
+	// hyperloglog commands
+	PFADD   = BulkString("PFADD")
+	PFCOUNT = BulkString("PFCOUNT")
+	PFMERGE = BulkString("PFMERGE")
+
+	// geospatial commands
+	GEOADD         = BulkString("GEOADD")
+	GEOPOS         = BulkString("GEOPOS")
+	GEODIST        = BulkString("GEODIST")
+	GEOHASH        = BulkString("GEOHASH")
+	GEOSEARCH      = BulkString("GEOSEARCH")
+	GEOSEARCHSTORE = BulkString("GEOSEARCHSTORE")
+
+	CH         = BulkString("CH")
+	FROMMEMBER = BulkString("FROMMEMBER")
+	FROMLONLAT = BulkString("FROMLONLAT")
+	BYRADIUS   = BulkString("BYRADIUS")
+	BYBOX      = BulkString("BYBOX")
+	ASC        = BulkString("ASC")
+	DESC       = BulkString("DESC")
+	ANY        = BulkString("ANY")
+	WITHCOORD  = BulkString("WITHCOORD")
+	WITHDIST   = BulkString("WITHDIST")
+	WITHHASH   = BulkString("WITHHASH")
+	STOREDIST  = BulkString("STOREDIST")
+
+	// database commands
+	SELECT   = BulkString("SELECT")
+	SWAPDB   = BulkString("SWAPDB")
+	MOVE     = BulkString("MOVE")
+	FLUSHDB  = BulkString("FLUSHDB")
+	FLUSHALL = BulkString("FLUSHALL")
+	DBSIZE   = BulkString("DBSIZE")
+	ASYNC    = BulkString("ASYNC")
+	SYNC     = BulkString("SYNC")
+
+	// pub/sub commands
+	SUBSCRIBE    = BulkString("SUBSCRIBE")
+	UNSUBSCRIBE  = BulkString("UNSUBSCRIBE")
+	PSUBSCRIBE   = BulkString("PSUBSCRIBE")
+	PUNSUBSCRIBE = BulkString("PUNSUBSCRIBE")
+	PUBLISH      = BulkString("PUBLISH")
+
+	// scripting commands
+	EVAL      = BulkString("EVAL")
+	EVALSHA   = BulkString("EVALSHA")
+	EVALRO    = BulkString("EVAL_RO")
+	EVALSHARO = BulkString("EVALSHA_RO")
+	SCRIPT    = BulkString("SCRIPT")
+	LOAD      = BulkString("LOAD")
+	FLUSH     = BulkString("FLUSH")
+	KILL      = BulkString("KILL")
+
+	// replication commands
+	REPLICAOF     = BulkString("REPLICAOF")
+	SLAVEOF       = BulkString("SLAVEOF")
+	REPLCONF      = BulkString("REPLCONF")
+	PSYNC         = BulkString("PSYNC")
+	ROLE          = BulkString("ROLE")
+	NO            = BulkString("NO")
+	ONE           = BulkString("ONE")
+	LISTENINGPORT = BulkString("LISTENING-PORT")
+	CAPA          = BulkString("CAPA")
+	ACK           = BulkString("ACK")
+	GETACK        = BulkString("GETACK")
+
+	// cluster commands
+	CLUSTER         = BulkString("CLUSTER")
+	ASKING          = BulkString("ASKING")
+	MIGRATE         = BulkString("MIGRATE")
+	DUMP            = BulkString("DUMP")
+	RESTORE         = BulkString("RESTORE")
+	RESTOREASKING   = BulkString("RESTORE-ASKING")
+	MYID            = BulkString("MYID")
+	NODES           = BulkString("NODES")
+	SLOTS           = BulkString("SLOTS")
+	SHARDS          = BulkString("SHARDS")
+	KEYSLOT         = BulkString("KEYSLOT")
+	COUNTKEYSINSLOT = BulkString("COUNTKEYSINSLOT")
+	GETKEYSINSLOT   = BulkString("GETKEYSINSLOT")
+	ADDSLOTS        = BulkString("ADDSLOTS")
+	ADDSLOTSRANGE   = BulkString("ADDSLOTSRANGE")
+	DELSLOTS        = BulkString("DELSLOTS")
+	DELSLOTSRANGE   = BulkString("DELSLOTSRANGE")
+	SETSLOT         = BulkString("SETSLOT")
+	MEET            = BulkString("MEET")
+	FORGET          = BulkString("FORGET")
+	IMPORTING       = BulkString("IMPORTING")
+	MIGRATING       = BulkString("MIGRATING")
+	STABLE          = BulkString("STABLE")
+	NODE            = BulkString("NODE")
+	COPY            = BulkString("COPY")
+	REPLACE         = BulkString("REPLACE")
+	ABSTTL          = BulkString("ABSTTL")
+	IDLETIME        = BulkString("IDLETIME")
+	FREQ            = BulkString("FREQ")
+
+	// server commands
+	INFO      = BulkString("INFO")
+	CONFIG    = BulkString("CONFIG")
+	RESETSTAT = BulkString("RESETSTAT")
+	REWRITE   = BulkString("REWRITE")
+	SLOWLOG   = BulkString("SLOWLOG")
+	LEN       = BulkString("LEN")
+	RESET     = BulkString("RESET")
+	MONITOR   = BulkString("MONITOR")
+	PING      = BulkString("PING")
+	DOCS      = BulkString("DOCS")
+	CLIENT    = BulkString("CLIENT")
+	SETNAME   = BulkString("SETNAME")
+	GETNAME   = BulkString("GETNAME")
+
+	// authentication commands and options, only known so that MONITOR redacts their passwords
+	AUTH  = BulkString("AUTH")
+	AUTH2 = BulkString("AUTH2")
+	HELLO = BulkString("HELLO")
 )
@@ -0,0 +1,54 @@
+package resp
+
+import "io"
+
+// maxRetainedBuffer is the capacity above which an Encoder drops its buffer once written, so that a single large
+// reply does not keep its memory for the lifetime of the connection.
+const maxRetainedBuffer = 64 << 10
+
+// Encoder encodes payloads into a buffer that is reused once written, so that encoding does not allocate once the
+// buffer has grown to the size of the payloads. it is not safe for concurrent use.
+type Encoder struct {
+	buf []byte
+}
+
+// Encode appends the encoding of p to the buffer, which is left as it was when p cannot be encoded.
+func (e *Encoder) Encode(p Payload) error {
+	buf, err := p.AppendRESP(e.buf)
+	if err != nil {
+		return err
+	}
+	e.buf = buf
+	return nil
+}
+
+// Buffered returns the number of bytes encoded and not written yet.
+func (e *Encoder) Buffered() int {
+	return len(e.buf)
+}
+
+// Bytes returns the bytes encoded and not written yet, valid until the next call to the encoder.
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// WriteTo writes the encoded payloads to w and empties the buffer, even when the write fails.
+func (e *Encoder) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(e.buf)
+	e.Reset()
+	return int64(n), err
+}
+
+// Reset discards the encoded payloads.
+func (e *Encoder) Reset() {
+	if cap(e.buf) > maxRetainedBuffer {
+		e.buf = nil
+		return
+	}
+	e.buf = e.buf[:0]
+}
+
+// Encode returns the encoding of p in a new slice.
+func Encode(p Payload) ([]byte, error) {
+	return p.AppendRESP(nil)
+}
@@ -0,0 +1,199 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendRESP(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		payload  Payload
+		expected string
+	}{
+		{"NullArray", NullArray{}, "*-1\r\n"},
+		{"Negative integer", Integer(-42), ":-42\r\n"},
+		{"Double", Double(3.25), ",3.25\r\n"},
+		{"Infinite double", Double(math.Inf(-1)), ",-inf\r\n"},
+		{"Boolean", Boolean(true), "#t\r\n"},
+		{"BigNumber", BigNumber("3492890328409238509324850943850943825024385"), "(3492890328409238509324850943850943825024385\r\n"},
+		{"VerbatimString", VerbatimString{Format: "txt", Text: "Some string"}, "=15\r\ntxt:Some string\r\n"},
+		{"Map", Map{{Key: SimpleString("first"), Value: Integer(1)}}, "%1\r\n+first\r\n:1\r\n"},
+		{"Set", Set{BulkString("a")}, "~1\r\n$1\r\na\r\n"},
+		{"Push", Push{BulkString("message"), Array{}}, ">2\r\n$7\r\nmessage\r\n*0\r\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.payload.AppendRESP([]byte("prefix"))
+			require.NoError(t, err)
+			require.Equal(t, "prefix"+tc.expected, string(data))
+		})
+	}
+}
+
+func TestAppendRESPUnencodable(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		payload Payload
+		element string
+	}{
+		{"Array", Array{BulkString("a"), 42}, "int"},
+		{"Nested array", Array{Array{"plain string"}}, "string"},
+		{"Map key", Map{{Key: []byte("k"), Value: Integer(1)}}, "[]uint8"},
+		{"Map value", Map{{Key: BulkString("k"), Value: nil}}, "<nil>"},
+		{"Set", Set{struct{}{}}, "struct {}"},
+		{"Push", Push{BulkString("message"), 1.5}, "float64"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.payload.AppendRESP(nil)
+			require.ErrorIs(t, err, ErrUnencodable)
+			require.EqualError(t, err, "value cannot be encoded in RESP: "+tc.element)
+		})
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	t.Run("Payloads are buffered until written", func(t *testing.T) {
+		var enc Encoder
+		require.NoError(t, enc.Encode(SimpleString("OK")))
+		require.NoError(t, enc.Encode(Array{BulkString("GET"), BulkString("k")}))
+		require.Equal(t, 25, enc.Buffered())
+		require.Equal(t, "+OK\r\n*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", string(enc.Bytes()))
+
+		var out bytes.Buffer
+		n, err := enc.WriteTo(&out)
+		require.NoError(t, err)
+		require.Equal(t, int64(25), n)
+		require.Equal(t, "+OK\r\n*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", out.String())
+		require.Zero(t, enc.Buffered())
+	})
+
+	t.Run("Unencodable payloads leave the buffer untouched", func(t *testing.T) {
+		var enc Encoder
+		require.NoError(t, enc.Encode(Integer(1)))
+		require.ErrorIs(t, enc.Encode(Array{BulkString("a"), 1}), ErrUnencodable)
+		require.Equal(t, ":1\r\n", string(enc.Bytes()))
+	})
+
+	t.Run("Failed writes empty the buffer", func(t *testing.T) {
+		var enc Encoder
+		require.NoError(t, enc.Encode(OK))
+		_, err := enc.WriteTo(failingWriter{})
+		require.ErrorIs(t, err, io.ErrClosedPipe)
+		require.Zero(t, enc.Buffered())
+	})
+
+	t.Run("Large buffers are not retained", func(t *testing.T) {
+		var enc Encoder
+		require.NoError(t, enc.Encode(BulkString(make([]byte, 2*maxRetainedBuffer))))
+		_, err := enc.WriteTo(io.Discard)
+		require.NoError(t, err)
+		require.Nil(t, enc.Bytes())
+
+		require.NoError(t, enc.Encode(BulkString("small")))
+		_, err = enc.WriteTo(io.Discard)
+		require.NoError(t, err)
+		require.NotNil(t, enc.Bytes())
+	})
+
+	t.Run("Encoding does not allocate", func(t *testing.T) {
+		var enc Encoder
+		reply := benchmarkReply()
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = enc.Encode(reply)
+			_, _ = enc.WriteTo(io.Discard)
+		})
+		require.Zero(t, allocs)
+	})
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+// benchmarkReply is a typical reply, such as the one of HGETALL or XRANGE.
+func benchmarkReply() Payload {
+	reply := make(Array, 0, 32)
+	for i := range 10 {
+		reply = append(reply,
+			BulkString("field:"+strconv.Itoa(i)),
+			BulkString("a value of some thirty bytes.."),
+			Array{Integer(i), Double(float64(i) / 4), Null{}},
+		)
+	}
+	return reply
+}
+
+func BenchmarkEncode(b *testing.B) {
+	payloads := map[string]Payload{
+		"BulkString": BulkString("a value of some thirty bytes.."),
+		"Command":    Array{SET, BulkString("key:000123"), BulkString("a value of some thirty bytes..")},
+		"Reply":      benchmarkReply(),
+	}
+	for _, name := range []string{"BulkString", "Command", "Reply"} {
+		p := payloads[name]
+
+		b.Run(name+"/Encoder", func(b *testing.B) {
+			b.ReportAllocs()
+			var enc Encoder
+			for b.Loop() {
+				if err := enc.Encode(p); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := enc.WriteTo(io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		// the former path: a reader per payload, copied to the connection
+		b.Run(name+"/Reader", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := io.Copy(io.Discard, legacyReader(p)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// legacyReader encodes p the way payloads used to be encoded, for the benchmark: each payload formatted into a
+// new reader, aggregates into a buffer of 1 KiB that their elements were copied to.
+func legacyReader(p Payload) io.Reader {
+	switch v := p.(type) {
+	case Array:
+		buf := bytes.NewBuffer(make([]byte, 0, 1024))
+		buf.WriteByte('*')
+		buf.WriteString(strconv.FormatInt(int64(len(v)), 10))
+		buf.WriteString("\r\n")
+		for _, e := range v {
+			element, ok := e.(Payload)
+			if !ok {
+				return nil
+			}
+			if _, err := io.Copy(buf, legacyReader(element)); err != nil {
+				return nil
+			}
+		}
+		return buf
+	case BulkString:
+		return bytes.NewReader(fmt.Appendf(nil, "$%d\r\n%s\r\n", len(v), v))
+	case Integer:
+		return bytes.NewReader(fmt.Appendf(nil, ":%d\r\n", v))
+	case Double:
+		return bytes.NewReader(fmt.Appendf(nil, ",%s\r\n", v))
+	case Null:
+		return bytes.NewReader([]byte("$-1\r\n"))
+	default:
+		panic(errors.New("not used by the benchmark"))
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 // Parser is a RESP parser
 type Parser struct {
 	reader *bufio.Reader
+	// whether it parses the replies of a server rather than commands
+	replies bool
 }
 
 // NewParser creates a new parser instance
@@ -19,6 +24,15 @@ func NewParser(rd io.Reader) *Parser {
 	}
 }
 
+// NewReplyParser creates a parser for the replies of a server, which unlike commands may be errors, nulls and RESP3 types.
+// nulls are parsed as Null and NullArray, errors as SimpleError, and RESP3 attributes are skipped.
+func NewReplyParser(rd io.Reader) *Parser {
+	return &Parser{
+		reader:  bufio.NewReader(rd),
+		replies: true,
+	}
+}
+
 // Parse is the entry point of the parser
 // it returns the parsed value (any) and a potential error
 func (p *Parser) Parse() (any, error) {
@@ -37,6 +51,12 @@ func (p *Parser) Parse() (any, error) {
 		return p.parseSimpleString(line)
 	case ':': // integer
 		return p.parseInteger(line)
+	}
+
+	if p.replies {
+		return p.parseReply(line)
+	}
+	switch line[0] {
 	// RESP3
 	case '_', ',', '#', '!', '=', '(', '%', '~', '|', '>': // nil
 		return nil, fmt.Errorf("RESP3 type not supported yet: %q", line)
@@ -45,6 +65,65 @@ func (p *Parser) Parse() (any, error) {
 	}
 }
 
+// WaitInput blocks until input is available or the reader fails, without consuming anything.
+// it lets a connection waiting on something else notice that the client went away.
+func (p *Parser) WaitInput() error {
+	_, err := p.reader.Peek(1)
+	return err
+}
+
+// parseReply parses the types only found in the replies of a server.
+func (p *Parser) parseReply(line []byte) (any, error) {
+	switch line[0] {
+	case '-': // simple error
+		return parseError(line[1:]), nil
+	case '!': // bulk error
+		data, err := p.readBlob(line)
+		if err != nil {
+			return nil, err
+		}
+		return parseError(data), nil
+	case '_': // null
+		return Null{}, nil
+	case ',': // double
+		return parseDouble(line)
+	case '#': // boolean
+		if len(line) != 2 || (line[1] != 't' && line[1] != 'f') {
+			return nil, fmt.Errorf("parse boolean failed: %q", line)
+		}
+		return Boolean(line[1] == 't'), nil
+	case '(': // big number
+		if _, ok := new(big.Int).SetString(string(line[1:]), 10); !ok {
+			return nil, fmt.Errorf("parse big number failed: %q", line)
+		}
+		return BigNumber(line[1:]), nil
+	case '=': // verbatim string
+		data, err := p.readBlob(line)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < 4 || data[3] != ':' {
+			return nil, fmt.Errorf("parse verbatim string failed: %q", data)
+		}
+		return VerbatimString{Format: string(data[:3]), Text: string(data[4:])}, nil
+	case '%': // map
+		return p.parseMap(line)
+	case '~': // set
+		elements, err := p.parseElements(line)
+		return Set(elements), err
+	case '>': // push
+		elements, err := p.parseElements(line)
+		return Push(elements), err
+	case '|': // attribute, the metadata of the next value which is what callers get
+		if _, err := p.parseMap(line); err != nil {
+			return nil, err
+		}
+		return p.Parse()
+	default:
+		return nil, fmt.Errorf("unsupported RESP type: %q", line)
+	}
+}
+
 // readLine reads a line (terminated by \r\n)
 func (p *Parser) readLine() ([]byte, error) {
 	line, err := p.reader.ReadBytes('\n')
@@ -56,13 +135,16 @@ func (p *Parser) readLine() ([]byte, error) {
 }
 
 // parseArray parses an array
-func (p *Parser) parseArray(line []byte) (Array, error) {
+func (p *Parser) parseArray(line []byte) (any, error) {
 	// line example: *3
 	count, err := strconv.Atoi(string(line[1:]))
 	if err != nil {
 		return nil, fmt.Errorf("parse array length failed: %w", err)
 	}
 
+	if count < 0 && p.replies {
+		return NullArray{}, nil
+	}
 	// Redis's empty array or null array
 	if count <= 0 {
 		return Array{}, nil
@@ -84,23 +166,26 @@ func (p *Parser) parseArray(line []byte) (Array, error) {
 }
 
 // parseBulkString parses a bulk string
-func (p *Parser) parseBulkString(line []byte) (BulkString, error) {
+func (p *Parser) parseBulkString(line []byte) (any, error) {
 	// line example: $5
 	length, err := strconv.Atoi(string(line[1:]))
 	if err != nil {
-		return "", fmt.Errorf("parse bulk string length failed: %w", err)
+		return nil, fmt.Errorf("parse bulk string length failed: %w", err)
 	}
 
 	// Redis's null bulk string
 	if length == -1 {
-		return "", nil // return an empty string to represent nil
+		if p.replies {
+			return Null{}, nil
+		}
+		return BulkString(""), nil // return an empty string to represent nil
 	}
 
 	// read the string itself
 	data := make([]byte, length+2) // +2 to read the trailing \r\n
 	_, err = io.ReadFull(p.reader, data)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	return BulkString(data[:length]), nil
@@ -119,3 +204,76 @@ func (p *Parser) parseInteger(line []byte) (Integer, error) {
 	}
 	return Integer(num), nil
 }
+
+// readBlob reads the data of a bulk error or a verbatim string, whose line holds its length.
+func (p *Parser) readBlob(line []byte) ([]byte, error) {
+	length, err := strconv.Atoi(string(line[1:]))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("parse blob length failed: %q", line)
+	}
+	data := make([]byte, length+2)
+	if _, err := io.ReadFull(p.reader, data); err != nil {
+		return nil, err
+	}
+	return data[:length], nil
+}
+
+// parseElements parses the elements of a set or a push message.
+func (p *Parser) parseElements(line []byte) ([]any, error) {
+	count, err := strconv.Atoi(string(line[1:]))
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("parse aggregate length failed: %q", line)
+	}
+	elements := make([]any, 0, count)
+	for range count {
+		val, err := p.Parse()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, val)
+	}
+	return elements, nil
+}
+
+func (p *Parser) parseMap(line []byte) (Map, error) {
+	count, err := strconv.Atoi(string(line[1:]))
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("parse map length failed: %q", line)
+	}
+	m := make(Map, 0, count)
+	for range count {
+		key, err := p.Parse()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.Parse()
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, MapEntry{Key: key, Value: value})
+	}
+	return m, nil
+}
+
+// parseError splits an error into its prefix, such as ERR or WRONGTYPE, and its message.
+func parseError(data []byte) SimpleError {
+	prefix, message, _ := strings.Cut(string(data), " ")
+	return SimpleError{prefix: prefix, message: message}
+}
+
+func parseDouble(line []byte) (Double, error) {
+	switch text := string(line[1:]); text {
+	case "inf":
+		return Double(math.Inf(1)), nil
+	case "-inf":
+		return Double(math.Inf(-1)), nil
+	case "nan":
+		return Double(math.NaN()), nil
+	default:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse double failed: %w", err)
+		}
+		return Double(f), nil
+	}
+}
@@ -0,0 +1,157 @@
+package resp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxBitOffset bounds bit offsets to strings of 512MB, the proto-max-bulk-len default of Redis.
+const maxBitOffset = 1<<32 - 1
+
+var (
+	errBitOffset    = errors.New("bit offset is not an integer or out of range")
+	errBitFieldType = NewSimpleError("Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+)
+
+// ParseBitOffset parses the offset of SETBIT and GETBIT.
+func ParseBitOffset(arg any) (uint64, error) {
+	str, ok := arg.(BulkString)
+	if !ok {
+		return 0, errBitOffset
+	}
+	offset, err := strconv.ParseUint(string(str), 10, 64)
+	if err != nil || offset > maxBitOffset {
+		return 0, errBitOffset
+	}
+	return offset, nil
+}
+
+// ParseBit parses a bit value, which must be 0 or 1.
+func ParseBit(arg any) (int, error) {
+	str, ok := arg.(BulkString)
+	if !ok || (str != "0" && str != "1") {
+		return 0, errors.New("bit is not an integer or out of range")
+	}
+	return int(str[0] - '0'), nil
+}
+
+// ParseBitRangeArgs parses the [start [end [BYTE|BIT]]] arguments of BITCOUNT and BITPOS.
+// BITCOUNT requires the end along with the start, BITPOS does not.
+func ParseBitRangeArgs(args Array, endRequired bool) (BitRangeArgs, error) {
+	parsed := BitRangeArgs{End: -1}
+	if len(args) == 0 {
+		return parsed, nil
+	}
+	if len(args) > 3 || (endRequired && len(args) == 1) {
+		return BitRangeArgs{}, errors.New("syntax error")
+	}
+
+	var err error
+	if parsed.Start, err = ParseInteger(args[0]); err != nil {
+		return BitRangeArgs{}, err
+	}
+	if len(args) >= 2 {
+		if parsed.End, err = ParseInteger(args[1]); err != nil {
+			return BitRangeArgs{}, err
+		}
+		parsed.EndGiven = true
+	}
+	if len(args) == 3 {
+		unit, ok := args[2].(BulkString)
+		if !ok {
+			return BitRangeArgs{}, errors.New("syntax error")
+		}
+		switch unit.Upper() {
+		case BYTE:
+		case BIT:
+			parsed.BitUnit = true
+		default:
+			return BitRangeArgs{}, errors.New("syntax error")
+		}
+	}
+	return parsed, nil
+}
+
+// ParseBitFieldArgs parses the operations of BITFIELD, or of BITFIELD_RO when readOnly is true.
+func ParseBitFieldArgs(args Array, readOnly bool) ([]BitFieldOp, error) {
+	strs, err := ParseStrings(args[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []BitFieldOp
+	overflow := WRAP
+	for i := 0; i < len(strs); i++ {
+		kind := BulkString(strs[i]).Upper()
+		remaining := len(strs) - i - 1
+
+		switch {
+		case kind == OVERFLOW && remaining >= 1 && !readOnly:
+			overflow = BulkString(strs[i+1]).Upper()
+			if overflow != WRAP && overflow != SAT && overflow != FAIL {
+				return nil, errors.New("invalid OVERFLOW type specified")
+			}
+			i++
+			continue
+		case kind == GET && remaining >= 2:
+		case (kind == SET || kind == INCRBY) && remaining >= 3 && !readOnly:
+		case readOnly:
+			return nil, NewSimpleError("BITFIELD_RO only supports the GET subcommand")
+		default:
+			return nil, errors.New("syntax error")
+		}
+
+		op := BitFieldOp{Kind: kind, Overflow: overflow}
+		if op.Signed, op.Bits, err = parseBitFieldType(strs[i+1]); err != nil {
+			return nil, err
+		}
+		if op.Offset, err = parseBitFieldOffset(strs[i+2], op.Bits); err != nil {
+			return nil, err
+		}
+		i += 2
+		if kind != GET {
+			if op.Value, err = strconv.ParseInt(strs[i+1], 10, 64); err != nil {
+				return nil, errors.New("value is not an integer or out of range")
+			}
+			i++
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// parseBitFieldType parses i<bits> with up to 64 bits, or u<bits> with up to 63 bits.
+func parseBitFieldType(s string) (bool, uint, error) {
+	if len(s) < 2 {
+		return false, 0, errBitFieldType
+	}
+	signed := s[0] == 'i' || s[0] == 'I'
+	if !signed && s[0] != 'u' && s[0] != 'U' {
+		return false, 0, errBitFieldType
+	}
+	bits, err := strconv.ParseUint(s[1:], 10, 8)
+	if err != nil || bits < 1 || (signed && bits > 64) || (!signed && bits > 63) {
+		return false, 0, errBitFieldType
+	}
+	return signed, uint(bits), nil
+}
+
+// parseBitFieldOffset parses a bit offset, or a multiple of the field width when prefixed with "#".
+func parseBitFieldOffset(s string, bits uint) (uint64, error) {
+	multiple, byWidth := strings.CutPrefix(s, "#")
+	offset, err := strconv.ParseUint(multiple, 10, 64)
+	if err != nil {
+		return 0, errBitOffset
+	}
+	if byWidth {
+		if offset > maxBitOffset {
+			return 0, errBitOffset
+		}
+		offset *= uint64(bits)
+	}
+	if offset+uint64(bits)-1 > maxBitOffset {
+		return 0, errBitOffset
+	}
+	return offset, nil
+}
@@ -0,0 +1,69 @@
+package resp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBitOffset(t *testing.T) {
+	offset, err := ParseBitOffset(BulkString("4294967295"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(4294967295), offset)
+
+	for _, invalid := range []string{"4294967296", "-1", "x"} {
+		_, err = ParseBitOffset(BulkString(invalid))
+		require.EqualError(t, err, "bit offset is not an integer or out of range", invalid)
+	}
+}
+
+func TestParseBit(t *testing.T) {
+	bit, err := ParseBit(BulkString("1"))
+	require.NoError(t, err)
+	require.Equal(t, 1, bit)
+
+	_, err = ParseBit(BulkString("2"))
+	require.Error(t, err)
+}
+
+func TestParseBitRangeArgs(t *testing.T) {
+	parsed, err := ParseBitRangeArgs(nil, true)
+	require.NoError(t, err)
+	require.Equal(t, BitRangeArgs{End: -1}, parsed)
+
+	parsed, err = ParseBitRangeArgs(bulkStrings("1", "-2", "bit"), true)
+	require.NoError(t, err)
+	require.Equal(t, BitRangeArgs{Start: 1, End: -2, EndGiven: true, BitUnit: true}, parsed)
+
+	parsed, err = ParseBitRangeArgs(bulkStrings("3"), false)
+	require.NoError(t, err)
+	require.Equal(t, BitRangeArgs{Start: 3, End: -1}, parsed)
+
+	_, err = ParseBitRangeArgs(bulkStrings("3"), true)
+	require.EqualError(t, err, "syntax error")
+
+	_, err = ParseBitRangeArgs(bulkStrings("0", "1", "WORD"), true)
+	require.EqualError(t, err, "syntax error")
+}
+
+func TestParseBitFieldArgs(t *testing.T) {
+	ops, err := ParseBitFieldArgs(bulkStrings("BITFIELD", "k", "GET", "u8", "#2", "OVERFLOW", "SAT", "INCRBY", "i5", "100", "-3", "SET", "i64", "0", "7"), false)
+	require.NoError(t, err)
+	require.Equal(t, []BitFieldOp{
+		{Kind: GET, Bits: 8, Offset: 16, Overflow: WRAP},
+		{Kind: INCRBY, Signed: true, Bits: 5, Offset: 100, Value: -3, Overflow: SAT},
+		{Kind: SET, Signed: true, Bits: 64, Offset: 0, Value: 7, Overflow: SAT},
+	}, ops)
+
+	_, err = ParseBitFieldArgs(bulkStrings("BITFIELD", "k", "GET", "u64", "0"), false)
+	require.ErrorIs(t, err, errBitFieldType)
+
+	_, err = ParseBitFieldArgs(bulkStrings("BITFIELD", "k", "OVERFLOW", "NOPE"), false)
+	require.EqualError(t, err, "invalid OVERFLOW type specified")
+
+	_, err = ParseBitFieldArgs(bulkStrings("BITFIELD", "k", "GET", "u8", "4294967290"), false)
+	require.EqualError(t, err, "bit offset is not an integer or out of range")
+
+	_, err = ParseBitFieldArgs(bulkStrings("BITFIELD_RO", "k", "SET", "u8", "0", "1"), true)
+	require.ErrorContains(t, err, "BITFIELD_RO only supports the GET subcommand")
+}
@@ -0,0 +1,184 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+)
+
+// slotCount is the number of hash slots of a cluster, cluster.SlotCount which this leaf package cannot import
+const slotCount = 16384
+
+var errInvalidSlot = NewSimpleError("Invalid or out of range slot")
+
+// ParseSlot parses a hash slot.
+func ParseSlot(arg any) (int, error) {
+	slot, err := ParseInteger(arg)
+	if err != nil || slot < 0 || slot >= slotCount {
+		return 0, errInvalidSlot
+	}
+	return int(slot), nil
+}
+
+// ParseSlots parses the slots of CLUSTER ADDSLOTS and DELSLOTS, each slot being given once.
+func ParseSlots(args Array) ([]int, error) {
+	slots := make([]int, len(args))
+	seen := make(map[int]bool, len(args))
+	for i, arg := range args {
+		slot, err := ParseSlot(arg)
+		if err != nil {
+			return nil, err
+		}
+		if seen[slot] {
+			return nil, NewSimpleError(fmt.Sprintf("Slot %d specified multiple times", slot))
+		}
+		seen[slot] = true
+		slots[i] = slot
+	}
+	return slots, nil
+}
+
+// ParseSlotRanges parses the start-slot end-slot pairs of CLUSTER ADDSLOTSRANGE and DELSLOTSRANGE into the slots they span.
+func ParseSlotRanges(args Array) ([]int, error) {
+	if len(args)%2 != 0 {
+		return nil, errors.New("syntax error")
+	}
+	var slots []int
+	seen := make(map[int]bool)
+	for i := 0; i < len(args); i += 2 {
+		start, err := ParseSlot(args[i])
+		if err != nil {
+			return nil, err
+		}
+		end, err := ParseSlot(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		if start > end {
+			return nil, NewSimpleError(fmt.Sprintf("start slot number %d is greater than end slot number %d", start, end))
+		}
+		for slot := start; slot <= end; slot++ {
+			if seen[slot] {
+				return nil, NewSimpleError(fmt.Sprintf("Slot %d specified multiple times", slot))
+			}
+			seen[slot] = true
+			slots = append(slots, slot)
+		}
+	}
+	return slots, nil
+}
+
+// ParseSetSlotArgs parses CLUSTER SETSLOT slot IMPORTING|MIGRATING|NODE node-id, or CLUSTER SETSLOT slot STABLE.
+func ParseSetSlotArgs(args Array) (*SetSlotArgs, error) {
+	slot, err := ParseSlot(args[2])
+	if err != nil {
+		return nil, err
+	}
+	strs, err := ParseStrings(args[3:])
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &SetSlotArgs{Slot: slot, State: BulkString(strs[0]).Upper()}
+	switch {
+	case parsed.State == STABLE && len(strs) == 1:
+	case (parsed.State == IMPORTING || parsed.State == MIGRATING || parsed.State == NODE) && len(strs) == 2:
+		parsed.NodeID = strs[1]
+	default:
+		return nil, NewSimpleError("Invalid CLUSTER SETSLOT action or number of arguments. Try CLUSTER HELP")
+	}
+	return parsed, nil
+}
+
+// ParseMigrateArgs parses host port key|"" destination-db timeout [COPY] [REPLACE] [KEYS key [key ...]], the timeout being in milliseconds.
+func ParseMigrateArgs(args Array) (*MigrateArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	port, err := ParseInteger(args[2])
+	if err != nil {
+		return nil, err
+	}
+	db, err := ParseInteger(args[4])
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := ParseInteger(args[5])
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = 1000
+	}
+
+	parsed := &MigrateArgs{Host: strs[0], Port: int(port), DB: int(db), Timeout: time.Duration(timeout) * time.Millisecond}
+	for i := 5; i < len(strs); i++ {
+		switch BulkString(strs[i]).Upper() {
+		case COPY:
+			parsed.Copy = true
+		case REPLACE:
+			parsed.Replace = true
+		case KEYS:
+			if strs[2] != "" {
+				return nil, NewSimpleError("When using MIGRATE KEYS option, the key argument must be set to the empty string")
+			}
+			parsed.Keys = strs[i+1:]
+			i = len(strs)
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	if parsed.Keys == nil {
+		parsed.Keys = []string{strs[2]}
+	}
+	return parsed, nil
+}
+
+// ParseRestoreArgs parses key ttl serialized-value [REPLACE] [ABSTTL] [IDLETIME seconds] [FREQ frequency],
+// the idle time and the frequency are accepted but ignored as no eviction policy uses them.
+func ParseRestoreArgs(args Array, clk clock.Clock) (*RestoreArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := ParseInteger(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if ttl < 0 {
+		return nil, NewSimpleError("Invalid TTL value, must be >= 0")
+	}
+
+	parsed := &RestoreArgs{Key: strs[0], Payload: []byte(strs[2])}
+	absolute := false
+	for i := 3; i < len(strs); i++ {
+		switch BulkString(strs[i]).Upper() {
+		case REPLACE:
+			parsed.Replace = true
+		case ABSTTL:
+			absolute = true
+		case IDLETIME, FREQ:
+			if i+1 >= len(strs) {
+				return nil, errors.New("syntax error")
+			}
+			if _, err := ParseInteger(args[i+2]); err != nil {
+				return nil, err
+			}
+			i++
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+
+	switch {
+	case ttl == 0:
+	case absolute:
+		parsed.ExpireAt = time.UnixMilli(ttl)
+	default:
+		parsed.ExpireAt = clk.Now().Add(time.Duration(ttl) * time.Millisecond)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,80 @@
+package resp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlots(t *testing.T) {
+	slots, err := ParseSlots(bulkStrings("0", "16383"))
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 16383}, slots)
+
+	_, err = ParseSlots(bulkStrings("16384"))
+	require.EqualError(t, err, "ERR Invalid or out of range slot")
+	_, err = ParseSlots(bulkStrings("1", "1"))
+	require.EqualError(t, err, "ERR Slot 1 specified multiple times")
+
+	slots, err = ParseSlotRanges(bulkStrings("1", "3", "7", "7"))
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 7}, slots)
+
+	_, err = ParseSlotRanges(bulkStrings("3", "1"))
+	require.EqualError(t, err, "ERR start slot number 3 is greater than end slot number 1")
+	_, err = ParseSlotRanges(bulkStrings("1", "3", "2", "4"))
+	require.EqualError(t, err, "ERR Slot 2 specified multiple times")
+}
+
+func TestParseSetSlotArgs(t *testing.T) {
+	parsed, err := ParseSetSlotArgs(bulkStrings("CLUSTER", "SETSLOT", "12", "migrating", "id"))
+	require.NoError(t, err)
+	require.Equal(t, &SetSlotArgs{Slot: 12, State: MIGRATING, NodeID: "id"}, parsed)
+
+	parsed, err = ParseSetSlotArgs(bulkStrings("CLUSTER", "SETSLOT", "12", "STABLE"))
+	require.NoError(t, err)
+	require.Equal(t, &SetSlotArgs{Slot: 12, State: STABLE}, parsed)
+
+	_, err = ParseSetSlotArgs(bulkStrings("CLUSTER", "SETSLOT", "12", "NODE"))
+	require.Error(t, err)
+	_, err = ParseSetSlotArgs(bulkStrings("CLUSTER", "SETSLOT", "12", "MOVING", "id"))
+	require.Error(t, err)
+}
+
+func TestParseMigrateArgs(t *testing.T) {
+	parsed, err := ParseMigrateArgs(bulkStrings("MIGRATE", "127.0.0.1", "7001", "key", "0", "500", "COPY"))
+	require.NoError(t, err)
+	require.Equal(t, &MigrateArgs{Host: "127.0.0.1", Port: 7001, Timeout: 500 * time.Millisecond, Copy: true, Keys: []string{"key"}}, parsed)
+
+	parsed, err = ParseMigrateArgs(bulkStrings("MIGRATE", "127.0.0.1", "7001", "", "2", "0", "REPLACE", "KEYS", "a", "b"))
+	require.NoError(t, err)
+	require.Equal(t, &MigrateArgs{Host: "127.0.0.1", Port: 7001, DB: 2, Timeout: time.Second, Replace: true, Keys: []string{"a", "b"}}, parsed)
+
+	_, err = ParseMigrateArgs(bulkStrings("MIGRATE", "127.0.0.1", "7001", "key", "0", "500", "KEYS", "a"))
+	require.Error(t, err)
+	_, err = ParseMigrateArgs(bulkStrings("MIGRATE", "127.0.0.1", "7001", "key", "0", "500", "AUTH", "secret"))
+	require.EqualError(t, err, "syntax error")
+}
+
+func TestParseRestoreArgs(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+
+	parsed, err := ParseRestoreArgs(bulkStrings("RESTORE", "key", "0", "payload", "REPLACE", "IDLETIME", "10"), clk)
+	require.NoError(t, err)
+	require.Equal(t, &RestoreArgs{Key: "key", Payload: []byte("payload"), Replace: true}, parsed)
+
+	parsed, err = ParseRestoreArgs(bulkStrings("RESTORE", "key", "1700000000123", "payload", "ABSTTL"), clk)
+	require.NoError(t, err)
+	require.True(t, time.UnixMilli(1700000000123).Equal(parsed.ExpireAt))
+
+	parsed, err = ParseRestoreArgs(bulkStrings("RESTORE", "key", "1000", "payload"), clk)
+	require.NoError(t, err)
+	require.Equal(t, clk.Now().Add(time.Second), parsed.ExpireAt)
+
+	_, err = ParseRestoreArgs(bulkStrings("RESTORE", "key", "-1", "payload"), clk)
+	require.EqualError(t, err, "ERR Invalid TTL value, must be >= 0")
+	_, err = ParseRestoreArgs(bulkStrings("RESTORE", "key", "0", "payload", "FREQ"), clk)
+	require.EqualError(t, err, "syntax error")
+}
@@ -3,7 +3,12 @@ package resp
 import (
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
 )
 
 func ParseGetArgs(args Array) (Stringer, error) {
@@ -14,7 +19,8 @@ func ParseGetArgs(args Array) (Stringer, error) {
 	return key, nil
 }
 
-func ParseSetArgs(args Array) (*SetArgs, error) {
+// ParseSetArgs parses the arguments of SET, relative expirations are counted from the time of clk.
+func ParseSetArgs(args Array, clk clock.Clock) (*SetArgs, error) {
 	key, ok := args[1].(Stringer)
 	if !ok {
 		return nil, errors.New("key is not a stringer")
@@ -34,7 +40,8 @@ func ParseSetArgs(args Array) (*SetArgs, error) {
 		return parsedArgs, nil
 	}
 
-	var ex, px *int64
+	// expiration options, indexed by option
+	expirations := make(map[BulkString]int64)
 
 	for i := 3; i < length; i++ {
 		option, ok := args[i].(BulkString)
@@ -42,20 +49,12 @@ func ParseSetArgs(args Array) (*SetArgs, error) {
 			return nil, fmt.Errorf("option is not a bulk string: %T", args[i])
 		}
 		switch option.Upper() {
-		case EX:
-			exValue, err := peekNextInteger(args, i)
-			if err != nil {
-				return nil, fmt.Errorf("syntax error: %w", err)
-			}
-			ex = &exValue
-			// skip the next argument
-			i++
-		case PX:
-			pxValue, err := peekNextInteger(args, i)
+		case EX, PX, EXAT, PXAT:
+			n, err := peekNextInteger(args, i)
 			if err != nil {
 				return nil, fmt.Errorf("syntax error: %w", err)
 			}
-			px = &pxValue
+			expirations[option.Upper()] = n
 			// skip the next argument
 			i++
 		case NX:
@@ -73,23 +72,42 @@ func ParseSetArgs(args Array) (*SetArgs, error) {
 		return nil, errors.New("syntax error: NX and XX options cannot be used together")
 	}
 
-	if ex != nil && px != nil {
-		return nil, errors.New("syntax error: EX and PX options cannot be used together")
+	expireAt, err := parseSetExpiration(expirations, clk)
+	if err != nil {
+		return nil, err
+	}
+	parsedArgs.ExpireAt = expireAt
+
+	return parsedArgs, nil
+}
+
+// parseSetExpiration converts the expiration option of SET to a deadline, the zero time when there is none.
+func parseSetExpiration(expirations map[BulkString]int64, clk clock.Clock) (time.Time, error) {
+	_, ex := expirations[EX]
+	_, px := expirations[PX]
+	if ex && px {
+		return time.Time{}, errors.New("syntax error: EX and PX options cannot be used together")
+	}
+	if len(expirations) > 1 {
+		return time.Time{}, errors.New("syntax error: only one of EX, PX, EXAT and PXAT can be used")
 	}
 
-	if ex != nil {
-		if *ex <= 0 {
-			return nil, errors.New("syntax error: EX value must be positive")
+	for option, n := range expirations {
+		if n <= 0 {
+			return time.Time{}, fmt.Errorf("syntax error: %s value must be positive", option)
 		}
-		parsedArgs.ExpireAt = time.Now().Add(time.Duration(*ex) * time.Second)
-	} else if px != nil {
-		if *px <= 0 {
-			return nil, errors.New("syntax error: PX value must be positive")
+		switch option {
+		case EX:
+			return clk.Now().Add(time.Duration(n) * time.Second), nil
+		case PX:
+			return clk.Now().Add(time.Duration(n) * time.Millisecond), nil
+		case EXAT:
+			return time.Unix(n, 0), nil
+		default:
+			return time.UnixMilli(n), nil
 		}
-		parsedArgs.ExpireAt = time.Now().Add(time.Duration(*px) * time.Millisecond)
 	}
-
-	return parsedArgs, nil
+	return time.Time{}, nil
 }
 
 func ParseDelArgs(args Array) ([]Stringer, error) {
@@ -103,3 +121,172 @@ func ParseDelArgs(args Array) ([]Stringer, error) {
 	}
 	return keys, nil
 }
+
+// ParseSubcommand returns the upper-cased subcommand of container commands such as CONFIG.
+func ParseSubcommand(args Array) (BulkString, error) {
+	subcommand, ok := args[1].(BulkString)
+	if !ok {
+		return "", fmt.Errorf("subcommand is not a bulk string: %T", args[1])
+	}
+	return subcommand.Upper(), nil
+}
+
+// ParseInfoArgs returns the lower-cased section names requested by INFO.
+func ParseInfoArgs(args Array) ([]string, error) {
+	sections := make([]string, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		section, ok := arg.(BulkString)
+		if !ok {
+			return nil, fmt.Errorf("section is not a bulk string: %T", arg)
+		}
+		sections = append(sections, strings.ToLower(string(section)))
+	}
+	return sections, nil
+}
+
+// ParseStrings converts bulk string arguments to strings.
+func ParseStrings(args Array) ([]string, error) {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(BulkString)
+		if !ok {
+			return nil, fmt.Errorf("argument is not a bulk string: %T", arg)
+		}
+		result[i] = string(str)
+	}
+	return result, nil
+}
+
+// ParseInteger parses an integer argument such as a database index.
+func ParseInteger(arg any) (int64, error) {
+	str, ok := arg.(BulkString)
+	if !ok {
+		return 0, errors.New("value is not an integer or out of range")
+	}
+	val, err := strconv.ParseInt(string(str), 10, 64)
+	if err != nil {
+		return 0, errors.New("value is not an integer or out of range")
+	}
+	return val, nil
+}
+
+// ParseFlushArgs reports whether FLUSHDB or FLUSHALL was called with the ASYNC option.
+func ParseFlushArgs(args Array) (bool, error) {
+	if len(args) == 1 {
+		return false, nil
+	}
+	if len(args) > 2 {
+		return false, errors.New("syntax error")
+	}
+	option, ok := args[1].(BulkString)
+	if !ok {
+		return false, errors.New("syntax error")
+	}
+	switch option.Upper() {
+	case ASYNC:
+		return true, nil
+	case SYNC:
+		return false, nil
+	default:
+		return false, errors.New("syntax error")
+	}
+}
+
+// ParseSlowlogGetArgs returns the number of entries requested by SLOWLOG GET [count], 10 by default and -1 for all of them.
+func ParseSlowlogGetArgs(args Array) (int, error) {
+	if len(args) == 2 {
+		return 10, nil
+	}
+	count, err := ParseInteger(args[2])
+	if err != nil {
+		return 0, err
+	}
+	if count < -1 {
+		return 0, NewSimpleError("count should be greater than or equal to -1")
+	}
+	return int(count), nil
+}
+
+// ParseScanArgs parses SCAN cursor [MATCH pattern] [COUNT count] [TYPE type], COUNT being 10 by default.
+func ParseScanArgs(args Array) (*ScanArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := strconv.ParseUint(strs[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	parsed := &ScanArgs{Cursor: cursor, Count: 10}
+	for i := 1; i < len(strs); i += 2 {
+		if i+1 >= len(strs) {
+			return nil, errors.New("syntax error")
+		}
+		switch BulkString(strs[i]).Upper() {
+		case MATCH:
+			parsed.Match = strs[i+1]
+		case COUNT:
+			count, err := strconv.Atoi(strs[i+1])
+			if err != nil {
+				return nil, errors.New("value is not an integer or out of range")
+			}
+			if count < 1 {
+				return nil, errors.New("syntax error")
+			}
+			parsed.Count = count
+		case TYPE:
+			parsed.Type = strings.ToLower(strs[i+1])
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return parsed, nil
+}
+
+// ParseClientName returns the name set by CLIENT SETNAME, an empty name removing it.
+// names cannot contain spaces, newlines or other special characters, as they are listed separated by spaces.
+func ParseClientName(arg any) (string, error) {
+	name, ok := arg.(BulkString)
+	if !ok {
+		return "", errors.New("client name is not a bulk string")
+	}
+	for _, ch := range []byte(name) {
+		if ch < '!' || ch > '~' {
+			return "", NewSimpleError("Client names cannot contain spaces, newlines or special characters.")
+		}
+	}
+	return string(name), nil
+}
+
+// ParseTimeout parses the timeout of blocking commands, in seconds with decimals, 0 meaning forever.
+func ParseTimeout(arg any) (time.Duration, error) {
+	str, ok := arg.(BulkString)
+	if !ok {
+		return 0, errors.New("timeout is not a float or out of range")
+	}
+	seconds, err := strconv.ParseFloat(string(str), 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, errors.New("timeout is not a float or out of range")
+	}
+	if seconds < 0 {
+		return 0, errors.New("timeout is negative")
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ParseListSide parses the LEFT|RIGHT arguments of LMOVE, it reports true for LEFT.
+func ParseListSide(arg any) (bool, error) {
+	side, ok := arg.(BulkString)
+	if !ok {
+		return false, errors.New("syntax error")
+	}
+	switch side.Upper() {
+	case LEFT:
+		return true, nil
+	case RIGHT:
+		return false, nil
+	default:
+		return false, errors.New("syntax error")
+	}
+}
@@ -4,17 +4,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/PlayerNeo42/gvalkey/clock"
 	"github.com/stretchr/testify/require"
 )
 
 func TestParseSetArgs(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+
 	t.Run("Simple SET", func(t *testing.T) {
 		args := Array{
 			BulkString("SET"),
 			BulkString("key"),
 			BulkString("value"),
 		}
-		parsed, err := ParseSetArgs(args)
+		parsed, err := ParseSetArgs(args, clk)
 		require.NoError(t, err)
 		require.Equal(t, BulkString("key"), parsed.Key)
 		require.Equal(t, BulkString("value"), parsed.Value)
@@ -32,11 +35,11 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("EX"),
 			BulkString("10"),
 		}
-		parsed, err := ParseSetArgs(args)
+		parsed, err := ParseSetArgs(args, clk)
 		require.NoError(t, err)
 		require.Equal(t, BulkString("key"), parsed.Key)
 		require.Equal(t, BulkString("value"), parsed.Value)
-		require.WithinDuration(t, time.Now().Add(10*time.Second), parsed.ExpireAt, 50*time.Millisecond)
+		require.Equal(t, clk.Now().Add(10*time.Second), parsed.ExpireAt)
 	})
 
 	t.Run("SET with PX", func(t *testing.T) {
@@ -47,11 +50,11 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("PX"),
 			BulkString("1234"),
 		}
-		parsed, err := ParseSetArgs(args)
+		parsed, err := ParseSetArgs(args, clk)
 		require.NoError(t, err)
 		require.Equal(t, BulkString("key"), parsed.Key)
 		require.Equal(t, BulkString("value"), parsed.Value)
-		require.WithinDuration(t, time.Now().Add(1234*time.Millisecond), parsed.ExpireAt, 50*time.Millisecond)
+		require.Equal(t, clk.Now().Add(1234*time.Millisecond), parsed.ExpireAt)
 	})
 
 	t.Run("SET with NX", func(t *testing.T) {
@@ -61,7 +64,7 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("value"),
 			BulkString("NX"),
 		}
-		parsed, err := ParseSetArgs(args)
+		parsed, err := ParseSetArgs(args, clk)
 		require.NoError(t, err)
 		require.True(t, parsed.NX)
 	})
@@ -73,7 +76,7 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("value"),
 			BulkString("XX"),
 		}
-		parsed, err := ParseSetArgs(args)
+		parsed, err := ParseSetArgs(args, clk)
 		require.NoError(t, err)
 		require.True(t, parsed.XX)
 	})
@@ -85,7 +88,7 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("value"),
 			BulkString("GET"),
 		}
-		parsed, err := ParseSetArgs(args)
+		parsed, err := ParseSetArgs(args, clk)
 		require.NoError(t, err)
 		require.True(t, parsed.Get)
 	})
@@ -100,11 +103,11 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("PX"),
 			BulkString("500"),
 		}
-		parsed, err := ParseSetArgs(args)
+		parsed, err := ParseSetArgs(args, clk)
 		require.NoError(t, err)
 		require.True(t, parsed.NX)
 		require.True(t, parsed.Get)
-		require.WithinDuration(t, time.Now().Add(500*time.Millisecond), parsed.ExpireAt, 50*time.Millisecond)
+		require.Equal(t, clk.Now().Add(500*time.Millisecond), parsed.ExpireAt)
 	})
 
 	t.Run("Error: NX and XX", func(t *testing.T) {
@@ -115,11 +118,38 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("NX"),
 			BulkString("XX"),
 		}
-		_, err := ParseSetArgs(args)
+		_, err := ParseSetArgs(args, clk)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "syntax error")
 	})
 
+	t.Run("SET with PXAT", func(t *testing.T) {
+		args := Array{
+			BulkString("SET"),
+			BulkString("key"),
+			BulkString("value"),
+			BulkString("PXAT"),
+			BulkString("1700000000123"),
+		}
+		parsed, err := ParseSetArgs(args, clk)
+		require.NoError(t, err)
+		require.True(t, time.UnixMilli(1700000000123).Equal(parsed.ExpireAt))
+	})
+
+	t.Run("Error: EX and EXAT", func(t *testing.T) {
+		args := Array{
+			BulkString("SET"),
+			BulkString("key"),
+			BulkString("value"),
+			BulkString("EX"),
+			BulkString("10"),
+			BulkString("EXAT"),
+			BulkString("1700000000"),
+		}
+		_, err := ParseSetArgs(args, clk)
+		require.EqualError(t, err, "syntax error: only one of EX, PX, EXAT and PXAT can be used")
+	})
+
 	t.Run("Error: EX and PX", func(t *testing.T) {
 		args := Array{
 			BulkString("SET"),
@@ -130,7 +160,7 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("PX"),
 			BulkString("10000"),
 		}
-		_, err := ParseSetArgs(args)
+		_, err := ParseSetArgs(args, clk)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "syntax error")
 	})
@@ -143,7 +173,137 @@ func TestParseSetArgs(t *testing.T) {
 			BulkString("EX"),
 			BulkString("not-a-number"),
 		}
-		_, err := ParseSetArgs(args)
+		_, err := ParseSetArgs(args, clk)
 		require.Error(t, err)
 	})
 }
+
+func TestParseInfoArgs(t *testing.T) {
+	t.Run("No sections", func(t *testing.T) {
+		sections, err := ParseInfoArgs(Array{BulkString("INFO")})
+		require.NoError(t, err)
+		require.Empty(t, sections)
+	})
+
+	t.Run("Sections are lower-cased", func(t *testing.T) {
+		sections, err := ParseInfoArgs(Array{BulkString("INFO"), BulkString("Server"), BulkString("KEYSPACE")})
+		require.NoError(t, err)
+		require.Equal(t, []string{"server", "keyspace"}, sections)
+	})
+
+	t.Run("Section is not a bulk string", func(t *testing.T) {
+		_, err := ParseInfoArgs(Array{BulkString("INFO"), Integer(1)})
+		require.Error(t, err)
+	})
+}
+
+func TestParseStrings(t *testing.T) {
+	strs, err := ParseStrings(Array{BulkString("a"), BulkString("b")})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, strs)
+
+	_, err = ParseStrings(Array{BulkString("a"), Integer(1)})
+	require.Error(t, err)
+}
+
+func TestParseInteger(t *testing.T) {
+	val, err := ParseInteger(BulkString("-12"))
+	require.NoError(t, err)
+	require.Equal(t, int64(-12), val)
+
+	_, err = ParseInteger(BulkString("1.5"))
+	require.EqualError(t, err, "value is not an integer or out of range")
+}
+
+func TestParseFlushArgs(t *testing.T) {
+	async, err := ParseFlushArgs(Array{FLUSHDB})
+	require.NoError(t, err)
+	require.False(t, async)
+
+	async, err = ParseFlushArgs(Array{FLUSHDB, BulkString("async")})
+	require.NoError(t, err)
+	require.True(t, async)
+
+	_, err = ParseFlushArgs(Array{FLUSHDB, BulkString("LAZY")})
+	require.Error(t, err)
+}
+
+func TestParseSlowlogGetArgs(t *testing.T) {
+	count, err := ParseSlowlogGetArgs(bulkStrings("SLOWLOG", "GET"))
+	require.NoError(t, err)
+	require.Equal(t, 10, count)
+
+	count, err = ParseSlowlogGetArgs(bulkStrings("SLOWLOG", "GET", "-1"))
+	require.NoError(t, err)
+	require.Equal(t, -1, count)
+
+	_, err = ParseSlowlogGetArgs(bulkStrings("SLOWLOG", "GET", "-2"))
+	require.EqualError(t, err, "ERR count should be greater than or equal to -1")
+	_, err = ParseSlowlogGetArgs(bulkStrings("SLOWLOG", "GET", "all"))
+	require.Error(t, err)
+}
+
+func TestParseScanArgs(t *testing.T) {
+	parsed, err := ParseScanArgs(bulkStrings("SCAN", "0"))
+	require.NoError(t, err)
+	require.Equal(t, &ScanArgs{Count: 10}, parsed)
+
+	parsed, err = ParseScanArgs(bulkStrings("SCAN", "17", "match", "user:*", "COUNT", "100", "TYPE", "Hash"))
+	require.NoError(t, err)
+	require.Equal(t, &ScanArgs{Cursor: 17, Match: "user:*", Count: 100, Type: "hash"}, parsed)
+
+	_, err = ParseScanArgs(bulkStrings("SCAN", "-1"))
+	require.EqualError(t, err, "invalid cursor")
+	_, err = ParseScanArgs(bulkStrings("SCAN", "0", "COUNT", "0"))
+	require.EqualError(t, err, "syntax error")
+	_, err = ParseScanArgs(bulkStrings("SCAN", "0", "COUNT", "ten"))
+	require.EqualError(t, err, "value is not an integer or out of range")
+	_, err = ParseScanArgs(bulkStrings("SCAN", "0", "MATCH"))
+	require.EqualError(t, err, "syntax error")
+	_, err = ParseScanArgs(bulkStrings("SCAN", "0", "LIMIT", "1"))
+	require.EqualError(t, err, "syntax error")
+}
+
+func TestParseClientName(t *testing.T) {
+	name, err := ParseClientName(BulkString("worker-1"))
+	require.NoError(t, err)
+	require.Equal(t, "worker-1", name)
+
+	name, err = ParseClientName(BulkString(""))
+	require.NoError(t, err)
+	require.Empty(t, name)
+
+	for _, invalid := range []string{"my worker", "worker\n", "caf\u00e9"} {
+		_, err = ParseClientName(BulkString(invalid))
+		require.EqualError(t, err, "ERR Client names cannot contain spaces, newlines or special characters.")
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	timeout, err := ParseTimeout(BulkString("0.5"))
+	require.NoError(t, err)
+	require.Equal(t, 500*time.Millisecond, timeout)
+
+	timeout, err = ParseTimeout(BulkString("0"))
+	require.NoError(t, err)
+	require.Zero(t, timeout)
+
+	_, err = ParseTimeout(BulkString("-1"))
+	require.EqualError(t, err, "timeout is negative")
+
+	_, err = ParseTimeout(BulkString("soon"))
+	require.EqualError(t, err, "timeout is not a float or out of range")
+}
+
+func TestParseListSide(t *testing.T) {
+	left, err := ParseListSide(BulkString("left"))
+	require.NoError(t, err)
+	require.True(t, left)
+
+	left, err = ParseListSide(BulkString("RIGHT"))
+	require.NoError(t, err)
+	require.False(t, left)
+
+	_, err = ParseListSide(BulkString("UP"))
+	require.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	errNotFloat = errors.New("value is not a valid float")
+	errGeoUnit  = errors.New("unsupported unit provided. please use M, KM, FT, MI")
+)
+
+// geoUnits are the meters per unit of the distance units.
+var geoUnits = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"ft": 0.3048,
+	"mi": 1609.34,
+}
+
+func parseFloat(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
+
+// ParseGeoUnit parses a distance unit and returns its length in meters.
+func ParseGeoUnit(arg any) (float64, error) {
+	str, ok := arg.(BulkString)
+	if !ok {
+		return 0, errGeoUnit
+	}
+	unit, ok := geoUnits[strings.ToLower(string(str))]
+	if !ok {
+		return 0, errGeoUnit
+	}
+	return unit, nil
+}
+
+// parseLonLat parses a longitude and a latitude, callers check that they are within the range geohashes cover.
+func parseLonLat(lonStr, latStr string) (lon, lat float64, err error) {
+	if lon, err = parseFloat(lonStr); err != nil {
+		return 0, 0, err
+	}
+	if lat, err = parseFloat(latStr); err != nil {
+		return 0, 0, err
+	}
+	return lon, lat, nil
+}
+
+// ParseGeoAddArgs parses GEOADD key [NX|XX] [CH] longitude latitude member [longitude latitude member ...].
+func ParseGeoAddArgs(args Array) (*GeoAddArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &GeoAddArgs{Key: strs[0]}
+	i := 1
+options:
+	for ; i < len(strs); i++ {
+		switch BulkString(strs[i]).Upper() {
+		case NX:
+			parsed.NX = true
+		case XX:
+			parsed.XX = true
+		case CH:
+			parsed.CH = true
+		default:
+			break options
+		}
+	}
+	if (len(strs)-i)%3 != 0 || i == len(strs) || (parsed.NX && parsed.XX) {
+		return nil, errors.New("syntax error")
+	}
+
+	for ; i < len(strs); i += 3 {
+		lon, lat, err := parseLonLat(strs[i], strs[i+1])
+		if err != nil {
+			return nil, err
+		}
+		parsed.Members = append(parsed.Members, GeoMember{lon, lat, strs[i+2]})
+	}
+	return parsed, nil
+}
+
+// ParseGeoSearchArgs parses the arguments of GEOSEARCH, or of GEOSEARCHSTORE when store is true:
+//
+//	GEOSEARCH key FROMMEMBER member|FROMLONLAT longitude latitude BYRADIUS radius unit|BYBOX width height unit
+//	  [ASC|DESC] [COUNT count [ANY]] [WITHCOORD] [WITHDIST] [WITHHASH]
+//	GEOSEARCHSTORE destination source ... [STOREDIST]
+func ParseGeoSearchArgs(args Array, store bool) (*GeoSearchArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	command := GEOSEARCH
+	parsed := &GeoSearchArgs{}
+	if store {
+		command = GEOSEARCHSTORE
+		parsed.Dest, strs = strs[0], strs[1:]
+	}
+	parsed.Key = strs[0]
+
+	for i := 1; i < len(strs); i++ {
+		if i, err = parseGeoSearchOption(parsed, strs, i, store); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case store && (parsed.WithCoord || parsed.WithDist || parsed.WithHash):
+		return nil, errors.New("GEOSEARCHSTORE is not compatible with WITHDIST, WITHHASH and WITHCOORD options")
+	case parsed.From == "":
+		return nil, fmt.Errorf("exactly one of FROMMEMBER or FROMLONLAT can be specified for %s", command)
+	case parsed.By == "":
+		return nil, fmt.Errorf("exactly one of BYRADIUS and BYBOX can be specified for %s", command)
+	case parsed.Any && parsed.Count == 0:
+		return nil, errors.New("the ANY argument requires COUNT argument")
+	}
+	return parsed, nil
+}
+
+// parseGeoSearchOption parses the option at strs[i] and returns the index of its last argument.
+func parseGeoSearchOption(parsed *GeoSearchArgs, strs []string, i int, store bool) (int, error) {
+	remaining := len(strs) - i - 1
+	option := BulkString(strs[i]).Upper()
+	switch {
+	case option == WITHCOORD:
+		parsed.WithCoord = true
+	case option == WITHDIST:
+		parsed.WithDist = true
+	case option == WITHHASH:
+		parsed.WithHash = true
+	case option == ANY:
+		parsed.Any = true
+	case option == ASC || option == DESC:
+		parsed.Order = option
+	case option == STOREDIST && store:
+		parsed.StoreDist = true
+	case option == COUNT && remaining >= 1:
+		count, err := ParseInteger(BulkString(strs[i+1]))
+		if err != nil {
+			return 0, err
+		}
+		if count <= 0 {
+			return 0, errors.New("COUNT must be > 0")
+		}
+		parsed.Count = count
+		return i + 1, nil
+	case option == FROMMEMBER && remaining >= 1 && parsed.From == "":
+		parsed.From, parsed.Member = option, strs[i+1]
+		return i + 1, nil
+	case option == FROMLONLAT && remaining >= 2 && parsed.From == "":
+		lon, lat, err := parseLonLat(strs[i+1], strs[i+2])
+		if err != nil {
+			return 0, err
+		}
+		parsed.From, parsed.Lon, parsed.Lat = option, lon, lat
+		return i + 2, nil
+	case option == BYRADIUS && remaining >= 2 && parsed.By == "":
+		return i + 2, parseGeoRadius(parsed, strs[i+1], strs[i+2])
+	case option == BYBOX && remaining >= 3 && parsed.By == "":
+		return i + 3, parseGeoBox(parsed, strs[i+1], strs[i+2], strs[i+3])
+	default:
+		return 0, errors.New("syntax error")
+	}
+	return i, nil
+}
+
+func parseGeoRadius(parsed *GeoSearchArgs, radiusStr, unitStr string) error {
+	radius, err := parseFloat(radiusStr)
+	if err != nil {
+		return errors.New("need numeric radius")
+	}
+	if radius < 0 {
+		return errors.New("radius cannot be negative")
+	}
+	unit, err := ParseGeoUnit(BulkString(unitStr))
+	if err != nil {
+		return err
+	}
+	parsed.By, parsed.Radius, parsed.Unit = BYRADIUS, radius, unit
+	return nil
+}
+
+func parseGeoBox(parsed *GeoSearchArgs, widthStr, heightStr, unitStr string) error {
+	width, err := parseFloat(widthStr)
+	if err != nil {
+		return errors.New("need numeric width")
+	}
+	height, err := parseFloat(heightStr)
+	if err != nil {
+		return errors.New("need numeric height")
+	}
+	if width < 0 || height < 0 {
+		return errors.New("height or width cannot be negative")
+	}
+	unit, err := ParseGeoUnit(BulkString(unitStr))
+	if err != nil {
+		return err
+	}
+	parsed.By, parsed.Width, parsed.Height, parsed.Unit = BYBOX, width, height, unit
+	return nil
+}
@@ -0,0 +1,91 @@
+package resp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGeoAddArgs(t *testing.T) {
+	parsed, err := ParseGeoAddArgs(bulkStrings("GEOADD", "k", "nx", "ch", "13.36", "38.11", "Palermo", "15.08", "37.50", "Catania"))
+	require.NoError(t, err)
+	require.Equal(t, &GeoAddArgs{
+		Key: "k",
+		NX:  true,
+		CH:  true,
+		Members: []GeoMember{
+			{13.36, 38.11, "Palermo"},
+			{15.08, 37.50, "Catania"},
+		},
+	}, parsed)
+
+	for _, invalid := range [][]string{
+		{"GEOADD", "k", "1", "2"},
+		{"GEOADD", "k", "NX", "XX", "1", "2", "m"},
+		{"GEOADD", "k", "CH", "1"},
+	} {
+		_, err = ParseGeoAddArgs(bulkStrings(invalid...))
+		require.EqualError(t, err, "syntax error", invalid)
+	}
+
+	_, err = ParseGeoAddArgs(bulkStrings("GEOADD", "k", "x", "2", "m"))
+	require.EqualError(t, err, "value is not a valid float")
+}
+
+func TestParseGeoSearchArgs(t *testing.T) {
+	parsed, err := ParseGeoSearchArgs(bulkStrings("GEOSEARCH", "k", "FROMLONLAT", "15", "37", "BYRADIUS", "200", "km", "desc", "COUNT", "2", "ANY", "WITHDIST", "WITHCOORD"), false)
+	require.NoError(t, err)
+	require.Equal(t, &GeoSearchArgs{
+		Key:       "k",
+		From:      FROMLONLAT,
+		Lon:       15,
+		Lat:       37,
+		By:        BYRADIUS,
+		Radius:    200,
+		Unit:      1000,
+		Order:     DESC,
+		Count:     2,
+		Any:       true,
+		WithCoord: true,
+		WithDist:  true,
+	}, parsed)
+
+	parsed, err = ParseGeoSearchArgs(bulkStrings("GEOSEARCHSTORE", "dst", "k", "FROMMEMBER", "m", "BYBOX", "1", "2", "FT", "STOREDIST"), true)
+	require.NoError(t, err)
+	require.Equal(t, &GeoSearchArgs{
+		Key:       "k",
+		Dest:      "dst",
+		From:      FROMMEMBER,
+		Member:    "m",
+		By:        BYBOX,
+		Width:     1,
+		Height:    2,
+		Unit:      0.3048,
+		StoreDist: true,
+	}, parsed)
+}
+
+func TestParseGeoSearchArgsErrors(t *testing.T) {
+	cases := []struct {
+		args  []string
+		store bool
+		err   string
+	}{
+		{[]string{"GEOSEARCH", "k", "BYRADIUS", "1", "m"}, false, "exactly one of FROMMEMBER or FROMLONLAT can be specified for GEOSEARCH"},
+		{[]string{"GEOSEARCHSTORE", "d", "k", "FROMMEMBER", "m"}, true, "exactly one of BYRADIUS and BYBOX can be specified for GEOSEARCHSTORE"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "FROMMEMBER", "n", "BYRADIUS", "1", "m"}, false, "syntax error"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYRADIUS", "1", "m", "BYBOX", "1", "1", "m"}, false, "syntax error"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYRADIUS", "1", "m", "STOREDIST"}, false, "syntax error"},
+		{[]string{"GEOSEARCHSTORE", "d", "k", "FROMMEMBER", "m", "BYRADIUS", "1", "m", "WITHDIST"}, true, "GEOSEARCHSTORE is not compatible with WITHDIST, WITHHASH and WITHCOORD options"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYRADIUS", "x", "m"}, false, "need numeric radius"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYRADIUS", "-1", "m"}, false, "radius cannot be negative"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYBOX", "1", "x", "m"}, false, "need numeric height"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYRADIUS", "1", "yd"}, false, "unsupported unit provided. please use M, KM, FT, MI"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYRADIUS", "1", "m", "COUNT", "0"}, false, "COUNT must be > 0"},
+		{[]string{"GEOSEARCH", "k", "FROMMEMBER", "m", "BYRADIUS", "1", "m", "ANY"}, false, "the ANY argument requires COUNT argument"},
+	}
+	for _, c := range cases {
+		_, err := ParseGeoSearchArgs(bulkStrings(c.args...), c.store)
+		require.EqualError(t, err, c.err, c.args)
+	}
+}
@@ -0,0 +1,104 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+)
+
+// maxFieldExpireMs bounds the expirations of hash fields, which Redis stores as 48 bits timestamps.
+const maxFieldExpireMs = 1<<48 - 1
+
+var (
+	errFieldsMissing  = NewSimpleError("Mandatory argument FIELDS is missing or not at the right position")
+	errNumFields      = NewSimpleError("Parameter `numFields` should be greater than 0")
+	errNumFieldsCount = NewSimpleError("The `numfields` parameter must match the number of arguments")
+)
+
+// ParseHSetArgs parses HSET key field value [field value ...] and returns the key and the field/value pairs.
+func ParseHSetArgs(args Array) (string, []string, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return "", nil, err
+	}
+	if len(strs) < 3 || len(strs)%2 != 1 {
+		return "", nil, errors.New("wrong number of arguments for 'hset' command")
+	}
+	return strs[0], strs[1:], nil
+}
+
+// parseFields parses FIELDS numfields field [field ...], which must be the last arguments.
+func parseFields(strs []string) ([]string, error) {
+	if len(strs) < 2 || BulkString(strs[0]).Upper() != FIELDS {
+		return nil, errFieldsMissing
+	}
+	n, err := strconv.ParseInt(strs[1], 10, 64)
+	if err != nil || n < 1 {
+		return nil, errNumFields
+	}
+	if n != int64(len(strs)-2) {
+		return nil, errNumFieldsCount
+	}
+	return strs[2:], nil
+}
+
+// ParseHFieldsArgs parses key FIELDS numfields field [field ...], the arguments of HTTL, HPTTL and HPERSIST.
+func ParseHFieldsArgs(args Array) (string, []string, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return "", nil, err
+	}
+	fields, err := parseFields(strs[1:])
+	if err != nil {
+		return "", nil, err
+	}
+	return strs[0], fields, nil
+}
+
+// ParseHExpireArgs parses key time [NX|XX|GT|LT] FIELDS numfields field [field ...].
+// the time is counted in unit, from the time of clk unless absolute is true, in which case it is a unix timestamp.
+func ParseHExpireArgs(args Array, unit time.Duration, absolute bool, clk clock.Clock) (*HExpireArgs, error) {
+	strs, err := ParseStrings(args)
+	if err != nil {
+		return nil, err
+	}
+	command, strs := strings.ToLower(strs[0]), strs[1:]
+
+	expire, err := ParseInteger(BulkString(strs[1]))
+	if err != nil {
+		return nil, err
+	}
+	if expire < 0 {
+		return nil, errors.New("invalid expire time, must be >= 0")
+	}
+	errExpireTime := fmt.Errorf("invalid expire time in '%s' command", command)
+	if expire > maxFieldExpireMs/unit.Milliseconds() {
+		return nil, errExpireTime
+	}
+	expireMs := expire * unit.Milliseconds()
+	if !absolute {
+		now := clk.Now().UnixMilli()
+		if expireMs > maxFieldExpireMs-now {
+			return nil, errExpireTime
+		}
+		expireMs += now
+	}
+
+	parsed := &HExpireArgs{Key: strs[0], ExpireAt: time.UnixMilli(expireMs)}
+	i := 2
+	if i < len(strs) {
+		switch condition := BulkString(strs[i]).Upper(); condition {
+		case NX, XX, GT, LT:
+			parsed.Condition = condition
+			i++
+		}
+	}
+	if parsed.Fields, err = parseFields(strs[i:]); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
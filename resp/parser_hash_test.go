@@ -0,0 +1,74 @@
+package resp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHSetArgs(t *testing.T) {
+	key, pairs, err := ParseHSetArgs(bulkStrings("HSET", "k", "f1", "v1", "f2", "v2"))
+	require.NoError(t, err)
+	require.Equal(t, "k", key)
+	require.Equal(t, []string{"f1", "v1", "f2", "v2"}, pairs)
+
+	_, _, err = ParseHSetArgs(bulkStrings("HSET", "k", "f1", "v1", "f2"))
+	require.EqualError(t, err, "wrong number of arguments for 'hset' command")
+}
+
+func TestParseHFieldsArgs(t *testing.T) {
+	key, fields, err := ParseHFieldsArgs(bulkStrings("HTTL", "k", "fields", "2", "a", "b"))
+	require.NoError(t, err)
+	require.Equal(t, "k", key)
+	require.Equal(t, []string{"a", "b"}, fields)
+
+	cases := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"HTTL", "k", "FIELD", "1", "a"}, "ERR Mandatory argument FIELDS is missing or not at the right position"},
+		{[]string{"HTTL", "k", "FIELDS", "0", "a"}, "ERR Parameter `numFields` should be greater than 0"},
+		{[]string{"HTTL", "k", "FIELDS", "x", "a"}, "ERR Parameter `numFields` should be greater than 0"},
+		{[]string{"HTTL", "k", "FIELDS", "2", "a"}, "ERR The `numfields` parameter must match the number of arguments"},
+		{[]string{"HTTL", "k", "FIELDS", "1", "a", "b"}, "ERR The `numfields` parameter must match the number of arguments"},
+	}
+	for _, c := range cases {
+		_, _, err = ParseHFieldsArgs(bulkStrings(c.args...))
+		require.EqualError(t, err, c.err, c.args)
+	}
+}
+
+func TestParseHExpireArgs(t *testing.T) {
+	clk := clock.NewFake(time.UnixMilli(1700000000123))
+
+	parsed, err := ParseHExpireArgs(bulkStrings("HEXPIRE", "k", "10", "gt", "FIELDS", "1", "a"), time.Second, false, clk)
+	require.NoError(t, err)
+	require.Equal(t, "k", parsed.Key)
+	require.Equal(t, GT, parsed.Condition)
+	require.Equal(t, []string{"a"}, parsed.Fields)
+	require.Equal(t, clk.Now().Add(10*time.Second), parsed.ExpireAt)
+
+	parsed, err = ParseHExpireArgs(bulkStrings("HPEXPIREAT", "k", "1700000000123", "FIELDS", "2", "a", "b"), time.Millisecond, true, clk)
+	require.NoError(t, err)
+	require.Empty(t, parsed.Condition)
+	require.Equal(t, time.UnixMilli(1700000000123), parsed.ExpireAt)
+	require.Equal(t, []string{"a", "b"}, parsed.Fields)
+
+	_, err = ParseHExpireArgs(bulkStrings("HEXPIRE", "k", "-1", "FIELDS", "1", "a"), time.Second, false, clk)
+	require.EqualError(t, err, "invalid expire time, must be >= 0")
+	_, err = ParseHExpireArgs(bulkStrings("HEXPIREAT", "k", "281474976710656", "FIELDS", "1", "a"), time.Second, true, clk)
+	require.EqualError(t, err, "invalid expire time in 'hexpireat' command")
+	_, err = ParseHExpireArgs(bulkStrings("HPEXPIRE", "k", "281474976710655", "FIELDS", "1", "a"), time.Millisecond, false, clk)
+	require.EqualError(t, err, "invalid expire time in 'hpexpire' command")
+	_, err = ParseHExpireArgs(bulkStrings("HEXPIRE", "k", "10", "XY", "FIELDS", "1", "a"), time.Second, false, clk)
+	require.EqualError(t, err, "ERR Mandatory argument FIELDS is missing or not at the right position")
+
+	// relative times overflow depending on the time they are counted from
+	clk.Set(time.UnixMilli(maxFieldExpireMs - 1000))
+	_, err = ParseHExpireArgs(bulkStrings("HPEXPIRE", "k", "1000", "FIELDS", "1", "a"), time.Millisecond, false, clk)
+	require.NoError(t, err)
+	_, err = ParseHExpireArgs(bulkStrings("HPEXPIRE", "k", "1001", "FIELDS", "1", "a"), time.Millisecond, false, clk)
+	require.EqualError(t, err, "invalid expire time in 'hpexpire' command")
+}
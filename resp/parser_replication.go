@@ -0,0 +1,33 @@
+package resp
+
+// ParseReplicaOfArgs parses host port, or NO ONE.
+func ParseReplicaOfArgs(args Array) (*ReplicaOfArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if BulkString(strs[0]).Upper() == NO && BulkString(strs[1]).Upper() == ONE {
+		return &ReplicaOfArgs{NoOne: true}, nil
+	}
+	port, err := ParseInteger(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if port < 1 || port > 65535 {
+		return nil, NewSimpleError("Invalid master port")
+	}
+	return &ReplicaOfArgs{Host: strs[0], Port: int(port)}, nil
+}
+
+// ParsePSyncArgs parses replicationid offset.
+func ParsePSyncArgs(args Array) (*PSyncArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	offset, err := ParseInteger(args[2])
+	if err != nil {
+		return nil, err
+	}
+	return &PSyncArgs{ReplID: strs[0], Offset: offset}, nil
+}
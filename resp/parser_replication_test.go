@@ -0,0 +1,31 @@
+package resp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplicaOfArgs(t *testing.T) {
+	parsed, err := ParseReplicaOfArgs(bulkStrings("REPLICAOF", "127.0.0.1", "6380"))
+	require.NoError(t, err)
+	require.Equal(t, &ReplicaOfArgs{Host: "127.0.0.1", Port: 6380}, parsed)
+
+	parsed, err = ParseReplicaOfArgs(bulkStrings("REPLICAOF", "no", "one"))
+	require.NoError(t, err)
+	require.True(t, parsed.NoOne)
+
+	_, err = ParseReplicaOfArgs(bulkStrings("REPLICAOF", "host", "port"))
+	require.EqualError(t, err, "value is not an integer or out of range")
+	_, err = ParseReplicaOfArgs(bulkStrings("REPLICAOF", "host", "70000"))
+	require.EqualError(t, err, "ERR Invalid master port")
+}
+
+func TestParsePSyncArgs(t *testing.T) {
+	parsed, err := ParsePSyncArgs(bulkStrings("PSYNC", "?", "-1"))
+	require.NoError(t, err)
+	require.Equal(t, &PSyncArgs{ReplID: "?", Offset: -1}, parsed)
+
+	_, err = ParsePSyncArgs(bulkStrings("PSYNC", "?", "x"))
+	require.Error(t, err)
+}
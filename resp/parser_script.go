@@ -0,0 +1,24 @@
+package resp
+
+// ParseEvalArgs parses script numkeys [key ...] [arg ...], the script being a body or a digest.
+func ParseEvalArgs(args Array) (*EvalArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	numKeys, err := ParseInteger(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if numKeys < 0 {
+		return nil, NewSimpleError("Number of keys can't be negative")
+	}
+	if numKeys > int64(len(strs)-2) {
+		return nil, NewSimpleError("Number of keys can't be greater than number of args")
+	}
+	return &EvalArgs{
+		Script: strs[0],
+		Keys:   strs[2 : 2+numKeys],
+		Args:   strs[2+numKeys:],
+	}, nil
+}
@@ -0,0 +1,25 @@
+package resp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEvalArgs(t *testing.T) {
+	parsed, err := ParseEvalArgs(bulkStrings("EVAL", "return 1", "2", "k1", "k2", "a1"))
+	require.NoError(t, err)
+	require.Equal(t, &EvalArgs{Script: "return 1", Keys: []string{"k1", "k2"}, Args: []string{"a1"}}, parsed)
+
+	parsed, err = ParseEvalArgs(bulkStrings("EVAL", "return 1", "0"))
+	require.NoError(t, err)
+	require.Empty(t, parsed.Keys)
+	require.Empty(t, parsed.Args)
+
+	_, err = ParseEvalArgs(bulkStrings("EVAL", "return 1", "-1"))
+	require.EqualError(t, err, "ERR Number of keys can't be negative")
+	_, err = ParseEvalArgs(bulkStrings("EVAL", "return 1", "2", "k1"))
+	require.EqualError(t, err, "ERR Number of keys can't be greater than number of args")
+	_, err = ParseEvalArgs(bulkStrings("EVAL", "return 1", "x"))
+	require.EqualError(t, err, "value is not an integer or out of range")
+}
@@ -0,0 +1,335 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+)
+
+var errMaxLenNegative = NewSimpleError("The MAXLEN argument must be >= 0.")
+
+// ParseXAddArgs parses XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~] threshold [LIMIT count]] *|id field value [field value ...].
+func ParseXAddArgs(args Array) (*XAddArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &XAddArgs{Key: strs[0]}
+	i := 1
+	for i < len(strs) {
+		option := BulkString(strs[i]).Upper()
+		switch option {
+		case NOMKSTREAM:
+			parsed.NoMkStream = true
+			i++
+			continue
+		case MAXLEN, MINID:
+			parsed.Trim, i, err = parseTrimArgs(strs, i)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		break
+	}
+
+	// the ID must be followed by at least one field/value pair
+	if i >= len(strs) || (len(strs)-i-1) == 0 || (len(strs)-i-1)%2 != 0 {
+		return nil, errors.New("wrong number of arguments for 'xadd' command")
+	}
+	parsed.ID = strs[i]
+	parsed.Fields = strs[i+1:]
+	return parsed, nil
+}
+
+// ParseXTrimArgs parses XTRIM key MAXLEN|MINID [=|~] threshold [LIMIT count].
+func ParseXTrimArgs(args Array) (string, TrimArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return "", TrimArgs{}, err
+	}
+
+	option := BulkString(strs[1]).Upper()
+	if option != MAXLEN && option != MINID {
+		return "", TrimArgs{}, errors.New("syntax error")
+	}
+	trim, next, err := parseTrimArgs(strs, 1)
+	if err != nil {
+		return "", TrimArgs{}, err
+	}
+	if next != len(strs) {
+		return "", TrimArgs{}, errors.New("syntax error")
+	}
+	return strs[0], trim, nil
+}
+
+// parseTrimArgs parses the trimming options starting at strs[i] and returns the index following them.
+func parseTrimArgs(strs []string, i int) (TrimArgs, int, error) {
+	trim := TrimArgs{Strategy: BulkString(strs[i]).Upper()}
+	i++
+
+	if i < len(strs) && (strs[i] == "~" || strs[i] == "=") {
+		trim.Approx = strs[i] == "~"
+		i++
+	}
+	if i >= len(strs) {
+		return TrimArgs{}, 0, errors.New("syntax error")
+	}
+	trim.Threshold = strs[i]
+	i++
+
+	if trim.Strategy == MAXLEN {
+		maxLen, err := strconv.ParseInt(trim.Threshold, 10, 64)
+		if err != nil {
+			return TrimArgs{}, 0, errors.New("value is not an integer or out of range")
+		}
+		if maxLen < 0 {
+			return TrimArgs{}, 0, errMaxLenNegative
+		}
+	}
+
+	if i < len(strs) && BulkString(strs[i]).Upper() == LIMIT {
+		if i+1 >= len(strs) {
+			return TrimArgs{}, 0, errors.New("syntax error")
+		}
+		limit, err := strconv.ParseInt(strs[i+1], 10, 64)
+		if err != nil || limit < 0 {
+			return TrimArgs{}, 0, errors.New("value is out of range, must be positive")
+		}
+		if !trim.Approx {
+			return TrimArgs{}, 0, errors.New("syntax error, LIMIT cannot be used without the special ~ option")
+		}
+		trim.Limit = limit
+		i += 2
+	}
+	return trim, i, nil
+}
+
+// ParseXRangeArgs parses XRANGE key start end [COUNT count], count is -1 without the COUNT option.
+func ParseXRangeArgs(args Array) (key, start, end string, count int64, err error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return "", "", "", 0, err
+	}
+
+	count = -1
+	switch {
+	case len(strs) == 3:
+	case len(strs) == 5 && BulkString(strs[3]).Upper() == COUNT:
+		count, err = strconv.ParseInt(strs[4], 10, 64)
+		if err != nil {
+			return "", "", "", 0, errors.New("value is not an integer or out of range")
+		}
+		count = max(count, 0)
+	default:
+		return "", "", "", 0, errors.New("syntax error")
+	}
+	return strs[0], strs[1], strs[2], count, nil
+}
+
+// ParseXReadArgs parses the options of XREAD, or of XREADGROUP when group is true, up to the STREAMS keyword.
+func ParseXReadArgs(args Array, group bool) (*XReadArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	name := "xread"
+	if group {
+		name = "xreadgroup"
+	}
+
+	parsed := &XReadArgs{}
+	for i := 0; i < len(strs); i++ {
+		option := BulkString(strs[i]).Upper()
+		remaining := len(strs) - i - 1
+
+		switch {
+		case option == COUNT && remaining >= 1:
+			count, err := strconv.ParseInt(strs[i+1], 10, 64)
+			if err != nil {
+				return nil, errors.New("value is not an integer or out of range")
+			}
+			parsed.Count = max(count, 0)
+			i++
+		case option == BLOCK && remaining >= 1:
+			ms, err := strconv.ParseInt(strs[i+1], 10, 64)
+			if err != nil {
+				return nil, errors.New("timeout is not an integer or out of range")
+			}
+			if ms < 0 {
+				return nil, errors.New("timeout is negative")
+			}
+			parsed.Block = true
+			parsed.Timeout = time.Duration(ms) * time.Millisecond
+			i++
+		case option == GROUP && group && remaining >= 2:
+			parsed.Group, parsed.Consumer = strs[i+1], strs[i+2]
+			i += 2
+		case option == NOACK && group:
+			parsed.NoAck = true
+		case option == STREAMS:
+			streams := strs[i+1:]
+			if len(streams) == 0 || len(streams)%2 != 0 {
+				return nil, NewSimpleError(fmt.Sprintf("Unbalanced '%s' list of streams: for each stream key an ID or '$' must be specified.", name))
+			}
+			parsed.Keys = streams[:len(streams)/2]
+			parsed.IDs = streams[len(streams)/2:]
+			if group && parsed.Group == "" {
+				return nil, NewSimpleError(fmt.Sprintf("Missing GROUP option for '%s'", strings.ToUpper(name)))
+			}
+			return parsed, nil
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return nil, errors.New("syntax error")
+}
+
+// ParseXPendingArgs parses XPENDING key group [[IDLE min-idle-time] start end count [consumer]].
+func ParseXPendingArgs(args Array) (*XPendingArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &XPendingArgs{Key: strs[0], Group: strs[1]}
+	if len(strs) == 2 {
+		return parsed, nil
+	}
+
+	i := 2
+	if BulkString(strs[i]).Upper() == IDLE {
+		if i+1 >= len(strs) {
+			return nil, errors.New("syntax error")
+		}
+		parsed.MinIdle, err = parseMinIdle(strs[i+1])
+		if err != nil {
+			return nil, err
+		}
+		i += 2
+	}
+	if remaining := len(strs) - i; remaining != 3 && remaining != 4 {
+		return nil, errors.New("syntax error")
+	}
+
+	parsed.Start, parsed.End = strs[i], strs[i+1]
+	parsed.Count, err = strconv.ParseInt(strs[i+2], 10, 64)
+	if err != nil {
+		return nil, errors.New("value is not an integer or out of range")
+	}
+	parsed.Count = max(parsed.Count, 0)
+	if i+3 < len(strs) {
+		parsed.Consumer = strs[i+3]
+	}
+	return parsed, nil
+}
+
+// ParseXClaimArgs parses XCLAIM key group consumer min-idle-time id [id ...] [IDLE ms] [TIME unix-time-ms]
+// [RETRYCOUNT count] [FORCE] [JUSTID] [LASTID id].
+// IDLE is converted to a delivery time with clk.
+func ParseXClaimArgs(args Array, clk clock.Clock) (*XClaimArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &XClaimArgs{Key: strs[0], Group: strs[1], Consumer: strs[2]}
+	parsed.MinIdle, err = parseMinIdle(strs[3])
+	if err != nil {
+		return nil, NewSimpleError("Invalid min-idle-time argument for XCLAIM")
+	}
+
+	// the IDs run up to the first option
+	i := 4
+	for ; i < len(strs); i++ {
+		switch BulkString(strs[i]).Upper() {
+		case IDLE, TIME, RETRYCOUNT, FORCE, JUSTID, LASTID:
+		default:
+			parsed.IDs = append(parsed.IDs, strs[i])
+			continue
+		}
+		break
+	}
+	if len(parsed.IDs) == 0 {
+		return nil, errors.New("wrong number of arguments for 'xclaim' command")
+	}
+
+	for ; i < len(strs); i++ {
+		option := BulkString(strs[i]).Upper()
+		switch {
+		case option == FORCE:
+			parsed.Force = true
+		case option == JUSTID:
+			parsed.JustID = true
+		case (option == IDLE || option == TIME || option == RETRYCOUNT) && i+1 < len(strs):
+			value, err := strconv.ParseInt(strs[i+1], 10, 64)
+			if err != nil {
+				return nil, NewSimpleError(fmt.Sprintf("Invalid %s option argument for XCLAIM", option))
+			}
+			switch option {
+			case IDLE:
+				deliveryTime := clk.Now().Add(-time.Duration(value) * time.Millisecond)
+				parsed.DeliveryTime = &deliveryTime
+			case TIME:
+				deliveryTime := time.UnixMilli(value)
+				parsed.DeliveryTime = &deliveryTime
+			default:
+				parsed.RetryCount = &value
+			}
+			i++
+		case option == LASTID && i+1 < len(strs):
+			// only meaningful to replicas of Redis, accepted for compatibility
+			i++
+		default:
+			return nil, NewSimpleError(fmt.Sprintf("Unrecognized XCLAIM option '%s'", strs[i]))
+		}
+	}
+	return parsed, nil
+}
+
+// ParseXAutoClaimArgs parses XAUTOCLAIM key group consumer min-idle-time start [COUNT count] [JUSTID].
+func ParseXAutoClaimArgs(args Array) (*XAutoClaimArgs, error) {
+	strs, err := ParseStrings(args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &XAutoClaimArgs{Key: strs[0], Group: strs[1], Consumer: strs[2], Start: strs[4], Count: 100}
+	parsed.MinIdle, err = parseMinIdle(strs[3])
+	if err != nil {
+		return nil, NewSimpleError("Invalid min-idle-time argument for XAUTOCLAIM")
+	}
+
+	for i := 5; i < len(strs); i++ {
+		option := BulkString(strs[i]).Upper()
+		switch {
+		case option == JUSTID:
+			parsed.JustID = true
+		case option == COUNT && i+1 < len(strs):
+			count, err := strconv.ParseInt(strs[i+1], 10, 64)
+			if err != nil || count < 1 || count > math.MaxInt32 {
+				return nil, NewSimpleError("COUNT must be > 0")
+			}
+			parsed.Count = count
+			i++
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return parsed, nil
+}
+
+// parseMinIdle parses an idle time in milliseconds, negative values meaning no minimum.
+func parseMinIdle(s string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("value is not an integer or out of range")
+	}
+	return time.Duration(max(ms, 0)) * time.Millisecond, nil
+}
@@ -0,0 +1,148 @@
+package resp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func bulkStrings(strs ...string) Array {
+	args := make(Array, len(strs))
+	for i, s := range strs {
+		args[i] = BulkString(s)
+	}
+	return args
+}
+
+func TestParseXAddArgs(t *testing.T) {
+	t.Run("Simple XADD", func(t *testing.T) {
+		parsed, err := ParseXAddArgs(bulkStrings("XADD", "s", "*", "f", "v"))
+		require.NoError(t, err)
+		require.Equal(t, &XAddArgs{Key: "s", ID: "*", Fields: []string{"f", "v"}}, parsed)
+	})
+
+	t.Run("XADD with options", func(t *testing.T) {
+		parsed, err := ParseXAddArgs(bulkStrings("XADD", "s", "nomkstream", "MAXLEN", "~", "10", "LIMIT", "5", "1-*", "f", "v"))
+		require.NoError(t, err)
+		require.True(t, parsed.NoMkStream)
+		require.Equal(t, TrimArgs{Strategy: MAXLEN, Approx: true, Threshold: "10", Limit: 5}, parsed.Trim)
+		require.Equal(t, "1-*", parsed.ID)
+	})
+
+	t.Run("Invalid XADD", func(t *testing.T) {
+		_, err := ParseXAddArgs(bulkStrings("XADD", "s", "*", "f"))
+		require.EqualError(t, err, "wrong number of arguments for 'xadd' command")
+
+		_, err = ParseXAddArgs(bulkStrings("XADD", "s", "MAXLEN", "-1", "*", "f", "v"))
+		require.ErrorIs(t, err, errMaxLenNegative)
+
+		_, err = ParseXAddArgs(bulkStrings("XADD", "s", "MAXLEN", "10", "LIMIT", "5", "*", "f", "v"))
+		require.EqualError(t, err, "syntax error, LIMIT cannot be used without the special ~ option")
+	})
+}
+
+func TestParseXTrimArgs(t *testing.T) {
+	key, trim, err := ParseXTrimArgs(bulkStrings("XTRIM", "s", "minid", "=", "5-0"))
+	require.NoError(t, err)
+	require.Equal(t, "s", key)
+	require.Equal(t, TrimArgs{Strategy: MINID, Threshold: "5-0"}, trim)
+
+	_, _, err = ParseXTrimArgs(bulkStrings("XTRIM", "s", "MAXLEN", "1", "extra"))
+	require.EqualError(t, err, "syntax error")
+}
+
+func TestParseXRangeArgs(t *testing.T) {
+	key, start, end, count, err := ParseXRangeArgs(bulkStrings("XRANGE", "s", "-", "+"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"s", "-", "+"}, []string{key, start, end})
+	require.Equal(t, int64(-1), count)
+
+	_, _, _, count, err = ParseXRangeArgs(bulkStrings("XRANGE", "s", "-", "+", "COUNT", "3"))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	_, _, _, _, err = ParseXRangeArgs(bulkStrings("XRANGE", "s", "-", "+", "LIMIT", "3"))
+	require.EqualError(t, err, "syntax error")
+}
+
+func TestParseXReadArgs(t *testing.T) {
+	t.Run("XREAD", func(t *testing.T) {
+		parsed, err := ParseXReadArgs(bulkStrings("XREAD", "COUNT", "2", "BLOCK", "1500", "STREAMS", "a", "b", "0", "$"), false)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), parsed.Count)
+		require.True(t, parsed.Block)
+		require.Equal(t, 1500*time.Millisecond, parsed.Timeout)
+		require.Equal(t, []string{"a", "b"}, parsed.Keys)
+		require.Equal(t, []string{"0", "$"}, parsed.IDs)
+
+		_, err = ParseXReadArgs(bulkStrings("XREAD", "STREAMS", "a", "b", "0"), false)
+		require.ErrorContains(t, err, "Unbalanced 'xread' list of streams")
+
+		_, err = ParseXReadArgs(bulkStrings("XREAD", "GROUP", "g", "c", "STREAMS", "a", "0"), false)
+		require.EqualError(t, err, "syntax error")
+	})
+
+	t.Run("XREADGROUP", func(t *testing.T) {
+		parsed, err := ParseXReadArgs(bulkStrings("XREADGROUP", "GROUP", "g", "c", "NOACK", "STREAMS", "a", ">"), true)
+		require.NoError(t, err)
+		require.Equal(t, "g", parsed.Group)
+		require.Equal(t, "c", parsed.Consumer)
+		require.True(t, parsed.NoAck)
+		require.False(t, parsed.Block)
+
+		_, err = ParseXReadArgs(bulkStrings("XREADGROUP", "STREAMS", "a", ">"), true)
+		require.ErrorContains(t, err, "Missing GROUP option for 'XREADGROUP'")
+	})
+}
+
+func TestParseXPendingArgs(t *testing.T) {
+	parsed, err := ParseXPendingArgs(bulkStrings("XPENDING", "s", "g"))
+	require.NoError(t, err)
+	require.Empty(t, parsed.Start)
+
+	parsed, err = ParseXPendingArgs(bulkStrings("XPENDING", "s", "g", "IDLE", "100", "-", "+", "10", "alice"))
+	require.NoError(t, err)
+	require.Equal(t, &XPendingArgs{Key: "s", Group: "g", MinIdle: 100 * time.Millisecond, Start: "-", End: "+", Count: 10, Consumer: "alice"}, parsed)
+
+	_, err = ParseXPendingArgs(bulkStrings("XPENDING", "s", "g", "-", "+"))
+	require.EqualError(t, err, "syntax error")
+}
+
+func TestParseXClaimArgs(t *testing.T) {
+	clk := clock.NewFake(time.UnixMilli(10000))
+	parsed, err := ParseXClaimArgs(bulkStrings("XCLAIM", "s", "g", "c", "1000", "1-0", "2-0", "RETRYCOUNT", "3", "force", "JUSTID", "TIME", "5000"), clk)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, parsed.MinIdle)
+	require.Equal(t, []string{"1-0", "2-0"}, parsed.IDs)
+	require.Equal(t, int64(3), *parsed.RetryCount)
+	require.True(t, parsed.Force)
+	require.True(t, parsed.JustID)
+	require.Equal(t, time.UnixMilli(5000), *parsed.DeliveryTime)
+
+	parsed, err = ParseXClaimArgs(bulkStrings("XCLAIM", "s", "g", "c", "1000", "1-0", "IDLE", "3000"), clk)
+	require.NoError(t, err)
+	require.Equal(t, time.UnixMilli(7000), *parsed.DeliveryTime)
+
+	_, err = ParseXClaimArgs(bulkStrings("XCLAIM", "s", "g", "c", "1000", "FORCE"), clk)
+	require.EqualError(t, err, "wrong number of arguments for 'xclaim' command")
+
+	_, err = ParseXClaimArgs(bulkStrings("XCLAIM", "s", "g", "c", "1000", "1-0", "FORCE", "2-0"), clk)
+	require.ErrorContains(t, err, "Unrecognized XCLAIM option '2-0'")
+}
+
+func TestParseXAutoClaimArgs(t *testing.T) {
+	parsed, err := ParseXAutoClaimArgs(bulkStrings("XAUTOCLAIM", "s", "g", "c", "10", "0-0"))
+	require.NoError(t, err)
+	require.Equal(t, int64(100), parsed.Count)
+	require.False(t, parsed.JustID)
+
+	parsed, err = ParseXAutoClaimArgs(bulkStrings("XAUTOCLAIM", "s", "g", "c", "10", "0-0", "COUNT", "5", "JUSTID"))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), parsed.Count)
+	require.True(t, parsed.JustID)
+
+	_, err = ParseXAutoClaimArgs(bulkStrings("XAUTOCLAIM", "s", "g", "c", "10", "0-0", "COUNT", "0"))
+	require.ErrorContains(t, err, "COUNT must be > 0")
+}
@@ -1,11 +1,16 @@
 // Package resp provides RESP (Redis Serialization Protocol) types and utilities.
 package resp
 
-import "io"
+import "errors"
 
-// Payload is used to marshal a value to a RESP-encoded byte slice.
+// ErrUnencodable is returned when an element of an aggregate is not a Payload.
+var ErrUnencodable = errors.New("value cannot be encoded in RESP")
+
+// Payload is a value that can be sent in the RESP format.
 type Payload interface {
-	RESPReader() io.Reader
+	// AppendRESP appends the encoding of the value to dst and returns the extended buffer.
+	// it fails with ErrUnencodable when an element of an aggregate cannot be encoded, dst then holding a partial encoding.
+	AppendRESP(dst []byte) ([]byte, error)
 }
 
 type Stringer interface {
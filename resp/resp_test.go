@@ -2,7 +2,6 @@ package resp_test
 
 import (
 	"bytes"
-	"io"
 	"strings"
 	"testing"
 
@@ -22,8 +21,7 @@ func TestPayloadIntegration(t *testing.T) {
 			resp.Null{},
 		}
 
-		reader := array.RESPReader()
-		result, err := io.ReadAll(reader)
+		result, err := array.AppendRESP(nil)
 		require.NoError(t, err)
 		expected := "*5\r\n+OK\r\n:123\r\n$5\r\nhello\r\n-ERR test error\r\n$-1\r\n"
 		require.Equal(t, expected, string(result))
@@ -39,8 +37,7 @@ func TestPayloadIntegration(t *testing.T) {
 			innerArray,
 		}
 
-		reader := outerArray.RESPReader()
-		result, err := io.ReadAll(reader)
+		result, err := outerArray.AppendRESP(nil)
 		require.NoError(t, err)
 		expected := "*2\r\n$5\r\nouter\r\n*2\r\n$5\r\ninner\r\n:456\r\n"
 		require.Equal(t, expected, string(result))
@@ -48,8 +45,7 @@ func TestPayloadIntegration(t *testing.T) {
 
 	t.Run("Empty array", func(t *testing.T) {
 		array := resp.Array{}
-		reader := array.RESPReader()
-		result, err := io.ReadAll(reader)
+		result, err := array.AppendRESP(nil)
 		require.NoError(t, err)
 		expected := "*0\r\n"
 		require.Equal(t, expected, string(result))
@@ -137,6 +133,66 @@ func TestParserIntegration(t *testing.T) {
 	})
 }
 
+func TestReplyParser(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected any
+		// what the reply encodes to when it differs from data, RESP2 having no null nor bulk error
+		encoded string
+	}{
+		{"Null bulk string", "$-1\r\n", resp.Null{}, ""},
+		{"Null array", "*-1\r\n", resp.NullArray{}, ""},
+		{"Simple error", "-WRONGTYPE Operation against a key\r\n", resp.NewPrefixedError("WRONGTYPE", "Operation against a key"), ""},
+		{"Bulk error", "!21\r\nSYNTAX invalid syntax\r\n", resp.NewPrefixedError("SYNTAX", "invalid syntax"), "-SYNTAX invalid syntax\r\n"},
+		{"Null", "_\r\n", resp.Null{}, "$-1\r\n"},
+		{"Double", ",1.5\r\n", resp.Double(1.5), ""},
+		{"Boolean", "#t\r\n", resp.Boolean(true), ""},
+		{"Big number", "(3492890328409238509324850943850943825024385\r\n", resp.BigNumber("3492890328409238509324850943850943825024385"), ""},
+		{"Verbatim string", "=15\r\ntxt:Some string\r\n", resp.VerbatimString{Format: "txt", Text: "Some string"}, ""},
+		{"Map", "%2\r\n+first\r\n:1\r\n+second\r\n_\r\n", resp.Map{
+			{Key: resp.SimpleString("first"), Value: resp.Integer(1)},
+			{Key: resp.SimpleString("second"), Value: resp.Null{}},
+		}, "%2\r\n+first\r\n:1\r\n+second\r\n$-1\r\n"},
+		{"Set", "~2\r\n$1\r\na\r\n#f\r\n", resp.Set{resp.BulkString("a"), resp.Boolean(false)}, ""},
+		{"Push", ">2\r\n$7\r\nmessage\r\n*1\r\n$-1\r\n", resp.Push{resp.BulkString("message"), resp.Array{resp.Null{}}}, ""},
+		{"Attribute", "|1\r\n+ttl\r\n:3600\r\n:42\r\n", resp.Integer(42), ":42\r\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser := resp.NewReplyParser(strings.NewReader(tc.data))
+			result, err := parser.Parse()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, result)
+
+			expected := tc.encoded
+			if expected == "" {
+				expected = tc.data
+			}
+			payload, ok := result.(resp.Payload)
+			require.True(t, ok)
+			encoded, err := payload.AppendRESP(nil)
+			require.NoError(t, err)
+			require.Equal(t, expected, string(encoded))
+		})
+	}
+
+	t.Run("Special doubles", func(t *testing.T) {
+		parser := resp.NewReplyParser(strings.NewReader(",inf\r\n,-inf\r\n,nan\r\n"))
+		for _, expected := range []string{"inf", "-inf", "nan"} {
+			result, err := parser.Parse()
+			require.NoError(t, err)
+			require.Equal(t, expected, result.(resp.Double).String())
+		}
+	})
+
+	t.Run("Invalid boolean", func(t *testing.T) {
+		_, err := resp.NewReplyParser(strings.NewReader("#x\r\n")).Parse()
+		require.ErrorContains(t, err, "parse boolean failed")
+	})
+}
+
 // Test parser error handling
 func TestParserErrorHandling(t *testing.T) {
 	t.Run("Invalid array length", func(t *testing.T) {
@@ -183,51 +239,42 @@ func TestParserErrorHandling(t *testing.T) {
 // Test constant definitions
 func TestConstants(t *testing.T) {
 	t.Run("Response constants", func(t *testing.T) {
-		okReader := resp.OK.RESPReader()
-		okData, err := io.ReadAll(okReader)
+		okData, err := resp.OK.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "+OK\r\n", string(okData))
 
-		nullReader := resp.NULL.RESPReader()
-		nullData, err := io.ReadAll(nullReader)
+		nullData, err := resp.NULL.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$-1\r\n", string(nullData))
 	})
 
 	t.Run("Command constants", func(t *testing.T) {
 		// Test some common command constants
-		setReader := resp.SET.RESPReader()
-		setData, err := io.ReadAll(setReader)
+		setData, err := resp.SET.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$3\r\nSET\r\n", string(setData))
 
-		getReader := resp.GET.RESPReader()
-		getData, err := io.ReadAll(getReader)
+		getData, err := resp.GET.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$3\r\nGET\r\n", string(getData))
 
-		delReader := resp.DEL.RESPReader()
-		delData, err := io.ReadAll(delReader)
+		delData, err := resp.DEL.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$3\r\nDEL\r\n", string(delData))
 
-		exReader := resp.EX.RESPReader()
-		exData, err := io.ReadAll(exReader)
+		exData, err := resp.EX.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$2\r\nEX\r\n", string(exData))
 
-		pxReader := resp.PX.RESPReader()
-		pxData, err := io.ReadAll(pxReader)
+		pxData, err := resp.PX.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$2\r\nPX\r\n", string(pxData))
 
-		nxReader := resp.NX.RESPReader()
-		nxData, err := io.ReadAll(nxReader)
+		nxData, err := resp.NX.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$2\r\nNX\r\n", string(nxData))
 
-		xxReader := resp.XX.RESPReader()
-		xxData, err := io.ReadAll(xxReader)
+		xxData, err := resp.XX.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$2\r\nXX\r\n", string(xxData))
 	})
@@ -283,8 +330,7 @@ func TestRoundTrip(t *testing.T) {
 			require.True(t, ok, "Type should implement Payload interface")
 
 			// Marshal
-			reader := marshaler.RESPReader()
-			encoded, err := io.ReadAll(reader)
+			encoded, err := marshaler.AppendRESP(nil)
 			require.NoError(t, err)
 			require.NotEmpty(t, encoded)
 
@@ -306,8 +352,7 @@ func TestRedisCommandScenarios(t *testing.T) {
 			resp.BulkString("myvalue"),
 		}
 
-		reader := command.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := command.AppendRESP(nil)
 		require.NoError(t, err)
 		parser := resp.NewParser(bytes.NewReader(encoded))
 		decoded, err := parser.Parse()
@@ -327,8 +372,7 @@ func TestRedisCommandScenarios(t *testing.T) {
 			resp.BulkString("mykey"),
 		}
 
-		reader := command.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := command.AppendRESP(nil)
 		require.NoError(t, err)
 		parser := resp.NewParser(bytes.NewReader(encoded))
 		decoded, err := parser.Parse()
@@ -349,8 +393,7 @@ func TestRedisCommandScenarios(t *testing.T) {
 			resp.BulkString("key3"),
 		}
 
-		reader := command.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := command.AppendRESP(nil)
 		require.NoError(t, err)
 		parser := resp.NewParser(bytes.NewReader(encoded))
 		decoded, err := parser.Parse()
@@ -373,8 +416,7 @@ func TestLargeDataHandling(t *testing.T) {
 		largeData := strings.Repeat("x", 1024)
 		bulkString := resp.BulkString(largeData)
 
-		reader := bulkString.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := bulkString.AppendRESP(nil)
 		require.NoError(t, err)
 		parser := resp.NewParser(bytes.NewReader(encoded))
 		decoded, err := parser.Parse()
@@ -390,8 +432,7 @@ func TestLargeDataHandling(t *testing.T) {
 			array[i] = resp.BulkString(strings.Repeat("data", i+1))
 		}
 
-		reader := array.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := array.AppendRESP(nil)
 		require.NoError(t, err)
 		parser := resp.NewParser(bytes.NewReader(encoded))
 		decoded, err := parser.Parse()
@@ -412,8 +453,7 @@ func TestLargeDataHandling(t *testing.T) {
 func TestEdgeCases(t *testing.T) {
 	t.Run("Zero integer", func(t *testing.T) {
 		i := resp.Integer(0)
-		reader := i.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := i.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, ":0\r\n", string(encoded))
 
@@ -425,8 +465,7 @@ func TestEdgeCases(t *testing.T) {
 
 	t.Run("Negative integer", func(t *testing.T) {
 		i := resp.Integer(-123)
-		reader := i.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := i.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, ":-123\r\n", string(encoded))
 
@@ -438,8 +477,7 @@ func TestEdgeCases(t *testing.T) {
 
 	t.Run("BulkString with special characters", func(t *testing.T) {
 		special := resp.BulkString("hello\r\nworld\ttab")
-		reader := special.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := special.AppendRESP(nil)
 		require.NoError(t, err)
 
 		parser := resp.NewParser(bytes.NewReader(encoded))
@@ -450,8 +488,7 @@ func TestEdgeCases(t *testing.T) {
 
 	t.Run("Empty simple string", func(t *testing.T) {
 		s := resp.SimpleString("")
-		reader := s.RESPReader()
-		encoded, err := io.ReadAll(reader)
+		encoded, err := s.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "+\r\n", string(encoded))
 
@@ -1,9 +1,8 @@
 package resp
 
 import (
-	"bytes"
 	"fmt"
-	"io"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -11,26 +10,43 @@ import (
 // Array
 type Array []any
 
-func (a Array) RESPReader() io.Reader {
-	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+func (a Array) AppendRESP(dst []byte) ([]byte, error) {
+	return appendAggregate(dst, '*', a)
+}
 
-	buf.WriteByte('*')
-	buf.WriteString(strconv.FormatInt(int64(len(a)), 10))
-	buf.WriteByte('\r')
-	buf.WriteByte('\n')
+// appendHeader appends the type byte and the length of an aggregate or a bulk string.
+func appendHeader(dst []byte, kind byte, n int) []byte {
+	dst = append(dst, kind)
+	dst = strconv.AppendInt(dst, int64(n), 10)
+	return append(dst, '\r', '\n')
+}
 
-	for _, v := range a {
-		marshaler, ok := v.(Payload)
-		if !ok {
-			return nil
+// appendAggregate appends an aggregate of kind along with its elements.
+func appendAggregate(dst []byte, kind byte, elements []any) ([]byte, error) {
+	dst = appendHeader(dst, kind, len(elements))
+	for _, v := range elements {
+		var err error
+		if dst, err = appendElement(dst, v); err != nil {
+			return dst, err
 		}
+	}
+	return dst, nil
+}
 
-		if _, err := io.Copy(buf, marshaler.RESPReader()); err != nil {
-			return nil
-		}
+// appendElement appends an element of an aggregate, which must be a Payload.
+func appendElement(dst []byte, v any) ([]byte, error) {
+	p, ok := v.(Payload)
+	if !ok {
+		return dst, fmt.Errorf("%w: %T", ErrUnencodable, v)
 	}
+	return p.AppendRESP(dst)
+}
 
-	return buf
+// appendLine appends a simple type: the type byte, s and CRLF.
+func appendLine(dst []byte, kind byte, s string) []byte {
+	dst = append(dst, kind)
+	dst = append(dst, s...)
+	return append(dst, '\r', '\n')
 }
 
 func (a Array) Bytes() []byte {
@@ -44,8 +60,8 @@ func (a Array) String() string {
 // SimpleString
 type SimpleString string
 
-func (s SimpleString) RESPReader() io.Reader {
-	return bytes.NewReader(fmt.Appendf(nil, "+%s\r\n", s))
+func (s SimpleString) AppendRESP(dst []byte) ([]byte, error) {
+	return appendLine(dst, '+', string(s)), nil
 }
 
 func (s SimpleString) Bytes() []byte {
@@ -58,15 +74,31 @@ func (s SimpleString) String() string {
 
 // SimpleError
 type SimpleError struct {
+	prefix  string
 	message string
 }
 
+// NewSimpleError creates a generic error, sent with the ERR prefix.
 func NewSimpleError(message string) SimpleError {
-	return SimpleError{message: message}
+	return SimpleError{prefix: "ERR", message: message}
 }
 
-func (e SimpleError) RESPReader() io.Reader {
-	return bytes.NewReader(fmt.Appendf(nil, "-ERR %s\r\n", e.message))
+// NewPrefixedError creates an error with a specific prefix such as WRONGTYPE or OOM, which clients use to tell errors apart.
+func NewPrefixedError(prefix, message string) SimpleError {
+	return SimpleError{prefix: prefix, message: message}
+}
+
+func (e SimpleError) AppendRESP(dst []byte) ([]byte, error) {
+	dst = append(dst, '-')
+	dst = append(dst, e.prefix...)
+	dst = append(dst, ' ')
+	dst = append(dst, e.message...)
+	return append(dst, '\r', '\n'), nil
+}
+
+// Error makes SimpleError usable as an error, so that command handlers can return it to pick the prefix sent to the client.
+func (e SimpleError) Error() string {
+	return e.prefix + " " + e.message
 }
 
 func (e SimpleError) Bytes() []byte {
@@ -80,8 +112,10 @@ func (e SimpleError) String() string {
 // BulkString
 type BulkString string
 
-func (b BulkString) RESPReader() io.Reader {
-	return bytes.NewReader(fmt.Appendf(nil, "$%d\r\n%s\r\n", len(b), b))
+func (b BulkString) AppendRESP(dst []byte) ([]byte, error) {
+	dst = appendHeader(dst, '$', len(b))
+	dst = append(dst, b...)
+	return append(dst, '\r', '\n'), nil
 }
 
 func (b BulkString) Bytes() []byte {
@@ -99,8 +133,10 @@ func (b BulkString) Upper() BulkString {
 // Integer
 type Integer int64
 
-func (i Integer) RESPReader() io.Reader {
-	return bytes.NewReader(fmt.Appendf(nil, ":%d\r\n", i))
+func (i Integer) AppendRESP(dst []byte) ([]byte, error) {
+	dst = append(dst, ':')
+	dst = strconv.AppendInt(dst, int64(i), 10)
+	return append(dst, '\r', '\n'), nil
 }
 
 func (i Integer) Bytes() []byte {
@@ -111,11 +147,26 @@ func (i Integer) String() string {
 	return strconv.FormatInt(int64(i), 10)
 }
 
+// NullArray is the null reply of commands returning arrays, such as a timed out BLPOP.
+type NullArray struct{}
+
+func (n NullArray) AppendRESP(dst []byte) ([]byte, error) {
+	return append(dst, "*-1\r\n"...), nil
+}
+
+func (n NullArray) Bytes() []byte {
+	return nil
+}
+
+func (n NullArray) String() string {
+	return ""
+}
+
 // Null
 type Null struct{}
 
-func (n Null) RESPReader() io.Reader {
-	return bytes.NewReader([]byte("$-1\r\n"))
+func (n Null) AppendRESP(dst []byte) ([]byte, error) {
+	return append(dst, "$-1\r\n"...), nil
 }
 
 func (n Null) Bytes() []byte {
@@ -125,3 +176,149 @@ func (n Null) Bytes() []byte {
 func (n Null) String() string {
 	return ""
 }
+
+// Double is a RESP3 floating point number.
+type Double float64
+
+func (d Double) AppendRESP(dst []byte) ([]byte, error) {
+	dst = d.appendText(append(dst, ','))
+	return append(dst, '\r', '\n'), nil
+}
+
+func (d Double) Bytes() []byte {
+	return d.appendText(nil)
+}
+
+func (d Double) String() string {
+	return string(d.appendText(nil))
+}
+
+// appendText appends the decimal form of d, or inf, -inf and nan.
+func (d Double) appendText(dst []byte) []byte {
+	switch f := float64(d); {
+	case math.IsInf(f, 1):
+		return append(dst, "inf"...)
+	case math.IsInf(f, -1):
+		return append(dst, "-inf"...)
+	case math.IsNaN(f):
+		return append(dst, "nan"...)
+	default:
+		return strconv.AppendFloat(dst, f, 'f', -1, 64)
+	}
+}
+
+// Boolean is a RESP3 boolean.
+type Boolean bool
+
+func (b Boolean) AppendRESP(dst []byte) ([]byte, error) {
+	if b {
+		return append(dst, "#t\r\n"...), nil
+	}
+	return append(dst, "#f\r\n"...), nil
+}
+
+func (b Boolean) Bytes() []byte {
+	return []byte(b.String())
+}
+
+func (b Boolean) String() string {
+	return strconv.FormatBool(bool(b))
+}
+
+// BigNumber is a RESP3 integer of arbitrary size, kept in its decimal form.
+type BigNumber string
+
+func (n BigNumber) AppendRESP(dst []byte) ([]byte, error) {
+	return appendLine(dst, '(', string(n)), nil
+}
+
+func (n BigNumber) Bytes() []byte {
+	return []byte(n)
+}
+
+func (n BigNumber) String() string {
+	return string(n)
+}
+
+// VerbatimString is a RESP3 string along with its format, txt for plain text and mkd for markdown.
+type VerbatimString struct {
+	Format string
+	Text   string
+}
+
+func (v VerbatimString) AppendRESP(dst []byte) ([]byte, error) {
+	dst = appendHeader(dst, '=', len(v.Format)+1+len(v.Text))
+	dst = append(dst, v.Format...)
+	dst = append(dst, ':')
+	dst = append(dst, v.Text...)
+	return append(dst, '\r', '\n'), nil
+}
+
+func (v VerbatimString) Bytes() []byte {
+	return []byte(v.Text)
+}
+
+func (v VerbatimString) String() string {
+	return v.Text
+}
+
+// MapEntry is a key and its value in a Map.
+type MapEntry struct {
+	Key   any
+	Value any
+}
+
+// Map is a RESP3 map, whose entries keep the order they were sent in.
+type Map []MapEntry
+
+func (m Map) AppendRESP(dst []byte) ([]byte, error) {
+	dst = appendHeader(dst, '%', len(m))
+	for _, entry := range m {
+		var err error
+		if dst, err = appendElement(dst, entry.Key); err != nil {
+			return dst, err
+		}
+		if dst, err = appendElement(dst, entry.Value); err != nil {
+			return dst, err
+		}
+	}
+	return dst, nil
+}
+
+func (m Map) Bytes() []byte {
+	return nil
+}
+
+func (m Map) String() string {
+	return ""
+}
+
+// Set is a RESP3 set.
+type Set []any
+
+func (s Set) AppendRESP(dst []byte) ([]byte, error) {
+	return appendAggregate(dst, '~', s)
+}
+
+func (s Set) Bytes() []byte {
+	return nil
+}
+
+func (s Set) String() string {
+	return ""
+}
+
+// Push is a RESP3 out of band message, such as a message published to a subscribed channel.
+type Push []any
+
+func (p Push) AppendRESP(dst []byte) ([]byte, error) {
+	return appendAggregate(dst, '>', p)
+}
+
+func (p Push) Bytes() []byte {
+	return nil
+}
+
+func (p Push) String() string {
+	return ""
+}
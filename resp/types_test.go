@@ -1,70 +1,70 @@
 package resp
 
 import (
-	"io"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
-func TestSimpleString_Reader(t *testing.T) {
+func TestSimpleString_AppendRESP(t *testing.T) {
 	s := SimpleString("OK")
-	reader := s.RESPReader()
-	data, err := io.ReadAll(reader)
+	data, err := s.AppendRESP(nil)
 	require.NoError(t, err)
 	require.Equal(t, "+OK\r\n", string(data))
 }
 
-func TestSimpleError_Reader(t *testing.T) {
+func TestSimpleError_AppendRESP(t *testing.T) {
 	e := NewSimpleError("Error message")
-	reader := e.RESPReader()
-	data, err := io.ReadAll(reader)
+	data, err := e.AppendRESP(nil)
 	require.NoError(t, err)
 	require.Equal(t, "-ERR Error message\r\n", string(data))
 }
 
-func TestBulkString_Reader(t *testing.T) {
+func TestBulkString_AppendRESP(t *testing.T) {
 	t.Run("Normal string", func(t *testing.T) {
 		b := BulkString("hello")
-		reader := b.RESPReader()
-		data, err := io.ReadAll(reader)
+		data, err := b.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$5\r\nhello\r\n", string(data))
 	})
 
 	t.Run("Empty string", func(t *testing.T) {
 		b := BulkString("")
-		reader := b.RESPReader()
-		data, err := io.ReadAll(reader)
+		data, err := b.AppendRESP(nil)
 		require.NoError(t, err)
 		require.Equal(t, "$0\r\n\r\n", string(data))
 	})
 }
 
-func TestInteger_Reader(t *testing.T) {
+func TestInteger_AppendRESP(t *testing.T) {
 	i := Integer(1000)
-	reader := i.RESPReader()
-	data, err := io.ReadAll(reader)
+	data, err := i.AppendRESP(nil)
 	require.NoError(t, err)
 	require.Equal(t, ":1000\r\n", string(data))
 }
 
-func TestNull_Reader(t *testing.T) {
+func TestNull_AppendRESP(t *testing.T) {
 	n := Null{}
-	reader := n.RESPReader()
-	data, err := io.ReadAll(reader)
+	data, err := n.AppendRESP(nil)
 	require.NoError(t, err)
 	require.Equal(t, "$-1\r\n", string(data))
 }
 
-func TestArray_Reader(t *testing.T) {
+func TestArray_AppendRESP(t *testing.T) {
 	a := Array{
 		BulkString("hello"),
 		Integer(123),
 	}
-	reader := a.RESPReader()
-	data, err := io.ReadAll(reader)
+	data, err := a.AppendRESP(nil)
 	require.NoError(t, err)
 	expected := "*2\r\n$5\r\nhello\r\n:123\r\n"
 	require.Equal(t, expected, string(data))
 }
+
+func TestPrefixedError_AppendRESP(t *testing.T) {
+	e := NewPrefixedError("WRONGTYPE", "Operation against a key holding the wrong kind of value")
+	data, err := e.AppendRESP(nil)
+	require.NoError(t, err)
+	require.Equal(t, "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", string(data))
+	require.Equal(t, "WRONGTYPE Operation against a key holding the wrong kind of value", e.Error())
+}
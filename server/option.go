@@ -1,6 +1,11 @@
 package server
 
-import "log/slog"
+import (
+	"log/slog"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+)
 
 type Option func(*Server)
 
@@ -9,3 +14,16 @@ func WithLogger(logger *slog.Logger) Option {
 		s.logger = logger
 	}
 }
+
+func WithConfig(registry *config.Registry) Option {
+	return func(s *Server) {
+		s.config = registry
+	}
+}
+
+// WithClock sets the clock of the expirations, the system clock by default.
+func WithClock(c clock.Clock) Option {
+	return func(s *Server) {
+		s.clock = c
+	}
+}
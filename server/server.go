@@ -1,55 +1,183 @@
 package server
 
 import (
+	"errors"
+	"io"
 	"log/slog"
 	"net"
+	"net/http"
+	"sync"
+	"time"
 
+	"github.com/PlayerNeo42/gvalkey/clock"
 	"github.com/PlayerNeo42/gvalkey/handler"
+	"github.com/PlayerNeo42/gvalkey/internal/config"
+	"github.com/PlayerNeo42/gvalkey/internal/stats"
 	"github.com/PlayerNeo42/gvalkey/store"
 	"github.com/PlayerNeo42/gvalkey/store/naive"
 )
 
+// ErrServerClosed is returned by Serve and ListenAndServe once Close has been called.
+var ErrServerClosed = errors.New("server closed")
+
 type Server struct {
 	addr    string
 	logger  *slog.Logger
-	storage store.Store
+	dbs     []store.Store
+	stats   *stats.Stats
+	config  *config.Registry
+	clock   clock.Clock
 	handler *handler.Handler
+
+	// listeners and connections being served, closed by Close
+	mu      sync.Mutex
+	closed  bool
+	open    map[io.Closer]struct{}
+	serving sync.WaitGroup
 }
 
 func NewServer(addr string, opts ...Option) *Server {
-	// storage := eventloop.NewEventloopStore()
-	storage := naive.NewNaiveStore()
-
 	s := &Server{
-		addr:    addr,
-		storage: storage,
-		logger:  slog.New(slog.DiscardHandler),
+		addr:   addr,
+		stats:  stats.New(),
+		config: config.NewRegistry(config.Default(), ""),
+		logger: slog.New(slog.DiscardHandler),
+		clock:  clock.Real,
+		open:   make(map[io.Closer]struct{}),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
 
-	s.handler = handler.New(s.logger, s.storage)
+	s.dbs = make([]store.Store, s.config.Config().Databases)
+	for i := range s.dbs {
+		var db store.Store
+		// expirations are reported to the handler along with the store, whose number may change with SWAPDB
+		onExpire := store.WithOnExpire(func(key string) { s.handler.KeyExpired(db, key) })
+		onExpireFields := store.WithOnExpireFields(func(key string, deleted bool) { s.handler.FieldsExpired(db, key, deleted) })
+		withClock := store.WithClock(s.clock)
+		// db = eventloop.NewEventloopStore(onExpire, onExpireFields, withClock)
+		db = naive.NewNaiveStore(onExpire, onExpireFields, withClock)
+		s.dbs[i] = db
+	}
+
+	s.handler = handler.New(s.logger, s.dbs, handler.WithStats(s.stats), handler.WithConfig(s.config), handler.WithClock(s.clock))
 
 	return s
 }
 
 func (s *Server) ListenAndServe() error {
+	if addr := s.config.Config().MetricsAddr; addr != "" {
+		go s.serveMetrics(addr)
+	}
+
 	s.logger.Info("server started", "addr", s.addr)
 	listener, err := net.Listen("tcp", s.addr)
 	if err != nil {
 		return err
 	}
+	return s.Serve(listener)
+}
+
+// Serve serves the connections accepted on listener until Close is called, and then returns ErrServerClosed.
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.track(listener) {
+		_ = listener.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(listener)
 	defer listener.Close()
 
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
 			s.logger.Error("accept connection failed", "error", err)
 			continue
 		}
+		if !s.track(conn) {
+			_ = conn.Close()
+			return ErrServerClosed
+		}
 
 		s.logger.Info("new connection", "remote_addr", conn.RemoteAddr().String())
-		go s.handler.Serve(conn)
+		go func() {
+			defer s.untrack(conn)
+			s.handler.Serve(conn)
+		}()
+	}
+}
+
+// track registers c to be closed and waited for by Close, unless the server is already closed.
+func (s *Server) track(c io.Closer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.open[c] = struct{}{}
+	s.serving.Add(1)
+	return true
+}
+
+// untrack is called once c is no longer served.
+func (s *Server) untrack(c io.Closer) {
+	s.mu.Lock()
+	delete(s.open, c)
+	s.mu.Unlock()
+	s.serving.Done()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// Close stops the listeners, closes the connections and waits for them to be released, then stops replicating a master
+// and the background expiration of the databases. it returns once every call to Serve has returned.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	var errs []error
+	for c := range s.open {
+		if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	s.mu.Unlock()
+
+	s.serving.Wait()
+	s.handler.Close()
+	for _, db := range s.handler.Databases() {
+		if c, ok := db.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DB returns the database numbered index, which tests may read and write directly.
+func (s *Server) DB(index int) store.Store {
+	return s.handler.DB(index)
+}
+
+// serveMetrics serves the metrics of the handler over HTTP, a failure leaving the server running without them.
+func (s *Server) serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.handler.MetricsHandler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	s.logger.Info("metrics server started", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil {
+		s.logger.Error("metrics server failed", "addr", addr, "error", err)
 	}
 }
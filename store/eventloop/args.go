@@ -1,9 +1,17 @@
 package eventloop
 
+import "github.com/PlayerNeo42/gvalkey/store"
+
 const (
 	CmdGet = iota
 	CmdSet
 	CmdDel
+	CmdCompute
+	CmdFlush
+	CmdRandomKey
+	CmdStats
+	CmdRange
+	CmdExpireStats
 )
 
 type cmd struct {
@@ -12,6 +20,11 @@ type cmd struct {
 	resp    any
 }
 
+type computeArgs struct {
+	key string
+	fn  store.ComputeFunc
+}
+
 type operationResult struct {
 	Value any
 	OK    bool
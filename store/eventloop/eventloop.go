@@ -11,18 +11,27 @@ import (
 
 var _ store.Store = (*EventloopStore)(nil)
 
+// expireBatch is the number of keys expired between two checks of the time budget of an expiration cycle.
+const expireBatch = 64
+
 type EventloopStore struct {
-	m          map[string]any
-	expiration map[string]time.Time
+	m        map[string]any
+	expiries *store.ExpiryIndex
+	// keys whose value is a store.FieldExpirer, checked by the background expiration
+	fieldExpirers map[string]struct{}
+	options       store.Options
+	expireStats   store.ExpireStats
 
 	cmdCh chan cmd
 }
 
-func NewEventloopStore() *EventloopStore {
+func NewEventloopStore(opts ...store.Option) *EventloopStore {
 	s := &EventloopStore{
-		m:          make(map[string]any),
-		expiration: make(map[string]time.Time),
-		cmdCh:      make(chan cmd, 1),
+		m:             make(map[string]any),
+		expiries:      store.NewExpiryIndex(),
+		fieldExpirers: make(map[string]struct{}),
+		options:       store.NewOptions(opts...),
+		cmdCh:         make(chan cmd, 1),
 	}
 
 	go s.Run(context.Background())
@@ -43,23 +52,53 @@ func (s *EventloopStore) Set(args resp.SetArgs) (any, bool) {
 	return result.Value, result.OK
 }
 
+func (s *EventloopStore) Compute(key string, fn store.ComputeFunc) {
+	executeCommand[struct{}](s, CmdCompute, computeArgs{key: key, fn: fn})
+}
+
+func (s *EventloopStore) Flush() {
+	executeCommand[struct{}](s, CmdFlush, nil)
+}
+
+func (s *EventloopStore) RandomKey(volatile bool) (string, bool) {
+	result := executeCommand[operationResult](s, CmdRandomKey, volatile)
+	key, ok := result.Value.(string)
+	return key, ok && result.OK
+}
+
+func (s *EventloopStore) Stats() store.Stats {
+	return executeCommand[store.Stats](s, CmdStats, nil)
+}
+
+func (s *EventloopStore) Range(fn func(key string, entry store.Entry) bool) {
+	executeCommand[struct{}](s, CmdRange, fn)
+}
+
+// ExpireStats returns the counters of the background expiration.
+func (s *EventloopStore) ExpireStats() store.ExpireStats {
+	return executeCommand[store.ExpireStats](s, CmdExpireStats, nil)
+}
+
 // Close closes the event loop and stops the cleanup goroutine.
 func (s *EventloopStore) Close() {
 	close(s.cmdCh)
 }
 
-// Run starts the event loop and cleans up expired keys every second.
+// Run starts the event loop, which also reclaims the expired keys every store.ExpireCycleInterval and the expired fields every second.
 func (s *EventloopStore) Run(ctx context.Context) {
-	// clean up expired keys every second
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+	keys := time.NewTicker(store.ExpireCycleInterval)
+	defer keys.Stop()
+	fields := time.NewTicker(time.Second)
+	defer fields.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
+		case <-keys.C:
 			s.expireKeys()
+		case <-fields.C:
+			s.expireFields()
 		case cmd := <-s.cmdCh:
 			// handle commands, all data operations are executed in this single goroutine
 			s.handleCommand(cmd)
@@ -89,13 +128,53 @@ func (s *EventloopStore) handleCommand(cmd cmd) {
 				respCh <- s.handleDel(key)
 			}
 		}
+
+	case CmdCompute:
+		if respCh, ok := cmd.resp.(chan struct{}); ok {
+			if args, ok := cmd.payload.(computeArgs); ok {
+				s.handleCompute(args)
+				respCh <- struct{}{}
+			}
+		}
+
+	case CmdFlush:
+		if respCh, ok := cmd.resp.(chan struct{}); ok {
+			s.m = make(map[string]any)
+			s.expiries = store.NewExpiryIndex()
+			s.fieldExpirers = make(map[string]struct{})
+			respCh <- struct{}{}
+		}
+
+	case CmdRandomKey:
+		if respCh, ok := cmd.resp.(chan operationResult); ok {
+			if volatile, ok := cmd.payload.(bool); ok {
+				respCh <- s.handleRandomKey(volatile)
+			}
+		}
+
+	case CmdStats:
+		if respCh, ok := cmd.resp.(chan store.Stats); ok {
+			respCh <- store.Stats{Keys: len(s.m), Expires: s.expiries.Len()}
+		}
+
+	case CmdExpireStats:
+		if respCh, ok := cmd.resp.(chan store.ExpireStats); ok {
+			respCh <- s.expireStats
+		}
+
+	case CmdRange:
+		if respCh, ok := cmd.resp.(chan struct{}); ok {
+			if fn, ok := cmd.payload.(func(string, store.Entry) bool); ok {
+				s.handleRange(fn)
+				respCh <- struct{}{}
+			}
+		}
 	}
 }
 
 func (s *EventloopStore) handleGet(key string) operationResult {
 	if s.isExpired(key) {
-		delete(s.m, key)
-		delete(s.expiration, key)
+		s.expire(key)
 		return operationResult{Value: nil, OK: false}
 	}
 
@@ -110,6 +189,7 @@ func (s *EventloopStore) handleSet(args resp.SetArgs) operationResult {
 
 	// treat expired keys as not existing for the purpose of nx/xx logic.
 	if exists && s.isExpired(key) {
+		s.expire(key)
 		exists = false
 	}
 
@@ -122,15 +202,9 @@ func (s *EventloopStore) handleSet(args resp.SetArgs) operationResult {
 	}
 
 	// set value
-	s.m[key] = args.Value
+	s.put(key, args.Value)
 
-	// set expiration time
-	if !args.ExpireAt.IsZero() {
-		s.expiration[key] = args.ExpireAt
-	} else {
-		// remove expiration time if previously set
-		delete(s.expiration, key)
-	}
+	s.setExpireAt(key, args.ExpireAt)
 
 	// return result
 	if args.Get && exists {
@@ -141,28 +215,154 @@ func (s *EventloopStore) handleSet(args resp.SetArgs) operationResult {
 }
 
 func (s *EventloopStore) handleDel(key string) bool {
+	if s.isExpired(key) {
+		s.expire(key)
+		return false
+	}
+
 	_, exists := s.m[key]
 	if exists {
-		delete(s.m, key)
-		delete(s.expiration, key)
+		s.remove(key)
 	}
 
 	return exists
 }
 
+func (s *EventloopStore) handleCompute(args computeArgs) {
+	key := args.key
+
+	if s.isExpired(key) {
+		s.expire(key)
+	}
+
+	value, exists := s.m[key]
+	entry := store.Entry{Value: value, ExpireAt: s.expireAt(key)}
+
+	newEntry, op := args.fn(entry, exists)
+	switch op {
+	case store.OpSet:
+		s.put(key, newEntry.Value)
+		s.setExpireAt(key, newEntry.ExpireAt)
+	case store.OpDelete:
+		s.remove(key)
+	case store.OpKeep:
+	}
+}
+
+func (s *EventloopStore) handleRandomKey(volatile bool) operationResult {
+	// map iteration order is randomized, so the first live key is random enough.
+	if volatile {
+		for key := range s.expiries.All() {
+			if !s.isExpired(key) {
+				return operationResult{Value: key, OK: true}
+			}
+		}
+		return operationResult{}
+	}
+
+	for key := range s.m {
+		if !s.isExpired(key) {
+			return operationResult{Value: key, OK: true}
+		}
+	}
+	return operationResult{}
+}
+
+func (s *EventloopStore) handleRange(fn func(string, store.Entry) bool) {
+	for key, value := range s.m {
+		if s.isExpired(key) {
+			continue
+		}
+		if !fn(key, store.Entry{Value: value, ExpireAt: s.expireAt(key)}) {
+			return
+		}
+	}
+}
+
+// expireKeys reclaims the keys that expired, in the order of their expiration and within store.ExpireCycleBudget.
 func (s *EventloopStore) expireKeys() {
-	now := time.Now()
-	for key, expireAt := range s.expiration {
-		if now.After(expireAt) {
-			delete(s.m, key)
-			delete(s.expiration, key)
+	// the budget is in real time, while expirations follow the clock of the store
+	start, now := time.Now(), s.options.Clock.Now()
+	defer func() { s.expireStats.CycleTime += time.Since(start) }()
+
+	for n := 1; ; n++ {
+		key, ok := s.expiries.PopExpired(now)
+		if !ok {
+			return
+		}
+		s.expire(key)
+		s.expireStats.Expired++
+
+		// the clock is only read every few keys, reading it costs about as much as expiring one
+		if n%expireBatch == 0 && time.Since(start) > store.ExpireCycleBudget {
+			s.expireStats.TimeCapReached++
+			return
 		}
 	}
 }
 
+// expireFields reclaims the expired fields of the values whose fields expire.
+func (s *EventloopStore) expireFields() {
+	start, now := time.Now(), s.options.Clock.Now()
+	defer func() { s.expireStats.CycleTime += time.Since(start) }()
+
+	for key := range s.fieldExpirers {
+		fe, ok := s.m[key].(store.FieldExpirer)
+		if !ok {
+			continue
+		}
+		expired, empty := fe.ExpireFields(now)
+		if expired == 0 {
+			continue
+		}
+		if empty {
+			s.remove(key)
+		}
+		s.options.OnExpireFields(key, empty)
+	}
+}
+
+// put sets the value of key, keeping track of the values whose fields expire.
+func (s *EventloopStore) put(key string, value any) {
+	s.m[key] = value
+	if _, ok := value.(store.FieldExpirer); ok {
+		s.fieldExpirers[key] = struct{}{}
+	} else {
+		delete(s.fieldExpirers, key)
+	}
+}
+
+// remove deletes key along with its expiration.
+func (s *EventloopStore) remove(key string) {
+	delete(s.m, key)
+	s.expiries.Remove(key)
+	delete(s.fieldExpirers, key)
+}
+
+// expireAt returns the expiration of key, zero when it has none.
+func (s *EventloopStore) expireAt(key string) time.Time {
+	at, _ := s.expiries.Get(key)
+	return at
+}
+
+// setExpireAt sets the expiration of key, a zero time removing it.
+func (s *EventloopStore) setExpireAt(key string, at time.Time) {
+	if at.IsZero() {
+		s.expiries.Remove(key)
+	} else {
+		s.expiries.Set(key, at)
+	}
+}
+
+// expire removes an expired key and reports it.
+func (s *EventloopStore) expire(key string) {
+	s.remove(key)
+	s.options.OnExpire(key)
+}
+
 func (s *EventloopStore) isExpired(key string) bool {
-	expireTime, exists := s.expiration[key]
-	return exists && time.Now().After(expireTime)
+	expireTime, exists := s.expiries.Get(key)
+	return exists && s.options.Clock.Now().After(expireTime)
 }
 
 // executeCommand executes a command and waits for the result
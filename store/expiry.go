@@ -0,0 +1,123 @@
+package store
+
+import (
+	"container/heap"
+	"iter"
+	"time"
+)
+
+const (
+	// ExpireCycleInterval is how often stores reclaim expired keys in the background.
+	ExpireCycleInterval = 100 * time.Millisecond
+	// ExpireCycleBudget bounds the time of a cycle, the keys left are reclaimed by the next ones,
+	// so that a mass expiration does not stall the store.
+	ExpireCycleBudget = 25 * time.Millisecond
+)
+
+// ExpireStats describes the work of the background expiration of a store.
+type ExpireStats struct {
+	// keys reclaimed by the background expiration
+	Expired int64
+	// cycles that reached ExpireCycleBudget with expired keys left
+	TimeCapReached int64
+	// time spent in expiration cycles
+	CycleTime time.Duration
+}
+
+// ExpiryIndex orders keys by expiration, so that the background expiration only visits the keys that expired.
+// it is not safe for concurrent use.
+type ExpiryIndex struct {
+	heap  expiryHeap
+	items map[string]*expiryItem
+}
+
+func NewExpiryIndex() *ExpiryIndex {
+	return &ExpiryIndex{items: make(map[string]*expiryItem)}
+}
+
+// Set records the expiration of key, replacing the previous one.
+func (x *ExpiryIndex) Set(key string, at time.Time) {
+	if item, ok := x.items[key]; ok {
+		item.at = at
+		heap.Fix(&x.heap, item.index)
+		return
+	}
+	item := &expiryItem{key: key, at: at}
+	x.items[key] = item
+	heap.Push(&x.heap, item)
+}
+
+// Get returns the expiration of key.
+func (x *ExpiryIndex) Get(key string) (time.Time, bool) {
+	item, ok := x.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return item.at, true
+}
+
+// Remove forgets the expiration of key.
+func (x *ExpiryIndex) Remove(key string) {
+	if item, ok := x.items[key]; ok {
+		heap.Remove(&x.heap, item.index)
+		delete(x.items, key)
+	}
+}
+
+// PopExpired removes and returns the key expiring first, if it expired before now.
+func (x *ExpiryIndex) PopExpired(now time.Time) (string, bool) {
+	if len(x.heap) == 0 || !now.After(x.heap[0].at) {
+		return "", false
+	}
+	item, _ := heap.Pop(&x.heap).(*expiryItem)
+	delete(x.items, item.key)
+	return item.key, true
+}
+
+func (x *ExpiryIndex) Len() int {
+	return len(x.heap)
+}
+
+// All iterates over the keys and their expiration, in the randomized order of Go maps.
+func (x *ExpiryIndex) All() iter.Seq2[string, time.Time] {
+	return func(yield func(string, time.Time) bool) {
+		for key, item := range x.items {
+			if !yield(key, item.at) {
+				return
+			}
+		}
+	}
+}
+
+type expiryItem struct {
+	key string
+	at  time.Time
+	// position in the heap
+	index int
+}
+
+// expiryHeap is a min-heap of expirations implementing heap.Interface.
+type expiryHeap []*expiryItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	item, _ := x.(*expiryItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryIndex(t *testing.T) {
+	now := time.Now()
+	x := NewExpiryIndex()
+	x.Set("c", now.Add(-1*time.Second))
+	x.Set("a", now.Add(-3*time.Second))
+	x.Set("b", now.Add(-2*time.Second))
+	x.Set("later", now.Add(time.Hour))
+	require.Equal(t, 4, x.Len())
+
+	// extending the expiration of a key moves it
+	x.Set("c", now.Add(2*time.Hour))
+	x.Remove("b")
+	x.Remove("missing")
+	at, ok := x.Get("c")
+	require.True(t, ok)
+	require.True(t, now.Add(2*time.Hour).Equal(at))
+
+	key, ok := x.PopExpired(now)
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+	_, ok = x.PopExpired(now)
+	require.False(t, ok, "the other keys did not expire")
+	_, ok = x.Get("a")
+	require.False(t, ok)
+
+	keys := map[string]bool{}
+	for key := range x.All() {
+		keys[key] = true
+	}
+	require.Equal(t, map[string]bool{"c": true, "later": true}, keys)
+
+	key, ok = x.PopExpired(now.Add(3 * time.Hour))
+	require.True(t, ok)
+	require.Equal(t, "later", key)
+}
@@ -2,7 +2,9 @@
 package naive
 
 import (
+	"hash/maphash"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/PlayerNeo42/gvalkey/resp"
@@ -16,22 +18,53 @@ type naiveStoreItem struct {
 	expiration time.Time // expiration timestamp, 0 means never expire
 }
 
-func (item *naiveStoreItem) isExpired() bool {
+func (item *naiveStoreItem) isExpired(now time.Time) bool {
 	if item.expiration.IsZero() {
 		return false
 	}
-	return time.Now().After(item.expiration)
+	return now.After(item.expiration)
 }
 
+const (
+	// lockStripes is the number of mutexes writers are spread over.
+	lockStripes = 256
+	// expireBatch is the number of keys expired between two checks of the time budget of an expiration cycle.
+	expireBatch = 64
+)
+
 // NaiveStore is a thread-safe in-memory key-value store implementation using Go's sync.Map.
+//
+// reads are lock-free, while writes to the same key are serialized by a striped mutex
+// so that read-modify-write operations such as conditional sets and Compute are atomic.
 type NaiveStore struct {
 	store       sync.Map
 	stopCleanup chan struct{} // channel for stopping the cleanup goroutine
+	options     store.Options
+
+	locks    [lockStripes]sync.Mutex
+	lockSeed maphash.Seed
+
+	// sync.Map has no length, so the keyspace size is tracked alongside it
+	keys    atomic.Int64
+	expires atomic.Int64
+
+	// expirations of the volatile keys, expiryMu is taken after the key lock
+	expiryMu sync.Mutex
+	expiries *store.ExpiryIndex
+	// items whose value is a store.FieldExpirer, by key
+	fieldExpirers sync.Map
+
+	expiredKeys    atomic.Int64
+	timeCapReached atomic.Int64
+	cycleTime      atomic.Int64
 }
 
-func NewNaiveStore() *NaiveStore {
+func NewNaiveStore(opts ...store.Option) *NaiveStore {
 	ms := &NaiveStore{
 		stopCleanup: make(chan struct{}),
+		options:     store.NewOptions(opts...),
+		lockSeed:    maphash.MakeSeed(),
+		expiries:    store.NewExpiryIndex(),
 	}
 
 	go ms.cleanupExpiredKeys()
@@ -42,6 +75,10 @@ func NewNaiveStore() *NaiveStore {
 func (s *NaiveStore) Set(args resp.SetArgs) (any, bool) {
 	key := args.Key.String()
 
+	mu := s.lockOf(key)
+	mu.Lock()
+	defer mu.Unlock()
+
 	// load the existing item first to check its status.
 	existing, exists := s.store.Load(key)
 
@@ -52,7 +89,7 @@ func (s *NaiveStore) Set(args resp.SetArgs) (any, bool) {
 		if !ok {
 			// this should not happen in normal operation, but as a safeguard, treat it as not exists.
 			exists = false
-		} else if oldItem.isExpired() {
+		} else if oldItem.isExpired(s.now()) {
 			// treat expired keys as not existing for the purpose of nx/xx logic.
 			exists = false
 		}
@@ -73,11 +110,10 @@ func (s *NaiveStore) Set(args resp.SetArgs) (any, bool) {
 		return nil, false
 	}
 
-	newItem := &naiveStoreItem{
+	s.replace(key, &naiveStoreItem{
 		value:      args.Value,
 		expiration: args.ExpireAt,
-	}
-	s.store.Store(key, newItem)
+	})
 
 	if args.Get && exists {
 		// 'exists' is true only if the key existed and was not expired.
@@ -100,8 +136,8 @@ func (s *NaiveStore) Get(key string) (any, bool) {
 	}
 
 	// check if expired
-	if item.isExpired() {
-		s.store.Delete(key)
+	if item.isExpired(s.now()) {
+		s.expire(key, item)
 		return nil, false
 	}
 
@@ -109,6 +145,75 @@ func (s *NaiveStore) Get(key string) (any, bool) {
 }
 
 func (s *NaiveStore) Del(key string) bool {
+	mu := s.lockOf(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	return s.delete(key)
+}
+
+func (s *NaiveStore) Compute(key string, fn store.ComputeFunc) {
+	mu := s.lockOf(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	var entry store.Entry
+	exists := false
+	if value, ok := s.store.Load(key); ok {
+		if item, ok := value.(*naiveStoreItem); ok {
+			if item.isExpired(s.now()) {
+				s.expire(key, item)
+			} else {
+				entry = store.Entry{Value: item.value, ExpireAt: item.expiration}
+				exists = true
+			}
+		}
+	}
+
+	newEntry, op := fn(entry, exists)
+	switch op {
+	case store.OpSet:
+		s.replace(key, &naiveStoreItem{value: newEntry.Value, expiration: newEntry.ExpireAt})
+	case store.OpDelete:
+		if exists {
+			s.delete(key)
+		}
+	case store.OpKeep:
+	}
+}
+
+func (s *NaiveStore) Flush() {
+	s.store.Range(func(key, _ any) bool {
+		k, ok := key.(string)
+		if !ok {
+			return true
+		}
+		mu := s.lockOf(k)
+		mu.Lock()
+		s.delete(k)
+		mu.Unlock()
+		return true
+	})
+}
+
+// replace stores item under key and updates the keyspace counters, the key lock must be held.
+func (s *NaiveStore) replace(key string, item *naiveStoreItem) {
+	previous, replaced := s.store.Swap(key, item)
+	s.track(item, 1)
+	if replaced {
+		if prevItem, ok := previous.(*naiveStoreItem); ok {
+			s.track(prevItem, -1)
+			s.unindex(key, prevItem)
+			if prevItem.isExpired(s.now()) {
+				s.options.OnExpire(key)
+			}
+		}
+	}
+	s.index(key, item)
+}
+
+// delete removes key and reports whether it existed, the key lock must be held.
+func (s *NaiveStore) delete(key string) bool {
 	existing, existed := s.store.LoadAndDelete(key)
 	if !existed {
 		return false
@@ -120,30 +225,214 @@ func (s *NaiveStore) Del(key string) bool {
 		// this is unexpected, but it was deleted, so we return true.
 		return true
 	}
+	s.track(item, -1)
+	s.unindex(key, item)
 
 	// return false if the key was expired (logically didn't exist), true otherwise.
-	return !item.isExpired()
+	if item.isExpired(s.now()) {
+		s.options.OnExpire(key)
+		return false
+	}
+	return true
+}
+
+func (s *NaiveStore) RandomKey(volatile bool) (string, bool) {
+	var found string
+	var ok bool
+	// sync.Map iterates in the randomized order of Go maps, so the first match is random enough.
+	s.store.Range(func(key, value any) bool {
+		item, isItem := value.(*naiveStoreItem)
+		if !isItem || item.isExpired(s.now()) || (volatile && item.expiration.IsZero()) {
+			return true
+		}
+		found, ok = key.(string)
+		return !ok
+	})
+	return found, ok
+}
+
+func (s *NaiveStore) Range(fn func(key string, entry store.Entry) bool) {
+	s.store.Range(func(key, _ any) bool {
+		k, ok := key.(string)
+		if !ok {
+			return true
+		}
+		mu := s.lockOf(k)
+		mu.Lock()
+		defer mu.Unlock()
+
+		// the key may have changed since the iteration reached it
+		value, ok := s.store.Load(k)
+		if !ok {
+			return true
+		}
+		item, ok := value.(*naiveStoreItem)
+		if !ok || item.isExpired(s.now()) {
+			return true
+		}
+		return fn(k, store.Entry{Value: item.value, ExpireAt: item.expiration})
+	})
+}
+
+func (s *NaiveStore) Stats() store.Stats {
+	return store.Stats{
+		Keys:    int(s.keys.Load()),
+		Expires: int(s.expires.Load()),
+	}
+}
+
+// expire removes an expired item, unless it has been replaced concurrently.
+func (s *NaiveStore) expire(key string, item *naiveStoreItem) {
+	if s.store.CompareAndDelete(key, item) {
+		s.track(item, -1)
+		s.unindex(key, item)
+		s.options.OnExpire(key)
+	}
+}
+
+// expireFields reclaims the expired fields of the value of item, unless it has been replaced concurrently.
+func (s *NaiveStore) expireFields(key string, item *naiveStoreItem) {
+	mu := s.lockOf(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if current, ok := s.store.Load(key); !ok || current != item {
+		return
+	}
+	fe, ok := item.value.(store.FieldExpirer)
+	if !ok {
+		return
+	}
+	expired, empty := fe.ExpireFields(s.now())
+	if expired == 0 {
+		return
+	}
+	if empty {
+		s.delete(key)
+	}
+	s.options.OnExpireFields(key, empty)
+}
+
+func (s *NaiveStore) now() time.Time {
+	return s.options.Clock.Now()
+}
+
+// lockOf returns the mutex serializing writes to key.
+func (s *NaiveStore) lockOf(key string) *sync.Mutex {
+	return &s.locks[maphash.String(s.lockSeed, key)%lockStripes]
+}
+
+// track adjusts the keyspace counters by delta for the given item.
+func (s *NaiveStore) track(item *naiveStoreItem, delta int64) {
+	s.keys.Add(delta)
+	if !item.expiration.IsZero() {
+		s.expires.Add(delta)
+	}
+}
+
+// index records the expiration of item and whether its fields expire, the key lock must be held.
+func (s *NaiveStore) index(key string, item *naiveStoreItem) {
+	if _, ok := item.value.(store.FieldExpirer); ok {
+		s.fieldExpirers.Store(key, item)
+	}
+	if item.expiration.IsZero() {
+		return
+	}
+	s.expiryMu.Lock()
+	defer s.expiryMu.Unlock()
+	s.expiries.Set(key, item.expiration)
+}
+
+// unindex forgets item, which was removed from key, unless another item was stored under key since.
+func (s *NaiveStore) unindex(key string, item *naiveStoreItem) {
+	s.fieldExpirers.CompareAndDelete(key, item)
+	if item.expiration.IsZero() {
+		return
+	}
+	s.expiryMu.Lock()
+	defer s.expiryMu.Unlock()
+	if at, ok := s.expiries.Get(key); ok && at.Equal(item.expiration) {
+		s.expiries.Remove(key)
+	}
+}
+
+// ExpireStats returns the counters of the background expiration.
+func (s *NaiveStore) ExpireStats() store.ExpireStats {
+	return store.ExpireStats{
+		Expired:        s.expiredKeys.Load(),
+		TimeCapReached: s.timeCapReached.Load(),
+		CycleTime:      time.Duration(s.cycleTime.Load()),
+	}
 }
 
 func (s *NaiveStore) cleanupExpiredKeys() {
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
+	keys := time.NewTicker(store.ExpireCycleInterval)
+	defer keys.Stop()
+	fields := time.NewTicker(time.Second)
+	defer fields.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			s.store.Range(func(key, value any) bool {
-				if item, ok := value.(*naiveStoreItem); ok && item.isExpired() {
-					s.store.Delete(key)
+		case <-keys.C:
+			s.expireKeys()
+		case <-fields.C:
+			start := time.Now()
+			s.fieldExpirers.Range(func(key, value any) bool {
+				k, ok := key.(string)
+				if !ok {
+					return true
+				}
+				if item, ok := value.(*naiveStoreItem); ok {
+					s.expireFields(k, item)
 				}
 				return true
 			})
+			s.cycleTime.Add(int64(time.Since(start)))
 		case <-s.stopCleanup:
 			return
 		}
 	}
 }
 
+// expireKeys reclaims the keys that expired, in the order of their expiration and within store.ExpireCycleBudget.
+func (s *NaiveStore) expireKeys() {
+	// the budget is in real time, while expirations follow the clock of the store
+	start, now := time.Now(), s.now()
+	defer func() { s.cycleTime.Add(int64(time.Since(start))) }()
+
+	for n := 1; ; n++ {
+		s.expiryMu.Lock()
+		key, ok := s.expiries.PopExpired(now)
+		s.expiryMu.Unlock()
+		if !ok {
+			return
+		}
+		s.expireKey(key)
+
+		// the clock is only read every few keys, reading it costs about as much as expiring one
+		if n%expireBatch == 0 && time.Since(start) > store.ExpireCycleBudget {
+			s.timeCapReached.Add(1)
+			return
+		}
+	}
+}
+
+// expireKey removes key, popped from the expiration index, if it is still expired.
+func (s *NaiveStore) expireKey(key string) {
+	mu := s.lockOf(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	value, ok := s.store.Load(key)
+	if !ok {
+		return
+	}
+	if item, ok := value.(*naiveStoreItem); ok && item.isExpired(s.now()) {
+		s.expire(key, item)
+		s.expiredKeys.Add(1)
+	}
+}
+
 // Close stops the cleanup goroutine
 func (s *NaiveStore) Close() {
 	close(s.stopCleanup)
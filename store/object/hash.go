@@ -0,0 +1,110 @@
+package object
+
+import (
+	"time"
+)
+
+// Hash is a map of fields to values, each field possibly having its own expiration.
+type Hash struct {
+	fields  map[string]string
+	expires map[string]time.Time
+	// earliest expiration of expires, it may be earlier once fields are persisted or deleted
+	nextExpire time.Time
+}
+
+func NewHash() *Hash {
+	return &Hash{
+		fields:  make(map[string]string),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// Len returns the number of fields, including expired fields that have not been reclaimed by ExpireFields yet.
+func (h *Hash) Len() int {
+	return len(h.fields)
+}
+
+// Get returns the value of field.
+func (h *Hash) Get(field string) (string, bool) {
+	value, ok := h.fields[field]
+	return value, ok
+}
+
+// Set sets the value of field, removing its expiration, and reports whether the field is new.
+func (h *Hash) Set(field, value string) bool {
+	_, exists := h.fields[field]
+	h.fields[field] = value
+	delete(h.expires, field)
+	return !exists
+}
+
+// Delete removes field and reports whether it existed.
+func (h *Hash) Delete(field string) bool {
+	if _, ok := h.fields[field]; !ok {
+		return false
+	}
+	delete(h.fields, field)
+	delete(h.expires, field)
+	return true
+}
+
+// Range calls fn on every field until it returns false.
+func (h *Hash) Range(fn func(field, value string) bool) {
+	for field, value := range h.fields {
+		if !fn(field, value) {
+			return
+		}
+	}
+}
+
+// ExpireAt returns the expiration of field, which is zero when the field does not expire.
+// ok is false when the field does not exist.
+func (h *Hash) ExpireAt(field string) (at time.Time, ok bool) {
+	if _, ok := h.fields[field]; !ok {
+		return time.Time{}, false
+	}
+	return h.expires[field], true
+}
+
+// SetExpireAt sets the expiration of an existing field.
+func (h *Hash) SetExpireAt(field string, at time.Time) {
+	if _, ok := h.fields[field]; !ok {
+		return
+	}
+	h.expires[field] = at
+	if len(h.expires) == 1 || at.Before(h.nextExpire) {
+		h.nextExpire = at
+	}
+}
+
+// Persist removes the expiration of field and reports whether it had one.
+func (h *Hash) Persist(field string) bool {
+	if _, ok := h.expires[field]; !ok {
+		return false
+	}
+	delete(h.expires, field)
+	return true
+}
+
+// ExpireFields removes the fields whose expiration is before now.
+// it returns the number of removed fields and whether the hash is left empty.
+func (h *Hash) ExpireFields(now time.Time) (expired int, empty bool) {
+	if len(h.expires) == 0 || now.Before(h.nextExpire) {
+		return 0, len(h.fields) == 0
+	}
+
+	var next time.Time
+	for field, at := range h.expires {
+		if now.After(at) {
+			delete(h.fields, field)
+			delete(h.expires, field)
+			expired++
+			continue
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	h.nextExpire = next
+	return expired, len(h.fields) == 0
+}
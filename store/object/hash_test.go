@@ -0,0 +1,79 @@
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashSetGetDelete(t *testing.T) {
+	h := NewHash()
+	require.True(t, h.Set("a", "1"))
+	require.False(t, h.Set("a", "2"))
+	require.True(t, h.Set("b", "3"))
+	require.Equal(t, 2, h.Len())
+
+	value, ok := h.Get("a")
+	require.True(t, ok)
+	require.Equal(t, "2", value)
+
+	require.True(t, h.Delete("a"))
+	require.False(t, h.Delete("a"))
+	_, ok = h.Get("a")
+	require.False(t, ok)
+
+	fields := map[string]string{}
+	h.Range(func(field, value string) bool {
+		fields[field] = value
+		return true
+	})
+	require.Equal(t, map[string]string{"b": "3"}, fields)
+}
+
+func TestHashFieldExpiration(t *testing.T) {
+	now := time.Now()
+	h := NewHash()
+	h.Set("a", "1")
+	h.Set("b", "2")
+	h.Set("c", "3")
+
+	h.SetExpireAt("a", now.Add(time.Second))
+	h.SetExpireAt("b", now.Add(time.Hour))
+	h.SetExpireAt("missing", now)
+
+	at, ok := h.ExpireAt("a")
+	require.True(t, ok)
+	require.Equal(t, now.Add(time.Second), at)
+	at, ok = h.ExpireAt("c")
+	require.True(t, ok)
+	require.True(t, at.IsZero())
+	_, ok = h.ExpireAt("missing")
+	require.False(t, ok)
+
+	expired, empty := h.ExpireFields(now)
+	require.Zero(t, expired)
+	require.False(t, empty)
+
+	expired, empty = h.ExpireFields(now.Add(2 * time.Second))
+	require.Equal(t, 1, expired)
+	require.False(t, empty)
+	_, ok = h.Get("a")
+	require.False(t, ok)
+
+	// setting a field removes its expiration
+	h.Set("b", "4")
+	require.False(t, h.Persist("b"))
+	h.SetExpireAt("c", now.Add(time.Minute))
+	require.True(t, h.Persist("c"))
+
+	expired, empty = h.ExpireFields(now.Add(2 * time.Hour))
+	require.Zero(t, expired)
+	require.False(t, empty)
+
+	h.SetExpireAt("b", now)
+	h.SetExpireAt("c", now)
+	expired, empty = h.ExpireFields(now.Add(time.Millisecond))
+	require.Equal(t, 2, expired)
+	require.True(t, empty)
+}
@@ -0,0 +1,117 @@
+// Package object implements the value types stored in the keyspace besides strings.
+//
+// objects are not safe for concurrent use, they must only be accessed from a store.ComputeFunc.
+package object
+
+// List is a double-ended queue of strings backed by a ring buffer.
+type List struct {
+	buf  []string
+	head int
+	n    int
+}
+
+func NewList() *List {
+	return &List{}
+}
+
+// Len returns the number of elements.
+func (l *List) Len() int {
+	return l.n
+}
+
+// PushLeft inserts values at the head one after the other, so that the last one ends up first.
+func (l *List) PushLeft(values ...string) {
+	for _, v := range values {
+		l.grow()
+		l.head = (l.head - 1 + len(l.buf)) % len(l.buf)
+		l.buf[l.head] = v
+		l.n++
+	}
+}
+
+// PushRight appends values at the tail.
+func (l *List) PushRight(values ...string) {
+	for _, v := range values {
+		l.grow()
+		l.buf[(l.head+l.n)%len(l.buf)] = v
+		l.n++
+	}
+}
+
+// PopLeft removes and returns the first element.
+func (l *List) PopLeft() (string, bool) {
+	if l.n == 0 {
+		return "", false
+	}
+	v := l.buf[l.head]
+	l.buf[l.head] = ""
+	l.head = (l.head + 1) % len(l.buf)
+	l.n--
+	return v, true
+}
+
+// PopRight removes and returns the last element.
+func (l *List) PopRight() (string, bool) {
+	if l.n == 0 {
+		return "", false
+	}
+	i := (l.head + l.n - 1) % len(l.buf)
+	v := l.buf[i]
+	l.buf[i] = ""
+	l.n--
+	return v, true
+}
+
+// Index returns the element at index i, negative indexes count from the tail.
+func (l *List) Index(i int) (string, bool) {
+	if i < 0 {
+		i += l.n
+	}
+	if i < 0 || i >= l.n {
+		return "", false
+	}
+	return l.buf[(l.head+i)%len(l.buf)], true
+}
+
+// Range returns the elements between start and stop inclusive, with the index semantics of LRANGE.
+func (l *List) Range(start, stop int) []string {
+	start, stop, ok := normalizeRange(start, stop, l.n)
+	if !ok {
+		return []string{}
+	}
+
+	result := make([]string, 0, stop-start+1)
+	for i := start; i <= stop; i++ {
+		result = append(result, l.buf[(l.head+i)%len(l.buf)])
+	}
+	return result
+}
+
+// grow makes room for at least one more element.
+func (l *List) grow() {
+	if l.n < len(l.buf) {
+		return
+	}
+
+	buf := make([]string, max(4, 2*len(l.buf)))
+	for i := range l.n {
+		buf[i] = l.buf[(l.head+i)%len(l.buf)]
+	}
+	l.buf = buf
+	l.head = 0
+}
+
+// normalizeRange resolves negative indexes against length and clamps them, ok is false for empty ranges.
+func normalizeRange(start, stop, length int) (first, last int, ok bool) {
+	if start < 0 {
+		start = max(0, start+length)
+	}
+	if stop < 0 {
+		stop += length
+	}
+	stop = min(stop, length-1)
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
@@ -0,0 +1,74 @@
+package object
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPushPop(t *testing.T) {
+	l := NewList()
+	l.PushLeft("b", "a")
+	l.PushRight("c", "d")
+	require.Equal(t, 4, l.Len())
+	require.Equal(t, []string{"a", "b", "c", "d"}, l.Range(0, -1))
+
+	v, ok := l.PopLeft()
+	require.True(t, ok)
+	require.Equal(t, "a", v)
+
+	v, ok = l.PopRight()
+	require.True(t, ok)
+	require.Equal(t, "d", v)
+
+	require.Equal(t, []string{"b", "c"}, l.Range(0, -1))
+
+	l.PopLeft()
+	l.PopLeft()
+	_, ok = l.PopLeft()
+	require.False(t, ok)
+	_, ok = l.PopRight()
+	require.False(t, ok)
+}
+
+func TestListGrowsAcrossWrap(t *testing.T) {
+	l := NewList()
+	var expected []string
+	for i := range 100 {
+		v := strconv.Itoa(i)
+		if i%2 == 0 {
+			l.PushLeft(v)
+			expected = append([]string{v}, expected...)
+		} else {
+			l.PushRight(v)
+			expected = append(expected, v)
+		}
+	}
+	require.Equal(t, expected, l.Range(0, -1))
+
+	v, ok := l.Index(-1)
+	require.True(t, ok)
+	require.Equal(t, "99", v)
+}
+
+func TestListRange(t *testing.T) {
+	l := NewList()
+	l.PushRight("a", "b", "c", "d", "e")
+
+	testCases := []struct {
+		start, stop int
+		expected    []string
+	}{
+		{0, 0, []string{"a"}},
+		{-2, -1, []string{"d", "e"}},
+		{-100, 1, []string{"a", "b"}},
+		{3, 100, []string{"d", "e"}},
+		{4, 2, []string{}},
+		{5, 10, []string{}},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, l.Range(tc.start, tc.stop), "LRANGE %d %d", tc.start, tc.stop)
+	}
+}
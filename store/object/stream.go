@@ -0,0 +1,371 @@
+package object
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamBlockSize is the number of entries per storage block, like the stream-node-max-entries default of Redis.
+// approximate trimming only removes whole blocks.
+const streamBlockSize = 100
+
+// Error is an error whose message is sent to clients as is, with the capitalization Redis uses.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+const (
+	ErrInvalidStreamID  = Error("Invalid stream ID specified as stream command argument")
+	ErrStreamIDZero     = Error("The ID specified in XADD must be greater than 0-0")
+	ErrStreamIDTooSmall = Error("The ID specified in XADD is equal or smaller than the target stream top item")
+	ErrSetIDTooSmall    = Error("The ID specified in XSETID is smaller than the target stream top item")
+)
+
+// StreamID identifies a stream entry, it is made of a millisecond timestamp and a sequence number.
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+var (
+	MinStreamID = StreamID{0, 0}
+	MaxStreamID = StreamID{math.MaxUint64, math.MaxUint64}
+)
+
+// ParseStreamID parses "<ms>-<seq>" or "<ms>", in which case the sequence is missingSeq.
+func ParseStreamID(s string, missingSeq uint64) (StreamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	if !hasSeq {
+		return StreamID{ms, missingSeq}, nil
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	return StreamID{ms, seq}, nil
+}
+
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// Compare returns -1, 0 or 1 depending on whether id is before, equal to or after other.
+func (id StreamID) Compare(other StreamID) int {
+	switch {
+	case id.Ms < other.Ms:
+		return -1
+	case id.Ms > other.Ms:
+		return 1
+	case id.Seq < other.Seq:
+		return -1
+	case id.Seq > other.Seq:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Next returns the smallest ID after id, ok is false when id is the maximum ID.
+func (id StreamID) Next() (StreamID, bool) {
+	switch {
+	case id.Seq < math.MaxUint64:
+		return StreamID{id.Ms, id.Seq + 1}, true
+	case id.Ms < math.MaxUint64:
+		return StreamID{id.Ms + 1, 0}, true
+	default:
+		return id, false
+	}
+}
+
+// Prev returns the largest ID before id, ok is false when id is the minimum ID.
+func (id StreamID) Prev() (StreamID, bool) {
+	switch {
+	case id.Seq > 0:
+		return StreamID{id.Ms, id.Seq - 1}, true
+	case id.Ms > 0:
+		return StreamID{id.Ms - 1, math.MaxUint64}, true
+	default:
+		return id, false
+	}
+}
+
+// StreamEntry is an entry of a stream, Fields holds field/value pairs.
+type StreamEntry struct {
+	ID     StreamID
+	Fields []string
+}
+
+// Stream is an append-only log of entries ordered by ID.
+//
+// entries are kept in fixed-size blocks, so that appending and trimming the head do not move the whole log.
+type Stream struct {
+	blocks [][]StreamEntry
+	length int
+
+	lastID       StreamID
+	maxDeletedID StreamID
+	entriesAdded uint64
+
+	groups map[string]*ConsumerGroup
+}
+
+func NewStream() *Stream {
+	return &Stream{groups: make(map[string]*ConsumerGroup)}
+}
+
+// Len returns the number of entries.
+func (s *Stream) Len() int {
+	return s.length
+}
+
+// Blocks returns the number of storage blocks.
+func (s *Stream) Blocks() int {
+	return len(s.blocks)
+}
+
+// LastID returns the ID of the last entry ever added, even if it was deleted since.
+func (s *Stream) LastID() StreamID {
+	return s.lastID
+}
+
+// MaxDeletedID returns the largest ID of the deleted entries.
+func (s *Stream) MaxDeletedID() StreamID {
+	return s.maxDeletedID
+}
+
+// EntriesAdded returns the number of entries ever added.
+func (s *Stream) EntriesAdded() uint64 {
+	return s.entriesAdded
+}
+
+// First returns the first entry.
+func (s *Stream) First() (StreamEntry, bool) {
+	if s.length == 0 {
+		return StreamEntry{}, false
+	}
+	return s.blocks[0][0], true
+}
+
+// Last returns the last entry.
+func (s *Stream) Last() (StreamEntry, bool) {
+	if s.length == 0 {
+		return StreamEntry{}, false
+	}
+	last := s.blocks[len(s.blocks)-1]
+	return last[len(last)-1], true
+}
+
+// NextID returns the ID XADD generates at time now, ok is false when the stream has exhausted the ID space.
+func (s *Stream) NextID(now time.Time) (StreamID, bool) {
+	ms := uint64(max(now.UnixMilli(), 0))
+	if ms > s.lastID.Ms {
+		return StreamID{ms, 0}, true
+	}
+	return s.lastID.Next()
+}
+
+// Add appends an entry, id must be greater than LastID.
+func (s *Stream) Add(id StreamID, fields []string) error {
+	if id.Compare(MinStreamID) == 0 {
+		return ErrStreamIDZero
+	}
+	if id.Compare(s.lastID) <= 0 {
+		return ErrStreamIDTooSmall
+	}
+
+	entry := StreamEntry{ID: id, Fields: fields}
+	if n := len(s.blocks); n > 0 && len(s.blocks[n-1]) < streamBlockSize {
+		s.blocks[n-1] = append(s.blocks[n-1], entry)
+	} else {
+		block := make([]StreamEntry, 0, streamBlockSize)
+		s.blocks = append(s.blocks, append(block, entry))
+	}
+	s.length++
+	s.lastID = id
+	s.entriesAdded++
+	return nil
+}
+
+// SetLastID changes the last ID, as XSETID does, it cannot go below the last entry.
+func (s *Stream) SetLastID(id StreamID) error {
+	if last, ok := s.Last(); ok && id.Compare(last.ID) < 0 {
+		return ErrSetIDTooSmall
+	}
+	s.lastID = id
+	return nil
+}
+
+// RestoreCounters sets the counters of a stream loaded from a snapshot, once its entries are added back.
+func (s *Stream) RestoreCounters(maxDeletedID StreamID, entriesAdded uint64) {
+	s.maxDeletedID = maxDeletedID
+	s.entriesAdded = entriesAdded
+}
+
+// Get returns the entry with the given ID.
+func (s *Stream) Get(id StreamID) (StreamEntry, bool) {
+	b, i := s.seek(id)
+	if b < len(s.blocks) && s.blocks[b][i].ID == id {
+		return s.blocks[b][i], true
+	}
+	return StreamEntry{}, false
+}
+
+// Range returns up to count entries between start and end inclusive, count <= 0 meaning no limit.
+// the entries are returned from end to start when rev is true.
+func (s *Stream) Range(start, end StreamID, count int, rev bool) []StreamEntry {
+	var result []StreamEntry
+	if start.Compare(end) > 0 {
+		return result
+	}
+
+	full := func() bool { return count > 0 && len(result) >= count }
+
+	if !rev {
+		for b, i := s.seek(start); b < len(s.blocks) && !full(); {
+			entry := s.blocks[b][i]
+			if entry.ID.Compare(end) > 0 {
+				break
+			}
+			result = append(result, entry)
+			if i++; i == len(s.blocks[b]) {
+				b, i = b+1, 0
+			}
+		}
+		return result
+	}
+
+	// start from the last entry <= end
+	b, i := s.seekAfter(end)
+	for !full() {
+		if i--; i < 0 {
+			if b--; b < 0 {
+				break
+			}
+			i = len(s.blocks[b]) - 1
+		}
+		entry := s.blocks[b][i]
+		if entry.ID.Compare(start) < 0 {
+			break
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// After returns up to count entries with an ID greater than id, count <= 0 meaning no limit.
+func (s *Stream) After(id StreamID, count int) []StreamEntry {
+	next, ok := id.Next()
+	if !ok {
+		return nil
+	}
+	return s.Range(next, MaxStreamID, count, false)
+}
+
+// Delete removes the entry with the given ID.
+func (s *Stream) Delete(id StreamID) bool {
+	b, i := s.seek(id)
+	if b >= len(s.blocks) || s.blocks[b][i].ID != id {
+		return false
+	}
+
+	s.blocks[b] = slices.Delete(s.blocks[b], i, i+1)
+	if len(s.blocks[b]) == 0 {
+		s.blocks = slices.Delete(s.blocks, b, b+1)
+	}
+	s.length--
+	if id.Compare(s.maxDeletedID) > 0 {
+		s.maxDeletedID = id
+	}
+	return true
+}
+
+// TrimMaxLen removes the oldest entries until at most maxLen remain and returns the number of removed entries.
+// with approx, only whole blocks are removed, so that slightly more than maxLen entries may remain.
+// limit bounds the number of removed entries, 0 meaning no limit.
+func (s *Stream) TrimMaxLen(maxLen int, approx bool, limit int) int {
+	return s.trim(func() bool { return s.length > maxLen }, func(block []StreamEntry) bool {
+		return s.length-len(block) >= maxLen
+	}, approx, limit)
+}
+
+// TrimMinID removes the entries with an ID lower than minID and returns the number of removed entries.
+func (s *Stream) TrimMinID(minID StreamID, approx bool, limit int) int {
+	return s.trim(func() bool {
+		first, ok := s.First()
+		return ok && first.ID.Compare(minID) < 0
+	}, func(block []StreamEntry) bool {
+		return block[len(block)-1].ID.Compare(minID) < 0
+	}, approx, limit)
+}
+
+// trim removes entries from the head while more reports true.
+// with approx, whole blocks are removed while blockFits reports that the whole head block can go.
+func (s *Stream) trim(more func() bool, blockFits func([]StreamEntry) bool, approx bool, limit int) int {
+	removed := 0
+	withinLimit := func(n int) bool { return limit <= 0 || removed+n <= limit }
+
+	for len(s.blocks) > 0 && more() {
+		head := s.blocks[0]
+		if approx {
+			if !blockFits(head) || !withinLimit(len(head)) {
+				break
+			}
+			s.dropHead(len(head))
+			removed += len(head)
+			continue
+		}
+		if !withinLimit(1) {
+			break
+		}
+		s.dropHead(1)
+		removed++
+	}
+	return removed
+}
+
+// dropHead removes the first n entries of the head block.
+func (s *Stream) dropHead(n int) {
+	head := s.blocks[0]
+	if last := head[n-1].ID; last.Compare(s.maxDeletedID) > 0 {
+		s.maxDeletedID = last
+	}
+	if n == len(head) {
+		s.blocks = s.blocks[1:]
+	} else {
+		s.blocks[0] = head[n:]
+	}
+	s.length -= n
+}
+
+// seek returns the position of the first entry with an ID >= id, b is len(blocks) when there is none.
+func (s *Stream) seek(id StreamID) (b, i int) {
+	b = sort.Search(len(s.blocks), func(b int) bool {
+		block := s.blocks[b]
+		return block[len(block)-1].ID.Compare(id) >= 0
+	})
+	if b == len(s.blocks) {
+		return b, 0
+	}
+	block := s.blocks[b]
+	i = sort.Search(len(block), func(i int) bool { return block[i].ID.Compare(id) >= 0 })
+	return b, i
+}
+
+// seekAfter returns the position of the first entry with an ID > id, b is len(blocks) when there is none.
+func (s *Stream) seekAfter(id StreamID) (b, i int) {
+	next, ok := id.Next()
+	if !ok {
+		return len(s.blocks), 0
+	}
+	return s.seek(next)
+}
@@ -0,0 +1,267 @@
+package object
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// ConsumerGroup delivers the entries of a stream to its consumers and tracks the entries they have not acknowledged yet.
+type ConsumerGroup struct {
+	Name string
+	// ID of the last entry delivered to the group
+	LastID StreamID
+
+	// the pending entries list, ordered by ID
+	pel       []*PendingEntry
+	pelIndex  map[StreamID]*PendingEntry
+	consumers map[string]*Consumer
+}
+
+// PendingEntry is an entry delivered to a consumer and not acknowledged yet.
+type PendingEntry struct {
+	ID            StreamID
+	Consumer      *Consumer
+	DeliveryTime  time.Time
+	DeliveryCount int64
+}
+
+// Consumer is a member of a consumer group.
+type Consumer struct {
+	Name string
+	// last time the consumer attempted an interaction, such as reading or claiming
+	SeenTime time.Time
+	// last time the consumer was actually delivered or claimed entries
+	ActiveTime time.Time
+
+	// entries delivered to the consumer and not acknowledged yet
+	pel map[StreamID]*PendingEntry
+}
+
+// Pending returns the number of entries pending for the consumer.
+func (c *Consumer) Pending() int {
+	return len(c.pel)
+}
+
+// CreateGroup adds a consumer group that delivers the entries after lastID, it reports false when the group exists.
+func (s *Stream) CreateGroup(name string, lastID StreamID) bool {
+	if _, ok := s.groups[name]; ok {
+		return false
+	}
+	s.groups[name] = &ConsumerGroup{
+		Name:      name,
+		LastID:    lastID,
+		pelIndex:  make(map[StreamID]*PendingEntry),
+		consumers: make(map[string]*Consumer),
+	}
+	return true
+}
+
+// DestroyGroup removes a consumer group along with its pending entries.
+func (s *Stream) DestroyGroup(name string) bool {
+	if _, ok := s.groups[name]; !ok {
+		return false
+	}
+	delete(s.groups, name)
+	return true
+}
+
+// Group returns the consumer group with the given name.
+func (s *Stream) Group(name string) (*ConsumerGroup, bool) {
+	g, ok := s.groups[name]
+	return g, ok
+}
+
+// Groups returns the consumer groups ordered by name.
+func (s *Stream) Groups() []*ConsumerGroup {
+	groups := make([]*ConsumerGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// Lag returns the number of entries of s not delivered to the group yet.
+func (g *ConsumerGroup) Lag(s *Stream) int {
+	return len(s.After(g.LastID, 0))
+}
+
+// CreateConsumer adds a consumer, it reports false when the consumer exists.
+func (g *ConsumerGroup) CreateConsumer(name string, now time.Time) (*Consumer, bool) {
+	if c, ok := g.consumers[name]; ok {
+		return c, false
+	}
+	c := &Consumer{
+		Name:     name,
+		SeenTime: now,
+		pel:      make(map[StreamID]*PendingEntry),
+	}
+	g.consumers[name] = c
+	return c, true
+}
+
+// Consumer returns the consumer with the given name.
+func (g *ConsumerGroup) Consumer(name string) (*Consumer, bool) {
+	c, ok := g.consumers[name]
+	return c, ok
+}
+
+// Consumers returns the consumers ordered by name.
+func (g *ConsumerGroup) Consumers() []*Consumer {
+	consumers := make([]*Consumer, 0, len(g.consumers))
+	for _, c := range g.consumers {
+		consumers = append(consumers, c)
+	}
+	sort.Slice(consumers, func(i, j int) bool { return consumers[i].Name < consumers[j].Name })
+	return consumers
+}
+
+// DeleteConsumer removes a consumer and its pending entries, it returns the number of entries that were pending.
+func (g *ConsumerGroup) DeleteConsumer(name string) (int, bool) {
+	c, ok := g.consumers[name]
+	if !ok {
+		return 0, false
+	}
+	pending := len(c.pel)
+	for id := range c.pel {
+		g.removePending(id)
+	}
+	delete(g.consumers, name)
+	return pending, true
+}
+
+// ReadNew delivers to consumer up to count entries never delivered to the group, count <= 0 meaning no limit.
+// the entries are added to the pending entries list unless noAck is set.
+func (g *ConsumerGroup) ReadNew(s *Stream, consumer *Consumer, count int, noAck bool, now time.Time) []StreamEntry {
+	consumer.SeenTime = now
+
+	entries := s.After(g.LastID, count)
+	if len(entries) == 0 {
+		return entries
+	}
+	consumer.ActiveTime = now
+	g.LastID = entries[len(entries)-1].ID
+
+	if noAck {
+		return entries
+	}
+	for _, entry := range entries {
+		pe := &PendingEntry{ID: entry.ID, Consumer: consumer, DeliveryTime: now, DeliveryCount: 1}
+		g.addPending(pe)
+	}
+	return entries
+}
+
+// ReadPending returns up to count of the entries pending for consumer with an ID greater than after.
+// deleted entries are reported with a nil Fields.
+func (g *ConsumerGroup) ReadPending(s *Stream, consumer *Consumer, after StreamID, count int, now time.Time) []StreamEntry {
+	consumer.SeenTime = now
+
+	var entries []StreamEntry
+	for _, pe := range g.pel[g.seek(after):] {
+		if count > 0 && len(entries) >= count {
+			break
+		}
+		if pe.Consumer != consumer || pe.ID == after {
+			continue
+		}
+		entry, ok := s.Get(pe.ID)
+		if !ok {
+			entry = StreamEntry{ID: pe.ID}
+		}
+		// re-reading the history counts as a delivery, like Redis does
+		pe.DeliveryTime = now
+		pe.DeliveryCount++
+		entries = append(entries, entry)
+	}
+	if entries == nil {
+		entries = []StreamEntry{}
+	}
+	return entries
+}
+
+// Ack removes an entry from the pending entries list.
+func (g *ConsumerGroup) Ack(id StreamID) bool {
+	return g.removePending(id)
+}
+
+// PendingCount returns the number of pending entries.
+func (g *ConsumerGroup) PendingCount() int {
+	return len(g.pel)
+}
+
+// PendingRange returns up to count pending entries between start and end inclusive, idle for at least minIdle.
+// the entries are restricted to those of consumer unless it is nil.
+func (g *ConsumerGroup) PendingRange(start, end StreamID, count int, consumer *Consumer, minIdle time.Duration, now time.Time) []*PendingEntry {
+	var result []*PendingEntry
+	for _, pe := range g.pel[g.seek(start):] {
+		if pe.ID.Compare(end) > 0 || (count > 0 && len(result) >= count) {
+			break
+		}
+		if consumer != nil && pe.Consumer != consumer {
+			continue
+		}
+		if now.Sub(pe.DeliveryTime) < minIdle {
+			continue
+		}
+		result = append(result, pe)
+	}
+	return result
+}
+
+// Pending returns the pending entry with the given ID.
+func (g *ConsumerGroup) Pending(id StreamID) (*PendingEntry, bool) {
+	pe, ok := g.pelIndex[id]
+	return pe, ok
+}
+
+// Claim changes the owner of a pending entry to consumer, creating the pending entry if it does not exist.
+func (g *ConsumerGroup) Claim(id StreamID, consumer *Consumer) *PendingEntry {
+	pe, ok := g.pelIndex[id]
+	if !ok {
+		pe = &PendingEntry{ID: id, Consumer: consumer}
+		g.addPending(pe)
+		return pe
+	}
+	delete(pe.Consumer.pel, id)
+	pe.Consumer = consumer
+	consumer.pel[id] = pe
+	return pe
+}
+
+// SetID changes the last delivered ID of the group.
+func (g *ConsumerGroup) SetID(id StreamID) {
+	g.LastID = id
+}
+
+func (g *ConsumerGroup) addPending(pe *PendingEntry) {
+	if old, ok := g.pelIndex[pe.ID]; ok {
+		// delivering again an entry already pending, as after XGROUP SETID, replaces it
+		delete(old.Consumer.pel, pe.ID)
+		i := g.seek(pe.ID)
+		g.pel[i] = pe
+	} else {
+		i := g.seek(pe.ID)
+		g.pel = slices.Insert(g.pel, i, pe)
+	}
+	g.pelIndex[pe.ID] = pe
+	pe.Consumer.pel[pe.ID] = pe
+}
+
+func (g *ConsumerGroup) removePending(id StreamID) bool {
+	pe, ok := g.pelIndex[id]
+	if !ok {
+		return false
+	}
+	delete(g.pelIndex, id)
+	delete(pe.Consumer.pel, id)
+	i := g.seek(id)
+	g.pel = slices.Delete(g.pel, i, i+1)
+	return true
+}
+
+// seek returns the position of the first pending entry with an ID >= id.
+func (g *ConsumerGroup) seek(id StreamID) int {
+	return sort.Search(len(g.pel), func(i int) bool { return g.pel[i].ID.Compare(id) >= 0 })
+}
@@ -0,0 +1,151 @@
+package object
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ids(entries []StreamEntry) []string {
+	result := make([]string, len(entries))
+	for i, e := range entries {
+		result[i] = e.ID.String()
+	}
+	return result
+}
+
+func newTestStream(t *testing.T, n int) *Stream {
+	t.Helper()
+
+	s := NewStream()
+	for i := 1; i <= n; i++ {
+		require.NoError(t, s.Add(StreamID{uint64(i), 0}, []string{"n", strconv.Itoa(i)}))
+	}
+	return s
+}
+
+func TestParseStreamID(t *testing.T) {
+	id, err := ParseStreamID("1526919030474-55", 0)
+	require.NoError(t, err)
+	require.Equal(t, StreamID{1526919030474, 55}, id)
+
+	id, err = ParseStreamID("12", 7)
+	require.NoError(t, err)
+	require.Equal(t, StreamID{12, 7}, id)
+
+	for _, invalid := range []string{"", "-", "a-1", "1-b", "1-2-3", "-1"} {
+		_, err = ParseStreamID(invalid, 0)
+		require.ErrorIs(t, err, ErrInvalidStreamID, invalid)
+	}
+}
+
+func TestStreamAdd(t *testing.T) {
+	s := NewStream()
+	require.ErrorIs(t, s.Add(MinStreamID, nil), ErrStreamIDZero)
+	require.NoError(t, s.Add(StreamID{5, 1}, []string{"a", "1"}))
+	require.ErrorIs(t, s.Add(StreamID{5, 1}, nil), ErrStreamIDTooSmall)
+	require.ErrorIs(t, s.Add(StreamID{4, 9}, nil), ErrStreamIDTooSmall)
+
+	next, ok := s.NextID(time.UnixMilli(3))
+	require.True(t, ok)
+	require.Equal(t, StreamID{5, 2}, next, "A clock behind the last ID should increment the sequence")
+
+	next, ok = s.NextID(time.UnixMilli(10))
+	require.True(t, ok)
+	require.Equal(t, StreamID{10, 0}, next)
+}
+
+func TestStreamRange(t *testing.T) {
+	// spans several blocks
+	s := newTestStream(t, 250)
+	require.Equal(t, 250, s.Len())
+	require.Equal(t, 3, s.Blocks())
+
+	require.Len(t, s.Range(MinStreamID, MaxStreamID, 0, false), 250)
+	require.Equal(t, []string{"99-0", "100-0", "101-0"}, ids(s.Range(StreamID{99, 0}, StreamID{101, 0}, 0, false)))
+	require.Equal(t, []string{"101-0", "100-0", "99-0"}, ids(s.Range(StreamID{99, 0}, StreamID{101, 0}, 0, true)))
+	require.Equal(t, []string{"250-0", "249-0"}, ids(s.Range(MinStreamID, MaxStreamID, 2, true)))
+	require.Equal(t, []string{"1-0", "2-0"}, ids(s.Range(MinStreamID, MaxStreamID, 2, false)))
+	require.Empty(t, s.Range(StreamID{300, 0}, MaxStreamID, 0, false))
+	require.Empty(t, s.Range(StreamID{5, 0}, StreamID{4, 0}, 0, false))
+
+	require.Equal(t, []string{"249-0", "250-0"}, ids(s.After(StreamID{248, 0}, 0)))
+}
+
+func TestStreamDelete(t *testing.T) {
+	s := newTestStream(t, 3)
+
+	require.True(t, s.Delete(StreamID{2, 0}))
+	require.False(t, s.Delete(StreamID{2, 0}))
+	require.Equal(t, []string{"1-0", "3-0"}, ids(s.Range(MinStreamID, MaxStreamID, 0, false)))
+	require.Equal(t, StreamID{2, 0}, s.MaxDeletedID())
+
+	require.True(t, s.Delete(StreamID{3, 0}))
+	require.Equal(t, StreamID{3, 0}, s.LastID(), "The last ID survives the deletion of the last entry")
+	require.ErrorIs(t, s.Add(StreamID{3, 0}, nil), ErrStreamIDTooSmall)
+}
+
+func TestStreamTrim(t *testing.T) {
+	t.Run("Exact MAXLEN", func(t *testing.T) {
+		s := newTestStream(t, 250)
+		require.Equal(t, 240, s.TrimMaxLen(10, false, 0))
+		require.Equal(t, 10, s.Len())
+		first, _ := s.First()
+		require.Equal(t, StreamID{241, 0}, first.ID)
+	})
+
+	t.Run("Approximate MAXLEN removes whole blocks", func(t *testing.T) {
+		s := newTestStream(t, 250)
+		require.Equal(t, 200, s.TrimMaxLen(10, true, 0))
+		require.Equal(t, 50, s.Len())
+	})
+
+	t.Run("MINID with LIMIT", func(t *testing.T) {
+		s := newTestStream(t, 250)
+		require.Equal(t, 100, s.TrimMinID(StreamID{201, 0}, true, 150))
+		require.Equal(t, 150, s.Len())
+		require.Equal(t, 100, s.TrimMinID(StreamID{201, 0}, false, 0))
+		require.Equal(t, StreamID{200, 0}, s.MaxDeletedID())
+	})
+}
+
+func TestConsumerGroup(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newTestStream(t, 5)
+
+	require.True(t, s.CreateGroup("g", StreamID{2, 0}))
+	require.False(t, s.CreateGroup("g", MinStreamID))
+	g, _ := s.Group("g")
+	alice, _ := g.CreateConsumer("alice", now)
+	bob, _ := g.CreateConsumer("bob", now)
+
+	require.Equal(t, 3, g.Lag(s))
+	require.Equal(t, []string{"3-0", "4-0"}, ids(g.ReadNew(s, alice, 2, false, now)))
+	require.Equal(t, []string{"5-0"}, ids(g.ReadNew(s, bob, 0, false, now)))
+	require.Empty(t, g.ReadNew(s, bob, 0, false, now))
+	require.Equal(t, 3, g.PendingCount())
+
+	history := g.ReadPending(s, alice, MinStreamID, 0, now.Add(time.Second))
+	require.Equal(t, []string{"3-0", "4-0"}, ids(history))
+	pe, _ := g.Pending(StreamID{3, 0})
+	require.Equal(t, int64(2), pe.DeliveryCount)
+
+	require.True(t, g.Ack(StreamID{3, 0}))
+	require.False(t, g.Ack(StreamID{3, 0}))
+	require.Equal(t, 1, alice.Pending())
+
+	idle := g.PendingRange(MinStreamID, MaxStreamID, 0, nil, 5*time.Second, now.Add(5*time.Second))
+	require.Len(t, idle, 1, "Only the entry delivered 5 seconds ago should be idle enough")
+	require.Equal(t, StreamID{5, 0}, idle[0].ID)
+
+	g.Claim(StreamID{5, 0}, alice)
+	require.Equal(t, 2, alice.Pending())
+	require.Zero(t, bob.Pending())
+
+	pending, ok := g.DeleteConsumer("alice")
+	require.True(t, ok)
+	require.Equal(t, 2, pending)
+	require.Zero(t, g.PendingCount())
+}
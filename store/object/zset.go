@@ -0,0 +1,205 @@
+package object
+
+import (
+	"math/rand/v2"
+)
+
+const (
+	// zsetMaxLevel and zsetLevelP are the skiplist parameters of Redis
+	zsetMaxLevel = 32
+	zsetLevelP   = 0.25
+)
+
+// ScoredMember is a member of a sorted set along with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// less orders members by score, then lexicographically.
+func (m ScoredMember) less(score float64, member string) bool {
+	return m.Score < score || (m.Score == score && m.Member < member)
+}
+
+type zsetNode struct {
+	ScoredMember
+	levels []zsetLevel
+}
+
+type zsetLevel struct {
+	forward *zsetNode
+	// number of nodes the forward link skips, used to compute ranks
+	span int
+}
+
+// SortedSet is a set of members ordered by score, indexed by a skiplist for range queries
+// and a map for score lookups, like the sorted sets of Redis.
+type SortedSet struct {
+	scores map[string]float64
+	head   *zsetNode
+	// number of nodes and of levels in use of the skiplist
+	length int
+	level  int
+}
+
+func NewSortedSet() *SortedSet {
+	return &SortedSet{
+		scores: make(map[string]float64),
+		head:   &zsetNode{levels: make([]zsetLevel, zsetMaxLevel)},
+		level:  1,
+	}
+}
+
+// Len returns the number of members.
+func (z *SortedSet) Len() int {
+	return len(z.scores)
+}
+
+// Score returns the score of member.
+func (z *SortedSet) Score(member string) (float64, bool) {
+	score, ok := z.scores[member]
+	return score, ok
+}
+
+// Add sets the score of member and reports whether it was added rather than updated.
+func (z *SortedSet) Add(member string, score float64) bool {
+	prev, exists := z.scores[member]
+	if exists {
+		if prev == score {
+			return false
+		}
+		z.delete(member, prev)
+	}
+	z.scores[member] = score
+	z.insert(member, score)
+	return !exists
+}
+
+// Remove deletes member and reports whether it was present.
+func (z *SortedSet) Remove(member string) bool {
+	score, ok := z.scores[member]
+	if !ok {
+		return false
+	}
+	delete(z.scores, member)
+	z.delete(member, score)
+	return true
+}
+
+// Range returns the members between ranks start and stop inclusive, negative ranks counting from the highest score.
+func (z *SortedSet) Range(start, stop int) []ScoredMember {
+	start, stop, ok := normalizeRange(start, stop, z.Len())
+	if !ok {
+		return []ScoredMember{}
+	}
+
+	result := make([]ScoredMember, 0, stop-start+1)
+	for n := z.nodeAt(start); n != nil && len(result) < cap(result); n = n.levels[0].forward {
+		result = append(result, n.ScoredMember)
+	}
+	return result
+}
+
+// RangeByScore calls fn on the members whose score is in [minScore, maxScore), in order, until fn returns false.
+func (z *SortedSet) RangeByScore(minScore, maxScore float64, fn func(ScoredMember) bool) {
+	x := z.head
+	for i := z.level - 1; i >= 0; i-- {
+		for next := x.levels[i].forward; next != nil && next.Score < minScore; next = x.levels[i].forward {
+			x = next
+		}
+	}
+	for n := x.levels[0].forward; n != nil && n.Score < maxScore; n = n.levels[0].forward {
+		if !fn(n.ScoredMember) {
+			return
+		}
+	}
+}
+
+// nodeAt returns the node of the 0-based rank, which must be in range.
+func (z *SortedSet) nodeAt(rank int) *zsetNode {
+	x, traversed := z.head, -1
+	for i := z.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && traversed+x.levels[i].span <= rank {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+func randomLevel() int {
+	level := 1
+	for level < zsetMaxLevel && rand.Float64() < zsetLevelP {
+		level++
+	}
+	return level
+}
+
+func (z *SortedSet) insert(member string, score float64) {
+	var update [zsetMaxLevel]*zsetNode
+	var rank [zsetMaxLevel]int
+
+	x := z.head
+	for i := z.level - 1; i >= 0; i-- {
+		if i < z.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].forward != nil && x.levels[i].forward.less(score, member) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomLevel()
+	if level > z.level {
+		for i := z.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = z.head
+			update[i].levels[i].span = z.length
+		}
+		z.level = level
+	}
+
+	n := &zsetNode{ScoredMember: ScoredMember{member, score}, levels: make([]zsetLevel, level)}
+	for i := range level {
+		n.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = n
+		n.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	// the levels above the new node skip one more node
+	for i := level; i < z.level; i++ {
+		update[i].levels[i].span++
+	}
+	z.length++
+}
+
+func (z *SortedSet) delete(member string, score float64) {
+	var update [zsetMaxLevel]*zsetNode
+
+	x := z.head
+	for i := z.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.less(score, member) {
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	n := x.levels[0].forward
+	for i := range z.level {
+		if update[i].levels[i].forward == n {
+			update[i].levels[i].span += n.levels[i].span - 1
+			update[i].levels[i].forward = n.levels[i].forward
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+	z.length--
+	for z.level > 1 && z.head.levels[z.level-1].forward == nil {
+		z.level--
+	}
+}
@@ -0,0 +1,89 @@
+package object
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedSetAddRemove(t *testing.T) {
+	z := NewSortedSet()
+	require.True(t, z.Add("b", 2))
+	require.True(t, z.Add("a", 1))
+	require.True(t, z.Add("c", 2))
+	require.False(t, z.Add("a", 3))
+	require.Equal(t, 3, z.Len())
+
+	score, ok := z.Score("a")
+	require.True(t, ok)
+	require.Equal(t, 3.0, score)
+
+	// equal scores are ordered by member
+	require.Equal(t, []ScoredMember{{"b", 2}, {"c", 2}, {"a", 3}}, z.Range(0, -1))
+
+	require.True(t, z.Remove("c"))
+	require.False(t, z.Remove("c"))
+	require.Equal(t, []ScoredMember{{"b", 2}, {"a", 3}}, z.Range(0, -1))
+	require.Equal(t, []ScoredMember{{"a", 3}}, z.Range(-1, -1))
+	require.Empty(t, z.Range(2, 5))
+}
+
+func TestSortedSetMatchesSortedSlice(t *testing.T) {
+	z := NewSortedSet()
+	scores := make(map[string]float64)
+	for range 5000 {
+		member := strconv.Itoa(rand.IntN(1000))
+		if rand.IntN(4) == 0 {
+			require.Equal(t, scores[member] != 0, z.Remove(member))
+			delete(scores, member)
+			continue
+		}
+		score := float64(rand.IntN(100) + 1)
+		_, exists := scores[member]
+		require.Equal(t, !exists, z.Add(member, score))
+		scores[member] = score
+	}
+
+	expected := make([]ScoredMember, 0, len(scores))
+	for member, score := range scores {
+		expected = append(expected, ScoredMember{member, score})
+	}
+	slices.SortFunc(expected, func(a, b ScoredMember) int {
+		if a.less(b.Score, b.Member) {
+			return -1
+		}
+		return 1
+	})
+	require.Equal(t, len(expected), z.Len())
+	require.Equal(t, expected, z.Range(0, -1))
+
+	// ranks are resolved through the spans of the skiplist
+	for _, rank := range []int{0, 1, len(expected) / 2, len(expected) - 1} {
+		require.Equal(t, expected[rank:rank+1], z.Range(rank, rank))
+	}
+	require.Equal(t, expected[10:20], z.Range(10, 19))
+}
+
+func TestSortedSetRangeByScore(t *testing.T) {
+	z := NewSortedSet()
+	for i := range 100 {
+		z.Add(strconv.Itoa(i), float64(i))
+	}
+
+	var members []string
+	z.RangeByScore(10, 15, func(m ScoredMember) bool {
+		members = append(members, m.Member)
+		return true
+	})
+	require.Equal(t, []string{"10", "11", "12", "13", "14"}, members)
+
+	members = nil
+	z.RangeByScore(90, 200, func(m ScoredMember) bool {
+		members = append(members, m.Member)
+		return len(members) < 2
+	})
+	require.Equal(t, []string{"90", "91"}, members)
+}
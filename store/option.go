@@ -0,0 +1,51 @@
+package store
+
+import "github.com/PlayerNeo42/gvalkey/clock"
+
+// Options holds the settings shared by all Store implementations.
+type Options struct {
+	// OnExpire is called with the key each time a key is removed because its TTL elapsed.
+	// it may be called from the store's internal goroutine, so it must not block or call back into the store.
+	OnExpire func(key string)
+
+	// OnExpireFields is called with the key each time the background expiration removes fields of a FieldExpirer,
+	// deleted telling whether the key was left empty and removed.
+	// like OnExpire, it must not block or call back into the store.
+	OnExpireFields func(key string, deleted bool)
+
+	// Clock tells whether keys expired, the system clock by default.
+	Clock clock.Clock
+}
+
+type Option func(*Options)
+
+// NewOptions applies opts on top of the default options.
+func NewOptions(opts ...Option) Options {
+	o := Options{
+		OnExpire:       func(string) {},
+		OnExpireFields: func(string, bool) {},
+		Clock:          clock.Real,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func WithOnExpire(fn func(key string)) Option {
+	return func(o *Options) {
+		o.OnExpire = fn
+	}
+}
+
+func WithOnExpireFields(fn func(key string, deleted bool)) Option {
+	return func(o *Options) {
+		o.OnExpireFields = fn
+	}
+}
+
+func WithClock(c clock.Clock) Option {
+	return func(o *Options) {
+		o.Clock = c
+	}
+}
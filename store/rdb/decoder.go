@@ -0,0 +1,311 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+var (
+	errBadMagic    = errors.New("wrong signature trying to load DB")
+	errBadChecksum = errors.New("wrong RDB checksum")
+	errBadLZF      = errors.New("invalid LZF compressed string")
+)
+
+// decoder reads the records of a file, the first error is kept and every further read returns zero values.
+type decoder struct {
+	r   *bufio.Reader
+	crc uint64
+	err error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(d.r, p); err != nil {
+		d.err = err
+		return p
+	}
+	d.crc = updateCRC(d.crc, p)
+	return p
+}
+
+func (d *decoder) readByte() byte {
+	return d.read(1)[0]
+}
+
+// readLen reads a length, encoded reports whether it is rather the encoding of a special string.
+func (d *decoder) readLen() (n uint64, encoded bool) {
+	first := d.readByte()
+	switch first >> 6 {
+	case len6Bit:
+		return uint64(first & 0x3F), false
+	case len14Bit:
+		return uint64(first&0x3F)<<8 | uint64(d.readByte()), false
+	case lenEncoded:
+		return uint64(first & 0x3F), true
+	}
+	switch first {
+	case len32Bit:
+		return uint64(binary.BigEndian.Uint32(d.read(4))), false
+	case len64Bit:
+		return binary.BigEndian.Uint64(d.read(8)), false
+	default:
+		d.fail(fmt.Errorf("unknown length encoding %d", first))
+		return 0, false
+	}
+}
+
+// readCount reads a length that must not be an encoded string.
+func (d *decoder) readCount() uint64 {
+	n, encoded := d.readLen()
+	if encoded {
+		d.fail(fmt.Errorf("unexpected string encoding %d", n))
+	}
+	return n
+}
+
+func (d *decoder) readString() string {
+	n, encoded := d.readLen()
+	if !encoded {
+		return string(d.read(int(n)))
+	}
+
+	switch n {
+	case encInt8:
+		return strconv.FormatInt(int64(int8(d.readByte())), 10)
+	case encInt16:
+		return strconv.FormatInt(int64(int16(binary.LittleEndian.Uint16(d.read(2)))), 10)
+	case encInt32:
+		return strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(d.read(4)))), 10)
+	case encLZF:
+		compressed := d.readCount()
+		length := d.readCount()
+		s, err := decompressLZF(d.read(int(compressed)), int(length))
+		d.fail(err)
+		return s
+	default:
+		d.fail(fmt.Errorf("unknown string encoding %d", n))
+		return ""
+	}
+}
+
+func (d *decoder) readMillis() time.Time {
+	return time.UnixMilli(int64(binary.LittleEndian.Uint64(d.read(8))))
+}
+
+func (d *decoder) readStreamID() object.StreamID {
+	return object.StreamID{Ms: d.readCount(), Seq: d.readCount()}
+}
+
+// fail records err unless an error was already met.
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) load(fn func(db int, key string, entry store.Entry) error) error {
+	header := string(d.read(len(magic) + 4))
+	if d.err != nil {
+		return d.err
+	}
+	if header[:len(magic)] != magic {
+		return errBadMagic
+	}
+	if v, err := strconv.Atoi(header[len(magic):]); err != nil || v < 1 || v > formatVersion {
+		return fmt.Errorf("can't handle RDB format version %s", header[len(magic):])
+	}
+
+	db := 0
+	var expireAt time.Time
+	for d.err == nil {
+		op := d.readByte()
+		switch op {
+		case opAux:
+			d.readString()
+			d.readString()
+		case opResizeDB:
+			d.readCount()
+			d.readCount()
+		case opExpireTimeMs:
+			expireAt = d.readMillis()
+		case opExpireTime:
+			expireAt = time.Unix(int64(binary.LittleEndian.Uint32(d.read(4))), 0)
+		case opSelectDB:
+			db = int(d.readCount())
+		case opIdle:
+			d.readCount()
+		case opFreq:
+			d.readByte()
+		case opEOF:
+			return d.readChecksum()
+		default:
+			key := d.readString()
+			value := d.readValue(op)
+			if d.err != nil {
+				break
+			}
+			if err := fn(db, key, store.Entry{Value: value, ExpireAt: expireAt}); err != nil {
+				return err
+			}
+			expireAt = time.Time{}
+		}
+	}
+	return d.err
+}
+
+// readChecksum checks the checksum ending the file, a zero checksum meaning it was not computed.
+func (d *decoder) readChecksum() error {
+	crc := d.crc
+	p := d.read(8)
+	if d.err != nil {
+		return d.err
+	}
+	if expected := binary.LittleEndian.Uint64(p); expected != 0 && expected != crc {
+		return errBadChecksum
+	}
+	return nil
+}
+
+func (d *decoder) readValue(typ byte) any {
+	switch typ {
+	case typeString:
+		return resp.BulkString(d.readString())
+	case typeList:
+		l := object.NewList()
+		for n := d.readCount(); n > 0 && d.err == nil; n-- {
+			l.PushRight(d.readString())
+		}
+		return l
+	case typeZSet2:
+		z := object.NewSortedSet()
+		for n := d.readCount(); n > 0 && d.err == nil; n-- {
+			member := d.readString()
+			z.Add(member, math.Float64frombits(binary.LittleEndian.Uint64(d.read(8))))
+		}
+		return z
+	case typeHash:
+		h := object.NewHash()
+		for n := d.readCount(); n > 0 && d.err == nil; n-- {
+			field := d.readString()
+			h.Set(field, d.readString())
+		}
+		return h
+	case typeHashMetadata:
+		return d.readHashMetadata()
+	case typeStream:
+		return d.readStream()
+	default:
+		d.fail(fmt.Errorf("unsupported value type %d", typ))
+		return nil
+	}
+}
+
+func (d *decoder) readHashMetadata() *object.Hash {
+	h := object.NewHash()
+	minExpire := d.readMillis().UnixMilli()
+	for n := d.readCount(); n > 0 && d.err == nil; n-- {
+		ttl := d.readCount()
+		field := d.readString()
+		h.Set(field, d.readString())
+		if ttl != 0 {
+			h.SetExpireAt(field, time.UnixMilli(minExpire+int64(ttl)-1))
+		}
+	}
+	return h
+}
+
+func (d *decoder) readStream() *object.Stream {
+	s := object.NewStream()
+	for n := d.readCount(); n > 0 && d.err == nil; n-- {
+		id := d.readStreamID()
+		fields := make([]string, d.readCount())
+		for i := range fields {
+			fields[i] = d.readString()
+		}
+		d.fail(s.Add(id, fields))
+	}
+	d.fail(s.SetLastID(d.readStreamID()))
+	maxDeletedID := d.readStreamID()
+	s.RestoreCounters(maxDeletedID, d.readCount())
+
+	for n := d.readCount(); n > 0 && d.err == nil; n-- {
+		name := d.readString()
+		s.CreateGroup(name, d.readStreamID())
+		g, _ := s.Group(name)
+
+		for m := d.readCount(); m > 0 && d.err == nil; m-- {
+			c, _ := g.CreateConsumer(d.readString(), time.Time{})
+			c.SeenTime = d.readMillis()
+			c.ActiveTime = d.readMillis()
+		}
+
+		for m := d.readCount(); m > 0 && d.err == nil; m-- {
+			id := d.readStreamID()
+			c, ok := g.Consumer(d.readString())
+			if !ok {
+				d.fail(fmt.Errorf("pending entry %s of an unknown consumer", id))
+				break
+			}
+			pe := g.Claim(id, c)
+			pe.DeliveryTime = d.readMillis()
+			pe.DeliveryCount = int64(d.readCount())
+		}
+	}
+	return s
+}
+
+// decompressLZF expands the LZF compressed strings that Redis saves when rdbcompression is enabled.
+func decompressLZF(in []byte, length int) (string, error) {
+	out := make([]byte, 0, length)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 1<<5 {
+			// a literal run of ctrl+1 bytes
+			if i+ctrl+1 > len(in) {
+				return "", errBadLZF
+			}
+			out = append(out, in[i:i+ctrl+1]...)
+			i += ctrl + 1
+			continue
+		}
+
+		// a back reference, its length in the top 3 bits, extended by a byte when they are all set
+		n := ctrl >> 5
+		if n == 7 {
+			if i >= len(in) {
+				return "", errBadLZF
+			}
+			n += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return "", errBadLZF
+		}
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 {
+			return "", errBadLZF
+		}
+		for j := range n + 2 {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != length {
+		return "", errBadLZF
+	}
+	return string(out), nil
+}
@@ -0,0 +1,243 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+)
+
+// encoder writes the records of a file, the first error is kept and stops any further write.
+type encoder struct {
+	w   io.Writer
+	crc uint64
+	err error
+	// time of the clock of the server when the encoding started
+	now time.Time
+}
+
+func (e *encoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+	e.crc = updateCRC(e.crc, p)
+	_, e.err = e.w.Write(p)
+}
+
+func (e *encoder) writeByte(b byte) {
+	e.write([]byte{b})
+}
+
+func (e *encoder) writeLen(n uint64) {
+	switch {
+	case n < 1<<6:
+		e.writeByte(byte(n))
+	case n < 1<<14:
+		e.write([]byte{len14Bit<<6 | byte(n>>8), byte(n)})
+	case n <= math.MaxUint32:
+		e.writeByte(len32Bit)
+		e.write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.writeByte(len64Bit)
+		e.write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+func (e *encoder) writeString(s string) {
+	e.writeLen(uint64(len(s)))
+	e.write([]byte(s))
+}
+
+// writeMillis writes a unix time in milliseconds, as 8 little-endian bytes.
+func (e *encoder) writeMillis(t time.Time) {
+	e.write(binary.LittleEndian.AppendUint64(nil, uint64(t.UnixMilli())))
+}
+
+func (e *encoder) writeAux(key, value string) {
+	e.writeByte(opAux)
+	e.writeString(key)
+	e.writeString(value)
+}
+
+// writeChecksum ends the file with the checksum of everything written before it.
+func (e *encoder) writeChecksum() {
+	crc := e.crc
+	e.write(binary.LittleEndian.AppendUint64(nil, crc))
+}
+
+// writeDB writes the keys of database index, nothing being written for an empty database.
+func (e *encoder) writeDB(index int, db store.Store) {
+	stats := db.Stats()
+	if stats.Keys == 0 {
+		return
+	}
+	e.writeByte(opSelectDB)
+	e.writeLen(uint64(index))
+	e.writeByte(opResizeDB)
+	e.writeLen(uint64(stats.Keys))
+	e.writeLen(uint64(stats.Expires))
+
+	db.Range(func(key string, entry store.Entry) bool {
+		e.writeEntry(key, entry)
+		return e.err == nil
+	})
+}
+
+func (e *encoder) writeEntry(key string, entry store.Entry) {
+	if !entry.ExpireAt.IsZero() {
+		e.writeByte(opExpireTimeMs)
+		e.writeMillis(entry.ExpireAt)
+	}
+	e.writeType(entry.Value)
+	e.writeString(key)
+	e.writeValue(entry.Value)
+}
+
+// writeType writes the type of value, or fails when value cannot be saved.
+func (e *encoder) writeType(value any) {
+	switch v := value.(type) {
+	case resp.BulkString:
+		e.writeByte(typeString)
+	case *object.List:
+		e.writeByte(typeList)
+	case *object.SortedSet:
+		e.writeByte(typeZSet2)
+	case *object.Hash:
+		if hashMinExpire(v).IsZero() {
+			e.writeByte(typeHash)
+		} else {
+			e.writeByte(typeHashMetadata)
+		}
+	case *object.Stream:
+		e.writeByte(typeStream)
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("cannot save a value of type %T", value)
+		}
+	}
+}
+
+// writeValue writes value with the encoding of the type written by writeType.
+func (e *encoder) writeValue(value any) {
+	switch v := value.(type) {
+	case resp.BulkString:
+		e.writeString(string(v))
+	case *object.List:
+		e.writeList(v)
+	case *object.SortedSet:
+		e.writeSortedSet(v)
+	case *object.Hash:
+		e.writeHash(v)
+	case *object.Stream:
+		e.writeStream(v)
+	}
+}
+
+func (e *encoder) writeList(l *object.List) {
+	elements := l.Range(0, -1)
+	e.writeLen(uint64(len(elements)))
+	for _, elem := range elements {
+		e.writeString(elem)
+	}
+}
+
+// writeSortedSet writes the members in increasing order of score, each followed by its score as a binary double.
+func (e *encoder) writeSortedSet(z *object.SortedSet) {
+	members := z.Range(0, -1)
+	e.writeLen(uint64(len(members)))
+	for _, m := range members {
+		e.writeString(m.Member)
+		e.write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(m.Score)))
+	}
+}
+
+// writeHash writes a hash with the plain encoding of Redis, or with its metadata encoding when fields expire.
+// the metadata encoding saves the earliest field expiration, and the expiration of each field relative to it plus one, 0 meaning none.
+func (e *encoder) writeHash(h *object.Hash) {
+	minExpire := hashMinExpire(h)
+	if minExpire.IsZero() {
+		e.writeLen(uint64(h.Len()))
+		h.Range(func(field, value string) bool {
+			e.writeString(field)
+			e.writeString(value)
+			return true
+		})
+		return
+	}
+
+	e.writeMillis(minExpire)
+	e.writeLen(uint64(h.Len()))
+	h.Range(func(field, value string) bool {
+		var ttl uint64
+		if at, _ := h.ExpireAt(field); !at.IsZero() {
+			ttl = uint64(at.UnixMilli()-minExpire.UnixMilli()) + 1
+		}
+		e.writeLen(ttl)
+		e.writeString(field)
+		e.writeString(value)
+		return true
+	})
+}
+
+// hashMinExpire returns the earliest expiration of the fields of h, zero when none expires.
+func hashMinExpire(h *object.Hash) time.Time {
+	var minExpire time.Time
+	h.Range(func(field, _ string) bool {
+		if at, _ := h.ExpireAt(field); !at.IsZero() && (minExpire.IsZero() || at.Before(minExpire)) {
+			minExpire = at
+		}
+		return true
+	})
+	return minExpire
+}
+
+func (e *encoder) writeStreamID(id object.StreamID) {
+	e.writeLen(id.Ms)
+	e.writeLen(id.Seq)
+}
+
+// writeStream writes the entries of a stream, its counters and its consumer groups.
+func (e *encoder) writeStream(s *object.Stream) {
+	entries := s.Range(object.MinStreamID, object.MaxStreamID, 0, false)
+	e.writeLen(uint64(len(entries)))
+	for _, entry := range entries {
+		e.writeStreamID(entry.ID)
+		e.writeLen(uint64(len(entry.Fields)))
+		for _, field := range entry.Fields {
+			e.writeString(field)
+		}
+	}
+	e.writeStreamID(s.LastID())
+	e.writeStreamID(s.MaxDeletedID())
+	e.writeLen(s.EntriesAdded())
+
+	groups := s.Groups()
+	e.writeLen(uint64(len(groups)))
+	for _, g := range groups {
+		e.writeString(g.Name)
+		e.writeStreamID(g.LastID)
+
+		consumers := g.Consumers()
+		e.writeLen(uint64(len(consumers)))
+		for _, c := range consumers {
+			e.writeString(c.Name)
+			e.writeMillis(c.SeenTime)
+			e.writeMillis(c.ActiveTime)
+		}
+
+		// no idle time is required, entries delivered in the future of the local clock included
+		pending := g.PendingRange(object.MinStreamID, object.MaxStreamID, 0, nil, math.MinInt64, e.now)
+		e.writeLen(uint64(len(pending)))
+		for _, pe := range pending {
+			e.writeStreamID(pe.ID)
+			e.writeString(pe.Consumer.Name)
+			e.writeMillis(pe.DeliveryTime)
+			e.writeLen(uint64(pe.DeliveryCount))
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package rdb saves and loads the keyspace in the RDB format of Redis, as transferred to replicas on full synchronization.
+//
+// strings, lists, sorted sets and hashes use the plain encodings of Redis,
+// streams use a simpler layout of their own, so that files holding streams can only be loaded by gvalkey.
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"strconv"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/PlayerNeo42/gvalkey/internal/version"
+	"github.com/PlayerNeo42/gvalkey/store"
+)
+
+var (
+	// ErrBadPayload is returned by Restore for payloads that are truncated, of a later version or with a wrong checksum.
+	ErrBadPayload = errors.New("payload version or checksum are wrong")
+	// ErrBadFormat is returned by Restore for payloads whose value cannot be decoded.
+	ErrBadFormat = errors.New("bad data format")
+)
+
+// version of the format, the one of Redis 7.4 which introduced hash field expiration
+const formatVersion = 12
+
+const magic = "REDIS"
+
+// opcodes introducing the records that are not keys
+const (
+	opAux          = 0xFA
+	opResizeDB     = 0xFB
+	opExpireTimeMs = 0xFC
+	opExpireTime   = 0xFD
+	opSelectDB     = 0xFE
+	opEOF          = 0xFF
+	opIdle         = 0xF8
+	opFreq         = 0xF9
+)
+
+// value types
+const (
+	typeString       = 0
+	typeList         = 1
+	typeHash         = 4
+	typeZSet2        = 5
+	typeHashMetadata = 22
+	// not a Redis type, streams are saved with a layout of their own
+	typeStream = 0xC8
+)
+
+// first bits of a length telling how it is encoded
+const (
+	len6Bit  = 0
+	len14Bit = 1
+	len32Bit = 0x80
+	len64Bit = 0x81
+	// strings saved as integers or compressed, rather than as a length followed by bytes
+	lenEncoded = 3
+)
+
+// special string encodings, following lenEncoded
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+// crcTable computes the CRC-64/Jones checksum of Redis, the reflected form of the polynomial being given to MakeTable.
+var crcTable = crc64.MakeTable(0x95ac9329ac4bc9b5)
+
+// updateCRC extends a checksum, hash/crc64 complements its values unlike Redis which neither complements the input nor the output.
+func updateCRC(crc uint64, p []byte) uint64 {
+	return ^crc64.Update(^crc, crcTable, p)
+}
+
+// Save writes the keyspace of dbs, indexed by database number, to w, dated by clk.
+func Save(w io.Writer, dbs []store.Store, clk clock.Clock) error {
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw, now: clk.Now()}
+
+	e.write(fmt.Appendf(nil, "%s%04d", magic, formatVersion))
+	e.writeAux("redis-ver", version.RedisVersion)
+	e.writeAux("redis-bits", strconv.Itoa(strconv.IntSize))
+	e.writeAux("ctime", strconv.FormatInt(e.now.Unix(), 10))
+	e.writeAux("gvalkey-ver", version.Version)
+
+	for i, db := range dbs {
+		e.writeDB(i, db)
+	}
+
+	e.writeByte(opEOF)
+	e.writeChecksum()
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// Load reads a file written by Save, calling fn on every key.
+func Load(r io.Reader, fn func(db int, key string, entry store.Entry) error) error {
+	d := &decoder{r: bufio.NewReader(r)}
+	return d.load(fn)
+}
+
+// Dump serializes value like the DUMP command of Redis: its type and encoding, followed by the version of the format and a checksum.
+// clk is the clock the idle times of the pending entries of streams are measured with.
+func Dump(value any, clk clock.Clock) ([]byte, error) {
+	var buf bytes.Buffer
+	e := &encoder{w: &buf, now: clk.Now()}
+	e.writeType(value)
+	e.writeValue(value)
+	e.write(binary.LittleEndian.AppendUint16(nil, formatVersion))
+	e.writeChecksum()
+	if e.err != nil {
+		return nil, e.err
+	}
+	return buf.Bytes(), nil
+}
+
+// Restore deserializes a value serialized by Dump.
+func Restore(payload []byte) (any, error) {
+	// type, version and checksum
+	if len(payload) < 11 {
+		return nil, ErrBadPayload
+	}
+	body, footer := payload[:len(payload)-10], payload[len(payload)-10:]
+	if binary.LittleEndian.Uint16(footer) > formatVersion {
+		return nil, ErrBadPayload
+	}
+	if crc := binary.LittleEndian.Uint64(footer[2:]); crc != 0 && crc != updateCRC(0, payload[:len(payload)-8]) {
+		return nil, ErrBadPayload
+	}
+
+	r := bytes.NewReader(body[1:])
+	d := &decoder{r: bufio.NewReader(r)}
+	value := d.readValue(body[0])
+	// the value must span the whole body
+	if d.err != nil || d.r.Buffered()+r.Len() > 0 {
+		return nil, ErrBadFormat
+	}
+	return value, nil
+}
@@ -0,0 +1,192 @@
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/clock"
+	"github.com/PlayerNeo42/gvalkey/resp"
+	"github.com/PlayerNeo42/gvalkey/store"
+	"github.com/PlayerNeo42/gvalkey/store/naive"
+	"github.com/PlayerNeo42/gvalkey/store/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	// check value of CRC-64/Jones as used by Redis
+	require.Equal(t, uint64(0xe9c6d914c4b8d9ca), updateCRC(0, []byte("123456789")))
+	require.Equal(t, updateCRC(0, []byte("123456789")), updateCRC(updateCRC(0, []byte("1234")), []byte("56789")))
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	expireAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+
+	db0 := naive.NewNaiveStore()
+	defer db0.Close()
+	db2 := naive.NewNaiveStore()
+	defer db2.Close()
+	empty := naive.NewNaiveStore()
+	defer empty.Close()
+
+	db0.Set(resp.SetArgs{Key: resp.BulkString("str"), Value: resp.BulkString("value")})
+	db0.Set(resp.SetArgs{Key: resp.BulkString("long"), Value: resp.BulkString(bytes.Repeat([]byte("x"), 20000)), ExpireAt: expireAt})
+
+	l := object.NewList()
+	l.PushRight("a", "b", "c")
+	db0.Set(resp.SetArgs{Key: resp.BulkString("list"), Value: l})
+
+	z := object.NewSortedSet()
+	z.Add("one", 1)
+	z.Add("half", 0.5)
+	db2.Set(resp.SetArgs{Key: resp.BulkString("zset"), Value: z})
+
+	h := object.NewHash()
+	h.Set("f1", "v1")
+	h.Set("f2", "v2")
+	db2.Set(resp.SetArgs{Key: resp.BulkString("hash"), Value: h})
+
+	hx := object.NewHash()
+	hx.Set("f1", "v1")
+	hx.Set("f2", "v2")
+	hx.Set("f3", "v3")
+	hx.SetExpireAt("f1", expireAt)
+	hx.SetExpireAt("f3", expireAt.Add(time.Minute))
+	db2.Set(resp.SetArgs{Key: resp.BulkString("hashx"), Value: hx})
+
+	s := object.NewStream()
+	require.NoError(t, s.Add(object.StreamID{Ms: 1, Seq: 0}, []string{"f", "v"}))
+	require.NoError(t, s.Add(object.StreamID{Ms: 2, Seq: 0}, []string{"f", "w"}))
+	require.True(t, s.CreateGroup("group", object.MinStreamID))
+	g, _ := s.Group("group")
+	consumer, _ := g.CreateConsumer("alice", time.UnixMilli(1000))
+	g.ReadNew(s, consumer, 1, false, time.UnixMilli(2000))
+	db2.Set(resp.SetArgs{Key: resp.BulkString("stream"), Value: s})
+
+	var buf bytes.Buffer
+	require.NoError(t, Save(&buf, []store.Store{db0, empty, db2}, clock.Real))
+	require.Equal(t, "REDIS0012", buf.String()[:9])
+
+	loaded := map[int]map[string]store.Entry{}
+	err := Load(bytes.NewReader(buf.Bytes()), func(db int, key string, entry store.Entry) error {
+		if loaded[db] == nil {
+			loaded[db] = map[string]store.Entry{}
+		}
+		loaded[db][key] = entry
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	require.Len(t, loaded[0], 3)
+	require.Len(t, loaded[2], 4)
+
+	require.Equal(t, resp.BulkString("value"), loaded[0]["str"].Value)
+	require.True(t, loaded[0]["str"].ExpireAt.IsZero())
+	require.Len(t, loaded[0]["long"].Value, 20000)
+	require.True(t, expireAt.Equal(loaded[0]["long"].ExpireAt))
+	require.Equal(t, []string{"a", "b", "c"}, loaded[0]["list"].Value.(*object.List).Range(0, -1))
+
+	require.Equal(t, z.Range(0, -1), loaded[2]["zset"].Value.(*object.SortedSet).Range(0, -1))
+
+	lh := loaded[2]["hash"].Value.(*object.Hash)
+	require.Equal(t, 2, lh.Len())
+	v, _ := lh.Get("f2")
+	require.Equal(t, "v2", v)
+
+	lhx := loaded[2]["hashx"].Value.(*object.Hash)
+	require.Equal(t, 3, lhx.Len())
+	at, _ := lhx.ExpireAt("f1")
+	require.True(t, expireAt.Equal(at))
+	at, _ = lhx.ExpireAt("f2")
+	require.True(t, at.IsZero())
+	at, _ = lhx.ExpireAt("f3")
+	require.True(t, expireAt.Add(time.Minute).Equal(at))
+
+	ls := loaded[2]["stream"].Value.(*object.Stream)
+	require.Equal(t, s.Range(object.MinStreamID, object.MaxStreamID, 0, false), ls.Range(object.MinStreamID, object.MaxStreamID, 0, false))
+	require.Equal(t, s.LastID(), ls.LastID())
+	require.Equal(t, s.EntriesAdded(), ls.EntriesAdded())
+	lg, ok := ls.Group("group")
+	require.True(t, ok)
+	require.Equal(t, object.StreamID{Ms: 1, Seq: 0}, lg.LastID)
+	pending := lg.PendingRange(object.MinStreamID, object.MaxStreamID, 0, nil, 0, time.UnixMilli(3000))
+	require.Len(t, pending, 1)
+	require.Equal(t, "alice", pending[0].Consumer.Name)
+	require.Equal(t, int64(1), pending[0].DeliveryCount)
+	require.True(t, time.UnixMilli(2000).Equal(pending[0].DeliveryTime))
+}
+
+func TestLoadErrors(t *testing.T) {
+	noop := func(int, string, store.Entry) error { return nil }
+
+	require.ErrorIs(t, Load(bytes.NewReader([]byte("RESPX0012")), noop), errBadMagic)
+	require.Error(t, Load(bytes.NewReader([]byte("REDIS0099")), noop))
+
+	var buf bytes.Buffer
+	db := naive.NewNaiveStore()
+	defer db.Close()
+	db.Set(resp.SetArgs{Key: resp.BulkString("k"), Value: resp.BulkString("v")})
+	require.NoError(t, Save(&buf, []store.Store{db}, clock.Real))
+
+	corrupted := bytes.Clone(buf.Bytes())
+	corrupted[len(corrupted)-1] ^= 0xFF
+	require.ErrorIs(t, Load(bytes.NewReader(corrupted), noop), errBadChecksum)
+
+	truncated := buf.Bytes()[:buf.Len()-12]
+	require.Error(t, Load(bytes.NewReader(truncated), noop))
+}
+
+func TestLZF(t *testing.T) {
+	// "aaaaaaaaaa" compressed: literal 'a', then a back reference of 9 bytes at distance 1
+	s, err := decompressLZF([]byte{0x00, 'a', 0xE0, 0x00, 0x00}, 10)
+	require.NoError(t, err)
+	require.Equal(t, "aaaaaaaaaa", s)
+
+	_, err = decompressLZF([]byte{0x05, 'a'}, 6)
+	require.ErrorIs(t, err, errBadLZF)
+}
+
+func TestStringEncodings(t *testing.T) {
+	d := &decoder{r: bufio.NewReader(bytes.NewReader([]byte{0xC0, 0xFE, 0xC1, 0x39, 0x30, 0xC2, 0x00, 0x00, 0x00, 0x80}))}
+	require.Equal(t, "-2", d.readString())
+	require.Equal(t, "12345", d.readString())
+	require.Equal(t, "-2147483648", d.readString())
+	require.NoError(t, d.err)
+}
+
+func TestDumpAndRestore(t *testing.T) {
+	// payload of DUMP for the integer 10 in the documentation of Redis
+	value, err := Restore([]byte("\x00\xc0\n\t\x00\xbem\x06\x89Z(\x00\n"))
+	require.NoError(t, err)
+	require.Equal(t, resp.BulkString("10"), value)
+
+	h := object.NewHash()
+	h.Set("a", "1")
+	h.Set("b", "2")
+	expireAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	h.SetExpireAt("b", expireAt)
+
+	payload, err := Dump(h, clock.Real)
+	require.NoError(t, err)
+	value, err = Restore(payload)
+	require.NoError(t, err)
+	restored, ok := value.(*object.Hash)
+	require.True(t, ok)
+	v, _ := restored.Get("a")
+	require.Equal(t, "1", v)
+	at, _ := restored.ExpireAt("b")
+	require.True(t, expireAt.Equal(at))
+
+	corrupted := bytes.Clone(payload)
+	corrupted[1] ^= 0xFF
+	_, err = Restore(corrupted)
+	require.ErrorIs(t, err, ErrBadPayload)
+
+	// trailing bytes, without a checksum
+	_, err = Restore([]byte("\x00\x03barX\x0c\x00\x00\x00\x00\x00\x00\x00\x00\x00"))
+	require.ErrorIs(t, err, ErrBadFormat)
+
+	_, err = Dump(42, clock.Real)
+	require.Error(t, err)
+}
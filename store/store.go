@@ -1,10 +1,73 @@
 // Package store provides multiple thread-safe in-memory key-value store implementations.
 package store
 
-import "github.com/PlayerNeo42/gvalkey/resp"
+import (
+	"time"
+
+	"github.com/PlayerNeo42/gvalkey/resp"
+)
 
 type Store interface {
 	Get(key string) (any, bool)
 	Set(args resp.SetArgs) (any, bool)
 	Del(key string) bool
+
+	// Compute atomically reads the entry stored at key and applies the change decided by fn.
+	// fn must not call back into the store, it may compute a key of another store.
+	Compute(key string, fn ComputeFunc)
+
+	// Flush removes every key.
+	Flush()
+
+	// RandomKey returns a random live key, restricted to keys with an expiration set when volatile is true.
+	RandomKey(volatile bool) (string, bool)
+
+	// Stats returns a point-in-time view of the keyspace.
+	Stats() Stats
+
+	// ExpireStats returns the counters of the background expiration.
+	ExpireStats() ExpireStats
+
+	// Range calls fn on every live key until it returns false.
+	// writers of the key are held back while fn runs, so that it can read mutable values, and fn must not call back into the store.
+	Range(fn func(key string, entry Entry) bool)
+}
+
+// Entry is a value stored under a key, along with its expiration.
+type Entry struct {
+	Value any
+	// expiration timestamp, zero means never expire
+	ExpireAt time.Time
+}
+
+// FieldExpirer is implemented by values whose fields expire individually, such as hashes with field TTLs.
+// the background expiration of stores reclaims their expired fields and removes the keys they leave empty.
+type FieldExpirer interface {
+	// ExpireFields removes the fields whose expiration is before now.
+	// it returns the number of removed fields and whether the value is left empty.
+	ExpireFields(now time.Time) (expired int, empty bool)
+}
+
+// Op is the change a ComputeFunc applies to the key.
+type Op int
+
+const (
+	// OpKeep leaves the key untouched.
+	OpKeep Op = iota
+	// OpSet stores the returned entry.
+	OpSet
+	// OpDelete removes the key.
+	OpDelete
+)
+
+// ComputeFunc receives the current entry of a key, exists is false for missing and expired keys.
+// it returns the new entry, which is only used with OpSet, and the change to apply.
+type ComputeFunc func(entry Entry, exists bool) (Entry, Op)
+
+// Stats describes the size of a store's keyspace.
+type Stats struct {
+	// number of keys, including expired keys that have not been reclaimed yet
+	Keys int
+	// number of keys with an expiration set
+	Expires int
 }
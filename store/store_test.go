@@ -1,21 +1,24 @@
 package store_test
 
 import (
+	"strconv"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/PlayerNeo42/gvalkey/clock"
 	"github.com/PlayerNeo42/gvalkey/resp"
 	"github.com/PlayerNeo42/gvalkey/store"
 	"github.com/PlayerNeo42/gvalkey/store/eventloop"
 	"github.com/PlayerNeo42/gvalkey/store/naive"
+	"github.com/PlayerNeo42/gvalkey/store/object"
 	"github.com/stretchr/testify/suite"
 )
 
 // TestNaiveStore tests the naive store implementation
 func TestNaiveStore(t *testing.T) {
-	naiveStoreFactory := func() store.Store {
-		return naive.NewNaiveStore()
+	naiveStoreFactory := func(opts ...store.Option) store.Store {
+		return naive.NewNaiveStore(opts...)
 	}
 
 	var naiveStore *naive.NaiveStore
@@ -33,8 +36,8 @@ func TestNaiveStore(t *testing.T) {
 
 // TestEventloopStore tests the eventloop store implementation
 func TestEventloopStore(t *testing.T) {
-	eventloopStoreFactory := func() store.Store {
-		return eventloop.NewEventloopStore()
+	eventloopStoreFactory := func(opts ...store.Option) store.Store {
+		return eventloop.NewEventloopStore(opts...)
 	}
 
 	cleanup := func() {}
@@ -57,14 +60,35 @@ func (m MockStringer) String() string {
 // StoreTestSuite defines a common test suite that can test any type that implements the Store interface
 type StoreTestSuite struct {
 	suite.Suite
-	storeFactory func() store.Store
+	storeFactory func(opts ...store.Option) store.Store
 	cleanup      func()
 	store        store.Store
+	// the clock of the store, which only moves when a test advances it
+	clock   *clock.Fake
+	expired chan string
+	// keys reported by OnExpireFields, suffixed with ":deleted" when they were removed
+	expiredFields chan string
 }
 
 // SetupTest initializes the store before each test
 func (s *StoreTestSuite) SetupTest() {
-	s.store = s.storeFactory()
+	s.expired = make(chan string, 16)
+	s.expiredFields = make(chan string, 16)
+	s.clock = clock.NewFake(time.Unix(1700000000, 0))
+	s.store = s.storeFactory(store.WithClock(s.clock), store.WithOnExpire(func(key string) {
+		select {
+		case s.expired <- key:
+		default:
+		}
+	}), store.WithOnExpireFields(func(key string, deleted bool) {
+		if deleted {
+			key += ":deleted"
+		}
+		select {
+		case s.expiredFields <- key:
+		default:
+		}
+	}))
 
 	// For EventloopStore, wait for event loop to start
 	s.Require().Eventually(func() bool {
@@ -124,7 +148,7 @@ func (s *StoreTestSuite) TestExpiration() {
 	setArgs := resp.SetArgs{
 		Key:      MockStringer{data: "expirekey"},
 		Value:    "expirevalue",
-		ExpireAt: time.Now().Add(1 * time.Second),
+		ExpireAt: s.clock.Now().Add(1 * time.Second),
 	}
 	_, ok := s.store.Set(setArgs)
 	s.Require().True(ok, "Setting expiring key should succeed")
@@ -134,11 +158,14 @@ func (s *StoreTestSuite) TestExpiration() {
 	s.Require().True(exists, "Key should exist immediately after setting")
 	s.Require().Equal("expirevalue", value, "Value should match the set value")
 
-	// Wait for expiration
-	s.Require().Eventually(func() bool {
-		_, exists := s.store.Get("expirekey")
-		return !exists
-	}, 3*time.Second, 100*time.Millisecond, "Key should expire after specified time")
+	// the key lives up to its expiration included
+	s.clock.Advance(time.Second)
+	_, exists = s.store.Get("expirekey")
+	s.Require().True(exists, "Key should exist until its expiration")
+
+	s.clock.Advance(time.Millisecond)
+	_, exists = s.store.Get("expirekey")
+	s.Require().False(exists, "Key should expire after specified time")
 }
 
 // TestSetNX tests the NX flag (only set if key does not exist)
@@ -293,3 +320,219 @@ func (s *StoreTestSuite) TestConcurrency() {
 		s.Require().False(exists, "Key should be deleted")
 	}
 }
+
+// TestStats tests the keyspace counters
+func (s *StoreTestSuite) TestStats() {
+	s.Require().Equal(store.Stats{}, s.store.Stats(), "New store should be empty")
+
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "plain"}, Value: "value"})
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "volatile"}, Value: "value", ExpireAt: s.clock.Now().Add(time.Hour)})
+	s.Require().Equal(store.Stats{Keys: 2, Expires: 1}, s.store.Stats())
+
+	// overwriting a volatile key without a TTL makes it persistent
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "volatile"}, Value: "value"})
+	s.Require().Equal(store.Stats{Keys: 2, Expires: 0}, s.store.Stats())
+
+	s.store.Del("plain")
+	s.Require().Equal(store.Stats{Keys: 1, Expires: 0}, s.store.Stats())
+}
+
+// TestOnExpire tests that expired keys are reported
+func (s *StoreTestSuite) TestOnExpire() {
+	setArgs := resp.SetArgs{
+		Key:      MockStringer{data: "reported"},
+		Value:    "value",
+		ExpireAt: s.clock.Now().Add(100 * time.Millisecond),
+	}
+	_, ok := s.store.Set(setArgs)
+	s.Require().True(ok)
+	s.clock.Advance(time.Second)
+
+	// the key is reclaimed by the background cleanup without being accessed
+	select {
+	case key := <-s.expired:
+		s.Require().Equal("reported", key)
+	case <-time.NewTimer(3 * time.Second).C:
+		s.Fail("expired key should be reported")
+	}
+	s.Require().Equal(store.Stats{}, s.store.Stats(), "Expired key should be removed")
+}
+
+// TestActiveExpiration tests that the background expiration only reclaims the keys whose latest expiration elapsed
+func (s *StoreTestSuite) TestActiveExpiration() {
+	expireAt := s.clock.Now().Add(50 * time.Millisecond)
+	for i := range 100 {
+		s.store.Set(resp.SetArgs{Key: MockStringer{data: strconv.Itoa(i)}, Value: "value", ExpireAt: expireAt})
+	}
+	// a key made persistent, and one whose expiration was extended
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "0"}, Value: "value"})
+	s.store.Compute("1", func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		entry.ExpireAt = s.clock.Now().Add(time.Hour)
+		return entry, store.OpSet
+	})
+	s.clock.Advance(time.Second)
+
+	s.Require().Eventually(func() bool {
+		return s.store.Stats().Keys == 2
+	}, 3*time.Second, 10*time.Millisecond, "expired keys should be reclaimed without being accessed")
+	s.Require().Equal(store.Stats{Keys: 2, Expires: 1}, s.store.Stats())
+	s.Require().Equal(int64(98), s.store.ExpireStats().Expired)
+	s.Require().Positive(s.store.ExpireStats().CycleTime)
+}
+
+// TestExpireFields tests that the background expiration reclaims the expired fields of hashes
+func (s *StoreTestSuite) TestExpireFields() {
+	expireAt := s.clock.Now().Add(100 * time.Millisecond)
+
+	partial := object.NewHash()
+	partial.Set("kept", "1")
+	partial.Set("expiring", "2")
+	partial.SetExpireAt("expiring", expireAt)
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "partial"}, Value: partial})
+
+	emptied := object.NewHash()
+	emptied.Set("expiring", "1")
+	emptied.SetExpireAt("expiring", expireAt)
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "emptied"}, Value: emptied})
+	s.clock.Advance(time.Second)
+
+	var reported []string
+	for range 2 {
+		select {
+		case key := <-s.expiredFields:
+			reported = append(reported, key)
+		case <-time.NewTimer(3 * time.Second).C:
+			s.FailNow("expired fields should be reported")
+		}
+	}
+	s.Require().ElementsMatch([]string{"partial", "emptied:deleted"}, reported)
+
+	// the hash is only read through Compute, which serializes with the background expiration
+	s.store.Compute("partial", func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		s.Require().True(exists)
+		hash, ok := entry.Value.(*object.Hash)
+		s.Require().True(ok)
+		s.Require().Equal(1, hash.Len())
+		return entry, store.OpKeep
+	})
+	_, exists := s.store.Get("emptied")
+	s.Require().False(exists, "Emptied hash should be removed")
+	s.Require().Equal(store.Stats{Keys: 1}, s.store.Stats())
+}
+
+// TestRandomKey tests picking random keys
+func (s *StoreTestSuite) TestRandomKey() {
+	_, ok := s.store.RandomKey(false)
+	s.Require().False(ok, "Empty store has no random key")
+
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "plain"}, Value: "value"})
+	_, ok = s.store.RandomKey(true)
+	s.Require().False(ok, "Store without volatile keys has no random volatile key")
+
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "volatile"}, Value: "value", ExpireAt: s.clock.Now().Add(time.Hour)})
+	key, ok := s.store.RandomKey(true)
+	s.Require().True(ok)
+	s.Require().Equal("volatile", key)
+
+	key, ok = s.store.RandomKey(false)
+	s.Require().True(ok)
+	s.Require().Contains([]string{"plain", "volatile"}, key)
+}
+
+// TestCompute tests atomic read-modify-write operations
+func (s *StoreTestSuite) TestCompute() {
+	expireAt := s.clock.Now().Add(time.Hour)
+
+	// create a missing key
+	s.store.Compute("counter", func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		s.False(exists)
+		return store.Entry{Value: 1, ExpireAt: expireAt}, store.OpSet
+	})
+	s.Require().Equal(store.Stats{Keys: 1, Expires: 1}, s.store.Stats())
+
+	// read the entry without changing it
+	var seen store.Entry
+	s.store.Compute("counter", func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		s.True(exists)
+		seen = entry
+		return store.Entry{}, store.OpKeep
+	})
+	s.Require().Equal(1, seen.Value)
+	s.Require().True(seen.ExpireAt.Equal(expireAt), "Expiration should be visible")
+
+	// concurrent increments are not lost
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.store.Compute("counter", func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+				return store.Entry{Value: entry.Value.(int) + 1, ExpireAt: entry.ExpireAt}, store.OpSet
+			})
+		}()
+	}
+	wg.Wait()
+
+	value, exists := s.store.Get("counter")
+	s.Require().True(exists)
+	s.Require().Equal(51, value)
+
+	// delete the key
+	s.store.Compute("counter", func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		return store.Entry{}, store.OpDelete
+	})
+	_, exists = s.store.Get("counter")
+	s.Require().False(exists)
+	s.Require().Equal(store.Stats{}, s.store.Stats())
+}
+
+// TestComputeExpired tests that expired keys are reported as missing
+func (s *StoreTestSuite) TestComputeExpired() {
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "expired"}, Value: "value", ExpireAt: s.clock.Now().Add(-time.Second)})
+
+	s.store.Compute("expired", func(entry store.Entry, exists bool) (store.Entry, store.Op) {
+		s.False(exists, "Expired key should not exist")
+		s.Nil(entry.Value)
+		return store.Entry{}, store.OpKeep
+	})
+	s.Require().Equal(store.Stats{}, s.store.Stats(), "Expired key should be removed")
+}
+
+// TestFlush tests removing every key
+func (s *StoreTestSuite) TestFlush() {
+	for i := range 10 {
+		s.store.Set(resp.SetArgs{Key: MockStringer{data: "key" + strconv.Itoa(i)}, Value: i, ExpireAt: s.clock.Now().Add(time.Hour)})
+	}
+	s.Require().Equal(store.Stats{Keys: 10, Expires: 10}, s.store.Stats())
+
+	s.store.Flush()
+
+	s.Require().Equal(store.Stats{}, s.store.Stats())
+	_, exists := s.store.Get("key0")
+	s.Require().False(exists)
+}
+
+// TestRange tests iterating over the live keys
+func (s *StoreTestSuite) TestRange() {
+	expireAt := s.clock.Now().Add(time.Hour)
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "a"}, Value: 1})
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "b"}, Value: 2, ExpireAt: expireAt})
+	s.store.Set(resp.SetArgs{Key: MockStringer{data: "expired"}, Value: 3, ExpireAt: s.clock.Now().Add(-time.Second)})
+
+	entries := make(map[string]store.Entry)
+	s.store.Range(func(key string, entry store.Entry) bool {
+		entries[key] = entry
+		return true
+	})
+	s.Require().Equal(map[string]store.Entry{
+		"a": {Value: 1},
+		"b": {Value: 2, ExpireAt: expireAt},
+	}, entries)
+
+	visited := 0
+	s.store.Range(func(string, store.Entry) bool {
+		visited++
+		return false
+	})
+	s.Require().Equal(1, visited, "Range should stop once fn returns false")
+}